@@ -0,0 +1,77 @@
+// Command audit captures pool state snapshots and recomputes quotes from them
+// offline, to verify execution quality after the fact:
+//
+//	go run ./cmd/audit -capture -rpc $RPC -protocol raydium_cpmm -pool <id> \
+//		-input <mint> -amount 1000000000 -out snapshot.json
+//	go run ./cmd/audit -snapshot snapshot.json -realized 149000000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/audit"
+)
+
+func main() {
+	capture := flag.Bool("capture", false, "capture a snapshot instead of verifying one")
+	endpoint := flag.String("rpc", "", "RPC endpoint to capture from")
+	protocolName := flag.String("protocol", "", "protocol of the pool, e.g. raydium_cpmm")
+	poolID := flag.String("pool", "", "pool to capture")
+	inputMint := flag.String("input", "", "input mint of the swap")
+	amount := flag.String("amount", "", "swap size in raw input units")
+	out := flag.String("out", "snapshot.json", "where to write a captured snapshot")
+	snapshotPath := flag.String("snapshot", "", "snapshot to verify")
+	realized := flag.String("realized", "", "output the trade received, in raw units")
+	flag.Parse()
+
+	ctx := context.Background()
+	if *capture {
+		amountIn, ok := math.NewIntFromString(*amount)
+		if !ok || *endpoint == "" || *poolID == "" || *inputMint == "" {
+			log.Fatal("-capture needs -rpc, -protocol, -pool, -input and a numeric -amount")
+		}
+		snapshot, err := audit.Capture(ctx, *endpoint, pkg.ProtocolName(*protocolName), *poolID, *inputMint, amountIn)
+		if err != nil {
+			log.Fatalf("capture failed: %v", err)
+		}
+		if err := snapshot.Save(*out); err != nil {
+			log.Fatalf("failed to save snapshot: %v", err)
+		}
+		fmt.Printf("captured quote %s for %s in %s\n", snapshot.AmountOut, snapshot.AmountIn, *out)
+		return
+	}
+
+	if *snapshotPath == "" {
+		log.Fatal("either -capture or -snapshot is required")
+	}
+	snapshot, err := audit.LoadSnapshot(*snapshotPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var realizedOut math.Int
+	if *realized != "" {
+		var ok bool
+		if realizedOut, ok = math.NewIntFromString(*realized); !ok {
+			log.Fatalf("invalid -realized %q", *realized)
+		}
+	}
+	report, err := audit.Verify(ctx, snapshot, realizedOut)
+	if err != nil {
+		log.Fatalf("verification failed: %v", err)
+	}
+	fmt.Printf("pool %s (%s), %s %s in, captured %s\n",
+		snapshot.PoolID, snapshot.Protocol, snapshot.AmountIn, snapshot.InputMint, snapshot.CapturedAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("recomputed quote %s, captured quote %s, match %v\n", report.Recomputed, report.Captured, report.Matches)
+	if !report.Realized.IsNil() {
+		fmt.Printf("realized %s, shortfall %.2f bps\n", report.Realized, report.ShortfallBps)
+	}
+	if !report.Matches {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,11 @@
+// Package examples holds runnable programs for the SDK's main flows. Each
+// subdirectory is a main package built and vetted with the rest of the
+// module, so CI fails when an example drifts from the API:
+//
+//	go run ./examples/quote -rpc $RPC -out $MINT                     # quote only
+//	go run ./examples/jito_swap -rpc $RPC -jito $JITO -key $KEY      # swap sent as a Jito bundle
+//	go run ./examples/multihop -rpc $RPC -key $KEY -via $USDC        # two hops through a middle mint
+//	go run ./examples/snipe -rpc $RPC -ws $WS -key $KEY -pool $POOL  # buy on a watched pool's first large swap
+//
+// Examples that take a key only simulate unless -send is given
+package examples
@@ -0,0 +1,85 @@
+// Command jito_swap swaps through the best pool of a pair and sends the
+// transaction as a Jito bundle with a tip, printing the execution report.
+// Without -send it only simulates the route
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/executor"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+
+func main() {
+	endpoint := flag.String("rpc", "https://api.mainnet-beta.solana.com", "RPC endpoint")
+	jitoEndpoint := flag.String("jito", "https://mainnet.block-engine.jito.wtf", "Jito block engine endpoint")
+	key := flag.String("key", os.Getenv("SOLROUTE_KEY"), "base58 private key of the swapping wallet")
+	inputMint := flag.String("in", sol.WSOL.String(), "input mint")
+	outputMint := flag.String("out", usdc, "output mint")
+	amount := flag.Int64("amount", 10_000_000, "input amount in base units")
+	slippageBps := flag.Int("slippage", executor.DefaultSlippageBps, "slippage tolerance in bps")
+	tip := flag.Uint64("tip", 100_000, "Jito tip in lamports")
+	send := flag.Bool("send", false, "send the bundle instead of simulating")
+	flag.Parse()
+
+	signer, err := solana.PrivateKeyFromBase58(*key)
+	if err != nil {
+		log.Fatalf("invalid key: %v", err)
+	}
+	ctx := context.Background()
+	solClient, err := sol.NewClient(ctx, *endpoint, *jitoEndpoint, 20)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+	r := router.NewSimpleRouter(protocol.All(solClient)...)
+	if _, err := r.QueryAllPools(ctx, *inputMint, *outputMint); err != nil {
+		log.Fatalf("failed to query pools: %v", err)
+	}
+
+	amountIn := math.NewInt(*amount)
+	pool, amountOut, err := r.GetBestPool(ctx, solClient, *inputMint, amountIn)
+	if err != nil {
+		log.Fatalf("failed to get best pool: %v", err)
+	}
+	route, err := router.NewSingleHopRoute(pool, *inputMint, amountIn, amountOut)
+	if err != nil {
+		log.Fatalf("failed to build route: %v", err)
+	}
+
+	if !*send {
+		if err := r.ApplyMinOut(ctx, solClient, route, *slippageBps, router.MinOutPerHop); err != nil {
+			log.Fatalf("failed to apply slippage: %v", err)
+		}
+		result, err := r.SimulateRouteOutput(ctx, solClient, route, signer.PublicKey())
+		if err != nil {
+			log.Fatalf("simulation failed: %v", err)
+		}
+		log.Printf("simulated %s out of %s quoted, %d compute units", result.AmountOut, route.AmountOut, result.UnitsConsumed)
+		return
+	}
+
+	exec := executor.New(solClient, r)
+	exec.SlippageBps = *slippageBps
+	exec.Fees.JitoTip = *tip
+	report, err := exec.ExecuteReport(ctx, route, sol.PrivateKeySigners(signer))
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Printf("failed to encode report: %v", err)
+		}
+	}
+	if err != nil {
+		log.Fatalf("swap failed: %v", err)
+	}
+}
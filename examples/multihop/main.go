@@ -0,0 +1,105 @@
+// Command multihop swaps through a middle mint, taking the best pool of each
+// leg, and constrains only the final output. Without -send it only
+// simulates the route
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/executor"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	jup  = "JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN"
+)
+
+func main() {
+	endpoint := flag.String("rpc", "https://api.mainnet-beta.solana.com", "RPC endpoint")
+	key := flag.String("key", os.Getenv("SOLROUTE_KEY"), "base58 private key of the swapping wallet")
+	inputMint := flag.String("in", sol.WSOL.String(), "input mint")
+	viaMint := flag.String("via", usdc, "middle mint")
+	outputMint := flag.String("out", jup, "output mint")
+	amount := flag.Int64("amount", 10_000_000, "input amount in base units")
+	slippageBps := flag.Int("slippage", executor.DefaultSlippageBps, "slippage tolerance in bps")
+	send := flag.Bool("send", false, "send the swap instead of simulating")
+	flag.Parse()
+
+	signer, err := solana.PrivateKeyFromBase58(*key)
+	if err != nil {
+		log.Fatalf("invalid key: %v", err)
+	}
+	ctx := context.Background()
+	solClient, err := sol.NewClient(ctx, *endpoint, "", 20)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	// one router per leg, so the best pool of a leg is chosen among that
+	// leg's pair only
+	first := router.NewSimpleRouter(protocol.All(solClient)...)
+	second := router.NewSimpleRouter(protocol.All(solClient)...)
+	amountIn := math.NewInt(*amount)
+	firstPool, middle := bestPool(ctx, solClient, first, *inputMint, *viaMint, amountIn)
+	secondPool, amountOut := bestPool(ctx, solClient, second, *viaMint, *outputMint, middle)
+
+	route := &router.Route{
+		Hops: []router.Hop{
+			{Pool: firstPool, InputMint: *inputMint, OutputMint: *viaMint, AmountIn: amountIn, AmountOut: middle},
+			{Pool: secondPool, InputMint: *viaMint, OutputMint: *outputMint, AmountIn: middle, AmountOut: amountOut},
+		},
+		AmountIn:  amountIn,
+		AmountOut: amountOut,
+	}
+	if err := first.ApplyMinOut(ctx, solClient, route, *slippageBps, router.MinOutFinalOnly); err != nil {
+		log.Fatalf("failed to apply slippage: %v", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(route.Quote()); err != nil {
+		log.Fatalf("failed to encode quote: %v", err)
+	}
+
+	if !*send {
+		result, err := first.SimulateRouteOutput(ctx, solClient, route, signer.PublicKey())
+		if err != nil {
+			log.Fatalf("simulation failed: %v", err)
+		}
+		log.Printf("simulated %s out of %s quoted, %d compute units", result.AmountOut, route.AmountOut, result.UnitsConsumed)
+		return
+	}
+
+	exec := executor.New(solClient, first)
+	exec.SlippageBps = *slippageBps
+	exec.MinOutMode = router.MinOutFinalOnly
+	report, err := exec.ExecuteReport(ctx, route, sol.PrivateKeySigners(signer))
+	if report != nil {
+		log.Printf("order %s %s: %s received, %d bps short of the quote", report.OrderID, report.Status, report.RealizedAmountOut, report.ShortfallBps)
+	}
+	if err != nil {
+		log.Fatalf("swap failed: %v", err)
+	}
+}
+
+// bestPool discovers the pools of inputMint and outputMint on r and returns
+// the one paying the most for amountIn
+func bestPool(ctx context.Context, solClient *sol.Client, r *router.SimpleRouter, inputMint, outputMint string, amountIn math.Int) (pkg.Pool, math.Int) {
+	if _, err := r.QueryAllPools(ctx, inputMint, outputMint); err != nil {
+		log.Fatalf("failed to query %s -> %s pools: %v", inputMint, outputMint, err)
+	}
+	pool, amountOut, err := r.GetBestPool(ctx, solClient, inputMint, amountIn)
+	if err != nil {
+		log.Fatalf("failed to quote %s -> %s: %v", inputMint, outputMint, err)
+	}
+	return pool, amountOut
+}
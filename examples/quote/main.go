@@ -0,0 +1,62 @@
+// Command quote discovers the pools of a pair, quotes every one of them and
+// prints the best route as JSON. It uses the read-only router, so it needs
+// no key and cannot send anything
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const usdc = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+
+func main() {
+	endpoint := flag.String("rpc", "https://api.mainnet-beta.solana.com", "RPC endpoint")
+	inputMint := flag.String("in", sol.WSOL.String(), "input mint")
+	outputMint := flag.String("out", usdc, "output mint")
+	amount := flag.Int64("amount", 10_000_000, "input amount in base units")
+	slippageBps := flag.Int("slippage", 50, "slippage tolerance of the minimum output, in bps")
+	flag.Parse()
+
+	ctx := context.Background()
+	r, err := router.NewReadOnlyRouter(ctx, *endpoint, 20)
+	if err != nil {
+		log.Fatalf("failed to create router: %v", err)
+	}
+
+	report, err := r.QueryAllPools(ctx, *inputMint, *outputMint)
+	if err != nil {
+		log.Fatalf("failed to query pools: %v", err)
+	}
+	for _, failed := range report.Failed() {
+		log.Printf("%s contributed no pools: %v", failed.Protocol, failed.Err)
+	}
+	log.Printf("found %d pools, skipped %d accounts", report.TotalPools(), report.TotalSkipped())
+
+	amountIn := math.NewInt(*amount)
+	for _, quote := range r.QuoteAll(ctx, *inputMint, amountIn) {
+		hop := quote.Hops[0]
+		fmt.Printf("%-16s %-44s %s\n", hop.Protocol, hop.PoolID, quote.AmountOut)
+	}
+
+	best, err := r.BestQuote(ctx, *inputMint, amountIn, *slippageBps)
+	if err != nil {
+		log.Fatalf("failed to quote: %v", err)
+	}
+	for _, warning := range best.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(best); err != nil {
+		log.Fatalf("failed to encode quote: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Command snipe watches a pool and, on the first large buy another trader
+// sends to it, buys the same token through that pool. Without -send it only
+// simulates the buy
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/executor"
+	"github.com/solana-zh/solroute/pkg/flow"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/venue"
+)
+
+func main() {
+	endpoint := flag.String("rpc", "https://api.mainnet-beta.solana.com", "RPC endpoint")
+	wsEndpoint := flag.String("ws", "wss://api.mainnet-beta.solana.com", "websocket endpoint")
+	key := flag.String("key", os.Getenv("SOLROUTE_KEY"), "base58 private key of the buying wallet")
+	poolID := flag.String("pool", "", "pool to watch")
+	spendMint := flag.String("spend", sol.WSOL.String(), "mint the trigger buys with and we spend")
+	trigger := flag.Int64("trigger", 5_000_000_000, "input of another trader's buy that triggers ours, in base units")
+	amount := flag.Int64("amount", 10_000_000, "amount to spend in base units")
+	slippageBps := flag.Int("slippage", 500, "slippage tolerance in bps")
+	send := flag.Bool("send", false, "send the buy instead of simulating")
+	flag.Parse()
+
+	signer, err := solana.PrivateKeyFromBase58(*key)
+	if err != nil {
+		log.Fatalf("invalid key: %v", err)
+	}
+	poolKey, err := solana.PublicKeyFromBase58(*poolID)
+	if err != nil {
+		log.Fatalf("invalid pool: %v", err)
+	}
+	spend, err := solana.PublicKeyFromBase58(*spendMint)
+	if err != nil {
+		log.Fatalf("invalid spend mint: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	solClient, err := sol.NewClient(ctx, *endpoint, "", 20)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+
+	monitor := flow.NewMonitor(flow.NewLogsSource(solClient, *wsEndpoint), poolKey)
+	monitor.MinAmountIn = map[solana.PublicKey]math.Int{spend: math.NewInt(*trigger)}
+	events, unsubscribe := monitor.Subscribe(16)
+	defer unsubscribe()
+	go func() {
+		if err := monitor.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Fatalf("monitor stopped: %v", err)
+		}
+	}()
+
+	log.Printf("watching %s for buys of at least %d", poolKey, *trigger)
+	var event flow.Event
+	for event = range events {
+		if event.Large && event.InputMint.Equals(spend) {
+			break
+		}
+	}
+	log.Printf("triggered by %s buying with %s", event.Signature, event.AmountIn)
+
+	// launches often trade on venues still in x/venue, whose swaps the
+	// monitor decodes once the package is imported
+	proto, err := protocol.New(event.Protocol, solClient)
+	if err != nil {
+		if proto, err = venue.New(event.Protocol, solClient); err != nil {
+			log.Fatalf("unsupported venue: %v", err)
+		}
+	}
+	pool, err := proto.FetchPoolByID(ctx, poolKey.String())
+	if err != nil {
+		log.Fatalf("failed to load pool: %v", err)
+	}
+	amountIn := math.NewInt(*amount)
+	amountOut, err := pool.Quote(ctx, solClient, *spendMint, amountIn)
+	if err != nil {
+		log.Fatalf("failed to quote: %v", err)
+	}
+	route, err := router.NewSingleHopRoute(pool, *spendMint, amountIn, amountOut)
+	if err != nil {
+		log.Fatalf("failed to build route: %v", err)
+	}
+	r := router.NewSimpleRouter(proto)
+
+	if !*send {
+		if err := r.ApplyMinOut(ctx, solClient, route, *slippageBps, router.MinOutPerHop); err != nil {
+			log.Fatalf("failed to apply slippage: %v", err)
+		}
+		result, err := r.SimulateRouteOutput(ctx, solClient, route, signer.PublicKey())
+		if err != nil {
+			log.Fatalf("simulation failed: %v", err)
+		}
+		log.Printf("simulated %s out of %s quoted", result.AmountOut, route.AmountOut)
+		return
+	}
+
+	exec := executor.New(solClient, r)
+	exec.SlippageBps = *slippageBps
+	report, err := exec.ExecuteReport(ctx, route, sol.PrivateKeySigners(signer))
+	if report != nil {
+		log.Printf("order %s %s: %s", report.OrderID, report.Status, report.Signature)
+	}
+	if err != nil {
+		log.Fatalf("buy failed: %v", err)
+	}
+}
@@ -58,39 +58,39 @@ func main() {
 	}
 	log.Printf("😈Your token account: %v", outTokenAccount.String())
 
-	router := router.NewSimpleRouter(
-		protocol.NewPumpAmm(solClient),
-		protocol.NewRaydiumAmm(solClient),
-		protocol.NewRaydiumClmm(solClient),
-		protocol.NewRaydiumCpmm(solClient),
-		protocol.NewMeteoraDlmm(solClient),
-	)
+	solRouter := router.NewSimpleRouter(protocol.All(solClient)...)
 
 	// Query available pools
 	log.Printf("⌛️Querying available pools...")
-	err = router.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String())
+	report, err := solRouter.QueryAllPools(ctx, inTokenAddr.String(), outTokenAddr.String())
 	if err != nil {
 		log.Fatalf("Failed to query all pools: %v", err)
 	}
-	log.Printf("👌Found %d pools", len(router.Pools))
+	for _, failed := range report.Failed() {
+		log.Printf("⚠️%v contributed no pools: %v", failed.Protocol, failed.Err)
+	}
+	log.Printf("👌Found %d pools, skipped %d accounts", len(solRouter.Pools), report.TotalSkipped())
 
-	signers := []solana.PrivateKey{}
+	signers := []sol.Signer{}
 	instructions := make([]solana.Instruction, 0)
 
 	amountIn := math.NewInt(defaultAmountIn)
-	bestPool, amountOut, err := router.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
+	bestPool, amountOut, err := solRouter.GetBestPool(ctx, solClient, inTokenAddr.String(), amountIn)
 	if err != nil {
 		log.Fatalf("Failed to get best pool: %v", err)
 	}
 	log.Printf("Selected best pool: %v, amountOut: %v", bestPool.GetID(), amountOut)
 
-	minAmountOut := amountOut.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
+	minAmountOut := router.ApplySlippage(amountOut, slippageBps)
 	instructionsBuy, err := bestPool.BuildSwapInstructions(ctx, solClient,
 		privateKey.PublicKey(), inTokenAddr.String(), amountIn, minAmountOut, inTokenAccount, outTokenAccount)
 	if err != nil {
 		log.Fatalf("Failed to build swap instructions: %v", err)
 	}
-	signers = append(signers, privateKey)
+	if err := router.CheckMinOut(bestPool, inTokenAddr.String(), instructionsBuy, minAmountOut); err != nil {
+		log.Fatalf("Swap instructions do not match the requested min out: %v", err)
+	}
+	signers = append(signers, sol.PrivateKeySigner{Key: privateKey})
 	instructions = append(instructions, instructionsBuy...)
 
 	tx, err := solClient.SignTransaction(ctx, signers, instructions...)
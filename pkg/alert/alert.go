@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind classifies execution anomalies
+type Kind string
+
+const (
+	KindSendRejected    Kind = "send_rejected"
+	KindSlippageBreach  Kind = "slippage_breach"
+	KindPoolQuarantined Kind = "pool_quarantined"
+	KindLowBalance      Kind = "low_balance"
+	KindLargeFlow       Kind = "large_flow"
+	KindRollback        Kind = "fill_rolled_back"
+	KindHalted          Kind = "executor_halted"
+)
+
+// Event is one anomaly worth telling an operator about
+type Event struct {
+	Kind    Kind
+	Message string
+	Fields  map[string]string
+	Time    time.Time
+}
+
+// NewEvent creates an event stamped with the current time
+func NewEvent(kind Kind, message string, fields map[string]string) Event {
+	return Event{Kind: kind, Message: message, Fields: fields, Time: time.Now()}
+}
+
+// Text renders the event as a single human readable message
+func (e Event) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", e.Kind, e.Message)
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", key, e.Fields[key])
+	}
+	return b.String()
+}
+
+// Notifier delivers events to an operator channel
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi fans events out to every notifier
+type Multi []Notifier
+
+// Notify delivers event to all notifiers, joining their errors
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const defaultHTTPTimeout = 10 * time.Second
+
+// WebhookNotifier posts events as JSON to a generic webhook
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url}
+}
+
+// Notify posts event as JSON
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, n.Client, n.URL, map[string]any{
+		"kind":    event.Kind,
+		"message": event.Message,
+		"fields":  event.Fields,
+		"time":    event.Time.UTC().Format(time.RFC3339),
+	})
+}
+
+// SlackNotifier posts events to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier for a Slack incoming webhook URL
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{WebhookURL: webhookURL}
+}
+
+// Notify posts event as a Slack message
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, n.Client, n.WebhookURL, map[string]string{"text": event.Text()})
+}
+
+// TelegramNotifier sends events through a Telegram bot
+type TelegramNotifier struct {
+	BotToken string
+	ChatID   string
+	Client   *http.Client
+}
+
+// NewTelegramNotifier creates a notifier sending to chatID with the bot's token
+func NewTelegramNotifier(botToken, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{BotToken: botToken, ChatID: chatID}
+}
+
+// Notify sends event as a Telegram message
+func (n *TelegramNotifier) Notify(ctx context.Context, event Event) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.BotToken)
+	return postJSON(ctx, n.Client, url, map[string]string{
+		"chat_id": n.ChatID,
+		"text":    event.Text(),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package alt manages address lookup tables that shrink routes too large for
+// a legacy transaction: it picks or extends tables holding a route's stable
+// accounts and retires tables that are no longer used
+package alt
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ProgramID is the address lookup table program
+var ProgramID = solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
+
+const (
+	createLookupTable     = 0
+	extendLookupTable     = 2
+	deactivateLookupTable = 3
+	closeLookupTable      = 4
+
+	// MaxAddresses is how many addresses a lookup table can hold
+	MaxAddresses = 256
+	// MaxExtendAddresses is how many addresses one extend transaction adds
+	// while staying under the transaction size limit
+	MaxExtendAddresses = 20
+	// DeactivationCooldownSlots is how long a deactivated table must wait
+	// before it can be closed, the depth of the slot hashes sysvar
+	DeactivationCooldownSlots = 513
+)
+
+// FindLookupTableAddress derives the table authority creates at recentSlot
+func FindLookupTableAddress(authority solana.PublicKey, recentSlot uint64) (solana.PublicKey, uint8, error) {
+	slot := binary.LittleEndian.AppendUint64(nil, recentSlot)
+	address, bump, err := sol.FindProgramAddress([][]byte{authority[:], slot}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, 0, fmt.Errorf("failed to derive lookup table PDA: %w", err)
+	}
+	return address, bump, nil
+}
+
+// NewCreateLookupTableInstruction creates the table of authority for
+// recentSlot, which must still be in the slot hashes sysvar
+func NewCreateLookupTableInstruction(authority, payer solana.PublicKey, recentSlot uint64) (solana.Instruction, solana.PublicKey, error) {
+	table, bump, err := FindLookupTableAddress(authority, recentSlot)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	data := binary.LittleEndian.AppendUint32(nil, createLookupTable)
+	data = binary.LittleEndian.AppendUint64(data, recentSlot)
+	data = append(data, bump)
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(table).WRITE(),
+		solana.Meta(authority).SIGNER(),
+		solana.Meta(payer).SIGNER().WRITE(),
+		solana.Meta(solana.SystemProgramID),
+	}
+	return solana.NewInstruction(ProgramID, accounts, data), table, nil
+}
+
+// NewExtendLookupTableInstruction appends addresses to table
+func NewExtendLookupTableInstruction(table, authority, payer solana.PublicKey, addresses []solana.PublicKey) (solana.Instruction, error) {
+	if len(addresses) == 0 || len(addresses) > MaxExtendAddresses {
+		return nil, fmt.Errorf("extend takes 1-%d addresses, got %d", MaxExtendAddresses, len(addresses))
+	}
+	data := binary.LittleEndian.AppendUint32(nil, extendLookupTable)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(addresses)))
+	for _, address := range addresses {
+		data = append(data, address[:]...)
+	}
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(table).WRITE(),
+		solana.Meta(authority).SIGNER(),
+		solana.Meta(payer).SIGNER().WRITE(),
+		solana.Meta(solana.SystemProgramID),
+	}
+	return solana.NewInstruction(ProgramID, accounts, data), nil
+}
+
+// NewDeactivateLookupTableInstruction starts the cooldown after which table
+// can be closed; a deactivated table can no longer be used or extended
+func NewDeactivateLookupTableInstruction(table, authority solana.PublicKey) solana.Instruction {
+	data := binary.LittleEndian.AppendUint32(nil, deactivateLookupTable)
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(table).WRITE(),
+		solana.Meta(authority).SIGNER(),
+	}
+	return solana.NewInstruction(ProgramID, accounts, data)
+}
+
+// NewCloseLookupTableInstruction closes a deactivated table and returns its
+// rent to recipient
+func NewCloseLookupTableInstruction(table, authority, recipient solana.PublicKey) solana.Instruction {
+	data := binary.LittleEndian.AppendUint32(nil, closeLookupTable)
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(table).WRITE(),
+		solana.Meta(authority).SIGNER(),
+		solana.Meta(recipient).WRITE(),
+	}
+	return solana.NewInstruction(ProgramID, accounts, data)
+}
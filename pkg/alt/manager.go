@@ -0,0 +1,327 @@
+package alt
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	// DefaultIdleTTL is how long a table goes unused before it is deactivated
+	DefaultIdleTTL = 24 * time.Hour
+	// DefaultConfirmTimeout bounds each table management transaction
+	DefaultConfirmTimeout = 30 * time.Second
+
+	warmupPollInterval = 400 * time.Millisecond
+)
+
+// Table is a lookup table owned by the manager's authority
+type Table struct {
+	Address   solana.PublicKey
+	Addresses solana.PublicKeySlice
+	LastUsed  time.Time
+	// DeactivationSlot is the slot the table was deactivated in, MaxUint64
+	// while it is active
+	DeactivationSlot uint64
+}
+
+// Active reports whether the table can still be used and extended
+func (t *Table) Active() bool {
+	return t.DeactivationSlot == math.MaxUint64
+}
+
+// Manager creates, extends and retires the lookup tables of Authority, which
+// also pays for them
+type Manager struct {
+	client    *sol.Client
+	authority solana.PrivateKey
+
+	// IdleTTL is how long a table goes unused before Run deactivates it
+	IdleTTL time.Duration
+	// ConfirmTimeout bounds each create, extend, deactivate and close
+	ConfirmTimeout time.Duration
+
+	mu     sync.Mutex
+	tables []*Table
+}
+
+// NewManager creates a manager for the lookup tables of authority
+func NewManager(solClient *sol.Client, authority solana.PrivateKey) *Manager {
+	return &Manager{
+		client:         solClient,
+		authority:      authority,
+		IdleTTL:        DefaultIdleTTL,
+		ConfirmTimeout: DefaultConfirmTimeout,
+	}
+}
+
+// Load adopts tables created by an earlier run, so they are reused and retired
+// like the ones this manager creates
+func (m *Manager) Load(ctx context.Context, addresses ...solana.PublicKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, address := range addresses {
+		state, err := m.client.GetAddressLookupTableState(ctx, address)
+		if err != nil {
+			return err
+		}
+		if state.Authority == nil || !state.Authority.Equals(m.authority.PublicKey()) {
+			return fmt.Errorf("lookup table %s is not owned by %s", address, m.authority.PublicKey())
+		}
+		m.tables = append(m.tables, &Table{
+			Address:          address,
+			Addresses:        state.Addresses,
+			LastUsed:         time.Now(),
+			DeactivationSlot: state.DeactivationSlot,
+		})
+	}
+	return nil
+}
+
+// Tables returns a snapshot of the managed tables
+func (m *Manager) Tables() []Table {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tables := make([]Table, 0, len(m.tables))
+	for _, table := range m.tables {
+		tables = append(tables, *table)
+	}
+	return tables
+}
+
+// Compress returns lookup tables covering every account of instructions that
+// can be loaded from a table: neither a signer nor an invoked program. Accounts
+// no active table holds yet are added to one first, extending the table with
+// the most room or creating a new one, and Compress waits for them to warm up
+func (m *Manager) Compress(ctx context.Context, instructions []solana.Instruction) (map[solana.PublicKey]solana.PublicKeySlice, error) {
+	accounts := tableAccounts(instructions)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	held := make(map[solana.PublicKey]*Table)
+	for _, table := range m.tables {
+		if !table.Active() {
+			continue
+		}
+		for _, address := range table.Addresses {
+			if _, ok := held[address]; !ok {
+				held[address] = table
+			}
+		}
+	}
+	missing := make([]solana.PublicKey, 0)
+	for _, account := range accounts {
+		if _, ok := held[account]; !ok {
+			missing = append(missing, account)
+		}
+	}
+
+	if len(missing) > 0 {
+		table, err := m.tableWithRoom(ctx, len(missing))
+		if err != nil {
+			return nil, err
+		}
+		if err := m.extend(ctx, table, missing); err != nil {
+			return nil, err
+		}
+		for _, account := range missing {
+			held[account] = table
+		}
+	}
+
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice)
+	now := time.Now()
+	for _, account := range accounts {
+		table := held[account]
+		if _, ok := tables[table.Address]; !ok {
+			tables[table.Address] = table.Addresses
+			table.LastUsed = now
+		}
+	}
+	return tables, nil
+}
+
+// Run retires idle tables every interval until ctx is done: tables unused for
+// IdleTTL are deactivated and closed once their cooldown has passed
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Retire(ctx); err != nil {
+				log.Printf("failed to retire lookup tables: %v", err)
+			}
+		}
+	}
+}
+
+// Retire deactivates tables idle for IdleTTL and closes deactivated tables
+// whose cooldown has passed, returning their rent to the authority
+func (m *Manager) Retire(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	slot, err := m.client.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return fmt.Errorf("failed to get slot: %w", err)
+	}
+
+	authority := m.authority.PublicKey()
+	kept := m.tables[:0]
+	for _, table := range m.tables {
+		switch {
+		case table.Active() && time.Since(table.LastUsed) > m.IdleTTL:
+			if err := m.send(ctx, NewDeactivateLookupTableInstruction(table.Address, authority)); err != nil {
+				log.Printf("failed to deactivate lookup table %s: %v", table.Address, err)
+			} else {
+				table.DeactivationSlot = slot
+			}
+		case !table.Active() && slot > table.DeactivationSlot+DeactivationCooldownSlots:
+			if err := m.send(ctx, NewCloseLookupTableInstruction(table.Address, authority, authority)); err != nil {
+				log.Printf("failed to close lookup table %s: %v", table.Address, err)
+			} else {
+				continue
+			}
+		}
+		kept = append(kept, table)
+	}
+	m.tables = kept
+	return nil
+}
+
+// tableWithRoom returns the active table with the most free slots if it can
+// take n more addresses, creating a new table otherwise
+func (m *Manager) tableWithRoom(ctx context.Context, n int) (*Table, error) {
+	if n > MaxAddresses {
+		return nil, fmt.Errorf("route needs %d table addresses, more than one table holds", n)
+	}
+	var best *Table
+	for _, table := range m.tables {
+		if table.Active() && (best == nil || len(table.Addresses) < len(best.Addresses)) {
+			best = table
+		}
+	}
+	if best != nil && len(best.Addresses)+n <= MaxAddresses {
+		return best, nil
+	}
+
+	slot, err := m.client.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slot: %w", err)
+	}
+	authority := m.authority.PublicKey()
+	instruction, address, err := NewCreateLookupTableInstruction(authority, authority, slot)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.send(ctx, instruction); err != nil {
+		return nil, fmt.Errorf("failed to create lookup table: %w", err)
+	}
+	log.Printf("created lookup table %s", address)
+	table := &Table{
+		Address:          address,
+		LastUsed:         time.Now(),
+		DeactivationSlot: math.MaxUint64,
+	}
+	m.tables = append(m.tables, table)
+	return table, nil
+}
+
+// extend adds addresses to table in as many transactions as needed, then
+// waits until they can be used
+func (m *Manager) extend(ctx context.Context, table *Table, addresses []solana.PublicKey) error {
+	authority := m.authority.PublicKey()
+	for start := 0; start < len(addresses); start += MaxExtendAddresses {
+		end := min(start+MaxExtendAddresses, len(addresses))
+		instruction, err := NewExtendLookupTableInstruction(table.Address, authority, authority, addresses[start:end])
+		if err != nil {
+			return err
+		}
+		if err := m.send(ctx, instruction); err != nil {
+			return fmt.Errorf("failed to extend lookup table %s: %w", table.Address, err)
+		}
+		table.Addresses = append(table.Addresses, addresses[start:end]...)
+	}
+	return m.awaitWarmup(ctx, table)
+}
+
+// awaitWarmup waits until the slot after the table's last extension, before
+// which the new addresses cannot be loaded
+func (m *Manager) awaitWarmup(ctx context.Context, table *Table) error {
+	state, err := m.client.GetAddressLookupTableState(ctx, table.Address)
+	if err != nil {
+		return err
+	}
+	table.Addresses = state.Addresses
+	for {
+		slot, err := m.client.GetSlot(ctx, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to get slot: %w", err)
+		}
+		if slot > state.LastExtendedSlot {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(warmupPollInterval):
+		}
+	}
+}
+
+// send signs instruction with the authority, sends it and waits for it to land
+func (m *Manager) send(ctx context.Context, instruction solana.Instruction) error {
+	tx, err := m.client.SignTransaction(ctx, sol.PrivateKeySigners(m.authority), instruction)
+	if err != nil {
+		return err
+	}
+	sig, err := m.client.SendTx(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return m.client.AwaitConfirmation(ctx, sig, m.ConfirmTimeout)
+}
+
+// tableAccounts returns the accounts of instructions a lookup table can
+// supply, in a stable order
+func tableAccounts(instructions []solana.Instruction) []solana.PublicKey {
+	programs := make(map[solana.PublicKey]bool)
+	signers := make(map[solana.PublicKey]bool)
+	for _, instruction := range instructions {
+		programs[instruction.ProgramID()] = true
+		for _, account := range instruction.Accounts() {
+			if account.IsSigner {
+				signers[account.PublicKey] = true
+			}
+		}
+	}
+
+	seen := make(map[solana.PublicKey]bool)
+	accounts := make([]solana.PublicKey, 0)
+	for _, instruction := range instructions {
+		for _, account := range instruction.Accounts() {
+			key := account.PublicKey
+			if seen[key] || programs[key] || signers[key] {
+				continue
+			}
+			seen[key] = true
+			accounts = append(accounts, key)
+		}
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return accounts[i].String() < accounts[j].String()
+	})
+	return accounts
+}
@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+// Trade is the realized outcome of one confirmed order
+type Trade struct {
+	OrderID    string
+	Wallet     string
+	InputMint  string
+	OutputMint string
+	Signature  string
+	Time       time.Time
+
+	AmountIn          math.Int
+	QuotedAmountOut   math.Int
+	RealizedAmountOut math.Int
+	// SlippageBps is how far the fill fell short of the quote; negative means price improvement
+	SlippageBps float64
+
+	NetworkFee   uint64
+	PriorityFee  uint64
+	Tip          uint64
+	ProtocolFees map[string]math.Int
+}
+
+// TotalLamportCost returns the lamports spent on fees and tips
+func (t Trade) TotalLamportCost() uint64 {
+	return t.NetworkFee + t.Tip
+}
+
+// TokenPnL is the cumulative flow of one token across trades
+type TokenPnL struct {
+	Mint     string
+	Spent    math.Int
+	Received math.Int
+	// Fees are venue fees paid in this token; lamport costs count towards WSOL
+	Fees math.Int
+	// Net is Received - Spent, minus lamport costs for WSOL
+	Net    math.Int
+	Trades int
+}
+
+// LoadTrades reads the confirmed orders matching filter from s as trades
+func LoadTrades(ctx context.Context, s store.Store, filter store.Filter) ([]Trade, error) {
+	filter.Status = store.StatusConfirmed
+	orders, err := s.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	return Trades(orders), nil
+}
+
+// Trades converts confirmed orders into trades, skipping everything else
+func Trades(orders []*store.Order) []Trade {
+	trades := make([]Trade, 0, len(orders))
+	for _, order := range orders {
+		if order.Status != store.StatusConfirmed {
+			continue
+		}
+		trades = append(trades, Trade{
+			OrderID:           order.ID,
+			Wallet:            order.Wallet,
+			InputMint:         order.InputMint,
+			OutputMint:        order.OutputMint,
+			Signature:         order.Signature,
+			Time:              order.UpdatedAt,
+			AmountIn:          orZero(order.AmountIn),
+			QuotedAmountOut:   orZero(order.QuotedAmountOut),
+			RealizedAmountOut: orZero(order.RealizedAmountOut),
+			SlippageBps:       slippageBps(order.QuotedAmountOut, order.RealizedAmountOut),
+			NetworkFee:        order.Fee,
+			PriorityFee:       order.PriorityFee,
+			Tip:               order.Tip,
+			ProtocolFees:      order.ProtocolFees,
+		})
+	}
+	return trades
+}
+
+// PnLByToken accumulates per-token flows across trades, sorted by mint
+func PnLByToken(trades []Trade) []TokenPnL {
+	byMint := make(map[string]*TokenPnL)
+	get := func(mint string) *TokenPnL {
+		pnl, ok := byMint[mint]
+		if !ok {
+			pnl = &TokenPnL{Mint: mint, Spent: math.ZeroInt(), Received: math.ZeroInt(), Fees: math.ZeroInt(), Net: math.ZeroInt()}
+			byMint[mint] = pnl
+		}
+		return pnl
+	}
+
+	wsol := sol.WSOL.String()
+	for _, trade := range trades {
+		in := get(trade.InputMint)
+		in.Spent = in.Spent.Add(trade.AmountIn)
+		in.Trades++
+		out := get(trade.OutputMint)
+		out.Received = out.Received.Add(trade.RealizedAmountOut)
+		out.Trades++
+
+		for mint, fee := range trade.ProtocolFees {
+			pnl := get(mint)
+			pnl.Fees = pnl.Fees.Add(fee)
+		}
+		if cost := trade.TotalLamportCost(); cost > 0 {
+			pnl := get(wsol)
+			pnl.Fees = pnl.Fees.Add(math.NewIntFromUint64(cost))
+			pnl.Net = pnl.Net.Sub(math.NewIntFromUint64(cost))
+		}
+	}
+
+	result := make([]TokenPnL, 0, len(byMint))
+	for _, pnl := range byMint {
+		// venue fees are already reflected in the realized amounts
+		pnl.Net = pnl.Net.Add(pnl.Received).Sub(pnl.Spent)
+		result = append(result, *pnl)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Mint < result[j].Mint
+	})
+	return result
+}
+
+// AverageSlippageBps returns the mean slippage across trades
+func AverageSlippageBps(trades []Trade) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, trade := range trades {
+		total += trade.SlippageBps
+	}
+	return total / float64(len(trades))
+}
+
+// WriteCSV writes one row per trade to w
+func WriteCSV(w io.Writer, trades []Trade) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"order_id", "time", "wallet", "signature", "input_mint", "output_mint",
+		"amount_in", "quoted_amount_out", "realized_amount_out", "slippage_bps",
+		"network_fee", "priority_fee", "tip",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, trade := range trades {
+		row := []string{
+			trade.OrderID,
+			trade.Time.UTC().Format(time.RFC3339),
+			trade.Wallet,
+			trade.Signature,
+			trade.InputMint,
+			trade.OutputMint,
+			trade.AmountIn.String(),
+			trade.QuotedAmountOut.String(),
+			trade.RealizedAmountOut.String(),
+			strconv.FormatFloat(trade.SlippageBps, 'f', 2, 64),
+			strconv.FormatUint(trade.NetworkFee, 10),
+			strconv.FormatUint(trade.PriorityFee, 10),
+			strconv.FormatUint(trade.Tip, 10),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// slippageBps returns (quoted - realized) / quoted in basis points
+func slippageBps(quoted, realized math.Int) float64 {
+	if quoted.IsNil() || realized.IsNil() || !quoted.IsPositive() {
+		return 0
+	}
+	q, _ := quoted.BigInt().Float64()
+	r, _ := realized.BigInt().Float64()
+	return (q - r) / q * 10000
+}
+
+func orZero(v math.Int) math.Int {
+	if v.IsNil() {
+		return math.ZeroInt()
+	}
+	return v
+}
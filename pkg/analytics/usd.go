@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/pricefeed"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// solDecimals is the number of decimals of SOL and WSOL
+const solDecimals = 9
+
+// TokenUSD is one token's PnL valued in USD
+type TokenUSD struct {
+	Mint     string
+	PriceUSD float64
+	FeesUSD  float64
+	NetUSD   float64
+}
+
+// USDReport expresses fees and PnL in USD at the feed's current prices
+type USDReport struct {
+	Tokens []TokenUSD
+	// FeesUSD is lamport costs plus venue fees across tokens
+	FeesUSD float64
+	NetUSD  float64
+	// Unpriced lists mints left out for lack of a price or decimals
+	Unpriced []string
+}
+
+// ValueInUSD values pnls with feed. decimals gives the decimals of each mint
+// other than WSOL, which is always known; integrators can fill it with
+// sol.Client.GetMintDecimals
+func ValueInUSD(ctx context.Context, pnls []TokenPnL, feed pricefeed.Feed, decimals map[string]uint8) (*USDReport, error) {
+	if feed == nil {
+		return nil, fmt.Errorf("a price feed is required")
+	}
+	report := &USDReport{Tokens: make([]TokenUSD, 0, len(pnls))}
+	for _, pnl := range pnls {
+		d, ok := mintDecimals(pnl.Mint, decimals)
+		if !ok {
+			report.Unpriced = append(report.Unpriced, pnl.Mint)
+			continue
+		}
+		price, err := feed.USDPrice(ctx, pnl.Mint)
+		if err != nil {
+			log.Printf("failed to price %s: %v", pnl.Mint, err)
+			report.Unpriced = append(report.Unpriced, pnl.Mint)
+			continue
+		}
+		token := TokenUSD{
+			Mint:     pnl.Mint,
+			PriceUSD: price,
+			FeesUSD:  AmountUSD(orZero(pnl.Fees), d, price),
+			NetUSD:   AmountUSD(orZero(pnl.Net), d, price),
+		}
+		report.Tokens = append(report.Tokens, token)
+		report.FeesUSD += token.FeesUSD
+		report.NetUSD += token.NetUSD
+	}
+	return report, nil
+}
+
+// LamportCostUSD returns the fees and tips of trades in USD
+func LamportCostUSD(ctx context.Context, trades []Trade, feed pricefeed.Feed) (float64, error) {
+	total := uint64(0)
+	for _, trade := range trades {
+		total += trade.TotalLamportCost()
+	}
+	price, err := feed.USDPrice(ctx, sol.WSOL.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to price SOL: %w", err)
+	}
+	return AmountUSD(math.NewIntFromUint64(total), solDecimals, price), nil
+}
+
+// AmountUSD converts a raw token amount with the given decimals to USD
+func AmountUSD(amount math.Int, decimals uint8, priceUSD float64) float64 {
+	whole := new(big.Float).SetInt(amount.BigInt())
+	whole.Quo(whole, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	value, _ := whole.Mul(whole, big.NewFloat(priceUSD)).Float64()
+	return value
+}
+
+func mintDecimals(mint string, decimals map[string]uint8) (uint8, bool) {
+	if mint == sol.WSOL.String() {
+		return solDecimals, true
+	}
+	d, ok := decimals[mint]
+	return d, ok
+}
@@ -1,7 +1,12 @@
 package pkg
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
 
 	"cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
@@ -12,11 +17,19 @@ import (
 type ProtocolName string
 
 const (
-	ProtocolNameRaydiumAmm  ProtocolName = "raydium_amm"
-	ProtocolNameRaydiumClmm ProtocolName = "raydium_clmm"
-	ProtocolNameRaydiumCpmm ProtocolName = "raydium_cpmm"
-	ProtocolNameMeteoraDlmm ProtocolName = "meteora_dlmm"
-	ProtocolNamePumpAmm     ProtocolName = "pump_amm"
+	ProtocolNameRaydiumAmm    ProtocolName = "raydium_amm"
+	ProtocolNameRaydiumClmm   ProtocolName = "raydium_clmm"
+	ProtocolNameRaydiumCpmm   ProtocolName = "raydium_cpmm"
+	ProtocolNameMeteoraDlmm   ProtocolName = "meteora_dlmm"
+	ProtocolNamePumpAmm       ProtocolName = "pump_amm"
+	ProtocolNameSPLStakePool  ProtocolName = "spl_stake_pool"
+	ProtocolNameMarinade      ProtocolName = "marinade"
+	ProtocolNameOrcaWhirlpool ProtocolName = "orca_whirlpool"
+	ProtocolNameMeteoraDamm   ProtocolName = "meteora_damm"
+	ProtocolNameMeteoraDammV2 ProtocolName = "meteora_damm_v2"
+	ProtocolNameLifinity      ProtocolName = "lifinity_v2"
+	ProtocolNamePhoenix       ProtocolName = "phoenix"
+	ProtocolNameOpenBook      ProtocolName = "openbook_v2"
 )
 
 type Pool interface {
@@ -42,3 +55,345 @@ type Protocol interface {
 	FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]Pool, error)
 	FetchPoolByID(ctx context.Context, poolID string) (Pool, error)
 }
+
+// BatchProtocol is implemented by protocols that load several pools by ID in
+// one batched account lookup. IDs that do not exist or do not decode are
+// left out of the result
+type BatchProtocol interface {
+	FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]Pool, error)
+}
+
+// FetchPoolsByIDs loads pools by ID through protocol's batched lookup when it
+// has one and one FetchPoolByID at a time otherwise, leaving out pools that
+// fail to load either way
+func FetchPoolsByIDs(ctx context.Context, protocol Protocol, poolIDs []string) ([]Pool, error) {
+	if batch, ok := protocol.(BatchProtocol); ok {
+		return batch.FetchPoolsByIDs(ctx, poolIDs)
+	}
+	pools := make([]Pool, 0, len(poolIDs))
+	for _, poolID := range poolIDs {
+		pool, err := protocol.FetchPoolByID(ctx, poolID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// PoolMeta is the static description of a pool: enough to place it in the
+// routing graph without loading its reserves, ticks or bins
+type PoolMeta struct {
+	ID        string
+	Protocol  ProtocolName
+	BaseMint  string
+	QuoteMint string
+	// FeeBps is the pool's base trading fee tier
+	FeeBps float64
+}
+
+// PoolMetaProtocol is implemented by protocols that can list the pools of a
+// pair as metadata only, fetching just the account bytes it needs. Full pool
+// state is loaded later with FetchPoolsByIDs for the pools actually used
+type PoolMetaProtocol interface {
+	FetchPoolMetasByPair(ctx context.Context, baseMint, quoteMint string) ([]PoolMeta, error)
+}
+
+// PoolSlice is the requested byte range of one pool account found by a scan
+type PoolSlice struct {
+	ID   string
+	Data []byte
+}
+
+// PoolScanProtocol is implemented by protocols that can scan the pools of a
+// pair fetching only length bytes from offset of each account, for callers
+// that need a few fields of many pools. A zero length lists the pool IDs only
+type PoolScanProtocol interface {
+	ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]PoolSlice, error)
+}
+
+// PoolCoverage counts what a protocol made of the accounts a pair scan
+// returned. Every discovered account is either decoded into a pool, failed
+// to decode or load the state it needs, or was left out as ineligible
+type PoolCoverage struct {
+	Discovered   int
+	Decoded      int
+	DecodeFailed int
+	// Ineligible counts accounts that decoded but cannot be routed, such as
+	// accounts of another program or pools the quote math does not support
+	Ineligible int
+}
+
+// Skipped returns the accounts that did not become pools
+func (c PoolCoverage) Skipped() int {
+	return c.DecodeFailed + c.Ineligible
+}
+
+// CoverageProtocol is implemented by protocols that report the coverage of
+// their pair scans, so a layout change that breaks decoding shows up as
+// skipped accounts instead of silently fewer pools
+type CoverageProtocol interface {
+	FetchPoolsByPairWithCoverage(ctx context.Context, baseMint, quoteMint string) ([]Pool, PoolCoverage, error)
+}
+
+// ExactOutputPool is implemented by pools whose swap instruction treats minOut
+// as the exact amount to receive for some directions, so it can never be left
+// unconstrained
+type ExactOutputPool interface {
+	MinOutIsExact(inputMint string) bool
+}
+
+// MinOutDecoder is implemented by pools that can read the slippage threshold
+// back from the swap instructions they built, so callers can verify that the
+// requested minimum output was actually encoded
+type MinOutDecoder interface {
+	DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error)
+}
+
+// UnencodedMinOutPool is implemented by pools whose swap instructions have no
+// minimum output field, such as fixed-rate deposits, limit-priced orders or
+// signed quotes. Their builds enforce minOut some other way, and they opt out
+// of the instruction check for the directions MinOutUnencoded reports
+type UnencodedMinOutPool interface {
+	MinOutUnencoded(inputMint string) bool
+}
+
+// RoundedMinOutPool is implemented by pools that can only encode the minimum
+// output in whole steps, such as the lots of an order book. MinOutStep is the
+// step of the output of inputMint; the encoded minimum is rounded up to it
+type RoundedMinOutPool interface {
+	MinOutStep(inputMint string) math.Int
+}
+
+// DecodeInstructionU64 finds the instruction of programID whose data starts
+// with prefix and reads the little-endian u64 at offset of its data
+func DecodeInstructionU64(instructions []solana.Instruction, programID solana.PublicKey, prefix []byte, offset int) (math.Int, error) {
+	for _, instruction := range instructions {
+		if !instruction.ProgramID().Equals(programID) {
+			continue
+		}
+		data, err := instruction.Data()
+		if err != nil {
+			return math.Int{}, fmt.Errorf("failed to encode instruction: %w", err)
+		}
+		if !bytes.HasPrefix(data, prefix) {
+			continue
+		}
+		if len(data) < offset+8 {
+			return math.Int{}, fmt.Errorf("instruction data too short: %d bytes", len(data))
+		}
+		return math.NewIntFromUint64(binary.LittleEndian.Uint64(data[offset:])), nil
+	}
+	return math.Int{}, fmt.Errorf("no swap instruction for program %s", programID)
+}
+
+// LotSizedPool is implemented by pools that fill only whole lots of the
+// input, such as order books. Their quotes do not scale with the amount: a
+// slightly smaller input can drop a lot and pay less than its pro rata share,
+// so memoized quotes are reused only for the exact amount quoted
+type LotSizedPool interface {
+	InputLotSize(inputMint string) math.Int
+}
+
+// AmountField locates a little-endian u64 in the data of the instruction of
+// ProgramID whose data starts with Prefix
+type AmountField struct {
+	ProgramID solana.PublicKey
+	Prefix    []byte
+	Offset    int
+}
+
+// PatchablePool is implemented by pools whose swap instruction carries the
+// input amount and the min out as fixed u64 fields, so instructions built
+// once can be reused for other amounts by rewriting those bytes. For
+// exact-out swaps amountIn is the maximum input and minOut the exact output
+type PatchablePool interface {
+	SwapAmountFields(inputMint string) (amountIn, minOut AmountField)
+}
+
+// ErrNotSwap is returned by swap decoders for instructions of their program
+// that are not swaps
+var ErrNotSwap = errors.New("not a swap instruction")
+
+// SwapParams are the parameters of a swap instruction decoded back from its
+// data and accounts. Mints are zero when the instruction does not name them
+type SwapParams struct {
+	Protocol          ProtocolName
+	Pool              solana.PublicKey
+	User              solana.PublicKey
+	UserInputAccount  solana.PublicKey
+	UserOutputAccount solana.PublicKey
+	InputMint         solana.PublicKey
+	OutputMint        solana.PublicKey
+	// AmountIn is the exact input, or the maximum input of exact output swaps
+	AmountIn math.Int
+	// MinAmountOut is the minimum output, or the exact output of exact output swaps
+	MinAmountOut math.Int
+	ExactOutput  bool
+	// AmountsInLots is set when AmountIn and MinAmountOut count the lots of an
+	// order book market rather than token units; the lot sizes are recorded
+	// in the market account, not the instruction
+	AmountsInLots bool
+}
+
+// CheckSwapAccounts returns an error when a swap instruction has fewer than
+// count accounts
+func CheckSwapAccounts(accounts []*solana.AccountMeta, count int) error {
+	if len(accounts) < count {
+		return fmt.Errorf("swap instruction has %d accounts, expected at least %d", len(accounts), count)
+	}
+	return nil
+}
+
+// NativeSOLPool is implemented by pools that take or pay native lamports
+// instead of wrapped SOL for the given mint, so no WSOL account is needed
+type NativeSOLPool interface {
+	UsesNativeSOL(mint string) bool
+}
+
+// DecimalsPool is implemented by pools whose state records the decimals of
+// their mints, sparing the router a mint account lookup when it normalizes
+// quotes to whole tokens
+type DecimalsPool interface {
+	MintDecimals(mint string) (uint8, bool)
+}
+
+// UpdatablePool is implemented by pools that keep runtime caches worth
+// preserving when the same pool is rediscovered. UpdateFrom copies the fresh
+// on-chain state of other into the receiver and reports whether it could
+type UpdatablePool interface {
+	UpdateFrom(other Pool) bool
+}
+
+// ResettablePool is implemented by pools that cache tick or bin arrays across
+// quotes. Reset drops those caches; the next quote fetches what it needs again
+type ResettablePool interface {
+	Reset()
+}
+
+// StreamedPool is implemented by pools that can be kept current from pushed
+// account updates, from a websocket or Geyser feed, instead of refetching
+// their tick or bin arrays on every quote. StreamAccounts lists the accounts
+// to follow for the pool's current position and restarts its bookkeeping;
+// ApplyAccountUpdate takes the data of one of them at slot, nil when the
+// account does not exist, and reports whether the pool moved so far that the
+// accounts to follow changed. Quotes read the cache only once every followed
+// account has been applied, and fetch as before otherwise
+type StreamedPool interface {
+	StreamAccounts() []solana.PublicKey
+	ApplyAccountUpdate(account solana.PublicKey, data []byte, slot uint64) (bool, error)
+}
+
+// ScheduledPool is implemented by pools that reject swaps before an open
+// time set at creation. OpensAt returns the zero time for pools open from
+// the start
+type ScheduledPool interface {
+	OpensAt() time.Time
+}
+
+// LookupTablePool is implemented by pools that publish address lookup tables
+// covering their swap accounts
+type LookupTablePool interface {
+	AddressLookupTables() []solana.PublicKey
+}
+
+// SwapFeePool is implemented by pools that can report the trading fee they
+// charge on an input amount, in input token units
+type SwapFeePool interface {
+	SwapFee(inputMint string, inputAmount math.Int) math.Int
+}
+
+// ReservesPool is implemented by pools that trade against two token reserves.
+// Reserves returns the base and quote amounts cached by the last Quote, nil
+// before the first one
+type ReservesPool interface {
+	Reserves() (base, quote math.Int)
+}
+
+// SpotPricePool is implemented by pools that can report their marginal price
+// from the state cached by the last Quote. RawSpotPrice is in output base
+// units per input base unit, before fees; ScalePrice turns it into whole
+// tokens for display
+type SpotPricePool interface {
+	RawSpotPrice(inputMint string) (math.LegacyDec, error)
+}
+
+// PriceEstimatePool is implemented by pools whose price can be estimated from
+// token account balances alone, such as the vaults of a constant product
+// pool. The router fetches the EstimateAccounts of many pools in one batch
+// and passes their balances, in the same order, to EstimatePrice, which
+// returns a raw price like RawSpotPrice without touching the pool's state
+type PriceEstimatePool interface {
+	EstimateAccounts() []solana.PublicKey
+	EstimatePrice(inputMint string, balances []uint64) (math.LegacyDec, error)
+}
+
+// ImpactBoundedPool is implemented by pools that can invert their quote math.
+// MaxInputForImpact returns the largest input whose average execution price,
+// before fees, stays within maxImpactBps of the spot price. It works on the
+// state cached by the last Quote
+type ImpactBoundedPool interface {
+	MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error)
+}
+
+// FeeAdjustedPool is implemented by venues whose economics are not fully
+// reflected in the quoted output, such as CLOB maker rebates or fee tiers
+// settled outside the swap. FeeAdjustment returns the output-mint amount
+// credited on top of amountOut, negative when it is an extra charge
+type FeeAdjustedPool interface {
+	FeeAdjustment(inputMint string, amountIn, amountOut math.Int) math.Int
+}
+
+// FeeTier is a fee rate that applies to trades of at least MinAmount, nil
+// meaning any size. Negative rates are rebates
+type FeeTier struct {
+	MinAmount math.Int
+	Bps       int64
+}
+
+// FeeSchedule holds fee tiers per mint, for adapters of venues that charge
+// different rates by token and trade size
+type FeeSchedule map[string][]FeeTier
+
+// Bps returns the rate of the highest tier of mint that amount reaches
+func (s FeeSchedule) Bps(mint string, amount math.Int) (int64, bool) {
+	var bps int64
+	found := false
+	var reached math.Int
+	for _, tier := range s[mint] {
+		minAmount := tier.MinAmount
+		if minAmount.IsNil() {
+			minAmount = math.ZeroInt()
+		}
+		if amount.LT(minAmount) || (found && minAmount.LT(reached)) {
+			continue
+		}
+		bps, reached, found = tier.Bps, minAmount, true
+	}
+	return bps, found
+}
+
+// Adjustment returns the amount credited for amount of mint under the
+// schedule: the rebate for negative rates, minus the fee for positive ones
+func (s FeeSchedule) Adjustment(mint string, amount math.Int) math.Int {
+	bps, ok := s.Bps(mint, amount)
+	if !ok || bps == 0 {
+		return math.ZeroInt()
+	}
+	if bps < 0 {
+		return amount.MulRaw(-bps).QuoRaw(10000)
+	}
+	// fees round up so a venue never looks cheaper than it is
+	return amount.MulRaw(bps).AddRaw(9999).QuoRaw(10000).Neg()
+}
+
+// CheckImpactBps validates a price impact bound in basis points
+func CheckImpactBps(maxImpactBps int) error {
+	if maxImpactBps <= 0 || maxImpactBps >= 10000 {
+		return fmt.Errorf("price impact must be between 1 and 9999 bps, got %d", maxImpactBps)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+type testPool struct{ id string }
+
+func (p *testPool) ProtocolName() ProtocolName              { return "test" }
+func (p *testPool) GetProgramID() solana.PublicKey          { return solana.PublicKey{} }
+func (p *testPool) GetID() string                           { return p.id }
+func (p *testPool) GetTokens() (baseMint, quoteMint string) { return "base", "quote" }
+func (p *testPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	return inputAmount, nil
+}
+func (p *testPool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount math.Int, minOut math.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+	return nil, nil
+}
+
+// testProtocol loads the pools it knows one at a time
+type testProtocol struct {
+	known   map[string]bool
+	fetched int
+}
+
+func (p *testProtocol) ProtocolName() ProtocolName { return "test" }
+func (p *testProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]Pool, error) {
+	return nil, nil
+}
+func (p *testProtocol) FetchPoolByID(ctx context.Context, poolID string) (Pool, error) {
+	p.fetched++
+	if !p.known[poolID] {
+		return nil, errors.New("pool not found")
+	}
+	return &testPool{id: poolID}, nil
+}
+
+// testBatchProtocol also loads pools in one batch
+type testBatchProtocol struct {
+	testProtocol
+	batches int
+}
+
+func (p *testBatchProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]Pool, error) {
+	p.batches++
+	pools := make([]Pool, 0, len(poolIDs))
+	for _, poolID := range poolIDs {
+		if p.known[poolID] {
+			pools = append(pools, &testPool{id: poolID})
+		}
+	}
+	return pools, nil
+}
+
+func TestFetchPoolsByIDs(t *testing.T) {
+	ids := []string{"a", "missing", "b"}
+
+	single := &testProtocol{known: map[string]bool{"a": true, "b": true}}
+	pools, err := FetchPoolsByIDs(context.Background(), single, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 || pools[0].GetID() != "a" || pools[1].GetID() != "b" {
+		t.Fatalf("got %d pools, want a and b", len(pools))
+	}
+	if single.fetched != len(ids) {
+		t.Fatalf("fetched %d pools one at a time, want %d", single.fetched, len(ids))
+	}
+
+	batch := &testBatchProtocol{testProtocol: testProtocol{known: map[string]bool{"a": true, "b": true}}}
+	pools, err = FetchPoolsByIDs(context.Background(), batch, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 || batch.batches != 1 || batch.fetched != 0 {
+		t.Fatalf("got %d pools in %d batches and %d single fetches, want 2 in 1 batch", len(pools), batch.batches, batch.fetched)
+	}
+}
+
+func TestFetchPoolsByIDsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FetchPoolsByIDs(ctx, &testProtocol{}, []string{"a"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package audit captures the chain state a pool quote was computed from and
+// recomputes the quote offline, so execution quality can be verified after
+// the fact from stored snapshots without an RPC endpoint
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/vcr"
+)
+
+// replayEndpoint is never dialed; every request is answered from the snapshot
+const replayEndpoint = "http://snapshot.invalid"
+
+const (
+	// captureRequestsPerSecond rate limits the reads of a capture
+	captureRequestsPerSecond = 20
+	// replayRequestsPerSecond keeps the rate limiter out of the way of replays
+	replayRequestsPerSecond = 10000
+)
+
+// Snapshot is a pool quote together with every RPC response it read: the
+// pool account, vaults, tick or bin arrays and clock, as a vcr cassette
+type Snapshot struct {
+	Protocol   pkg.ProtocolName `json:"protocol"`
+	PoolID     string           `json:"pool_id"`
+	InputMint  string           `json:"input_mint"`
+	AmountIn   math.Int         `json:"amount_in"`
+	AmountOut  math.Int         `json:"amount_out"`
+	CapturedAt time.Time        `json:"captured_at"`
+	State      vcr.Cassette     `json:"state"`
+}
+
+// Report compares a recomputed quote with the one captured and, when known,
+// with what the trade actually received
+type Report struct {
+	Recomputed math.Int
+	Captured   math.Int
+	// Matches reports whether the recomputed quote equals the captured one
+	Matches bool
+	// Realized is the output the trade received, nil when not given
+	Realized math.Int
+	// ShortfallBps is how far Realized fell short of Recomputed; negative
+	// means the trade did better than the quote
+	ShortfallBps float64
+}
+
+// Capture quotes amountIn of inputMint on a pool through endpoint and records
+// everything the quote read
+func Capture(ctx context.Context, endpoint string, protocolName pkg.ProtocolName, poolID, inputMint string, amountIn math.Int) (*Snapshot, error) {
+	recorder, err := vcr.New("", vcr.ModeRecord, nil)
+	if err != nil {
+		return nil, err
+	}
+	solClient, err := sol.NewClientWithHTTPClient(ctx, endpoint, "", captureRequestsPerSecond, recorder.Client())
+	if err != nil {
+		return nil, err
+	}
+	amountOut, err := quote(ctx, solClient, protocolName, poolID, inputMint, amountIn)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		Protocol:   protocolName,
+		PoolID:     poolID,
+		InputMint:  inputMint,
+		AmountIn:   amountIn,
+		AmountOut:  amountOut,
+		CapturedAt: time.Now().UTC(),
+		State:      recorder.Cassette(),
+	}, nil
+}
+
+// Recompute quotes the snapshot's pool again from the recorded state alone.
+// The same code path runs as at capture, so the result is deterministic
+func Recompute(ctx context.Context, snapshot *Snapshot) (math.Int, error) {
+	recorder, err := vcr.NewReplay(snapshot.State)
+	if err != nil {
+		return math.Int{}, err
+	}
+	solClient, err := sol.NewClientWithHTTPClient(ctx, replayEndpoint, "", replayRequestsPerSecond, recorder.Client())
+	if err != nil {
+		return math.Int{}, err
+	}
+	return quote(ctx, solClient, snapshot.Protocol, snapshot.PoolID, snapshot.InputMint, snapshot.AmountIn)
+}
+
+// Verify recomputes the snapshot's quote and compares it with the captured
+// quote and with realized, the output the trade received; realized may be nil
+func Verify(ctx context.Context, snapshot *Snapshot, realized math.Int) (*Report, error) {
+	recomputed, err := Recompute(ctx, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute quote: %w", err)
+	}
+	report := &Report{
+		Recomputed: recomputed,
+		Captured:   snapshot.AmountOut,
+		Matches:    !snapshot.AmountOut.IsNil() && recomputed.Equal(snapshot.AmountOut),
+		Realized:   realized,
+	}
+	if !realized.IsNil() && recomputed.IsPositive() {
+		q, _ := recomputed.BigInt().Float64()
+		r, _ := realized.BigInt().Float64()
+		report.ShortfallBps = (q - r) / q * 10000
+	}
+	return report, nil
+}
+
+// Save writes snapshot to path as JSON
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadSnapshot reads a snapshot written by Save
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %s: %w", path, err)
+	}
+	return &snapshot, nil
+}
+
+// quote loads a pool by ID and quotes it, the sequence both capture and
+// replay run
+func quote(ctx context.Context, solClient *sol.Client, protocolName pkg.ProtocolName, poolID, inputMint string, amountIn math.Int) (math.Int, error) {
+	proto, err := protocol.New(protocolName, solClient)
+	if err != nil {
+		return math.Int{}, err
+	}
+	pool, err := proto.FetchPoolByID(ctx, poolID)
+	if err != nil {
+		return math.Int{}, fmt.Errorf("failed to load pool %s: %w", poolID, err)
+	}
+	amountOut, err := pool.Quote(ctx, solClient, inputMint, amountIn)
+	if err != nil {
+		return math.Int{}, fmt.Errorf("failed to quote pool %s: %w", poolID, err)
+	}
+	return amountOut, nil
+}
@@ -0,0 +1,105 @@
+// Package bench benchmarks the quoting hot paths of every pool type against
+// the fixtures in testdata. Compare runs with benchstat to catch regressions
+// in the math layer before release:
+//
+//	go test -run '^$' -bench . -benchmem -count 10 ./pkg/bench > new.txt
+//	benchstat old.txt new.txt
+package bench
+
+import (
+	"context"
+	"testing"
+
+	cosmath "cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// benchCase is one benchmarked quote
+type benchCase struct {
+	name  string
+	quote func() (cosmath.Int, error)
+}
+
+// benchCases returns every benchmark case: the constant product quotes, CLMM
+// swapCompute and DLMM bin traversal, each with an order that stays near the
+// spot price and one that walks the liquidity
+func benchCases(p *pools) []benchCase {
+	wsol, usdc := sol.WSOL.String(), usdcMint.String()
+	oneSol := cosmath.NewInt(1_000_000_000)
+	largeSol := oneSol.MulRaw(15_000)
+	largeUsdc := cosmath.NewInt(2_000_000 * 1_000_000)
+
+	pure := func(compute func(string, cosmath.Int) cosmath.Int, inputMint string, amount cosmath.Int) func() (cosmath.Int, error) {
+		return func() (cosmath.Int, error) {
+			return compute(inputMint, amount), nil
+		}
+	}
+	clmmQuote := func(inputMint string, amount cosmath.Int) func() (cosmath.Int, error) {
+		return func() (cosmath.Int, error) {
+			out, err := p.clmm.ComputeAmountOutFormat(inputMint, amount)
+			return out.Neg(), err
+		}
+	}
+	dlmmQuote := func(inputMint string, amount cosmath.Int) func() (cosmath.Int, error) {
+		return func() (cosmath.Int, error) {
+			return p.dlmm.Quote(context.Background(), nil, inputMint, amount)
+		}
+	}
+
+	return []benchCase{
+		{"amm/sol-usdc/small", pure(p.amm.ComputeAmountOut, wsol, oneSol)},
+		{"amm/sol-usdc/large", pure(p.amm.ComputeAmountOut, wsol, largeSol)},
+		{"cpmm/sol-usdc/small", pure(p.cpmm.ComputeAmountOut, wsol, oneSol)},
+		{"cpmm/sol-usdc/large", pure(p.cpmm.ComputeAmountOut, wsol, largeSol)},
+		{"pump/sol-usdc/small", pure(p.pump.ComputeAmountOut, wsol, oneSol)},
+		{"pump/sol-usdc/large", pure(p.pump.ComputeAmountOut, wsol, largeSol)},
+		{"clmm/sol-usdc/small", clmmQuote(wsol, oneSol)},
+		{"clmm/sol-usdc/large", clmmQuote(wsol, largeSol)},
+		{"clmm/usdc-sol/large", clmmQuote(usdc, largeUsdc)},
+		{"dlmm/sol-usdc/small", dlmmQuote(wsol, oneSol)},
+		{"dlmm/sol-usdc/large", dlmmQuote(wsol, largeSol)},
+		{"dlmm/usdc-sol/large", dlmmQuote(usdc, largeUsdc)},
+	}
+}
+
+// TestFixtures quotes every case once, so a broken fixture fails the tests
+// instead of a benchmark timing an error path. With -update it first
+// regenerates the fixtures
+func TestFixtures(t *testing.T) {
+	if *update {
+		if err := writeFixtures(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := loadPools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range benchCases(p) {
+		out, err := c.quote()
+		if err != nil {
+			t.Errorf("%s: quote failed: %v", c.name, err)
+			continue
+		}
+		if !out.IsPositive() {
+			t.Errorf("%s: quote returned %s", c.name, out)
+		}
+	}
+}
+
+func BenchmarkQuote(b *testing.B) {
+	p, err := loadPools()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, c := range benchCases(p) {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.quote(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
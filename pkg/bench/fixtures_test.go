@@ -0,0 +1,344 @@
+package bench
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	cosmath "cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/pool/pump"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"lukechampine.com/uint128"
+)
+
+var update = flag.Bool("update", false, "regenerate testdata/pools.json from the synthetic pool layouts")
+
+// The fixtures are synthetic pools shaped like the mainnet SOL/USDC pools:
+// a spot price of 150 USDC per SOL, reserves in the tens of millions of
+// dollars and liquidity spread over a realistic number of ticks and bins.
+// The CLMM and DLMM pools are stored as account data under testdata and
+// decoded like fetched accounts, so the benchmarks need no RPC endpoint
+var (
+	usdcMint = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+
+	// spotPrice is USDC per SOL in raw units (6 and 9 decimals)
+	spotPrice = 0.15
+
+	fixturesPath = filepath.Join("testdata", "pools.json")
+)
+
+const (
+	// sol reserves of the constant product pools
+	cpSolReserve = 100_000 * 1_000_000_000
+
+	// CLMM layout: nested positions centered on the current tick
+	clmmTickSpacing       = 10
+	clmmFeeRate           = 500
+	clmmPositionCount     = 30
+	clmmPositionWidth     = 60
+	clmmPositionLiquidity = 10_000_000_000_000
+	clmmPoolSize          = 1544
+	clmmTickArraySize     = 60
+	clmmTickArrayDataSize = 10240
+	clmmTickStateSize     = 168
+	clmmBitmapMiddle      = 512
+
+	// DLMM layout: bin arrays of 70 bins around the active bin
+	dlmmBinStep      = 10
+	dlmmBaseFactor   = 10_000
+	dlmmBinArrays    = 3
+	dlmmBinsPerArray = 70
+	dlmmBinAmountX   = 130 * 1_000_000_000
+	dlmmBinAmountY   = 20_000 * 1_000_000
+	dlmmPoolSize     = 904
+	dlmmBinArraySize = 56 + dlmmBinsPerArray*144
+	dlmmBitmapOffset = 584
+	dlmmBitmapMiddle = 512
+)
+
+// poolFixtures is the content of testdata/pools.json
+type poolFixtures struct {
+	// SolReserve and UsdcReserve are the constant product pools' reserves
+	SolReserve  cosmath.Int `json:"solReserve"`
+	UsdcReserve cosmath.Int `json:"usdcReserve"`
+	CLMM        clmmFixture `json:"clmm"`
+	DLMM        dlmmFixture `json:"dlmm"`
+}
+
+type clmmFixture struct {
+	ID solana.PublicKey `json:"id"`
+	// FeeRate is read from the pool's amm config, which is not stored
+	FeeRate    uint32   `json:"feeRate"`
+	Pool       []byte   `json:"pool"`
+	TickArrays [][]byte `json:"tickArrays"`
+}
+
+type dlmmFixture struct {
+	ID        solana.PublicKey `json:"id"`
+	Pool      []byte           `json:"pool"`
+	BinArrays [][]byte         `json:"binArrays"`
+}
+
+// pools are the fixtures decoded into the pool types the benchmarks quote
+type pools struct {
+	amm  *raydium.AMMPool
+	cpmm *raydium.CPMMPool
+	pump *pump.PumpAMMPool
+	clmm *raydium.CLMMPool
+	dlmm *meteora.MeteoraDlmmPool
+}
+
+// loadPools decodes testdata/pools.json
+func loadPools() (*pools, error) {
+	data, err := os.ReadFile(fixturesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	var fixtures poolFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to decode fixtures: %w", err)
+	}
+
+	clmm, err := decodeCLMM(fixtures.CLMM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode clmm fixture: %w", err)
+	}
+	dlmm, err := decodeDLMM(fixtures.DLMM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode dlmm fixture: %w", err)
+	}
+	return &pools{
+		amm: &raydium.AMMPool{
+			BaseMint:     sol.WSOL,
+			QuoteMint:    usdcMint,
+			BaseDecimal:  9,
+			QuoteDecimal: 6,
+			BaseReserve:  fixtures.SolReserve,
+			QuoteReserve: fixtures.UsdcReserve,
+		},
+		cpmm: &raydium.CPMMPool{
+			Token0Mint:   sol.WSOL,
+			Token1Mint:   usdcMint,
+			BaseDecimal:  9,
+			QuoteDecimal: 6,
+			BaseReserve:  fixtures.SolReserve,
+			QuoteReserve: fixtures.UsdcReserve,
+		},
+		pump: &pump.PumpAMMPool{
+			BaseMint:    sol.WSOL,
+			QuoteMint:   usdcMint,
+			BaseAmount:  fixtures.SolReserve,
+			QuoteAmount: fixtures.UsdcReserve,
+		},
+		clmm: clmm,
+		dlmm: dlmm,
+	}, nil
+}
+
+func decodeCLMM(fixture clmmFixture) (*raydium.CLMMPool, error) {
+	pool := &raydium.CLMMPool{
+		PoolId:         fixture.ID,
+		FeeRate:        fixture.FeeRate,
+		TickArrayCache: make(map[string]raydium.TickArray),
+	}
+	if err := pool.Decode(fixture.Pool); err != nil {
+		return nil, err
+	}
+	for _, data := range fixture.TickArrays {
+		var tickArray raydium.TickArray
+		if err := tickArray.Decode(data); err != nil {
+			return nil, err
+		}
+		pool.TickArrayCache[strconv.FormatInt(int64(tickArray.StartTickIndex), 10)] = tickArray
+	}
+	return pool, nil
+}
+
+func decodeDLMM(fixture dlmmFixture) (*meteora.MeteoraDlmmPool, error) {
+	pool := &meteora.MeteoraDlmmPool{
+		PoolId:    fixture.ID,
+		BinArrays: make(map[string]meteora.BinArray),
+	}
+	if err := pool.Decode(fixture.Pool); err != nil {
+		return nil, err
+	}
+	for _, data := range fixture.BinArrays {
+		binArray, err := meteora.ParseBinArray(data)
+		if err != nil {
+			return nil, err
+		}
+		index := int64(binary.LittleEndian.Uint64(data[8:]))
+		pda, _ := meteora.DeriveBinArrayPDA(pool.PoolId, index)
+		pool.BinArrays[pda.String()] = binArray
+	}
+	return pool, nil
+}
+
+// writeFixtures regenerates testdata/pools.json
+func writeFixtures() error {
+	solReserve := cosmath.NewInt(cpSolReserve)
+	fixtures := poolFixtures{
+		SolReserve:  solReserve,
+		UsdcReserve: solReserve.MulRaw(15).QuoRaw(100),
+		CLMM:        clmmAccounts(),
+		DLMM:        dlmmAccounts(),
+	}
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fixturesPath, append(data, '\n'), 0o644)
+}
+
+// clmmAccounts encodes a Raydium CLMM pool whose tick arrays hold nested
+// positions, so a large swap crosses an initialized tick every few hundred
+// ticks and walks several tick arrays
+func clmmAccounts() clmmFixture {
+	tickCurrent := int64(math.Floor(math.Log(spotPrice) / math.Log(1.0001)))
+	ticksPerArray := int64(clmmTickSpacing * clmmTickArraySize)
+	center := tickCurrent - mod(tickCurrent, clmmPositionWidth)
+	fixture := clmmFixture{
+		ID:      solana.MustPublicKeyFromBase58("3ucNos4NbumPLZNWztqGHNFFgkHeRMBQAVemeeomsUxv"),
+		FeeRate: clmmFeeRate,
+	}
+
+	liquidityNet := make(map[int64]int64)
+	for k := int64(1); k <= clmmPositionCount; k++ {
+		liquidityNet[center-k*clmmPositionWidth] += clmmPositionLiquidity
+		liquidityNet[center+k*clmmPositionWidth] -= clmmPositionLiquidity
+	}
+
+	var bitmap [16]uint64
+	reach := int64(clmmPositionCount * clmmPositionWidth)
+	first := floorDiv(center-reach, ticksPerArray) * ticksPerArray
+	last := floorDiv(center+reach, ticksPerArray) * ticksPerArray
+	for start := first; start <= last; start += ticksPerArray {
+		data := make([]byte, clmmTickArrayDataSize)
+		copy(data[8:], fixture.ID.Bytes())
+		binary.LittleEndian.PutUint32(data[40:], uint32(int32(start)))
+		initialized := 0
+		for i := 0; i < clmmTickArraySize; i++ {
+			tick := start + int64(i)*clmmTickSpacing
+			offset := 44 + i*clmmTickStateSize
+			binary.LittleEndian.PutUint32(data[offset:], uint32(int32(tick)))
+			if net, ok := liquidityNet[tick]; ok {
+				// liquidity_net is an i128
+				binary.LittleEndian.PutUint64(data[offset+4:], uint64(net))
+				binary.LittleEndian.PutUint64(data[offset+12:], uint64(net>>63))
+				uint128.From64(uint64(abs(net))).PutBytes(data[offset+20:])
+				initialized++
+			}
+		}
+		data[44+clmmTickArraySize*clmmTickStateSize] = byte(initialized)
+		fixture.TickArrays = append(fixture.TickArrays, data)
+
+		bit := start/ticksPerArray + clmmBitmapMiddle
+		bitmap[bit/64] |= 1 << (bit % 64)
+	}
+
+	// offsets follow CLMMPool.Decode
+	data := make([]byte, clmmPoolSize)
+	copy(data[8+1+32*2:], sol.WSOL.Bytes())
+	copy(data[8+1+32*3:], usdcMint.Bytes())
+	data[233], data[234] = 9, 6
+	binary.LittleEndian.PutUint16(data[235:], clmmTickSpacing)
+	uint128.From64(clmmPositionLiquidity).Mul64(clmmPositionCount).PutBytes(data[237:])
+	sqrtPriceX64(float64(tickCurrent) + 0.5).PutBytes(data[253:])
+	binary.LittleEndian.PutUint32(data[269:], uint32(int32(tickCurrent)))
+	for i, word := range bitmap {
+		binary.LittleEndian.PutUint64(data[904+8*i:], word)
+	}
+	fixture.Pool = data
+	return fixture
+}
+
+// sqrtPriceX64 returns sqrt(1.0001^tick) as a Q64.64 number
+func sqrtPriceX64(tick float64) uint128.Uint128 {
+	return toX64(math.Sqrt(math.Pow(1.0001, tick)))
+}
+
+// toX64 converts v to a Q64.64 number
+func toX64(v float64) uint128.Uint128 {
+	scaled, _ := new(big.Float).SetMantExp(big.NewFloat(v), 64).Int(nil)
+	return uint128.FromBig(scaled)
+}
+
+// dlmmAccounts encodes a Meteora DLMM pool with SOL in the bins above the
+// active bin, USDC below it and both in the active bin, spread over
+// dlmmBinArrays bin arrays on each side
+func dlmmAccounts() dlmmFixture {
+	activeID := int32(math.Floor(math.Log(spotPrice) / math.Log(1+dlmmBinStep/10_000.0)))
+	activeArray := floorDiv(int64(activeID), dlmmBinsPerArray)
+	fixture := dlmmFixture{ID: solana.MustPublicKeyFromBase58("5rCf1DM8LjKTw4YqhnoLcngyZYeNnQqztScTogYHAS6")}
+
+	data := make([]byte, dlmmPoolSize)
+	binary.LittleEndian.PutUint16(data[8:], dlmmBaseFactor)
+	binary.LittleEndian.PutUint16(data[10:], 30)      // filter period
+	binary.LittleEndian.PutUint16(data[12:], 600)     // decay period
+	binary.LittleEndian.PutUint16(data[14:], 5_000)   // reduction factor
+	binary.LittleEndian.PutUint32(data[16:], 7_500)   // variable fee control
+	binary.LittleEndian.PutUint32(data[20:], 150_000) // max volatility accumulator
+	minBinID := int32(meteora.MinBinID)
+	binary.LittleEndian.PutUint32(data[24:], uint32(minBinID))
+	binary.LittleEndian.PutUint32(data[28:], uint32(meteora.MaxBinID))
+	binary.LittleEndian.PutUint16(data[32:], 500)              // protocol share
+	binary.LittleEndian.PutUint32(data[48:], uint32(activeID)) // index reference
+	binary.LittleEndian.PutUint32(data[76:], uint32(activeID))
+	binary.LittleEndian.PutUint16(data[80:], dlmmBinStep)
+	copy(data[88:], sol.WSOL.Bytes())
+	copy(data[120:], usdcMint.Bytes())
+	for index := activeArray - dlmmBinArrays; index <= activeArray+dlmmBinArrays; index++ {
+		bit := index + dlmmBitmapMiddle
+		word := dlmmBitmapOffset + 8*int(bit/64)
+		binary.LittleEndian.PutUint64(data[word:], binary.LittleEndian.Uint64(data[word:])|1<<(bit%64))
+		fixture.BinArrays = append(fixture.BinArrays, dlmmBinArrayData(fixture.ID, index, activeID))
+	}
+	fixture.Pool = data
+	return fixture
+}
+
+// dlmmBinArrayData encodes one bin array account, storing each bin's price
+// as mainnet bins do
+func dlmmBinArrayData(lbPair solana.PublicKey, index int64, activeID int32) []byte {
+	data := make([]byte, dlmmBinArraySize)
+	binary.LittleEndian.PutUint64(data[8:], uint64(index))
+	copy(data[24:], lbPair.Bytes())
+	for i := 0; i < dlmmBinsPerArray; i++ {
+		binID := index*dlmmBinsPerArray + int64(i)
+		offset := 56 + i*144
+		if binID >= int64(activeID) {
+			binary.LittleEndian.PutUint64(data[offset:], dlmmBinAmountX)
+		}
+		if binID <= int64(activeID) {
+			binary.LittleEndian.PutUint64(data[offset+8:], dlmmBinAmountY)
+		}
+		price := toX64(math.Pow(1+dlmmBinStep/10_000.0, float64(binID)))
+		binary.LittleEndian.PutUint64(data[offset+16:], price.Lo)
+		binary.LittleEndian.PutUint64(data[offset+24:], price.Hi)
+	}
+	return data
+}
+
+func floorDiv(a, b int64) int64 {
+	return int64(math.Floor(float64(a) / float64(b)))
+}
+
+func mod(a, b int64) int64 {
+	return a - floorDiv(a, b)*b
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
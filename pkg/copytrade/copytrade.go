@@ -0,0 +1,174 @@
+// Package copytrade mirrors the swaps of a target wallet: every swap seen on
+// the flow monitor is scaled, checked against the allowlist and re-routed
+// through the router and executor for our own wallet
+package copytrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/executor"
+	"github.com/solana-zh/solroute/pkg/flow"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+const (
+	DefaultScale          = 1.0
+	DefaultMaxSlippageBps = 100
+
+	// eventBuffer is the number of target swaps queued while one is executing
+	eventBuffer = 64
+)
+
+// ErrSkipped is returned by Mirror for swaps that are not copied
+var ErrSkipped = errors.New("swap not copied")
+
+// Config controls which swaps are copied and how large the copies are
+type Config struct {
+	Target solana.PublicKey
+	// Scale multiplies the target's input amount
+	Scale float64
+	// MaxAmountIn caps the copied input per input mint; mints without a cap
+	// are copied at the scaled size
+	MaxAmountIn map[solana.PublicKey]math.Int
+	// Allowlist holds the mints that may be bought or sold; both sides of a
+	// swap must be listed. An empty allowlist allows every mint
+	Allowlist map[solana.PublicKey]struct{}
+	// MaxSlippageBps is the slippage tolerated on the copied swap
+	MaxSlippageBps int
+}
+
+// Trader copies the target's swaps with the first signer's wallet
+type Trader struct {
+	client  *sol.Client
+	router  *router.SimpleRouter
+	signers []sol.Signer
+	config  Config
+
+	// Executor sends the copies; set its Store and Alerts to persist and
+	// monitor them like any other order
+	Executor *executor.Executor
+
+	pair [2]string
+}
+
+// NewTrader creates a copy trader quoting through r and signing with signers
+func NewTrader(solClient *sol.Client, r *router.SimpleRouter, signers []sol.Signer, config Config) *Trader {
+	if config.Scale <= 0 {
+		config.Scale = DefaultScale
+	}
+	if config.MaxSlippageBps <= 0 {
+		config.MaxSlippageBps = DefaultMaxSlippageBps
+	}
+	exec := executor.New(solClient, r)
+	exec.SlippageBps = config.MaxSlippageBps
+	return &Trader{
+		client:   solClient,
+		router:   r,
+		signers:  signers,
+		config:   config,
+		Executor: exec,
+	}
+}
+
+// Run follows the target's swaps on source and copies them one at a time
+// until ctx is cancelled or the source fails. Swaps arriving while the queue
+// is full are dropped
+func (t *Trader) Run(ctx context.Context, source flow.Source) error {
+	if len(t.signers) == 0 {
+		return fmt.Errorf("at least one signer is required")
+	}
+	monitor := flow.NewWalletMonitor(source, t.config.Target)
+	events, unsubscribe := monitor.Subscribe(eventBuffer)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- monitor.Run(ctx)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errCh:
+			return err
+		case event := <-events:
+			order, err := t.Mirror(ctx, event)
+			if errors.Is(err, ErrSkipped) {
+				log.Printf("copytrade: %s: %v", event.Signature, err)
+				continue
+			}
+			if err != nil {
+				log.Printf("copytrade: failed to copy %s: %v", event.Signature, err)
+				continue
+			}
+			log.Printf("copytrade: copied %s as %s", event.Signature, order.Signature)
+		}
+	}
+}
+
+// Mirror copies one swap of the target. It returns an error wrapping
+// ErrSkipped for swaps the config excludes
+func (t *Trader) Mirror(ctx context.Context, event flow.Event) (*store.Order, error) {
+	if !event.User.Equals(t.config.Target) {
+		return nil, fmt.Errorf("%w: sent by %s, not the target", ErrSkipped, event.User)
+	}
+	if event.InputMint.IsZero() || event.OutputMint.IsZero() {
+		return nil, fmt.Errorf("%w: mints of the %s swap are unknown", ErrSkipped, event.Protocol)
+	}
+	if event.AmountsInLots {
+		return nil, fmt.Errorf("%w: amounts of the %s swap are in order book lots", ErrSkipped, event.Protocol)
+	}
+	if !t.allowed(event.InputMint) || !t.allowed(event.OutputMint) {
+		return nil, fmt.Errorf("%w: %s -> %s is not allowlisted", ErrSkipped, event.InputMint, event.OutputMint)
+	}
+	amountIn := t.amountIn(event)
+	if !amountIn.IsPositive() {
+		return nil, fmt.Errorf("%w: scaled input is zero", ErrSkipped)
+	}
+
+	inputMint, outputMint := event.InputMint.String(), event.OutputMint.String()
+	if t.pair != [2]string{inputMint, outputMint} {
+		if _, err := t.router.QueryAllPools(ctx, inputMint, outputMint); err != nil {
+			return nil, fmt.Errorf("failed to query pools: %w", err)
+		}
+		t.pair = [2]string{inputMint, outputMint}
+	}
+	pool, amountOut, err := t.router.GetBestPool(ctx, t.client, inputMint, amountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote %s: %w", amountIn, err)
+	}
+	route, err := router.NewSingleHopRoute(pool, inputMint, amountIn, amountOut)
+	if err != nil {
+		return nil, err
+	}
+	return t.Executor.Execute(ctx, route, t.signers)
+}
+
+func (t *Trader) allowed(mint solana.PublicKey) bool {
+	if len(t.config.Allowlist) == 0 {
+		return true
+	}
+	_, ok := t.config.Allowlist[mint]
+	return ok
+}
+
+// amountIn scales the target's input and applies the cap of its mint
+func (t *Trader) amountIn(event flow.Event) math.Int {
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(event.AmountIn.BigInt()), big.NewFloat(t.config.Scale)).Int(nil)
+	amount := math.NewIntFromBigInt(scaled)
+	if limit, ok := t.config.MaxAmountIn[event.InputMint]; ok && !limit.IsNil() && amount.GT(limit) {
+		amount = limit
+	}
+	return amount
+}
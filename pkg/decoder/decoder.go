@@ -0,0 +1,118 @@
+// Package decoder parses swap instructions of the supported venues back into
+// their parameters, for auditing bundles before signing and for analyzing
+// other traders' transactions
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/lifinity"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/pool/openbook"
+	"github.com/solana-zh/solroute/pkg/pool/orca"
+	"github.com/solana-zh/solroute/pkg/pool/phoenix"
+	"github.com/solana-zh/solroute/pkg/pool/pump"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+)
+
+// ErrUnknownProgram is returned for instructions of programs without a decoder
+var ErrUnknownProgram = errors.New("no swap decoder for program")
+
+// SwapDecoder parses one program's swap instruction from its accounts and data
+type SwapDecoder func(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error)
+
+var decodersMu sync.RWMutex
+
+var decoders = map[solana.PublicKey]SwapDecoder{
+	raydium.RAYDIUM_AMM_PROGRAM_ID:  raydium.DecodeAMMSwap,
+	raydium.RAYDIUM_CPMM_PROGRAM_ID: raydium.DecodeCPMMSwap,
+	raydium.RAYDIUM_CLMM_PROGRAM_ID: raydium.DecodeCLMMSwap,
+	meteora.MeteoraProgramID:        meteora.DecodeSwap,
+	pump.PumpSwapProgramID:          pump.DecodeSwap,
+	stakepool.ProgramID:             stakepool.DecodeSwap,
+	marinade.ProgramID:              marinade.DecodeSwap,
+	orca.ProgramID:                  orca.DecodeSwap,
+	meteora.DammProgramID:           meteora.DecodeDammSwap,
+	meteora.DammV2ProgramID:         meteora.DecodeDammV2Swap,
+	lifinity.ProgramID:              lifinity.DecodeSwap,
+	openbook.ProgramID:              openbook.DecodeSwap,
+	phoenix.ProgramID:               phoenix.DecodeSwap,
+}
+
+// Register adds the swap decoder of programID, replacing a registered one. It
+// lets venues defined outside this package, such as those of x/venue, be
+// decoded
+func Register(programID solana.PublicKey, decode SwapDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[programID] = decode
+}
+
+func lookup(programID solana.PublicKey) (SwapDecoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	decode, ok := decoders[programID]
+	return decode, ok
+}
+
+// Swap is a swap decoded from a transaction
+type Swap struct {
+	// Index is the position of the instruction in the transaction
+	Index int
+	pkg.SwapParams
+}
+
+// Decode parses a swap instruction of programID. It returns ErrUnknownProgram
+// for programs without a decoder and pkg.ErrNotSwap for their other instructions
+func Decode(programID solana.PublicKey, accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	decode, ok := lookup(programID)
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownProgram, programID)
+	}
+	return decode(accounts, data)
+}
+
+// DecodeInstruction parses a swap instruction, such as one returned by
+// Pool.BuildSwapInstructions
+func DecodeInstruction(instruction solana.Instruction) (*pkg.SwapParams, error) {
+	data, err := instruction.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode instruction: %w", err)
+	}
+	return Decode(instruction.ProgramID(), instruction.Accounts(), data)
+}
+
+// DecodeTransaction returns every swap of a supported venue in tx. Versioned
+// transactions must have their address tables set with Message.SetAddressTables
+// so accounts loaded from lookup tables can be resolved
+func DecodeTransaction(tx *solana.Transaction) ([]Swap, error) {
+	var swaps []Swap
+	for i, instruction := range tx.Message.Instructions {
+		programID, err := tx.ResolveProgramIDIndex(instruction.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: failed to resolve program: %w", i, err)
+		}
+		if _, ok := lookup(programID); !ok {
+			continue
+		}
+		accounts, err := instruction.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: failed to resolve accounts: %w", i, err)
+		}
+		params, err := Decode(programID, accounts, instruction.Data)
+		if errors.Is(err, pkg.ErrNotSwap) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		swaps = append(swaps, Swap{Index: i, SwapParams: *params})
+	}
+	return swaps, nil
+}
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/alert"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+const DefaultMaxConsecutiveRejections = 3
+
+// AlertPolicy configures which execution anomalies are reported to Notifier.
+// Zero thresholds disable the corresponding check
+type AlertPolicy struct {
+	Notifier alert.Notifier
+	// MaxSlippageBps alerts when a fill falls short of its quote by more than this
+	MaxSlippageBps float64
+	// MaxConsecutiveRejections alerts once this many sends in a row are rejected
+	MaxConsecutiveRejections int
+	// MinLamports alerts when the wallet's SOL balance drops below this after a trade
+	MinLamports uint64
+}
+
+// WatchBreaker reports every pool the circuit breaker quarantines
+func (e *Executor) WatchBreaker(breaker *router.CircuitBreaker) {
+	breaker.OnTrip = func(poolID string, backoff time.Duration, err error) {
+		e.notify(context.Background(), alert.NewEvent(alert.KindPoolQuarantined,
+			"pool quarantined after repeated failures", map[string]string{
+				"pool":    poolID,
+				"backoff": backoff.String(),
+				"error":   fmt.Sprint(err),
+			}))
+	}
+}
+
+// reportPools feeds the outcome of an order's swap to the router's circuit
+// breaker, so pools whose swaps fail to build or revert on chain are
+// quarantined like pools that fail to quote. A nil err is a success. A failed
+// multi-hop swap counts against every pool of the route, as the failing hop
+// is not known
+func (e *Executor) reportPools(order *store.Order, err error) {
+	if e.router == nil || e.router.Breaker == nil {
+		return
+	}
+	for _, poolID := range order.Pools {
+		if err == nil {
+			e.router.Breaker.RecordSuccess(poolID)
+		} else {
+			e.router.Breaker.RecordFailure(poolID, err)
+		}
+	}
+}
+
+// recordSendResult tracks consecutive rejected sends and alerts when they pile up
+func (e *Executor) recordSendResult(ctx context.Context, order *store.Order, err error) {
+	if err == nil {
+		e.rejections.Store(0)
+		return
+	}
+	rejections := int(e.rejections.Add(1))
+	limit := e.Alerts.MaxConsecutiveRejections
+	if limit <= 0 {
+		limit = DefaultMaxConsecutiveRejections
+	}
+	if rejections == limit {
+		e.notify(ctx, alert.NewEvent(alert.KindSendRejected,
+			fmt.Sprintf("%d consecutive sends rejected", rejections), map[string]string{
+				"order": order.ID,
+				"error": err.Error(),
+			}))
+	}
+}
+
+// checkFill alerts on slippage breaches and low wallet balances after a confirmed trade
+func (e *Executor) checkFill(ctx context.Context, order *store.Order) {
+	if e.Alerts.MaxSlippageBps > 0 && !order.QuotedAmountOut.IsNil() && order.QuotedAmountOut.IsPositive() &&
+		!order.RealizedAmountOut.IsNil() {
+		quoted, _ := order.QuotedAmountOut.BigInt().Float64()
+		realized, _ := order.RealizedAmountOut.BigInt().Float64()
+		slippage := (quoted - realized) / quoted * 10000
+		if slippage > e.Alerts.MaxSlippageBps {
+			e.notify(ctx, alert.NewEvent(alert.KindSlippageBreach,
+				"fill slipped beyond the configured limit", map[string]string{
+					"order":        order.ID,
+					"signature":    order.Signature,
+					"quoted":       order.QuotedAmountOut.String(),
+					"realized":     order.RealizedAmountOut.String(),
+					"slippage_bps": strconv.FormatFloat(slippage, 'f', 2, 64),
+				}))
+		}
+	}
+
+	if e.Alerts.MinLamports > 0 {
+		wallet, err := solana.PublicKeyFromBase58(order.Wallet)
+		if err != nil {
+			return
+		}
+		balance, err := e.client.GetBalance(ctx, wallet, rpc.CommitmentConfirmed)
+		if err != nil {
+			log.Printf("failed to check balance of %s: %v", order.Wallet, err)
+			return
+		}
+		if balance.Value < e.Alerts.MinLamports {
+			e.notify(ctx, alert.NewEvent(alert.KindLowBalance,
+				"wallet balance below threshold", map[string]string{
+					"wallet":    order.Wallet,
+					"lamports":  strconv.FormatUint(balance.Value, 10),
+					"threshold": strconv.FormatUint(e.Alerts.MinLamports, 10),
+				}))
+		}
+	}
+}
+
+func (e *Executor) notify(ctx context.Context, event alert.Event) {
+	if e.Alerts.Notifier == nil {
+		return
+	}
+	if err := e.Alerts.Notifier.Notify(ctx, event); err != nil {
+		log.Printf("failed to deliver %s alert: %v", event.Kind, err)
+	}
+}
@@ -0,0 +1,350 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+// ErrInsufficientBalance is returned by a basket's pre-flight when the wallet
+// holds less of an input mint than the basket's routes spend together
+var ErrInsufficientBalance = errors.New("insufficient balance for basket")
+
+// BasketMode is how ExecuteBasket lands the routes of a basket
+type BasketMode int
+
+const (
+	// BasketSequential sends one transaction per route in order, each once
+	// the previous one settled; a failed route does not stop the rest
+	BasketSequential BasketMode = iota
+	// BasketBundle sends every route in one Jito bundle tipped once, which
+	// lands all of them or none
+	BasketBundle
+)
+
+// BasketReport is the consolidated outcome of a basket
+type BasketReport struct {
+	// Orders holds one order per route, in route order
+	Orders []*store.Order
+	// BundleID is set when the basket was sent as a bundle
+	BundleID string
+
+	Confirmed int
+	Failed    int
+	// Spent totals AmountIn and Received the realized output per mint over
+	// the confirmed orders
+	Spent    map[string]math.Int
+	Received map[string]math.Int
+	// Fees totals the network fees and tips of the confirmed orders in lamports
+	Fees uint64
+}
+
+// Complete reports whether every route of the basket was confirmed
+func (r *BasketReport) Complete() bool {
+	return r.Failed == 0 && r.Confirmed == len(r.Orders)
+}
+
+// basketLeg is one route of a basket, quoted and built but not yet signed
+type basketLeg struct {
+	route        *router.Route
+	order        *store.Order
+	instructions []solana.Instruction
+	cost         executionCost
+}
+
+// ExecuteBasket swaps along several independent routes for the first signer,
+// as for a rebalance touching several pairs. Every route is quoted and built
+// and the wallet checked to cover all of them together before anything is
+// sent, so a basket the wallet cannot afford fails without a partial fill.
+// The report counts what landed; the error is set when the basket did not
+// complete. Baskets are refused with ErrHalted while the executor is halted
+func (e *Executor) ExecuteBasket(ctx context.Context, routes []*router.Route, signers []sol.Signer, mode BasketMode) (*BasketReport, error) {
+	if err := e.guard(); err != nil {
+		return nil, err
+	}
+	report, err := e.executeBasket(ctx, routes, signers, mode)
+	if report != nil {
+		for _, order := range report.Orders {
+			e.recordOutcome(order)
+		}
+	}
+	return report, err
+}
+
+func (e *Executor) executeBasket(ctx context.Context, routes []*router.Route, signers []sol.Signer, mode BasketMode) (*BasketReport, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("basket has no routes")
+	}
+	if mode == BasketBundle {
+		if !e.client.JitoEnabled() {
+			return nil, sol.ErrJitoUnavailable
+		}
+		if len(routes) >= sol.MaxBundleTransactions {
+			return nil, fmt.Errorf("bundle basket takes at most %d routes, got %d", sol.MaxBundleTransactions-1, len(routes))
+		}
+	}
+	user := signers[0].PublicKey()
+
+	report := &BasketReport{Orders: make([]*store.Order, 0, len(routes))}
+	legs := make([]*basketLeg, 0, len(routes))
+	for i, route := range routes {
+		leg, err := e.prepareLeg(ctx, user, route, len(signers))
+		if leg != nil {
+			report.Orders = append(report.Orders, leg.order)
+			legs = append(legs, leg)
+		}
+		if err != nil {
+			return e.abortBasket(ctx, report, legs, fmt.Errorf("route %d: %w", i, err))
+		}
+	}
+	if mode == BasketBundle {
+		// the bundle carries a single tip transaction
+		if legs[0].cost.Tip == 0 {
+			return e.abortBasket(ctx, report, legs, fmt.Errorf("bundle basket needs a Jito tip within the fee budget"))
+		}
+		for _, leg := range legs[1:] {
+			leg.cost.Tip = 0
+		}
+	}
+	if err := e.basketPreflight(ctx, signers, legs); err != nil {
+		return e.abortBasket(ctx, report, legs, err)
+	}
+
+	var err error
+	if mode == BasketBundle {
+		err = e.sendBundleBasket(ctx, report, legs, signers)
+	} else {
+		err = e.sendSequentialBasket(ctx, legs, signers)
+	}
+	report.tally()
+	if err == nil && !report.Complete() {
+		err = fmt.Errorf("basket confirmed %d of %d routes", report.Confirmed, len(report.Orders))
+	}
+	log.Printf("basket settled: %d confirmed, %d failed, %d lamports in fees", report.Confirmed, report.Failed, report.Fees)
+	return report, err
+}
+
+// prepareLeg quotes and builds one route of a basket and records its order.
+// The leg is returned with its order even when building fails, so the order
+// can be marked failed
+func (e *Executor) prepareLeg(ctx context.Context, user solana.PublicKey, route *router.Route, signers int) (*basketLeg, error) {
+	if err := e.quoteRoute(ctx, user, route); err != nil {
+		return nil, err
+	}
+	if err := e.admit(ctx, route); err != nil {
+		return nil, err
+	}
+	leg := &basketLeg{route: route, order: newOrder(user, route)}
+	if err := e.save(ctx, leg.order); err != nil {
+		return leg, err
+	}
+	instructions, err := e.buildInstructions(ctx, user, route)
+	if err != nil {
+		return leg, fmt.Errorf("failed to build route: %w", err)
+	}
+	leg.instructions = instructions
+	if leg.cost, err = e.planFees(signers); err != nil {
+		return leg, err
+	}
+	return leg, nil
+}
+
+// abortBasket fails the orders of a basket that never sent anything
+func (e *Executor) abortBasket(ctx context.Context, report *BasketReport, legs []*basketLeg, err error) (*BasketReport, error) {
+	for _, leg := range legs {
+		e.fail(ctx, leg.order, err)
+	}
+	report.tally()
+	return report, err
+}
+
+// basketPreflight checks that the wallet holds every input mint in the amount
+// all routes spend together, and lamports for the SOL inputs, the token
+// accounts the routes create and their fees and tips, topping up the
+// lamports from Funding when configured
+func (e *Executor) basketPreflight(ctx context.Context, signers []sol.Signer, legs []*basketLeg) error {
+	payer := signers[0].PublicKey()
+	spend := make(map[string]math.Int)
+	instructions := make([]solana.Instruction, 0)
+	var required uint64
+	for _, leg := range legs {
+		addAmount(spend, leg.route.InputMint(), leg.route.AmountIn)
+		instructions = append(instructions, leg.instructions...)
+		required += leg.cost.Total()
+	}
+
+	for mint, amount := range spend {
+		// routes start from native lamports, checked with the fees below
+		if mint == sol.WSOL.String() {
+			if !amount.IsUint64() {
+				return fmt.Errorf("%w: %s lamports", ErrInsufficientBalance, amount)
+			}
+			required += amount.Uint64()
+			continue
+		}
+		mintKey, err := solana.PublicKeyFromBase58(mint)
+		if err != nil {
+			return fmt.Errorf("invalid input mint %s: %w", mint, err)
+		}
+		_, balance, err := e.client.GetUserTokenBalance(ctx, payer, mintKey)
+		if err != nil {
+			return fmt.Errorf("failed to get %s balance of %s: %w", mint, payer, err)
+		}
+		if amount.GT(math.NewIntFromUint64(balance)) {
+			return fmt.Errorf("%w: %s holds %d of %s, basket spends %s", ErrInsufficientBalance, payer, balance, mint, amount)
+		}
+	}
+
+	missing, err := e.client.MissingTokenAccounts(ctx, instructions)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		rent, err := e.client.GetMinimumBalanceForRentExemption(ctx, sol.TokenAccountSize, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to get token account rent: %w", err)
+		}
+		required += rent * uint64(len(missing))
+	}
+	balance, err := e.client.GetBalance(ctx, payer, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to get balance of %s: %w", payer, err)
+	}
+	if balance.Value >= required {
+		return nil
+	}
+	if e.Funding == nil {
+		return fmt.Errorf("%w: %s has %d, basket needs %d for %d routes and %d accounts",
+			ErrInsufficientLamports, payer, balance.Value, required, len(legs), len(missing))
+	}
+	return e.topUp(ctx, payer, required-balance.Value)
+}
+
+// sendSequentialBasket lands the legs one after another, each signed right
+// before it is sent so its blockhash is fresh. A leg that fails is recorded
+// and the next one sent
+func (e *Executor) sendSequentialBasket(ctx context.Context, legs []*basketLeg, signers []sol.Signer) error {
+	for _, leg := range legs {
+		if err := ctx.Err(); err != nil {
+			e.fail(ctx, leg.order, err)
+			continue
+		}
+		if err := e.checkDeadline(ctx, leg.route); err != nil {
+			e.fail(ctx, leg.order, err)
+			continue
+		}
+		tx, err := e.sign(ctx, signers, append(leg.cost.instructions(), leg.instructions...))
+		if err != nil {
+			e.fail(ctx, leg.order, err)
+			continue
+		}
+		if err := e.markSigned(ctx, leg.order, tx, leg.cost.Tip); err != nil {
+			return err
+		}
+		if leg.cost.Tip > 0 {
+			_, err = e.client.SendBundle(ctx, leg.cost.Tip, signers, tx)
+		} else {
+			_, err = e.client.SendTx(ctx, tx)
+		}
+		e.recordSendResult(ctx, leg.order, err)
+		if err != nil {
+			e.fail(ctx, leg.order, err)
+			continue
+		}
+		leg.order.Status = store.StatusSent
+		if err := e.save(ctx, leg.order); err != nil {
+			return err
+		}
+		if _, err := e.confirm(ctx, leg.order, leg.route, signers, tx); err != nil {
+			log.Printf("basket order %s failed: %v", leg.order.ID, err)
+		}
+	}
+	return nil
+}
+
+// sendBundleBasket signs every leg and sends them as one bundle tipped from
+// the first leg's budget. Should any leg fail to sign or pass its deadline,
+// none is sent
+func (e *Executor) sendBundleBasket(ctx context.Context, report *BasketReport, legs []*basketLeg, signers []sol.Signer) error {
+	txs := make([]*solana.Transaction, 0, len(legs))
+	for i, leg := range legs {
+		tx, err := e.sign(ctx, signers, append(leg.cost.instructions(), leg.instructions...))
+		if err != nil {
+			_, err = e.abortBasket(ctx, report, legs, fmt.Errorf("route %d: %w", i, err))
+			return err
+		}
+		if err := e.markSigned(ctx, leg.order, tx, leg.cost.Tip); err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	}
+	for i, leg := range legs {
+		if err := e.checkDeadline(ctx, leg.route); err != nil {
+			_, err = e.abortBasket(ctx, report, legs, fmt.Errorf("route %d: %w", i, err))
+			return err
+		}
+	}
+
+	bundleID, err := e.client.SendBundleTxs(ctx, legs[0].cost.Tip, signers, txs)
+	e.recordSendResult(ctx, legs[0].order, err)
+	if err != nil {
+		_, err = e.abortBasket(ctx, report, legs, err)
+		return err
+	}
+	report.BundleID = bundleID
+	for _, leg := range legs {
+		leg.order.Status = store.StatusSent
+		if err := e.save(ctx, leg.order); err != nil {
+			return err
+		}
+	}
+	for i, leg := range legs {
+		if _, err := e.confirm(ctx, leg.order, leg.route, signers, txs[i]); err != nil {
+			log.Printf("basket order %s failed: %v", leg.order.ID, err)
+		}
+	}
+	return nil
+}
+
+// tally recomputes the report's totals from its orders
+func (r *BasketReport) tally() {
+	r.Confirmed, r.Failed, r.Fees = 0, 0, 0
+	r.Spent = make(map[string]math.Int)
+	r.Received = make(map[string]math.Int)
+	for _, order := range r.Orders {
+		switch order.Status {
+		case store.StatusConfirmed:
+		case store.StatusFailed:
+			r.Failed++
+			continue
+		default:
+			continue
+		}
+		r.Confirmed++
+		r.Fees += order.Fee + order.Tip
+		addAmount(r.Spent, order.InputMint, order.AmountIn)
+		addAmount(r.Received, order.OutputMint, order.RealizedAmountOut)
+	}
+}
+
+// addAmount adds amount to totals[mint], ignoring unset amounts
+func addAmount(totals map[string]math.Int, mint string, amount math.Int) {
+	if amount.IsNil() {
+		return
+	}
+	if prev, ok := totals[mint]; ok {
+		amount = amount.Add(prev)
+	}
+	totals[mint] = amount
+}
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+// DefaultComputeUnitLimit is requested when a priority fee is set without a limit
+const DefaultComputeUnitLimit = 400_000
+
+// microLamportsPerLamport converts compute unit prices to lamports
+const microLamportsPerLamport = 1_000_000
+
+// ErrOverBudget is returned when a route's estimated execution cost exceeds
+// FeePolicy.MaxLamports and cannot be downgraded to fit
+var ErrOverBudget = errors.New("route execution cost exceeds budget")
+
+// FeePolicy sets what the executor pays to land a route and caps the total
+type FeePolicy struct {
+	// ComputeUnitPrice is the priority fee in micro-lamports per compute unit
+	ComputeUnitPrice uint64
+	// ComputeUnitLimit defaults to DefaultComputeUnitLimit when a price is set
+	ComputeUnitLimit uint32
+	// JitoTip, when set and a Jito client is connected, sends routes as bundles
+	// tipping this many lamports
+	JitoTip uint64
+
+	// MaxLamports caps base fee, priority fee and tip of one route; zero disables the cap
+	MaxLamports uint64
+	// RejectOverBudget rejects routes over MaxLamports instead of dropping the
+	// tip and lowering the priority fee until they fit
+	RejectOverBudget bool
+}
+
+// executionCost is the lamport cost of landing a route, excluding rent
+type executionCost struct {
+	computeUnitLimit uint32
+	computeUnitPrice uint64
+
+	BaseFee     uint64
+	PriorityFee uint64
+	Tip         uint64
+}
+
+func (c executionCost) Total() uint64 {
+	return c.BaseFee + c.PriorityFee + c.Tip
+}
+
+// planFees estimates the cost of a route signed by signers under the fee
+// policy, downgrading or rejecting it when it exceeds the budget
+func (e *Executor) planFees(signers int) (executionCost, error) {
+	policy := e.Fees
+	cost := executionCost{
+		computeUnitLimit: policy.ComputeUnitLimit,
+		computeUnitPrice: policy.ComputeUnitPrice,
+		BaseFee:          baseFeePerSignature * uint64(signers),
+		Tip:              policy.JitoTip,
+	}
+	if cost.computeUnitPrice > 0 && cost.computeUnitLimit == 0 {
+		cost.computeUnitLimit = DefaultComputeUnitLimit
+	}
+	cost.PriorityFee = priorityFee(cost.computeUnitPrice, cost.computeUnitLimit)
+	if cost.Tip > 0 && !e.client.JitoEnabled() {
+		log.Printf("jito is not connected, sending without the %d lamport tip", cost.Tip)
+		cost.Tip = 0
+	}
+
+	budget := policy.MaxLamports
+	if budget == 0 || cost.Total() <= budget {
+		return cost, nil
+	}
+	if policy.RejectOverBudget || cost.BaseFee > budget {
+		return executionCost{}, fmt.Errorf("%w: estimated %d lamports, budget %d", ErrOverBudget, cost.Total(), budget)
+	}
+
+	estimated := cost.Total()
+	// downgrade to a regular send first, then lower the priority fee to fit
+	cost.Tip = 0
+	if cost.Total() > budget {
+		cost.computeUnitPrice = (budget - cost.BaseFee) * microLamportsPerLamport / uint64(cost.computeUnitLimit)
+		cost.PriorityFee = priorityFee(cost.computeUnitPrice, cost.computeUnitLimit)
+	}
+	log.Printf("route estimated at %d lamports exceeds budget %d, downgraded to %d (tip %d, priority fee %d)",
+		estimated, budget, cost.Total(), cost.Tip, cost.PriorityFee)
+	return cost, nil
+}
+
+// instructions returns the compute budget instructions that set the planned priority fee
+func (c executionCost) instructions() []solana.Instruction {
+	if c.computeUnitPrice == 0 {
+		return nil
+	}
+	return []solana.Instruction{
+		computebudget.NewSetComputeUnitLimitInstruction(c.computeUnitLimit).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(c.computeUnitPrice).Build(),
+	}
+}
+
+// priorityFee converts a compute unit price and limit to lamports, rounding up
+func priorityFee(computeUnitPrice uint64, computeUnitLimit uint32) uint64 {
+	microLamports := computeUnitPrice * uint64(computeUnitLimit)
+	return (microLamports + microLamportsPerLamport - 1) / microLamportsPerLamport
+}
@@ -0,0 +1,517 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/alt"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+const (
+	DefaultSlippageBps    = 100
+	DefaultConfirmTimeout = 30 * time.Second
+
+	// baseFeePerSignature is the network fee charged per signature before priority fees
+	baseFeePerSignature = 5000
+	// blockhashLifetime bounds how long a transaction whose last valid block
+	// height is unknown can still land after it was signed
+	blockhashLifetime = 2 * time.Minute
+)
+
+// Executor turns routes into confirmed swaps: it applies slippage, builds and
+// signs the transaction, sends it and measures the realized output. When a
+// Store is set every lifecycle step is recorded, so a restarted process can
+// resume orders that were in flight
+type Executor struct {
+	client *sol.Client
+	router *router.SimpleRouter
+
+	SlippageBps    int
+	MinOutMode     router.MinOutMode
+	ConfirmTimeout time.Duration
+	// Store is optional; nil disables persistence
+	Store store.Store
+	// Alerts reports execution anomalies; a nil Notifier disables alerting
+	Alerts AlertPolicy
+	// Funding, when set, tops up a fee payer short on token account rent
+	Funding *Funding
+	// Fees sets the priority fee, Jito tip and lamport budget of each route
+	Fees FeePolicy
+	// Tables, when set, moves routes too large for a legacy transaction into
+	// a v0 transaction loading their accounts from managed lookup tables
+	Tables *alt.Manager
+	// Reorgs, when set, watches confirmed fills until they finalize
+	Reorgs *ReorgPolicy
+	// SimulatedMinOut, when set, derives the final minimum output from a
+	// simulation of the route rather than its quote
+	SimulatedMinOut *SimulatedMinOut
+	// RoundTrip, when set, refuses routes buying a token that a simulated
+	// small buy and sell back shows cannot be sold
+	RoundTrip *RoundTripCheck
+	// Intents, when set, offers each route to an intent auction first and
+	// executes it directly only when the auction fails
+	Intents *IntentPolicy
+	// Safety, when set, caps the notional executed per window and halts the
+	// executor on kill switches or repeated failures
+	Safety *SafetyPolicy
+	// MaxScheduleWait bounds how long Execute waits for a route's NotBefore
+	// or its pools to open; zero waits as long as ctx allows
+	MaxScheduleWait time.Duration
+
+	rejections atomic.Int64
+	safety     safetyState
+}
+
+// New creates an executor that quotes through r and sends through solClient
+func New(solClient *sol.Client, r *router.SimpleRouter) *Executor {
+	return &Executor{
+		client:         solClient,
+		router:         r,
+		SlippageBps:    DefaultSlippageBps,
+		MinOutMode:     router.MinOutPerHop,
+		ConfirmTimeout: DefaultConfirmTimeout,
+	}
+}
+
+// Execute swaps along route for the first signer, who also pays the fees, and
+// returns the order once it is confirmed or has failed. Routes are refused
+// with ErrHalted while the executor is halted
+func (e *Executor) Execute(ctx context.Context, route *router.Route, signers []sol.Signer) (*store.Order, error) {
+	if err := e.guard(); err != nil {
+		return nil, err
+	}
+	order, err := e.execute(ctx, route, signers)
+	e.recordOutcome(order)
+	return order, err
+}
+
+func (e *Executor) execute(ctx context.Context, route *router.Route, signers []sol.Signer) (*store.Order, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+	user := signers[0].PublicKey()
+
+	if err := e.quoteRoute(ctx, user, route); err != nil {
+		return nil, err
+	}
+	if err := e.admit(ctx, route); err != nil {
+		return nil, err
+	}
+
+	order := newOrder(user, route)
+	if err := e.save(ctx, order); err != nil {
+		return nil, err
+	}
+	if e.Intents != nil {
+		if order, handled, err := e.executeIntent(ctx, order, route, signers); handled {
+			return order, err
+		}
+	}
+
+	instructions, err := e.buildInstructions(ctx, user, route)
+	if err != nil {
+		err = fmt.Errorf("failed to build route: %w", err)
+		e.reportPools(order, err)
+		return e.fail(ctx, order, err)
+	}
+	cost, err := e.planFees(len(signers))
+	if err != nil {
+		return e.fail(ctx, order, err)
+	}
+	if err := e.preflight(ctx, signers, instructions, cost); err != nil {
+		return e.fail(ctx, order, err)
+	}
+	instructions = append(cost.instructions(), instructions...)
+	tx, err := e.sign(ctx, signers, instructions)
+	if err != nil {
+		return e.fail(ctx, order, err)
+	}
+
+	if err := e.markSigned(ctx, order, tx, cost.Tip); err != nil {
+		return nil, err
+	}
+
+	if err := e.checkDeadline(ctx, route); err != nil {
+		return e.fail(ctx, order, err)
+	}
+	if cost.Tip > 0 {
+		_, err = e.client.SendBundle(ctx, cost.Tip, signers, tx)
+	} else {
+		_, err = e.client.SendTx(ctx, tx)
+	}
+	e.recordSendResult(ctx, order, err)
+	if err != nil {
+		return e.fail(ctx, order, err)
+	}
+	order.Status = store.StatusSent
+	if err := e.save(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return e.confirm(ctx, order, route, signers, tx)
+}
+
+// quoteRoute sets the route's minimum outputs for user, quoting only once
+// the route may go so they are current
+func (e *Executor) quoteRoute(ctx context.Context, user solana.PublicKey, route *router.Route) error {
+	if err := e.awaitWindow(ctx, route); err != nil {
+		return err
+	}
+	if e.RoundTrip != nil {
+		if err := e.checkRoundTrip(ctx, user, route); err != nil {
+			return err
+		}
+	}
+	if err := e.router.ApplyMinOut(ctx, e.client, route, e.SlippageBps, e.MinOutMode); err != nil {
+		return fmt.Errorf("failed to quote route: %w", err)
+	}
+	if e.SimulatedMinOut != nil {
+		return e.applySimulatedMinOut(ctx, user, route)
+	}
+	return nil
+}
+
+// markSigned records the order's signature before it is sent, so a crash
+// mid-send can still be resolved
+func (e *Executor) markSigned(ctx context.Context, order *store.Order, tx *solana.Transaction, tip uint64) error {
+	order.Signature = tx.Signatures[0].String()
+	order.LastValidBlockHeight, _ = e.client.LastValidBlockHeight(tx)
+	order.Tip = tip
+	order.Status = store.StatusSigned
+	return e.save(ctx, order)
+}
+
+// confirm waits for a sent order's transaction and records its realized fill
+func (e *Executor) confirm(ctx context.Context, order *store.Order, route *router.Route, signers []sol.Signer, tx *solana.Transaction) (*store.Order, error) {
+	if err := e.client.AwaitConfirmation(ctx, tx.Signatures[0], e.ConfirmTimeout); err != nil {
+		if errors.Is(err, sol.ErrTransactionFailed) {
+			e.reportPools(order, err)
+		}
+		return e.fail(ctx, order, err)
+	}
+	e.reportPools(order, nil)
+	if err := e.settle(ctx, order); err != nil {
+		return nil, err
+	}
+	e.checkFill(ctx, order)
+	if e.Reorgs != nil {
+		e.watchFinality(ctx, order, route, signers)
+	}
+	return order, nil
+}
+
+// sign signs instructions as a legacy transaction, falling back to a v0
+// transaction through the lookup table manager when it exceeds the size limit
+func (e *Executor) sign(ctx context.Context, signers []sol.Signer, instructions []solana.Instruction) (*solana.Transaction, error) {
+	tx, err := e.client.SignTransaction(ctx, signers, instructions...)
+	if err != nil {
+		return nil, err
+	}
+	size, err := sol.TransactionSize(tx)
+	if err != nil {
+		return nil, err
+	}
+	if size <= sol.MaxTransactionSize {
+		return tx, nil
+	}
+	if e.Tables == nil {
+		return nil, fmt.Errorf("transaction is %d bytes, over the %d byte limit", size, sol.MaxTransactionSize)
+	}
+
+	tables, err := e.Tables.Compress(ctx, instructions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare lookup tables: %w", err)
+	}
+	tx, err = e.client.SignTransactionWithTables(ctx, signers, tables, instructions...)
+	if err != nil {
+		return nil, err
+	}
+	if size, err = sol.TransactionSize(tx); err != nil {
+		return nil, err
+	}
+	if size > sol.MaxTransactionSize {
+		return nil, fmt.Errorf("transaction is %d bytes with lookup tables, over the %d byte limit", size, sol.MaxTransactionSize)
+	}
+	return tx, nil
+}
+
+// buildInstructions patches the route's prebuilt instructions when its pair
+// is warm and builds them from scratch otherwise
+func (e *Executor) buildInstructions(ctx context.Context, user solana.PublicKey, route *router.Route) ([]solana.Instruction, error) {
+	if e.router.Warm != nil {
+		instructions, err := e.router.Warm.Instructions(user, route)
+		if err == nil {
+			return instructions, nil
+		}
+		if !errors.Is(err, router.ErrNotWarm) {
+			log.Printf("failed to patch warm route: %v", err)
+		}
+	}
+	return router.BuildRouteInstructionsWithWSOL(ctx, e.client, user, route)
+}
+
+// Resume settles orders left signed or sent by a previous run, marking them
+// confirmed or failed according to their on-chain status. A transaction not
+// found yet may still land, so it is awaited until its blockhash expires
+func (e *Executor) Resume(ctx context.Context) ([]*store.Order, error) {
+	if e.Store == nil {
+		return nil, nil
+	}
+	orders := make([]*store.Order, 0)
+	for _, status := range []store.OrderStatus{store.StatusSigned, store.StatusSent} {
+		pending, err := e.Store.ListOrders(ctx, store.Filter{Status: status})
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, pending...)
+	}
+
+	for _, order := range orders {
+		sig, err := solana.SignatureFromBase58(order.Signature)
+		if err != nil {
+			e.fail(ctx, order, fmt.Errorf("invalid stored signature: %w", err))
+			continue
+		}
+		res, err := e.client.GetSignatureStatuses(ctx, true, sig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of %s: %w", order.Signature, err)
+		}
+		if len(res.Value) == 0 || res.Value[0] == nil {
+			if err := e.awaitUnexpired(ctx, order, sig); err != nil {
+				if ctx.Err() != nil {
+					// still in flight; the next Resume picks it up again
+					return orders, ctx.Err()
+				}
+				e.fail(ctx, order, err)
+				continue
+			}
+		} else if res.Value[0].Err != nil {
+			err := fmt.Errorf("%w: %v", sol.ErrTransactionFailed, res.Value[0].Err)
+			e.reportPools(order, err)
+			e.fail(ctx, order, err)
+			continue
+		}
+		if err := e.settle(ctx, order); err != nil {
+			log.Printf("failed to settle order %s: %v", order.ID, err)
+		}
+	}
+	return orders, nil
+}
+
+// awaitUnexpired waits for the order's transaction to confirm while its
+// blockhash is valid. Without a recorded last valid block height the
+// transaction is given blockhashLifetime from when the order was last saved
+func (e *Executor) awaitUnexpired(ctx context.Context, order *store.Order, sig solana.Signature) error {
+	if order.LastValidBlockHeight > 0 {
+		return e.client.AwaitConfirmationUntil(ctx, sig, order.LastValidBlockHeight)
+	}
+	remaining := time.Until(order.UpdatedAt.Add(blockhashLifetime))
+	if remaining <= 0 {
+		return fmt.Errorf("transaction not found: %w", sol.ErrBlockhashExpired)
+	}
+	err := e.client.AwaitConfirmation(ctx, sig, remaining)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("transaction not found: %w", sol.ErrBlockhashExpired)
+	}
+	return err
+}
+
+// settle reads the confirmed transaction and records the realized output
+func (e *Executor) settle(ctx context.Context, order *store.Order) error {
+	sig, err := solana.SignatureFromBase58(order.Signature)
+	if err != nil {
+		return err
+	}
+	result, err := e.client.GetTransaction(ctx, sig)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction %s: %w", order.Signature, err)
+	}
+	order.Slot = result.Slot
+	if result.Meta != nil {
+		order.Fee = result.Meta.Fee
+		if tx, err := result.Transaction.GetTransaction(); err == nil {
+			baseFee := baseFeePerSignature * uint64(len(tx.Signatures))
+			if order.Fee > baseFee {
+				order.PriorityFee = order.Fee - baseFee
+			}
+		}
+		wallet, err := solana.PublicKeyFromBase58(order.Wallet)
+		if err == nil {
+			order.RealizedAmountOut = realizedAmount(result, wallet, order.OutputMint)
+		}
+		// token output paid to a recipient lands in their account; SOL is
+		// sent on from the wallet and counted as delivered
+		if order.Recipient != "" && order.OutputMint != sol.WSOL.String() {
+			if recipient, err := solana.PublicKeyFromBase58(order.Recipient); err == nil {
+				order.RealizedAmountOut = realizedAmount(result, recipient, order.OutputMint)
+			}
+		}
+	}
+	order.Status = store.StatusConfirmed
+	return e.save(ctx, order)
+}
+
+// fail records err on the order and returns it
+func (e *Executor) fail(ctx context.Context, order *store.Order, err error) (*store.Order, error) {
+	order.Status = store.StatusFailed
+	order.Error = err.Error()
+	if saveErr := e.save(ctx, order); saveErr != nil {
+		log.Printf("failed to save order %s: %v", order.ID, saveErr)
+	}
+	return order, err
+}
+
+func (e *Executor) save(ctx context.Context, order *store.Order) error {
+	order.UpdatedAt = time.Now()
+	if e.Store == nil {
+		return nil
+	}
+	return e.Store.SaveOrder(ctx, order)
+}
+
+func newOrder(user solana.PublicKey, route *router.Route) *store.Order {
+	pools := make([]string, 0, len(route.Hops))
+	var protocolFees map[string]math.Int
+	for _, hop := range route.Hops {
+		pools = append(pools, hop.Pool.GetID())
+		feePool, ok := hop.Pool.(pkg.SwapFeePool)
+		if !ok || hop.AmountIn.IsNil() {
+			continue
+		}
+		if protocolFees == nil {
+			protocolFees = make(map[string]math.Int)
+		}
+		fee := feePool.SwapFee(hop.InputMint, hop.AmountIn)
+		if prev, ok := protocolFees[hop.InputMint]; ok {
+			fee = fee.Add(prev)
+		}
+		protocolFees[hop.InputMint] = fee
+	}
+	var recipient string
+	if !route.Recipient.IsZero() {
+		recipient = route.Recipient.String()
+	}
+	now := time.Now()
+	return &store.Order{
+		ID:              newOrderID(),
+		Status:          store.StatusQuoted,
+		Wallet:          user.String(),
+		Recipient:       recipient,
+		InputMint:       route.InputMint(),
+		OutputMint:      route.OutputMint(),
+		Pools:           pools,
+		AmountIn:        route.AmountIn,
+		QuotedAmountOut: route.AmountOut,
+		MinAmountOut:    route.Hops[len(route.Hops)-1].MinAmountOut,
+		ProtocolFees:    protocolFees,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func newOrderID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// realizedAmount is the wallet's balance change of mint in a confirmed
+// transaction. Output unwrapped from WSOL shows up in the lamport balance
+// instead, less the rent and balance a WSOL account held before the
+// transaction returned on closing, plus SOL delivered to another account
+func realizedAmount(result *rpc.GetTransactionResult, wallet solana.PublicKey, mint string) math.Int {
+	meta := result.Meta
+	delta := math.ZeroInt()
+	found := false
+	for _, balance := range meta.PostTokenBalances {
+		if balance.Owner != nil && balance.Owner.Equals(wallet) && balance.Mint.String() == mint {
+			delta = delta.Add(tokenAmount(balance))
+			found = true
+		}
+	}
+	for _, balance := range meta.PreTokenBalances {
+		if balance.Owner != nil && balance.Owner.Equals(wallet) && balance.Mint.String() == mint {
+			delta = delta.Sub(tokenAmount(balance))
+			found = true
+		}
+	}
+	if mint != sol.WSOL.String() || (found && delta.IsPositive()) {
+		return delta
+	}
+
+	// the fee payer is the first account; add the fee back to isolate the swap
+	if len(meta.PreBalances) == 0 || len(meta.PostBalances) == 0 {
+		return delta
+	}
+	lamports := math.NewIntFromUint64(meta.PostBalances[0]).
+		Sub(math.NewIntFromUint64(meta.PreBalances[0])).
+		Add(math.NewIntFromUint64(meta.Fee))
+	reclaimed, delivered := wsolSettlement(result, wallet)
+	return lamports.Sub(math.NewIntFromUint64(reclaimed)).Add(math.NewIntFromUint64(delivered))
+}
+
+// wsolSettlement reads, from a confirmed transaction, the lamports the
+// wallet's WSOL account held before it was closed, which the wallet gets back
+// without the swap producing them, and the lamports the wallet transferred to
+// accounts other than that WSOL account, which a route delivery sends on
+func wsolSettlement(result *rpc.GetTransactionResult, wallet solana.PublicKey) (reclaimed, delivered uint64) {
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return 0, 0
+	}
+	wsolAccount, _, err := sol.FindAssociatedTokenAddress(wallet, sol.WSOL)
+	if err != nil {
+		return 0, 0
+	}
+	meta := result.Meta
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	keys = append(keys, meta.LoadedAddresses.Writable...)
+	keys = append(keys, meta.LoadedAddresses.ReadOnly...)
+
+	for i, key := range keys {
+		if key.Equals(wsolAccount) && i < len(meta.PreBalances) && i < len(meta.PostBalances) && meta.PostBalances[i] == 0 {
+			reclaimed = meta.PreBalances[i]
+		}
+	}
+	for _, instruction := range tx.Message.Instructions {
+		if int(instruction.ProgramIDIndex) >= len(keys) || !keys[instruction.ProgramIDIndex].Equals(solana.SystemProgramID) {
+			continue
+		}
+		data := instruction.Data
+		if len(instruction.Accounts) < 2 || len(data) < 12 || binary.LittleEndian.Uint32(data) != system.Instruction_Transfer {
+			continue
+		}
+		from, to := int(instruction.Accounts[0]), int(instruction.Accounts[1])
+		if from >= len(keys) || to >= len(keys) || !keys[from].Equals(wallet) || keys[to].Equals(wsolAccount) || keys[to].Equals(wallet) {
+			continue
+		}
+		delivered += binary.LittleEndian.Uint64(data[4:12])
+	}
+	return reclaimed, delivered
+}
+
+func tokenAmount(balance rpc.TokenBalance) math.Int {
+	if balance.UiTokenAmount == nil {
+		return math.ZeroInt()
+	}
+	amount, ok := math.NewIntFromString(balance.UiTokenAmount.Amount)
+	if !ok {
+		return math.ZeroInt()
+	}
+	return amount
+}
@@ -0,0 +1,272 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+// defaultIntentTimeout bounds an auction when IntentPolicy.Timeout is zero
+const defaultIntentTimeout = 3 * time.Second
+
+// ErrIntentUnsupported is returned by intent backends for intents they
+// cannot auction, such as pairs no solver quotes
+var ErrIntentUnsupported = errors.New("intent not supported")
+
+// Intent is a route offered to an auction: solvers compete to pay at least
+// MinAmountOut of OutputMint for AmountIn of InputMint before Deadline.
+// QuotedAmountOut is what the route itself would pay, the price to beat
+type Intent struct {
+	OrderID         string           `json:"order_id"`
+	User            solana.PublicKey `json:"user"`
+	Recipient       solana.PublicKey `json:"recipient"`
+	InputMint       solana.PublicKey `json:"input_mint"`
+	OutputMint      solana.PublicKey `json:"output_mint"`
+	AmountIn        math.Int         `json:"amount_in"`
+	QuotedAmountOut math.Int         `json:"quoted_amount_out"`
+	MinAmountOut    math.Int         `json:"min_amount_out"`
+	Deadline        time.Time        `json:"deadline"`
+}
+
+// IntentFill is the winning bid of an auction. The backend either returns
+// the settlement Transaction, signed by the solver and left for the user to
+// sign and send, or sends the settlement itself and returns its Signature
+type IntentFill struct {
+	AmountOut   math.Int
+	Transaction *solana.Transaction
+	Signature   solana.Signature
+}
+
+// IntentBackend auctions intents, as Pyth Express Relay or Hashflow do. The
+// backend is not trusted: a settlement left for the user to sign is simulated
+// and checked against the intent first, and one it sent itself counts only
+// once it landed paying at least MinAmountOut
+type IntentBackend interface {
+	SubmitIntent(ctx context.Context, intent Intent) (*IntentFill, error)
+}
+
+// IntentPolicy offers orders to an intent backend before executing them
+// directly. An auction that fails, or whose winning bid pays less than the
+// route's minimum output, falls back to the route; once a settlement is sent
+// the order stays with it
+type IntentPolicy struct {
+	Backend IntentBackend
+	// Timeout bounds the auction; zero allows three seconds
+	Timeout time.Duration
+	// NoFallback fails orders whose auction fails instead of executing the
+	// route directly
+	NoFallback bool
+	// Programs are the settlement programs a winning settlement may call
+	// besides the compute budget, token and associated token programs;
+	// settlements calling anything else are refused unsigned
+	Programs []solana.PublicKey
+	// MaxLamports bounds the SOL a settlement may take from the user's wallet
+	// for fees and rent, besides a native SOL input; zero allows 0.01 SOL
+	MaxLamports uint64
+}
+
+// executeIntent offers route to the intent backend. It reports false, with
+// the order untouched, when the route should be executed directly instead
+func (e *Executor) executeIntent(ctx context.Context, order *store.Order, route *router.Route, signers []sol.Signer) (*store.Order, bool, error) {
+	policy := e.Intents
+	fill, intent, err := e.auction(ctx, order, route, signers[0].PublicKey())
+	if err == nil {
+		err = e.signFill(ctx, fill, intent, signers)
+	}
+	if err != nil {
+		if policy.NoFallback {
+			order, err = e.fail(ctx, order, fmt.Errorf("intent auction failed: %w", err))
+			return order, true, err
+		}
+		log.Printf("order %s: intent auction failed, executing directly: %v", order.ID, err)
+		return order, false, nil
+	}
+
+	if fill.Transaction != nil {
+		if err := e.markSigned(ctx, order, fill.Transaction, 0); err != nil {
+			return nil, true, err
+		}
+		_, err = e.client.SendTx(ctx, fill.Transaction)
+		e.recordSendResult(ctx, order, err)
+		if err != nil {
+			order, err = e.fail(ctx, order, err)
+			return order, true, err
+		}
+		fill.Signature = fill.Transaction.Signatures[0]
+	} else {
+		order.Signature = fill.Signature.String()
+	}
+	order.Status = store.StatusSent
+	if err := e.save(ctx, order); err != nil {
+		return nil, true, err
+	}
+
+	if err := e.client.AwaitConfirmation(ctx, fill.Signature, e.ConfirmTimeout); err != nil {
+		order, err = e.fail(ctx, order, err)
+		return order, true, err
+	}
+	if err := e.settle(ctx, order); err != nil {
+		return nil, true, err
+	}
+	// the amount a backend reports is only its claim; the chain has the fill
+	if order.RealizedAmountOut.IsNil() || order.RealizedAmountOut.LT(order.MinAmountOut) {
+		order, err = e.fail(ctx, order, fmt.Errorf("intent settlement paid %s, below the minimum %s", order.RealizedAmountOut, order.MinAmountOut))
+		return order, true, err
+	}
+	e.checkFill(ctx, order)
+	return order, true, nil
+}
+
+// auction submits the order's intent and checks the winning bid beats the
+// route's minimum output
+func (e *Executor) auction(ctx context.Context, order *store.Order, route *router.Route, user solana.PublicKey) (*IntentFill, Intent, error) {
+	timeout := e.Intents.Timeout
+	if timeout <= 0 {
+		timeout = defaultIntentTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if !route.NotAfter.IsZero() && route.NotAfter.Before(deadline) {
+		deadline = route.NotAfter
+	}
+	auctionCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	inputMint, err := solana.PublicKeyFromBase58(order.InputMint)
+	if err != nil {
+		return nil, Intent{}, fmt.Errorf("invalid input mint: %w", err)
+	}
+	outputMint, err := solana.PublicKeyFromBase58(order.OutputMint)
+	if err != nil {
+		return nil, Intent{}, fmt.Errorf("invalid output mint: %w", err)
+	}
+	intent := Intent{
+		OrderID:         order.ID,
+		User:            user,
+		Recipient:       route.Recipient,
+		InputMint:       inputMint,
+		OutputMint:      outputMint,
+		AmountIn:        order.AmountIn,
+		QuotedAmountOut: order.QuotedAmountOut,
+		MinAmountOut:    order.MinAmountOut,
+		Deadline:        deadline,
+	}
+	fill, err := e.Intents.Backend.SubmitIntent(auctionCtx, intent)
+	if err != nil {
+		return nil, intent, err
+	}
+	if fill.Transaction == nil && fill.Signature.IsZero() {
+		return nil, intent, fmt.Errorf("intent fill carries no settlement")
+	}
+	if fill.AmountOut.IsNil() || fill.AmountOut.LT(order.MinAmountOut) {
+		return nil, intent, fmt.Errorf("intent fill of %s is below the minimum %s", fill.AmountOut, order.MinAmountOut)
+	}
+	return fill, intent, nil
+}
+
+// signFill adds the user's signatures to a settlement left for them to send,
+// once it is verified against intent, which must then be fully signed
+func (e *Executor) signFill(ctx context.Context, fill *IntentFill, intent Intent, signers []sol.Signer) error {
+	if fill.Transaction == nil {
+		return nil
+	}
+	if !fill.Transaction.Message.IsSigner(signers[0].PublicKey()) {
+		return fmt.Errorf("intent settlement does not take the user's signature")
+	}
+	if err := e.verifySettlement(ctx, fill.Transaction, intent); err != nil {
+		return fmt.Errorf("intent settlement refused: %w", err)
+	}
+	if err := sol.PartialSignTransaction(fill.Transaction, signers); err != nil {
+		return err
+	}
+	if missing := sol.MissingSigners(fill.Transaction); len(missing) > 0 {
+		return fmt.Errorf("intent settlement still needs %d signatures", len(missing))
+	}
+	return nil
+}
+
+// HTTPIntentBackend posts intents as JSON to an auction endpoint and reads
+// the winning bid back: the amount out with either a base64 settlement
+// transaction or the signature of one already sent. A 204 or 404 answer
+// means the intent is not supported
+type HTTPIntentBackend struct {
+	URL string
+	// Header is added to every request, e.g. an API key
+	Header http.Header
+	Client *http.Client
+}
+
+// NewHTTPIntentBackend creates a backend posting to url
+func NewHTTPIntentBackend(url string) *HTTPIntentBackend {
+	return &HTTPIntentBackend{URL: url}
+}
+
+// httpIntentFill is the endpoint's answer
+type httpIntentFill struct {
+	AmountOut   math.Int `json:"amount_out"`
+	Transaction string   `json:"transaction"`
+	Signature   string   `json:"signature"`
+}
+
+// SubmitIntent posts intent and waits for the auction's answer
+func (b *HTTPIntentBackend) SubmitIntent(ctx context.Context, intent Intent) (*IntentFill, error) {
+	body, err := json.Marshal(intent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode intent: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create intent request: %w", err)
+	}
+	for key, values := range b.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit intent: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound:
+		return nil, ErrIntentUnsupported
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("intent endpoint returned %s", resp.Status)
+	}
+
+	var answer httpIntentFill
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("failed to decode intent fill: %w", err)
+	}
+	fill := &IntentFill{AmountOut: answer.AmountOut}
+	if answer.Transaction != "" {
+		fill.Transaction = new(solana.Transaction)
+		if err := fill.Transaction.UnmarshalBase64(answer.Transaction); err != nil {
+			return nil, fmt.Errorf("failed to decode intent settlement: %w", err)
+		}
+	}
+	if answer.Signature != "" {
+		if fill.Signature, err = solana.SignatureFromBase58(answer.Signature); err != nil {
+			return nil, fmt.Errorf("invalid intent settlement signature: %w", err)
+		}
+	}
+	return fill, nil
+}
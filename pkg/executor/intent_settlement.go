@@ -0,0 +1,214 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// defaultIntentLamports bounds what a settlement may take from the user's
+// wallet in SOL, besides a native SOL input: fees and token account rent
+const defaultIntentLamports = 10_000_000
+
+// settlementPrograms may be invoked by any settlement: they cannot move the
+// user's funds beyond what the balance checks allow
+var settlementPrograms = []solana.PublicKey{
+	solana.ComputeBudget,
+	solana.TokenProgramID,
+	sol.Token2022ProgramID,
+	solana.SPLAssociatedTokenAccountProgramID,
+}
+
+// tokenAccountState is the part of an SPL token account a settlement must
+// leave alone, besides its amount
+type tokenAccountState struct {
+	mint     solana.PublicKey
+	owner    solana.PublicKey
+	amount   uint64
+	delegate []byte
+	closer   []byte
+}
+
+// decodeTokenAccountState reads an SPL token or Token-2022 account, ok is
+// false for anything else
+func decodeTokenAccountState(account *rpc.Account) (tokenAccountState, bool) {
+	if account == nil || account.Data == nil {
+		return tokenAccountState{}, false
+	}
+	if !account.Owner.Equals(solana.TokenProgramID) && !account.Owner.Equals(sol.Token2022ProgramID) {
+		return tokenAccountState{}, false
+	}
+	data := account.Data.GetBinary()
+	if uint64(len(data)) < sol.TokenAccountSize {
+		return tokenAccountState{}, false
+	}
+	return tokenAccountState{
+		mint:     solana.PublicKeyFromBytes(data[0:32]),
+		owner:    solana.PublicKeyFromBytes(data[32:64]),
+		amount:   binary.LittleEndian.Uint64(data[64:72]),
+		delegate: data[72:108],
+		closer:   data[129:165],
+	}, true
+}
+
+// verifySettlement simulates a settlement left for the user to sign and
+// refuses it unless every instruction calls an allowed program and the
+// user's balances move no further than the intent allows: at most AmountIn
+// of InputMint out, at least MinAmountOut of OutputMint in for the user or
+// the intent's recipient, and no other token leaving the user's accounts
+func (e *Executor) verifySettlement(ctx context.Context, tx *solana.Transaction, intent Intent) error {
+	allowed := append(append([]solana.PublicKey{}, settlementPrograms...), e.Intents.Programs...)
+	if err := checkSettlementPrograms(tx, allowed); err != nil {
+		return err
+	}
+
+	if lookups := tx.Message.GetAddressTableLookups(); len(lookups) > 0 {
+		tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(lookups))
+		for _, lookup := range lookups {
+			addresses, err := e.client.GetAddressLookupTable(ctx, lookup.AccountKey)
+			if err != nil {
+				return fmt.Errorf("failed to load settlement lookup table %s: %w", lookup.AccountKey, err)
+			}
+			tables[lookup.AccountKey] = addresses
+		}
+		if err := tx.Message.SetAddressTables(tables); err != nil {
+			return fmt.Errorf("failed to resolve settlement accounts: %w", err)
+		}
+	}
+	keys, err := tx.Message.GetAllKeys()
+	if err != nil {
+		return fmt.Errorf("failed to resolve settlement accounts: %w", err)
+	}
+	writable := make([]solana.PublicKey, 0, len(keys))
+	for _, key := range keys {
+		if ok, err := tx.Message.IsWritable(key); err == nil && ok {
+			writable = append(writable, key)
+		}
+	}
+
+	before, err := e.client.GetMultipleAccountsWithOpts(ctx, writable)
+	if err != nil {
+		return fmt.Errorf("failed to read settlement accounts: %w", err)
+	}
+	res, err := e.client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentProcessed,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: writable,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to simulate intent settlement: %w", err)
+	}
+	if res.Value.Err != nil {
+		return fmt.Errorf("intent settlement simulation failed: %v", res.Value.Err)
+	}
+	if len(before.Value) != len(writable) || len(res.Value.Accounts) != len(writable) {
+		return fmt.Errorf("settlement simulation returned %d of %d accounts", len(res.Value.Accounts), len(writable))
+	}
+	maxLamports := e.Intents.MaxLamports
+	if maxLamports == 0 {
+		maxLamports = defaultIntentLamports
+	}
+	return checkSettlementBalances(intent, maxLamports, writable, before.Value, res.Value.Accounts)
+}
+
+// checkSettlementPrograms refuses a settlement calling a program outside allowed
+func checkSettlementPrograms(tx *solana.Transaction, allowed []solana.PublicKey) error {
+	for i, instruction := range tx.Message.Instructions {
+		program, err := tx.Message.Program(instruction.ProgramIDIndex)
+		if err != nil {
+			return fmt.Errorf("invalid settlement instruction %d: %w", i, err)
+		}
+		if !solana.PublicKeySlice(allowed).Contains(program) {
+			return fmt.Errorf("settlement instruction %d calls program %s, which is not allowed", i, program)
+		}
+	}
+	return nil
+}
+
+// checkSettlementBalances compares the writable accounts of a settlement
+// before and after its simulation against what intent allows
+func checkSettlementBalances(intent Intent, maxLamports uint64, keys []solana.PublicKey, before, after []*rpc.Account) error {
+	receiver := intent.User
+	if !intent.Recipient.IsZero() {
+		receiver = intent.Recipient
+	}
+	spent := make(map[solana.PublicKey]math.Int)
+	received := math.ZeroInt()
+	var lamportsTaken uint64
+	for i, key := range keys {
+		if key.Equals(intent.User) {
+			if lamports(before[i]) > lamports(after[i]) {
+				lamportsTaken = lamports(before[i]) - lamports(after[i])
+			}
+			continue
+		}
+
+		pre, wasToken := decodeTokenAccountState(before[i])
+		post, isToken := decodeTokenAccountState(after[i])
+		if wasToken && pre.owner.Equals(intent.User) {
+			if !isToken || !post.owner.Equals(intent.User) {
+				return fmt.Errorf("settlement closes or reassigns the user's token account %s", key)
+			}
+			if !bytes.Equal(pre.delegate, post.delegate) || !bytes.Equal(pre.closer, post.closer) {
+				return fmt.Errorf("settlement changes the authorities of the user's token account %s", key)
+			}
+			if post.amount < pre.amount {
+				out := math.NewIntFromUint64(pre.amount - post.amount)
+				if prev, ok := spent[pre.mint]; ok {
+					out = out.Add(prev)
+				}
+				spent[pre.mint] = out
+			}
+		}
+		if isToken && post.owner.Equals(receiver) && post.mint.Equals(intent.OutputMint) {
+			var preAmount uint64
+			if wasToken && pre.owner.Equals(receiver) {
+				preAmount = pre.amount
+			}
+			if post.amount > preAmount {
+				received = received.Add(math.NewIntFromUint64(post.amount - preAmount))
+			}
+		}
+	}
+
+	// a native SOL input is paid from the wallet's lamports
+	if lamportsTaken > maxLamports {
+		if !intent.InputMint.Equals(sol.WSOL) {
+			return fmt.Errorf("settlement takes %d lamports from the user's wallet, over the %d allowed", lamportsTaken, maxLamports)
+		}
+		out := math.NewIntFromUint64(lamportsTaken - maxLamports)
+		if prev, ok := spent[sol.WSOL]; ok {
+			out = out.Add(prev)
+		}
+		spent[sol.WSOL] = out
+	}
+	for mint, out := range spent {
+		if !mint.Equals(intent.InputMint) {
+			return fmt.Errorf("settlement takes %s of %s from the user, which the intent does not sell", out, mint)
+		}
+		if out.GT(intent.AmountIn) {
+			return fmt.Errorf("settlement takes %s of %s from the user, over the %s offered", out, mint, intent.AmountIn)
+		}
+	}
+	if received.LT(intent.MinAmountOut) {
+		return fmt.Errorf("settlement pays %s of %s, below the minimum %s", received, intent.OutputMint, intent.MinAmountOut)
+	}
+	return nil
+}
+
+func lamports(account *rpc.Account) uint64 {
+	if account == nil {
+		return 0
+	}
+	return account.Lamports
+}
@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+var (
+	testUser       = solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	testInputMint  = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	testOutputMint = solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB")
+	testOtherMint  = solana.MustPublicKeyFromBase58("DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263")
+)
+
+func testTokenAccount(mint, owner solana.PublicKey, amount uint64, delegate *solana.PublicKey) *rpc.Account {
+	data := make([]byte, sol.TokenAccountSize)
+	copy(data[0:32], mint[:])
+	copy(data[32:64], owner[:])
+	binary.LittleEndian.PutUint64(data[64:72], amount)
+	if delegate != nil {
+		binary.LittleEndian.PutUint32(data[72:76], 1)
+		copy(data[76:108], delegate[:])
+	}
+	data[108] = 1
+	return &rpc.Account{
+		Owner: solana.TokenProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(data),
+	}
+}
+
+func testIntent() Intent {
+	return Intent{
+		User:         testUser,
+		InputMint:    testInputMint,
+		OutputMint:   testOutputMint,
+		AmountIn:     math.NewInt(1_000),
+		MinAmountOut: math.NewInt(900),
+	}
+}
+
+func TestCheckSettlementBalances(t *testing.T) {
+	inputAccount := solana.NewWallet().PublicKey()
+	outputAccount := solana.NewWallet().PublicKey()
+	otherAccount := solana.NewWallet().PublicKey()
+	solver := solana.NewWallet().PublicKey()
+	keys := []solana.PublicKey{testUser, inputAccount, outputAccount, otherAccount}
+	wallet := func(lamports uint64) *rpc.Account {
+		return &rpc.Account{Owner: solana.SystemProgramID, Lamports: lamports}
+	}
+	before := []*rpc.Account{
+		wallet(1_000_000_000),
+		testTokenAccount(testInputMint, testUser, 5_000, nil),
+		testTokenAccount(testOutputMint, testUser, 0, nil),
+		testTokenAccount(testOtherMint, testUser, 7_000, nil),
+	}
+
+	tests := []struct {
+		name  string
+		after []*rpc.Account
+		err   string
+	}{
+		{
+			name: "fair settlement",
+			after: []*rpc.Account{
+				wallet(1_000_000_000 - 5_000),
+				testTokenAccount(testInputMint, testUser, 4_000, nil),
+				testTokenAccount(testOutputMint, testUser, 950, nil),
+				testTokenAccount(testOtherMint, testUser, 7_000, nil),
+			},
+		},
+		{
+			name: "takes more than offered",
+			after: []*rpc.Account{
+				wallet(1_000_000_000),
+				testTokenAccount(testInputMint, testUser, 3_000, nil),
+				testTokenAccount(testOutputMint, testUser, 950, nil),
+				testTokenAccount(testOtherMint, testUser, 7_000, nil),
+			},
+			err: "over the 1000 offered",
+		},
+		{
+			name: "pays below the minimum",
+			after: []*rpc.Account{
+				wallet(1_000_000_000),
+				testTokenAccount(testInputMint, testUser, 4_000, nil),
+				testTokenAccount(testOutputMint, testUser, 899, nil),
+				testTokenAccount(testOtherMint, testUser, 7_000, nil),
+			},
+			err: "below the minimum 900",
+		},
+		{
+			name: "drains another token",
+			after: []*rpc.Account{
+				wallet(1_000_000_000),
+				testTokenAccount(testInputMint, testUser, 4_000, nil),
+				testTokenAccount(testOutputMint, testUser, 950, nil),
+				testTokenAccount(testOtherMint, testUser, 0, nil),
+			},
+			err: "which the intent does not sell",
+		},
+		{
+			name: "approves a delegate",
+			after: []*rpc.Account{
+				wallet(1_000_000_000),
+				testTokenAccount(testInputMint, testUser, 4_000, &solver),
+				testTokenAccount(testOutputMint, testUser, 950, nil),
+				testTokenAccount(testOtherMint, testUser, 7_000, nil),
+			},
+			err: "changes the authorities",
+		},
+		{
+			name: "reassigns an account",
+			after: []*rpc.Account{
+				wallet(1_000_000_000),
+				testTokenAccount(testInputMint, testUser, 4_000, nil),
+				testTokenAccount(testOutputMint, testUser, 950, nil),
+				testTokenAccount(testOtherMint, solver, 7_000, nil),
+			},
+			err: "closes or reassigns",
+		},
+		{
+			name: "takes lamports",
+			after: []*rpc.Account{
+				wallet(500_000_000),
+				testTokenAccount(testInputMint, testUser, 4_000, nil),
+				testTokenAccount(testOutputMint, testUser, 950, nil),
+				testTokenAccount(testOtherMint, testUser, 7_000, nil),
+			},
+			err: "lamports from the user's wallet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSettlementBalances(testIntent(), defaultIntentLamports, keys, before, tt.after)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCheckSettlementBalancesRecipient(t *testing.T) {
+	recipient := solana.NewWallet().PublicKey()
+	inputAccount := solana.NewWallet().PublicKey()
+	outputAccount := solana.NewWallet().PublicKey()
+	keys := []solana.PublicKey{inputAccount, outputAccount}
+	before := []*rpc.Account{
+		testTokenAccount(testInputMint, testUser, 1_000, nil),
+		nil,
+	}
+	after := []*rpc.Account{
+		testTokenAccount(testInputMint, testUser, 0, nil),
+		testTokenAccount(testOutputMint, recipient, 900, nil),
+	}
+
+	intent := testIntent()
+	if err := checkSettlementBalances(intent, defaultIntentLamports, keys, before, after); err == nil {
+		t.Fatal("output paid to someone else counted for the user")
+	}
+	intent.Recipient = recipient
+	if err := checkSettlementBalances(intent, defaultIntentLamports, keys, before, after); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckSettlementPrograms(t *testing.T) {
+	settlementProgram := solana.NewWallet().PublicKey()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(solana.ComputeBudget, nil, []byte{2, 0, 0, 0, 0}),
+		solana.NewInstruction(settlementProgram, solana.AccountMetaSlice{solana.Meta(testUser).SIGNER().WRITE()}, nil),
+	}, solana.Hash{}, solana.TransactionPayer(testUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkSettlementPrograms(tx, settlementPrograms); err == nil {
+		t.Fatal("settlement calling an unknown program was allowed")
+	}
+	allowed := append(append([]solana.PublicKey{}, settlementPrograms...), settlementProgram)
+	if err := checkSettlementPrograms(tx, allowed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ErrInsufficientLamports is returned by pre-flight when the fee payer cannot
+// cover the rent of the token accounts a swap creates plus its fees
+var ErrInsufficientLamports = errors.New("insufficient lamports for token account rent and fees")
+
+// Funding tops up a fee payer that is short on lamports for token account rent
+type Funding struct {
+	Wallet sol.Signer
+	// Buffer is transferred on top of the shortfall so consecutive swaps do
+	// not each need a top-up
+	Buffer uint64
+	// MaxTopUp caps a single transfer; zero means no cap
+	MaxTopUp uint64
+}
+
+// preflight checks that the fee payer can afford the token accounts the
+// instructions create plus the planned fees and tip, topping it up from
+// Funding when configured. Swaps that create no accounts are not checked
+func (e *Executor) preflight(ctx context.Context, signers []sol.Signer, instructions []solana.Instruction, cost executionCost) error {
+	missing, err := e.client.MissingTokenAccounts(ctx, instructions)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	rent, err := e.client.GetMinimumBalanceForRentExemption(ctx, sol.TokenAccountSize, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to get token account rent: %w", err)
+	}
+	required := rent*uint64(len(missing)) + cost.Total()
+
+	payer := signers[0].PublicKey()
+	balance, err := e.client.GetBalance(ctx, payer, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to get balance of %s: %w", payer, err)
+	}
+	if balance.Value >= required {
+		return nil
+	}
+	shortfall := required - balance.Value
+	if e.Funding == nil {
+		return fmt.Errorf("%w: %s has %d, needs %d for %d accounts",
+			ErrInsufficientLamports, payer, balance.Value, required, len(missing))
+	}
+	return e.topUp(ctx, payer, shortfall)
+}
+
+// topUp transfers the shortfall plus the funding buffer to payer and waits
+// for the transfer to confirm
+func (e *Executor) topUp(ctx context.Context, payer solana.PublicKey, shortfall uint64) error {
+	amount := shortfall + e.Funding.Buffer
+	if e.Funding.MaxTopUp > 0 && amount > e.Funding.MaxTopUp {
+		if shortfall > e.Funding.MaxTopUp {
+			return fmt.Errorf("%w: shortfall %d exceeds max top-up %d",
+				ErrInsufficientLamports, shortfall, e.Funding.MaxTopUp)
+		}
+		amount = e.Funding.MaxTopUp
+	}
+
+	transfer, err := system.NewTransferInstruction(amount, e.Funding.Wallet.PublicKey(), payer).ValidateAndBuild()
+	if err != nil {
+		return err
+	}
+	tx, err := e.client.SignTransaction(ctx, []sol.Signer{e.Funding.Wallet}, transfer)
+	if err != nil {
+		return fmt.Errorf("failed to sign top-up: %w", err)
+	}
+	sig, err := e.client.SendTx(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to send top-up: %w", err)
+	}
+	if err := e.client.AwaitConfirmation(ctx, sig, e.ConfirmTimeout); err != nil {
+		return fmt.Errorf("top-up not confirmed: %w", err)
+	}
+	log.Printf("topped up %s with %d lamports from %s", payer, amount, e.Funding.Wallet.PublicKey())
+	return nil
+}
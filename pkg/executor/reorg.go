@@ -0,0 +1,200 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/alert"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+const (
+	DefaultFinalityPollInterval = 2 * time.Second
+	DefaultFinalityTimeout      = 2 * time.Minute
+	// DefaultMissingPolls is how many polls in a row must miss a confirmed
+	// signature before it counts as dropped, riding out lagging RPC nodes
+	DefaultMissingPolls = 3
+)
+
+// ReorgPolicy watches confirmed fills until they finalize. A fill whose
+// transaction disappears, or lands again with an error, was on a dropped fork:
+// the order is marked rolled back, an alert carries the wallet's re-checked
+// balances, and the route is optionally executed again
+type ReorgPolicy struct {
+	PollInterval time.Duration
+	// Timeout bounds how long a fill is watched before it is left as confirmed
+	Timeout time.Duration
+	// MissingPolls is how many polls in a row must miss the signature
+	MissingPolls int
+	// Reexecute runs the route again once the dropped transaction can no
+	// longer land
+	Reexecute bool
+	// OnRollback, when set, receives every rolled back order along with the
+	// replacement order and error of its re-execution, if any
+	OnRollback func(rolledBack, replacement *store.Order, err error)
+}
+
+// watchFinality follows a copy of order in the background until it
+// finalizes, rolls back or the policy's timeout passes
+func (e *Executor) watchFinality(ctx context.Context, confirmed *store.Order, route *router.Route, signers []sol.Signer) {
+	order := *confirmed
+	policy := *e.Reorgs
+	if policy.PollInterval <= 0 {
+		policy.PollInterval = DefaultFinalityPollInterval
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = DefaultFinalityTimeout
+	}
+	if policy.MissingPolls <= 0 {
+		policy.MissingPolls = DefaultMissingPolls
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), policy.Timeout)
+		defer cancel()
+		err := e.awaitFinality(ctx, &order, policy)
+		if err == nil || !errors.Is(err, errRolledBack) {
+			if err != nil {
+				log.Printf("stopped watching order %s before finality: %v", order.ID, err)
+			}
+			return
+		}
+		e.rollBack(ctx, &order, route, signers, policy, err)
+	}()
+}
+
+var errRolledBack = errors.New("transaction rolled back")
+
+// awaitFinality polls the order's signature until it is finalized. It returns
+// an error wrapping errRolledBack when the transaction left the cluster's view
+// or came back failed
+func (e *Executor) awaitFinality(ctx context.Context, order *store.Order, policy ReorgPolicy) error {
+	sig, err := solana.SignatureFromBase58(order.Signature)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(policy.PollInterval)
+	defer ticker.Stop()
+	missing := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		res, err := e.client.GetSignatureStatuses(ctx, false, sig)
+		if err != nil {
+			continue
+		}
+		if len(res.Value) == 0 || res.Value[0] == nil {
+			missing++
+			if missing >= policy.MissingPolls {
+				return fmt.Errorf("%w: %s no longer found after confirming in slot %d", errRolledBack, order.Signature, order.Slot)
+			}
+			continue
+		}
+		missing = 0
+		status := res.Value[0]
+		if status.Err != nil {
+			return fmt.Errorf("%w: %s landed again in slot %d and failed: %v", errRolledBack, order.Signature, status.Slot, status.Err)
+		}
+		if status.Slot != order.Slot {
+			log.Printf("order %s moved from slot %d to %d", order.ID, order.Slot, status.Slot)
+			order.Slot = status.Slot
+			if err := e.save(ctx, order); err != nil {
+				log.Printf("failed to save order %s: %v", order.ID, err)
+			}
+		}
+		if status.ConfirmationStatus == rpc.ConfirmationStatusFinalized {
+			return nil
+		}
+	}
+}
+
+// rollBack records a dropped fill, alerts with the wallet's current balances
+// and re-executes the route when the policy asks for it
+func (e *Executor) rollBack(ctx context.Context, order *store.Order, route *router.Route, signers []sol.Signer, policy ReorgPolicy, cause error) {
+	// a dropped transaction whose blockhash is still valid may land on the
+	// surviving fork; only once it cannot is the fill really gone
+	sig, _ := solana.SignatureFromBase58(order.Signature)
+	if err := e.client.AwaitConfirmation(ctx, sig, 0); err == nil {
+		log.Printf("order %s landed again after a rollback", order.ID)
+		if err := e.settle(ctx, order); err != nil {
+			log.Printf("failed to settle order %s: %v", order.ID, err)
+		}
+		return
+	}
+
+	order.Status = store.StatusRolledBack
+	order.Error = cause.Error()
+	order.RealizedAmountOut = math.ZeroInt()
+	if err := e.save(ctx, order); err != nil {
+		log.Printf("failed to save order %s: %v", order.ID, err)
+	}
+
+	fields := map[string]string{
+		"order":     order.ID,
+		"signature": order.Signature,
+		"slot":      strconv.FormatUint(order.Slot, 10),
+		"error":     cause.Error(),
+	}
+	for mint, balance := range e.walletBalances(ctx, order) {
+		fields["balance_"+mint] = balance
+	}
+	e.notify(ctx, alert.NewEvent(alert.KindRollback, "confirmed fill was rolled back by a fork switch", fields))
+
+	var replacement *store.Order
+	var err error
+	if policy.Reexecute {
+		// the watch deadline must not cut the new execution short
+		replacement, err = e.Execute(context.WithoutCancel(ctx), route, signers)
+		if err != nil {
+			log.Printf("failed to re-execute rolled back order %s: %v", order.ID, err)
+		}
+	}
+	if policy.OnRollback != nil {
+		policy.OnRollback(order, replacement, err)
+	}
+}
+
+// walletBalances re-reads the wallet's input and output balances in raw units
+func (e *Executor) walletBalances(ctx context.Context, order *store.Order) map[string]string {
+	wallet, err := solana.PublicKeyFromBase58(order.Wallet)
+	if err != nil {
+		return nil
+	}
+	balances := make(map[string]string)
+	for _, mint := range []string{order.InputMint, order.OutputMint} {
+		if mint == sol.WSOL.String() {
+			balance, err := e.client.GetBalance(ctx, wallet, rpc.CommitmentFinalized)
+			if err != nil {
+				log.Printf("failed to check balance of %s: %v", order.Wallet, err)
+				continue
+			}
+			balances[mint] = strconv.FormatUint(balance.Value, 10)
+			continue
+		}
+		mintKey, err := solana.PublicKeyFromBase58(mint)
+		if err != nil {
+			continue
+		}
+		_, balance, err := e.client.GetUserTokenBalance(ctx, wallet, mintKey)
+		if err != nil {
+			log.Printf("failed to check %s balance of %s: %v", mint, order.Wallet, err)
+			continue
+		}
+		balances[mint] = strconv.FormatUint(balance, 10)
+	}
+	return balances
+}
@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+// ExecutionReport is the outcome of executing a route: the quote it was sent
+// with next to what it filled for and cost, by value so it can be logged or
+// returned from a service without reading the store
+type ExecutionReport struct {
+	OrderID string            `json:"order_id"`
+	Status  store.OrderStatus `json:"status"`
+	Wallet  string            `json:"wallet"`
+	Quote   router.RouteQuote `json:"quote"`
+	// RealizedAmountOut is what was received, nil until the order confirmed
+	RealizedAmountOut math.Int `json:"realized_amount_out"`
+	// ShortfallBps is how far RealizedAmountOut fell below the quoted output,
+	// negative when it beat it; zero until the order confirmed
+	ShortfallBps int64 `json:"shortfall_bps"`
+
+	Signature string `json:"signature,omitempty"`
+	Slot      uint64 `json:"slot,omitempty"`
+	// Fee, PriorityFee and Tip are in lamports as on the order
+	Fee          uint64              `json:"fee"`
+	PriorityFee  uint64              `json:"priority_fee"`
+	Tip          uint64              `json:"tip"`
+	ProtocolFees map[string]math.Int `json:"protocol_fees,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// NewExecutionReport describes the order executing route
+func NewExecutionReport(route *router.Route, order *store.Order) *ExecutionReport {
+	report := &ExecutionReport{
+		OrderID:           order.ID,
+		Status:            order.Status,
+		Wallet:            order.Wallet,
+		Quote:             route.Quote(),
+		RealizedAmountOut: order.RealizedAmountOut,
+		Signature:         order.Signature,
+		Slot:              order.Slot,
+		Fee:               order.Fee,
+		PriorityFee:       order.PriorityFee,
+		Tip:               order.Tip,
+		ProtocolFees:      order.ProtocolFees,
+		Error:             order.Error,
+	}
+	quoted := order.QuotedAmountOut
+	if order.Status == store.StatusConfirmed && !order.RealizedAmountOut.IsNil() && !quoted.IsNil() && quoted.IsPositive() {
+		report.ShortfallBps = quoted.Sub(order.RealizedAmountOut).MulRaw(10000).Quo(quoted).Int64()
+	}
+	return report
+}
+
+// ExecuteReport is Execute returning an ExecutionReport. A route that failed
+// after its order was created returns both the report and the error
+func (e *Executor) ExecuteReport(ctx context.Context, route *router.Route, signers []sol.Signer) (*ExecutionReport, error) {
+	order, err := e.Execute(ctx, route, signers)
+	if order == nil {
+		return nil, err
+	}
+	return NewExecutionReport(route, order), err
+}
@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// defaultRoundTripProbeBps is the share of the route's input probed when
+// RoundTripCheck.ProbeBps is zero
+const defaultRoundTripProbeBps = 100
+
+// RoundTripCheck refuses routes buying a token that a simulated small buy and
+// sell back through the same pool shows cannot be sold, or sells back at too
+// great a loss. Only routes of one hop are checked, for tokens bought rather
+// than sold for SOL
+type RoundTripCheck struct {
+	// ProbeBps is the share of the route's input spent on the probe; zero
+	// probes with 1%
+	ProbeBps int
+	// MaxLossBps is the round trip loss tolerated, fees of both legs included
+	MaxLossBps int
+}
+
+// checkRoundTrip runs the round trip check on route for user, returning an
+// error wrapping router.ErrHoneypot for tokens failing it
+func (e *Executor) checkRoundTrip(ctx context.Context, user solana.PublicKey, route *router.Route) error {
+	policy := e.RoundTrip
+	probeBps := policy.ProbeBps
+	if probeBps == 0 {
+		probeBps = defaultRoundTripProbeBps
+	}
+	if probeBps < 0 || probeBps > 10000 {
+		return fmt.Errorf("invalid round trip probe %d bps", probeBps)
+	}
+	if len(route.Hops) != 1 {
+		log.Printf("skipping round trip check of a %d hop route", len(route.Hops))
+		return nil
+	}
+	hop := route.Hops[0]
+	if hop.OutputMint == sol.WSOL.String() {
+		return nil
+	}
+
+	probe := hop.AmountIn.MulRaw(int64(probeBps)).QuoRaw(10000)
+	if !probe.IsPositive() {
+		return fmt.Errorf("round trip probe of %s is empty", hop.AmountIn)
+	}
+	result, err := e.router.SimulateRoundTrip(ctx, e.client, hop.Pool, user, hop.InputMint, probe)
+	if errors.Is(err, router.ErrOutputNotMeasurable) {
+		log.Printf("skipping round trip check: %v", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to simulate round trip: %w", err)
+	}
+	return result.Check(policy.MaxLossBps)
+}
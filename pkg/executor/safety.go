@@ -0,0 +1,218 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/alert"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/pkg/store"
+)
+
+// ErrHalted is returned by Execute and ExecuteBasket while the executor is
+// halted, whether by Halt, a kill switch or the consecutive failure limit
+var ErrHalted = errors.New("executor halted")
+
+// ErrNotionalLimit is returned for routes whose SOL notional would take a
+// window of the safety policy past its limit
+var ErrNotionalLimit = errors.New("notional limit reached")
+
+// NotionalLimit caps the SOL notional of the orders admitted within any
+// trailing Window
+type NotionalLimit struct {
+	Window      time.Duration
+	MaxLamports uint64
+}
+
+// SafetyPolicy bounds what a misbehaving strategy can do through the
+// executor. Zero values disable the corresponding guard
+type SafetyPolicy struct {
+	// Limits caps the notional per window, e.g. one limit per minute and one
+	// per hour. Orders count from the moment they are admitted, whether they
+	// land or not
+	Limits []NotionalLimit
+	// Value prices amount of mint in lamports, for routes that neither
+	// spend, receive nor pass through SOL. Without it such routes are
+	// refused while Limits are set
+	Value func(ctx context.Context, mint string, amount math.Int) (uint64, error)
+	// MaxConsecutiveFailures halts the executor once this many orders in a
+	// row fail
+	MaxConsecutiveFailures int
+	// KillFile halts the executor while a file exists at this path
+	KillFile string
+	// Kill halts the executor for good once it is done, e.g. a context from
+	// signal.NotifyContext
+	Kill context.Context
+}
+
+// notionalEntry is the notional of one admitted order
+type notionalEntry struct {
+	at       time.Time
+	lamports uint64
+}
+
+// safetyState is the executor's record of halts, failures and admitted notional
+type safetyState struct {
+	mu       sync.Mutex
+	halted   string
+	failures int
+	admitted []notionalEntry
+}
+
+// Halt stops the executor from starting orders until ClearHalt; orders
+// already under way carry on
+func (e *Executor) Halt(reason string) {
+	e.safety.mu.Lock()
+	already := e.safety.halted != ""
+	if !already {
+		e.safety.halted = reason
+	}
+	e.safety.mu.Unlock()
+	if already {
+		return
+	}
+	log.Printf("executor halted: %s", reason)
+	e.notify(context.Background(), alert.NewEvent(alert.KindHalted, "executor halted", map[string]string{
+		"reason": reason,
+	}))
+}
+
+// ClearHalt lifts a halt and resets the consecutive failure count. A kill
+// file still present or a done Kill context halts the executor again
+func (e *Executor) ClearHalt() {
+	e.safety.mu.Lock()
+	defer e.safety.mu.Unlock()
+	e.safety.halted = ""
+	e.safety.failures = 0
+}
+
+// Halted returns the reason the executor is halted, and false when it is not
+func (e *Executor) Halted() (string, bool) {
+	e.checkKillSwitches()
+	e.safety.mu.Lock()
+	defer e.safety.mu.Unlock()
+	return e.safety.halted, e.safety.halted != ""
+}
+
+// checkKillSwitches halts the executor when a kill switch of the safety
+// policy is set
+func (e *Executor) checkKillSwitches() {
+	policy := e.Safety
+	if policy == nil {
+		return
+	}
+	if policy.Kill != nil && policy.Kill.Err() != nil {
+		e.Halt(fmt.Sprintf("kill context done: %v", context.Cause(policy.Kill)))
+	}
+	if policy.KillFile != "" {
+		if _, err := os.Stat(policy.KillFile); err == nil {
+			e.Halt(fmt.Sprintf("kill file %s present", policy.KillFile))
+		}
+	}
+}
+
+// guard refuses new orders while the executor is halted
+func (e *Executor) guard() error {
+	if reason, halted := e.Halted(); halted {
+		return fmt.Errorf("%w: %s", ErrHalted, reason)
+	}
+	return nil
+}
+
+// admit counts route against the notional limits, refusing it when a window
+// would go past its limit
+func (e *Executor) admit(ctx context.Context, route *router.Route) error {
+	policy := e.Safety
+	if policy == nil || len(policy.Limits) == 0 {
+		return nil
+	}
+	lamports, err := e.notional(ctx, route)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotionalLimit, err)
+	}
+
+	e.safety.mu.Lock()
+	defer e.safety.mu.Unlock()
+	now := time.Now()
+	var longest time.Duration
+	for _, limit := range policy.Limits {
+		longest = max(longest, limit.Window)
+	}
+	kept := e.safety.admitted[:0]
+	for _, entry := range e.safety.admitted {
+		if now.Sub(entry.at) < longest {
+			kept = append(kept, entry)
+		}
+	}
+	e.safety.admitted = kept
+
+	for _, limit := range policy.Limits {
+		total := lamports
+		for _, entry := range e.safety.admitted {
+			if now.Sub(entry.at) < limit.Window {
+				total += entry.lamports
+			}
+		}
+		if total > limit.MaxLamports {
+			return fmt.Errorf("%w: %d lamports within %s, over the %d limit", ErrNotionalLimit, total, limit.Window, limit.MaxLamports)
+		}
+	}
+	e.safety.admitted = append(e.safety.admitted, notionalEntry{at: now, lamports: lamports})
+	return nil
+}
+
+// notional is the route's value in lamports: its SOL leg when it spends,
+// receives or passes through SOL, and the policy's Value of its input
+// otherwise
+func (e *Executor) notional(ctx context.Context, route *router.Route) (uint64, error) {
+	wsol := sol.WSOL.String()
+	for _, hop := range route.Hops {
+		amount := hop.AmountOut
+		if hop.InputMint == wsol {
+			amount = hop.AmountIn
+		} else if hop.OutputMint != wsol {
+			continue
+		}
+		if amount.IsNil() || !amount.IsUint64() {
+			return 0, fmt.Errorf("route's SOL amount %s is out of range", amount)
+		}
+		return amount.Uint64(), nil
+	}
+	if e.Safety.Value == nil {
+		return 0, fmt.Errorf("route from %s has no SOL leg to value", route.InputMint())
+	}
+	lamports, err := e.Safety.Value(ctx, route.InputMint(), route.AmountIn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to value %s of %s: %w", route.AmountIn, route.InputMint(), err)
+	}
+	return lamports, nil
+}
+
+// recordOutcome counts a failed order towards the consecutive failure limit,
+// halting the executor once it is reached, and resets the count on a
+// confirmed one
+func (e *Executor) recordOutcome(order *store.Order) {
+	if order == nil || (order.Status != store.StatusConfirmed && order.Status != store.StatusFailed) {
+		return
+	}
+	e.safety.mu.Lock()
+	if order.Status == store.StatusConfirmed {
+		e.safety.failures = 0
+	} else {
+		e.safety.failures++
+	}
+	failures := e.safety.failures
+	e.safety.mu.Unlock()
+
+	if order.Status == store.StatusFailed && e.Safety != nil &&
+		e.Safety.MaxConsecutiveFailures > 0 && failures >= e.Safety.MaxConsecutiveFailures {
+		e.Halt(fmt.Sprintf("%d consecutive orders failed", failures))
+	}
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/solana-zh/solroute/pkg/router"
+)
+
+// scheduled reports whether route carries a time constraint, sparing routes
+// without one the clock read
+func scheduled(route *router.Route) bool {
+	return !route.NotAfter.IsZero() || !route.Earliest().IsZero()
+}
+
+// awaitWindow blocks until the cluster time enters the route's execution
+// window. Waits longer than MaxScheduleWait, when set, are rejected instead
+func (e *Executor) awaitWindow(ctx context.Context, route *router.Route) error {
+	if !scheduled(route) {
+		return nil
+	}
+	for {
+		now, err := e.client.ChainTime(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read cluster time: %w", err)
+		}
+		wait, err := route.CheckWindow(now)
+		if err != nil || wait == 0 {
+			return err
+		}
+		if e.MaxScheduleWait > 0 && wait > e.MaxScheduleWait {
+			return fmt.Errorf("route opens in %s, beyond the %s schedule limit", wait.Round(time.Second), e.MaxScheduleWait)
+		}
+		log.Printf("route scheduled: waiting %s for cluster time %s", wait.Round(time.Millisecond), route.Earliest().UTC().Format(time.RFC3339))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// checkDeadline returns router.ErrRouteExpired when the route's NotAfter has
+// passed, checked again right before sending
+func (e *Executor) checkDeadline(ctx context.Context, route *router.Route) error {
+	if route.NotAfter.IsZero() {
+		return nil
+	}
+	now, err := e.client.ChainTime(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster time: %w", err)
+	}
+	_, err = route.CheckWindow(now)
+	return err
+}
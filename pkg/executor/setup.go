@@ -0,0 +1,173 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/router"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultProbeAmount is the input, in raw units of the base mint, of the swaps
+// built to collect a pair's pool accounts
+const DefaultProbeAmount = 1_000_000
+
+// PairSetup is a pair to prepare before its first trade
+type PairSetup struct {
+	BaseMint  string
+	QuoteMint string
+	// Pools, when set and the executor has Tables, have the accounts of a
+	// swap through each of them loaded into a lookup table up front
+	Pools []pkg.Pool
+	// ProbeAmount sizes the swaps built to collect pool accounts; zero means
+	// DefaultProbeAmount
+	ProbeAmount math.Int
+}
+
+// PairReadiness reports what a pair setup found and did
+type PairReadiness struct {
+	// Accounts maps each mint to the signer's token account for it
+	Accounts map[string]solana.PublicKey
+	// Created lists the token accounts the setup transaction created
+	Created []solana.PublicKey
+	// Signature is the setup transaction, empty when nothing was missing
+	Signature string
+	// Tables lists the lookup tables holding the pools' accounts
+	Tables []solana.PublicKey
+	// Ready is true once every account exists and, when pool tables were
+	// asked for, they are warm
+	Ready bool
+}
+
+// PreparePair creates the first signer's token accounts for both mints of
+// setup, including the WSOL account when SOL is one side, in one transaction,
+// and warms a lookup table with the pools' accounts when Tables is set. It
+// saves the first trade of the pair the account creation and table latency
+func (e *Executor) PreparePair(ctx context.Context, signers []sol.Signer, setup PairSetup) (*PairReadiness, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+	user := signers[0].PublicKey()
+
+	mints := make([]solana.PublicKey, 0, 2)
+	for _, mint := range []string{setup.BaseMint, setup.QuoteMint} {
+		key, err := solana.PublicKeyFromBase58(mint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mint %q: %w", mint, err)
+		}
+		mints = append(mints, key)
+	}
+	if mints[0].Equals(mints[1]) {
+		return nil, fmt.Errorf("pair needs two different mints, got %s twice", mints[0])
+	}
+
+	creates, accounts, err := e.tokenAccountCreates(ctx, user, mints)
+	if err != nil {
+		return nil, err
+	}
+	readiness := &PairReadiness{Accounts: accounts}
+
+	missing, err := e.client.MissingTokenAccounts(ctx, creates)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		tx, err := e.sign(ctx, signers, creates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign setup transaction: %w", err)
+		}
+		sig, err := e.client.SendTx(ctx, tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send setup transaction: %w", err)
+		}
+		readiness.Signature = sig.String()
+		if err := e.client.AwaitConfirmation(ctx, sig, e.ConfirmTimeout); err != nil {
+			return readiness, fmt.Errorf("setup transaction %s did not confirm: %w", sig, err)
+		}
+		readiness.Created = missing
+		log.Printf("created %d token accounts for %s/%s in %s", len(missing), setup.BaseMint, setup.QuoteMint, sig)
+	}
+
+	if e.Tables != nil && len(setup.Pools) > 0 {
+		tables, err := e.warmPairTables(ctx, user, setup)
+		if err != nil {
+			return readiness, fmt.Errorf("failed to warm lookup tables: %w", err)
+		}
+		readiness.Tables = tables
+	}
+	readiness.Ready = true
+	return readiness, nil
+}
+
+// tokenAccountCreates returns idempotent create instructions for the user's
+// token accounts of mints, each derived under the program owning its mint
+func (e *Executor) tokenAccountCreates(ctx context.Context, user solana.PublicKey, mints []solana.PublicKey) ([]solana.Instruction, map[string]solana.PublicKey, error) {
+	results, err := e.client.GetMultipleAccountsWithOpts(ctx, mints)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch mints: %w", err)
+	}
+
+	creates := make([]solana.Instruction, 0, len(mints))
+	accounts := make(map[string]solana.PublicKey, len(mints))
+	for i, mint := range mints {
+		if i >= len(results.Value) || results.Value[i] == nil {
+			return nil, nil, fmt.Errorf("mint %s not found", mint)
+		}
+		tokenProgram := results.Value[i].Owner
+		if !tokenProgram.Equals(solana.TokenProgramID) && !tokenProgram.Equals(sol.Token2022ProgramID) {
+			return nil, nil, fmt.Errorf("account %s is not a mint: owned by %s", mint, tokenProgram)
+		}
+		create, err := sol.NewCreateATAIdempotentInstructionWithProgram(user, user, mint, tokenProgram)
+		if err != nil {
+			return nil, nil, err
+		}
+		ata, _, err := sol.FindAssociatedTokenAddressWithProgram(user, mint, tokenProgram)
+		if err != nil {
+			return nil, nil, err
+		}
+		creates = append(creates, create)
+		accounts[mint.String()] = ata
+	}
+	return creates, accounts, nil
+}
+
+// warmPairTables builds a probe swap through each pool of setup and loads the
+// accounts they touch into the executor's lookup tables
+func (e *Executor) warmPairTables(ctx context.Context, user solana.PublicKey, setup PairSetup) ([]solana.PublicKey, error) {
+	amountIn := setup.ProbeAmount
+	if amountIn.IsNil() || !amountIn.IsPositive() {
+		amountIn = math.NewInt(DefaultProbeAmount)
+	}
+
+	instructions := make([]solana.Instruction, 0)
+	for _, pool := range setup.Pools {
+		amountOut, err := pool.Quote(ctx, e.client, setup.BaseMint, amountIn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to quote pool %s: %w", pool.GetID(), err)
+		}
+		route, err := router.NewSingleHopRoute(pool, setup.BaseMint, amountIn, amountOut)
+		if err != nil {
+			return nil, err
+		}
+		route.Hops[0].MinAmountOut = amountOut
+		poolInstructions, err := router.BuildRouteInstructionsWithWSOL(ctx, e.client, user, route)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, poolInstructions...)
+	}
+
+	tables, err := e.Tables.Compress(ctx, instructions)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]solana.PublicKey, 0, len(tables))
+	for address := range tables {
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
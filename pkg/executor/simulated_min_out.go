@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/router"
+)
+
+// SimulatedMinOut derives a route's final minimum output from a simulation of
+// the route instead of its quote, for venues whose local math is slightly off.
+// The quote still bounds the downside: the simulated output must reach the
+// quote less MaxShortfallBps, and the minimum never drops below that floor
+type SimulatedMinOut struct {
+	// BufferBps is taken off the simulated output
+	BufferBps int
+	// MaxShortfallBps is how far the simulated output may fall below the
+	// quote; zero uses the executor's SlippageBps
+	MaxShortfallBps int
+}
+
+// applySimulatedMinOut simulates route for user with its final minimum output
+// at the quote floor and sets that minimum to the simulated output less the
+// buffer. Routes whose output cannot be measured keep their quoted minimum
+func (e *Executor) applySimulatedMinOut(ctx context.Context, user solana.PublicKey, route *router.Route) error {
+	policy := e.SimulatedMinOut
+	if policy.BufferBps < 0 || policy.BufferBps >= 10000 {
+		return fmt.Errorf("invalid simulation buffer %d bps", policy.BufferBps)
+	}
+	maxShortfallBps := policy.MaxShortfallBps
+	if maxShortfallBps == 0 {
+		maxShortfallBps = e.SlippageBps
+	}
+	if maxShortfallBps < 0 || maxShortfallBps >= 10000 {
+		return fmt.Errorf("invalid max shortfall %d bps", maxShortfallBps)
+	}
+
+	last := &route.Hops[len(route.Hops)-1]
+	// an exact output is the trade itself, not a bound to tune
+	if exact, ok := last.Pool.(pkg.ExactOutputPool); ok && exact.MinOutIsExact(last.InputMint) {
+		return nil
+	}
+	quoted := last.MinAmountOut
+	floor := router.ApplySlippage(route.AmountOut, maxShortfallBps)
+	last.MinAmountOut = floor
+
+	result, err := e.router.SimulateRouteOutput(ctx, e.client, route, user)
+	if errors.Is(err, router.ErrOutputNotMeasurable) {
+		log.Printf("keeping quoted min out: %v", err)
+		last.MinAmountOut = quoted
+		return nil
+	}
+	if err != nil {
+		last.MinAmountOut = quoted
+		return fmt.Errorf("failed to simulate route: %w", err)
+	}
+	if result.AmountOut.LT(floor) {
+		last.MinAmountOut = quoted
+		return fmt.Errorf("simulated output %s is below %s, the quote %s less %d bps",
+			result.AmountOut, floor, route.AmountOut, maxShortfallBps)
+	}
+
+	minOut := router.ApplySlippage(result.AmountOut, policy.BufferBps)
+	if minOut.LT(floor) {
+		minOut = floor
+	}
+	last.MinAmountOut = minOut
+	return nil
+}
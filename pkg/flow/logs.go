@@ -0,0 +1,116 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// maxSeenSignatures bounds the signatures remembered to deliver a
+// transaction touching several accounts once
+const maxSeenSignatures = 10_000
+
+// LogsSource is a Source built on standard RPC: it follows the logs that
+// mention each account over websocket and fetches the confirmed transactions.
+// It sees swaps only after they land; use a Geyser or shred feed to see them
+// earlier
+type LogsSource struct {
+	client     *sol.Client
+	wsEndpoint string
+
+	mu   sync.Mutex
+	seen map[solana.Signature]struct{}
+}
+
+// NewLogsSource creates a logs source on the given RPC client and websocket endpoint
+func NewLogsSource(solClient *sol.Client, wsEndpoint string) *LogsSource {
+	return &LogsSource{
+		client:     solClient,
+		wsEndpoint: wsEndpoint,
+		seen:       make(map[solana.Signature]struct{}),
+	}
+}
+
+// Stream delivers the successful transactions mentioning accounts until ctx
+// is cancelled or a subscription fails
+func (s *LogsSource) Stream(ctx context.Context, accounts []solana.PublicKey, handle func(Transaction)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	client, err := ws.Connect(ctx, s.wsEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer client.Close()
+
+	errCh := make(chan error, 1)
+	for _, account := range accounts {
+		sub, err := client.LogsSubscribeMentions(account, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", account, err)
+		}
+		go func() {
+			defer sub.Unsubscribe()
+			for {
+				result, err := sub.Recv(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						select {
+						case errCh <- err:
+						default:
+						}
+					}
+					return
+				}
+				if result.Value.Err != nil || !s.markSeen(result.Value.Signature) {
+					continue
+				}
+				tx, err := s.fetch(ctx, result.Value.Signature)
+				if err != nil {
+					log.Printf("flow: %v", err)
+					continue
+				}
+				handle(tx)
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (s *LogsSource) fetch(ctx context.Context, sig solana.Signature) (Transaction, error) {
+	result, err := s.client.GetTransaction(ctx, sig)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to fetch transaction %s: %w", sig, err)
+	}
+	tx, err := result.Transaction.GetTransaction()
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to decode transaction %s: %w", sig, err)
+	}
+	return Transaction{Signature: sig, Slot: result.Slot, Tx: tx, Meta: result.Meta}, nil
+}
+
+// markSeen records sig and reports whether it is new
+func (s *LogsSource) markSeen(sig solana.Signature) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[sig]; ok {
+		return false
+	}
+	if len(s.seen) >= maxSeenSignatures {
+		s.seen = make(map[solana.Signature]struct{})
+	}
+	s.seen[sig] = struct{}{}
+	return true
+}
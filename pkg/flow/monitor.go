@@ -0,0 +1,279 @@
+// Package flow watches the swaps other traders send to a set of pools, so
+// strategies can react to large inbound flow before or right after it lands
+package flow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/alert"
+	"github.com/solana-zh/solroute/pkg/decoder"
+)
+
+// Transaction is one transaction delivered by a Source
+type Transaction struct {
+	Signature solana.Signature
+	Slot      uint64
+	Tx        *solana.Transaction
+	// Meta is nil for transactions seen before execution, e.g. from a
+	// mempool or shred feed
+	Meta *rpc.TransactionMeta
+}
+
+// Pending reports whether the transaction was seen before it executed
+func (t Transaction) Pending() bool {
+	return t.Meta == nil
+}
+
+// Source streams transactions that may touch the watched accounts. Geyser,
+// shred and mempool feeds implement it; the Monitor does the filtering, so a
+// source may deliver more than it was asked for. handle may be called
+// concurrently
+type Source interface {
+	Stream(ctx context.Context, accounts []solana.PublicKey, handle func(Transaction)) error
+}
+
+// Event is a swap on a watched pool
+type Event struct {
+	Signature solana.Signature
+	Slot      uint64
+	Pending   bool
+	decoder.Swap
+	// Large is set when the input reached the threshold of its mint
+	Large bool
+}
+
+// Monitor decodes the transactions of a Source, keeps the swaps that touch a
+// watched pool or are sent by a watched wallet and emits them to subscribers,
+// alerting on large ones
+type Monitor struct {
+	source  Source
+	pools   map[solana.PublicKey]struct{}
+	wallets map[solana.PublicKey]struct{}
+
+	// MinAmountIn is the input, per input mint, from which a swap is large.
+	// Swaps whose input mint is unknown or has no threshold are never large
+	MinAmountIn map[solana.PublicKey]math.Int
+	// Notifier, when set, receives an alert for every large swap
+	Notifier alert.Notifier
+
+	subsMu      sync.Mutex
+	subscribers map[int]chan Event
+	nextSub     int
+}
+
+// NewMonitor creates a monitor of pools fed by source
+func NewMonitor(source Source, pools ...solana.PublicKey) *Monitor {
+	return newMonitor(source, keySet(pools), keySet(nil))
+}
+
+// NewWalletMonitor creates a monitor of the swaps sent by wallets on any
+// pool, fed by source
+func NewWalletMonitor(source Source, wallets ...solana.PublicKey) *Monitor {
+	return newMonitor(source, keySet(nil), keySet(wallets))
+}
+
+func newMonitor(source Source, pools, wallets map[solana.PublicKey]struct{}) *Monitor {
+	return &Monitor{
+		source:      source,
+		pools:       pools,
+		wallets:     wallets,
+		MinAmountIn: make(map[solana.PublicKey]math.Int),
+		subscribers: make(map[int]chan Event),
+	}
+}
+
+func keySet(keys []solana.PublicKey) map[solana.PublicKey]struct{} {
+	set := make(map[solana.PublicKey]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set
+}
+
+// Run streams from the source until ctx is cancelled or the source fails
+func (m *Monitor) Run(ctx context.Context) error {
+	accounts := make([]solana.PublicKey, 0, len(m.pools)+len(m.wallets))
+	for pool := range m.pools {
+		accounts = append(accounts, pool)
+	}
+	for wallet := range m.wallets {
+		accounts = append(accounts, wallet)
+	}
+	return m.source.Stream(ctx, accounts, func(tx Transaction) {
+		m.Handle(ctx, tx)
+	})
+}
+
+// Subscribe streams swap events. Events are dropped for subscribers whose
+// buffer is full; call the returned function to unsubscribe
+func (m *Monitor) Subscribe(buffer int) (<-chan Event, func()) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	id := m.nextSub
+	m.nextSub++
+	ch := make(chan Event, buffer)
+	m.subscribers[id] = ch
+	return ch, func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		if _, ok := m.subscribers[id]; ok {
+			delete(m.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// Handle decodes one transaction and emits its swaps on watched pools. It is
+// called by Run, and can be called directly by feeds that push transactions
+func (m *Monitor) Handle(ctx context.Context, tx Transaction) {
+	if tx.Tx == nil {
+		return
+	}
+	if tx.Meta != nil {
+		if err := resolveLoadedAddresses(tx.Tx, tx.Meta.LoadedAddresses); err != nil {
+			log.Printf("flow: %s: %v", tx.Signature, err)
+			return
+		}
+	}
+	swaps, err := decoder.DecodeTransaction(tx.Tx)
+	if err != nil {
+		log.Printf("flow: failed to decode %s: %v", tx.Signature, err)
+		return
+	}
+
+	for _, swap := range swaps {
+		if !m.watches(swap) {
+			continue
+		}
+		if tx.Meta != nil {
+			fillMints(tx.Tx, tx.Meta, &swap)
+		}
+		event := Event{
+			Signature: tx.Signature,
+			Slot:      tx.Slot,
+			Pending:   tx.Pending(),
+			Swap:      swap,
+			Large:     m.isLarge(swap),
+		}
+		if event.Large {
+			m.notify(ctx, event)
+		}
+		m.emit(event)
+	}
+}
+
+func (m *Monitor) watches(swap decoder.Swap) bool {
+	if _, ok := m.pools[swap.Pool]; ok {
+		return true
+	}
+	_, ok := m.wallets[swap.User]
+	return ok
+}
+
+func (m *Monitor) isLarge(swap decoder.Swap) bool {
+	threshold, ok := m.MinAmountIn[swap.InputMint]
+	return ok && !swap.InputMint.IsZero() && !swap.AmountsInLots && !threshold.IsNil() && swap.AmountIn.GTE(threshold)
+}
+
+func (m *Monitor) notify(ctx context.Context, event Event) {
+	if m.Notifier == nil {
+		return
+	}
+	err := m.Notifier.Notify(ctx, alert.NewEvent(alert.KindLargeFlow,
+		"large swap on a watched account", map[string]string{
+			"signature":  event.Signature.String(),
+			"protocol":   string(event.Protocol),
+			"pool":       event.Pool.String(),
+			"user":       event.User.String(),
+			"input_mint": event.InputMint.String(),
+			"amount_in":  event.AmountIn.String(),
+			"pending":    fmt.Sprint(event.Pending),
+		}))
+	if err != nil {
+		log.Printf("flow: failed to send alert: %v", err)
+	}
+}
+
+func (m *Monitor) emit(event Event) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// resolveLoadedAddresses rebuilds the address tables of a versioned
+// transaction from the addresses its execution loaded, which list the
+// writable lookups of every table first and then the readonly ones
+func resolveLoadedAddresses(tx *solana.Transaction, loaded rpc.LoadedAddresses) error {
+	lookups := tx.Message.AddressTableLookups
+	if len(lookups) == 0 || tx.Message.IsResolved() || tx.Message.GetAddressTables() != nil {
+		return nil
+	}
+
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice)
+	place := func(table solana.PublicKey, index uint8, key solana.PublicKey) {
+		entries := tables[table]
+		for len(entries) <= int(index) {
+			entries = append(entries, solana.PublicKey{})
+		}
+		entries[index] = key
+		tables[table] = entries
+	}
+	writable, readonly := loaded.Writable, loaded.ReadOnly
+	for _, lookup := range lookups {
+		if len(writable) < len(lookup.WritableIndexes) {
+			return fmt.Errorf("loaded addresses do not cover the writable lookups")
+		}
+		for i, index := range lookup.WritableIndexes {
+			place(lookup.AccountKey, index, writable[i])
+		}
+		writable = writable[len(lookup.WritableIndexes):]
+	}
+	for _, lookup := range lookups {
+		if len(readonly) < len(lookup.ReadonlyIndexes) {
+			return fmt.Errorf("loaded addresses do not cover the readonly lookups")
+		}
+		for i, index := range lookup.ReadonlyIndexes {
+			place(lookup.AccountKey, index, readonly[i])
+		}
+		readonly = readonly[len(lookup.ReadonlyIndexes):]
+	}
+	return tx.Message.SetAddressTables(tables)
+}
+
+// fillMints sets the mints a decoder could not read from the instruction,
+// using the token balances recorded for the user's accounts
+func fillMints(tx *solana.Transaction, meta *rpc.TransactionMeta, swap *decoder.Swap) {
+	if !swap.InputMint.IsZero() && !swap.OutputMint.IsZero() {
+		return
+	}
+	keys, err := tx.Message.GetAllKeys()
+	if err != nil {
+		return
+	}
+	mints := make(map[solana.PublicKey]solana.PublicKey)
+	for _, balances := range [][]rpc.TokenBalance{meta.PreTokenBalances, meta.PostTokenBalances} {
+		for _, balance := range balances {
+			if int(balance.AccountIndex) < len(keys) {
+				mints[keys[balance.AccountIndex]] = balance.Mint
+			}
+		}
+	}
+	if swap.InputMint.IsZero() {
+		swap.InputMint = mints[swap.UserInputAccount]
+	}
+	if swap.OutputMint.IsZero() {
+		swap.OutputMint = mints[swap.UserOutputAccount]
+	}
+}
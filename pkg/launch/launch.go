@@ -0,0 +1,330 @@
+// Package launch creates a token end to end: the mint, its Metaplex metadata,
+// the creator's initial supply and a seeded Raydium CPMM or Pump AMM pool
+package launch
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/layout"
+	"github.com/solana-zh/solroute/pkg/pool/pump"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// mintAccountSize is the data size of an SPL token mint
+const mintAccountSize = 82
+
+// Config describes a token launch
+type Config struct {
+	// Mint is the keypair of the new mint, e.g. a vanity address from
+	// utils.FindKeyPairWithPrefix; nil generates a random one
+	Mint     solana.PrivateKey
+	Decimals uint8
+	// Supply is minted to the creator's associated token account
+	Supply   uint64
+	Metadata Metadata
+	// RevokeMintAuthority fixes the supply once it is minted
+	RevokeMintAuthority bool
+
+	// Pool seeds a pool with part of the supply; nil skips pool creation
+	Pool *PoolConfig
+}
+
+// PoolConfig describes the pool a launch is seeded into
+type PoolConfig struct {
+	// Protocol is pkg.ProtocolNameRaydiumCpmm or pkg.ProtocolNamePumpAmm
+	Protocol  pkg.ProtocolName
+	QuoteMint solana.PublicKey
+	// BaseAmount of the new token and QuoteAmount of the quote mint are deposited.
+	// A WSOL quote is wrapped from the creator's SOL
+	BaseAmount  uint64
+	QuoteAmount uint64
+	// AmmConfig selects the CPMM fee tier; zero uses the default tier
+	AmmConfig solana.PublicKey
+	// Index distinguishes several Pump AMM pools of one creator on a pair
+	Index uint16
+}
+
+// Step is one transaction of a launch
+type Step struct {
+	Name         string
+	Instructions []solana.Instruction
+	Signers      []solana.PrivateKey
+
+	// Signature is set once the step is sent
+	Signature solana.Signature
+	// Size is the serialized size of the signed transaction
+	Size int
+	// Simulated is set when a dry run simulated the step. Later steps depend
+	// on accounts earlier ones create, so only the first step can be simulated
+	Simulated     bool
+	UnitsConsumed uint64
+	Logs          []string
+}
+
+// Result is the outcome of a launch
+type Result struct {
+	Mint     solana.PublicKey
+	Metadata solana.PublicKey
+	// CreatorAccount holds the minted supply
+	CreatorAccount solana.PublicKey
+	// Pool is zero when no pool was configured
+	Pool   solana.PublicKey
+	Steps  []*Step
+	DryRun bool
+}
+
+// Launcher runs token launches
+type Launcher struct {
+	client *sol.Client
+	// DryRun builds and signs every step and simulates the first one without
+	// sending anything
+	DryRun bool
+}
+
+// NewLauncher creates a launcher sending through solClient
+func NewLauncher(solClient *sol.Client) *Launcher {
+	return &Launcher{client: solClient}
+}
+
+// Launch creates the token described by config with creator paying for and
+// owning everything. Steps run in order, each waiting for the previous one
+// to confirm; on failure the result lists the steps already sent
+func (l *Launcher) Launch(ctx context.Context, creator solana.PrivateKey, config Config) (*Result, error) {
+	if config.Mint == nil {
+		mint, err := solana.NewRandomPrivateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate mint keypair: %w", err)
+		}
+		config.Mint = mint
+	}
+	result, err := l.Plan(ctx, creator, config)
+	if err != nil {
+		return nil, err
+	}
+
+	blockhash, err := l.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockhash: %w", err)
+	}
+	for i, step := range result.Steps {
+		tx, err := sol.SignTransactionWithBlockhash(blockhash.Value.Blockhash, sol.PrivateKeySigners(step.Signers...), step.Instructions...)
+		if err != nil {
+			return result, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return result, fmt.Errorf("step %s: failed to serialize: %w", step.Name, err)
+		}
+		step.Size = len(raw)
+
+		if l.DryRun {
+			if i == 0 {
+				if err := l.simulate(ctx, step, tx); err != nil {
+					return result, err
+				}
+			}
+			continue
+		}
+
+		if i > 0 {
+			// the first blockhash may be close to expiry after waiting on earlier steps
+			tx, err = l.client.SignTransaction(ctx, sol.PrivateKeySigners(step.Signers...), step.Instructions...)
+			if err != nil {
+				return result, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+		}
+		step.Signature, err = l.client.SendTx(ctx, tx)
+		if err != nil {
+			return result, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		if err := l.client.AwaitConfirmation(ctx, step.Signature, sol.DefaultConfirmTimeout); err != nil {
+			return result, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		log.Printf("launch step %s confirmed: %s", step.Name, step.Signature)
+	}
+	return result, nil
+}
+
+// Plan builds the launch steps without signing or sending them
+func (l *Launcher) Plan(ctx context.Context, creator solana.PrivateKey, config Config) (*Result, error) {
+	if config.Mint == nil {
+		return nil, fmt.Errorf("mint keypair is required")
+	}
+	if err := config.Metadata.validate(); err != nil {
+		return nil, err
+	}
+	if config.Pool != nil && config.Pool.BaseAmount > config.Supply {
+		return nil, fmt.Errorf("pool base amount %d exceeds supply %d", config.Pool.BaseAmount, config.Supply)
+	}
+
+	owner := creator.PublicKey()
+	mint := config.Mint.PublicKey()
+	result := &Result{Mint: mint, DryRun: l.DryRun}
+
+	rent, err := l.client.GetMinimumBalanceForRentExemption(ctx, mintAccountSize, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mint rent: %w", err)
+	}
+	mintStep, err := mintInstructions(owner, mint, rent, config)
+	if err != nil {
+		return nil, err
+	}
+	result.Steps = append(result.Steps, &Step{
+		Name:         "mint",
+		Instructions: mintStep,
+		Signers:      []solana.PrivateKey{creator, config.Mint},
+	})
+	if result.Metadata, err = FindMetadataAddress(mint); err != nil {
+		return nil, err
+	}
+	if result.CreatorAccount, _, err = sol.FindAssociatedTokenAddress(owner, mint); err != nil {
+		return nil, err
+	}
+
+	if config.Pool != nil {
+		poolStep, pool, err := poolInstructions(owner, mint, *config.Pool)
+		if err != nil {
+			return nil, err
+		}
+		if err := layout.Validate(poolStep); err != nil {
+			return nil, err
+		}
+		result.Pool = pool
+		result.Steps = append(result.Steps, &Step{
+			Name:         "pool",
+			Instructions: poolStep,
+			Signers:      []solana.PrivateKey{creator},
+		})
+	}
+	return result, nil
+}
+
+// mintInstructions creates and initializes the mint, attaches its metadata
+// and mints the supply to the creator
+func mintInstructions(owner, mint solana.PublicKey, rent uint64, config Config) ([]solana.Instruction, error) {
+	createAccount, err := system.NewCreateAccountInstruction(rent, mintAccountSize, solana.TokenProgramID, owner, mint).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	initMint, err := token.NewInitializeMint2Instruction(config.Decimals, owner, solana.PublicKey{}, mint).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := NewCreateMetadataInstruction(owner, mint, owner, config.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	instructions := []solana.Instruction{createAccount, initMint, metadata}
+	if config.Supply == 0 {
+		return instructions, nil
+	}
+
+	createATA, err := sol.NewCreateATAIdempotentInstruction(owner, owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	ata, _, err := sol.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return nil, err
+	}
+	mintTo, err := token.NewMintToInstruction(config.Supply, mint, ata, owner, nil).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	instructions = append(instructions, createATA, mintTo)
+	if config.RevokeMintAuthority {
+		revoke, err := token.NewSetAuthorityInstruction(token.AuthorityMintTokens, solana.PublicKey{}, mint, owner, nil).ValidateAndBuild()
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, revoke)
+	}
+	return instructions, nil
+}
+
+// poolInstructions creates the pool with the new token as base, wrapping a
+// WSOL quote beforehand and returning the leftover afterwards
+func poolInstructions(owner, mint solana.PublicKey, config PoolConfig) ([]solana.Instruction, solana.PublicKey, error) {
+	instructions := make([]solana.Instruction, 0)
+	wrapped := config.QuoteMint.Equals(sol.WSOL)
+	if wrapped {
+		wrap, err := sol.WrapSolInstructions(owner, config.QuoteAmount)
+		if err != nil {
+			return nil, solana.PublicKey{}, err
+		}
+		instructions = append(instructions, wrap...)
+	}
+
+	var create solana.Instruction
+	var pool solana.PublicKey
+	switch config.Protocol {
+	case pkg.ProtocolNameRaydiumCpmm:
+		instruction, addresses, err := raydium.NewCPMMInitializeInstruction(raydium.CPMMCreateParams{
+			Creator:   owner,
+			AmmConfig: config.AmmConfig,
+			MintA:     mint,
+			MintB:     config.QuoteMint,
+			AmountA:   config.BaseAmount,
+			AmountB:   config.QuoteAmount,
+		})
+		if err != nil {
+			return nil, solana.PublicKey{}, err
+		}
+		create, pool = instruction, addresses.Pool
+	case pkg.ProtocolNamePumpAmm:
+		instruction, addresses, err := pump.NewCreatePoolInstruction(pump.CreatePoolParams{
+			Creator:       owner,
+			Index:         config.Index,
+			BaseMint:      mint,
+			QuoteMint:     config.QuoteMint,
+			BaseAmountIn:  config.BaseAmount,
+			QuoteAmountIn: config.QuoteAmount,
+			CoinCreator:   owner,
+		})
+		if err != nil {
+			return nil, solana.PublicKey{}, err
+		}
+		create, pool = instruction, addresses.Pool
+	default:
+		return nil, solana.PublicKey{}, fmt.Errorf("pool creation is not supported on %s", config.Protocol)
+	}
+	instructions = append(instructions, create)
+
+	if wrapped {
+		unwrap, err := sol.UnwrapSolInstruction(owner)
+		if err != nil {
+			return nil, solana.PublicKey{}, err
+		}
+		instructions = append(instructions, unwrap)
+	}
+	return instructions, pool, nil
+}
+
+// simulate runs step against current chain state and records the outcome
+func (l *Launcher) simulate(ctx context.Context, step *Step, tx *solana.Transaction) error {
+	res, err := l.client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentProcessed,
+	})
+	if err != nil {
+		return fmt.Errorf("step %s: failed to simulate: %w", step.Name, err)
+	}
+	step.Simulated = true
+	step.Logs = res.Value.Logs
+	if res.Value.UnitsConsumed != nil {
+		step.UnitsConsumed = *res.Value.UnitsConsumed
+	}
+	if res.Value.Err != nil {
+		return fmt.Errorf("step %s: simulation failed: %v", step.Name, res.Value.Err)
+	}
+	return nil
+}
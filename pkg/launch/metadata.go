@@ -0,0 +1,106 @@
+package launch
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MetadataProgramID is the Metaplex Token Metadata program
+var MetadataProgramID = solana.MustPublicKeyFromBase58("metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s")
+
+const (
+	createMetadataAccountV3 = 33
+
+	maxNameLength   = 32
+	maxSymbolLength = 10
+	maxURILength    = 200
+)
+
+// Metadata is the on-chain name, symbol and off-chain JSON URI of a token
+type Metadata struct {
+	Name   string
+	Symbol string
+	URI    string
+	// SellerFeeBps only matters to NFT marketplaces and is usually zero for fungible tokens
+	SellerFeeBps uint16
+	// Mutable lets the update authority change the metadata later
+	Mutable bool
+}
+
+func (m Metadata) validate() error {
+	if m.Name == "" || len(m.Name) > maxNameLength {
+		return fmt.Errorf("metadata name must be 1-%d bytes", maxNameLength)
+	}
+	if len(m.Symbol) > maxSymbolLength {
+		return fmt.Errorf("metadata symbol must be at most %d bytes", maxSymbolLength)
+	}
+	if len(m.URI) > maxURILength {
+		return fmt.Errorf("metadata uri must be at most %d bytes", maxURILength)
+	}
+	return nil
+}
+
+// FindMetadataAddress derives the metadata account of mint
+func FindMetadataAddress(mint solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{
+		[]byte("metadata"), MetadataProgramID[:], mint[:],
+	}, MetadataProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive metadata PDA: %w", err)
+	}
+	return address, nil
+}
+
+// NewCreateMetadataInstruction builds a CreateMetadataAccountV3 instruction
+// for mint. authority must be the mint authority and becomes the update authority
+func NewCreateMetadataInstruction(payer, mint, authority solana.PublicKey, metadata Metadata) (solana.Instruction, error) {
+	if err := metadata.validate(); err != nil {
+		return nil, err
+	}
+	address, err := FindMetadataAddress(mint)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{createMetadataAccountV3}
+	data = appendString(data, metadata.Name)
+	data = appendString(data, metadata.Symbol)
+	data = appendString(data, metadata.URI)
+	data = binary.LittleEndian.AppendUint16(data, metadata.SellerFeeBps)
+	data = append(data,
+		0, // creators: None
+		0, // collection: None
+		0, // uses: None
+		boolByte(metadata.Mutable),
+		0, // collection_details: None
+	)
+
+	return solana.NewInstruction(
+		MetadataProgramID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(address, true, false),
+			solana.NewAccountMeta(mint, false, false),
+			solana.NewAccountMeta(authority, false, true),
+			solana.NewAccountMeta(payer, true, true),
+			solana.NewAccountMeta(authority, false, true),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+		},
+		data,
+	), nil
+}
+
+// appendString appends a borsh string: a u32 length followed by the bytes
+func appendString(data []byte, s string) []byte {
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(s)))
+	return append(data, s...)
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
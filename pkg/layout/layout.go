@@ -0,0 +1,132 @@
+// Package layout checks built instructions against the account layouts their
+// programs expect, so a misplaced or mis-flagged account is caught before a
+// transaction is signed rather than as an opaque on-chain failure
+package layout
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// ErrLayoutMismatch is returned when an instruction's accounts do not match its template
+var ErrLayoutMismatch = errors.New("instruction accounts do not match layout")
+
+// Role is the expected role of one account of an instruction
+type Role struct {
+	Name     string
+	Writable bool
+	Signer   bool
+	// Address pins accounts that are always the same, such as programs; zero means any
+	Address solana.PublicKey
+}
+
+// Template is the account layout of one instruction, identified by its
+// program and data prefix
+type Template struct {
+	Name      string
+	ProgramID solana.PublicKey
+	// Prefix is the leading instruction data, e.g. an Anchor discriminator
+	Prefix   []byte
+	Accounts []Role
+	// MinAccounts allows trailing Accounts to be omitted, as for optional
+	// accounts; zero requires all of them
+	MinAccounts int
+	// Remaining is the role of accounts past Accounts, such as tick or bin
+	// arrays; nil rejects extra accounts
+	Remaining *Role
+}
+
+// Matches reports whether the template describes instruction
+func (t *Template) Matches(instruction solana.Instruction) bool {
+	if !instruction.ProgramID().Equals(t.ProgramID) {
+		return false
+	}
+	data, err := instruction.Data()
+	return err == nil && bytes.HasPrefix(data, t.Prefix)
+}
+
+// Check compares the accounts of instruction with the template. An account may
+// be writable where the template does not require it, but never read-only
+// where it must be writable, and signers must match exactly
+func (t *Template) Check(instruction solana.Instruction) error {
+	accounts := instruction.Accounts()
+	minAccounts := t.MinAccounts
+	if minAccounts == 0 {
+		minAccounts = len(t.Accounts)
+	}
+	if len(accounts) < minAccounts {
+		return fmt.Errorf("%w: %s has %d accounts, expected at least %d", ErrLayoutMismatch, t.Name, len(accounts), minAccounts)
+	}
+	if len(accounts) > len(t.Accounts) && t.Remaining == nil {
+		return fmt.Errorf("%w: %s has %d accounts, expected at most %d", ErrLayoutMismatch, t.Name, len(accounts), len(t.Accounts))
+	}
+
+	for i, meta := range accounts {
+		role := t.Remaining
+		if i < len(t.Accounts) {
+			role = &t.Accounts[i]
+		}
+		if role.Writable && !meta.IsWritable {
+			return fmt.Errorf("%w: %s account %d (%s) must be writable", ErrLayoutMismatch, t.Name, i, role.Name)
+		}
+		if role.Signer != meta.IsSigner {
+			return fmt.Errorf("%w: %s account %d (%s) signer is %v, expected %v", ErrLayoutMismatch, t.Name, i, role.Name, meta.IsSigner, role.Signer)
+		}
+		if !role.Address.IsZero() && !meta.PublicKey.Equals(role.Address) {
+			return fmt.Errorf("%w: %s account %d (%s) is %s, expected %s", ErrLayoutMismatch, t.Name, i, role.Name, meta.PublicKey, role.Address)
+		}
+	}
+	return nil
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[solana.PublicKey][]*Template)
+)
+
+// Register adds a template, replacing a registered one for the same program
+// and prefix. It lets callers cover programs added outside this package or
+// update a layout after a program upgrade
+func Register(t Template) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	templates := registry[t.ProgramID]
+	for i, existing := range templates {
+		if bytes.Equal(existing.Prefix, t.Prefix) {
+			templates[i] = &t
+			return
+		}
+	}
+	registry[t.ProgramID] = append(templates, &t)
+}
+
+// Lookup returns the template that describes instruction
+func Lookup(instruction solana.Instruction) (*Template, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, t := range registry[instruction.ProgramID()] {
+		if t.Matches(instruction) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Validate checks every instruction that has a registered template;
+// instructions of unknown programs pass unchecked
+func Validate(instructions []solana.Instruction) error {
+	for i, instruction := range instructions {
+		t, ok := Lookup(instruction)
+		if !ok {
+			continue
+		}
+		if err := t.Check(instruction); err != nil {
+			return fmt.Errorf("instruction %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package layout
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/pool/lifinity"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/pool/openbook"
+	"github.com/solana-zh/solroute/pkg/pool/phoenix"
+	"github.com/solana-zh/solroute/pkg/pool/pump"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// golden is an instruction stored under testdata, named after the template
+// that describes it. Goldens are laid out from the program's IDL, naming each
+// account after it, and never captured from our builders, so a builder and a
+// template that agree on a wrong layout still fail
+type golden struct {
+	Program  string          `json:"program"`
+	Data     string          `json:"data"`
+	Accounts []goldenAccount `json:"accounts"`
+}
+
+type goldenAccount struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"pubkey"`
+	Writable  bool   `json:"writable,omitempty"`
+	Signer    bool   `json:"signer,omitempty"`
+}
+
+// readGolden returns the golden instruction of name and its IDL account names
+func readGolden(name string) (solana.Instruction, []string, error) {
+	raw, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	var g golden
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, nil, err
+	}
+	program, err := solana.PublicKeyFromBase58(g.Program)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := hex.DecodeString(g.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	accounts := make(solana.AccountMetaSlice, len(g.Accounts))
+	names := make([]string, len(g.Accounts))
+	for i, account := range g.Accounts {
+		key, err := solana.PublicKeyFromBase58(account.PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		accounts[i] = solana.NewAccountMeta(key, account.Writable, account.Signer)
+		names[i] = account.Name
+	}
+	return solana.NewInstruction(program, accounts, data), names, nil
+}
+
+func templates() []*Template {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var all []*Template
+	for _, t := range registry {
+		all = append(all, t...)
+	}
+	return all
+}
+
+func TestGoldenInstructions(t *testing.T) {
+	for _, tmpl := range templates() {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			instruction, names, err := readGolden(tmpl.Name)
+			if err != nil {
+				t.Fatalf("failed to read golden instruction: %v", err)
+			}
+			found, ok := Lookup(instruction)
+			if !ok || found.Name != tmpl.Name {
+				t.Fatalf("golden instruction matched %v, want %s", found, tmpl.Name)
+			}
+			if err := tmpl.Check(instruction); err != nil {
+				t.Fatal(err)
+			}
+			// the template places every account where the IDL does
+			for i, role := range tmpl.Accounts {
+				if i < len(names) && names[i] != role.Name {
+					t.Errorf("account %d is %s in the IDL, %s in the template", i, names[i], role.Name)
+				}
+			}
+
+			// every role the template pins is enforced
+			for i, meta := range instruction.Accounts() {
+				role := tmpl.Remaining
+				if i < len(tmpl.Accounts) {
+					role = &tmpl.Accounts[i]
+				}
+				if role.Writable {
+					meta.IsWritable = false
+					if err := tmpl.Check(instruction); !errors.Is(err, ErrLayoutMismatch) {
+						t.Errorf("account %d (%s) passed read-only", i, role.Name)
+					}
+					meta.IsWritable = true
+				}
+				meta.IsSigner = !meta.IsSigner
+				if err := tmpl.Check(instruction); !errors.Is(err, ErrLayoutMismatch) {
+					t.Errorf("account %d (%s) passed with signer %v", i, role.Name, meta.IsSigner)
+				}
+				meta.IsSigner = !meta.IsSigner
+				if !role.Address.IsZero() {
+					key := meta.PublicKey
+					meta.PublicKey = solana.NewWallet().PublicKey()
+					if err := tmpl.Check(instruction); !errors.Is(err, ErrLayoutMismatch) {
+						t.Errorf("account %d (%s) passed at another address", i, role.Name)
+					}
+					meta.PublicKey = key
+				}
+			}
+		})
+	}
+}
+
+// builtInstructions are swaps and pool creations from the builders that need
+// no RPC reads
+func builtInstructions(t *testing.T) []solana.Instruction {
+	t.Helper()
+	key := func() solana.PublicKey { return solana.NewWallet().PublicKey() }
+	user := key()
+	mintA, mintB := key(), key()
+	amountIn, minOut := math.NewInt(5_000_000), math.NewInt(1_000)
+
+	swaps := []struct {
+		pool interface {
+			BuildSwapInstructions(context.Context, *sol.Client, solana.PublicKey, string, math.Int, math.Int, solana.PublicKey, solana.PublicKey) ([]solana.Instruction, error)
+		}
+		inputMints []solana.PublicKey
+	}{
+		{&raydium.AMMPool{PoolId: key(), BaseMint: mintA, QuoteMint: mintB}, []solana.PublicKey{mintA}},
+		{&raydium.CPMMPool{PoolId: key(), Token0Mint: mintA, Token1Mint: mintB}, []solana.PublicKey{mintA}},
+		{&meteora.MeteoraDlmmPool{PoolId: key(), TokenXMint: mintA, TokenYMint: mintB}, []solana.PublicKey{mintA}},
+		{&meteora.MeteoraDammV2Pool{PoolId: key(), TokenAMint: mintA, TokenBMint: mintB}, []solana.PublicKey{mintA}},
+		{&lifinity.LifinityPool{PoolId: key(), TokenAMint: mintA, TokenBMint: mintB}, []solana.PublicKey{mintA}},
+		{&phoenix.PhoenixPool{MarketId: key(), Header: phoenix.MarketHeader{BaseMint: mintA, QuoteMint: mintB, BaseLotSize: 1, QuoteLotSize: 1}}, []solana.PublicKey{mintA}},
+		{&openbook.OpenBookPool{MarketId: key(), Market: openbook.Market{BaseMint: mintA, QuoteMint: mintB, BaseLotSize: 1, QuoteLotSize: 1}}, []solana.PublicKey{mintA}},
+		{&pump.PumpAMMPool{PoolId: key(), BaseMint: mintA, QuoteMint: sol.WSOL}, []solana.PublicKey{mintA, sol.WSOL}},
+		{&stakepool.StakePool{PoolId: key(), PoolMint: mintA, TokenProgramID: solana.TokenProgramID}, []solana.PublicKey{mintA, sol.WSOL}},
+		{&marinade.MarinadePool{PoolId: key(), MsolMint: mintA, MsolSupply: 1_000_000_000, TotalActiveBalance: 1_000_000_000, SolLegLamports: 1_000_000_000, StakingSolCap: 1_000_000_000_000}, []solana.PublicKey{mintA, sol.WSOL}},
+	}
+
+	var instructions []solana.Instruction
+	for _, swap := range swaps {
+		for _, inputMint := range swap.inputMints {
+			built, err := swap.pool.BuildSwapInstructions(context.Background(), nil, user, inputMint.String(), amountIn, minOut, key(), key())
+			if err != nil {
+				t.Fatalf("failed to build %T swap from %s: %v", swap.pool, inputMint, err)
+			}
+			instructions = append(instructions, built...)
+		}
+	}
+
+	cpmmCreate, _, err := raydium.NewCPMMInitializeInstruction(raydium.CPMMCreateParams{Creator: user, MintA: mintA, MintB: mintB, AmountA: 1_000_000, AmountB: 1_000_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pumpCreate, _, err := pump.NewCreatePoolInstruction(pump.CreatePoolParams{Creator: user, BaseMint: mintA, QuoteMint: sol.WSOL, BaseAmountIn: 1_000_000, QuoteAmountIn: 1_000_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(instructions, cpmmCreate, pumpCreate)
+}
+
+func TestBuildersMatchLayouts(t *testing.T) {
+	for _, instruction := range builtInstructions(t) {
+		tmpl, ok := Lookup(instruction)
+		if !ok {
+			data, _ := instruction.Data()
+			t.Errorf("built instruction of %s with data %x has no layout template", instruction.ProgramID(), data)
+			continue
+		}
+		if err := tmpl.Check(instruction); err != nil {
+			t.Errorf("built %s: %v", tmpl.Name, err)
+			continue
+		}
+		// the arguments are encoded at the IDL's size
+		golden, _, err := readGolden(tmpl.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := golden.Data()
+		if data, _ := instruction.Data(); len(data) != len(want) {
+			t.Errorf("built %s has %d bytes of data, the IDL %d", tmpl.Name, len(data), len(want))
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package layout
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/anchor"
+	"github.com/solana-zh/solroute/pkg/pool/lifinity"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/pool/openbook"
+	"github.com/solana-zh/solroute/pkg/pool/orca"
+	"github.com/solana-zh/solroute/pkg/pool/phoenix"
+	"github.com/solana-zh/solroute/pkg/pool/pump"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+)
+
+// The layouts below follow the programs' published IDLs
+
+func init() {
+	ammSwap := []Role{
+		program("token_program", solana.TokenProgramID),
+		writable("amm"),
+		readonly("amm_authority"),
+		writable("amm_open_orders"),
+		writable("amm_target_orders"),
+		writable("pool_coin_token_account"),
+		writable("pool_pc_token_account"),
+		readonly("serum_program"),
+		writable("serum_market"),
+		writable("serum_bids"),
+		writable("serum_asks"),
+		writable("serum_event_queue"),
+		writable("serum_coin_vault"),
+		writable("serum_pc_vault"),
+		readonly("serum_vault_signer"),
+		writable("user_source_token_account"),
+		writable("user_destination_token_account"),
+		signer("user_source_owner"),
+	}
+	Register(Template{Name: "raydium_amm.swap_base_in", ProgramID: raydium.RAYDIUM_AMM_PROGRAM_ID, Prefix: []byte{9}, Accounts: ammSwap})
+	Register(Template{Name: "raydium_amm.swap_base_out", ProgramID: raydium.RAYDIUM_AMM_PROGRAM_ID, Prefix: []byte{11}, Accounts: ammSwap})
+
+	cpmmSwap := []Role{
+		signer("payer"),
+		readonly("authority"),
+		readonly("amm_config"),
+		writable("pool_state"),
+		writable("input_token_account"),
+		writable("output_token_account"),
+		writable("input_vault"),
+		writable("output_vault"),
+		readonly("input_token_program"),
+		readonly("output_token_program"),
+		readonly("input_token_mint"),
+		readonly("output_token_mint"),
+		writable("observation_state"),
+	}
+	Register(Template{Name: "raydium_cpmm.swap_base_input", ProgramID: raydium.RAYDIUM_CPMM_PROGRAM_ID, Prefix: raydium.SwapBaseInputDiscriminator, Accounts: cpmmSwap})
+	Register(Template{Name: "raydium_cpmm.swap_base_output", ProgramID: raydium.RAYDIUM_CPMM_PROGRAM_ID, Prefix: raydium.SwapBaseOutputDiscriminator, Accounts: cpmmSwap})
+	Register(Template{
+		Name:      "raydium_cpmm.initialize",
+		ProgramID: raydium.RAYDIUM_CPMM_PROGRAM_ID,
+		Prefix:    raydium.CPMMInitializeDiscriminator,
+		Accounts: []Role{
+			writableSigner("creator"),
+			readonly("amm_config"),
+			readonly("authority"),
+			writable("pool_state"),
+			readonly("token_0_mint"),
+			readonly("token_1_mint"),
+			writable("lp_mint"),
+			writable("creator_token_0"),
+			writable("creator_token_1"),
+			writable("creator_lp_token"),
+			writable("token_0_vault"),
+			writable("token_1_vault"),
+			writable("create_pool_fee"),
+			writable("observation_state"),
+			program("token_program", solana.TokenProgramID),
+			readonly("token_0_program"),
+			readonly("token_1_program"),
+			program("associated_token_program", solana.SPLAssociatedTokenAccountProgramID),
+			program("system_program", solana.SystemProgramID),
+			program("rent", solana.SysVarRentPubkey),
+		},
+	})
+
+	Register(Template{
+		Name:      "raydium_clmm.swap_v2",
+		ProgramID: raydium.RAYDIUM_CLMM_PROGRAM_ID,
+		Prefix:    raydium.CLMMSwapDiscriminator,
+		Accounts: []Role{
+			signer("payer"),
+			readonly("amm_config"),
+			writable("pool_state"),
+			writable("input_token_account"),
+			writable("output_token_account"),
+			writable("input_vault"),
+			writable("output_vault"),
+			writable("observation_state"),
+			program("token_program", solana.TokenProgramID),
+			program("token_program_2022", raydium.TOKEN_2022_PROGRAM_ID),
+			program("memo_program", raydium.MEMO_PROGRAM_ID),
+			readonly("input_vault_mint"),
+			readonly("output_vault_mint"),
+		},
+		// the tick array bitmap extension and tick arrays
+		Remaining: &Role{Name: "tick_array", Writable: true},
+	})
+
+	Register(Template{
+		Name:      "raydium_clmm.swap",
+		ProgramID: raydium.RAYDIUM_CLMM_PROGRAM_ID,
+		Prefix:    raydium.CLMMLegacySwapDiscriminator,
+		Accounts: []Role{
+			signer("payer"),
+			readonly("amm_config"),
+			writable("pool_state"),
+			writable("input_token_account"),
+			writable("output_token_account"),
+			writable("input_vault"),
+			writable("output_vault"),
+			writable("observation_state"),
+			program("token_program", solana.TokenProgramID),
+			writable("tick_array"),
+		},
+		// the tick array bitmap extension and further tick arrays
+		Remaining: &Role{Name: "tick_array", Writable: true},
+	})
+
+	whirlpoolSwapTail := []Role{
+		writable("token_owner_account_a"),
+		writable("token_vault_a"),
+		writable("token_owner_account_b"),
+		writable("token_vault_b"),
+		writable("tick_array_0"),
+		writable("tick_array_1"),
+		writable("tick_array_2"),
+		writable("oracle"),
+	}
+	Register(Template{
+		Name:      "orca_whirlpool.swap",
+		ProgramID: orca.ProgramID,
+		Prefix:    orca.SwapDiscriminator,
+		Accounts: append([]Role{
+			program("token_program", solana.TokenProgramID),
+			signer("token_authority"),
+			writable("whirlpool"),
+		}, whirlpoolSwapTail...),
+	})
+	Register(Template{
+		Name:      "orca_whirlpool.swap_v2",
+		ProgramID: orca.ProgramID,
+		Prefix:    orca.SwapV2Discriminator,
+		Accounts: append([]Role{
+			readonly("token_program_a"),
+			readonly("token_program_b"),
+			program("memo_program", solana.MemoProgramID),
+			signer("token_authority"),
+			writable("whirlpool"),
+			readonly("token_mint_a"),
+			readonly("token_mint_b"),
+		}, whirlpoolSwapTail...),
+		// supplemental tick arrays and transfer hook accounts
+		Remaining: &Role{Name: "remaining_account"},
+	})
+
+	Register(Template{
+		Name:      "meteora_dlmm.swap2",
+		ProgramID: meteora.MeteoraProgramID,
+		Prefix:    meteora.Swap2IxDiscm[:],
+		Accounts: []Role{
+			writable("lb_pair"),
+			readonly("bin_array_bitmap_extension"),
+			writable("reserve_x"),
+			writable("reserve_y"),
+			writable("user_token_in"),
+			writable("user_token_out"),
+			readonly("token_x_mint"),
+			readonly("token_y_mint"),
+			writable("oracle"),
+			readonly("host_fee_in"),
+			signer("user"),
+			readonly("token_x_program"),
+			readonly("token_y_program"),
+			program("memo_program", meteora.MemoProgramID),
+			program("event_authority", meteora.DeriveEventAuthorityPDA()),
+			program("program", meteora.MeteoraProgramID),
+		},
+		Remaining: &Role{Name: "bin_array", Writable: true},
+	})
+
+	Register(Template{
+		Name:      "meteora_damm.swap",
+		ProgramID: meteora.DammProgramID,
+		Prefix:    meteora.DammSwapDiscriminator,
+		Accounts: []Role{
+			writable("pool"),
+			writable("user_source_token"),
+			writable("user_destination_token"),
+			writable("a_vault"),
+			writable("b_vault"),
+			writable("a_token_vault"),
+			writable("b_token_vault"),
+			writable("a_vault_lp_mint"),
+			writable("b_vault_lp_mint"),
+			writable("a_vault_lp"),
+			writable("b_vault_lp"),
+			writable("protocol_token_fee"),
+			signer("user"),
+			program("vault_program", meteora.VaultProgramID),
+			program("token_program", solana.TokenProgramID),
+		},
+	})
+
+	Register(Template{
+		Name:      "meteora_damm_v2.swap",
+		ProgramID: meteora.DammV2ProgramID,
+		Prefix:    meteora.DammV2SwapDiscriminator,
+		Accounts: []Role{
+			program("pool_authority", meteora.DammV2PoolAuthority),
+			writable("pool"),
+			writable("input_token_account"),
+			writable("output_token_account"),
+			writable("token_a_vault"),
+			writable("token_b_vault"),
+			readonly("token_a_mint"),
+			readonly("token_b_mint"),
+			signer("payer"),
+			readonly("token_a_program"),
+			readonly("token_b_program"),
+			readonly("referral_token_account"),
+			program("event_authority", meteora.DeriveDammV2EventAuthorityPDA()),
+			program("program", meteora.DammV2ProgramID),
+		},
+	})
+
+	Register(Template{
+		Name:      "lifinity.swap",
+		ProgramID: lifinity.ProgramID,
+		Prefix:    lifinity.SwapDiscriminator,
+		Accounts: []Role{
+			readonly("authority"),
+			writable("amm"),
+			signer("user_transfer_authority"),
+			writable("source_info"),
+			writable("destination_info"),
+			writable("swap_source"),
+			writable("swap_destination"),
+			writable("pool_mint"),
+			writable("fee_account"),
+			readonly("token_program"),
+			readonly("oracle_main_account"),
+			readonly("oracle_sub_account"),
+			readonly("oracle_pc_account"),
+		},
+	})
+
+	Register(Template{
+		Name:      "phoenix.swap",
+		ProgramID: phoenix.ProgramID,
+		Prefix:    []byte{0},
+		Accounts: []Role{
+			program("phoenix_program", phoenix.ProgramID),
+			program("log_authority", phoenix.LogAuthority()),
+			writable("market"),
+			signer("trader"),
+			writable("base_account"),
+			writable("quote_account"),
+			writable("base_vault"),
+			writable("quote_vault"),
+			program("token_program", solana.TokenProgramID),
+		},
+	})
+
+	Register(Template{
+		Name:      "openbook_v2.place_take_order",
+		ProgramID: openbook.ProgramID,
+		Prefix:    openbook.PlaceTakeOrderDiscriminator,
+		Accounts: []Role{
+			writableSigner("signer"),
+			writableSigner("penalty_payer"),
+			writable("market"),
+			readonly("market_authority"),
+			writable("bids"),
+			writable("asks"),
+			writable("market_base_vault"),
+			writable("market_quote_vault"),
+			writable("event_heap"),
+			writable("user_base_account"),
+			writable("user_quote_account"),
+			readonly("oracle_a"),
+			readonly("oracle_b"),
+			program("token_program", solana.TokenProgramID),
+			program("system_program", solana.SystemProgramID),
+			readonly("open_orders_admin"),
+		},
+	})
+
+	pumpSwap := []Role{
+		readonly("pool"),
+		writableSigner("user"),
+		program("global_config", pump.PumpGlobalConfig),
+		readonly("base_mint"),
+		readonly("quote_mint"),
+		writable("user_base_token_account"),
+		writable("user_quote_token_account"),
+		writable("pool_base_token_account"),
+		writable("pool_quote_token_account"),
+		readonly("protocol_fee_recipient"),
+		writable("protocol_fee_recipient_token_account"),
+		readonly("base_token_program"),
+		readonly("quote_token_program"),
+		program("system_program", solana.SystemProgramID),
+		program("associated_token_program", solana.SPLAssociatedTokenAccountProgramID),
+		program("event_authority", pump.PumpEventAuthority),
+		program("program", pump.PumpSwapProgramID),
+		writable("coin_creator_vault_ata"),
+		readonly("coin_creator_vault_authority"),
+	}
+	// pools without a coin creator omit the creator vault accounts
+	Register(Template{Name: "pump_amm.buy", ProgramID: pump.PumpSwapProgramID, Prefix: anchor.GetDiscriminator("global", "buy"), Accounts: pumpSwap, MinAccounts: 17})
+	Register(Template{Name: "pump_amm.sell", ProgramID: pump.PumpSwapProgramID, Prefix: anchor.GetDiscriminator("global", "sell"), Accounts: pumpSwap, MinAccounts: 17})
+	Register(Template{
+		Name:      "pump_amm.create_pool",
+		ProgramID: pump.PumpSwapProgramID,
+		Prefix:    pump.CreatePoolDiscriminator,
+		Accounts: []Role{
+			writable("pool"),
+			program("global_config", pump.PumpGlobalConfig),
+			writableSigner("creator"),
+			readonly("base_mint"),
+			readonly("quote_mint"),
+			writable("lp_mint"),
+			writable("user_base_token_account"),
+			writable("user_quote_token_account"),
+			writable("user_pool_token_account"),
+			writable("pool_base_token_account"),
+			writable("pool_quote_token_account"),
+			program("system_program", solana.SystemProgramID),
+			program("token_2022_program", pump.Token2022ProgramID),
+			readonly("base_token_program"),
+			readonly("quote_token_program"),
+			program("associated_token_program", solana.SPLAssociatedTokenAccountProgramID),
+			program("event_authority", pump.PumpEventAuthority),
+			program("program", pump.PumpSwapProgramID),
+		},
+	})
+
+	stakePoolDepositSol := []Role{
+		writable("stake_pool"),
+		readonly("withdraw_authority"),
+		writable("reserve_stake"),
+		writableSigner("lamports_from"),
+		writable("pool_tokens_to"),
+		writable("manager_fee_account"),
+		writable("referrer_pool_tokens_account"),
+		writable("pool_mint"),
+		program("system_program", solana.SystemProgramID),
+		readonly("token_program"),
+		signer("sol_deposit_authority"),
+	}
+	// the SOL deposit and withdraw authorities follow only when the pool sets them
+	Register(Template{Name: "spl_stake_pool.deposit_sol", ProgramID: stakepool.ProgramID, Prefix: []byte{14}, Accounts: stakePoolDepositSol, MinAccounts: 10})
+	Register(Template{Name: "spl_stake_pool.deposit_sol_with_slippage", ProgramID: stakepool.ProgramID, Prefix: []byte{stakepool.DepositSolWithSlippage}, Accounts: stakePoolDepositSol, MinAccounts: 10})
+	stakePoolWithdrawSol := []Role{
+		writable("stake_pool"),
+		readonly("withdraw_authority"),
+		signer("user_transfer_authority"),
+		writable("pool_tokens_from"),
+		writable("reserve_stake"),
+		writable("lamports_to"),
+		writable("manager_fee_account"),
+		writable("pool_mint"),
+		program("clock_sysvar", solana.SysVarClockPubkey),
+		program("stake_history_sysvar", stakepool.StakeHistorySysvar),
+		program("stake_program", stakepool.StakeProgramID),
+		readonly("token_program"),
+		signer("sol_withdraw_authority"),
+	}
+	Register(Template{Name: "spl_stake_pool.withdraw_sol", ProgramID: stakepool.ProgramID, Prefix: []byte{16}, Accounts: stakePoolWithdrawSol, MinAccounts: 12})
+	Register(Template{Name: "spl_stake_pool.withdraw_sol_with_slippage", ProgramID: stakepool.ProgramID, Prefix: []byte{stakepool.WithdrawSolWithSlippage}, Accounts: stakePoolWithdrawSol, MinAccounts: 12})
+
+	Register(Template{
+		Name:      "marinade.deposit",
+		ProgramID: marinade.ProgramID,
+		Prefix:    anchor.GetDiscriminator("global", "deposit"),
+		Accounts: []Role{
+			writable("state"),
+			writable("msol_mint"),
+			writable("liq_pool_sol_leg_pda"),
+			writable("liq_pool_msol_leg"),
+			readonly("liq_pool_msol_leg_authority"),
+			writable("reserve_pda"),
+			writableSigner("transfer_from"),
+			writable("mint_to"),
+			readonly("msol_mint_authority"),
+			program("system_program", solana.SystemProgramID),
+			program("token_program", solana.TokenProgramID),
+		},
+	})
+	Register(Template{
+		Name:      "marinade.liquid_unstake",
+		ProgramID: marinade.ProgramID,
+		Prefix:    anchor.GetDiscriminator("global", "liquid_unstake"),
+		Accounts: []Role{
+			writable("state"),
+			writable("msol_mint"),
+			writable("liq_pool_sol_leg_pda"),
+			writable("liq_pool_msol_leg"),
+			writable("treasury_msol_account"),
+			writable("get_msol_from"),
+			signer("get_msol_from_authority"),
+			writable("transfer_sol_to"),
+			program("system_program", solana.SystemProgramID),
+			program("token_program", solana.TokenProgramID),
+		},
+	})
+}
+
+func readonly(name string) Role {
+	return Role{Name: name}
+}
+
+func writable(name string) Role {
+	return Role{Name: name, Writable: true}
+}
+
+func signer(name string) Role {
+	return Role{Name: name, Signer: true}
+}
+
+func writableSigner(name string) Role {
+	return Role{Name: name, Writable: true, Signer: true}
+}
+
+func program(name string, address solana.PublicKey) Role {
+	return Role{Name: name, Address: address}
+}
@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	hidPacketSize = 64
+	hidChannel    = 0x0101
+	hidTagAPDU    = 0x05
+
+	// ledgerVendorID is the USB vendor ID of Ledger devices
+	ledgerVendorID = 0x2c97
+)
+
+// hidTransport frames APDUs into Ledger's 64-byte HID packets
+type hidTransport struct {
+	device io.ReadWriteCloser
+}
+
+func (t *hidTransport) Exchange(apdu []byte) ([]byte, error) {
+	if err := t.write(apdu); err != nil {
+		return nil, fmt.Errorf("ledger: write failed: %w", err)
+	}
+	response, err := t.read()
+	if err != nil {
+		return nil, fmt.Errorf("ledger: read failed: %w", err)
+	}
+	return response, nil
+}
+
+func (t *hidTransport) Close() error {
+	return t.device.Close()
+}
+
+func (t *hidTransport) write(apdu []byte) error {
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(apdu)))
+	data = append(data, apdu...)
+	for seq := uint16(0); len(data) > 0 || seq == 0; seq++ {
+		// leading zero is the HID report ID
+		packet := make([]byte, 1+hidPacketSize)
+		binary.BigEndian.PutUint16(packet[1:], hidChannel)
+		packet[3] = hidTagAPDU
+		binary.BigEndian.PutUint16(packet[4:], seq)
+		n := copy(packet[6:], data)
+		data = data[n:]
+		if _, err := t.device.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *hidTransport) read() ([]byte, error) {
+	var response []byte
+	total := -1
+	for seq := uint16(0); total < 0 || len(response) < total; seq++ {
+		packet := make([]byte, hidPacketSize)
+		if _, err := io.ReadFull(t.device, packet); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(packet) != hidChannel || packet[2] != hidTagAPDU {
+			return nil, fmt.Errorf("unexpected packet header")
+		}
+		if binary.BigEndian.Uint16(packet[3:]) != seq {
+			return nil, fmt.Errorf("unexpected packet sequence")
+		}
+		payload := packet[5:]
+		if seq == 0 {
+			total = int(binary.BigEndian.Uint16(payload))
+			payload = payload[2:]
+		}
+		response = append(response, payload...)
+	}
+	return response[:total], nil
+}
@@ -0,0 +1,34 @@
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenHID opens the first Ledger device exposed through hidraw
+func OpenHID() (Transport, error) {
+	devices, err := filepath.Glob("/sys/class/hidraw/hidraw*")
+	if err != nil {
+		return nil, err
+	}
+	vendor := fmt.Sprintf(":%08X:", ledgerVendorID)
+	for _, device := range devices {
+		uevent, err := os.ReadFile(filepath.Join(device, "device", "uevent"))
+		if err != nil || !strings.Contains(string(uevent), vendor) {
+			continue
+		}
+		// interface 0 is the generic APDU interface; others are FIDO/U2F
+		target, err := filepath.EvalSymlinks(filepath.Join(device, "device"))
+		if err != nil || !strings.Contains(target, ":1.0/") {
+			continue
+		}
+		file, err := os.OpenFile(filepath.Join("/dev", filepath.Base(device)), os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("ledger: failed to open device: %w", err)
+		}
+		return &hidTransport{device: file}, nil
+	}
+	return nil, fmt.Errorf("ledger: no device found")
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package ledger
+
+import "fmt"
+
+// OpenHID opens the first Ledger device; only hidraw on Linux is supported,
+// elsewhere pass a custom Transport to NewSigner
+func OpenHID() (Transport, error) {
+	return nil, fmt.Errorf("ledger: HID transport is only supported on linux")
+}
@@ -0,0 +1,221 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Solana app APDU constants
+const (
+	cla = 0xe0
+
+	insGetAppConfig = 0x04
+	insGetPubkey    = 0x05
+	insSignMessage  = 0x06
+
+	p1NonConfirm = 0x00
+	p1Confirm    = 0x01
+
+	p2Extend = 0x01
+	p2More   = 0x02
+
+	maxChunkSize = 255
+
+	swOK                  = 0x9000
+	swNotSupported        = 0x6808
+	swUserCancel          = 0x6985
+	swInvalidMessage      = 0x6a80
+	swAppNotOpen          = 0x6e00
+	swAppNotOpenAlternate = 0x6d00
+)
+
+var (
+	// ErrBlindSigningDisabled means the transaction cannot be displayed in full
+	// and blind signing is turned off in the Solana app settings
+	ErrBlindSigningDisabled = errors.New("ledger: blind signing is disabled, enable it in the Solana app settings")
+	// ErrUserRejected means the user declined on the device
+	ErrUserRejected = errors.New("ledger: rejected on device")
+	// ErrAppNotOpen means the Solana app is not open on the device
+	ErrAppNotOpen = errors.New("ledger: Solana app is not open")
+)
+
+// DefaultDerivationPath is m/44'/501'/0'/0', the path used by most Solana wallets
+var DefaultDerivationPath = []uint32{44 | hardened, 501 | hardened, 0 | hardened, 0 | hardened}
+
+const hardened = 0x80000000
+
+// Transport exchanges raw APDUs with the device
+type Transport interface {
+	Exchange(apdu []byte) ([]byte, error)
+	Close() error
+}
+
+// AppConfig is the Solana app configuration reported by the device
+type AppConfig struct {
+	BlindSigningEnabled bool
+	Version             string
+}
+
+// Signer signs transactions with a key held on a Ledger device; it implements sol.Signer
+type Signer struct {
+	mu        sync.Mutex
+	transport Transport
+	path      []uint32
+	publicKey solana.PublicKey
+	config    AppConfig
+}
+
+// Open connects to the first Ledger device over HID and loads the key at path
+func Open(path []uint32) (*Signer, error) {
+	transport, err := OpenHID()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewSigner(transport, path)
+	if err != nil {
+		transport.Close()
+		return nil, err
+	}
+	return signer, nil
+}
+
+// NewSigner loads the key at path over an already open transport
+func NewSigner(transport Transport, path []uint32) (*Signer, error) {
+	if len(path) == 0 {
+		path = DefaultDerivationPath
+	}
+	s := &Signer{transport: transport, path: path}
+
+	config, err := s.appConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read app config: %w", err)
+	}
+	s.config = config
+
+	response, err := s.send(insGetPubkey, p1NonConfirm, 0, serializePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+	if len(response) != solana.PublicKeyLength {
+		return nil, fmt.Errorf("invalid public key length: %d", len(response))
+	}
+	s.publicKey = solana.PublicKeyFromBytes(response)
+	return s, nil
+}
+
+// PublicKey returns the public key at the signer's derivation path
+func (s *Signer) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+// Config returns the Solana app configuration read when the signer was opened
+func (s *Signer) Config() AppConfig {
+	return s.config
+}
+
+// SignMessage asks the device to sign a serialized (legacy or versioned)
+// transaction message, blocking until the user confirms
+func (s *Signer) SignMessage(message []byte) (solana.Signature, error) {
+	// versioned messages can't be fully displayed by the app and always need
+	// blind signing, so fail before prompting the user
+	if len(message) > 0 && message[0]&0x80 != 0 && !s.config.BlindSigningEnabled {
+		return solana.Signature{}, ErrBlindSigningDisabled
+	}
+
+	// first chunk carries the signer count and derivation path
+	payload := append([]byte{1}, serializePath(s.path)...)
+	first := message
+	var rest []byte
+	if room := maxChunkSize - len(payload); len(message) > room {
+		first, rest = message[:room], message[room:]
+	}
+	payload = append(payload, first...)
+
+	p2 := byte(0)
+	if len(rest) > 0 {
+		p2 = p2More
+	}
+	response, err := s.send(insSignMessage, p1Confirm, p2, payload)
+	for err == nil && len(rest) > 0 {
+		chunk := rest
+		if len(chunk) > maxChunkSize {
+			chunk = chunk[:maxChunkSize]
+		}
+		rest = rest[len(chunk):]
+		p2 = p2Extend
+		if len(rest) > 0 {
+			p2 |= p2More
+		}
+		response, err = s.send(insSignMessage, p1Confirm, p2, chunk)
+	}
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	if len(response) != solana.SignatureLength {
+		return solana.Signature{}, fmt.Errorf("invalid signature length: %d", len(response))
+	}
+	return solana.SignatureFromBytes(response), nil
+}
+
+// Close releases the device
+func (s *Signer) Close() error {
+	return s.transport.Close()
+}
+
+func (s *Signer) appConfig() (AppConfig, error) {
+	response, err := s.send(insGetAppConfig, p1NonConfirm, 0, nil)
+	if err != nil {
+		return AppConfig{}, err
+	}
+	if len(response) < 5 {
+		return AppConfig{}, fmt.Errorf("invalid app config length: %d", len(response))
+	}
+	return AppConfig{
+		BlindSigningEnabled: response[0] != 0,
+		Version:             fmt.Sprintf("%d.%d.%d", response[2], response[3], response[4]),
+	}, nil
+}
+
+func (s *Signer) send(ins, p1, p2 byte, data []byte) ([]byte, error) {
+	if len(data) > maxChunkSize {
+		return nil, fmt.Errorf("apdu payload too large: %d", len(data))
+	}
+	apdu := append([]byte{cla, ins, p1, p2, byte(len(data))}, data...)
+
+	s.mu.Lock()
+	response, err := s.transport.Exchange(apdu)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(response) < 2 {
+		return nil, fmt.Errorf("ledger: short response")
+	}
+	sw := binary.BigEndian.Uint16(response[len(response)-2:])
+	switch sw {
+	case swOK:
+		return response[:len(response)-2], nil
+	case swNotSupported:
+		return nil, ErrBlindSigningDisabled
+	case swUserCancel:
+		return nil, ErrUserRejected
+	case swAppNotOpen, swAppNotOpenAlternate:
+		return nil, ErrAppNotOpen
+	case swInvalidMessage:
+		return nil, fmt.Errorf("ledger: invalid message")
+	}
+	return nil, fmt.Errorf("ledger: status 0x%04x", sw)
+}
+
+func serializePath(path []uint32) []byte {
+	buf := make([]byte, 1, 1+4*len(path))
+	buf[0] = byte(len(path))
+	for _, index := range path {
+		buf = binary.BigEndian.AppendUint32(buf, index)
+	}
+	return buf
+}
@@ -0,0 +1,76 @@
+// Package lru orders cache keys by last use so pools can bound the tick and
+// bin arrays they keep across quotes
+package lru
+
+import "container/list"
+
+// Tracker records when keys were last used and reports the least recently
+// used ones once more than its capacity are tracked. It holds only keys; the
+// cache itself stays with its owner
+type Tracker struct {
+	capacity int
+	order    *list.List // front is the most recently used key
+	items    map[string]*list.Element
+}
+
+// NewTracker creates a tracker keeping at most capacity keys, unbounded when
+// capacity is not positive
+func NewTracker(capacity int) *Tracker {
+	return &Tracker{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Touch marks key as just used and returns the keys evicted to stay within
+// capacity, which the owner must drop from its cache
+func (t *Tracker) Touch(key string) []string {
+	if element, ok := t.items[key]; ok {
+		t.order.MoveToFront(element)
+		return nil
+	}
+	t.items[key] = t.order.PushFront(key)
+	if t.capacity <= 0 {
+		return nil
+	}
+	var evicted []string
+	for t.order.Len() > t.capacity {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		key := oldest.Value.(string)
+		delete(t.items, key)
+		evicted = append(evicted, key)
+	}
+	return evicted
+}
+
+// Remove stops tracking key
+func (t *Tracker) Remove(key string) {
+	if element, ok := t.items[key]; ok {
+		t.order.Remove(element)
+		delete(t.items, key)
+	}
+}
+
+// Has reports whether key is tracked
+func (t *Tracker) Has(key string) bool {
+	_, ok := t.items[key]
+	return ok
+}
+
+// Len returns how many keys are tracked
+func (t *Tracker) Len() int {
+	return t.order.Len()
+}
+
+// Capacity returns the most keys the tracker keeps
+func (t *Tracker) Capacity() int {
+	return t.capacity
+}
+
+// Reset forgets every key
+func (t *Tracker) Reset() {
+	t.order.Init()
+	t.items = make(map[string]*list.Element)
+}
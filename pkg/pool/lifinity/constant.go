@@ -0,0 +1,47 @@
+// Package lifinity quotes and builds swaps on Lifinity v2, a proactive market
+// maker whose constant product curve is concentrated around an oracle price
+package lifinity
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	// ProgramID is the Lifinity v2 program
+	ProgramID = solana.MustPublicKeyFromBase58("2wT8Yq49kHgDzXuPxZSaeLaH1qbmGXtEyPy64bL7aD3c")
+
+	// AmmDiscriminator prefixes Lifinity v2 pool accounts
+	AmmDiscriminator = []byte{143, 245, 200, 17, 74, 214, 196, 135}
+	// SwapDiscriminator is the Lifinity v2 swap instruction
+	SwapDiscriminator = []byte{248, 198, 158, 145, 225, 117, 135, 200}
+
+	// PythReceiverProgramID owns the Pyth pull oracle price update accounts
+	PythReceiverProgramID = solana.MustPublicKeyFromBase58("rec5EKMGg6MxZYaMdyBfgwp4d5rB9T1VQH5pJv5LtFJ")
+)
+
+// Lifinity v2 pool account layout
+const (
+	AmmSize          = 911
+	TokenAMintOffset = 254
+	TokenBMintOffset = 286
+
+	freezeTradeOffset   = 122
+	tokenProgramOffset  = 126
+	tokenAAccountOffset = 158
+	tokenBAccountOffset = 190
+	poolMintOffset      = 222
+	feeAccountOffset    = 318
+	oracleMainOffset    = 350
+	oracleSubOffset     = 382
+	oraclePcOffset      = 414
+	feesOffset          = 446
+	curveOffset         = 510
+)
+
+const (
+	// MaxOracleAge is how old an oracle price may be, by cluster time, before
+	// the pool stops quoting
+	MaxOracleAge = 60 * time.Second
+)
@@ -0,0 +1,36 @@
+package lifinity
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a Lifinity v2 swap instruction. It does not name the
+// mints, so the input and output mints are left zero
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 13); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameLifinity,
+		Pool:              accounts[1].PublicKey,
+		User:              accounts[2].PublicKey,
+		UserInputAccount:  accounts[3].PublicKey,
+		UserOutputAccount: accounts[4].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24])),
+	}
+	return params, nil
+}
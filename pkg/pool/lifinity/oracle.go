@@ -0,0 +1,98 @@
+package lifinity
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// priceUpdateDiscriminator prefixes Pyth pull oracle PriceUpdateV2 accounts
+	priceUpdateDiscriminator = []byte{34, 241, 35, 99, 157, 126, 244, 205}
+)
+
+const (
+	// pythMagic opens Pyth push oracle price accounts
+	pythMagic = 0xa1b2c3d4
+	// pythStatusTrading is the aggregate status of a price fit to trade on
+	pythStatusTrading = 1
+)
+
+// OraclePrice is a Pyth price of token A in token B: Price * 10^Exponent
+// whole B per whole A
+type OraclePrice struct {
+	Price       int64
+	Conf        uint64
+	Exponent    int32
+	PublishTime int64
+}
+
+// ParseOraclePrice reads a Pyth price from either a push oracle price account
+// or a pull oracle PriceUpdateV2 account, the two kinds Lifinity pools use
+func ParseOraclePrice(data []byte) (*OraclePrice, error) {
+	if bytes.HasPrefix(data, priceUpdateDiscriminator) {
+		return parsePriceUpdate(data)
+	}
+	if len(data) < 240 || binary.LittleEndian.Uint32(data) != pythMagic {
+		return nil, fmt.Errorf("not a pyth price account")
+	}
+	if status := binary.LittleEndian.Uint32(data[224:]); status != pythStatusTrading {
+		return nil, fmt.Errorf("pyth price status %d is not trading", status)
+	}
+	return &OraclePrice{
+		Exponent:    int32(binary.LittleEndian.Uint32(data[20:])),
+		PublishTime: int64(binary.LittleEndian.Uint64(data[96:])),
+		Price:       int64(binary.LittleEndian.Uint64(data[208:])),
+		Conf:        binary.LittleEndian.Uint64(data[216:]),
+	}, nil
+}
+
+// parsePriceUpdate reads a PriceUpdateV2 account, whose price message follows
+// a verification level of one byte when full and two when partial
+func parsePriceUpdate(data []byte) (*OraclePrice, error) {
+	offset := 40
+	if len(data) <= offset {
+		return nil, fmt.Errorf("price update account too short: %d bytes", len(data))
+	}
+	if data[offset] == 0 {
+		offset += 2
+	} else {
+		offset++
+	}
+	// the message opens with the 32 byte feed ID
+	offset += 32
+	if len(data) < offset+36 {
+		return nil, fmt.Errorf("price update account too short: %d bytes", len(data))
+	}
+	return &OraclePrice{
+		Price:       int64(binary.LittleEndian.Uint64(data[offset:])),
+		Conf:        binary.LittleEndian.Uint64(data[offset+8:]),
+		Exponent:    int32(binary.LittleEndian.Uint32(data[offset+16:])),
+		PublishTime: int64(binary.LittleEndian.Uint64(data[offset+20:])),
+	}, nil
+}
+
+// rawPrice returns the price in token B base units per token A base unit as
+// a fraction, scaling the oracle's exponent by the mints' decimals
+func (p *OraclePrice) rawPrice(decimalsA, decimalsB uint8) (num, den *big.Int, err error) {
+	if p.Price <= 0 {
+		return nil, nil, fmt.Errorf("oracle price %d is not positive", p.Price)
+	}
+	num, den = big.NewInt(p.Price), big.NewInt(1)
+	exponent := int64(p.Exponent) + int64(decimalsB) - int64(decimalsA)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(absInt64(exponent)), nil)
+	if exponent >= 0 {
+		num.Mul(num, scale)
+	} else {
+		den.Mul(den, scale)
+	}
+	return num, den, nil
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,357 @@
+package lifinity
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// LifinityPool is a Lifinity v2 pool. Rather than pricing off its reserves
+// it centers a constant product curve on the oracle price of token A in
+// token B, deepened by the pool's concentration. Token A and token B are the
+// base and quote mints
+type LifinityPool struct {
+	PoolId        solana.PublicKey
+	TokenAMint    solana.PublicKey
+	TokenBMint    solana.PublicKey
+	TokenAAccount solana.PublicKey
+	TokenBAccount solana.PublicKey
+	PoolMint      solana.PublicKey
+	FeeAccount    solana.PublicKey
+	TokenProgram  solana.PublicKey
+	OracleMain    solana.PublicKey
+	OracleSub     solana.PublicKey
+	OraclePc      solana.PublicKey
+
+	// Frozen pools reject swaps
+	Frozen              bool
+	TradeFeeNumerator   uint64
+	TradeFeeDenominator uint64
+	// Concentration multiplies the depth of the curve around the oracle price
+	Concentration uint64
+
+	// BaseReserve and QuoteReserve are the token A and B balances of the last quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+	decimals     [2]uint8
+	oracle       *OraclePrice
+}
+
+func (pool *LifinityPool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameLifinity
+}
+
+func (pool *LifinityPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *LifinityPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token A as base and token B as quote
+func (pool *LifinityPool) GetTokens() (string, string) {
+	return pool.TokenAMint.String(), pool.TokenBMint.String()
+}
+
+// Decode parses a Lifinity v2 pool account
+func (pool *LifinityPool) Decode(data []byte) error {
+	if len(data) < AmmSize {
+		return fmt.Errorf("lifinity pool account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, AmmDiscriminator) {
+		return fmt.Errorf("not a lifinity pool account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.Frozen = data[freezeTradeOffset] != 0
+	pool.TokenProgram = key(tokenProgramOffset)
+	pool.TokenAAccount = key(tokenAAccountOffset)
+	pool.TokenBAccount = key(tokenBAccountOffset)
+	pool.PoolMint = key(poolMintOffset)
+	pool.TokenAMint = key(TokenAMintOffset)
+	pool.TokenBMint = key(TokenBMintOffset)
+	pool.FeeAccount = key(feeAccountOffset)
+	pool.OracleMain = key(oracleMainOffset)
+	pool.OracleSub = key(oracleSubOffset)
+	pool.OraclePc = key(oraclePcOffset)
+	pool.TradeFeeNumerator = u64(feesOffset)
+	pool.TradeFeeDenominator = u64(feesOffset + 8)
+	// curve type byte, then its parameter
+	pool.Concentration = u64(curveOffset + 1)
+	if pool.TradeFeeNumerator >= pool.TradeFeeDenominator {
+		return fmt.Errorf("invalid trade fee %d/%d", pool.TradeFeeNumerator, pool.TradeFeeDenominator)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the reserves, decimals and oracle price of the last quote
+func (pool *LifinityPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*LifinityPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	base, quote, decimals, oracle := pool.BaseReserve, pool.QuoteReserve, pool.decimals, pool.oracle
+	*pool = *fresh
+	pool.BaseReserve, pool.QuoteReserve, pool.decimals, pool.oracle = base, quote, decimals, oracle
+	return true
+}
+
+// Quote refreshes the pool, its reserves, the mints' decimals and the oracle
+// price in one batch and returns the output for inputAmount. Pools whose
+// oracle is older than MaxOracleAge by cluster time do not quote, as the
+// program rejects swaps on stale prices
+func (pool *LifinityPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{
+		pool.PoolId, pool.TokenAAccount, pool.TokenBAccount, pool.TokenAMint, pool.TokenBMint,
+		pool.OracleMain, solana.SysVarClockPubkey,
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode lifinity pool %s: %w", pool.PoolId, err)
+		}
+	}
+	baseReserve, ok := sol.TokenAccountAmount(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("token A account %s not found", pool.TokenAAccount)
+	}
+	quoteReserve, ok := sol.TokenAccountAmount(results, 2)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("token B account %s not found", pool.TokenBAccount)
+	}
+	pool.BaseReserve = math.NewIntFromUint64(baseReserve)
+	pool.QuoteReserve = math.NewIntFromUint64(quoteReserve)
+	for i := 0; i < 2; i++ {
+		decimals, ok := sol.MintDecimals(results, 3+i)
+		if !ok {
+			return math.ZeroInt(), fmt.Errorf("mint %s not found", accounts[3+i])
+		}
+		pool.decimals[i] = decimals
+	}
+
+	data, ok := sol.AccountData(results, 5)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("oracle %s not found", pool.OracleMain)
+	}
+	oracle, err := ParseOraclePrice(data)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to read oracle %s: %w", pool.OracleMain, err)
+	}
+	data, ok = sol.AccountData(results, 6)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if age := time.Duration(int64(clock.UnixTimestamp)-oracle.PublishTime) * time.Second; age > MaxOracleAge {
+		return math.ZeroInt(), fmt.Errorf("oracle %s price is %s old", pool.OracleMain, age)
+	}
+	pool.oracle = oracle
+
+	if pool.Frozen {
+		return math.ZeroInt(), fmt.Errorf("lifinity pool %s has trading frozen", pool.PoolId)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// ComputeAmountOut prices inputAmount, net of the trade fee, against the
+// cached reserves and oracle price. The output never exceeds the reserve
+func (pool *LifinityPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	var aToB bool
+	switch inputMint {
+	case pool.TokenAMint.String():
+		aToB = true
+	case pool.TokenBMint.String():
+	default:
+		return math.ZeroInt(), fmt.Errorf("mint %s is not traded by lifinity pool %s", inputMint, pool.PoolId)
+	}
+	virtualA, virtualB, err := pool.virtualReserves()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+
+	amount := inputAmount.BigInt()
+	amount.Sub(amount, pool.SwapFee(inputMint, inputAmount).BigInt())
+	reserveIn, reserveOut, actualOut := virtualA, virtualB, pool.QuoteReserve
+	if !aToB {
+		reserveIn, reserveOut, actualOut = virtualB, virtualA, pool.BaseReserve
+	}
+	// constant product on the virtual reserves, rounded down
+	amountOut := new(big.Int).Mul(reserveOut, amount)
+	amountOut.Quo(amountOut, new(big.Int).Add(reserveIn, amount))
+	if amountOut.Cmp(actualOut.BigInt()) >= 0 {
+		return math.ZeroInt(), fmt.Errorf("output %s exceeds the pool reserve %s", amountOut, actualOut)
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// virtualReserves returns the reserves of a constant product curve whose
+// price is the oracle's and whose invariant is the actual reserves' scaled
+// by the square of the concentration
+func (pool *LifinityPool) virtualReserves() (*big.Int, *big.Int, error) {
+	if pool.oracle == nil || pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return nil, nil, fmt.Errorf("pool state not loaded")
+	}
+	if !pool.BaseReserve.IsPositive() || !pool.QuoteReserve.IsPositive() {
+		return nil, nil, fmt.Errorf("lifinity pool %s has an empty reserve", pool.PoolId)
+	}
+	num, den, err := pool.oracle.rawPrice(pool.decimals[0], pool.decimals[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	concentration := pool.Concentration
+	if concentration == 0 {
+		concentration = 1
+	}
+	invariant := new(big.Int).Mul(pool.BaseReserve.BigInt(), pool.QuoteReserve.BigInt())
+	c := new(big.Int).SetUint64(concentration)
+	invariant.Mul(invariant, c).Mul(invariant, c)
+
+	// a * b = invariant with b / a = num / den
+	virtualA := new(big.Int).Mul(invariant, den)
+	virtualA.Quo(virtualA, num).Sqrt(virtualA)
+	virtualB := new(big.Int).Mul(invariant, num)
+	virtualB.Quo(virtualB, den).Sqrt(virtualB)
+	if virtualA.Sign() == 0 || virtualB.Sign() == 0 {
+		return nil, nil, fmt.Errorf("lifinity pool %s is too shallow at the oracle price", pool.PoolId)
+	}
+	return virtualA, virtualB, nil
+}
+
+// SwapFee returns the trade fee charged on inputAmount
+func (pool *LifinityPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	if pool.TradeFeeDenominator == 0 {
+		return math.ZeroInt()
+	}
+	fee := new(big.Int).Mul(inputAmount.BigInt(), new(big.Int).SetUint64(pool.TradeFeeNumerator))
+	return math.NewIntFromBigInt(fee.Quo(fee, new(big.Int).SetUint64(pool.TradeFeeDenominator)))
+}
+
+// Reserves returns the token A and B amounts cached by the last Quote
+func (pool *LifinityPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the oracle price of the last Quote, which the pool's curve
+// is centered on
+func (pool *LifinityPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	if pool.oracle == nil {
+		return math.LegacyDec{}, fmt.Errorf("pool state not loaded")
+	}
+	num, den, err := pool.oracle.rawPrice(pool.decimals[0], pool.decimals[1])
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	return pkg.RatioPrice(num, den, inputMint != pool.TokenAMint.String())
+}
+
+// MaxInputForImpact solves the virtual constant product curve for the
+// largest input within maxImpactBps of the oracle price, grossed up by the
+// trade fee
+func (pool *LifinityPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	virtualA, virtualB, err := pool.virtualReserves()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn := virtualA
+	if inputMint == pool.TokenBMint.String() {
+		reserveIn = virtualB
+	}
+	bps := big.NewInt(int64(maxImpactBps))
+	amount := new(big.Int).Mul(reserveIn, bps)
+	amount.Quo(amount, new(big.Int).Sub(big.NewInt(10000), bps))
+	denominator := new(big.Int).SetUint64(pool.TradeFeeDenominator)
+	amount.Mul(amount, denominator)
+	amount.Quo(amount, denominator.Sub(denominator, new(big.Int).SetUint64(pool.TradeFeeNumerator)))
+	return math.NewIntFromBigInt(amount), nil
+}
+
+// Authority derives the PDA that owns the pool's token accounts
+func (pool *LifinityPool) Authority() (solana.PublicKey, error) {
+	authority, _, err := sol.FindProgramAddress([][]byte{pool.PoolId.Bytes()}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive pool authority: %w", err)
+	}
+	return authority, nil
+}
+
+// BuildSwapInstructions builds a Lifinity v2 swap passing the pool's three oracles
+func (pool *LifinityPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	authority, err := pool.Authority()
+	if err != nil {
+		return nil, err
+	}
+	source, destination := userBaseAccount, userQuoteAccount
+	poolSource, poolDestination := pool.TokenAAccount, pool.TokenBAccount
+	if inputMint == pool.TokenBMint.String() {
+		source, destination = userQuoteAccount, userBaseAccount
+		poolSource, poolDestination = pool.TokenBAccount, pool.TokenAAccount
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(authority),
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(poolSource).WRITE(),
+		solana.Meta(poolDestination).WRITE(),
+		solana.Meta(pool.PoolMint).WRITE(),
+		solana.Meta(pool.FeeAccount).WRITE(),
+		solana.Meta(pool.TokenProgram),
+		solana.Meta(pool.OracleMain),
+		solana.Meta(pool.OracleSub),
+		solana.Meta(pool.OraclePc),
+	}
+	data := make([]byte, 0, 24)
+	data = append(data, SwapDiscriminator...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_amount_out back from the swap instruction
+func (pool *LifinityPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, SwapDiscriminator, 16)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in the swap instruction
+func (pool *LifinityPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SwapDiscriminator, Offset: 8},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SwapDiscriminator, Offset: 16}
+}
@@ -0,0 +1,52 @@
+// Package marinade routes through Marinade's liquid staking program as swaps:
+// depositing SOL returns mSOL, partly sold from the liquidity pool's mSOL leg
+// and the rest minted, and liquid unstaking sells mSOL to the pool's SOL leg
+// for a fee that grows as that leg drains
+package marinade
+
+import "github.com/gagliardetto/solana-go"
+
+var (
+	// ProgramID is the Marinade liquid staking program
+	ProgramID = solana.MustPublicKeyFromBase58("MarBmsSgKXdrN1egZf5sqe1TMai9K1rChYNDJgjq7aD")
+	// StateAddress is Marinade's state account, the only pool it has
+	StateAddress = solana.MustPublicKeyFromBase58("8szGkuLTAux9XMgZ2vtY39jVSowEcpBfFfD8hXSEqdGC")
+	// MSOLMint is the mSOL mint
+	MSOLMint = solana.MustPublicKeyFromBase58("mSoLzYCxHdYgdzU16g5QSh3i5K3z3KZK7ytfqcJm7So")
+)
+
+// Seeds of the PDAs derived from the state account
+const (
+	reserveSeed           = "reserve"
+	msolMintAuthoritySeed = "st_mint"
+	solLegSeed            = "liq_sol"
+	msolLegAuthoritySeed  = "liq_st_sol_authority"
+)
+
+const (
+	// basisPointsDenominator is the denominator of the liquidity pool fees
+	basisPointsDenominator = 10_000
+	// stateMinSize covers every State field read, up to the paused flag
+	stateMinSize = pausedOffset + 1
+)
+
+// Offsets of the State account fields read for quoting, after the 8 byte
+// Anchor discriminator
+const (
+	msolMintOffset                  = 8
+	treasuryMsolAccountOffset       = 104
+	rentExemptForTokenAccOffset     = 138
+	delayedUnstakeCoolingDownOffset = 226
+	totalActiveBalanceOffset        = 376
+	msolLegOffset                   = 420
+	lpLiquidityTargetOffset         = 452
+	lpMaxFeeOffset                  = 460
+	lpMinFeeOffset                  = 464
+	availableReserveBalanceOffset   = 496
+	msolSupplyOffset                = 504
+	circulatingTicketBalanceOffset  = 528
+	minDepositOffset                = 544
+	stakingSolCapOffset             = 560
+	emergencyCoolingDownOffset      = 568
+	pausedOffset                    = 608
+)
@@ -0,0 +1,54 @@
+package marinade
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DecodeSwap parses a deposit or a liquid unstake as a swap between SOL and
+// mSOL. The SOL side is the user's system account, named by the WSOL mint,
+// and the minimum out is zero since the program takes none
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	deposit := bytes.HasPrefix(data, anchor.GetDiscriminator("global", "deposit"))
+	if !deposit && !bytes.HasPrefix(data, anchor.GetDiscriminator("global", "liquid_unstake")) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 16 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:     pkg.ProtocolNameMarinade,
+		AmountIn:     math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut: math.ZeroInt(),
+	}
+	if deposit {
+		if err := pkg.CheckSwapAccounts(accounts, 11); err != nil {
+			return nil, err
+		}
+		params.Pool = accounts[0].PublicKey
+		params.User = accounts[6].PublicKey
+		params.UserInputAccount = accounts[6].PublicKey
+		params.UserOutputAccount = accounts[7].PublicKey
+		params.InputMint = sol.WSOL
+		params.OutputMint = accounts[1].PublicKey
+		return params, nil
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 10); err != nil {
+		return nil, err
+	}
+	params.Pool = accounts[0].PublicKey
+	params.User = accounts[6].PublicKey
+	params.UserInputAccount = accounts[5].PublicKey
+	params.UserOutputAccount = accounts[7].PublicKey
+	params.InputMint = accounts[1].PublicKey
+	params.OutputMint = sol.WSOL
+	return params, nil
+}
@@ -0,0 +1,367 @@
+package marinade
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MarinadePool is Marinade's state traded as a pool between SOL and mSOL.
+// SOL moves as native lamports, so routes need no WSOL around it
+type MarinadePool struct {
+	PoolId              solana.PublicKey
+	MsolMint            solana.PublicKey
+	TreasuryMsolAccount solana.PublicKey
+	MsolLeg             solana.PublicKey
+
+	RentExemptForTokenAcc     uint64
+	DelayedUnstakeCoolingDown uint64
+	TotalActiveBalance        uint64
+	AvailableReserveBalance   uint64
+	MsolSupply                uint64
+	CirculatingTicketBalance  uint64
+	EmergencyCoolingDown      uint64
+	MinDeposit                uint64
+	StakingSolCap             uint64
+	Paused                    bool
+
+	// LpLiquidityTarget, LpMaxFeeBps and LpMinFeeBps shape the liquid unstake
+	// fee, which falls from the max to the min as the SOL leg left after the
+	// unstake grows towards the target
+	LpLiquidityTarget uint64
+	LpMaxFeeBps       uint32
+	LpMinFeeBps       uint32
+
+	// MsolLegAmount and SolLegLamports are the liquidity pool's balances as of
+	// the last quote
+	MsolLegAmount  uint64
+	SolLegLamports uint64
+}
+
+func (pool *MarinadePool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameMarinade
+}
+
+func (pool *MarinadePool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *MarinadePool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns mSOL as base and SOL, by its WSOL mint, as quote
+func (pool *MarinadePool) GetTokens() (string, string) {
+	return pool.MsolMint.String(), sol.WSOL.String()
+}
+
+// UsesNativeSOL reports that deposits take and liquid unstakes pay native lamports
+func (pool *MarinadePool) UsesNativeSOL(mint string) bool {
+	return mint == sol.WSOL.String()
+}
+
+// Decode parses the State account fields used for quoting and building swaps
+func (pool *MarinadePool) Decode(data []byte) error {
+	if len(data) < stateMinSize {
+		return fmt.Errorf("state account too short: %d bytes", len(data))
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	u32 := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.MsolMint = key(msolMintOffset)
+	pool.TreasuryMsolAccount = key(treasuryMsolAccountOffset)
+	pool.MsolLeg = key(msolLegOffset)
+	pool.RentExemptForTokenAcc = u64(rentExemptForTokenAccOffset)
+	pool.DelayedUnstakeCoolingDown = u64(delayedUnstakeCoolingDownOffset)
+	pool.TotalActiveBalance = u64(totalActiveBalanceOffset)
+	pool.LpLiquidityTarget = u64(lpLiquidityTargetOffset)
+	pool.LpMaxFeeBps = u32(lpMaxFeeOffset)
+	pool.LpMinFeeBps = u32(lpMinFeeOffset)
+	pool.AvailableReserveBalance = u64(availableReserveBalanceOffset)
+	pool.MsolSupply = u64(msolSupplyOffset)
+	pool.CirculatingTicketBalance = u64(circulatingTicketBalanceOffset)
+	pool.MinDeposit = u64(minDepositOffset)
+	pool.StakingSolCap = u64(stakingSolCapOffset)
+	pool.EmergencyCoolingDown = u64(emergencyCoolingDownOffset)
+	pool.Paused = data[pausedOffset] != 0
+	return nil
+}
+
+// Quote refreshes the state and both liquidity pool legs, then prices a SOL
+// deposit or an mSOL liquid unstake
+func (pool *MarinadePool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	stateData, err := solClient.GetAccountInfoWithOpts(ctx, pool.PoolId)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to get marinade state: %w", err)
+	}
+	if err := pool.Decode(stateData.Value.Data.GetBinary()); err != nil {
+		return math.ZeroInt(), err
+	}
+	solLeg, err := pool.solLeg()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.MsolLeg, solLeg})
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	msolLegAmount, ok := sol.TokenAccountAmount(results, 0)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("liquidity pool mSOL leg %s not found", pool.MsolLeg)
+	}
+	pool.MsolLegAmount = msolLegAmount
+	pool.SolLegLamports = 0
+	if len(results.Value) > 1 && results.Value[1] != nil {
+		pool.SolLegLamports = results.Value[1].Lamports
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// totalVirtualStakedLamports is the SOL backing the mSOL supply, which with
+// it sets the exchange rate
+func (pool *MarinadePool) totalVirtualStakedLamports() math.Int {
+	total := pool.totalLamportsUnderControl()
+	tickets := math.NewIntFromUint64(pool.CirculatingTicketBalance)
+	if total.LT(tickets) {
+		return math.ZeroInt()
+	}
+	return total.Sub(tickets)
+}
+
+func (pool *MarinadePool) totalLamportsUnderControl() math.Int {
+	return math.NewIntFromUint64(pool.TotalActiveBalance).
+		Add(math.NewIntFromUint64(pool.DelayedUnstakeCoolingDown)).
+		Add(math.NewIntFromUint64(pool.EmergencyCoolingDown)).
+		Add(math.NewIntFromUint64(pool.AvailableReserveBalance))
+}
+
+// msolFromLamports and lamportsFromMsol convert at the exchange rate,
+// rounding down as the program does
+func (pool *MarinadePool) msolFromLamports(lamports math.Int) math.Int {
+	total := pool.totalVirtualStakedLamports()
+	if pool.MsolSupply == 0 || total.IsZero() {
+		return lamports
+	}
+	return lamports.Mul(math.NewIntFromUint64(pool.MsolSupply)).Quo(total)
+}
+
+func (pool *MarinadePool) lamportsFromMsol(msol math.Int) math.Int {
+	if pool.MsolSupply == 0 {
+		return msol
+	}
+	return msol.Mul(pool.totalVirtualStakedLamports()).Quo(math.NewIntFromUint64(pool.MsolSupply))
+}
+
+// SolValue is the lamports msol is worth at the exchange rate, the value
+// Sanctum's Marinade SOL value calculator gives it
+func (pool *MarinadePool) SolValue(msol math.Int) math.Int {
+	return pool.lamportsFromMsol(msol)
+}
+
+// MsolForSolValue is the mSOL worth lamports at the exchange rate, rounded down
+func (pool *MarinadePool) MsolForSolValue(lamports math.Int) math.Int {
+	return pool.msolFromLamports(lamports)
+}
+
+// availableSolLiquidity is what the SOL leg can pay while staying rent exempt
+func (pool *MarinadePool) availableSolLiquidity() math.Int {
+	if pool.SolLegLamports <= pool.RentExemptForTokenAcc {
+		return math.ZeroInt()
+	}
+	return math.NewIntFromUint64(pool.SolLegLamports - pool.RentExemptForTokenAcc)
+}
+
+// unstakeFeeBps is the liquid unstake fee on an unstake worth lamports,
+// priced by the SOL the leg keeps afterwards
+func (pool *MarinadePool) unstakeFeeBps(lamports math.Int) uint32 {
+	available := pool.availableSolLiquidity()
+	if lamports.GTE(available) {
+		return pool.LpMaxFeeBps
+	}
+	after := available.Sub(lamports)
+	target := math.NewIntFromUint64(pool.LpLiquidityTarget)
+	if after.GTE(target) || pool.LpMaxFeeBps < pool.LpMinFeeBps {
+		return pool.LpMinFeeBps
+	}
+	delta := math.NewInt(int64(pool.LpMaxFeeBps - pool.LpMinFeeBps)).Mul(after).Quo(target)
+	return pool.LpMaxFeeBps - uint32(delta.Int64())
+}
+
+// ComputeAmountOut prices inputAmount from the cached state with the
+// program's integer math. A deposit first buys mSOL from the liquidity pool's
+// mSOL leg and mints the rest, both at the exchange rate
+func (pool *MarinadePool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if pool.Paused {
+		return math.ZeroInt(), fmt.Errorf("marinade is paused")
+	}
+
+	switch inputMint {
+	case sol.WSOL.String():
+		if inputAmount.LT(math.NewIntFromUint64(pool.MinDeposit)) {
+			return math.ZeroInt(), fmt.Errorf("deposit of %s lamports is below the %d minimum", inputAmount, pool.MinDeposit)
+		}
+		order := pool.msolFromLamports(inputAmount)
+		fromLeg := math.MinInt(order, math.NewIntFromUint64(pool.MsolLegAmount))
+		if fromLeg.Equal(order) {
+			return order, nil
+		}
+		staked := inputAmount
+		if fromLeg.IsPositive() {
+			staked = inputAmount.Sub(pool.lamportsFromMsol(fromLeg))
+		}
+		if pool.totalLamportsUnderControl().Add(staked).GT(math.NewIntFromUint64(pool.StakingSolCap)) {
+			return math.ZeroInt(), fmt.Errorf("deposit of %s lamports exceeds the staking cap", inputAmount)
+		}
+		return fromLeg.Add(pool.msolFromLamports(staked)), nil
+	case pool.MsolMint.String():
+		fee := pool.SwapFee(inputMint, inputAmount)
+		lamports := pool.lamportsFromMsol(inputAmount.Sub(fee))
+		if !lamports.IsPositive() {
+			return math.ZeroInt(), fmt.Errorf("unstake of %s mSOL is too small", inputAmount)
+		}
+		if lamports.GT(pool.availableSolLiquidity()) {
+			return math.ZeroInt(), fmt.Errorf("unstake of %s lamports exceeds the %s the liquidity pool holds", lamports, pool.availableSolLiquidity())
+		}
+		return lamports, nil
+	}
+	return math.ZeroInt(), fmt.Errorf("mint %s is not traded by marinade", inputMint)
+}
+
+// MinOutUnencoded reports that neither a deposit nor a liquid unstake
+// encodes a minimum output; the build prices the cached state against minOut
+func (pool *MarinadePool) MinOutUnencoded(inputMint string) bool {
+	return true
+}
+
+// BuildSwapInstructions builds a deposit or a liquid unstake. Neither takes
+// a minimum output, so the cached state is priced instead and the build fails
+// when it already falls short of minOut
+func (pool *MarinadePool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	expected, err := pool.ComputeAmountOut(inputMint, inputAmount)
+	if err != nil {
+		return nil, err
+	}
+	if expected.LT(minOut) {
+		return nil, fmt.Errorf("marinade pays %s, below the minimum %s", expected, minOut)
+	}
+	solLeg, err := pool.solLeg()
+	if err != nil {
+		return nil, err
+	}
+
+	switch inputMint {
+	case sol.WSOL.String():
+		msolLegAuthority, err := pool.pda(msolLegAuthoritySeed)
+		if err != nil {
+			return nil, err
+		}
+		reserve, err := pool.pda(reserveSeed)
+		if err != nil {
+			return nil, err
+		}
+		mintAuthority, err := pool.pda(msolMintAuthoritySeed)
+		if err != nil {
+			return nil, err
+		}
+		accounts := solana.AccountMetaSlice{
+			solana.Meta(pool.PoolId).WRITE(),
+			solana.Meta(pool.MsolMint).WRITE(),
+			solana.Meta(solLeg).WRITE(),
+			solana.Meta(pool.MsolLeg).WRITE(),
+			solana.Meta(msolLegAuthority),
+			solana.Meta(reserve).WRITE(),
+			solana.Meta(user).WRITE().SIGNER(),
+			solana.Meta(userBaseAccount).WRITE(),
+			solana.Meta(mintAuthority),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(solana.TokenProgramID),
+		}
+		return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, instructionData("deposit", inputAmount))}, nil
+	case pool.MsolMint.String():
+		accounts := solana.AccountMetaSlice{
+			solana.Meta(pool.PoolId).WRITE(),
+			solana.Meta(pool.MsolMint).WRITE(),
+			solana.Meta(solLeg).WRITE(),
+			solana.Meta(pool.MsolLeg).WRITE(),
+			solana.Meta(pool.TreasuryMsolAccount).WRITE(),
+			solana.Meta(userBaseAccount).WRITE(),
+			solana.Meta(user).SIGNER(),
+			solana.Meta(user).WRITE(),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(solana.TokenProgramID),
+		}
+		return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, instructionData("liquid_unstake", inputAmount))}, nil
+	}
+	return nil, fmt.Errorf("mint %s is not traded by marinade", inputMint)
+}
+
+// instructionData is an Anchor instruction taking a single u64 amount
+func instructionData(name string, amount math.Int) []byte {
+	data := make([]byte, 16)
+	copy(data, anchor.GetDiscriminator("global", name))
+	binary.LittleEndian.PutUint64(data[8:], amount.Uint64())
+	return data
+}
+
+func (pool *MarinadePool) pda(seed string) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{pool.PoolId[:], []byte(seed)}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive %s PDA: %w", seed, err)
+	}
+	return address, nil
+}
+
+// solLeg is the system account holding the liquidity pool's SOL
+func (pool *MarinadePool) solLeg() (solana.PublicKey, error) {
+	return pool.pda(solLegSeed)
+}
+
+// SwapFee returns the liquid unstake fee in mSOL; deposits are free
+func (pool *MarinadePool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	if inputMint == sol.WSOL.String() {
+		return math.ZeroInt()
+	}
+	bps := pool.unstakeFeeBps(pool.lamportsFromMsol(inputAmount))
+	return inputAmount.Mul(math.NewInt(int64(bps))).QuoRaw(basisPointsDenominator)
+}
+
+// MaxInputForImpact returns the largest input the pool takes: the exchange
+// rate does not move with size, so only the staking cap bounds deposits and
+// the SOL leg bounds liquid unstakes
+func (pool *MarinadePool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if inputMint == sol.WSOL.String() {
+		underControl := pool.totalLamportsUnderControl()
+		capacity := math.NewIntFromUint64(pool.StakingSolCap)
+		if underControl.GTE(capacity) {
+			// deposits still fill from the mSOL leg when staking is capped
+			return pool.lamportsFromMsol(math.NewIntFromUint64(pool.MsolLegAmount)), nil
+		}
+		return capacity.Sub(underControl).Add(pool.lamportsFromMsol(math.NewIntFromUint64(pool.MsolLegAmount))), nil
+	}
+	return pool.msolFromLamports(pool.availableSolLiquidity()), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	cosmosmath "cosmossdk.io/math"
 	"lukechampine.com/uint128"
 )
 
@@ -30,29 +31,29 @@ func (bin *Bin) IsEmpty(isX bool) bool {
 }
 
 // GetMaxAmountOut returns the maximum amount that can be swapped out for the given direction
-func (bin *Bin) GetMaxAmountOut(swapForY bool) uint64 {
+func (bin *Bin) GetMaxAmountOut(swapForY bool) cosmosmath.Int {
 	if swapForY {
-		return bin.amountY
+		return cosmosmath.NewIntFromUint64(bin.amountY)
 	}
-	return bin.amountX
+	return cosmosmath.NewIntFromUint64(bin.amountX)
 }
 
 // GetAmountOut calculates the output amount for a given input amount and price
 // Uses rounding down for both swap directions
-func (bin *Bin) GetAmountOut(amountIn uint64, price uint128.Uint128, swapForY bool) (*big.Int, error) {
+func (bin *Bin) GetAmountOut(amountIn cosmosmath.Int, price uint128.Uint128, swapForY bool) (cosmosmath.Int, error) {
 	if swapForY {
 		// Calculate: price * amountIn >> SCALE_OFFSET (rounding down)
-		return SafeMulShrCast(
+		return SafeMulShr(
 			price.Big(),
-			big.NewInt(int64(amountIn)),
+			amountIn.BigInt(),
 			ScaleOffset,
 			RoundingDown,
 		)
 	}
 
 	// Calculate: (amountIn << SCALE_OFFSET) / price (rounding down)
-	return SafeShlDivCast(
-		big.NewInt(int64(amountIn)),
+	return SafeShlDiv(
+		amountIn.BigInt(),
 		price.Big(),
 		ScaleOffset,
 		RoundingDown,
@@ -61,11 +62,11 @@ func (bin *Bin) GetAmountOut(amountIn uint64, price uint128.Uint128, swapForY bo
 
 // GetMaxAmountIn calculates the maximum input amount that can be swapped for the given price
 // Uses rounding up for both swap directions
-func (bin *Bin) GetMaxAmountIn(price uint128.Uint128, swapForY bool) (*big.Int, error) {
+func (bin *Bin) GetMaxAmountIn(price uint128.Uint128, swapForY bool) (cosmosmath.Int, error) {
 	if swapForY {
 		// Calculate: amountY << SCALE_OFFSET / price (rounding up)
-		return SafeShlDivCast(
-			big.NewInt(int64(bin.amountY)),
+		return SafeShlDiv(
+			new(big.Int).SetUint64(bin.amountY),
 			price.Big(),
 			ScaleOffset,
 			RoundingUp,
@@ -73,8 +74,8 @@ func (bin *Bin) GetMaxAmountIn(price uint128.Uint128, swapForY bool) (*big.Int,
 	}
 
 	// Calculate: amountX * price >> SCALE_OFFSET (rounding up)
-	return SafeMulShrCast(
-		big.NewInt(int64(bin.amountX)),
+	return SafeMulShr(
+		new(big.Int).SetUint64(bin.amountX),
 		price.Big(),
 		ScaleOffset,
 		RoundingUp,
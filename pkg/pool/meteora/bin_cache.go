@@ -0,0 +1,83 @@
+package meteora
+
+import (
+	"math"
+
+	"github.com/solana-zh/solroute/pkg/lru"
+)
+
+const (
+	// DefaultMaxBinArrays bounds the bin arrays a DLMM pool keeps across
+	// quotes when MaxBinArrays is not set
+	DefaultMaxBinArrays = 16
+
+	// DefaultBinArrayDepth is how many bin arrays with liquidity are loaded
+	// in each direction from the active bin when BinArrayDepth is not set
+	DefaultBinArrayDepth = 4
+)
+
+// binArrayDepth is the number of bin arrays the pool loads in each direction
+func (pool *MeteoraDlmmPool) binArrayDepth() int {
+	if pool.BinArrayDepth <= 0 {
+		return DefaultBinArrayDepth
+	}
+	return min(pool.BinArrayDepth, math.MaxUint8)
+}
+
+// maxBinArrayDepth bounds how far a quote running out of bin arrays loads in
+// its direction: half the cache, leaving the other direction its share
+func (pool *MeteoraDlmmPool) maxBinArrayDepth() int {
+	return min(pool.binArrayLimit()/2, math.MaxUint8)
+}
+
+// binArrayLimit is the pool's bin array bound, never below what one quote loads
+func (pool *MeteoraDlmmPool) binArrayLimit() int {
+	limit := pool.MaxBinArrays
+	if limit <= 0 {
+		limit = DefaultMaxBinArrays
+	}
+	return max(limit, 2*pool.binArrayDepth())
+}
+
+// storeBinArray caches binArray under its account key as the most recently
+// used one, evicting the least recently used arrays beyond the pool's limit.
+// Every cached array is passed to the swap instruction, so the bound also
+// keeps swaps within the account limit
+func (pool *MeteoraDlmmPool) storeBinArray(key string, binArray BinArray) {
+	if pool.BinArrays == nil {
+		pool.BinArrays = make(map[string]BinArray)
+	}
+	if pool.binArrayUse == nil || pool.binArrayUse.Capacity() != pool.binArrayLimit() {
+		pool.binArrayUse = lru.NewTracker(pool.binArrayLimit())
+		for cached := range pool.BinArrays {
+			for _, evicted := range pool.binArrayUse.Touch(cached) {
+				delete(pool.BinArrays, evicted)
+			}
+		}
+	}
+	pool.BinArrays[key] = binArray
+	for _, evicted := range pool.binArrayUse.Touch(key) {
+		delete(pool.BinArrays, evicted)
+	}
+}
+
+// cachedBinArray returns the cached bin array at key and marks it as used
+func (pool *MeteoraDlmmPool) cachedBinArray(key string) (BinArray, bool) {
+	binArray, ok := pool.BinArrays[key]
+	if ok && pool.binArrayUse != nil && pool.binArrayUse.Has(key) {
+		pool.binArrayUse.Touch(key)
+	}
+	return binArray, ok
+}
+
+// Reset drops the pool's cached bin arrays; the next quote fetches what it
+// needs again
+func (pool *MeteoraDlmmPool) Reset() {
+	if pool.stream != nil {
+		pool.stream.Lock()
+		defer pool.stream.Unlock()
+		pool.stream.MarkStale()
+	}
+	pool.BinArrays = nil
+	pool.binArrayUse = nil
+}
@@ -82,3 +82,113 @@ const (
 	ActivationTypeSlot      ActivationType = iota // Activated at a specific slot
 	ActivationTypeTimestamp                       // Activated at a specific timestamp
 )
+
+// Dynamic AMM constants. Pools hold no tokens themselves: each side sits in a
+// Meteora dynamic vault, and the pool owns LP tokens of both vaults
+var (
+	// DammProgramID is the Meteora Dynamic AMM program ID
+	DammProgramID = solana.MustPublicKeyFromBase58("Eo7WjKq67rjJQSZxS6z3YkapzY3eMj6Xy8X5EQVn5UaB")
+	// VaultProgramID is the Meteora dynamic vault program ID
+	VaultProgramID = solana.MustPublicKeyFromBase58("24Uqj9JCLxUeoC3hGfh5W3s9FM9uCHDS2SG3LYwBpyTi")
+
+	// DammPoolDiscriminator prefixes Dynamic AMM pool accounts
+	DammPoolDiscriminator = []byte{241, 154, 109, 4, 17, 177, 109, 188}
+	// VaultDiscriminator prefixes dynamic vault accounts
+	VaultDiscriminator = []byte{211, 8, 232, 43, 2, 152, 117, 119}
+	// DammSwapDiscriminator is the Dynamic AMM swap instruction
+	DammSwapDiscriminator = []byte{248, 198, 158, 145, 225, 117, 135, 200}
+)
+
+// Dynamic AMM pool account layout
+const (
+	DammTokenAMintOffset = 40
+	DammTokenBMintOffset = 72
+
+	dammLpMintOffset            = 8
+	dammAVaultOffset            = 104
+	dammBVaultOffset            = 136
+	dammAVaultLpOffset          = 168
+	dammBVaultLpOffset          = 200
+	dammEnabledOffset           = 233
+	dammProtocolTokenAFeeOffset = 234
+	dammProtocolTokenBFeeOffset = 266
+	dammFeesOffset              = 330
+	dammCurveTypeOffset         = 874
+	dammMinPoolSize             = dammCurveTypeOffset + 1
+)
+
+// Dynamic vault account layout
+const (
+	vaultEnabledOffset                 = 8
+	vaultTotalAmountOffset             = 11
+	vaultTokenVaultOffset              = 19
+	vaultTokenMintOffset               = 83
+	vaultLpMintOffset                  = 115
+	vaultLockedProfitOffset            = 1203
+	vaultSize                          = vaultLockedProfitOffset + 24
+	lockedProfitDegradationDenominator = 1_000_000_000_000
+)
+
+// DammCurveType is the invariant a Dynamic AMM pool trades on
+type DammCurveType uint8
+
+const (
+	DammCurveConstantProduct DammCurveType = iota // x * y = k
+	DammCurveStable                               // stableswap with an amplification factor
+)
+
+// DAMM v2 (cp-amm) constants. Pools are single range concentrated liquidity
+// with their own token vaults
+var (
+	// DammV2ProgramID is the Meteora DAMM v2 program ID
+	DammV2ProgramID = solana.MustPublicKeyFromBase58("cpamdpZCGKUy5JxQXB4dcpGPiikHawvSWAd6mEn1sGG")
+	// DammV2PoolAuthority signs for the vaults of every DAMM v2 pool
+	DammV2PoolAuthority = solana.MustPublicKeyFromBase58("HLnpSz9h2S4hiLQ43rnSD9XkcUThA7B8hQMKmDaiTLcC")
+
+	// DammV2PoolDiscriminator prefixes DAMM v2 pool accounts
+	DammV2PoolDiscriminator = []byte{241, 154, 109, 4, 17, 177, 109, 188}
+	// DammV2SwapDiscriminator is the DAMM v2 swap instruction
+	DammV2SwapDiscriminator = []byte{248, 198, 158, 145, 225, 117, 135, 200}
+)
+
+// DAMM v2 pool account layout
+const (
+	DammV2PoolSize         = 1112
+	DammV2TokenAMintOffset = 168
+	DammV2TokenBMintOffset = 200
+
+	dammV2BaseFeeOffset      = 8
+	dammV2DynamicFeeOffset   = 56
+	dammV2TokenAVaultOffset  = 232
+	dammV2TokenBVaultOffset  = 264
+	dammV2LiquidityOffset    = 360
+	dammV2SqrtMinPriceOffset = 424
+	dammV2SqrtMaxPriceOffset = 440
+	dammV2SqrtPriceOffset    = 456
+	dammV2ActivationOffset   = 472
+	dammV2StatusOffset       = 481
+	dammV2TokenAFlagOffset   = 482
+	dammV2CollectFeeOffset   = 484
+
+	// dammV2FeeDenominator is the denominator of fee numerators
+	dammV2FeeDenominator = 1_000_000_000
+	// dammV2MaxFeeNumerator caps base plus dynamic fee at 50%
+	dammV2MaxFeeNumerator = 500_000_000
+)
+
+// FeeSchedulerMode is how a DAMM v2 pool's base fee decays from its cliff
+// fee after activation
+type FeeSchedulerMode uint8
+
+const (
+	FeeSchedulerLinear      FeeSchedulerMode = iota // minus a fixed reduction per period
+	FeeSchedulerExponential                         // times (1 - reduction bps) per period
+)
+
+// CollectFeeMode is which token a DAMM v2 pool charges its fee in
+type CollectFeeMode uint8
+
+const (
+	CollectFeeBothTokens CollectFeeMode = iota // fee on the output token
+	CollectFeeOnlyB                            // fee always in token B
+)
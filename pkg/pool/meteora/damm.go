@@ -0,0 +1,400 @@
+package meteora
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MeteoraDammPool is a Meteora Dynamic AMM pool. Its reserves are the pool's
+// shares of two dynamic vaults, so quotes read the vaults and their LP mints
+// along with the pool. Token A and token B are the base and quote mints
+type MeteoraDammPool struct {
+	PoolId            solana.PublicKey
+	LpMint            solana.PublicKey
+	TokenAMint        solana.PublicKey
+	TokenBMint        solana.PublicKey
+	AVault            solana.PublicKey
+	BVault            solana.PublicKey
+	AVaultLp          solana.PublicKey
+	BVaultLp          solana.PublicKey
+	Enabled           bool
+	ProtocolTokenAFee solana.PublicKey
+	ProtocolTokenBFee solana.PublicKey
+
+	TradeFeeNumerator           uint64
+	TradeFeeDenominator         uint64
+	ProtocolTradeFeeNumerator   uint64
+	ProtocolTradeFeeDenominator uint64
+	CurveType                   DammCurveType
+
+	// BaseReserve and QuoteReserve are the token A and B amounts the pool's
+	// vault shares withdraw, cached by the last Quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+
+	// vaults holds the vaults of token A and B as of the last quote
+	vaults [2]*DynamicVault
+	// poolLp is the pool's LP balance in each vault and lpSupply the supply
+	// of each vault's LP mint
+	poolLp   [2]uint64
+	lpSupply [2]uint64
+	// liquid is what each vault holds in its token account, the most a
+	// swap can withdraw without the vault recalling lent funds
+	liquid [2]uint64
+	// now is the cluster unix time of the last quote, which sets the locked
+	// vault profit
+	now uint64
+}
+
+func (pool *MeteoraDammPool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameMeteoraDamm
+}
+
+func (pool *MeteoraDammPool) GetProgramID() solana.PublicKey {
+	return DammProgramID
+}
+
+func (pool *MeteoraDammPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token A as base and token B as quote
+func (pool *MeteoraDammPool) GetTokens() (string, string) {
+	return pool.TokenAMint.String(), pool.TokenBMint.String()
+}
+
+// Decode parses a Dynamic AMM pool account
+func (pool *MeteoraDammPool) Decode(data []byte) error {
+	if len(data) < dammMinPoolSize {
+		return fmt.Errorf("dynamic amm pool account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, DammPoolDiscriminator) {
+		return fmt.Errorf("not a dynamic amm pool account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.LpMint = key(dammLpMintOffset)
+	pool.TokenAMint = key(DammTokenAMintOffset)
+	pool.TokenBMint = key(DammTokenBMintOffset)
+	pool.AVault = key(dammAVaultOffset)
+	pool.BVault = key(dammBVaultOffset)
+	pool.AVaultLp = key(dammAVaultLpOffset)
+	pool.BVaultLp = key(dammBVaultLpOffset)
+	pool.Enabled = data[dammEnabledOffset] != 0
+	pool.ProtocolTokenAFee = key(dammProtocolTokenAFeeOffset)
+	pool.ProtocolTokenBFee = key(dammProtocolTokenBFeeOffset)
+	pool.TradeFeeNumerator = u64(dammFeesOffset)
+	pool.TradeFeeDenominator = u64(dammFeesOffset + 8)
+	pool.ProtocolTradeFeeNumerator = u64(dammFeesOffset + 16)
+	pool.ProtocolTradeFeeDenominator = u64(dammFeesOffset + 24)
+	pool.CurveType = DammCurveType(data[dammCurveTypeOffset])
+	if pool.TradeFeeDenominator == 0 {
+		return fmt.Errorf("dynamic amm pool has a zero fee denominator")
+	}
+	return nil
+}
+
+// Supported reports whether the pool trades on the constant product curve,
+// the only one the quote math here implements
+func (pool *MeteoraDammPool) Supported() bool {
+	return pool.CurveType == DammCurveConstantProduct
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the vaults already loaded
+func (pool *MeteoraDammPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*MeteoraDammPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	vaults := pool.vaults
+	*pool = *fresh
+	if vaults[0] != nil && vaults[0].TokenMint.Equals(pool.TokenAMint) &&
+		vaults[1] != nil && vaults[1].TokenMint.Equals(pool.TokenBMint) {
+		pool.vaults = vaults
+	}
+	return true
+}
+
+// ensureVaults loads both vaults when no quote has yet, for the addresses of
+// their token accounts and LP mints
+func (pool *MeteoraDammPool) ensureVaults(ctx context.Context, solClient *sol.Client) error {
+	if pool.vaults[0] != nil && pool.vaults[1] != nil {
+		return nil
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.AVault, pool.BVault})
+	if err != nil {
+		return fmt.Errorf("failed to fetch pool vaults: %w", err)
+	}
+	var vaults [2]*DynamicVault
+	for i, address := range []solana.PublicKey{pool.AVault, pool.BVault} {
+		data, ok := sol.AccountData(results, i)
+		if !ok {
+			return fmt.Errorf("vault %s not found", address)
+		}
+		vault := &DynamicVault{}
+		if err := vault.Decode(data); err != nil {
+			return fmt.Errorf("failed to decode vault %s: %w", address, err)
+		}
+		vaults[i] = vault
+	}
+	pool.vaults = vaults
+	return nil
+}
+
+// Quote refreshes the pool, both vaults, the pool's vault shares and the
+// clock in one batch, then prices inputAmount as the program would
+func (pool *MeteoraDammPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !pool.Supported() {
+		return math.ZeroInt(), fmt.Errorf("stable curve dynamic amm pool %s is not supported", pool.PoolId)
+	}
+	if err := pool.ensureVaults(ctx, solClient); err != nil {
+		return math.ZeroInt(), err
+	}
+	accounts := []solana.PublicKey{
+		pool.PoolId,
+		pool.AVault, pool.BVault,
+		pool.AVaultLp, pool.BVaultLp,
+		pool.vaults[0].LpMint, pool.vaults[1].LpMint,
+		pool.vaults[0].TokenVault, pool.vaults[1].TokenVault,
+		solana.SysVarClockPubkey,
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode dynamic amm pool %s: %w", pool.PoolId, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if data, ok := sol.AccountData(results, 1+i); ok {
+			if err := pool.vaults[i].Decode(data); err != nil {
+				return math.ZeroInt(), fmt.Errorf("failed to decode vault %s: %w", accounts[1+i], err)
+			}
+		}
+		var ok bool
+		if pool.poolLp[i], ok = sol.TokenAccountAmount(results, 3+i); !ok {
+			return math.ZeroInt(), fmt.Errorf("vault LP account %s not found", accounts[3+i])
+		}
+		if pool.lpSupply[i], ok = sol.MintSupply(results, 5+i); !ok {
+			return math.ZeroInt(), fmt.Errorf("vault LP mint %s not found", accounts[5+i])
+		}
+		if pool.liquid[i], ok = sol.TokenAccountAmount(results, 7+i); !ok {
+			return math.ZeroInt(), fmt.Errorf("vault token account %s not found", accounts[7+i])
+		}
+	}
+	data, ok := sol.AccountData(results, 9)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	pool.now = clock.UnixTimestamp
+	pool.BaseReserve = math.NewIntFromBigInt(pool.reserve(0))
+	pool.QuoteReserve = math.NewIntFromBigInt(pool.reserve(1))
+
+	if !pool.Enabled {
+		return math.ZeroInt(), fmt.Errorf("dynamic amm pool %s is disabled", pool.PoolId)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// reserve returns what the pool's share of vault i withdraws
+func (pool *MeteoraDammPool) reserve(i int) *big.Int {
+	return pool.vaults[i].amountByShare(pool.now, new(big.Int).SetUint64(pool.poolLp[i]), new(big.Int).SetUint64(pool.lpSupply[i]))
+}
+
+// ComputeAmountOut prices inputAmount against the cached pool and vault
+// state. The protocol fee is skimmed off the input before it is deposited
+// into the input vault, and the trading fee off what the deposit is worth.
+// The output is what the curve pays rounded through the output vault's LP
+func (pool *MeteoraDammPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if pool.vaults[0] == nil || pool.vaults[1] == nil {
+		return math.ZeroInt(), fmt.Errorf("pool vaults not loaded")
+	}
+	in := 0
+	switch inputMint {
+	case pool.TokenAMint.String():
+	case pool.TokenBMint.String():
+		in = 1
+	default:
+		return math.ZeroInt(), fmt.Errorf("mint %s is not traded by dynamic amm pool %s", inputMint, pool.PoolId)
+	}
+	out := 1 - in
+	amount := inputAmount.BigInt()
+
+	tradeFee := calculateFee(amount, pool.TradeFeeNumerator, pool.TradeFeeDenominator)
+	protocolFee := calculateFee(tradeFee, pool.ProtocolTradeFeeNumerator, pool.ProtocolTradeFeeDenominator)
+	tradeFee.Sub(tradeFee, protocolFee)
+	deposit := new(big.Int).Sub(amount, protocolFee)
+
+	// the deposit is measured by how much the pool's vault share grows
+	inVault := *pool.vaults[in]
+	lpAmount := new(big.Int).SetUint64(pool.poolLp[in])
+	lpSupply := new(big.Int).SetUint64(pool.lpSupply[in])
+	before := inVault.amountByShare(pool.now, lpAmount, lpSupply)
+	minted := inVault.unmintAmount(pool.now, deposit, lpSupply)
+	total := new(big.Int).Add(new(big.Int).SetUint64(inVault.TotalAmount), deposit)
+	if !total.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("vault total exceeds uint64")
+	}
+	inVault.TotalAmount = total.Uint64()
+	after := inVault.amountByShare(pool.now, new(big.Int).Add(lpAmount, minted), new(big.Int).Add(lpSupply, minted))
+	curveIn := after.Sub(after, before)
+	curveIn.Sub(curveIn, tradeFee)
+	if curveIn.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("amount %s does not cover the trading fee", inputAmount)
+	}
+
+	swapped, err := constantProductSwap(curveIn, pool.reserve(in), pool.reserve(out))
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	outVault := pool.vaults[out]
+	outSupply := new(big.Int).SetUint64(pool.lpSupply[out])
+	burnt := outVault.unmintAmount(pool.now, swapped, outSupply)
+	amountOut := outVault.amountByShare(pool.now, burnt, outSupply)
+	if amountOut.Cmp(new(big.Int).SetUint64(pool.liquid[out])) >= 0 {
+		return math.ZeroInt(), fmt.Errorf("output %s exceeds the liquid vault balance %d", amountOut, pool.liquid[out])
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// calculateFee is the SPL token-swap fee: amount * numerator / denominator
+// rounded down, but at least 1 for a nonzero amount and rate
+func calculateFee(amount *big.Int, numerator, denominator uint64) *big.Int {
+	if numerator == 0 || denominator == 0 || amount.Sign() == 0 {
+		return new(big.Int)
+	}
+	fee := new(big.Int).Mul(amount, new(big.Int).SetUint64(numerator))
+	fee.Quo(fee, new(big.Int).SetUint64(denominator))
+	if fee.Sign() == 0 {
+		return big.NewInt(1)
+	}
+	return fee
+}
+
+// constantProductSwap returns the output of the SPL constant product curve,
+// which rounds the new destination reserve up
+func constantProductSwap(amountIn, reserveIn, reserveOut *big.Int) (*big.Int, error) {
+	invariant := new(big.Int).Mul(reserveIn, reserveOut)
+	newReserveIn := new(big.Int).Add(reserveIn, amountIn)
+	newReserveOut, remainder := new(big.Int).QuoRem(invariant, newReserveIn, new(big.Int))
+	if newReserveOut.Sign() == 0 {
+		return nil, fmt.Errorf("swap would drain the pool")
+	}
+	if remainder.Sign() > 0 {
+		newReserveOut.Add(newReserveOut, big.NewInt(1))
+	}
+	return newReserveOut.Sub(reserveOut, newReserveOut), nil
+}
+
+// SwapFee returns the trading fee charged on inputAmount, protocol share included
+func (pool *MeteoraDammPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return math.NewIntFromBigInt(calculateFee(inputAmount.BigInt(), pool.TradeFeeNumerator, pool.TradeFeeDenominator))
+}
+
+// Reserves returns the token A and B amounts cached by the last Quote
+func (pool *MeteoraDammPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the ratio of the reserves cached by the last Quote
+func (pool *MeteoraDammPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	return pkg.PriceFromReserves(pool.BaseReserve, pool.QuoteReserve, inputMint == pool.TokenAMint.String())
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price, grossed up by the trading fee
+func (pool *MeteoraDammPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return math.ZeroInt(), fmt.Errorf("pool reserves not loaded")
+	}
+	reserveIn := pool.BaseReserve
+	if inputMint == pool.TokenBMint.String() {
+		reserveIn = pool.QuoteReserve
+	}
+	// the curve price is reserveOut / (reserveIn + a), within b of spot while a <= reserveIn * b / (1 - b)
+	bps := math.NewInt(int64(maxImpactBps))
+	curveIn := reserveIn.Mul(bps).Quo(math.NewInt(10000).Sub(bps))
+	denominator := math.NewIntFromUint64(pool.TradeFeeDenominator)
+	return curveIn.Mul(denominator).Quo(denominator.Sub(math.NewIntFromUint64(pool.TradeFeeNumerator))), nil
+}
+
+// BuildSwapInstructions builds a Dynamic AMM swap. The protocol fee is paid
+// into the pool's fee account of the input token
+func (pool *MeteoraDammPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	if err := pool.ensureVaults(ctx, solClient); err != nil {
+		return nil, err
+	}
+	source, destination, protocolFee := userBaseAccount, userQuoteAccount, pool.ProtocolTokenAFee
+	if inputMint == pool.TokenBMint.String() {
+		source, destination, protocolFee = userQuoteAccount, userBaseAccount, pool.ProtocolTokenBFee
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(pool.AVault).WRITE(),
+		solana.Meta(pool.BVault).WRITE(),
+		solana.Meta(pool.vaults[0].TokenVault).WRITE(),
+		solana.Meta(pool.vaults[1].TokenVault).WRITE(),
+		solana.Meta(pool.vaults[0].LpMint).WRITE(),
+		solana.Meta(pool.vaults[1].LpMint).WRITE(),
+		solana.Meta(pool.AVaultLp).WRITE(),
+		solana.Meta(pool.BVaultLp).WRITE(),
+		solana.Meta(protocolFee).WRITE(),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(VaultProgramID),
+		solana.Meta(solana.TokenProgramID),
+	}
+	data := make([]byte, 0, 24)
+	data = append(data, DammSwapDiscriminator...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(DammProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_out_amount back from the swap instruction
+func (pool *MeteoraDammPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, DammProgramID, DammSwapDiscriminator, 16)
+}
+
+// SwapAmountFields locates in_amount and minimum_out_amount in the swap instruction
+func (pool *MeteoraDammPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: DammProgramID, Prefix: DammSwapDiscriminator, Offset: 8},
+		pkg.AmountField{ProgramID: DammProgramID, Prefix: DammSwapDiscriminator, Offset: 16}
+}
@@ -0,0 +1,32 @@
+package meteora
+
+import (
+	"context"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDammDecodeMinOut(t *testing.T) {
+	pool := &MeteoraDammPool{
+		PoolId:     solana.NewWallet().PublicKey(),
+		TokenAMint: solana.NewWallet().PublicKey(),
+		TokenBMint: solana.NewWallet().PublicKey(),
+		vaults:     [2]*DynamicVault{{}, {}},
+	}
+	minOut := math.NewInt(1_234_567)
+	for _, inputMint := range []solana.PublicKey{pool.TokenAMint, pool.TokenBMint} {
+		instructions, err := pool.BuildSwapInstructions(context.Background(), nil, solana.NewWallet().PublicKey(), inputMint.String(), math.NewInt(5_000_000), minOut, solana.PublicKey{}, solana.PublicKey{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := pool.DecodeMinOut(inputMint.String(), instructions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !encoded.Equal(minOut) {
+			t.Fatalf("swap from %s encoded min out %s, want %s", inputMint, encoded, minOut)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package meteora
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"lukechampine.com/uint128"
+)
+
+// DammV2BaseFee is a DAMM v2 pool's base fee schedule. From activation the
+// fee starts at CliffFeeNumerator and decays every PeriodFrequency points
+// for NumberOfPeriod periods
+type DammV2BaseFee struct {
+	CliffFeeNumerator uint64
+	SchedulerMode     FeeSchedulerMode
+	NumberOfPeriod    uint16
+	PeriodFrequency   uint64
+	ReductionFactor   uint64
+}
+
+// DammV2DynamicFee is the volatility fee added on top of the base fee
+type DammV2DynamicFee struct {
+	Initialized           bool
+	VariableFeeControl    uint32
+	BinStep               uint16
+	VolatilityAccumulator uint128.Uint128
+}
+
+// MeteoraDammV2Pool is a Meteora DAMM v2 (cp-amm) pool: one concentrated
+// liquidity range between SqrtMinPrice and SqrtMaxPrice. Token A and token B
+// are the base and quote mints
+type MeteoraDammV2Pool struct {
+	PoolId      solana.PublicKey
+	TokenAMint  solana.PublicKey
+	TokenBMint  solana.PublicKey
+	TokenAVault solana.PublicKey
+	TokenBVault solana.PublicKey
+
+	BaseFee    DammV2BaseFee
+	DynamicFee DammV2DynamicFee
+
+	Liquidity    uint128.Uint128
+	SqrtMinPrice uint128.Uint128
+	SqrtMaxPrice uint128.Uint128
+	SqrtPrice    uint128.Uint128
+	// ActivationPoint is the slot or unix time swaps open at, by ActivationType
+	ActivationPoint uint64
+	ActivationType  ActivationType
+	// Disabled pools reject swaps
+	Disabled       bool
+	TokenAIs2022   bool
+	TokenBIs2022   bool
+	CollectFeeMode CollectFeeMode
+
+	// clock is the cluster clock of the last quote, whose slot or time sets
+	// the scheduled base fee
+	clock *sol.Clock
+	// fees holds the Token-2022 transfer fees of the mints, nil without one
+	fees [2]*sol.TransferFeeConfig
+}
+
+func (pool *MeteoraDammV2Pool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameMeteoraDammV2
+}
+
+func (pool *MeteoraDammV2Pool) GetProgramID() solana.PublicKey {
+	return DammV2ProgramID
+}
+
+func (pool *MeteoraDammV2Pool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token A as base and token B as quote
+func (pool *MeteoraDammV2Pool) GetTokens() (string, string) {
+	return pool.TokenAMint.String(), pool.TokenBMint.String()
+}
+
+// OpensAt returns the activation time of pools activated by timestamp. Slot
+// activated pools report the zero time and are checked by Quote instead
+func (pool *MeteoraDammV2Pool) OpensAt() time.Time {
+	if pool.ActivationType != ActivationTypeTimestamp || pool.ActivationPoint == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(pool.ActivationPoint), 0)
+}
+
+// Decode parses a DAMM v2 pool account
+func (pool *MeteoraDammV2Pool) Decode(data []byte) error {
+	if len(data) < DammV2PoolSize {
+		return fmt.Errorf("damm v2 pool account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, DammV2PoolDiscriminator) {
+		return fmt.Errorf("not a damm v2 pool account")
+	}
+	u16 := func(offset int) uint16 { return binary.LittleEndian.Uint16(data[offset:]) }
+	u32 := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[offset:]) }
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.BaseFee = DammV2BaseFee{
+		CliffFeeNumerator: u64(dammV2BaseFeeOffset),
+		SchedulerMode:     FeeSchedulerMode(data[dammV2BaseFeeOffset+8]),
+		NumberOfPeriod:    u16(dammV2BaseFeeOffset + 14),
+		PeriodFrequency:   u64(dammV2BaseFeeOffset + 16),
+		ReductionFactor:   u64(dammV2BaseFeeOffset + 24),
+	}
+	pool.DynamicFee = DammV2DynamicFee{
+		Initialized:           data[dammV2DynamicFeeOffset] != 0,
+		VariableFeeControl:    u32(dammV2DynamicFeeOffset + 12),
+		BinStep:               u16(dammV2DynamicFeeOffset + 16),
+		VolatilityAccumulator: uint128.FromBytes(data[dammV2DynamicFeeOffset+64:]),
+	}
+	pool.TokenAMint = key(DammV2TokenAMintOffset)
+	pool.TokenBMint = key(DammV2TokenBMintOffset)
+	pool.TokenAVault = key(dammV2TokenAVaultOffset)
+	pool.TokenBVault = key(dammV2TokenBVaultOffset)
+	pool.Liquidity = uint128.FromBytes(data[dammV2LiquidityOffset:])
+	pool.SqrtMinPrice = uint128.FromBytes(data[dammV2SqrtMinPriceOffset:])
+	pool.SqrtMaxPrice = uint128.FromBytes(data[dammV2SqrtMaxPriceOffset:])
+	pool.SqrtPrice = uint128.FromBytes(data[dammV2SqrtPriceOffset:])
+	pool.ActivationPoint = u64(dammV2ActivationOffset)
+	pool.ActivationType = ActivationType(data[dammV2ActivationOffset+8])
+	pool.Disabled = data[dammV2StatusOffset] != 0
+	pool.TokenAIs2022 = data[dammV2TokenAFlagOffset] != 0
+	pool.TokenBIs2022 = data[dammV2TokenAFlagOffset+1] != 0
+	pool.CollectFeeMode = CollectFeeMode(data[dammV2CollectFeeOffset])
+	if pool.BaseFee.SchedulerMode > FeeSchedulerExponential {
+		return fmt.Errorf("unknown fee scheduler mode %d", pool.BaseFee.SchedulerMode)
+	}
+	if pool.CollectFeeMode > CollectFeeOnlyB {
+		return fmt.Errorf("unknown collect fee mode %d", pool.CollectFeeMode)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the clock and transfer fees of the last quote
+func (pool *MeteoraDammV2Pool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*MeteoraDammV2Pool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	clock, fees := pool.clock, pool.fees
+	*pool = *fresh
+	pool.clock, pool.fees = clock, fees
+	return true
+}
+
+// tokenProgram returns the token program of mint i, 0 for token A
+func (pool *MeteoraDammV2Pool) tokenProgram(i int) solana.PublicKey {
+	if (i == 0 && pool.TokenAIs2022) || (i == 1 && pool.TokenBIs2022) {
+		return solana.Token2022ProgramID
+	}
+	return solana.TokenProgramID
+}
+
+// Quote refreshes the pool, both mints and the clock in one batch, then
+// returns what the user receives for inputAmount net of the fee in force and
+// of Token-2022 transfer fees on both the input and the output
+func (pool *MeteoraDammV2Pool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.PoolId, pool.TokenAMint, pool.TokenBMint, solana.SysVarClockPubkey})
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode damm v2 pool %s: %w", pool.PoolId, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		pool.fees[i] = nil
+		if data, ok := sol.AccountData(results, 1+i); ok && pool.tokenProgram(i).Equals(solana.Token2022ProgramID) {
+			if config, ok := sol.ParseTransferFeeConfig(data); ok {
+				pool.fees[i] = config
+			}
+		}
+	}
+	data, ok := sol.AccountData(results, 3)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	if pool.clock, err = sol.ParseClock(data); err != nil {
+		return math.ZeroInt(), err
+	}
+
+	if pool.Disabled {
+		return math.ZeroInt(), fmt.Errorf("damm v2 pool %s is disabled", pool.PoolId)
+	}
+	if point := pool.currentPoint(); point < pool.ActivationPoint {
+		return math.ZeroInt(), fmt.Errorf("damm v2 pool %s activates at %d, now %d", pool.PoolId, pool.ActivationPoint, point)
+	}
+
+	aToB := inputMint == pool.TokenAMint.String()
+	curveIn := inputAmount.Sub(pool.transferFee(inputMint, inputAmount))
+	amountOut, err := pool.ComputeAmountOut(inputMint, curveIn)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	outputMint := pool.TokenBMint.String()
+	if !aToB {
+		outputMint = pool.TokenAMint.String()
+	}
+	return amountOut.Sub(pool.transferFee(outputMint, amountOut)), nil
+}
+
+// transferFee returns the Token-2022 fee on a transfer of amount of mint
+func (pool *MeteoraDammV2Pool) transferFee(mint string, amount math.Int) math.Int {
+	i := 0
+	if mint == pool.TokenBMint.String() {
+		i = 1
+	}
+	if pool.clock == nil {
+		return math.ZeroInt()
+	}
+	return pool.fees[i].Fee(pool.clock.Epoch, amount)
+}
+
+// currentPoint is the slot or unix time of the last quote, as the pool's
+// activation type counts them
+func (pool *MeteoraDammV2Pool) currentPoint() uint64 {
+	if pool.clock == nil {
+		return 0
+	}
+	if pool.ActivationType == ActivationTypeTimestamp {
+		return pool.clock.UnixTimestamp
+	}
+	return pool.clock.Slot
+}
+
+// ComputeAmountOut prices inputAmount against the cached pool state, before
+// transfer fees. The fee is taken off the input when the pool collects it in
+// the input token and off the output otherwise
+func (pool *MeteoraDammV2Pool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	var aToB bool
+	switch inputMint {
+	case pool.TokenAMint.String():
+		aToB = true
+	case pool.TokenBMint.String():
+	default:
+		return math.ZeroInt(), fmt.Errorf("mint %s is not traded by damm v2 pool %s", inputMint, pool.PoolId)
+	}
+	feeNumerator := pool.FeeNumerator()
+	feeOnInput := pool.feeOnInput(aToB)
+
+	amount := inputAmount.BigInt()
+	if feeOnInput {
+		amount.Sub(amount, tradingFee(amount, feeNumerator))
+	}
+	amountOut, err := pool.swap(aToB, amount)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if !feeOnInput {
+		amountOut.Sub(amountOut, tradingFee(amountOut, feeNumerator))
+	}
+	if !amountOut.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("output exceeds uint64")
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// feeOnInput reports whether the pool charges the fee on the input: only
+// pools collecting in token B swapping B for A do
+func (pool *MeteoraDammV2Pool) feeOnInput(aToB bool) bool {
+	return pool.CollectFeeMode == CollectFeeOnlyB && !aToB
+}
+
+// tradingFee is amount * feeNumerator / dammV2FeeDenominator rounded up
+func tradingFee(amount *big.Int, feeNumerator uint64) *big.Int {
+	fee := new(big.Int).Mul(amount, new(big.Int).SetUint64(feeNumerator))
+	return ceilDiv(fee, big.NewInt(dammV2FeeDenominator))
+}
+
+// SwapFee returns the fee in force charged on inputAmount, in the token the
+// pool collects it in
+func (pool *MeteoraDammV2Pool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return math.NewIntFromBigInt(tradingFee(inputAmount.BigInt(), pool.FeeNumerator()))
+}
+
+// RawSpotPrice is the pool price cached by the last Quote
+func (pool *MeteoraDammV2Pool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	return pkg.PriceFromSqrtX64(pool.SqrtPrice.Big(), inputMint == pool.TokenAMint.String())
+}
+
+// MaxInputForImpact treats the range as a constant product curve on its
+// virtual reserves, L / sqrt(P) of token A and L * sqrt(P) of token B, capped
+// at the input that moves the price to the end of the range
+func (pool *MeteoraDammV2Pool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.SqrtPrice.IsZero() || pool.Liquidity.IsZero() {
+		return math.ZeroInt(), fmt.Errorf("pool price not loaded")
+	}
+	aToB := inputMint == pool.TokenAMint.String()
+	liquidity, sqrtPrice := pool.Liquidity.Big(), pool.SqrtPrice.Big()
+
+	var virtualIn, rangeIn *big.Int
+	if aToB {
+		virtualIn = new(big.Int).Quo(liquidity, sqrtPrice)
+		rangeIn = amountADelta(pool.SqrtMinPrice.Big(), sqrtPrice, liquidity, true)
+	} else {
+		virtualIn = new(big.Int).Mul(liquidity, sqrtPrice)
+		virtualIn.Rsh(virtualIn, 128)
+		rangeIn = amountBDelta(sqrtPrice, pool.SqrtMaxPrice.Big(), liquidity, true)
+	}
+	bps := big.NewInt(int64(maxImpactBps))
+	curveIn := new(big.Int).Mul(virtualIn, bps)
+	curveIn.Quo(curveIn, new(big.Int).Sub(big.NewInt(10000), bps))
+	if curveIn.Cmp(rangeIn) > 0 {
+		curveIn = rangeIn
+	}
+	if pool.feeOnInput(aToB) {
+		denominator := big.NewInt(dammV2FeeDenominator)
+		curveIn.Mul(curveIn, denominator)
+		curveIn.Quo(curveIn, new(big.Int).Sub(denominator, new(big.Int).SetUint64(pool.FeeNumerator())))
+	}
+	return math.NewIntFromBigInt(curveIn), nil
+}
+
+// BuildSwapInstructions builds a DAMM v2 swap without a referral account
+func (pool *MeteoraDammV2Pool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	source, destination := userBaseAccount, userQuoteAccount
+	if inputMint == pool.TokenBMint.String() {
+		source, destination = userQuoteAccount, userBaseAccount
+	}
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(DammV2PoolAuthority),
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(pool.TokenAVault).WRITE(),
+		solana.Meta(pool.TokenBVault).WRITE(),
+		solana.Meta(pool.TokenAMint),
+		solana.Meta(pool.TokenBMint),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(pool.tokenProgram(0)),
+		solana.Meta(pool.tokenProgram(1)),
+		// the program ID stands in for the absent referral token account
+		solana.Meta(DammV2ProgramID),
+		solana.Meta(DeriveDammV2EventAuthorityPDA()),
+		solana.Meta(DammV2ProgramID),
+	}
+	data := make([]byte, 0, 24)
+	data = append(data, DammV2SwapDiscriminator...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(DammV2ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_amount_out back from the swap instruction
+func (pool *MeteoraDammV2Pool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, DammV2ProgramID, DammV2SwapDiscriminator, 16)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in the swap instruction
+func (pool *MeteoraDammV2Pool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: DammV2ProgramID, Prefix: DammV2SwapDiscriminator, Offset: 8},
+		pkg.AmountField{ProgramID: DammV2ProgramID, Prefix: DammV2SwapDiscriminator, Offset: 16}
+}
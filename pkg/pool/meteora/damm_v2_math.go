@@ -0,0 +1,145 @@
+package meteora
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// oneQ64 is 1.0 in Q64.64
+var oneQ64 = new(big.Int).Lsh(big.NewInt(1), 64)
+
+// FeeNumerator returns the fee in force at the last quoted clock over
+// dammV2FeeDenominator: the scheduled base fee plus the dynamic fee, capped
+// at dammV2MaxFeeNumerator
+func (pool *MeteoraDammV2Pool) FeeNumerator() uint64 {
+	fee := new(big.Int).SetUint64(pool.baseFeeNumerator(pool.currentPoint()))
+	fee.Add(fee, pool.variableFeeNumerator())
+	if fee.Cmp(big.NewInt(dammV2MaxFeeNumerator)) > 0 {
+		return dammV2MaxFeeNumerator
+	}
+	return fee.Uint64()
+}
+
+// baseFeeNumerator applies the fee schedule: the cliff fee reduced once for
+// every PeriodFrequency points passed since activation, at most NumberOfPeriod
+// times
+func (pool *MeteoraDammV2Pool) baseFeeNumerator(point uint64) uint64 {
+	schedule := pool.BaseFee
+	if schedule.PeriodFrequency == 0 || point < pool.ActivationPoint {
+		return schedule.CliffFeeNumerator
+	}
+	period := (point - pool.ActivationPoint) / schedule.PeriodFrequency
+	if period > uint64(schedule.NumberOfPeriod) {
+		period = uint64(schedule.NumberOfPeriod)
+	}
+
+	switch schedule.SchedulerMode {
+	case FeeSchedulerLinear:
+		reduction := new(big.Int).Mul(new(big.Int).SetUint64(period), new(big.Int).SetUint64(schedule.ReductionFactor))
+		fee := new(big.Int).SetUint64(schedule.CliffFeeNumerator)
+		if fee.Cmp(reduction) <= 0 {
+			return 0
+		}
+		return fee.Sub(fee, reduction).Uint64()
+	default:
+		// reduction factor is in basis points per period
+		cut := new(big.Int).Lsh(new(big.Int).SetUint64(schedule.ReductionFactor), 64)
+		cut.Quo(cut, big.NewInt(10000))
+		if cut.Cmp(oneQ64) >= 0 {
+			return 0
+		}
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(schedule.CliffFeeNumerator), powQ64(new(big.Int).Sub(oneQ64, cut), period))
+		return fee.Rsh(fee, 64).Uint64()
+	}
+}
+
+// variableFeeNumerator is the dynamic fee, growing with the square of the
+// volatility accumulated in bin steps
+func (pool *MeteoraDammV2Pool) variableFeeNumerator() *big.Int {
+	dynamic := pool.DynamicFee
+	if !dynamic.Initialized {
+		return new(big.Int)
+	}
+	volatility := new(big.Int).Mul(dynamic.VolatilityAccumulator.Big(), big.NewInt(int64(dynamic.BinStep)))
+	fee := volatility.Mul(volatility, volatility)
+	fee.Mul(fee, big.NewInt(int64(dynamic.VariableFeeControl)))
+	return ceilDiv(fee, big.NewInt(100_000_000_000))
+}
+
+// powQ64 raises the Q64.64 base to exp by squaring, truncating each product
+func powQ64(base *big.Int, exp uint64) *big.Int {
+	result := new(big.Int).Set(oneQ64)
+	square := new(big.Int).Set(base)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result.Mul(result, square).Rsh(result, 64)
+		}
+		square.Mul(square, square).Rsh(square, 64)
+	}
+	return result
+}
+
+// swap moves the price along the pool's range for amount of the input token
+// and returns the output, failing when the range cannot absorb amount
+func (pool *MeteoraDammV2Pool) swap(aToB bool, amount *big.Int) (*big.Int, error) {
+	liquidity, sqrtPrice := pool.Liquidity.Big(), pool.SqrtPrice.Big()
+	if liquidity.Sign() == 0 {
+		return nil, fmt.Errorf("damm v2 pool %s has no liquidity", pool.PoolId)
+	}
+	if amount.Sign() == 0 {
+		return new(big.Int), nil
+	}
+
+	if aToB {
+		// sqrt(P') = L * sqrt(P) / (L + amount * sqrt(P)), rounded up
+		numerator := new(big.Int).Mul(liquidity, sqrtPrice)
+		denominator := new(big.Int).Mul(amount, sqrtPrice)
+		next := ceilDiv(numerator, denominator.Add(denominator, liquidity))
+		if next.Cmp(pool.SqrtMinPrice.Big()) < 0 {
+			return nil, fmt.Errorf("swap exceeds the price range of damm v2 pool %s", pool.PoolId)
+		}
+		return amountBDelta(next, sqrtPrice, liquidity, false), nil
+	}
+	// sqrt(P') = sqrt(P) + amount / L, rounded down
+	next := new(big.Int).Lsh(amount, 128)
+	next.Quo(next, liquidity).Add(next, sqrtPrice)
+	if next.Cmp(pool.SqrtMaxPrice.Big()) > 0 {
+		return nil, fmt.Errorf("swap exceeds the price range of damm v2 pool %s", pool.PoolId)
+	}
+	return amountADelta(sqrtPrice, next, liquidity, false), nil
+}
+
+// amountADelta is the token A moving the price between two Q64 square root
+// prices: L * (upper - lower) / (lower * upper)
+func amountADelta(lower, upper, liquidity *big.Int, roundUp bool) *big.Int {
+	numerator := new(big.Int).Sub(upper, lower)
+	numerator.Mul(numerator, liquidity)
+	denominator := new(big.Int).Mul(lower, upper)
+	if denominator.Sign() == 0 {
+		return new(big.Int)
+	}
+	if roundUp {
+		return ceilDiv(numerator, denominator)
+	}
+	return numerator.Quo(numerator, denominator)
+}
+
+// amountBDelta is the token B moving the price between two Q64 square root
+// prices: L * (upper - lower) / 2^128
+func amountBDelta(lower, upper, liquidity *big.Int, roundUp bool) *big.Int {
+	product := new(big.Int).Sub(upper, lower)
+	product.Mul(product, liquidity)
+	if roundUp {
+		return ceilDiv(product, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return product.Rsh(product, 128)
+}
+
+// ceilDiv divides two non-negative integers rounding up
+func ceilDiv(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
@@ -0,0 +1,90 @@
+package meteora
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DynamicVault is a Meteora dynamic vault, which lends out part of its tokens
+// and issues LP tokens for shares of the total. Profit reported by its
+// strategies unlocks linearly, so withdrawals only see the unlocked amount
+type DynamicVault struct {
+	Enabled     bool
+	TotalAmount uint64
+	TokenVault  solana.PublicKey
+	TokenMint   solana.PublicKey
+	LpMint      solana.PublicKey
+
+	LastUpdatedLockedProfit uint64
+	LastReport              uint64
+	LockedProfitDegradation uint64
+}
+
+// Decode parses a dynamic vault account
+func (vault *DynamicVault) Decode(data []byte) error {
+	if len(data) < vaultSize {
+		return fmt.Errorf("vault account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, VaultDiscriminator) {
+		return fmt.Errorf("not a dynamic vault account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	vault.Enabled = data[vaultEnabledOffset] != 0
+	vault.TotalAmount = u64(vaultTotalAmountOffset)
+	vault.TokenVault = key(vaultTokenVaultOffset)
+	vault.TokenMint = key(vaultTokenMintOffset)
+	vault.LpMint = key(vaultLpMintOffset)
+	vault.LastUpdatedLockedProfit = u64(vaultLockedProfitOffset)
+	vault.LastReport = u64(vaultLockedProfitOffset + 8)
+	vault.LockedProfitDegradation = u64(vaultLockedProfitOffset + 16)
+	return nil
+}
+
+// lockedProfit returns the reported profit still locked at unix time now
+func (vault *DynamicVault) lockedProfit(now uint64) *big.Int {
+	if now < vault.LastReport {
+		return new(big.Int).SetUint64(vault.LastUpdatedLockedProfit)
+	}
+	ratio := new(big.Int).Mul(new(big.Int).SetUint64(now-vault.LastReport), new(big.Int).SetUint64(vault.LockedProfitDegradation))
+	denominator := big.NewInt(lockedProfitDegradationDenominator)
+	if ratio.Cmp(denominator) > 0 {
+		return new(big.Int)
+	}
+	locked := new(big.Int).Mul(new(big.Int).SetUint64(vault.LastUpdatedLockedProfit), ratio.Sub(denominator, ratio))
+	return locked.Quo(locked, denominator)
+}
+
+// unlockedAmount returns the tokens LP holders can withdraw at unix time now
+func (vault *DynamicVault) unlockedAmount(now uint64) *big.Int {
+	unlocked := new(big.Int).SetUint64(vault.TotalAmount)
+	unlocked.Sub(unlocked, vault.lockedProfit(now))
+	if unlocked.Sign() < 0 {
+		return new(big.Int)
+	}
+	return unlocked
+}
+
+// amountByShare returns the tokens share LP tokens out of lpSupply withdraw
+func (vault *DynamicVault) amountByShare(now uint64, share, lpSupply *big.Int) *big.Int {
+	if lpSupply.Sign() == 0 {
+		return new(big.Int)
+	}
+	amount := new(big.Int).Mul(share, vault.unlockedAmount(now))
+	return amount.Quo(amount, lpSupply)
+}
+
+// unmintAmount returns the LP tokens out of lpSupply that amount of tokens is worth
+func (vault *DynamicVault) unmintAmount(now uint64, amount, lpSupply *big.Int) *big.Int {
+	unlocked := vault.unlockedAmount(now)
+	if unlocked.Sign() == 0 {
+		return new(big.Int)
+	}
+	lp := new(big.Int).Mul(amount, lpSupply)
+	return lp.Quo(lp, unlocked)
+}
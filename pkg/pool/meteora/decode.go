@@ -0,0 +1,87 @@
+package meteora
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a Meteora DLMM swap2 instruction. The swap direction is
+// given by the mint of the user's input account, which the instruction does
+// not name, so the input and output mints are left zero
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, Swap2IxDiscm[:]) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 16); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameMeteoraDlmm,
+		Pool:              accounts[0].PublicKey,
+		UserInputAccount:  accounts[4].PublicKey,
+		UserOutputAccount: accounts[5].PublicKey,
+		User:              accounts[10].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24])),
+	}
+	return params, nil
+}
+
+// DecodeDammSwap parses a Meteora Dynamic AMM swap instruction. Like DLMM it
+// does not name the mints, so the input and output mints are left zero
+func DecodeDammSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, DammSwapDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 15); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameMeteoraDamm,
+		Pool:              accounts[0].PublicKey,
+		UserInputAccount:  accounts[1].PublicKey,
+		UserOutputAccount: accounts[2].PublicKey,
+		User:              accounts[12].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24])),
+	}
+	return params, nil
+}
+
+// DecodeDammV2Swap parses a Meteora DAMM v2 swap instruction. The accounts
+// list both mints but not which one is the input, so the mints are left zero
+func DecodeDammV2Swap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, DammV2SwapDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 14); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameMeteoraDammV2,
+		Pool:              accounts[1].PublicKey,
+		UserInputAccount:  accounts[2].PublicKey,
+		UserOutputAccount: accounts[3].PublicKey,
+		User:              accounts[8].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24])),
+	}
+	return params, nil
+}
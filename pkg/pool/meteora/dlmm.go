@@ -3,11 +3,12 @@ package meteora
 import (
 	"context"
 	"fmt"
-	"math/big"
 	"unsafe"
 
+	cosmosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
 	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/lru"
 	"github.com/solana-zh/solroute/pkg/sol"
 )
 
@@ -82,12 +83,24 @@ type MeteoraDlmmPool struct {
 	_                        [16]uint8        `bin:"borsh"` // padding to ensure 904 bytes total size
 
 	// Runtime fields (not part of on-chain data)
-	PoolId             solana.PublicKey
-	BinArrays          map[string]BinArray // key: binArrayPubkey
+	PoolId    solana.PublicKey
+	BinArrays map[string]BinArray // key: binArrayPubkey
+	// MaxBinArrays bounds BinArrays, evicting the least recently used arrays;
+	// zero means DefaultMaxBinArrays
+	MaxBinArrays int
+	// BinArrayDepth is how many bin arrays with liquidity are loaded, and
+	// streamed, in each direction from the active bin; zero means
+	// DefaultBinArrayDepth. A quote running past them loads more in its
+	// direction, up to half of MaxBinArrays
+	BinArrayDepth      int
+	binArrayUse        *lru.Tracker
 	BitmapExtensionKey solana.PublicKey
 	bitmapExtension    *BinArrayBitmapExtension
 	Clock              sol.Clock
 	orgActiveId        int32
+	// stream, when set, keeps the pair, clock and bin arrays current from
+	// account updates
+	stream *pkg.StreamState
 }
 
 func (pool *MeteoraDlmmPool) ProtocolName() pkg.ProtocolName {
@@ -120,6 +133,10 @@ func (pool *MeteoraDlmmPool) Offset(field string) uint64 {
 		return 120
 	case "TokenXMint":
 		return 88
+	case "BaseFeePowerFactor":
+		return 34
+	case "BinStep":
+		return 80
 	default:
 		return 0
 	}
@@ -336,42 +353,20 @@ func (pool *MeteoraDlmmPool) Decode(data []byte) error {
 }
 
 // ComputeFee calculates the fee for a given amount using ceiling division
-func (pool *MeteoraDlmmPool) ComputeFee(amount uint64) (uint64, error) {
+func (pool *MeteoraDlmmPool) ComputeFee(amount cosmosmath.Int) (cosmosmath.Int, error) {
 	// Get total fee rate
 	totalFeeRate, err := pool.GetTotalFee()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total fee: %w", err)
+		return cosmosmath.Int{}, fmt.Errorf("failed to get total fee: %w", err)
 	}
 
-	// Calculate denominator: FEE_PRECISION - totalFeeRate
-	feePrecision := new(big.Int).SetUint64(FeePrecision)
-	denominator := new(big.Int).Sub(feePrecision, totalFeeRate)
-	if denominator.Sign() <= 0 {
-		return 0, fmt.Errorf("denominator overflow or zero: feePrecision=%v, totalFeeRate=%v", feePrecision, totalFeeRate)
+	if !totalFeeRate.IsUint64() || totalFeeRate.Uint64() >= FeePrecision {
+		return cosmosmath.Int{}, fmt.Errorf("denominator overflow or zero: feePrecision=%v, totalFeeRate=%v", FeePrecision, totalFeeRate)
 	}
+	denominator := cosmosmath.NewIntFromUint64(FeePrecision - totalFeeRate.Uint64())
 
-	// Ceiling division calculation
 	// fee = (amount * totalFeeRate + denominator - 1) / denominator
-
-	// 1. amount * totalFeeRate
-	amountBig := new(big.Int).SetUint64(amount)
-	fee := new(big.Int).Mul(amountBig, totalFeeRate)
-
-	// 2. + denominator
-	fee.Add(fee, denominator)
-
-	// 3. - 1
-	fee.Sub(fee, big.NewInt(1))
-
-	// 4. / denominator
-	fee.Div(fee, denominator)
-
-	// Check if result exceeds uint64 range
-	if !fee.IsUint64() {
-		return 0, fmt.Errorf("fee exceeds uint64 range")
-	}
-
-	return fee.Uint64(), nil
+	return amount.Mul(cosmosmath.NewIntFromBigInt(totalFeeRate)).Add(denominator).SubRaw(1).Quo(denominator), nil
 }
 
 // UpdateClock fetches and updates the current clock information
@@ -386,43 +381,44 @@ func (pool *MeteoraDlmmPool) UpdateClock(ctx context.Context, client *sol.Client
 
 // GetBinArrayForSwap retrieves bin arrays needed for swap operations
 func (pool *MeteoraDlmmPool) GetBinArrayForSwap(ctx context.Context, client *sol.Client) error {
-	if pool.BinArrays == nil {
-		pool.BinArrays = make(map[string]BinArray) // Initialize bin array map
-	}
-
 	// Get active bin array public keys for both positive and negative orders
 	var activeBinArrayPubkeys []solana.PublicKey
 
-	positiveOrderActiveBinArrayPubkeys, err := pool.GetBinArrayPubkeysForSwap(true, 4)
+	positiveOrderActiveBinArrayPubkeys, err := pool.GetBinArrayPubkeysForSwap(true, uint8(pool.binArrayDepth()))
 	if err != nil {
 		return fmt.Errorf("failed to get positive order bin array pubkeys: %w", err)
 	}
 	activeBinArrayPubkeys = append(activeBinArrayPubkeys, positiveOrderActiveBinArrayPubkeys...)
 
-	negativeOrderActiveBinArrayPubkeys, err := pool.GetBinArrayPubkeysForSwap(false, 4)
+	negativeOrderActiveBinArrayPubkeys, err := pool.GetBinArrayPubkeysForSwap(false, uint8(pool.binArrayDepth()))
 	if err != nil {
 		return fmt.Errorf("failed to get negative order bin array pubkeys: %w", err)
 	}
 	activeBinArrayPubkeys = append(activeBinArrayPubkeys, negativeOrderActiveBinArrayPubkeys...)
+	return pool.loadBinArrays(ctx, client, activeBinArrayPubkeys)
+}
 
+// loadBinArrays fetches the bin arrays at pubkeys into the cache
+func (pool *MeteoraDlmmPool) loadBinArrays(ctx context.Context, client *sol.Client, pubkeys []solana.PublicKey) error {
 	// Fetch all bin array accounts in batch
-	results, err := client.GetMultipleAccountsWithOpts(ctx, activeBinArrayPubkeys)
+	results, err := client.GetMultipleAccountsWithOpts(ctx, pubkeys)
 	if err != nil {
 		return fmt.Errorf("batch request failed: %w", err)
 	}
 
 	// Parse and store bin arrays
-	for i, result := range results.Value {
-		if result == nil {
-			// Skip nil results (account doesn't exist)
+	for i := range pubkeys {
+		data, ok := sol.AccountData(results, i)
+		if !ok {
+			// Skip missing results (account doesn't exist)
 			continue
 		}
-		accountKey := activeBinArrayPubkeys[i].String()
-		binArray, err := ParseBinArray(result.Data.GetBinary())
+		accountKey := pubkeys[i].String()
+		binArray, err := ParseBinArray(data)
 		if err != nil {
 			return fmt.Errorf("failed to parse bin array for account %s: %w", accountKey, err)
 		}
-		pool.BinArrays[accountKey] = binArray
+		pool.storeBinArray(accountKey, binArray)
 	}
 	return nil
 }
@@ -0,0 +1,99 @@
+package meteora
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// lbPairSize is the size of a DLMM pair account
+const lbPairSize = 904
+
+// StreamAccounts returns the pair, the clock sysvar and the bin arrays a
+// quote walks on each side of the active bin, and follows them
+func (pool *MeteoraDlmmPool) StreamAccounts() []solana.PublicKey {
+	if pool.stream == nil {
+		pool.stream = &pkg.StreamState{}
+	}
+	pool.stream.Lock()
+	defer pool.stream.Unlock()
+
+	accounts := append([]solana.PublicKey{pool.PoolId, solana.SysVarClockPubkey}, pool.swapBinArrays()...)
+	pool.stream.Follow(accounts)
+	return accounts
+}
+
+// ApplyAccountUpdate applies an update of a followed account. Once the
+// active bin moves the bin arrays a quote walks past the ones followed, the
+// pool stops trusting them and reports the change
+func (pool *MeteoraDlmmPool) ApplyAccountUpdate(account solana.PublicKey, data []byte, slot uint64) (bool, error) {
+	if pool.stream == nil {
+		return false, fmt.Errorf("pool %s is not streamed", pool.PoolId)
+	}
+	pool.stream.Lock()
+	defer pool.stream.Unlock()
+	if !pool.stream.Accept(account, slot) {
+		return false, nil
+	}
+	changed, err := pool.applyStreamUpdate(account, data)
+	if err != nil {
+		// quotes fetch again rather than trust a cache missing this update
+		pool.stream.MarkStale()
+	}
+	return changed, err
+}
+
+// applyStreamUpdate applies the data of a followed account with the stream locked
+func (pool *MeteoraDlmmPool) applyStreamUpdate(account solana.PublicKey, data []byte) (bool, error) {
+	switch {
+	case account.Equals(pool.PoolId):
+		if len(data) < lbPairSize {
+			return false, fmt.Errorf("pool account %s too short: %d bytes", account, len(data))
+		}
+		fresh := *pool
+		if err := fresh.Decode(data); err != nil {
+			return false, fmt.Errorf("failed to decode pool %s: %w", account, err)
+		}
+		*pool = fresh
+		if pool.stream.Covers(pool.swapBinArrays()) {
+			return false, nil
+		}
+		pool.stream.MarkStale()
+		return true, nil
+	case account.Equals(solana.SysVarClockPubkey):
+		clock, err := sol.ParseClock(data)
+		if err != nil {
+			return false, err
+		}
+		pool.Clock = *clock
+		return false, nil
+	}
+
+	key := account.String()
+	if data == nil {
+		delete(pool.BinArrays, key)
+		if pool.binArrayUse != nil {
+			pool.binArrayUse.Remove(key)
+		}
+		return false, nil
+	}
+	binArray, err := ParseBinArray(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse bin array %s: %w", account, err)
+	}
+	if pda, _ := DeriveBinArrayPDA(pool.PoolId, binArray.index); !binArray.LbPair.Equals(pool.PoolId) || !pda.Equals(account) {
+		return false, fmt.Errorf("bin array %s does not belong to pool %s", account, pool.PoolId)
+	}
+	pool.storeBinArray(key, binArray)
+	return false, nil
+}
+
+// swapBinArrays returns the bin arrays with liquidity a quote may walk in
+// either direction from the active bin
+func (pool *MeteoraDlmmPool) swapBinArrays() []solana.PublicKey {
+	up, _ := pool.GetBinArrayPubkeysForSwap(false, uint8(pool.binArrayDepth()))
+	down, _ := pool.GetBinArrayPubkeysForSwap(true, uint8(pool.binArrayDepth()))
+	return append(up, down...)
+}
@@ -0,0 +1,120 @@
+package meteora
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	cosmosmath "cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// BinLiquidity is the liquidity held in one DLMM bin
+type BinLiquidity struct {
+	BinID int32
+	// Price is token Y per token X in raw units
+	Price   float64
+	AmountX uint64
+	AmountY uint64
+	Active  bool
+}
+
+// LiquidityDistribution returns the non-empty bins of the loaded bin arrays,
+// ordered by bin id. Bin arrays are loaded by Quote or GetBinArrayForSwap
+func (pool *MeteoraDlmmPool) LiquidityDistribution() []BinLiquidity {
+	base := 1 + float64(pool.binStep)/BasisPointMax
+	ladder := make([]BinLiquidity, 0)
+	for _, binArray := range pool.BinArrays {
+		lowerBinID, _, err := GetBinArrayLowerUpperBinID(int32(binArray.index))
+		if err != nil {
+			continue
+		}
+		for i, bin := range binArray.bins {
+			if bin.amountX == 0 && bin.amountY == 0 {
+				continue
+			}
+			id := lowerBinID + int32(i)
+			ladder = append(ladder, BinLiquidity{
+				BinID:   id,
+				Price:   math.Pow(base, float64(id)),
+				AmountX: bin.amountX,
+				AmountY: bin.amountY,
+				Active:  id == pool.activeId,
+			})
+		}
+	}
+	sort.Slice(ladder, func(i, j int) bool {
+		return ladder[i].BinID < ladder[j].BinID
+	})
+	return ladder
+}
+
+// RawSpotPrice is the price of the active bin cached by the last Quote
+func (pool *MeteoraDlmmPool) RawSpotPrice(inputMint string) (cosmosmath.LegacyDec, error) {
+	price, err := GetPriceFromID(pool.activeId, pool.binStep)
+	if err != nil {
+		return cosmosmath.LegacyDec{}, err
+	}
+	return pkg.PriceFromX64(price.Big(), inputMint == pool.TokenXMint.String())
+}
+
+// MaxInputForImpact walks the loaded bins away from the active one for the
+// largest input within maxImpactBps of the active bin price. The result is
+// capped by the liquidity of the loaded bin arrays
+func (pool *MeteoraDlmmPool) MaxInputForImpact(inputMint string, maxImpactBps int) (cosmosmath.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return cosmosmath.ZeroInt(), err
+	}
+	totalFee, err := pool.GetTotalFee()
+	if err != nil {
+		return cosmosmath.ZeroInt(), fmt.Errorf("failed to get total fee: %w", err)
+	}
+	feeRate := float64(totalFee.Uint64()) / FeePrecision
+
+	swapForY := inputMint == pool.TokenXMint.String()
+	ladder := pool.LiquidityDistribution()
+	if swapForY {
+		// selling X consumes Y from the active bin downwards
+		sort.Slice(ladder, func(i, j int) bool {
+			return ladder[i].BinID > ladder[j].BinID
+		})
+	}
+
+	// rate is output per unit of input after fees in a bin
+	rate := func(price float64) float64 {
+		if swapForY {
+			return price
+		}
+		return 1 / price
+	}
+	base := 1 + float64(pool.binStep)/BasisPointMax
+	floor := rate(math.Pow(base, float64(pool.activeId))) * (1 - float64(maxImpactBps)/10000)
+
+	amountIn, amountOut := 0.0, 0.0
+	for _, bin := range ladder {
+		if (swapForY && bin.BinID > pool.activeId) || (!swapForY && bin.BinID < pool.activeId) {
+			continue
+		}
+		binRate := rate(bin.Price)
+		available := float64(bin.AmountX)
+		if swapForY {
+			available = float64(bin.AmountY)
+		}
+		if available == 0 {
+			continue
+		}
+		capacity := available / binRate
+		if amountOut+available >= floor*(amountIn+capacity) {
+			amountIn += capacity
+			amountOut += available
+			continue
+		}
+		// the bound is crossed inside this bin: solve (out + r*x) / (in + x) = floor
+		amountIn += math.Max(0, (amountOut-floor*amountIn)/(floor-binRate))
+		break
+	}
+
+	gross, _ := big.NewFloat(amountIn / (1 - feeRate)).Int(nil)
+	return cosmosmath.NewIntFromBigInt(gross), nil
+}
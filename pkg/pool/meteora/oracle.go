@@ -0,0 +1,164 @@
+package meteora
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	oracleHeaderSize      = 8 + 8 + 8 + 8 // discriminator, idx, active_size, length
+	oracleObservationSize = 16 + 8 + 8    // cumulative_active_bin_id, created_at, last_updated_at
+)
+
+// OracleObservation is one sample of the DLMM oracle ring buffer
+type OracleObservation struct {
+	// CumulativeActiveBinID is the time weighted sum of the active bin id
+	CumulativeActiveBinID *big.Int
+	CreatedAt             int64
+	LastUpdatedAt         int64
+}
+
+// Oracle is the DLMM oracle account of a pool
+type Oracle struct {
+	Idx          uint64
+	ActiveSize   uint64
+	Length       uint64
+	Observations []OracleObservation
+}
+
+// PriceSample is the average price between two consecutive oracle observations
+type PriceSample struct {
+	From int64
+	To   int64
+	// AverageBinID is the time weighted active bin over the sample
+	AverageBinID float64
+	// Price is token Y per token X in raw units at AverageBinID
+	Price float64
+	// VolatilityBps is how far the average bin moved since the previous sample, in basis points
+	VolatilityBps float64
+}
+
+// DecodeOracle decodes a DLMM oracle account
+func DecodeOracle(data []byte) (*Oracle, error) {
+	if len(data) < oracleHeaderSize {
+		return nil, fmt.Errorf("invalid oracle data length: %d", len(data))
+	}
+	oracle := &Oracle{
+		Idx:        binary.LittleEndian.Uint64(data[8:16]),
+		ActiveSize: binary.LittleEndian.Uint64(data[16:24]),
+		Length:     binary.LittleEndian.Uint64(data[24:32]),
+	}
+	if oracle.ActiveSize > oracle.Length {
+		return nil, fmt.Errorf("invalid oracle active size %d > length %d", oracle.ActiveSize, oracle.Length)
+	}
+	if uint64(len(data)) < oracleHeaderSize+oracle.Length*oracleObservationSize {
+		return nil, fmt.Errorf("oracle data too short for %d observations", oracle.Length)
+	}
+
+	oracle.Observations = make([]OracleObservation, oracle.Length)
+	for i := range oracle.Observations {
+		offset := oracleHeaderSize + i*oracleObservationSize
+		oracle.Observations[i] = OracleObservation{
+			CumulativeActiveBinID: int128FromLE(data[offset : offset+16]),
+			CreatedAt:             int64(binary.LittleEndian.Uint64(data[offset+16 : offset+24])),
+			LastUpdatedAt:         int64(binary.LittleEndian.Uint64(data[offset+24 : offset+32])),
+		}
+	}
+	return oracle, nil
+}
+
+// Samples returns the written observations from oldest to newest
+func (o *Oracle) Samples() []OracleObservation {
+	if o.ActiveSize == 0 {
+		return nil
+	}
+	samples := make([]OracleObservation, 0, o.ActiveSize)
+	start := uint64(0)
+	if o.ActiveSize == o.Length {
+		// the buffer has wrapped; the oldest sample follows the newest one
+		start = (o.Idx + 1) % o.Length
+	}
+	for i := uint64(0); i < o.ActiveSize; i++ {
+		samples = append(samples, o.Observations[(start+i)%o.ActiveSize])
+	}
+	return samples
+}
+
+// PriceHistory turns consecutive observations into average price samples
+func (o *Oracle) PriceHistory(binStep uint16) []PriceSample {
+	observations := o.Samples()
+	history := make([]PriceSample, 0, len(observations))
+	base := 1 + float64(binStep)/BasisPointMax
+	for i := 1; i < len(observations); i++ {
+		prev, next := observations[i-1], observations[i]
+		elapsed := next.LastUpdatedAt - prev.LastUpdatedAt
+		if elapsed <= 0 {
+			continue
+		}
+		delta := new(big.Int).Sub(next.CumulativeActiveBinID, prev.CumulativeActiveBinID)
+		deltaFloat, _ := new(big.Float).SetInt(delta).Float64()
+		averageBin := deltaFloat / float64(elapsed)
+
+		sample := PriceSample{
+			From:         prev.LastUpdatedAt,
+			To:           next.LastUpdatedAt,
+			AverageBinID: averageBin,
+			Price:        math.Pow(base, averageBin),
+		}
+		if len(history) > 0 {
+			sample.VolatilityBps = math.Abs(averageBin-history[len(history)-1].AverageBinID) * float64(binStep)
+		}
+		history = append(history, sample)
+	}
+	return history
+}
+
+// OracleAddress returns the pool's oracle account
+func (pool *MeteoraDlmmPool) OracleAddress() solana.PublicKey {
+	return pool.oracle
+}
+
+// VolatilityAccumulator returns the pool's current volatility accumulator
+func (pool *MeteoraDlmmPool) VolatilityAccumulator() uint32 {
+	return pool.vParameters.volatilityAccumulator
+}
+
+// FetchOracle loads the pool's oracle account
+func (pool *MeteoraDlmmPool) FetchOracle(ctx context.Context, client *sol.Client) (*Oracle, error) {
+	account, err := client.GetAccountInfoWithOpts(ctx, pool.oracle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oracle account: %w", err)
+	}
+	if account == nil || account.Value == nil {
+		return nil, fmt.Errorf("oracle account %s not found", pool.oracle)
+	}
+	return DecodeOracle(account.Value.Data.GetBinary())
+}
+
+// PriceHistory fetches the oracle and returns its price and volatility samples
+func (pool *MeteoraDlmmPool) PriceHistory(ctx context.Context, client *sol.Client) ([]PriceSample, error) {
+	oracle, err := pool.FetchOracle(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return oracle.PriceHistory(pool.binStep), nil
+}
+
+// int128FromLE decodes a little-endian two's complement i128
+func int128FromLE(data []byte) *big.Int {
+	be := make([]byte, 16)
+	for i := range be {
+		be[i] = data[15-i]
+	}
+	value := new(big.Int).SetBytes(be)
+	if data[15]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return value
+}
@@ -6,17 +6,55 @@ import (
 	"fmt"
 	"math"
 	"math/big"
-	"time"
 
 	cosmosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
 	"github.com/solana-zh/solroute/pkg/sol"
-	"lukechampine.com/uint128"
 )
 
-// Quote calculates the output amount for a given input amount and token
+// Quote calculates the output amount for a given input amount and token. A
+// quote walking past the loaded bin arrays loads more in its direction and
+// walks again, unless the pool is streamed or solClient is nil
 func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount cosmosmath.Int) (cosmosmath.Int, error) {
+	// a streamed pool that moved past its followed bin arrays loads them as
+	// discovery does until the stream catches up
+	if s := pool.stream; s != nil {
+		s.Lock()
+		defer s.Unlock()
+		if !s.Ready() {
+			if err := pool.UpdateClock(ctx, solClient); err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			if err := pool.GetBinArrayForSwap(ctx, solClient); err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+		}
+	}
+
+	swapForY := inputMint == pool.TokenXMint.String()
+	depth := pool.binArrayDepth()
+	for {
+		amountOut, err := pool.quote(ctx, inputMint, inputAmount)
+		if !errors.Is(err, errBinArrayNotLoaded) || solClient == nil || pool.stream != nil || depth >= pool.maxBinArrayDepth() {
+			return amountOut, err
+		}
+		depth = min(2*depth, pool.maxBinArrayDepth())
+		pubkeys, err := pool.GetBinArrayPubkeysForSwap(swapForY, uint8(depth))
+		if err != nil {
+			return cosmosmath.ZeroInt(), err
+		}
+		if err := pool.loadBinArrays(ctx, solClient, pubkeys); err != nil {
+			return cosmosmath.ZeroInt(), err
+		}
+	}
+}
+
+// quote walks the bins from the active one with the bin arrays loaded
+func (pool *MeteoraDlmmPool) quote(ctx context.Context, inputMint string, inputAmount cosmosmath.Int) (cosmosmath.Int, error) {
 	pool.orgActiveId = pool.activeId
+	// the walk moves the active bin; every exit, including a cancelled
+	// quote, puts it back
+	defer func() { pool.activeId = pool.orgActiveId }()
 	totalAmountOut := cosmosmath.ZeroInt()
 
 	if err := pool.validateSwapActivation(); err != nil {
@@ -37,6 +75,9 @@ func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, i
 
 		// Process active bins
 		for {
+			if err := ctx.Err(); err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
 			withinRange, err := activeBinArray.IsBinIDWithinRange(pool.activeId)
 			if err != nil {
 				return cosmosmath.ZeroInt(), fmt.Errorf("failed to check bin ID range: %w", err)
@@ -60,14 +101,14 @@ func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, i
 				if !activeBin.IsEmpty(!swapForY) {
 					swapResult, err := pool.Swap(
 						activeBin,
-						amountLeft.Uint64(),
+						amountLeft,
 						swapForY,
 					)
 					if err != nil {
 						return cosmosmath.ZeroInt(), fmt.Errorf("swap failed: %w", err)
 					}
-					amountLeft = amountLeft.Sub(cosmosmath.NewInt(int64(swapResult.amountInWithFees)))
-					totalAmountOut = totalAmountOut.Add(cosmosmath.NewInt(int64(swapResult.amountOut)))
+					amountLeft = amountLeft.Sub(swapResult.amountInWithFees)
+					totalAmountOut = totalAmountOut.Add(swapResult.amountOut)
 				}
 				if err := pool.AdvanceActiveBin(swapForY); err != nil {
 					return cosmosmath.ZeroInt(), fmt.Errorf("failed to advance active bin: %w", err)
@@ -76,13 +117,16 @@ func (pool *MeteoraDlmmPool) Quote(ctx context.Context, solClient *sol.Client, i
 		}
 	}
 
-	pool.activeId = pool.orgActiveId
 	return totalAmountOut, nil
 }
 
+// errBinArrayNotLoaded is returned by a quote walking into a bin array with
+// liquidity that is not cached
+var errBinArrayNotLoaded = errors.New("active bin array not found")
+
 // validateSwapActivation checks if the swap is allowed based on pair status and activation conditions
 func (pool *MeteoraDlmmPool) validateSwapActivation() error {
-	currentTimestamp := uint64(time.Now().Unix())
+	currentTimestamp := pool.Clock.UnixTimestamp
 	currentSlot := uint64(pool.Clock.Slot)
 
 	// Check pair status
@@ -130,19 +174,22 @@ func (pool *MeteoraDlmmPool) UpdateReferences() {
 // SwapResult represents the result of a swap operation
 type SwapResult struct {
 	// Amount of token swapped into the bin (including fees)
-	amountInWithFees uint64
+	amountInWithFees cosmosmath.Int
 	// Amount of token swapped out from the bin
-	amountOut uint64
+	amountOut cosmosmath.Int
 	// Swap fee, includes protocol fee
-	fee uint64
+	fee cosmosmath.Int
 	// Protocol fee portion
-	protocolFee uint64
+	protocolFee cosmosmath.Int
 	// Indicates whether we reached exact out amount
 	isExactOutAmount bool
 }
 
-// Swap performs a swap operation on a specific bin
-func (pool *MeteoraDlmmPool) Swap(bin *Bin, amountIn uint64, swapForY bool) (*SwapResult, error) {
+// Swap prices a swap of up to amountIn against a specific bin. Amounts are
+// carried as Int, so a large trade of a low-decimal token is neither
+// truncated nor capped to u64 on its way through the bins. The bin itself is
+// left as it was: a quote walks past every bin it swaps in
+func (pool *MeteoraDlmmPool) Swap(bin *Bin, amountIn cosmosmath.Int, swapForY bool) (*SwapResult, error) {
 	price, err := bin.GetOrStoreBinPrice(pool.activeId, pool.binStep)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bin price: %w", err)
@@ -153,68 +200,42 @@ func (pool *MeteoraDlmmPool) Swap(bin *Bin, amountIn uint64, swapForY bool) (*Sw
 	if err != nil {
 		return nil, fmt.Errorf("failed to get max amount in: %w", err)
 	}
-	maxFee, err := pool.ComputeFee(maxAmountIn.Uint64())
+	maxFee, err := pool.ComputeFee(maxAmountIn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute max fee: %w", err)
 	}
-	maxAmountIn = maxAmountIn.Add(maxAmountIn, big.NewInt(int64(maxFee))) // Go automatically checks overflow
+	maxAmountIn = maxAmountIn.Add(maxFee)
 
-	var (
-		amountInWithFees uint64
-		amountOut        uint64
-		fee              uint64
-		protocolFee      uint64
-	)
-
-	// Determine actual swap amount and fees
-	if amountIn > maxAmountIn.Uint64() {
-		amountInWithFees = maxAmountIn.Uint64()
-		amountOut = maxAmountOut
-		fee = maxFee
-		protocolFee, err = pool.ComputeProtocolFee(maxFee)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compute protocol fee: %w", err)
-		}
-	} else {
-		fee, err = pool.ComputeFeeFromAmount(amountIn)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compute fee from amount: %w", err)
-		}
-		amountInAfterFee := amountIn - fee
-		amountOutTemp, err := bin.GetAmountOut(amountInAfterFee, price, swapForY)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get amount out: %w", err)
-		}
-
-		amountOut = min(amountOutTemp.Uint64(), maxAmountOut)
-		amountInWithFees = amountIn
-
-		protocolFee, err = pool.ComputeProtocolFee(fee)
+	// the whole bin is taken when amountIn covers it with its fee
+	if amountIn.GT(maxAmountIn) {
+		protocolFee, err := pool.ComputeProtocolFee(maxFee)
 		if err != nil {
 			return nil, fmt.Errorf("failed to compute protocol fee: %w", err)
 		}
+		return &SwapResult{
+			amountInWithFees: maxAmountIn,
+			amountOut:        maxAmountOut,
+			fee:              maxFee,
+			protocolFee:      protocolFee,
+			isExactOutAmount: false,
+		}, nil
 	}
 
-	amountIntoBin := amountInWithFees - fee
-
-	// Update bin amounts
-	if swapForY {
-		bin.amountX += amountIntoBin
-		if bin.amountY < amountOut {
-			return nil, fmt.Errorf("insufficient Y amount")
-		}
-		bin.amountY -= amountOut
-	} else {
-		bin.amountY += amountIntoBin
-		if bin.amountX < amountOut {
-			return nil, fmt.Errorf("insufficient X amount")
-		}
-		bin.amountX -= amountOut
+	fee, err := pool.ComputeFeeFromAmount(amountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute fee from amount: %w", err)
+	}
+	amountOut, err := bin.GetAmountOut(amountIn.Sub(fee), price, swapForY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get amount out: %w", err)
+	}
+	protocolFee, err := pool.ComputeProtocolFee(fee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute protocol fee: %w", err)
 	}
-
 	return &SwapResult{
-		amountInWithFees: amountInWithFees,
-		amountOut:        amountOut,
+		amountInWithFees: amountIn,
+		amountOut:        cosmosmath.MinInt(amountOut, maxAmountOut),
 		fee:              fee,
 		protocolFee:      protocolFee,
 		isExactOutAmount: false,
@@ -279,53 +300,23 @@ func (pool *MeteoraDlmmPool) UpdateVolatilityAccumulator() error {
 }
 
 // ComputeProtocolFee calculates the protocol fee from the total fee amount
-func (pool *MeteoraDlmmPool) ComputeProtocolFee(feeAmount uint64) (uint64, error) {
-	// Convert feeAmount to uint128
-	feeAmountBig := uint128.From64(feeAmount)
-
-	// Convert protocol_share to uint128
-	protocolShare := uint128.From64(uint64(pool.parameters.protocolShare))
-
-	// Calculate feeAmount * protocol_share
-	protocolFee := feeAmountBig.Mul(protocolShare)
-
-	// Divide by BASIS_POINT_MAX
-	basisPointMax := uint128.From64(BasisPointMax)
-	if basisPointMax.IsZero() {
-		return 0, fmt.Errorf("division by zero")
-	}
-	protocolFee = protocolFee.Div(basisPointMax)
-
-	// Check if result can be safely converted to uint64
-	if protocolFee.Hi != 0 {
-		return 0, fmt.Errorf("protocol fee exceeds uint64 range")
-	}
-
-	return protocolFee.Lo, nil
+func (pool *MeteoraDlmmPool) ComputeProtocolFee(feeAmount cosmosmath.Int) (cosmosmath.Int, error) {
+	// feeAmount * protocol_share / BASIS_POINT_MAX
+	protocolShare := cosmosmath.NewInt(int64(pool.parameters.protocolShare))
+	return feeAmount.Mul(protocolShare).QuoRaw(BasisPointMax), nil
 }
 
 // ComputeFeeFromAmount calculates the fee from an amount including fees
-func (pool *MeteoraDlmmPool) ComputeFeeFromAmount(amountWithFees uint64) (uint64, error) {
+func (pool *MeteoraDlmmPool) ComputeFeeFromAmount(amountWithFees cosmosmath.Int) (cosmosmath.Int, error) {
 	// Get total fee rate
 	totalFeeRate, err := pool.GetTotalFee()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total fee: %w", err)
+		return cosmosmath.Int{}, fmt.Errorf("failed to get total fee: %w", err)
 	}
 
-	// Convert to big.Int for calculation
-	amount := new(big.Int).SetUint64(amountWithFees)
-	feeRate := totalFeeRate
-
-	// Calculate amount * totalFeeRate
-	feeAmount := new(big.Int).Mul(amount, feeRate)
-
-	// Add FEE_PRECISION - 1
-	feeAmount = feeAmount.Add(feeAmount, big.NewInt(FeePrecision-1))
-
-	// Divide by FEE_PRECISION
-	feeAmount = feeAmount.Div(feeAmount, big.NewInt(FeePrecision))
-
-	return feeAmount.Uint64(), nil
+	// fee = (amount * totalFeeRate + FEE_PRECISION - 1) / FEE_PRECISION
+	feePrecision := cosmosmath.NewIntFromUint64(FeePrecision)
+	return amountWithFees.Mul(cosmosmath.NewIntFromBigInt(totalFeeRate)).Add(feePrecision).SubRaw(1).Quo(feePrecision), nil
 }
 
 // GetTotalFee calculates the total fee rate by combining base and variable fees
@@ -535,9 +526,9 @@ func (pool *MeteoraDlmmPool) getCurrentActiveBinArray(swapForY bool) (BinArray,
 	// Generate PDA address for bin array
 	pda, _ := DeriveBinArrayPDA(pool.PoolId, binArrayIdx)
 
-	binArray, exists := pool.BinArrays[pda.String()]
+	binArray, exists := pool.cachedBinArray(pda.String())
 	if !exists {
-		return BinArray{}, errors.New("active bin array not found")
+		return BinArray{}, fmt.Errorf("%w: %d", errBinArrayNotLoaded, binArrayIdx)
 	}
 	return binArray, nil
 }
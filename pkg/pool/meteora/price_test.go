@@ -0,0 +1,111 @@
+package meteora
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	cosmosmath "cosmossdk.io/math"
+	"lukechampine.com/uint128"
+)
+
+// pow2 is 2^n as an Int
+func pow2(n uint) cosmosmath.Int {
+	return cosmosmath.NewIntFromBigInt(new(big.Int).Lsh(big.NewInt(1), n))
+}
+
+func TestSafeMulShrBoundaries(t *testing.T) {
+	one := new(big.Int).Lsh(big.NewInt(1), ScaleOffset)
+	tests := []struct {
+		name string
+		x    *big.Int
+		want cosmosmath.Int
+		err  bool
+	}{
+		{"u64 max", new(big.Int).SetUint64(math.MaxUint64), cosmosmath.NewIntFromUint64(math.MaxUint64), false},
+		{"past u64", pow2(64).BigInt(), pow2(64), false},
+		{"u128 max", maxU128, cosmosmath.NewIntFromBigInt(maxU128), false},
+		{"past u128", pow2(128).BigInt(), cosmosmath.Int{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// multiplying by 1.0 in Q64.64 returns x
+			got, err := SafeMulShr(tt.x, one, ScaleOffset, RoundingDown)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeShlDivBoundaries(t *testing.T) {
+	// (2^64 - 1) << 64 is the largest shift of a u64 and fits u128
+	got, err := SafeShlDiv(new(big.Int).SetUint64(math.MaxUint64), big.NewInt(1), ScaleOffset, RoundingDown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := pow2(128).Sub(pow2(64)); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got, err := SafeShlDiv(pow2(64).BigInt(), big.NewInt(1), ScaleOffset, RoundingDown); err == nil {
+		t.Fatalf("got %s, want an error past u128", got)
+	}
+	// rounding up only adds one on a remainder
+	got, err = SafeShlDiv(big.NewInt(1), big.NewInt(3), ScaleOffset, RoundingUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := pow2(64).QuoRaw(3).AddRaw(1); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestSwapPastU64(t *testing.T) {
+	// the lowest Q64.64 price: one Y atom buys 2^64 X atoms, so draining a
+	// full Y bin takes about 2^128 X
+	price := uint128.From64(1)
+	newBin := func() *Bin { return &Bin{amountY: math.MaxUint64, price: price} }
+	maxAmountIn := cosmosmath.NewIntFromUint64(math.MaxUint64).Mul(pow2(64))
+
+	tests := []struct {
+		name       string
+		amountIn   cosmosmath.Int
+		wantIn     cosmosmath.Int
+		wantOut    cosmosmath.Int
+		baseFactor uint16
+	}{
+		{"partial fill past u64", pow2(100), pow2(100), pow2(36), 0},
+		{"drains the bin", pow2(130), maxAmountIn, cosmosmath.NewIntFromUint64(math.MaxUint64), 0},
+		// a 1 bps fee rounds up: ceil(2^100 / 10^4) of the input stays as fee
+		{"fee past u64", pow2(100), pow2(100), pow2(100).Sub(pow2(100).AddRaw(9_999).QuoRaw(10_000)).Quo(pow2(64)), 10_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &MeteoraDlmmPool{binStep: 1}
+			pool.parameters.baseFactor = tt.baseFactor
+			bin := newBin()
+			result, err := pool.Swap(bin, tt.amountIn, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.amountInWithFees.Equal(tt.wantIn) {
+				t.Fatalf("amount in = %s, want %s", result.amountInWithFees, tt.wantIn)
+			}
+			if !result.amountOut.Equal(tt.wantOut) {
+				t.Fatalf("amount out = %s, want %s", result.amountOut, tt.wantOut)
+			}
+			if *bin != *newBin() {
+				t.Fatal("swap changed the bin")
+			}
+		})
+	}
+}
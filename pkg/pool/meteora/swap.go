@@ -10,6 +10,7 @@ import (
 	"cosmossdk.io/math"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
 	"github.com/solana-zh/solroute/pkg/sol"
 )
 
@@ -120,6 +121,17 @@ type SwapInstruction struct {
 	solana.AccountMetaSlice `bin:"-" borsh_skip:"true"`
 }
 
+// DecodeMinOut reads min_amount_out back from the swap2 instruction
+func (pool *MeteoraDlmmPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, MeteoraProgramID, Swap2IxDiscm[:], 16)
+}
+
+// SwapAmountFields locates amount_in and min_amount_out in swap2
+func (pool *MeteoraDlmmPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: MeteoraProgramID, Prefix: Swap2IxDiscm[:], Offset: 8},
+		pkg.AmountField{ProgramID: MeteoraProgramID, Prefix: Swap2IxDiscm[:], Offset: 16}
+}
+
 // ProgramID returns the Meteora program ID
 func (instruction *SwapInstruction) ProgramID() solana.PublicKey {
 	return MeteoraProgramID
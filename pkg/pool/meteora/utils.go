@@ -4,8 +4,11 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"math/bits"
 
+	cosmosmath "cosmossdk.io/math"
 	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
 	"lukechampine.com/uint128"
 )
 
@@ -91,7 +94,14 @@ func BinIDToBinArrayIndex(binID int32) int64 {
 // DeriveEventAuthorityPDA derives the event authority PDA
 func DeriveEventAuthorityPDA() solana.PublicKey {
 	seeds := [][]byte{[]byte("__event_authority")}
-	pda, _, _ := solana.FindProgramAddress(seeds, MeteoraProgramID)
+	pda, _, _ := sol.FindProgramAddress(seeds, MeteoraProgramID)
+	return pda
+}
+
+// DeriveDammV2EventAuthorityPDA derives the DAMM v2 event authority PDA
+func DeriveDammV2EventAuthorityPDA() solana.PublicKey {
+	seeds := [][]byte{[]byte("__event_authority")}
+	pda, _, _ := sol.FindProgramAddress(seeds, DammV2ProgramID)
 	return pda
 }
 
@@ -109,7 +119,7 @@ func DeriveBinArrayPDA(lbPair solana.PublicKey, binArrayIndex int64) (solana.Pub
 	}
 
 	// Find the PDA
-	pda, bump, err := solana.FindProgramAddress(seeds, MeteoraProgramID)
+	pda, bump, err := sol.FindProgramAddress(seeds, MeteoraProgramID)
 	if err != nil {
 		return solana.PublicKey{}, 0
 	}
@@ -119,7 +129,7 @@ func DeriveBinArrayPDA(lbPair solana.PublicKey, binArrayIndex int64) (solana.Pub
 
 // DeriveBinArrayBitmapExtension derives the bin array bitmap extension PDA
 func DeriveBinArrayBitmapExtension(lbPair solana.PublicKey) (solana.PublicKey, uint8) {
-	pda, bump, err := solana.FindProgramAddress(
+	pda, bump, err := sol.FindProgramAddress(
 		[][]byte{
 			[]byte(BinArrayBitmapSeed),
 			lbPair.Bytes(),
@@ -146,24 +156,8 @@ func IsOverflowDefaultBinArrayBitmap(binArrayIndex int32) bool {
 
 // FromLimbs converts a slice of uint64 limbs to a big.Int
 func FromLimbs(limbs []uint64) *big.Int {
-	// Create a new big integer
-	result := new(big.Int)
-
-	// If no limbs, return 0
-	if len(limbs) == 0 {
-		return result
-	}
-
-	// Process each limb from highest to lowest
-	// Since big.Int expects big-endian bytes, we start from the highest position
-	for i := len(limbs) - 1; i >= 0; i-- {
-		// Shift result left by 64 bits
-		result.Lsh(result, 64)
-		// Add current limb
-		result.Or(result, new(big.Int).SetUint64(limbs[i]))
-	}
-
-	return result
+	// Limbs are little-endian, which matches big.Int's internal word order
+	return new(big.Int).SetBits(limbsToWords(limbs))
 }
 
 // GetBinArrayOffset calculates the offset for a bin array index
@@ -171,25 +165,34 @@ func GetBinArrayOffset(binArrayIndex int32) uint {
 	return uint(binArrayIndex + BinArrayBitmapSize)
 }
 
-// SafeMulShrCast safely performs multiplication and right shift with casting
-func SafeMulShrCast(x, y *big.Int, offset uint8, rounding Rounding) (*big.Int, error) {
-	// Calculate mul_shr
+// maxU128 bounds the results of the program's mul_shr and shl_div
+var maxU128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// SafeMulShr performs multiplication and right shift, failing when the result
+// exceeds u128 like the program's mul_shr. Amounts are not cast to u64: an
+// amount crossing several bins may exceed what a single bin takes
+func SafeMulShr(x, y *big.Int, offset uint8, rounding Rounding) (cosmosmath.Int, error) {
 	result, err := MulShr(x, y, offset, rounding)
 	if err != nil {
-		return nil, fmt.Errorf("mul shr calculation error: %w", err)
+		return cosmosmath.Int{}, fmt.Errorf("mul shr calculation error: %w", err)
 	}
-
-	return result, nil
+	if result.Cmp(maxU128) > 0 {
+		return cosmosmath.Int{}, fmt.Errorf("mul shr result %s exceeds u128", result)
+	}
+	return cosmosmath.NewIntFromBigInt(result), nil
 }
 
-// SafeShlDivCast safely performs left shift and division with casting
-func SafeShlDivCast(x, y *big.Int, offset uint8, rounding Rounding) (*big.Int, error) {
+// SafeShlDiv performs left shift and division, failing when the result
+// exceeds u128 like the program's shl_div
+func SafeShlDiv(x, y *big.Int, offset uint8, rounding Rounding) (cosmosmath.Int, error) {
 	result, err := ShlDiv(x, y, offset, rounding)
 	if err != nil {
-		return nil, fmt.Errorf("overflow in shl div: %w", err)
+		return cosmosmath.Int{}, fmt.Errorf("overflow in shl div: %w", err)
 	}
-
-	return result, nil
+	if result.Cmp(maxU128) > 0 {
+		return cosmosmath.Int{}, fmt.Errorf("shl div result %s exceeds u128", result)
+	}
+	return cosmosmath.NewIntFromBigInt(result), nil
 }
 
 // SafeMulDivCast safely performs multiplication and division with casting
@@ -367,14 +370,30 @@ func BinArrayOffsetInBitmap(binArrayIndex int32) (int, error) {
 
 // ArrayToBigInt converts an array of 8 uint64 values to a big.Int
 func ArrayToBigInt(arr [8]uint64) *big.Int {
-	result := new(big.Int)
-	// From high to low, shift left by 64 bits each time and add new limb
-	for i := 0; i < 8; i++ {
-		temp := new(big.Int).SetUint64(arr[i])
-		result.Lsh(result, 64)  // Shift left by 64 bits
-		result.Or(result, temp) // OR operation, add new limb
+	// arr[0] is the most significant limb, so reverse into little-endian order
+	var limbs [8]uint64
+	for i := range arr {
+		limbs[len(arr)-1-i] = arr[i]
+	}
+	return new(big.Int).SetBits(limbsToWords(limbs[:]))
+}
+
+// limbsToWords converts little-endian uint64 limbs into big.Word limbs
+func limbsToWords(limbs []uint64) []big.Word {
+	if bits.UintSize == 64 {
+		words := make([]big.Word, len(limbs))
+		for i, limb := range limbs {
+			words[i] = big.Word(limb)
+		}
+		return words
+	}
+
+	words := make([]big.Word, 2*len(limbs))
+	for i, limb := range limbs {
+		words[2*i] = big.Word(uint32(limb))
+		words[2*i+1] = big.Word(uint32(limb >> 32))
 	}
-	return result
+	return words
 }
 
 // CountLeadingZeros counts the number of leading zeros in a big.Int
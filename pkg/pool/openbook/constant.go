@@ -0,0 +1,73 @@
+// Package openbook quotes and builds swaps against OpenBook v2 markets, an
+// on-chain central limit order book keeping its bids and asks in separate
+// book side accounts. Swaps are taker orders settled straight to the
+// trader's token accounts, with no open orders account
+package openbook
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	// ProgramID is the OpenBook v2 program
+	ProgramID = solana.MustPublicKeyFromBase58("opnb2LAfJYbRMAHHvqjCwQxanZn7ReEHp1k81EohpZb")
+
+	// MarketDiscriminator prefixes OpenBook v2 market accounts
+	MarketDiscriminator = []byte{219, 190, 213, 55, 0, 227, 198, 154}
+	// BookSideDiscriminator prefixes the bids and asks accounts
+	BookSideDiscriminator = []byte{72, 44, 225, 141, 178, 130, 97, 57}
+	// PlaceTakeOrderDiscriminator prefixes place_take_order instructions
+	PlaceTakeOrderDiscriminator = []byte{3, 44, 71, 3, 26, 199, 203, 85}
+)
+
+// OpenBook v2 market layout
+const (
+	MarketSize      = 848
+	BaseMintOffset  = 576
+	QuoteMintOffset = 608
+
+	baseDecimalsOffset     = 9
+	quoteDecimalsOffset    = 10
+	timeExpiryOffset       = 48
+	openOrdersAdminOffset  = 88
+	bidsOffset             = 200
+	asksOffset             = 232
+	eventHeapOffset        = 264
+	oracleAOffset          = 296
+	oracleBOffset          = 328
+	quoteLotSizeOffset     = 448
+	baseLotSizeOffset      = 456
+	takerFeeOffset         = 488
+	marketBaseVaultOffset  = 640
+	marketQuoteVaultOffset = 680
+)
+
+// OpenBook v2 book side layout
+const (
+	// fixedRootOffset is the root of the tree of fixed price orders; the
+	// oracle pegged tree follows it
+	fixedRootOffset = 8
+	nodesOffset     = 840
+	nodeSize        = 88
+	maxNodes        = 1024
+
+	nodeTagInner = 1
+	nodeTagLeaf  = 2
+)
+
+// Side is the side of an order
+type Side uint8
+
+const (
+	SideBid Side = iota // buys base with quote
+	SideAsk             // sells base for quote
+)
+
+const (
+	// orderTypeImmediateOrCancel is the ImmediateOrCancel PlaceOrderType
+	orderTypeImmediateOrCancel = 1
+	// matchLimit is the most resting orders one swap matches against
+	matchLimit = 50
+	// feesScale is the denominator of the market's fee rates
+	feesScale = 1_000_000
+)
@@ -0,0 +1,46 @@
+package openbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses an OpenBook v2 place_take_order. It does not name the
+// mints, so they are left zero. Its amounts are in the market's lots and it
+// carries a limit price instead of a minimum output, left unset
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, PlaceTakeOrderDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 35 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 16); err != nil {
+		return nil, err
+	}
+	side := Side(data[8])
+	if side != SideBid && side != SideAsk {
+		return nil, fmt.Errorf("invalid order side %d", side)
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:      pkg.ProtocolNameOpenBook,
+		Pool:          accounts[2].PublicKey,
+		User:          accounts[0].PublicKey,
+		MinAmountOut:  math.ZeroInt(),
+		AmountsInLots: true,
+	}
+	if side == SideAsk {
+		params.UserInputAccount, params.UserOutputAccount = accounts[9].PublicKey, accounts[10].PublicKey
+		params.AmountIn = math.NewIntFromUint64(binary.LittleEndian.Uint64(data[17:25]))
+	} else {
+		params.UserInputAccount, params.UserOutputAccount = accounts[10].PublicKey, accounts[9].PublicKey
+		params.AmountIn = math.NewIntFromUint64(binary.LittleEndian.Uint64(data[25:33]))
+	}
+	return params, nil
+}
@@ -0,0 +1,144 @@
+package openbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Market is the part of an OpenBook v2 market account routing needs
+type Market struct {
+	BaseDecimals  uint8
+	QuoteDecimals uint8
+	// TimeExpiry closes the market to new orders at this unix time when nonzero
+	TimeExpiry int64
+	// OpenOrdersAdmin must cosign every order when set
+	OpenOrdersAdmin solana.PublicKey
+	Bids            solana.PublicKey
+	Asks            solana.PublicKey
+	EventHeap       solana.PublicKey
+	OracleA         solana.PublicKey
+	OracleB         solana.PublicKey
+	QuoteLotSize    uint64
+	BaseLotSize     uint64
+	// TakerFee is in millionths of the quote traded
+	TakerFee         int64
+	BaseMint         solana.PublicKey
+	QuoteMint        solana.PublicKey
+	MarketBaseVault  solana.PublicKey
+	MarketQuoteVault solana.PublicKey
+}
+
+// Decode parses a market account
+func (m *Market) Decode(data []byte) error {
+	if len(data) < MarketSize {
+		return fmt.Errorf("openbook market account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, MarketDiscriminator) {
+		return fmt.Errorf("not an openbook market account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	m.BaseDecimals = data[baseDecimalsOffset]
+	m.QuoteDecimals = data[quoteDecimalsOffset]
+	m.TimeExpiry = int64(u64(timeExpiryOffset))
+	m.OpenOrdersAdmin = key(openOrdersAdminOffset)
+	m.Bids = key(bidsOffset)
+	m.Asks = key(asksOffset)
+	m.EventHeap = key(eventHeapOffset)
+	m.OracleA = key(oracleAOffset)
+	m.OracleB = key(oracleBOffset)
+	m.QuoteLotSize = u64(quoteLotSizeOffset)
+	m.BaseLotSize = u64(baseLotSizeOffset)
+	m.TakerFee = int64(u64(takerFeeOffset))
+	m.BaseMint = key(BaseMintOffset)
+	m.QuoteMint = key(QuoteMintOffset)
+	m.MarketBaseVault = key(marketBaseVaultOffset)
+	m.MarketQuoteVault = key(marketQuoteVaultOffset)
+	if int64(m.BaseLotSize) <= 0 || int64(m.QuoteLotSize) <= 0 {
+		return fmt.Errorf("openbook market has a non-positive lot size")
+	}
+	if m.TakerFee < 0 || m.TakerFee >= feesScale {
+		return fmt.Errorf("openbook market taker fee %d out of range", m.TakerFee)
+	}
+	return nil
+}
+
+// Permissioned reports whether orders need the open orders admin's signature
+func (m *Market) Permissioned() bool {
+	return !m.OpenOrdersAdmin.IsZero()
+}
+
+// Expired reports whether the market stopped taking orders at unix time now
+func (m *Market) Expired(now int64) bool {
+	return m.TimeExpiry != 0 && now >= m.TimeExpiry
+}
+
+// Order is a resting fixed price order of a book side
+type Order struct {
+	// PriceLots is the price in quote lots per base lot
+	PriceLots uint64
+	Quantity  uint64
+	// TimeInForce expires the order that many seconds after Timestamp when
+	// nonzero
+	TimeInForce uint16
+	Timestamp   uint64
+}
+
+// expired reports whether the matching engine skips the order at unix time now
+func (o Order) expired(now uint64) bool {
+	return o.TimeInForce != 0 && now >= o.Timestamp+uint64(o.TimeInForce)
+}
+
+// DecodeBookSide parses the fixed price orders of a bids or asks account,
+// best first. Oracle pegged orders are left out, as their price moves with
+// an oracle the quote does not load
+func DecodeBookSide(data []byte, side Side) ([]Order, error) {
+	if len(data) < nodesOffset+maxNodes*nodeSize || !bytes.HasPrefix(data, BookSideDiscriminator) {
+		return nil, fmt.Errorf("not an openbook book side account")
+	}
+	root := binary.LittleEndian.Uint32(data[fixedRootOffset:])
+	leafCount := binary.LittleEndian.Uint32(data[fixedRootOffset+4:])
+	orders := make([]Order, 0, leafCount)
+	if leafCount == 0 {
+		return orders, nil
+	}
+
+	stack := []uint32{root}
+	visited := 0
+	for len(stack) > 0 {
+		index := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if index >= maxNodes || visited >= maxNodes {
+			return nil, fmt.Errorf("corrupt tree at node %d", index)
+		}
+		visited++
+		node := data[nodesOffset+int(index)*nodeSize:][:nodeSize]
+		switch node[0] {
+		case nodeTagInner:
+			stack = append(stack, binary.LittleEndian.Uint32(node[24:]), binary.LittleEndian.Uint32(node[28:]))
+		case nodeTagLeaf:
+			orders = append(orders, Order{
+				PriceLots:   binary.LittleEndian.Uint64(node[16:]),
+				Quantity:    binary.LittleEndian.Uint64(node[56:]),
+				TimeInForce: binary.LittleEndian.Uint16(node[2:]),
+				Timestamp:   binary.LittleEndian.Uint64(node[64:]),
+			})
+		default:
+			return nil, fmt.Errorf("unexpected node tag %d at node %d", node[0], index)
+		}
+	}
+	if len(orders) != int(leafCount) {
+		return nil, fmt.Errorf("tree holds %d orders, root counts %d", len(orders), leafCount)
+	}
+	if side == SideBid {
+		sort.SliceStable(orders, func(i, j int) bool { return orders[i].PriceLots > orders[j].PriceLots })
+	} else {
+		sort.SliceStable(orders, func(i, j int) bool { return orders[i].PriceLots < orders[j].PriceLots })
+	}
+	return orders, nil
+}
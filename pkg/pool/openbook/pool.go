@@ -0,0 +1,435 @@
+package openbook
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// maxLots is the i64 maximum, leaving a side of a taker order unbounded
+const maxLots = 1<<63 - 1
+
+// OpenBookPool is an OpenBook v2 market routed as a pool: selling base hits
+// the bids and buying base with quote lifts the asks, as one immediate-or-
+// cancel taker order paying the taker fee in quote
+type OpenBookPool struct {
+	MarketId solana.PublicKey
+	Market   Market
+
+	// bids and asks are the fixed price orders of the last quote, best first,
+	// with the unix time their expiry was checked against
+	bids []Order
+	asks []Order
+	now  uint64
+}
+
+func (pool *OpenBookPool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameOpenBook
+}
+
+func (pool *OpenBookPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *OpenBookPool) GetID() string {
+	return pool.MarketId.String()
+}
+
+// GetTokens returns the market's base and quote mints
+func (pool *OpenBookPool) GetTokens() (string, string) {
+	return pool.Market.BaseMint.String(), pool.Market.QuoteMint.String()
+}
+
+// Decode parses the market account; the book sides are loaded by Quote
+func (pool *OpenBookPool) Decode(data []byte) error {
+	return pool.Market.Decode(data)
+}
+
+// UpdateFrom takes the freshly decoded market of a rediscovered pool while
+// keeping the book of the last quote
+func (pool *OpenBookPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*OpenBookPool)
+	if !ok || fresh == pool || !fresh.MarketId.Equals(pool.MarketId) {
+		return false
+	}
+	pool.Market = fresh.Market
+	return true
+}
+
+// MintDecimals returns the decimals the market records for mint
+func (pool *OpenBookPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case pool.Market.BaseMint.String():
+		return pool.Market.BaseDecimals, true
+	case pool.Market.QuoteMint.String():
+		return pool.Market.QuoteDecimals, true
+	}
+	return 0, false
+}
+
+// Quote loads the market, both book sides and the clock in one batch and
+// fills inputAmount against the book. Inputs the book cannot fill in full
+// within the match limit fail rather than quote a partial fill
+func (pool *OpenBookPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{pool.MarketId, pool.Market.Bids, pool.Market.Asks, solana.SysVarClockPubkey}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	data, ok := sol.AccountData(results, 0)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("openbook market %s not found", pool.MarketId)
+	}
+	if err := pool.Market.Decode(data); err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to decode openbook market %s: %w", pool.MarketId, err)
+	}
+	sides := make([][]Order, 2)
+	for i, side := range []Side{SideBid, SideAsk} {
+		data, ok := sol.AccountData(results, 1+i)
+		if !ok {
+			return math.ZeroInt(), fmt.Errorf("book side %s not found", accounts[1+i])
+		}
+		if sides[i], err = DecodeBookSide(data, side); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode book side %s: %w", accounts[1+i], err)
+		}
+	}
+	clockData, ok := sol.AccountData(results, 3)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(clockData)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	pool.bids, pool.asks, pool.now = sides[0], sides[1], clock.UnixTimestamp
+
+	if pool.Market.Expired(int64(clock.UnixTimestamp)) {
+		return math.ZeroInt(), fmt.Errorf("openbook market %s expired", pool.MarketId)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// ComputeAmountOut fills inputAmount against the cached book. Input below
+// one lot is left with the trader, as the order only counts whole lots
+func (pool *OpenBookPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if pool.bids == nil || pool.asks == nil {
+		return math.ZeroInt(), fmt.Errorf("order book not loaded")
+	}
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	switch inputMint {
+	case pool.Market.BaseMint.String():
+		return pool.sell(inputAmount.Uint64() / pool.Market.BaseLotSize)
+	case pool.Market.QuoteMint.String():
+		return pool.buy(inputAmount.Uint64() / pool.Market.QuoteLotSize)
+	}
+	return math.ZeroInt(), fmt.Errorf("mint %s is not traded by openbook market %s", inputMint, pool.MarketId)
+}
+
+// sell matches baseLots against the bids and returns the quote received
+// after the taker fee
+func (pool *OpenBookPool) sell(baseLots uint64) (math.Int, error) {
+	if baseLots == 0 {
+		return math.ZeroInt(), fmt.Errorf("input is below one base lot of %d", pool.Market.BaseLotSize)
+	}
+	remaining := baseLots
+	quoteLots := new(big.Int)
+	matched := 0
+	for _, order := range pool.bids {
+		if remaining == 0 || matched == matchLimit {
+			break
+		}
+		if order.expired(pool.now) {
+			continue
+		}
+		fill := min(remaining, order.Quantity)
+		quoteLots.Add(quoteLots, lotsProduct(fill, order.PriceLots))
+		remaining -= fill
+		matched++
+	}
+	if remaining > 0 {
+		return math.ZeroInt(), fmt.Errorf("bids fill %d of %d base lots", baseLots-remaining, baseLots)
+	}
+	quote := quoteLots.Mul(quoteLots, new(big.Int).SetUint64(pool.Market.QuoteLotSize))
+	quote.Sub(quote, pool.takerFee(quote))
+	return math.NewIntFromBigInt(quote), nil
+}
+
+// buy spends quoteLots, the taker fee included, lifting the asks and returns
+// the base received
+func (pool *OpenBookPool) buy(quoteLots uint64) (math.Int, error) {
+	budget := pool.netQuoteLots(quoteLots)
+	if budget.Sign() == 0 {
+		return math.ZeroInt(), fmt.Errorf("input is below one quote lot of %d after fees", pool.Market.QuoteLotSize)
+	}
+	var baseLots uint64
+	exhausted := false
+	matched := 0
+	for _, order := range pool.asks {
+		if matched == matchLimit {
+			break
+		}
+		if order.expired(pool.now) {
+			continue
+		}
+		fill := order.Quantity
+		if affordable := new(big.Int).Quo(budget, new(big.Int).SetUint64(order.PriceLots)); affordable.Cmp(new(big.Int).SetUint64(fill)) < 0 {
+			fill = affordable.Uint64()
+			exhausted = true
+		}
+		budget.Sub(budget, lotsProduct(fill, order.PriceLots))
+		baseLots += fill
+		matched++
+		if exhausted || budget.Sign() == 0 {
+			exhausted = true
+			break
+		}
+	}
+	if !exhausted {
+		return math.ZeroInt(), fmt.Errorf("asks fill %d base lots before running out", baseLots)
+	}
+	if baseLots == 0 {
+		return math.ZeroInt(), fmt.Errorf("input buys less than one base lot")
+	}
+	return math.NewIntFromUint64(baseLots).Mul(math.NewIntFromUint64(pool.Market.BaseLotSize)), nil
+}
+
+// netQuoteLots is the quote lots of quoteLotsIncludingFees left to match
+// once the taker fee is set aside, rounded down as the program does
+func (pool *OpenBookPool) netQuoteLots(quoteLotsIncludingFees uint64) *big.Int {
+	lots := new(big.Int).SetUint64(quoteLotsIncludingFees)
+	lots.Mul(lots, big.NewInt(feesScale))
+	return lots.Quo(lots, big.NewInt(feesScale+pool.Market.TakerFee))
+}
+
+// takerFee is the taker fee on quote native units, rounded up
+func (pool *OpenBookPool) takerFee(quote *big.Int) *big.Int {
+	fee := new(big.Int).Mul(quote, big.NewInt(pool.Market.TakerFee))
+	fee.Add(fee, big.NewInt(feesScale-1))
+	return fee.Quo(fee, big.NewInt(feesScale))
+}
+
+// lotsProduct is baseLots times priceLots, the quote lots they trade for
+func lotsProduct(baseLots, priceLots uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(baseLots), new(big.Int).SetUint64(priceLots))
+}
+
+// bookSide returns the cached orders the input meets: the bids when selling
+// base, the asks when buying it
+func (pool *OpenBookPool) bookSide(inputMint string) (orders []Order, selling bool) {
+	if inputMint == pool.Market.BaseMint.String() {
+		return pool.bids, true
+	}
+	return pool.asks, false
+}
+
+// RawSpotPrice is the best price the input meets in the cached book
+func (pool *OpenBookPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	if pool.bids == nil || pool.asks == nil {
+		return math.LegacyDec{}, fmt.Errorf("order book not loaded")
+	}
+	orders, selling := pool.bookSide(inputMint)
+	for _, order := range orders {
+		if order.expired(pool.now) {
+			continue
+		}
+		// quote atoms per base atom
+		num := new(big.Int).SetUint64(order.PriceLots)
+		num.Mul(num, new(big.Int).SetUint64(pool.Market.QuoteLotSize))
+		return pkg.RatioPrice(num, new(big.Int).SetUint64(pool.Market.BaseLotSize), !selling)
+	}
+	return math.LegacyDec{}, fmt.Errorf("openbook market %s has an empty book side", pool.MarketId)
+}
+
+// MaxInputForImpact sums the orders priced within maxImpactBps of the best
+// price on the side the input meets, up to the match limit
+func (pool *OpenBookPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.bids == nil || pool.asks == nil {
+		return math.ZeroInt(), fmt.Errorf("order book not loaded")
+	}
+	orders, selling := pool.bookSide(inputMint)
+	var best *big.Int
+	baseLots := uint64(0)
+	quoteLots := new(big.Int)
+	matched := 0
+	for _, order := range orders {
+		if matched == matchLimit {
+			break
+		}
+		if order.expired(pool.now) {
+			continue
+		}
+		price := new(big.Int).SetUint64(order.PriceLots)
+		if best == nil {
+			best = price
+		}
+		// compare price·10000 against best·(10000 ∓ maxImpactBps)
+		scaled := new(big.Int).Mul(price, big.NewInt(10000))
+		if selling && scaled.Cmp(new(big.Int).Mul(best, big.NewInt(int64(10000-maxImpactBps)))) < 0 {
+			break
+		}
+		if !selling && scaled.Cmp(new(big.Int).Mul(best, big.NewInt(int64(10000+maxImpactBps)))) > 0 {
+			break
+		}
+		baseLots += order.Quantity
+		quoteLots.Add(quoteLots, lotsProduct(order.Quantity, order.PriceLots))
+		matched++
+	}
+	if selling {
+		return math.NewIntFromUint64(baseLots).Mul(math.NewIntFromUint64(pool.Market.BaseLotSize)), nil
+	}
+	// gross the matched quote up by the taker fee paid on top
+	quoteLots.Mul(quoteLots, big.NewInt(feesScale+pool.Market.TakerFee))
+	quoteLots.Quo(quoteLots, big.NewInt(feesScale))
+	return math.NewIntFromBigInt(quoteLots).Mul(math.NewIntFromUint64(pool.Market.QuoteLotSize)), nil
+}
+
+// MarketAuthority derives the PDA holding the market's vaults
+func (pool *OpenBookPool) MarketAuthority() solana.PublicKey {
+	authority, _, _ := sol.FindProgramAddress([][]byte{[]byte("Market"), pool.MarketId.Bytes()}, ProgramID)
+	return authority
+}
+
+// BuildSwapInstructions builds a place_take_order of the input's lots. The
+// instruction has no minimum output, so minOut becomes the order's limit
+// price: the lowest bid an ask may hit, or the highest ask a bid may lift,
+// such that filling the whole input within it pays at least minOut. An order
+// the book fills only partly within the limit returns the rest of the input
+func (pool *OpenBookPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	market := pool.Market
+	if market.Permissioned() {
+		return nil, fmt.Errorf("openbook market %s requires its open orders admin to sign", pool.MarketId)
+	}
+	var side Side
+	var priceLots, baseLots, quoteLots uint64
+	switch inputMint {
+	case market.BaseMint.String():
+		side = SideAsk
+		baseLots, quoteLots = inputAmount.Uint64()/market.BaseLotSize, maxLots
+		if baseLots == 0 {
+			return nil, fmt.Errorf("input is below one base lot of %d", market.BaseLotSize)
+		}
+		priceLots = pool.askLimit(baseLots, minOut)
+	case market.QuoteMint.String():
+		side = SideBid
+		baseLots, quoteLots = maxLots, inputAmount.Uint64()/market.QuoteLotSize
+		limit, err := pool.bidLimit(quoteLots, minOut)
+		if err != nil {
+			return nil, err
+		}
+		priceLots = limit
+	default:
+		return nil, fmt.Errorf("mint %s is not traded by openbook market %s", inputMint, pool.MarketId)
+	}
+
+	// markets without oracles and admins take the program ID for those accounts
+	optional := func(key solana.PublicKey) solana.PublicKey {
+		if key.IsZero() {
+			return ProgramID
+		}
+		return key
+	}
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(user).WRITE().SIGNER(),
+		solana.Meta(user).WRITE().SIGNER(),
+		solana.Meta(pool.MarketId).WRITE(),
+		solana.Meta(pool.MarketAuthority()),
+		solana.Meta(market.Bids).WRITE(),
+		solana.Meta(market.Asks).WRITE(),
+		solana.Meta(market.MarketBaseVault).WRITE(),
+		solana.Meta(market.MarketQuoteVault).WRITE(),
+		solana.Meta(market.EventHeap).WRITE(),
+		solana.Meta(userBaseAccount).WRITE(),
+		solana.Meta(userQuoteAccount).WRITE(),
+		solana.Meta(optional(market.OracleA)),
+		solana.Meta(optional(market.OracleB)),
+		solana.Meta(solana.TokenProgramID),
+		solana.Meta(solana.SystemProgramID),
+		solana.Meta(ProgramID),
+	}
+	data := make([]byte, 0, 35)
+	data = append(data, PlaceTakeOrderDiscriminator...)
+	data = append(data, byte(side))
+	data = binary.LittleEndian.AppendUint64(data, priceLots)
+	data = binary.LittleEndian.AppendUint64(data, baseLots)
+	data = binary.LittleEndian.AppendUint64(data, quoteLots)
+	data = append(data, orderTypeImmediateOrCancel, matchLimit)
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// InputLotSize is the lot size of inputMint; the book only fills whole lots
+// of the input
+func (pool *OpenBookPool) InputLotSize(inputMint string) math.Int {
+	if inputMint == pool.Market.BaseMint.String() {
+		return math.NewIntFromUint64(pool.Market.BaseLotSize)
+	}
+	return math.NewIntFromUint64(pool.Market.QuoteLotSize)
+}
+
+// MinOutUnencoded reports that place_take_order encodes minOut as its limit
+// price rather than as a minimum output
+func (pool *OpenBookPool) MinOutUnencoded(inputMint string) bool {
+	return true
+}
+
+// askLimit is the lowest price in quote lots per base lot at which selling
+// baseLots in full pays at least minOut after the taker fee, which rounds up
+func (pool *OpenBookPool) askLimit(baseLots uint64, minOut math.Int) uint64 {
+	if minOut.IsZero() {
+		return 1
+	}
+	// quote native such that quote - ceil(quote·fee) >= minOut
+	gross := new(big.Int).Add(minOut.BigInt(), big.NewInt(1))
+	gross.Mul(gross, big.NewInt(feesScale))
+	gross = ceilQuo(gross, big.NewInt(feesScale-pool.Market.TakerFee))
+	price := ceilQuo(gross, new(big.Int).Mul(new(big.Int).SetUint64(baseLots), new(big.Int).SetUint64(pool.Market.QuoteLotSize)))
+	if !price.IsUint64() || price.Uint64() > maxLots {
+		return maxLots
+	}
+	return max(price.Uint64(), 1)
+}
+
+// bidLimit is the highest price in quote lots per base lot at which spending
+// quoteLots in full buys at least minOut. The last level matched can leave
+// up to a lot's price unspent, so the limit leaves room for one more lot
+func (pool *OpenBookPool) bidLimit(quoteLots uint64, minOut math.Int) (uint64, error) {
+	if quoteLots == 0 {
+		return 0, fmt.Errorf("input is below one quote lot of %d", pool.Market.QuoteLotSize)
+	}
+	if minOut.IsZero() {
+		return maxLots, nil
+	}
+	minLots := ceilQuo(minOut.BigInt(), new(big.Int).SetUint64(pool.Market.BaseLotSize))
+	price := new(big.Int).Quo(pool.netQuoteLots(quoteLots), minLots.Add(minLots, big.NewInt(1)))
+	if price.Sign() == 0 {
+		return 0, fmt.Errorf("min out %s is beyond what the input can buy at any price", minOut)
+	}
+	return price.Uint64(), nil
+}
+
+func ceilQuo(a, b *big.Int) *big.Int {
+	q := new(big.Int).Add(a, new(big.Int).Sub(b, big.NewInt(1)))
+	return q.Quo(q, b)
+}
@@ -0,0 +1,81 @@
+// Package orca quotes and builds swaps on Orca Whirlpools, concentrated
+// liquidity pools whose ticks are stored in arrays of 88 next to the pool
+package orca
+
+import (
+	"math/big"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	// ProgramID is the Orca Whirlpool program
+	ProgramID = solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+
+	// WhirlpoolDiscriminator prefixes Whirlpool accounts
+	WhirlpoolDiscriminator = []byte{63, 149, 209, 12, 225, 128, 99, 9}
+	// TickArrayDiscriminator prefixes fixed tick array accounts
+	TickArrayDiscriminator = []byte{69, 97, 189, 190, 110, 7, 66, 187}
+	// DynamicTickArrayDiscriminator prefixes tick arrays that only store
+	// their initialized ticks
+	DynamicTickArrayDiscriminator = []byte{17, 216, 246, 142, 225, 199, 218, 56}
+
+	// SwapDiscriminator is the legacy swap, for pools of classic SPL Token mints
+	SwapDiscriminator = []byte{248, 198, 158, 145, 225, 117, 135, 200}
+	// SwapV2Discriminator is swap_v2, which also handles Token-2022 mints
+	SwapV2Discriminator = []byte{43, 4, 237, 11, 26, 201, 30, 98}
+)
+
+// Bounds of the sqrt price, as Q64.64, passed as the swap's price limit
+var (
+	MinSqrtPriceX64    = big.NewInt(4295048016)
+	MaxSqrtPriceX64, _ = new(big.Int).SetString("79226673515401279992447579055", 10)
+)
+
+const (
+	// TickArraySize is the number of ticks in one tick array
+	TickArraySize = 88
+	// MinTick and MaxTick bound the tick index
+	MinTick = -443636
+	MaxTick = 443636
+
+	// feeRateDenominator is the denominator of fee_rate, in hundredths of a
+	// basis point
+	feeRateDenominator = 1_000_000
+	// swapTickArrays is the number of tick arrays a swap references
+	swapTickArrays = 3
+
+	tickArraySeed = "tick_array"
+	oracleSeed    = "oracle"
+)
+
+// WhirlpoolSize is the size of a Whirlpool account
+const WhirlpoolSize = 653
+
+// Offsets of the Whirlpool account fields, after the 8 byte Anchor
+// discriminator. The mint offsets are exported for memcmp filters
+const (
+	whirlpoolsConfigOffset = 8
+	tickSpacingOffset      = 41
+	feeTierIndexOffset     = 43
+	feeRateOffset          = 45
+	liquidityOffset        = 49
+	sqrtPriceOffset        = 65
+	tickCurrentIndexOffset = 81
+	TokenMintAOffset       = 101
+	tokenVaultAOffset      = 133
+	TokenMintBOffset       = 181
+	tokenVaultBOffset      = 213
+)
+
+// Tick array layout: the fixed array stores every tick after its start index,
+// the dynamic one its start index, the pool and a bitmap of initialized ticks,
+// then one tag byte per tick followed by the tick data when initialized
+const (
+	tickArrayStartOffset        = 8
+	tickArrayTicksOffset        = 12
+	dynamicTickArrayTicksOffset = 8 + 4 + 32 + 16
+	// tickDataSize covers liquidity_net, liquidity_gross, both fee growths
+	// and the three reward growths
+	tickDataSize = 16 * 7
+)
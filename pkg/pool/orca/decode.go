@@ -0,0 +1,60 @@
+package orca
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a Whirlpool swap_v2 or legacy swap instruction. When
+// amount_specified_is_input is false the amount is the exact output and the
+// threshold the maximum input. The legacy swap does not name the mints
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	legacy := bytes.HasPrefix(data, SwapDiscriminator)
+	if !legacy && !bytes.HasPrefix(data, SwapV2Discriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 42 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	// authority, whirlpool and the owner account of token A; the owner of
+	// token B follows the vault of token A
+	user, pool, ownerA := 1, 2, 3
+	minAccounts := 11
+	if !legacy {
+		user, pool, ownerA = 3, 4, 7
+		minAccounts = 15
+	}
+	if err := pkg.CheckSwapAccounts(accounts, minAccounts); err != nil {
+		return nil, err
+	}
+
+	amount := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16]))
+	threshold := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24]))
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameOrcaWhirlpool,
+		User:              accounts[user].PublicKey,
+		Pool:              accounts[pool].PublicKey,
+		UserInputAccount:  accounts[ownerA].PublicKey,
+		UserOutputAccount: accounts[ownerA+2].PublicKey,
+		AmountIn:          amount,
+		MinAmountOut:      threshold,
+	}
+	if !legacy {
+		params.InputMint = accounts[5].PublicKey
+		params.OutputMint = accounts[6].PublicKey
+	}
+	if aToB := data[41] != 0; !aToB {
+		params.UserInputAccount, params.UserOutputAccount = params.UserOutputAccount, params.UserInputAccount
+		params.InputMint, params.OutputMint = params.OutputMint, params.InputMint
+	}
+	if data[40] == 0 {
+		params.AmountIn, params.MinAmountOut = threshold, amount
+		params.ExactOutput = true
+	}
+	return params, nil
+}
@@ -0,0 +1,182 @@
+package orca
+
+import (
+	"fmt"
+	"math/big"
+)
+
+var (
+	q64        = new(big.Int).Lsh(big.NewInt(1), 64)
+	maxU64     = new(big.Int).SetUint64(^uint64(0))
+	maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+	// tickRatios are sqrt(1.0001)^-(2^i) as Q64.64, multiplied in for each
+	// bit i of the absolute tick
+	tickRatios = mustBigInts(
+		"18445821805675395072",
+		"18444899583751176192",
+		"18443055278223355904",
+		"18439367220385607680",
+		"18431993317065453568",
+		"18417254355718170624",
+		"18387811781193609216",
+		"18329067761203558400",
+		"18212142134806163456",
+		"17980523815641700352",
+		"17526086738831433728",
+		"16651378430235570176",
+		"15030750278694412288",
+		"12247334978884435968",
+		"8131365268886854656",
+		"3584323654725218816",
+		"696457651848324352",
+		"26294789957507116",
+		"37481735321082",
+	)
+)
+
+func mustBigInts(values ...string) []*big.Int {
+	out := make([]*big.Int, len(values))
+	for i, value := range values {
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			panic("invalid constant " + value)
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// sqrtPriceFromTick returns sqrt(1.0001^tick) as Q64.64
+func sqrtPriceFromTick(tick int32) (*big.Int, error) {
+	if tick < MinTick || tick > MaxTick {
+		return nil, fmt.Errorf("tick %d out of range", tick)
+	}
+	abs := tick
+	if abs < 0 {
+		abs = -abs
+	}
+	ratio := new(big.Int).Set(q64)
+	for i, factor := range tickRatios {
+		if abs&(1<<i) != 0 {
+			ratio.Mul(ratio, factor)
+			ratio.Rsh(ratio, 64)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(maxUint128, ratio)
+	}
+	return ratio, nil
+}
+
+// amountADelta is the token A amount between two sqrt prices at liquidity:
+// liquidity * (upper - lower) << 64 / (upper * lower)
+func amountADelta(p0, p1, liquidity *big.Int, roundUp bool) *big.Int {
+	lower, upper := p0, p1
+	if lower.Cmp(upper) > 0 {
+		lower, upper = upper, lower
+	}
+	numerator := new(big.Int).Sub(upper, lower)
+	numerator.Mul(numerator, liquidity)
+	numerator.Lsh(numerator, 64)
+	denominator := new(big.Int).Mul(upper, lower)
+	if denominator.Sign() == 0 {
+		return new(big.Int)
+	}
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if roundUp && remainder.Sign() != 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// amountBDelta is the token B amount between two sqrt prices at liquidity:
+// liquidity * (upper - lower) >> 64
+func amountBDelta(p0, p1, liquidity *big.Int, roundUp bool) *big.Int {
+	diff := new(big.Int).Sub(p1, p0)
+	diff.Abs(diff)
+	product := diff.Mul(diff, liquidity)
+	quotient := new(big.Int).Rsh(product, 64)
+	if roundUp && new(big.Int).And(product, new(big.Int).Sub(q64, big.NewInt(1))).Sign() != 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// nextSqrtPriceFromInput moves the sqrt price by an input amount. Token A in
+// lowers it, rounding up; token B in raises it, rounding down
+func nextSqrtPriceFromInput(sqrtPrice, liquidity, amount *big.Int, aToB bool) *big.Int {
+	if amount.Sign() == 0 || liquidity.Sign() == 0 {
+		return new(big.Int).Set(sqrtPrice)
+	}
+	if aToB {
+		numerator := new(big.Int).Mul(liquidity, sqrtPrice)
+		numerator.Lsh(numerator, 64)
+		denominator := new(big.Int).Lsh(liquidity, 64)
+		denominator.Add(denominator, new(big.Int).Mul(sqrtPrice, amount))
+		quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+		if remainder.Sign() != 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+		return quotient
+	}
+	delta := new(big.Int).Lsh(amount, 64)
+	delta.Quo(delta, liquidity)
+	return delta.Add(delta, sqrtPrice)
+}
+
+// swapStep is one exact input step of a swap towards a target price
+type swapStep struct {
+	nextSqrtPrice *big.Int
+	amountIn      *big.Int
+	amountOut     *big.Int
+	fee           *big.Int
+}
+
+// computeSwapStep swaps remaining, fee included, from sqrtPrice towards
+// target at constant liquidity, with the rounding of the program's
+// compute_swap for exact input swaps
+func computeSwapStep(remaining, liquidity, sqrtPrice, target *big.Int, feeRate uint16, aToB bool) swapStep {
+	feeDenominator := big.NewInt(feeRateDenominator)
+	lessFee := new(big.Int).Mul(remaining, big.NewInt(int64(feeRateDenominator-int(feeRate))))
+	lessFee.Quo(lessFee, feeDenominator)
+
+	amountIn := inputDelta(sqrtPrice, target, liquidity, aToB)
+	// an input beyond u64 cannot reach the target in one instruction
+	reachable := amountIn.Cmp(maxU64) <= 0
+	next := new(big.Int).Set(target)
+	if !reachable || lessFee.Cmp(amountIn) < 0 {
+		next = nextSqrtPriceFromInput(sqrtPrice, liquidity, lessFee, aToB)
+	}
+	isMaxSwap := next.Cmp(target) == 0
+	if !isMaxSwap || !reachable {
+		amountIn = inputDelta(sqrtPrice, next, liquidity, aToB)
+	}
+
+	amountOut := amountBDelta(sqrtPrice, next, liquidity, false)
+	if !aToB {
+		amountOut = amountADelta(sqrtPrice, next, liquidity, false)
+	}
+
+	var fee *big.Int
+	if isMaxSwap {
+		fee = new(big.Int).Mul(amountIn, big.NewInt(int64(feeRate)))
+		divisor := big.NewInt(int64(feeRateDenominator - int(feeRate)))
+		quotient, remainder := fee.QuoRem(fee, divisor, new(big.Int))
+		if remainder.Sign() != 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+		fee = quotient
+	} else {
+		fee = new(big.Int).Sub(remaining, amountIn)
+	}
+	return swapStep{nextSqrtPrice: next, amountIn: amountIn, amountOut: amountOut, fee: fee}
+}
+
+// inputDelta is the input that moves the price from p0 to p1, rounded up
+func inputDelta(p0, p1, liquidity *big.Int, aToB bool) *big.Int {
+	if aToB {
+		return amountADelta(p0, p1, liquidity, true)
+	}
+	return amountBDelta(p0, p1, liquidity, true)
+}
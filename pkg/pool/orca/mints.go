@@ -0,0 +1,106 @@
+package orca
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// whirlpoolMints is what the pool knows of its mints: the token program
+// owning each, its Token-2022 transfer fee if any, and the epoch the fees
+// apply in
+type whirlpoolMints struct {
+	programs [2]solana.PublicKey
+	fees     [2]*sol.TransferFeeConfig
+	epoch    uint64
+}
+
+// mintAccounts are fetched along with the pool on every quote: both mints
+// and the clock, whose epoch selects the transfer fee in force
+func (pool *WhirlpoolPool) mintAccounts() []solana.PublicKey {
+	return []solana.PublicKey{pool.TokenMintA, pool.TokenMintB, solana.SysVarClockPubkey}
+}
+
+// loadMints reads the accounts of mintAccounts from results starting at
+// offset. Accounts missing from the response keep what was loaded before
+func (pool *WhirlpoolPool) loadMints(results *rpc.GetMultipleAccountsResult, offset int) {
+	mints := &whirlpoolMints{}
+	if pool.mints != nil {
+		*mints = *pool.mints
+	}
+	for i := 0; i < 2; i++ {
+		if offset+i >= len(results.Value) || results.Value[offset+i] == nil {
+			continue
+		}
+		account := results.Value[offset+i]
+		mints.programs[i] = account.Owner
+		mints.fees[i] = nil
+		if account.Owner.Equals(solana.Token2022ProgramID) {
+			if config, ok := sol.ParseTransferFeeConfig(account.Data.GetBinary()); ok {
+				mints.fees[i] = config
+			}
+		}
+	}
+	if data, ok := sol.AccountData(results, offset+2); ok {
+		if clock, err := sol.ParseClock(data); err == nil {
+			mints.epoch = clock.Epoch
+		}
+	}
+	pool.mints = mints
+}
+
+// ensureMints loads the mints when no quote has yet
+func (pool *WhirlpoolPool) ensureMints(ctx context.Context, solClient *sol.Client) error {
+	if pool.mints != nil {
+		return nil
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, pool.mintAccounts())
+	if err != nil {
+		return fmt.Errorf("failed to fetch pool mints: %w", err)
+	}
+	pool.loadMints(results, 0)
+	if pool.mints.programs[0].IsZero() || pool.mints.programs[1].IsZero() {
+		return fmt.Errorf("mints of whirlpool %s not found", pool.PoolId)
+	}
+	return nil
+}
+
+// usesSwapV2 reports whether swaps go through swap_v2, which handles
+// Token-2022 mints and their transfer fees. Pools of two classic SPL Token
+// mints use the legacy swap
+func (pool *WhirlpoolPool) usesSwapV2() bool {
+	if pool.mints == nil {
+		return true
+	}
+	for _, program := range pool.mints.programs {
+		if !program.Equals(solana.TokenProgramID) {
+			return true
+		}
+	}
+	return false
+}
+
+// swapDiscriminator is the discriminator of the swap instruction the pool builds
+func (pool *WhirlpoolPool) swapDiscriminator() []byte {
+	if pool.usesSwapV2() {
+		return SwapV2Discriminator
+	}
+	return SwapDiscriminator
+}
+
+// transferFee returns the Token-2022 fee on a transfer of amount of the
+// pool's mint, zero for mints without one
+func (pool *WhirlpoolPool) transferFee(mint string, amount math.Int) math.Int {
+	if pool.mints == nil {
+		return math.ZeroInt()
+	}
+	i := 0
+	if mint == pool.TokenMintB.String() {
+		i = 1
+	}
+	return pool.mints.fees[i].Fee(pool.mints.epoch, amount)
+}
@@ -0,0 +1,147 @@
+package orca
+
+import (
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// maxImpactSearchSteps bounds both the doubling and the bisection phase
+const maxImpactSearchSteps = 128
+
+// swap returns the output of an exact input swap of amount across the cached
+// tick arrays, crossing initialized ticks until the input is spent. It fails
+// when the input outlasts the tick arrays a swap instruction can reference
+func (pool *WhirlpoolPool) swap(aToB bool, amount *big.Int) (*big.Int, error) {
+	seq, err := pool.swapSequence(aToB)
+	if err != nil {
+		return nil, err
+	}
+	limit := MaxSqrtPriceX64
+	if aToB {
+		limit = MinSqrtPriceX64
+	}
+
+	remaining := new(big.Int).Set(amount)
+	amountOut := new(big.Int)
+	sqrtPrice := pool.SqrtPriceX64.Big()
+	liquidity := pool.Liquidity.Big()
+	tick := pool.TickCurrentIndex
+	arrayIndex := 0
+	for remaining.Sign() > 0 && sqrtPrice.Cmp(limit) != 0 {
+		index, nextTick, next, err := seq.next(tick, arrayIndex)
+		if err != nil {
+			return nil, err
+		}
+		arrayIndex = index
+		nextSqrtPrice, err := sqrtPriceFromTick(nextTick)
+		if err != nil {
+			return nil, err
+		}
+		target := nextSqrtPrice
+		if (aToB && target.Cmp(limit) < 0) || (!aToB && target.Cmp(limit) > 0) {
+			target = limit
+		}
+
+		step := computeSwapStep(remaining, liquidity, sqrtPrice, target, pool.FeeRate, aToB)
+		remaining.Sub(remaining, step.amountIn)
+		remaining.Sub(remaining, step.fee)
+		amountOut.Add(amountOut, step.amountOut)
+
+		if step.nextSqrtPrice.Cmp(nextSqrtPrice) == 0 {
+			if next.Initialized {
+				if aToB {
+					liquidity.Sub(liquidity, next.LiquidityNet)
+				} else {
+					liquidity.Add(liquidity, next.LiquidityNet)
+				}
+				if liquidity.Sign() < 0 {
+					return nil, fmt.Errorf("negative liquidity crossing tick %d", nextTick)
+				}
+			}
+			tick = nextTick
+			if aToB {
+				tick--
+			}
+		}
+		sqrtPrice = step.nextSqrtPrice
+	}
+	if remaining.Sign() > 0 {
+		return nil, fmt.Errorf("whirlpool %s cannot fill the input within its price range", pool.PoolId)
+	}
+	if amountOut.Cmp(maxU64) > 0 {
+		return nil, fmt.Errorf("output exceeds uint64")
+	}
+	return amountOut, nil
+}
+
+// RawSpotPrice is the pool price cached by the last Quote
+func (pool *WhirlpoolPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	return pkg.PriceFromSqrtX64(pool.SqrtPriceX64.Big(), inputMint == pool.TokenMintA.String())
+}
+
+// MaxInputForImpact searches the cached tick arrays for the largest input
+// within maxImpactBps of the spot price. Inputs that run past the loaded tick
+// arrays count as exceeding the bound
+func (pool *WhirlpoolPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.SqrtPriceX64.IsZero() {
+		return math.ZeroInt(), fmt.Errorf("pool price not loaded")
+	}
+	aToB := inputMint == pool.TokenMintA.String()
+
+	// spot output per unit of input after fees, scaled down by the bound
+	sqrtPrice := new(big.Float).SetInt(pool.SqrtPriceX64.Big())
+	spot := new(big.Float).Mul(sqrtPrice, sqrtPrice)
+	spot.Quo(spot, new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 128)))
+	if !aToB {
+		spot.Quo(big.NewFloat(1), spot)
+	}
+	feeRate := float64(pool.FeeRate) / feeRateDenominator
+	spot.Mul(spot, big.NewFloat((1-feeRate)*(1-float64(maxImpactBps)/10000)))
+
+	within := func(amount math.Int) (bool, error) {
+		out, err := pool.ComputeAmountOut(inputMint, amount)
+		if err != nil {
+			return false, err
+		}
+		floor := new(big.Float).Mul(new(big.Float).SetInt(amount.BigInt()), spot)
+		return new(big.Float).SetInt(out.BigInt()).Cmp(floor) >= 0, nil
+	}
+
+	// small inputs lose most of their output to rounding, so double until the
+	// bound is first met and then until it is exceeded
+	lo, hi := math.ZeroInt(), math.Int{}
+	found := false
+	amount := math.OneInt()
+	for step := 0; step < maxImpactSearchSteps && hi.IsNil() && amount.IsUint64(); step++ {
+		ok, err := within(amount)
+		if err != nil && !found {
+			return math.ZeroInt(), fmt.Errorf("failed to quote %s: %w", amount, err)
+		}
+		switch {
+		case ok:
+			lo, found = amount, true
+		case found:
+			hi = amount
+		}
+		amount = amount.MulRaw(2)
+	}
+	if hi.IsNil() {
+		return lo, nil
+	}
+
+	for step := 0; step < maxImpactSearchSteps && hi.Sub(lo).GT(math.OneInt()); step++ {
+		mid := lo.Add(hi).QuoRaw(2)
+		if ok, _ := within(mid); ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
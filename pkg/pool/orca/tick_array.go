@@ -0,0 +1,208 @@
+package orca
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Tick is the part of a tick the swap math reads
+type Tick struct {
+	Initialized  bool
+	LiquidityNet *big.Int
+}
+
+// TickArray is a run of TickArraySize ticks spaced by the pool's tick spacing
+// from StartTickIndex
+type TickArray struct {
+	StartTickIndex int32
+	Ticks          [TickArraySize]Tick
+}
+
+// Decode parses a fixed or a dynamic tick array account
+func (t *TickArray) Decode(data []byte) error {
+	switch {
+	case bytes.HasPrefix(data, TickArrayDiscriminator):
+		if len(data) < tickArrayTicksOffset+TickArraySize*(1+tickDataSize) {
+			return fmt.Errorf("tick array too short: %d bytes", len(data))
+		}
+		t.StartTickIndex = int32(binary.LittleEndian.Uint32(data[tickArrayStartOffset:]))
+		offset := tickArrayTicksOffset
+		for i := range t.Ticks {
+			t.Ticks[i] = decodeTick(data[offset] != 0, data[offset+1:])
+			offset += 1 + tickDataSize
+		}
+		return nil
+	case bytes.HasPrefix(data, DynamicTickArrayDiscriminator):
+		if len(data) < dynamicTickArrayTicksOffset {
+			return fmt.Errorf("tick array too short: %d bytes", len(data))
+		}
+		t.StartTickIndex = int32(binary.LittleEndian.Uint32(data[tickArrayStartOffset:]))
+		offset := dynamicTickArrayTicksOffset
+		for i := range t.Ticks {
+			if offset >= len(data) {
+				return fmt.Errorf("tick array truncated at tick %d", i)
+			}
+			initialized := data[offset] != 0
+			offset++
+			t.Ticks[i] = Tick{}
+			if !initialized {
+				continue
+			}
+			if offset+tickDataSize > len(data) {
+				return fmt.Errorf("tick array truncated at tick %d", i)
+			}
+			t.Ticks[i] = decodeTick(true, data[offset:])
+			offset += tickDataSize
+		}
+		return nil
+	}
+	return fmt.Errorf("not a tick array account")
+}
+
+// decodeTick reads liquidity_net, a little-endian i128, from the tick data
+func decodeTick(initialized bool, data []byte) Tick {
+	if !initialized {
+		return Tick{}
+	}
+	be := make([]byte, 16)
+	for i := 0; i < 16; i++ {
+		be[i] = data[15-i]
+	}
+	net := new(big.Int).SetBytes(be)
+	if be[0]&0x80 != 0 {
+		net.Sub(net, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return Tick{Initialized: true, LiquidityNet: net}
+}
+
+// ticksPerArray is the tick index span of one tick array
+func (pool *WhirlpoolPool) ticksPerArray() int32 {
+	return int32(pool.TickSpacing) * TickArraySize
+}
+
+// tickArrayStart returns the start index of the tick array holding tick
+func (pool *WhirlpoolPool) tickArrayStart(tick int32) int32 {
+	span := pool.ticksPerArray()
+	return floorDiv(tick, span) * span
+}
+
+// tickArrayStarts returns the start indexes of the tick arrays a swap in the
+// direction crosses, from the one holding the current tick. Swaps from B to
+// A start one tick spacing up, as the program searches from the next tick
+func (pool *WhirlpoolPool) tickArrayStarts(aToB bool) []int32 {
+	span := pool.ticksPerArray()
+	tick := pool.TickCurrentIndex
+	if !aToB {
+		tick += int32(pool.TickSpacing)
+	}
+	start := pool.tickArrayStart(tick)
+	starts := make([]int32, 0, swapTickArrays)
+	for i := 0; i < swapTickArrays; i++ {
+		if start+span <= MinTick || start > MaxTick {
+			break
+		}
+		starts = append(starts, start)
+		if aToB {
+			start -= span
+		} else {
+			start += span
+		}
+	}
+	return starts
+}
+
+// tickArrayAddress derives the tick array PDA starting at start
+func (pool *WhirlpoolPool) tickArrayAddress(start int32) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{
+		[]byte(tickArraySeed),
+		pool.PoolId[:],
+		[]byte(strconv.FormatInt(int64(start), 10)),
+	}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive tick array PDA: %w", err)
+	}
+	return address, nil
+}
+
+// tickSequence is the loaded tick arrays a swap crosses, in swap order
+type tickSequence struct {
+	arrays  []*TickArray
+	spacing int32
+	aToB    bool
+}
+
+// swapSequence returns the cached tick arrays of a swap in the direction,
+// stopping at the first one not loaded
+func (pool *WhirlpoolPool) swapSequence(aToB bool) (*tickSequence, error) {
+	seq := &tickSequence{spacing: int32(pool.TickSpacing), aToB: aToB}
+	for _, start := range pool.tickArrayStarts(aToB) {
+		tickArray, ok := pool.tickArrays[start]
+		if !ok {
+			break
+		}
+		seq.arrays = append(seq.arrays, tickArray)
+	}
+	if len(seq.arrays) == 0 {
+		return nil, fmt.Errorf("tick arrays of whirlpool %s not loaded", pool.PoolId)
+	}
+	return seq, nil
+}
+
+// next returns the next initialized tick past tick in the swap direction,
+// searching from array index from. Past the last array it returns the array
+// boundary as an uninitialized tick, as the program does
+func (s *tickSequence) next(tick int32, from int) (int, int32, Tick, error) {
+	search := tick
+	span := s.spacing * TickArraySize
+	for i := from; i < len(s.arrays); i++ {
+		array := s.arrays[i]
+		offset := floorDiv(search-array.StartTickIndex, s.spacing)
+		if !s.aToB {
+			// b to a searches from the tick after the current one
+			offset++
+		}
+		if offset < 0 || offset > TickArraySize || (s.aToB && offset == TickArraySize) {
+			return 0, 0, Tick{}, fmt.Errorf("tick %d is outside the swap's tick arrays", tick)
+		}
+		for offset >= 0 && offset < TickArraySize {
+			if array.Ticks[offset].Initialized {
+				return i, array.StartTickIndex + offset*s.spacing, array.Ticks[offset], nil
+			}
+			if s.aToB {
+				offset--
+			} else {
+				offset++
+			}
+		}
+		if i+1 == len(s.arrays) {
+			boundary := array.StartTickIndex
+			if !s.aToB {
+				boundary += (TickArraySize - 1) * s.spacing
+				if boundary <= tick {
+					break
+				}
+			}
+			return i, boundary, Tick{}, nil
+		}
+		if s.aToB {
+			search = array.StartTickIndex - 1
+		} else {
+			search = array.StartTickIndex + span - 1
+		}
+	}
+	return 0, 0, Tick{}, fmt.Errorf("swap runs past the loaded tick arrays")
+}
+
+func floorDiv(a, b int32) int32 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
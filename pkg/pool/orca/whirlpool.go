@@ -0,0 +1,318 @@
+package orca
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"lukechampine.com/uint128"
+)
+
+// WhirlpoolPool is an Orca Whirlpool. Token A and token B are the base and
+// quote mints, ordered by the program
+type WhirlpoolPool struct {
+	PoolId           solana.PublicKey
+	WhirlpoolsConfig solana.PublicKey
+	TickSpacing      uint16
+	// FeeTierIndex equals TickSpacing except on adaptive fee pools
+	FeeTierIndex uint16
+	// FeeRate is the static fee in hundredths of a basis point
+	FeeRate          uint16
+	Liquidity        uint128.Uint128
+	SqrtPriceX64     uint128.Uint128
+	TickCurrentIndex int32
+	TokenMintA       solana.PublicKey
+	TokenVaultA      solana.PublicKey
+	TokenMintB       solana.PublicKey
+	TokenVaultB      solana.PublicKey
+
+	// tickArrays holds the tick arrays of the last quotes by start index;
+	// arrays that do not exist on chain are kept empty
+	tickArrays map[int32]*TickArray
+	// mints holds the mint programs and transfer fees of the last quote
+	mints *whirlpoolMints
+}
+
+func (pool *WhirlpoolPool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameOrcaWhirlpool
+}
+
+func (pool *WhirlpoolPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *WhirlpoolPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token A as base and token B as quote
+func (pool *WhirlpoolPool) GetTokens() (string, string) {
+	return pool.TokenMintA.String(), pool.TokenMintB.String()
+}
+
+// Decode parses a Whirlpool account
+func (pool *WhirlpoolPool) Decode(data []byte) error {
+	if len(data) < WhirlpoolSize {
+		return fmt.Errorf("whirlpool account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, WhirlpoolDiscriminator) {
+		return fmt.Errorf("not a whirlpool account")
+	}
+	u16 := func(offset int) uint16 { return binary.LittleEndian.Uint16(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.WhirlpoolsConfig = key(whirlpoolsConfigOffset)
+	pool.TickSpacing = u16(tickSpacingOffset)
+	pool.FeeTierIndex = u16(feeTierIndexOffset)
+	pool.FeeRate = u16(feeRateOffset)
+	pool.Liquidity = uint128.FromBytes(data[liquidityOffset:])
+	pool.SqrtPriceX64 = uint128.FromBytes(data[sqrtPriceOffset:])
+	pool.TickCurrentIndex = int32(binary.LittleEndian.Uint32(data[tickCurrentIndexOffset:]))
+	pool.TokenMintA = key(TokenMintAOffset)
+	pool.TokenVaultA = key(tokenVaultAOffset)
+	pool.TokenMintB = key(TokenMintBOffset)
+	pool.TokenVaultB = key(tokenVaultBOffset)
+	if pool.TickSpacing == 0 {
+		return fmt.Errorf("whirlpool has zero tick spacing")
+	}
+	return nil
+}
+
+// UsesAdaptiveFee reports whether the pool adds a volatility based fee to its
+// static fee. The quote math here only knows the static fee
+func (pool *WhirlpoolPool) UsesAdaptiveFee() bool {
+	return pool.FeeTierIndex != pool.TickSpacing
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the tick arrays and mints already loaded
+func (pool *WhirlpoolPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*WhirlpoolPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	tickArrays, mints := pool.tickArrays, pool.mints
+	*pool = *fresh
+	pool.tickArrays, pool.mints = tickArrays, mints
+	return true
+}
+
+// Reset drops the cached tick arrays
+func (pool *WhirlpoolPool) Reset() {
+	pool.tickArrays = nil
+}
+
+// Quote refreshes the pool, its mints and the tick arrays the swap crosses,
+// then returns what the user receives for inputAmount net of Token-2022
+// transfer fees on both the input and the output
+func (pool *WhirlpoolPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	if pool.UsesAdaptiveFee() {
+		return math.ZeroInt(), fmt.Errorf("adaptive fee whirlpool %s is not supported", pool.PoolId)
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, append([]solana.PublicKey{pool.PoolId}, pool.mintAccounts()...))
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode whirlpool %s: %w", pool.PoolId, err)
+		}
+	}
+	pool.loadMints(results, 1)
+
+	aToB := inputMint == pool.TokenMintA.String()
+	if err := pool.loadTickArrays(ctx, solClient, aToB); err != nil {
+		return math.ZeroInt(), err
+	}
+
+	outputMint := pool.TokenMintB.String()
+	if !aToB {
+		outputMint = pool.TokenMintA.String()
+	}
+	// the input's transfer fee is withheld before it reaches the vault and
+	// the output's before it reaches the user
+	curveIn := inputAmount.Sub(pool.transferFee(inputMint, inputAmount))
+	amountOut, err := pool.ComputeAmountOut(inputMint, curveIn)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return amountOut.Sub(pool.transferFee(outputMint, amountOut)), nil
+}
+
+// loadTickArrays fetches the tick arrays a swap in the direction crosses
+func (pool *WhirlpoolPool) loadTickArrays(ctx context.Context, solClient *sol.Client, aToB bool) error {
+	starts := pool.tickArrayStarts(aToB)
+	addresses := make([]solana.PublicKey, len(starts))
+	for i, start := range starts {
+		address, err := pool.tickArrayAddress(start)
+		if err != nil {
+			return err
+		}
+		addresses[i] = address
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, addresses)
+	if err != nil {
+		return fmt.Errorf("batch request failed: %w", err)
+	}
+	if pool.tickArrays == nil {
+		pool.tickArrays = make(map[int32]*TickArray)
+	}
+	for i, start := range starts {
+		tickArray := &TickArray{StartTickIndex: start}
+		// tick arrays that were never initialized hold no initialized ticks
+		if data, ok := sol.AccountData(results, i); ok {
+			if err := tickArray.Decode(data); err != nil {
+				return fmt.Errorf("failed to decode tick array %s: %w", addresses[i], err)
+			}
+		}
+		pool.tickArrays[start] = tickArray
+	}
+	return nil
+}
+
+// ComputeAmountOut prices inputAmount against the cached pool state and tick
+// arrays, before transfer fees
+func (pool *WhirlpoolPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if inputMint != pool.TokenMintA.String() && inputMint != pool.TokenMintB.String() {
+		return math.ZeroInt(), fmt.Errorf("mint %s is not traded by whirlpool %s", inputMint, pool.PoolId)
+	}
+	amountOut, err := pool.swap(inputMint == pool.TokenMintA.String(), inputAmount.BigInt())
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// SwapFee returns the static trading fee charged on inputAmount
+func (pool *WhirlpoolPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return inputAmount.MulRaw(int64(pool.FeeRate)).QuoRaw(feeRateDenominator)
+}
+
+// CurrentPrice returns the price of token A in token B, in raw units
+func (pool *WhirlpoolPool) CurrentPrice() float64 {
+	sqrtPrice, _ := new(big.Float).Quo(new(big.Float).SetInt(pool.SqrtPriceX64.Big()), new(big.Float).SetInt(q64)).Float64()
+	return sqrtPrice * sqrtPrice
+}
+
+// BuildSwapInstructions builds a swap_v2 for pools with a Token-2022 mint and
+// the legacy swap for pools of two classic SPL Token mints. minOut is what the
+// user receives net of transfer fees, as Quote returns it
+func (pool *WhirlpoolPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	if err := pool.ensureMints(ctx, solClient); err != nil {
+		return nil, err
+	}
+	aToB := inputMint == pool.TokenMintA.String()
+
+	tickArrays := make([]solana.PublicKey, 0, swapTickArrays)
+	for _, start := range pool.tickArrayStarts(aToB) {
+		address, err := pool.tickArrayAddress(start)
+		if err != nil {
+			return nil, err
+		}
+		tickArrays = append(tickArrays, address)
+	}
+	// the program takes exactly three tick arrays, repeating the last one
+	// near the ends of the price range
+	for len(tickArrays) < swapTickArrays {
+		tickArrays = append(tickArrays, tickArrays[len(tickArrays)-1])
+	}
+	oracle, _, err := sol.FindProgramAddress([][]byte{[]byte(oracleSeed), pool.PoolId[:]}, ProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive oracle PDA: %w", err)
+	}
+
+	accounts := make(solana.AccountMetaSlice, 0, 15)
+	if pool.usesSwapV2() {
+		programA, programB := pool.mints.programs[0], pool.mints.programs[1]
+		accounts = append(accounts,
+			solana.Meta(programA),
+			solana.Meta(programB),
+			solana.Meta(solana.MemoProgramID),
+			solana.Meta(user).SIGNER(),
+			solana.Meta(pool.PoolId).WRITE(),
+			solana.Meta(pool.TokenMintA),
+			solana.Meta(pool.TokenMintB),
+		)
+	} else {
+		accounts = append(accounts,
+			solana.Meta(solana.TokenProgramID),
+			solana.Meta(user).SIGNER(),
+			solana.Meta(pool.PoolId).WRITE(),
+		)
+	}
+	accounts = append(accounts,
+		solana.Meta(userBaseAccount).WRITE(),
+		solana.Meta(pool.TokenVaultA).WRITE(),
+		solana.Meta(userQuoteAccount).WRITE(),
+		solana.Meta(pool.TokenVaultB).WRITE(),
+		solana.Meta(tickArrays[0]).WRITE(),
+		solana.Meta(tickArrays[1]).WRITE(),
+		solana.Meta(tickArrays[2]).WRITE(),
+		solana.Meta(oracle).WRITE(),
+	)
+
+	data := swapData(pool.swapDiscriminator(), inputAmount.Uint64(), minOut.Uint64(), aToB)
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// swapData encodes an exact input swap limited only by the price range.
+// swap_v2 ends with an empty remaining accounts info
+func swapData(discriminator []byte, amount, minOut uint64, aToB bool) []byte {
+	data := make([]byte, 0, 43)
+	data = append(data, discriminator...)
+	data = binary.LittleEndian.AppendUint64(data, amount)
+	data = binary.LittleEndian.AppendUint64(data, minOut)
+	limit := uint128.FromBig(MaxSqrtPriceX64)
+	if aToB {
+		limit = uint128.FromBig(MinSqrtPriceX64)
+	}
+	data = binary.LittleEndian.AppendUint64(data, limit.Lo)
+	data = binary.LittleEndian.AppendUint64(data, limit.Hi)
+	data = append(data, 1, boolByte(aToB))
+	if bytes.Equal(discriminator, SwapV2Discriminator) {
+		data = append(data, 0)
+	}
+	return data
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// DecodeMinOut reads other_amount_threshold back from the swap instruction
+func (pool *WhirlpoolPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, pool.swapDiscriminator(), 16)
+}
+
+// SwapAmountFields locates amount and other_amount_threshold in the swap
+// instruction, laid out alike in swap_v2 and the legacy swap
+func (pool *WhirlpoolPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	discriminator := pool.swapDiscriminator()
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: discriminator, Offset: 8},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: discriminator, Offset: 16}
+}
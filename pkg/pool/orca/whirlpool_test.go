@@ -0,0 +1,36 @@
+package orca
+
+import (
+	"context"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDecodeMinOut(t *testing.T) {
+	user := solana.NewWallet().PublicKey()
+	minOut := math.NewInt(1_234_567)
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+		pool := &WhirlpoolPool{
+			PoolId:      solana.NewWallet().PublicKey(),
+			TickSpacing: 64,
+			TokenMintA:  solana.NewWallet().PublicKey(),
+			TokenMintB:  solana.NewWallet().PublicKey(),
+			mints:       &whirlpoolMints{programs: [2]solana.PublicKey{program, program}},
+		}
+		for _, inputMint := range []solana.PublicKey{pool.TokenMintA, pool.TokenMintB} {
+			instructions, err := pool.BuildSwapInstructions(context.Background(), nil, user, inputMint.String(), math.NewInt(5_000_000), minOut, solana.PublicKey{}, solana.PublicKey{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := pool.DecodeMinOut(inputMint.String(), instructions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !encoded.Equal(minOut) {
+				t.Fatalf("swap v2 %v from %s encoded min out %s, want %s", pool.usesSwapV2(), inputMint, encoded, minOut)
+			}
+		}
+	}
+}
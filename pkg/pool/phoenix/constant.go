@@ -0,0 +1,79 @@
+// Package phoenix quotes and builds swaps against Phoenix, an on-chain
+// central limit order book whose whole book lives in the market account.
+// Swaps are immediate-or-cancel orders matched against the resting orders
+package phoenix
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	// ProgramID is the Phoenix program
+	ProgramID = solana.MustPublicKeyFromBase58("PhoeNiXZ8ByJGLkxNfZRnkUfjvmuYqLR89jjFHGqdXY")
+
+	// MarketDiscriminator prefixes Phoenix market accounts
+	MarketDiscriminator = []byte{85, 153, 127, 98, 215, 115, 0, 175}
+)
+
+// Phoenix market header layout
+const (
+	// MarketHeaderSize is the size of the header preceding the order book
+	MarketHeaderSize = 576
+	BaseMintOffset   = 48
+	QuoteMintOffset  = 128
+
+	statusOffset        = 8
+	bidsSizeOffset      = 16
+	asksSizeOffset      = 24
+	baseDecimalsOffset  = 40
+	baseVaultOffset     = 80
+	baseLotSizeOffset   = 112
+	quoteDecimalsOffset = 120
+	quoteVaultOffset    = 160
+	quoteLotSizeOffset  = 192
+)
+
+// Phoenix order book layout, following the header
+const (
+	baseLotsPerBaseUnitOffset = 832
+	tickSizeOffset            = 840
+	takerFeeBpsOffset         = 856
+	bidsOffset                = 880
+
+	// treeHeaderSize is the root and allocator header of a red-black tree
+	treeHeaderSize = 32
+	// orderNodeSize is a tree node holding an order ID and a resting order
+	orderNodeSize = 64
+)
+
+// MarketStatus is the trading state of a Phoenix market
+type MarketStatus uint64
+
+const (
+	MarketStatusUninitialized MarketStatus = iota
+	MarketStatusActive                     // open to takers and makers
+	MarketStatusPostOnly                   // makers only
+	MarketStatusPaused
+	MarketStatusClosed
+	MarketStatusTombstoned
+)
+
+// Side is the side of an order
+type Side uint8
+
+const (
+	SideBid Side = iota // buys base with quote
+	SideAsk             // sells base for quote
+)
+
+const (
+	// instructionSwap is the Swap instruction tag
+	instructionSwap = 0
+	// orderPacketImmediateOrCancel is the ImmediateOrCancel order packet tag
+	orderPacketImmediateOrCancel = 2
+	// selfTradeCancelProvide cancels the resting order on a self trade
+	selfTradeCancelProvide = 1
+
+	// bpsDenominator is the denominator of the taker fee
+	bpsDenominator = 10000
+)
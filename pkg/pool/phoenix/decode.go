@@ -0,0 +1,52 @@
+package phoenix
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a Phoenix swap, an immediate-or-cancel order. It does not
+// name the mints, so they are left zero, and its amounts are in the market's
+// lots: base lots sold or quote lots spent, and the minimum lots to fill
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if len(data) < 4 || data[0] != instructionSwap || data[1] != orderPacketImmediateOrCancel {
+		return nil, pkg.ErrNotSwap
+	}
+	side := Side(data[2])
+	if side != SideBid && side != SideAsk {
+		return nil, fmt.Errorf("invalid order side %d", side)
+	}
+	// the order packet opens with an optional limit price
+	offset := 4
+	if data[3] == 1 {
+		offset += 8
+	}
+	if len(data) < offset+32 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 9); err != nil {
+		return nil, err
+	}
+	u64 := func(field int) math.Int {
+		return math.NewIntFromUint64(binary.LittleEndian.Uint64(data[offset+field*8:]))
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:      pkg.ProtocolNamePhoenix,
+		Pool:          accounts[2].PublicKey,
+		User:          accounts[3].PublicKey,
+		AmountsInLots: true,
+	}
+	if side == SideAsk {
+		params.UserInputAccount, params.UserOutputAccount = accounts[4].PublicKey, accounts[5].PublicKey
+		params.AmountIn, params.MinAmountOut = u64(0), u64(3)
+	} else {
+		params.UserInputAccount, params.UserOutputAccount = accounts[5].PublicKey, accounts[4].PublicKey
+		params.AmountIn, params.MinAmountOut = u64(1), u64(2)
+	}
+	return params, nil
+}
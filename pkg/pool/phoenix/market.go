@@ -0,0 +1,137 @@
+package phoenix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// MarketHeader is the fixed part of a Phoenix market account
+type MarketHeader struct {
+	Status        MarketStatus
+	BidsSize      uint64
+	AsksSize      uint64
+	BaseDecimals  uint32
+	BaseMint      solana.PublicKey
+	BaseVault     solana.PublicKey
+	BaseLotSize   uint64
+	QuoteDecimals uint32
+	QuoteMint     solana.PublicKey
+	QuoteVault    solana.PublicKey
+	QuoteLotSize  uint64
+}
+
+// Decode parses the market header, which is all discovery fetches
+func (h *MarketHeader) Decode(data []byte) error {
+	if len(data) < MarketHeaderSize {
+		return fmt.Errorf("phoenix market account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, MarketDiscriminator) {
+		return fmt.Errorf("not a phoenix market account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	h.Status = MarketStatus(u64(statusOffset))
+	h.BidsSize = u64(bidsSizeOffset)
+	h.AsksSize = u64(asksSizeOffset)
+	h.BaseDecimals = binary.LittleEndian.Uint32(data[baseDecimalsOffset:])
+	h.BaseMint = key(BaseMintOffset)
+	h.BaseVault = key(baseVaultOffset)
+	h.BaseLotSize = u64(baseLotSizeOffset)
+	h.QuoteDecimals = binary.LittleEndian.Uint32(data[quoteDecimalsOffset:])
+	h.QuoteMint = key(QuoteMintOffset)
+	h.QuoteVault = key(quoteVaultOffset)
+	h.QuoteLotSize = u64(quoteLotSizeOffset)
+	if h.BaseLotSize == 0 || h.QuoteLotSize == 0 {
+		return fmt.Errorf("phoenix market has a zero lot size")
+	}
+	return nil
+}
+
+// Order is a resting order of the book
+type Order struct {
+	PriceInTicks uint64
+	NumBaseLots  uint64
+	// LastValidSlot and LastValidUnixTimestamp expire the order when nonzero
+	LastValidSlot          uint64
+	LastValidUnixTimestamp uint64
+}
+
+// expired reports whether the matching engine skips the order at slot and
+// unix time now
+func (o Order) expired(slot, now uint64) bool {
+	return (o.LastValidSlot != 0 && o.LastValidSlot < slot) ||
+		(o.LastValidUnixTimestamp != 0 && o.LastValidUnixTimestamp < now)
+}
+
+// OrderBook is the matching state of a Phoenix market. Bids are ordered from
+// the highest price and asks from the lowest
+type OrderBook struct {
+	BaseLotsPerBaseUnit            uint64
+	TickSizeInQuoteLotsPerBaseUnit uint64
+	TakerFeeBps                    uint64
+	Bids                           []Order
+	Asks                           []Order
+}
+
+// DecodeOrderBook parses the order book following the header of a fully
+// fetched market account
+func DecodeOrderBook(header *MarketHeader, data []byte) (*OrderBook, error) {
+	asksOffset := bidsOffset + treeHeaderSize + int(header.BidsSize)*orderNodeSize
+	end := asksOffset + treeHeaderSize + int(header.AsksSize)*orderNodeSize
+	if header.BidsSize == 0 || header.AsksSize == 0 || len(data) < end {
+		return nil, fmt.Errorf("phoenix market account holds %d bytes, the book needs %d", len(data), end)
+	}
+	book := &OrderBook{
+		BaseLotsPerBaseUnit:            binary.LittleEndian.Uint64(data[baseLotsPerBaseUnitOffset:]),
+		TickSizeInQuoteLotsPerBaseUnit: binary.LittleEndian.Uint64(data[tickSizeOffset:]),
+		TakerFeeBps:                    binary.LittleEndian.Uint64(data[takerFeeBpsOffset:]),
+	}
+	if book.BaseLotsPerBaseUnit == 0 || book.TickSizeInQuoteLotsPerBaseUnit == 0 {
+		return nil, fmt.Errorf("phoenix market has a zero tick or lot size")
+	}
+	var err error
+	if book.Bids, err = parseOrders(data, bidsOffset, header.BidsSize); err != nil {
+		return nil, fmt.Errorf("failed to parse bids: %w", err)
+	}
+	if book.Asks, err = parseOrders(data, asksOffset, header.AsksSize); err != nil {
+		return nil, fmt.Errorf("failed to parse asks: %w", err)
+	}
+	sort.SliceStable(book.Bids, func(i, j int) bool { return book.Bids[i].PriceInTicks > book.Bids[j].PriceInTicks })
+	sort.SliceStable(book.Asks, func(i, j int) bool { return book.Asks[i].PriceInTicks < book.Asks[j].PriceInTicks })
+	return book, nil
+}
+
+// parseOrders walks the red-black tree at offset, whose nodes are numbered
+// from 1 with 0 as the nil node and link through their left and right registers
+func parseOrders(data []byte, offset int, capacity uint64) ([]Order, error) {
+	node := func(index uint32) []byte {
+		start := offset + treeHeaderSize + int(index-1)*orderNodeSize
+		return data[start : start+orderNodeSize]
+	}
+	orders := make([]Order, 0)
+	stack := []uint32{binary.LittleEndian.Uint32(data[offset:])}
+	for len(stack) > 0 {
+		index := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if index == 0 {
+			continue
+		}
+		if uint64(index) > capacity || uint64(len(orders)) >= capacity {
+			return nil, fmt.Errorf("corrupt tree at node %d", index)
+		}
+		n := node(index)
+		orders = append(orders, Order{
+			PriceInTicks:           binary.LittleEndian.Uint64(n[16:]),
+			NumBaseLots:            binary.LittleEndian.Uint64(n[40:]),
+			LastValidSlot:          binary.LittleEndian.Uint64(n[48:]),
+			LastValidUnixTimestamp: binary.LittleEndian.Uint64(n[56:]),
+		})
+		stack = append(stack, binary.LittleEndian.Uint32(n[0:]), binary.LittleEndian.Uint32(n[4:]))
+	}
+	return orders, nil
+}
@@ -0,0 +1,395 @@
+package phoenix
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// PhoenixPool is a Phoenix market routed as a pool: selling base hits the
+// bids and buying base with quote lifts the asks, as one immediate-or-cancel
+// order paying the taker fee in quote
+type PhoenixPool struct {
+	MarketId solana.PublicKey
+	Header   MarketHeader
+
+	// book is the order book of the last quote, with the slot and unix time
+	// its orders' expiry was checked against
+	book *OrderBook
+	slot uint64
+	now  uint64
+}
+
+func (pool *PhoenixPool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNamePhoenix
+}
+
+func (pool *PhoenixPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *PhoenixPool) GetID() string {
+	return pool.MarketId.String()
+}
+
+// GetTokens returns the market's base and quote mints
+func (pool *PhoenixPool) GetTokens() (string, string) {
+	return pool.Header.BaseMint.String(), pool.Header.QuoteMint.String()
+}
+
+// Decode parses the market header; the order book is loaded by Quote
+func (pool *PhoenixPool) Decode(data []byte) error {
+	return pool.Header.Decode(data)
+}
+
+// UpdateFrom takes the freshly decoded header of a rediscovered market while
+// keeping the order book of the last quote
+func (pool *PhoenixPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*PhoenixPool)
+	if !ok || fresh == pool || !fresh.MarketId.Equals(pool.MarketId) {
+		return false
+	}
+	pool.Header = fresh.Header
+	return true
+}
+
+// MintDecimals returns the decimals the market header records for mint
+func (pool *PhoenixPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case pool.Header.BaseMint.String():
+		return uint8(pool.Header.BaseDecimals), true
+	case pool.Header.QuoteMint.String():
+		return uint8(pool.Header.QuoteDecimals), true
+	}
+	return 0, false
+}
+
+// Quote loads the market with its order book and the clock in one batch and
+// fills inputAmount against the book. Inputs the book cannot fill in full
+// fail rather than quote a partial fill
+func (pool *PhoenixPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.MarketId, solana.SysVarClockPubkey})
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	data, ok := sol.AccountData(results, 0)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("phoenix market %s not found", pool.MarketId)
+	}
+	if err := pool.Header.Decode(data); err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to decode phoenix market %s: %w", pool.MarketId, err)
+	}
+	book, err := DecodeOrderBook(&pool.Header, data)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to decode phoenix market %s: %w", pool.MarketId, err)
+	}
+	clockData, ok := sol.AccountData(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(clockData)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	pool.book, pool.slot, pool.now = book, clock.Slot, clock.UnixTimestamp
+
+	if pool.Header.Status != MarketStatusActive {
+		return math.ZeroInt(), fmt.Errorf("phoenix market %s is not open to takers (status %d)", pool.MarketId, pool.Header.Status)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// quoteLots is what baseLots cost at priceInTicks, rounded down as the
+// matching engine does
+func (pool *PhoenixPool) quoteLots(baseLots, priceInTicks uint64) *big.Int {
+	lots := new(big.Int).SetUint64(baseLots)
+	lots.Mul(lots, new(big.Int).SetUint64(priceInTicks))
+	lots.Mul(lots, new(big.Int).SetUint64(pool.book.TickSizeInQuoteLotsPerBaseUnit))
+	return lots.Quo(lots, new(big.Int).SetUint64(pool.book.BaseLotsPerBaseUnit))
+}
+
+// ComputeAmountOut fills inputAmount against the cached book. Input below
+// one lot is left with the trader, as the order only counts whole lots
+func (pool *PhoenixPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if pool.book == nil {
+		return math.ZeroInt(), fmt.Errorf("order book not loaded")
+	}
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	switch inputMint {
+	case pool.Header.BaseMint.String():
+		return pool.sell(inputAmount.Uint64() / pool.Header.BaseLotSize)
+	case pool.Header.QuoteMint.String():
+		return pool.buy(inputAmount.Uint64() / pool.Header.QuoteLotSize)
+	}
+	return math.ZeroInt(), fmt.Errorf("mint %s is not traded by phoenix market %s", inputMint, pool.MarketId)
+}
+
+// sell matches baseLots against the bids and returns the quote received
+// after the taker fee
+func (pool *PhoenixPool) sell(baseLots uint64) (math.Int, error) {
+	if baseLots == 0 {
+		return math.ZeroInt(), fmt.Errorf("input is below one base lot of %d", pool.Header.BaseLotSize)
+	}
+	remaining := baseLots
+	received := new(big.Int)
+	for _, order := range pool.book.Bids {
+		if remaining == 0 {
+			break
+		}
+		if order.expired(pool.slot, pool.now) {
+			continue
+		}
+		fill := min(remaining, order.NumBaseLots)
+		received.Add(received, pool.quoteLots(fill, order.PriceInTicks))
+		remaining -= fill
+	}
+	if remaining > 0 {
+		return math.ZeroInt(), fmt.Errorf("bids fill %d of %d base lots", baseLots-remaining, baseLots)
+	}
+	received.Sub(received, pool.takerFee(received))
+	received.Mul(received, new(big.Int).SetUint64(pool.Header.QuoteLotSize))
+	return math.NewIntFromBigInt(received), nil
+}
+
+// buy spends quoteLots, the taker fee included, lifting the asks and returns
+// the base received
+func (pool *PhoenixPool) buy(quoteLots uint64) (math.Int, error) {
+	if quoteLots == 0 {
+		return math.ZeroInt(), fmt.Errorf("input is below one quote lot of %d", pool.Header.QuoteLotSize)
+	}
+	// the fee comes on top of the matched quote lots
+	budget := new(big.Int).SetUint64(quoteLots)
+	budget.Mul(budget, big.NewInt(bpsDenominator))
+	budget.Quo(budget, big.NewInt(int64(bpsDenominator+pool.book.TakerFeeBps)))
+
+	var baseLots uint64
+	exhausted := false
+	for _, order := range pool.book.Asks {
+		if order.expired(pool.slot, pool.now) {
+			continue
+		}
+		fill := order.NumBaseLots
+		if cost := pool.quoteLots(fill, order.PriceInTicks); cost.Cmp(budget) > 0 {
+			fill = pool.affordableLots(budget, order.PriceInTicks)
+			exhausted = true
+		}
+		budget.Sub(budget, pool.quoteLots(fill, order.PriceInTicks))
+		baseLots += fill
+		if exhausted || budget.Sign() == 0 {
+			exhausted = true
+			break
+		}
+	}
+	if !exhausted {
+		return math.ZeroInt(), fmt.Errorf("asks fill %d base lots before running out", baseLots)
+	}
+	if baseLots == 0 {
+		return math.ZeroInt(), fmt.Errorf("input buys less than one base lot")
+	}
+	return math.NewIntFromUint64(baseLots).Mul(math.NewIntFromUint64(pool.Header.BaseLotSize)), nil
+}
+
+// affordableLots is the most base lots budget buys at priceInTicks
+func (pool *PhoenixPool) affordableLots(budget *big.Int, priceInTicks uint64) uint64 {
+	lots := new(big.Int).Mul(budget, new(big.Int).SetUint64(pool.book.BaseLotsPerBaseUnit))
+	price := new(big.Int).SetUint64(priceInTicks)
+	price.Mul(price, new(big.Int).SetUint64(pool.book.TickSizeInQuoteLotsPerBaseUnit))
+	lots.Quo(lots, price)
+	// integer division can leave the cost of the last lot over budget
+	for lots.Sign() > 0 && pool.quoteLots(lots.Uint64(), priceInTicks).Cmp(budget) > 0 {
+		lots.Sub(lots, big.NewInt(1))
+	}
+	return lots.Uint64()
+}
+
+// takerFee is the fee on quoteLots matched, rounded up
+func (pool *PhoenixPool) takerFee(quoteLots *big.Int) *big.Int {
+	fee := new(big.Int).Mul(quoteLots, new(big.Int).SetUint64(pool.book.TakerFeeBps))
+	fee.Add(fee, big.NewInt(bpsDenominator-1))
+	return fee.Quo(fee, big.NewInt(bpsDenominator))
+}
+
+// RawSpotPrice is the best price the input meets in the cached book: the
+// best bid when selling base, the best ask when buying it
+func (pool *PhoenixPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	if pool.book == nil {
+		return math.LegacyDec{}, fmt.Errorf("order book not loaded")
+	}
+	selling := inputMint == pool.Header.BaseMint.String()
+	orders := pool.book.Asks
+	if selling {
+		orders = pool.book.Bids
+	}
+	for _, order := range orders {
+		if order.expired(pool.slot, pool.now) {
+			continue
+		}
+		// quote atoms per base atom
+		num := new(big.Int).SetUint64(order.PriceInTicks)
+		num.Mul(num, new(big.Int).SetUint64(pool.book.TickSizeInQuoteLotsPerBaseUnit))
+		num.Mul(num, new(big.Int).SetUint64(pool.Header.QuoteLotSize))
+		den := new(big.Int).SetUint64(pool.book.BaseLotsPerBaseUnit)
+		den.Mul(den, new(big.Int).SetUint64(pool.Header.BaseLotSize))
+		return pkg.RatioPrice(num, den, !selling)
+	}
+	return math.LegacyDec{}, fmt.Errorf("phoenix market %s has an empty book side", pool.MarketId)
+}
+
+// MaxInputForImpact sums the book levels priced within maxImpactBps of the
+// best price on the side the input meets
+func (pool *PhoenixPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.book == nil {
+		return math.ZeroInt(), fmt.Errorf("order book not loaded")
+	}
+	selling := inputMint == pool.Header.BaseMint.String()
+	orders := pool.book.Asks
+	if selling {
+		orders = pool.book.Bids
+	}
+	var best uint64
+	baseLots := uint64(0)
+	quoteLots := new(big.Int)
+	for _, order := range orders {
+		if order.expired(pool.slot, pool.now) {
+			continue
+		}
+		if best == 0 {
+			best = order.PriceInTicks
+		}
+		if selling && order.PriceInTicks*bpsDenominator < best*uint64(bpsDenominator-maxImpactBps) {
+			break
+		}
+		if !selling && order.PriceInTicks*bpsDenominator > best*uint64(bpsDenominator+maxImpactBps) {
+			break
+		}
+		baseLots += order.NumBaseLots
+		quoteLots.Add(quoteLots, pool.quoteLots(order.NumBaseLots, order.PriceInTicks))
+	}
+	if selling {
+		return math.NewIntFromUint64(baseLots).Mul(math.NewIntFromUint64(pool.Header.BaseLotSize)), nil
+	}
+	// gross the matched quote up by the taker fee paid on top
+	quoteLots.Mul(quoteLots, big.NewInt(int64(bpsDenominator+pool.book.TakerFeeBps)))
+	quoteLots.Quo(quoteLots, big.NewInt(bpsDenominator))
+	return math.NewIntFromBigInt(quoteLots).Mul(math.NewIntFromUint64(pool.Header.QuoteLotSize)), nil
+}
+
+// LogAuthority derives the PDA Phoenix logs market events through
+func LogAuthority() solana.PublicKey {
+	authority, _, _ := sol.FindProgramAddress([][]byte{[]byte("log")}, ProgramID)
+	return authority
+}
+
+// BuildSwapInstructions builds a Phoenix swap: an immediate-or-cancel ask of
+// the input's base lots, or bid of its quote lots, with minOut rounded up to
+// whole lots as the minimum fill
+func (pool *PhoenixPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	header := pool.Header
+	var side Side
+	var baseLots, quoteLots, minBaseLots, minQuoteLots uint64
+	switch inputMint {
+	case header.BaseMint.String():
+		side = SideAsk
+		baseLots = inputAmount.Uint64() / header.BaseLotSize
+		minQuoteLots = ceilLots(minOut.Uint64(), header.QuoteLotSize)
+	case header.QuoteMint.String():
+		side = SideBid
+		quoteLots = inputAmount.Uint64() / header.QuoteLotSize
+		minBaseLots = ceilLots(minOut.Uint64(), header.BaseLotSize)
+	default:
+		return nil, fmt.Errorf("mint %s is not traded by phoenix market %s", inputMint, pool.MarketId)
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(ProgramID),
+		solana.Meta(LogAuthority()),
+		solana.Meta(pool.MarketId).WRITE(),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(userBaseAccount).WRITE(),
+		solana.Meta(userQuoteAccount).WRITE(),
+		solana.Meta(header.BaseVault).WRITE(),
+		solana.Meta(header.QuoteVault).WRITE(),
+		solana.Meta(solana.TokenProgramID),
+	}
+	data := make([]byte, 0, 57)
+	data = append(data, swapPrefix(side)...)
+	data = binary.LittleEndian.AppendUint64(data, baseLots)
+	data = binary.LittleEndian.AppendUint64(data, quoteLots)
+	data = binary.LittleEndian.AppendUint64(data, minBaseLots)
+	data = binary.LittleEndian.AppendUint64(data, minQuoteLots)
+	data = append(data, selfTradeCancelProvide)
+	// no match limit, a zero client order ID, wallet funds and no expiry
+	data = append(data, 0)
+	data = append(data, make([]byte, 16)...)
+	data = append(data, 0, 0, 0)
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// swapPrefix opens a swap of an immediate-or-cancel order on side without a
+// price limit
+func swapPrefix(side Side) []byte {
+	return []byte{instructionSwap, orderPacketImmediateOrCancel, byte(side), 0}
+}
+
+// ceilLots is the lots of lotSize that amount needs, rounded up
+func ceilLots(amount, lotSize uint64) uint64 {
+	return (amount + lotSize - 1) / lotSize
+}
+
+// InputLotSize is the lot size of inputMint; the book only fills whole lots
+// of the input
+func (pool *PhoenixPool) InputLotSize(inputMint string) math.Int {
+	if inputMint == pool.Header.BaseMint.String() {
+		return math.NewIntFromUint64(pool.Header.BaseLotSize)
+	}
+	return math.NewIntFromUint64(pool.Header.QuoteLotSize)
+}
+
+// MinOutStep is the lot size of the output of inputMint, to which the swap
+// rounds its minimum fill up
+func (pool *PhoenixPool) MinOutStep(inputMint string) math.Int {
+	if inputMint == pool.Header.BaseMint.String() {
+		return math.NewIntFromUint64(pool.Header.QuoteLotSize)
+	}
+	return math.NewIntFromUint64(pool.Header.BaseLotSize)
+}
+
+// DecodeMinOut reads the minimum fill back from the swap instruction, in
+// output token units
+func (pool *PhoenixPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	if inputMint == pool.Header.BaseMint.String() {
+		lots, err := pkg.DecodeInstructionU64(instructions, ProgramID, swapPrefix(SideAsk), 28)
+		if err != nil {
+			return math.ZeroInt(), err
+		}
+		return lots.Mul(math.NewIntFromUint64(pool.Header.QuoteLotSize)), nil
+	}
+	lots, err := pkg.DecodeInstructionU64(instructions, ProgramID, swapPrefix(SideBid), 20)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return lots.Mul(math.NewIntFromUint64(pool.Header.BaseLotSize)), nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"log"
 
 	"cosmossdk.io/math"
 	bin "github.com/gagliardetto/binary"
@@ -27,8 +28,20 @@ const (
 	// QuoteMintOffset represents the offset for QuoteMint in the pool data
 	QuoteMintOffset = BaseMintOffset + 32
 
+	// LpFeeBps and ProtocolFeeBps are the swap fees assumed until the global
+	// config is read. The program takes each from the quote side and rounds it
+	// up separately
+	LpFeeBps       = 20
+	ProtocolFeeBps = 5
+
+	// TotalFeeBps is the combined swap fee
+	TotalFeeBps = LpFeeBps + ProtocolFeeBps
+
+	// FeeDenominator is the denominator of the basis point fees
+	FeeDenominator = 10_000
+
 	// DefaultFeeRate represents the default fee rate for swaps (0.25%)
-	DefaultFeeRate = 0.00250
+	DefaultFeeRate = float64(TotalFeeBps) / FeeDenominator
 )
 
 // PumpAMMPool represents an AMM pool for the Pump protocol
@@ -48,6 +61,8 @@ type PumpAMMPool struct {
 	PoolId      solana.PublicKey
 	BaseAmount  math.Int
 	QuoteAmount math.Int
+	// Config is the global config read by the last Quote, nil until then
+	Config *GlobalConfig
 }
 
 func (pool *PumpAMMPool) ProtocolName() pkg.ProtocolName {
@@ -112,7 +127,7 @@ func ParsePoolData(data []byte) (*PumpAMMPool, error) {
 	offset += 32
 	layout.LpSupply = binary.LittleEndian.Uint64(data[offset : offset+8])
 	offset += 8
-	if len(data[offset:]) > 32 {
+	if len(data[offset:]) >= 32 {
 		layout.CoinCreator = solana.PublicKeyFromBytes(data[offset : offset+32])
 	} else {
 		layout.CoinCreator = solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
@@ -140,10 +155,109 @@ func (s *PumpAMMPool) BuildSwapInstructions(
 	userQuoteAccount solana.PublicKey,
 ) ([]solana.Instruction, error) {
 	if inputMint == s.BaseMint.String() {
-		return s.buyInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount)
-	} else {
 		return s.sellInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount)
 	}
+
+	// buys are exact-out: the program takes whatever quote minOut costs at
+	// execution time, capped at the input amount
+	if !minOut.IsPositive() {
+		return nil, fmt.Errorf("buy needs a positive base amount out")
+	}
+	if !s.BaseAmount.IsNil() && !s.QuoteAmount.IsNil() {
+		required, err := s.QuoteInForBaseOut(minOut)
+		if err != nil {
+			return nil, err
+		}
+		if required.GT(inputAmount) {
+			return nil, fmt.Errorf("buying %s base costs %s quote, more than the %s offered", minOut, required, inputAmount)
+		}
+	}
+	return s.buyInAMMPool(user, s, inputAmount, minOut, userBaseAccount, userQuoteAccount)
+}
+
+// MinOutIsExact reports whether minOut is the exact base amount bought, which
+// is the case for buys where the quote amount in is only capped
+func (s *PumpAMMPool) MinOutIsExact(inputMint string) bool {
+	return inputMint == s.QuoteMint.String()
+}
+
+// DecodeMinOut reads the threshold back from the swap instruction: the exact
+// base_amount_out of a buy or the min_quote_amount_out of a sell
+func (s *PumpAMMPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	if inputMint == s.QuoteMint.String() {
+		return pkg.DecodeInstructionU64(instructions, PumpSwapProgramID, anchor.GetDiscriminator("global", "buy"), 8)
+	}
+	return pkg.DecodeInstructionU64(instructions, PumpSwapProgramID, anchor.GetDiscriminator("global", "sell"), 16)
+}
+
+// SwapAmountFields locates the amounts of buy and sell. A buy is exact-out:
+// max_quote_amount_in bounds the input and base_amount_out is the output
+func (s *PumpAMMPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	if inputMint == s.QuoteMint.String() {
+		buy := anchor.GetDiscriminator("global", "buy")
+		return pkg.AmountField{ProgramID: PumpSwapProgramID, Prefix: buy, Offset: 16},
+			pkg.AmountField{ProgramID: PumpSwapProgramID, Prefix: buy, Offset: 8}
+	}
+	sell := anchor.GetDiscriminator("global", "sell")
+	return pkg.AmountField{ProgramID: PumpSwapProgramID, Prefix: sell, Offset: 8},
+		pkg.AmountField{ProgramID: PumpSwapProgramID, Prefix: sell, Offset: 16}
+}
+
+// QuoteInForBaseOut returns the quote a buy of exactly baseOut costs at the
+// cached reserves: the curve input rounded up, plus the fees the program
+// charges on top of it, each rounded up
+func (s *PumpAMMPool) QuoteInForBaseOut(baseOut math.Int) (math.Int, error) {
+	if s.BaseAmount.IsNil() || s.QuoteAmount.IsNil() {
+		return math.ZeroInt(), fmt.Errorf("pool reserves not loaded")
+	}
+	if !baseOut.IsPositive() {
+		return math.ZeroInt(), nil
+	}
+	if baseOut.GTE(s.BaseAmount) {
+		return math.ZeroInt(), fmt.Errorf("base out %s exceeds pool reserve %s", baseOut, s.BaseAmount)
+	}
+	remaining := s.BaseAmount.Sub(baseOut)
+	curveIn := s.QuoteAmount.Mul(baseOut).Add(remaining).SubRaw(1).Quo(remaining)
+	return curveIn.Add(s.swapFee(curveIn)), nil
+}
+
+// SwapFee returns the fees charged on inputAmount. Sells pay the fees from the
+// quote amount out, so they are expressed at the input's value
+func (s *PumpAMMPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	if inputMint == s.BaseMint.String() {
+		return s.swapFee(inputAmount)
+	}
+	return s.swapFee(s.buyCurveIn(inputAmount))
+}
+
+// Reserves returns the base and quote vault balances cached by the last Quote
+func (s *PumpAMMPool) Reserves() (math.Int, math.Int) {
+	return s.BaseAmount, s.QuoteAmount
+}
+
+// RawSpotPrice is the vault balance ratio cached by the last Quote
+func (s *PumpAMMPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	baseMint, _ := s.GetTokens()
+	return pkg.PriceFromReserves(s.BaseAmount, s.QuoteAmount, inputMint == baseMint)
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price
+func (s *PumpAMMPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if s.BaseAmount.IsNil() || s.QuoteAmount.IsNil() {
+		return math.ZeroInt(), fmt.Errorf("pool reserves not loaded")
+	}
+	// the curve price is reserveOut / (reserveIn + a), within b of spot while a <= reserveIn * b / (1 - b)
+	bps := math.NewInt(int64(maxImpactBps))
+	if inputMint == s.BaseMint.String() {
+		return s.BaseAmount.Mul(bps).Quo(math.NewInt(10000).Sub(bps)), nil
+	}
+	// buys pay the fee on top of the quote that reaches the curve
+	curveIn := s.QuoteAmount.Mul(bps).Quo(math.NewInt(10000).Sub(bps))
+	return curveIn.Add(s.swapFee(curveIn)), nil
 }
 
 func (s *PumpAMMPool) buyInAMMPool(
@@ -251,7 +365,7 @@ func (s *PumpAMMPool) sellInAMMPool(
 		if err != nil {
 			return nil, fmt.Errorf("failed to get coin creator vault ata: %w", err)
 		}
-		inst.AccountMetaSlice[17] = solana.NewAccountMeta(ata, false, false)
+		inst.AccountMetaSlice[17] = solana.NewAccountMeta(ata, true, false)
 		authority, err := GetCoinCreatorVaultAuthority(pool.CoinCreator)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get coin creator vault authority: %w", err)
@@ -347,47 +461,94 @@ func (pool *PumpAMMPool) Quote(ctx context.Context, solClient *sol.Client, input
 	accounts := make([]solana.PublicKey, 0)
 	accounts = append(accounts, pool.PoolBaseTokenAccount)
 	accounts = append(accounts, pool.PoolQuoteTokenAccount)
+	accounts = append(accounts, PumpGlobalConfig)
 	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
 	if err != nil {
 		return math.NewInt(0), fmt.Errorf("batch request failed: %v", err)
 	}
-	for i, result := range results.Value {
-		if result == nil {
-			return math.NewInt(0), fmt.Errorf("result is nil, account: %v", accounts[i].String())
+	// a vault missing at the queried commitment keeps its last known balance
+	if amount, ok := sol.TokenAccountAmount(results, 0); ok {
+		pool.BaseAmount = math.NewIntFromUint64(amount)
+	} else if pool.BaseAmount.IsNil() {
+		return math.NewInt(0), fmt.Errorf("vault account missing: %v", pool.PoolBaseTokenAccount.String())
+	} else {
+		log.Printf("vault account %v missing, using cached balance", pool.PoolBaseTokenAccount.String())
+	}
+	if amount, ok := sol.TokenAccountAmount(results, 1); ok {
+		pool.QuoteAmount = math.NewIntFromUint64(amount)
+	} else if pool.QuoteAmount.IsNil() {
+		return math.NewInt(0), fmt.Errorf("vault account missing: %v", pool.PoolQuoteTokenAccount.String())
+	} else {
+		log.Printf("vault account %v missing, using cached balance", pool.PoolQuoteTokenAccount.String())
+	}
+	if data, ok := sol.AccountData(results, 2); ok {
+		config, err := ParseGlobalConfig(data)
+		if err != nil {
+			return math.NewInt(0), fmt.Errorf("failed to parse global config: %w", err)
 		}
-		accountKey := accounts[i].String()
-		if pool.PoolBaseTokenAccount.String() == accountKey {
-			amountBytes := result.Data.GetBinary()[64:72]
-			amountUint := binary.LittleEndian.Uint64(amountBytes)
-			amount := math.NewIntFromUint64(amountUint)
-			pool.BaseAmount = amount
-		} else {
-			amountBytes := result.Data.GetBinary()[64:72]
-			amountUint := binary.LittleEndian.Uint64(amountBytes)
-			amount := math.NewIntFromUint64(amountUint)
-			pool.QuoteAmount = amount
+		pool.Config = config
+	} else if pool.Config == nil {
+		return math.NewInt(0), fmt.Errorf("global config account missing: %v", PumpGlobalConfig.String())
+	} else {
+		log.Printf("global config account %v missing, using cached fees", PumpGlobalConfig.String())
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount), nil
+}
+
+// ComputeAmountOut calculates the output for inputAmount from the cached
+// reserves with the program's integer math: sells take the rounded-up fees
+// from the quote out, buys return the largest base amount whose exact-out cost
+// fits in inputAmount
+func (pool *PumpAMMPool) ComputeAmountOut(inputMint string, inputAmount math.Int) math.Int {
+	if inputMint == pool.BaseMint.String() {
+		quoteOut := pool.QuoteAmount.Mul(inputAmount).Quo(pool.BaseAmount.Add(inputAmount))
+		amountOut := quoteOut.Sub(pool.swapFee(quoteOut))
+		if amountOut.IsNegative() {
+			return math.ZeroInt()
 		}
+		return amountOut
 	}
+	// the base out of the largest curve input is the largest whose rounded-up
+	// curve input, and so its exact-out cost, still fits
+	curveIn := pool.buyCurveIn(inputAmount)
+	return pool.BaseAmount.Mul(curveIn).Quo(pool.QuoteAmount.Add(curveIn))
+}
 
-	feeRate := 1 - DefaultFeeRate
-	feeMultiplier := math.NewInt(int64(feeRate * float64(BaseDecimalInt)))
+// fees returns the global config read by the last Quote, or the defaults
+func (pool *PumpAMMPool) fees() GlobalConfig {
+	if pool.Config == nil {
+		return DefaultGlobalConfig
+	}
+	return *pool.Config
+}
 
-	// Calculate k = baseAmount * quoteAmount
-	k := pool.BaseAmount.Mul(pool.QuoteAmount)
+// swapFee returns the fees the program charges on a quote amount, each
+// rounded up separately
+func (pool *PumpAMMPool) swapFee(quoteAmount math.Int) math.Int {
+	fee := math.ZeroInt()
+	for _, bps := range pool.fees().feeBps(pool.CoinCreator) {
+		fee = fee.Add(ceilFee(quoteAmount, bps))
+	}
+	return fee
+}
 
-	if inputMint == pool.BaseMint.String() {
-		// Calculate newBase = baseAmount + amountWithFee
-		newBase := pool.BaseAmount.Add(inputAmount.Mul(feeMultiplier).Quo(BaseDecimal))
-		// Calculate newQuote = k / newBase
-		newQuote := k.Quo(newBase)
-		priceBaseToQuote := pool.QuoteAmount.Sub(newQuote)
-		return priceBaseToQuote, nil
-	} else {
-		// Calculate newQuote = quoteAmount + amountWithFee
-		newQuote := pool.QuoteAmount.Add(inputAmount.Mul(feeMultiplier).Quo(BaseDecimal))
-		// Calculate newBase = k / newQuote
-		newBase := k.Quo(newQuote)
-		priceQuoteToBase := pool.BaseAmount.Sub(newBase)
-		return priceQuoteToBase, nil
+// buyCurveIn returns the largest quote amount that reaches the curve on a buy
+// whose fees, charged on top of it, fit in quoteAmount
+func (pool *PumpAMMPool) buyCurveIn(quoteAmount math.Int) math.Int {
+	if !quoteAmount.IsPositive() {
+		return math.ZeroInt()
 	}
+	total := pool.fees().TotalFeeBps(pool.CoinCreator)
+	// the fee-inclusive ratio bounds the curve input from above, the separate
+	// rounding of each fee lowers it by at most one unit per fee
+	curveIn := quoteAmount.Mul(math.NewIntFromUint64(FeeDenominator)).Quo(math.NewIntFromUint64(FeeDenominator + total))
+	for curveIn.IsPositive() && curveIn.Add(pool.swapFee(curveIn)).GT(quoteAmount) {
+		curveIn = curveIn.SubRaw(1)
+	}
+	return curveIn
+}
+
+// ceilFee returns bps of amount rounded up, as the program charges fees
+func ceilFee(amount math.Int, bps uint64) math.Int {
+	return amount.Mul(math.NewIntFromUint64(bps)).AddRaw(FeeDenominator - 1).QuoRaw(FeeDenominator)
 }
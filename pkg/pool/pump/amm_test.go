@@ -0,0 +1,92 @@
+package pump
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestParseGlobalConfig(t *testing.T) {
+	data := make([]byte, 353)
+	binary.LittleEndian.PutUint64(data[lpFeeOffset:], 20)
+	binary.LittleEndian.PutUint64(data[protocolFeeOffset:], 5)
+	binary.LittleEndian.PutUint64(data[coinCreatorFeeOffset:], 5)
+	config, err := ParseGlobalConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *config != (GlobalConfig{LpFeeBps: 20, ProtocolFeeBps: 5, CoinCreatorFeeBps: 5}) {
+		t.Fatalf("parsed %+v", *config)
+	}
+	if _, err := ParseGlobalConfig(data[:GlobalConfigSize-1]); err == nil {
+		t.Fatal("a truncated global config parsed")
+	}
+}
+
+// TestBuyFitsExactOutCost checks a buy returns the largest base amount whose
+// exact-out cost, with every fee rounded up on its own, fits in the input
+func TestBuyFitsExactOutCost(t *testing.T) {
+	config := &GlobalConfig{LpFeeBps: 20, ProtocolFeeBps: 5, CoinCreatorFeeBps: 5}
+	tests := []struct {
+		name        string
+		coinCreator solana.PublicKey
+		config      *GlobalConfig
+	}{
+		{"default fees", solana.PublicKey{}, nil},
+		{"no coin creator", solana.PublicKey{}, config},
+		{"coin creator", solana.NewWallet().PublicKey(), config},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &PumpAMMPool{
+				BaseMint:    solana.NewWallet().PublicKey(),
+				QuoteMint:   solana.NewWallet().PublicKey(),
+				CoinCreator: tt.coinCreator,
+				BaseAmount:  math.NewInt(206_900_000_000_000),
+				QuoteAmount: math.NewInt(84_990_000_000),
+				Config:      tt.config,
+			}
+			for _, amount := range []int64{1, 401, 4_001, 1_000_000, 1_000_000_007, 12_345_678_901} {
+				quoteIn := math.NewInt(amount)
+				baseOut := pool.ComputeAmountOut(pool.QuoteMint.String(), quoteIn)
+				if baseOut.IsPositive() {
+					cost, err := pool.QuoteInForBaseOut(baseOut)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if cost.GT(quoteIn) {
+						t.Fatalf("buying %s costs %s, more than the %s in", baseOut, cost, quoteIn)
+					}
+				}
+				cost, err := pool.QuoteInForBaseOut(baseOut.AddRaw(1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cost.LTE(quoteIn) {
+					t.Fatalf("%s in buys %s, but one more costs only %s", quoteIn, baseOut, cost)
+				}
+			}
+		})
+	}
+}
+
+func TestSellChargesCoinCreatorFee(t *testing.T) {
+	pool := &PumpAMMPool{
+		BaseMint:    solana.NewWallet().PublicKey(),
+		QuoteMint:   solana.NewWallet().PublicKey(),
+		BaseAmount:  math.NewInt(1_000_000),
+		QuoteAmount: math.NewInt(1_000_000),
+		Config:      &GlobalConfig{LpFeeBps: 20, ProtocolFeeBps: 5, CoinCreatorFeeBps: 5},
+	}
+	// 999,999 quote out of the curve less fees of 2,000 and 500, then 500 more
+	inputAmount := math.NewInt(1_000_000_000_000)
+	if out := pool.ComputeAmountOut(pool.BaseMint.String(), inputAmount); !out.Equal(math.NewInt(997_499)) {
+		t.Fatalf("sell without a coin creator returned %s, want 997499", out)
+	}
+	pool.CoinCreator = solana.NewWallet().PublicKey()
+	if out := pool.ComputeAmountOut(pool.BaseMint.String(), inputAmount); !out.Equal(math.NewInt(996_999)) {
+		t.Fatalf("sell with a coin creator returned %s, want 996999", out)
+	}
+}
@@ -0,0 +1,61 @@
+package pump
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// GlobalConfigSize is the minimum size of the global config account data
+	GlobalConfigSize = 321
+
+	lpFeeOffset          = 40
+	protocolFeeOffset    = 48
+	coinCreatorFeeOffset = 313
+)
+
+// GlobalConfig holds the swap fees the PumpSwap global config account sets
+// for every pool
+type GlobalConfig struct {
+	LpFeeBps          uint64
+	ProtocolFeeBps    uint64
+	CoinCreatorFeeBps uint64
+}
+
+// DefaultGlobalConfig is the fee schedule used until the global config is read
+var DefaultGlobalConfig = GlobalConfig{
+	LpFeeBps:       LpFeeBps,
+	ProtocolFeeBps: ProtocolFeeBps,
+}
+
+// ParseGlobalConfig parses the fee fields of the global config account
+func ParseGlobalConfig(data []byte) (*GlobalConfig, error) {
+	if len(data) < GlobalConfigSize {
+		return nil, fmt.Errorf("data too short: expected %d bytes, got %d", GlobalConfigSize, len(data))
+	}
+	return &GlobalConfig{
+		LpFeeBps:          binary.LittleEndian.Uint64(data[lpFeeOffset : lpFeeOffset+8]),
+		ProtocolFeeBps:    binary.LittleEndian.Uint64(data[protocolFeeOffset : protocolFeeOffset+8]),
+		CoinCreatorFeeBps: binary.LittleEndian.Uint64(data[coinCreatorFeeOffset : coinCreatorFeeOffset+8]),
+	}, nil
+}
+
+// feeBps returns the fees charged on a pool's swaps in the order the program
+// adds them. The coin creator fee only applies to pools with a coin creator
+func (c GlobalConfig) feeBps(coinCreator solana.PublicKey) []uint64 {
+	if coinCreator.IsZero() {
+		return []uint64{c.LpFeeBps, c.ProtocolFeeBps}
+	}
+	return []uint64{c.LpFeeBps, c.ProtocolFeeBps, c.CoinCreatorFeeBps}
+}
+
+// TotalFeeBps is the combined fee charged on a pool's swaps
+func (c GlobalConfig) TotalFeeBps(coinCreator solana.PublicKey) uint64 {
+	total := uint64(0)
+	for _, bps := range c.feeBps(coinCreator) {
+		total += bps
+	}
+	return total
+}
@@ -0,0 +1,150 @@
+package pump
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+var (
+	CreatePoolDiscriminator = []byte{233, 146, 209, 142, 207, 104, 64, 188}
+
+	// Token2022ProgramID owns Pump AMM LP mints
+	Token2022ProgramID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+	// PumpEventAuthority is the PDA Pump AMM emits its CPI events through
+	PumpEventAuthority = solana.MustPublicKeyFromBase58("GS4CU59F31iL7aR2Q8zVS8DRrcRnXX1yjQ66TqNVQnaR")
+)
+
+// PoolAddresses are the accounts a Pump AMM pool is created with
+type PoolAddresses struct {
+	Pool                  solana.PublicKey
+	LPMint                solana.PublicKey
+	PoolBaseTokenAccount  solana.PublicKey
+	PoolQuoteTokenAccount solana.PublicKey
+}
+
+// DerivePoolAddresses derives the pool a creator opens for a base/quote pair
+// at index, the same creator may open several pools on one pair
+func DerivePoolAddresses(index uint16, creator, baseMint, quoteMint, baseTokenProgram, quoteTokenProgram solana.PublicKey) (PoolAddresses, error) {
+	indexBytes := make([]byte, 2)
+	binary.LittleEndian.PutUint16(indexBytes, index)
+	pool, _, err := sol.FindProgramAddress([][]byte{
+		[]byte("pool"), indexBytes, creator[:], baseMint[:], quoteMint[:],
+	}, PumpSwapProgramID)
+	if err != nil {
+		return PoolAddresses{}, fmt.Errorf("failed to derive pool PDA: %w", err)
+	}
+	lpMint, _, err := sol.FindProgramAddress([][]byte{[]byte("pool_lp_mint"), pool[:]}, PumpSwapProgramID)
+	if err != nil {
+		return PoolAddresses{}, fmt.Errorf("failed to derive lp mint PDA: %w", err)
+	}
+	poolBase, _, err := sol.FindAssociatedTokenAddressWithProgram(pool, baseMint, baseTokenProgram)
+	if err != nil {
+		return PoolAddresses{}, err
+	}
+	poolQuote, _, err := sol.FindAssociatedTokenAddressWithProgram(pool, quoteMint, quoteTokenProgram)
+	if err != nil {
+		return PoolAddresses{}, err
+	}
+	return PoolAddresses{
+		Pool:                  pool,
+		LPMint:                lpMint,
+		PoolBaseTokenAccount:  poolBase,
+		PoolQuoteTokenAccount: poolQuote,
+	}, nil
+}
+
+// CreatePoolParams describes a new Pump AMM pool and the liquidity it is seeded with
+type CreatePoolParams struct {
+	Creator solana.PublicKey
+	Index   uint16
+
+	BaseMint  solana.PublicKey
+	QuoteMint solana.PublicKey
+	// BaseTokenProgram and QuoteTokenProgram default to the SPL Token program
+	BaseTokenProgram  solana.PublicKey
+	QuoteTokenProgram solana.PublicKey
+
+	BaseAmountIn  uint64
+	QuoteAmountIn uint64
+	// CreatorBaseAccount and CreatorQuoteAccount hold the seed liquidity and
+	// default to the creator's associated token accounts
+	CreatorBaseAccount  solana.PublicKey
+	CreatorQuoteAccount solana.PublicKey
+
+	// CoinCreator earns the creator fee on swaps; zero disables creator fees
+	CoinCreator solana.PublicKey
+}
+
+// NewCreatePoolInstruction builds the instruction that creates a Pump AMM pool
+// and deposits its initial liquidity, minting the LP tokens to the creator
+func NewCreatePoolInstruction(params CreatePoolParams) (solana.Instruction, PoolAddresses, error) {
+	if params.BaseMint.Equals(params.QuoteMint) {
+		return nil, PoolAddresses{}, fmt.Errorf("pool mints must differ")
+	}
+	if params.BaseAmountIn == 0 || params.QuoteAmountIn == 0 {
+		return nil, PoolAddresses{}, fmt.Errorf("initial liquidity must be positive")
+	}
+	baseProgram, quoteProgram := params.BaseTokenProgram, params.QuoteTokenProgram
+	if baseProgram.IsZero() {
+		baseProgram = solana.TokenProgramID
+	}
+	if quoteProgram.IsZero() {
+		quoteProgram = solana.TokenProgramID
+	}
+
+	addresses, err := DerivePoolAddresses(params.Index, params.Creator, params.BaseMint, params.QuoteMint, baseProgram, quoteProgram)
+	if err != nil {
+		return nil, PoolAddresses{}, err
+	}
+	creatorBase := params.CreatorBaseAccount
+	if creatorBase.IsZero() {
+		if creatorBase, _, err = sol.FindAssociatedTokenAddressWithProgram(params.Creator, params.BaseMint, baseProgram); err != nil {
+			return nil, PoolAddresses{}, err
+		}
+	}
+	creatorQuote := params.CreatorQuoteAccount
+	if creatorQuote.IsZero() {
+		if creatorQuote, _, err = sol.FindAssociatedTokenAddressWithProgram(params.Creator, params.QuoteMint, quoteProgram); err != nil {
+			return nil, PoolAddresses{}, err
+		}
+	}
+	creatorLP, _, err := sol.FindAssociatedTokenAddressWithProgram(params.Creator, addresses.LPMint, Token2022ProgramID)
+	if err != nil {
+		return nil, PoolAddresses{}, err
+	}
+
+	data := make([]byte, 58)
+	copy(data[0:8], CreatePoolDiscriminator)
+	binary.LittleEndian.PutUint16(data[8:10], params.Index)
+	binary.LittleEndian.PutUint64(data[10:18], params.BaseAmountIn)
+	binary.LittleEndian.PutUint64(data[18:26], params.QuoteAmountIn)
+	copy(data[26:58], params.CoinCreator[:])
+
+	return solana.NewInstruction(
+		PumpSwapProgramID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(addresses.Pool, true, false),
+			solana.NewAccountMeta(PumpGlobalConfig, false, false),
+			solana.NewAccountMeta(params.Creator, true, true),
+			solana.NewAccountMeta(params.BaseMint, false, false),
+			solana.NewAccountMeta(params.QuoteMint, false, false),
+			solana.NewAccountMeta(addresses.LPMint, true, false),
+			solana.NewAccountMeta(creatorBase, true, false),
+			solana.NewAccountMeta(creatorQuote, true, false),
+			solana.NewAccountMeta(creatorLP, true, false),
+			solana.NewAccountMeta(addresses.PoolBaseTokenAccount, true, false),
+			solana.NewAccountMeta(addresses.PoolQuoteTokenAccount, true, false),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+			solana.NewAccountMeta(Token2022ProgramID, false, false),
+			solana.NewAccountMeta(baseProgram, false, false),
+			solana.NewAccountMeta(quoteProgram, false, false),
+			solana.NewAccountMeta(solana.SPLAssociatedTokenAccountProgramID, false, false),
+			solana.NewAccountMeta(PumpEventAuthority, false, false),
+			solana.NewAccountMeta(PumpSwapProgramID, false, false),
+		},
+		data,
+	), addresses, nil
+}
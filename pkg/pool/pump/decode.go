@@ -0,0 +1,49 @@
+package pump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+)
+
+// DecodeSwap parses a PumpSwap buy or sell instruction. A buy receives the
+// exact base_amount_out for at most max_quote_amount_in, a sell spends
+// base_amount_in for at least min_quote_amount_out
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	buy := bytes.HasPrefix(data, anchor.GetDiscriminator("global", "buy"))
+	if !buy && !bytes.HasPrefix(data, anchor.GetDiscriminator("global", "sell")) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 17); err != nil {
+		return nil, err
+	}
+
+	first := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16]))
+	second := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24]))
+	params := &pkg.SwapParams{
+		Protocol: pkg.ProtocolNamePumpAmm,
+		Pool:     accounts[0].PublicKey,
+		User:     accounts[1].PublicKey,
+	}
+	baseMint, quoteMint := accounts[3].PublicKey, accounts[4].PublicKey
+	userBase, userQuote := accounts[5].PublicKey, accounts[6].PublicKey
+	if buy {
+		params.InputMint, params.OutputMint = quoteMint, baseMint
+		params.UserInputAccount, params.UserOutputAccount = userQuote, userBase
+		params.AmountIn, params.MinAmountOut = second, first
+		params.ExactOutput = true
+	} else {
+		params.InputMint, params.OutputMint = baseMint, quoteMint
+		params.UserInputAccount, params.UserOutputAccount = userBase, userQuote
+		params.AmountIn, params.MinAmountOut = first, second
+	}
+	return params, nil
+}
@@ -23,7 +23,7 @@ func GetCoinCreatorVaultAuthority(coinCreator solana.PublicKey) (solana.PublicKe
 		coinCreator.Bytes(),
 	}
 
-	pda, _, err := solana.FindProgramAddress(seeds, PumpSwapProgramID)
+	pda, _, err := sol.FindProgramAddress(seeds, PumpSwapProgramID)
 	if err != nil {
 		return solana.PublicKey{}, fmt.Errorf("failed to find program address: %w", err)
 	}
@@ -42,7 +42,7 @@ func GetCoinCreatorVaultATA(coinCreator solana.PublicKey) (solana.PublicKey, err
 		return solana.PublicKey{}, fmt.Errorf("failed to get vault authority: %w", err)
 	}
 
-	ata, _, err := solana.FindAssociatedTokenAddress(
+	ata, _, err := sol.FindAssociatedTokenAddress(
 		creatorVaultAuthority, // owner
 		sol.WSOL,              // mint
 	)
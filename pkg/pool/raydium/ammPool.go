@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"time"
 	"unsafe"
 
 	"cosmossdk.io/math"
@@ -323,6 +324,83 @@ func (p *AMMPool) GetTokens() (baseMint, quoteMint string) {
 	return p.BaseMint.String(), p.QuoteMint.String()
 }
 
+// OpensAt returns when the pool starts accepting swaps
+func (p *AMMPool) OpensAt() time.Time {
+	return openTime(p.PoolOpenTime)
+}
+
+// openTime converts an on-chain open time in unix seconds, zero for pools
+// open from the start
+func openTime(unix uint64) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(unix), 0)
+}
+
+// MintDecimals returns the decimals the pool records for mint
+func (p *AMMPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case p.BaseMint.String():
+		return uint8(p.BaseDecimal), true
+	case p.QuoteMint.String():
+		return uint8(p.QuoteDecimal), true
+	}
+	return 0, false
+}
+
+// SwapFee returns the trading fee charged on inputAmount
+func (p *AMMPool) SwapFee(inputMint string, inputAmount cosmath.Int) cosmath.Int {
+	return inputAmount.Mul(LIQUIDITY_FEES_NUMERATOR).Quo(LIQUIDITY_FEES_DENOMINATOR)
+}
+
+// Reserves returns the base and quote reserves cached by the last Quote, net
+// of pending PnL
+func (p *AMMPool) Reserves() (cosmath.Int, cosmath.Int) {
+	return p.BaseReserve, p.QuoteReserve
+}
+
+// RawSpotPrice is the reserve ratio cached by the last Quote
+func (p *AMMPool) RawSpotPrice(inputMint string) (cosmath.LegacyDec, error) {
+	baseMint, _ := p.GetTokens()
+	return pkg.PriceFromReserves(p.BaseReserve, p.QuoteReserve, inputMint == baseMint)
+}
+
+// EstimateAccounts returns the pool's vaults, whose balances EstimatePrice reads
+func (p *AMMPool) EstimateAccounts() []solana.PublicKey {
+	return []solana.PublicKey{p.BaseVault, p.QuoteVault}
+}
+
+// EstimatePrice is the vault ratio net of the pending PnL decoded with the
+// pool, without loading the OpenBook market
+func (p *AMMPool) EstimatePrice(inputMint string, balances []uint64) (cosmath.LegacyDec, error) {
+	if len(balances) != 2 {
+		return cosmath.LegacyDec{}, fmt.Errorf("expected 2 vault balances, got %d", len(balances))
+	}
+	base := cosmath.NewIntFromUint64(balances[0]).Sub(cosmath.NewIntFromUint64(p.BaseNeedTakePnl))
+	quote := cosmath.NewIntFromUint64(balances[1]).Sub(cosmath.NewIntFromUint64(p.QuoteNeedTakePnl))
+	if !base.IsPositive() || !quote.IsPositive() {
+		return cosmath.LegacyDec{}, fmt.Errorf("pool %s has an empty vault", p.PoolId)
+	}
+	return pkg.PriceFromReserves(base, quote, inputMint == p.BaseMint.String())
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price
+func (p *AMMPool) MaxInputForImpact(inputMint string, maxImpactBps int) (cosmath.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return cosmath.ZeroInt(), err
+	}
+	if p.BaseReserve.IsNil() || p.QuoteReserve.IsNil() {
+		return cosmath.ZeroInt(), fmt.Errorf("pool reserves not loaded")
+	}
+	reserveIn := p.BaseReserve
+	if inputMint == p.QuoteMint.String() {
+		reserveIn = p.QuoteReserve
+	}
+	return constantProductMaxInput(reserveIn, maxImpactBps), nil
+}
+
 // Quote calculates the expected output amount for a given input amount
 // It takes into account the current pool reserves and fees
 func (p *AMMPool) Quote(
@@ -339,28 +417,30 @@ func (p *AMMPool) Quote(
 	if err != nil {
 		return math.NewInt(0), fmt.Errorf("batch request failed: %v", err)
 	}
-	for i, result := range results.Value {
-		if result == nil {
-			return math.NewInt(0), fmt.Errorf("result is nil, account: %v", accounts[i].String())
-		}
-		accountKey := accounts[i].String()
-		if p.BaseVault.String() == accountKey {
-			amountBytes := result.Data.GetBinary()[64:72]
-			amountUint := binary.LittleEndian.Uint64(amountBytes)
-			amount := math.NewIntFromUint64(amountUint)
-			p.BaseAmount = amount
-		} else {
-			amountBytes := result.Data.GetBinary()[64:72]
-			amountUint := binary.LittleEndian.Uint64(amountBytes)
-			amount := math.NewIntFromUint64(amountUint)
-			p.QuoteAmount = amount
-		}
+	// a vault missing at the queried commitment keeps its last known balance
+	if amount, ok := sol.TokenAccountAmount(results, 0); ok {
+		p.BaseAmount = math.NewIntFromUint64(amount)
+	} else if p.BaseAmount.IsNil() {
+		return math.NewInt(0), fmt.Errorf("vault account missing: %v", p.BaseVault.String())
+	} else {
+		log.Printf("vault account %v missing, using cached balance", p.BaseVault.String())
+	}
+	if amount, ok := sol.TokenAccountAmount(results, 1); ok {
+		p.QuoteAmount = math.NewIntFromUint64(amount)
+	} else if p.QuoteAmount.IsNil() {
+		return math.NewInt(0), fmt.Errorf("vault account missing: %v", p.QuoteVault.String())
+	} else {
+		log.Printf("vault account %v missing, using cached balance", p.QuoteVault.String())
 	}
 
 	// Calculate effective reserves by subtracting pending PnL
 	p.BaseReserve = p.BaseAmount.Sub(cosmath.NewInt(int64(p.BaseNeedTakePnl)))
 	p.QuoteReserve = p.QuoteAmount.Sub(cosmath.NewInt(int64(p.QuoteNeedTakePnl)))
+	return p.ComputeAmountOut(inputMint, inputAmount), nil
+}
 
+// ComputeAmountOut calculates the output for inputAmount from the cached reserves
+func (p *AMMPool) ComputeAmountOut(inputMint string, inputAmount cosmath.Int) cosmath.Int {
 	// Set reserves and decimals based on swap direction
 	reserves := []cosmath.Int{p.BaseReserve, p.QuoteReserve}
 	mintDecimals := []int{int(p.BaseDecimal), int(p.QuoteDecimal)}
@@ -390,7 +470,7 @@ func (p *AMMPool) Quote(
 		denominator := reserveIn.Add(amountInWithFee)
 		amountOutRaw = reserveOut.Mul(amountInWithFee).Quo(denominator)
 	}
-	return amountOutRaw, nil
+	return amountOutRaw
 }
 
 // BuildSwapInstructions constructs the necessary instructions for executing a swap
@@ -460,6 +540,17 @@ func (pool *AMMPool) BuildSwapInstructions(
 	return instrs, nil
 }
 
+// DecodeMinOut reads minimum_amount_out back from the swap_base_in instruction
+func (pool *AMMPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (cosmath.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, RAYDIUM_AMM_PROGRAM_ID, []byte{9}, 9)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in swap_base_in
+func (pool *AMMPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: RAYDIUM_AMM_PROGRAM_ID, Prefix: []byte{9}, Offset: 1},
+		pkg.AmountField{ProgramID: RAYDIUM_AMM_PROGRAM_ID, Prefix: []byte{9}, Offset: 9}
+}
+
 type InSwapInstruction struct {
 	bin.BaseVariant
 	InAmount                uint64
@@ -9,12 +9,13 @@ import (
 	"log"
 	"math"
 	"math/big"
-	"strconv"
+	"time"
 
 	cosmath "cosmossdk.io/math"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/lru"
 	"github.com/solana-zh/solroute/pkg/sol"
 	"lukechampine.com/uint128"
 )
@@ -72,6 +73,23 @@ type CLMMPool struct {
 	ExBitmapAddress   solana.PublicKey
 	exTickArrayBitmap *TickArrayBitmapExtensionType
 	TickArrayCache    map[string]TickArray
+	// MaxTickArrays bounds TickArrayCache, evicting the least recently used
+	// arrays; zero means DefaultMaxTickArrays
+	MaxTickArrays int
+	// TickArrayDepth bounds the initialized tick arrays a quote loads ahead
+	// of the current tick, and a stream follows on each side; zero means
+	// DefaultTickArrayDepth. Quotes load only as many as their amount is
+	// estimated to cross, loading more up to the bound when that falls short
+	TickArrayDepth int
+	tickArrayUse   *lru.Tracker
+
+	// bitmapCache holds merged tick array bitmaps until the next refresh
+	bitmapCache *tickArrayBitmapCache
+	// mints holds the mint programs and transfer fees of the last quote
+	mints *clmmMints
+	// stream, when set, keeps the tick arrays current from account updates
+	stream       *pkg.StreamState
+	streamStarts map[solana.PublicKey]int64
 }
 
 type RewardInfo struct {
@@ -97,6 +115,8 @@ func (pool *CLMMPool) GetProgramID() solana.PublicKey {
 }
 
 func (l *CLMMPool) Decode(data []byte) error {
+	l.invalidateTickArrayBitmaps()
+
 	// Skip 8 bytes discriminator if present
 	if len(data) > 8 {
 		data = data[8:]
@@ -269,6 +289,8 @@ func (l *CLMMPool) Offset(field string) uint64 {
 	baseOffset := uint64(8)
 
 	switch field {
+	case "AmmConfig":
+		return baseOffset + 1 // bump
 	case "TokenMint0":
 		return baseOffset + 1 + 32 + 32 // bump + ammConfig + owner
 	case "TokenMint1":
@@ -284,6 +306,10 @@ func (l *CLMMPool) CurrentPrice() float64 {
 	return price
 }
 
+// BuildSwapInstructions builds a swap_v2 for pools with a Token-2022 mint and
+// the legacy swap for pools of two classic SPL Token mints. minOut is what the
+// user receives net of transfer fees, as Quote returns it, which is the
+// amount swap_v2 checks other_amount_threshold against
 func (p *CLMMPool) BuildSwapInstructions(
 	ctx context.Context,
 	solClient *sol.Client,
@@ -308,40 +334,40 @@ func (p *CLMMPool) BuildSwapInstructions(
 		inputValueMint = p.TokenMint1
 		outputValueMint = p.TokenMint0
 	}
+	if err := p.ensureMints(ctx, solClient); err != nil {
+		return nil, err
+	}
 
 	inst := RayCLMMSwapInstruction{
 		Amount:               amountIn.Uint64(),
 		OtherAmountThreshold: minOutAmountWithDecimals.Uint64(),
 		SqrtPriceLimitX64:    uint128.Zero,
 		IsBaseInput:          inputValueMint == p.TokenMint0,
-		AccountMetaSlice:     make(solana.AccountMetaSlice, 16),
+		Legacy:               !p.usesSwapV2(),
+		AccountMetaSlice:     make(solana.AccountMetaSlice, 0, 16),
 	}
 	inst.BaseVariant = bin.BaseVariant{
 		Impl: inst,
 	}
 
 	// Set up account metas in the correct order according to SDK
-	inst.AccountMetaSlice[0] = solana.NewAccountMeta(userAddr, false, true)
-	inst.AccountMetaSlice[1] = solana.NewAccountMeta(p.AmmConfig, false, false)
-	inst.AccountMetaSlice[2] = solana.NewAccountMeta(p.PoolId, true, false)
-
-	if inputMint == p.TokenMint0.String() {
-		inst.AccountMetaSlice[3] = solana.NewAccountMeta(userBaseAccount, true, false)
-		inst.AccountMetaSlice[4] = solana.NewAccountMeta(userQuoteAccount, true, false)
-		inst.AccountMetaSlice[5] = solana.NewAccountMeta(p.TokenVault0, true, false)
-		inst.AccountMetaSlice[6] = solana.NewAccountMeta(p.TokenVault1, true, false)
-	} else {
-		inst.AccountMetaSlice[3] = solana.NewAccountMeta(userQuoteAccount, true, false)
-		inst.AccountMetaSlice[4] = solana.NewAccountMeta(userBaseAccount, true, false)
-		inst.AccountMetaSlice[5] = solana.NewAccountMeta(p.TokenVault1, true, false)
-		inst.AccountMetaSlice[6] = solana.NewAccountMeta(p.TokenVault0, true, false)
-	}
-	inst.AccountMetaSlice[7] = solana.NewAccountMeta(p.ObservationKey, true, false)
-	inst.AccountMetaSlice[8] = solana.NewAccountMeta(solana.TokenProgramID, false, false)
-	inst.AccountMetaSlice[9] = solana.NewAccountMeta(TOKEN_2022_PROGRAM_ID, false, false)
-	inst.AccountMetaSlice[10] = solana.NewAccountMeta(MEMO_PROGRAM_ID, false, false)
-	inst.AccountMetaSlice[11] = solana.NewAccountMeta(inputValueMint, false, false)
-	inst.AccountMetaSlice[12] = solana.NewAccountMeta(outputValueMint, false, false)
+	userInput, userOutput := userBaseAccount, userQuoteAccount
+	inputVault, outputVault := p.TokenVault0, p.TokenVault1
+	if inputMint != p.TokenMint0.String() {
+		userInput, userOutput = userQuoteAccount, userBaseAccount
+		inputVault, outputVault = p.TokenVault1, p.TokenVault0
+	}
+	inst.AccountMetaSlice = append(inst.AccountMetaSlice,
+		solana.NewAccountMeta(userAddr, false, true),
+		solana.NewAccountMeta(p.AmmConfig, false, false),
+		solana.NewAccountMeta(p.PoolId, true, false),
+		solana.NewAccountMeta(userInput, true, false),
+		solana.NewAccountMeta(userOutput, true, false),
+		solana.NewAccountMeta(inputVault, true, false),
+		solana.NewAccountMeta(outputVault, true, false),
+		solana.NewAccountMeta(p.ObservationKey, true, false),
+		solana.NewAccountMeta(solana.TokenProgramID, false, false),
+	)
 
 	// Add bitmap extension as remaining account if it exists
 	exBitmapAddress, _, err := GetPdaExBitmapAccount(RAYDIUM_CLMM_PROGRAM_ID, p.PoolId)
@@ -349,7 +375,6 @@ func (p *CLMMPool) BuildSwapInstructions(
 		log.Printf("get pda address error: %v", err)
 		return nil, fmt.Errorf("get pda address error: %v", err)
 	}
-	inst.AccountMetaSlice[13] = solana.NewAccountMeta(exBitmapAddress, true, false) // exTickArrayBitmap (is_writable = true, is_signer = false)
 
 	// Add tick arrays as remaining accounts
 	remainingAccounts, err := p.GetRemainAccounts(ctx, solClient, inputValueMint.String())
@@ -357,8 +382,26 @@ func (p *CLMMPool) BuildSwapInstructions(
 		log.Printf("GetRemainAccounts error: %v", err)
 		return nil, err
 	}
-	inst.AccountMetaSlice[14] = solana.NewAccountMeta(remainingAccounts[0], true, false)
-	inst.AccountMetaSlice[15] = solana.NewAccountMeta(remainingAccounts[1], true, false)
+
+	if inst.Legacy {
+		// the legacy swap names the first tick array, then takes the bitmap
+		// extension and further tick arrays as remaining accounts
+		inst.AccountMetaSlice = append(inst.AccountMetaSlice,
+			solana.NewAccountMeta(remainingAccounts[0], true, false),
+			solana.NewAccountMeta(exBitmapAddress, true, false),
+			solana.NewAccountMeta(remainingAccounts[1], true, false),
+		)
+	} else {
+		inst.AccountMetaSlice = append(inst.AccountMetaSlice,
+			solana.NewAccountMeta(TOKEN_2022_PROGRAM_ID, false, false),
+			solana.NewAccountMeta(MEMO_PROGRAM_ID, false, false),
+			solana.NewAccountMeta(inputValueMint, false, false),
+			solana.NewAccountMeta(outputValueMint, false, false),
+			solana.NewAccountMeta(exBitmapAddress, true, false), // exTickArrayBitmap (is_writable = true, is_signer = false)
+			solana.NewAccountMeta(remainingAccounts[0], true, false),
+			solana.NewAccountMeta(remainingAccounts[1], true, false),
+		)
+	}
 
 	instrs = append(instrs, &inst)
 
@@ -373,6 +416,22 @@ type RayCLMMSwapInstruction struct {
 	SqrtPriceLimitX64       uint128.Uint128
 	IsBaseInput             bool
 	solana.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+	// Legacy encodes the swap instruction of classic SPL Token pools instead
+	// of swap_v2; both take the same arguments
+	Legacy bool `bin:"-" borsh_skip:"true"`
+}
+
+// DecodeMinOut reads other_amount_threshold back from the swap instruction
+func (p *CLMMPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (cosmath.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, RAYDIUM_CLMM_PROGRAM_ID, p.swapDiscriminator(), 16)
+}
+
+// SwapAmountFields locates amount and other_amount_threshold in the swap
+// instruction, laid out alike in swap_v2 and the legacy swap
+func (p *CLMMPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	discriminator := p.swapDiscriminator()
+	return pkg.AmountField{ProgramID: RAYDIUM_CLMM_PROGRAM_ID, Prefix: discriminator, Offset: 8},
+		pkg.AmountField{ProgramID: RAYDIUM_CLMM_PROGRAM_ID, Prefix: discriminator, Offset: 16}
 }
 
 // ProgramID returns the program ID for the Raydium CLMM program
@@ -390,7 +449,10 @@ func (inst *RayCLMMSwapInstruction) Data() ([]byte, error) {
 	buf := new(bytes.Buffer)
 
 	// Write discriminator for swap instruction
-	discriminator := []byte{43, 4, 237, 11, 26, 201, 30, 98} // anchorDataBuf.swap
+	discriminator := CLMMSwapDiscriminator
+	if inst.Legacy {
+		discriminator = CLMMLegacySwapDiscriminator
+	}
 	if _, err := buf.Write(discriminator); err != nil {
 		return nil, fmt.Errorf("failed to write discriminator: %w", err)
 	}
@@ -431,62 +493,133 @@ func (pool *CLMMPool) GetTokens() (baseMint, quoteMint string) {
 	return pool.TokenMint0.String(), pool.TokenMint1.String()
 }
 
+// OpensAt returns when the pool starts accepting swaps
+func (pool *CLMMPool) OpensAt() time.Time {
+	return openTime(pool.OpenTime)
+}
+
+// MintDecimals returns the decimals the pool records for mint
+func (pool *CLMMPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case pool.TokenMint0.String():
+		return pool.MintDecimals0, true
+	case pool.TokenMint1.String():
+		return pool.MintDecimals1, true
+	}
+	return 0, false
+}
+
+// Quote returns what the user receives for inputAmount, net of Token-2022
+// transfer fees on both the input and the output
 func (pool *CLMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount cosmath.Int) (cosmath.Int, error) {
-	// update pool state first
-	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.ExBitmapAddress})
+	// streamed pools hold their bitmap extension and tick arrays current
+	streamed := false
+	if s := pool.stream; s != nil {
+		s.Lock()
+		defer s.Unlock()
+		streamed = s.Ready()
+	}
+	accounts := pool.mintAccounts()
+	if !streamed {
+		accounts = append([]solana.PublicKey{pool.ExBitmapAddress}, accounts...)
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
 	if err != nil {
 		return cosmath.Int{}, fmt.Errorf("batch request failed: %v", err)
 	}
-	for _, result := range results.Value {
-		pool.ParseExBitmapInfo(result.Data.GetBinary())
+	if streamed {
+		pool.loadMints(results, 0)
+	} else {
+		// a missing bitmap extension keeps the previously parsed bitmap
+		if data, ok := sol.AccountData(results, 0); ok {
+			pool.ParseExBitmapInfo(data)
+		}
+		pool.loadMints(results, 1)
 	}
 
-	tickArrayAddresses, err := pool.GetTickArrayAddresses()
-	if err != nil {
-		return cosmath.Int{}, fmt.Errorf("get tick array address error: %v", err)
-	}
-	results, err = solClient.GetMultipleAccountsWithOpts(ctx, tickArrayAddresses)
-	if err != nil {
-		log.Printf("batch request failed: %v", err)
-		return cosmath.Int{}, fmt.Errorf("batch request failed: %v", err)
+	outputMint := pool.TokenMint1.String()
+	if inputMint != pool.TokenMint0.String() {
+		inputMint, outputMint = pool.TokenMint1.String(), pool.TokenMint0.String()
 	}
-	for _, result := range results.Value {
-		tickArray := &TickArray{}
-		err := tickArray.Decode(result.Data.GetBinary())
-		if err != nil {
-			return cosmath.Int{}, fmt.Errorf("failed to decode tick array: %w", err)
+	zeroForOne := inputMint == pool.TokenMint0.String()
+	// the input's transfer fee is withheld before it reaches the vault and
+	// the output's before it reaches the user
+	curveIn := inputAmount.Sub(pool.transferFee(inputMint, inputAmount))
+
+	var amountOut cosmath.Int
+	depth := pool.tickArrayDepth()
+	count, loaded := pool.estimateTickArrays(zeroForOne, curveIn), 0
+	for {
+		if !streamed {
+			starts := pool.tickArrayStartsAhead(zeroForOne, count)
+			if err := pool.loadTickArrays(ctx, solClient, starts[min(loaded, len(starts)):]); err != nil {
+				return cosmath.Int{}, err
+			}
+			loaded = len(starts)
 		}
-		if pool.TickArrayCache == nil {
-			pool.TickArrayCache = make(map[string]TickArray)
+		amountOut, err = pool.computeAmountOut(ctx, inputMint, curveIn)
+		if streamed || count >= depth || !errors.Is(err, errTickArrayNotLoaded) {
+			break
 		}
-		pool.TickArrayCache[strconv.FormatInt(int64(tickArray.StartTickIndex), 10)] = *tickArray
+		count = min(2*count, depth)
+	}
+	if err != nil {
+		return cosmath.Int{}, err
 	}
+	amountOut = amountOut.Neg()
+	return amountOut.Sub(pool.transferFee(outputMint, amountOut)), nil
+}
 
-	if inputMint == pool.TokenMint0.String() {
-		priceBaseToQuote, err := pool.ComputeAmountOutFormat(pool.TokenMint0.String(), inputAmount)
-		if err != nil {
-			return cosmath.Int{}, err
+// loadTickArrays fetches the tick arrays starting at starts into the cache
+func (pool *CLMMPool) loadTickArrays(ctx context.Context, solClient *sol.Client, starts []int64) error {
+	if len(starts) == 0 {
+		return nil
+	}
+	tickArrayAddresses := make([]solana.PublicKey, 0, len(starts))
+	for _, start := range starts {
+		tickArrayAddresses = append(tickArrayAddresses, getPdaTickArrayAddress(RAYDIUM_CLMM_PROGRAM_ID, pool.PoolId, start))
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, tickArrayAddresses)
+	if err != nil {
+		log.Printf("batch request failed: %v", err)
+		return fmt.Errorf("batch request failed: %v", err)
+	}
+	for i := range tickArrayAddresses {
+		// tick arrays that are not initialized (or not yet visible) are skipped
+		data, ok := sol.AccountData(results, i)
+		if !ok {
+			continue
 		}
-		return priceBaseToQuote.Neg(), nil
-	} else {
-		priceQuoteToBase, err := pool.ComputeAmountOutFormat(pool.TokenMint1.String(), inputAmount)
+		tickArray := &TickArray{}
+		err := tickArray.Decode(data)
 		if err != nil {
-			return cosmath.Int{}, err
+			return fmt.Errorf("failed to decode tick array: %w", err)
 		}
-		return priceQuoteToBase.Neg(), nil
+		pool.storeTickArray(*tickArray)
 	}
+	return nil
 }
 
 // ComputeAmountOutFormat calculates the expected output amount for a given input amount
 func (pool *CLMMPool) ComputeAmountOutFormat(inputTokenMint string, inputAmount cosmath.Int) (cosmath.Int, error) {
+	return pool.computeAmountOut(context.Background(), inputTokenMint, inputAmount)
+}
+
+// computeAmountOut is ComputeAmountOutFormat stopping at the next tick
+// crossing once ctx is done
+func (pool *CLMMPool) computeAmountOut(ctx context.Context, inputTokenMint string, inputAmount cosmath.Int) (cosmath.Int, error) {
 	zeroForOne := inputTokenMint == pool.TokenMint0.String()
 
-	firstTickArrayStartIndex, _, err := pool.getFirstInitializedTickArray(zeroForOne, pool.exTickArrayBitmap)
+	firstTickArrayStartIndex, firstTickArray, err := pool.getFirstInitializedTickArray(zeroForOne, pool.exTickArrayBitmap)
 	if err != nil {
 		return cosmath.Int{}, fmt.Errorf("failed to get first initialized tick array: %w", err)
 	}
+	if _, ok := pool.cachedTickArray(firstTickArrayStartIndex); !ok && !firstTickArray.IsZero() {
+		return cosmath.Int{}, fmt.Errorf("%w: %d", errTickArrayNotLoaded, firstTickArrayStartIndex)
+	}
 
 	expectedAmountOut, err := pool.swapCompute(
+		ctx,
 		int64(pool.TickCurrent),
 		zeroForOne,
 		inputAmount,
@@ -503,6 +636,7 @@ func (pool *CLMMPool) ComputeAmountOutFormat(inputTokenMint string, inputAmount
 
 // swapCompute performs the core swap calculation logic
 func (pool *CLMMPool) swapCompute(
+	ctx context.Context,
 	currentTick int64,
 	zeroForOne bool,
 	amountSpecified cosmath.Int,
@@ -541,7 +675,7 @@ func (pool *CLMMPool) swapCompute(
 	accounts := make([]*solana.PublicKey, 0)
 	liquidity := cosmath.NewIntFromBigInt(pool.Liquidity.Big())
 	tickAarrayStartIndex := lastSavedTickArrayStartIndex
-	tickArrayCurrent := pool.TickArrayCache[strconv.FormatInt(lastSavedTickArrayStartIndex, 10)]
+	tickArrayCurrent, _ := pool.cachedTickArray(lastSavedTickArrayStartIndex)
 
 	// Set price limits based on direction
 	if baseInput {
@@ -557,6 +691,9 @@ func (pool *CLMMPool) swapCompute(
 		if amountSpecifiedRemaining.IsZero() || sqrtPriceX64.Equal(sqrtPriceLimitX64) {
 			break
 		}
+		if err := ctx.Err(); err != nil {
+			return cosmath.Int{}, err
+		}
 
 		sqrtPriceStartX64 := sqrtPriceX64
 		tickState := getNextInitTick(&tickArrayCurrent, tick, int64(pool.TickSpacing), zeroForOne, t)
@@ -570,7 +707,7 @@ func (pool *CLMMPool) swapCompute(
 				exTickArrayBitmap,
 				tick,
 				int64(pool.TickSpacing),
-				pool.TickArrayBitmap,
+				pool.tickArrayBitmaps().defaultBitmap,
 				zeroForOne,
 			)
 			if err != nil {
@@ -584,7 +721,10 @@ func (pool *CLMMPool) swapCompute(
 			expectedNextTickArrayAddress := getPdaTickArrayAddress(RAYDIUM_CLMM_PROGRAM_ID, pool.PoolId, tickAarrayStartIndex)
 
 			tickArrayAddress = &expectedNextTickArrayAddress
-			tickArrayCurrent = pool.TickArrayCache[strconv.FormatInt(tickAarrayStartIndex, 10)]
+			var loaded bool
+			if tickArrayCurrent, loaded = pool.cachedTickArray(tickAarrayStartIndex); !loaded {
+				return cosmath.Int{}, fmt.Errorf("%w: %d", errTickArrayNotLoaded, tickAarrayStartIndex)
+			}
 			nextInitTick, err = firstInitializedTick(&tickArrayCurrent, zeroForOne)
 			if err != nil {
 				return cosmath.Int{}, fmt.Errorf("failed to get first initialized tick: %w", err)
@@ -692,13 +832,7 @@ func (pool *CLMMPool) GetRemainAccounts(
 	allNeededAccounts = append(allNeededAccounts, firstTickArray)
 
 	// Get next tick array
-	tickAarrayStartIndex, _ := nextInitializedTickArray(
-		int64(pool.TickCurrent),
-		int64(pool.TickSpacing),
-		zeroForOne,
-		pool.TickArrayBitmap,
-		pool.exTickArrayBitmap,
-	)
+	tickAarrayStartIndex, _ := pool.nextInitializedTickArray(int64(pool.TickCurrent), zeroForOne)
 
 	exTickArrayBitmapAddress := getPdaTickArrayAddress(RAYDIUM_CLMM_PROGRAM_ID, pool.PoolId, tickAarrayStartIndex)
 	allNeededAccounts = append(allNeededAccounts, exTickArrayBitmapAddress)
@@ -0,0 +1,143 @@
+package raydium
+
+import (
+	"math/big"
+
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// tickArrayBitmapCache holds the merged 512-bit bitmaps of a CLMM pool, ordered
+// from the most negative extension bitmap to the most positive one
+type tickArrayBitmapCache struct {
+	merged        []*big.Int
+	defaultBitmap *big.Int
+}
+
+// tickArrayBitmaps returns the pool's merged bitmaps, building them on first use
+// after a refresh of the pool state or the bitmap extension
+func (p *CLMMPool) tickArrayBitmaps() *tickArrayBitmapCache {
+	if p.bitmapCache != nil {
+		return p.bitmapCache
+	}
+
+	cache := &tickArrayBitmapCache{
+		merged:        mergeTickArrayBitmaps(p.TickArrayBitmap, p.exTickArrayBitmap),
+		defaultBitmap: MergeTickArrayBitmap(p.TickArrayBitmap[:]),
+	}
+	p.bitmapCache = cache
+	return cache
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the tick array cache, bitmap extension, mints and stream already loaded
+func (p *CLMMPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*CLMMPool)
+	if !ok || fresh == p || !fresh.PoolId.Equals(p.PoolId) {
+		return false
+	}
+
+	if p.stream != nil {
+		p.stream.Lock()
+		defer p.stream.Unlock()
+	}
+	tickArrayCache, tickArrayUse, exTickArrayBitmap := p.TickArrayCache, p.tickArrayUse, p.exTickArrayBitmap
+	maxTickArrays, mints := p.MaxTickArrays, p.mints
+	stream, streamStarts := p.stream, p.streamStarts
+	*p = *fresh
+	p.TickArrayCache, p.tickArrayUse, p.exTickArrayBitmap = tickArrayCache, tickArrayUse, exTickArrayBitmap
+	p.MaxTickArrays, p.mints = maxTickArrays, mints
+	p.stream, p.streamStarts = stream, streamStarts
+	p.invalidateTickArrayBitmaps()
+	return true
+}
+
+// invalidateTickArrayBitmaps drops the merged bitmaps so the next search rebuilds them
+func (p *CLMMPool) invalidateTickArrayBitmaps() {
+	p.bitmapCache = nil
+}
+
+// searchLowBitFromStart is SearchLowBitFromStart over the pool's cached bitmaps
+func (p *CLMMPool) searchLowBitFromStart(currentTickArrayBitStartIndex int64, expectedCount int64) []int64 {
+	return searchLowBit(p.tickArrayBitmaps().merged, currentTickArrayBitStartIndex, expectedCount, int64(p.TickSpacing))
+}
+
+// searchHighBitFromStart is SearchHighBitFromStart over the pool's cached bitmaps
+func (p *CLMMPool) searchHighBitFromStart(currentTickArrayBitStartIndex int64, expectedCount int64) []int64 {
+	return searchHighBit(p.tickArrayBitmaps().merged, currentTickArrayBitStartIndex, expectedCount, int64(p.TickSpacing))
+}
+
+// mergeTickArrayBitmaps merges the default bitmap and its extension into 512-bit words
+func mergeTickArrayBitmaps(tickArrayBitmap [16]uint64, exTickArrayBitmap *TickArrayBitmapExtensionType) []*big.Int {
+	negative, positive := extensionBitmaps(exTickArrayBitmap)
+
+	tickArrayBitmaps := make([]*big.Int, 0, len(negative)+2+len(positive))
+	for i := len(negative) - 1; i >= 0; i-- {
+		tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(negative[i]))
+	}
+	tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(tickArrayBitmap[0:8]))
+	tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(tickArrayBitmap[8:16]))
+	for _, bitmap := range positive {
+		tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(bitmap))
+	}
+	return tickArrayBitmaps
+}
+
+// extensionBitmaps returns the extension bitmaps, treating a missing extension as empty
+func extensionBitmaps(exTickArrayBitmap *TickArrayBitmapExtensionType) (negative, positive [][]uint64) {
+	if exTickArrayBitmap != nil {
+		return exTickArrayBitmap.NegativeTickArrayBitmap, exTickArrayBitmap.PositiveTickArrayBitmap
+	}
+	empty := make([][]uint64, EXTENSION_TICKARRAY_BITMAP_SIZE)
+	for i := range empty {
+		empty[i] = make([]uint64, 8)
+	}
+	return empty, empty
+}
+
+// searchLowBit walks merged bitmaps downwards from the start index
+func searchLowBit(tickArrayBitmaps []*big.Int, currentTickArrayBitStartIndex int64, expectedCount int64, tickSpacing int64) []int64 {
+	result := make([]int64, 0)
+	for currentTickArrayBitStartIndex >= -7680 {
+		arrayIndex := (currentTickArrayBitStartIndex + 7680) / 512
+		searchIndex := (currentTickArrayBitStartIndex + 7680) % 512
+
+		if tickArrayBitmaps[arrayIndex].Bit(int(searchIndex)) == 1 {
+			result = append(result, currentTickArrayBitStartIndex)
+		}
+
+		currentTickArrayBitStartIndex--
+		if len(result) == int(expectedCount) {
+			break
+		}
+	}
+	return scaleTickArrayOffsets(result, tickSpacing)
+}
+
+// searchHighBit walks merged bitmaps upwards from the start index
+func searchHighBit(tickArrayBitmaps []*big.Int, currentTickArrayBitStartIndex int64, expectedCount int64, tickSpacing int64) []int64 {
+	result := make([]int64, 0)
+	for currentTickArrayBitStartIndex < 7680 {
+		arrayIndex := (currentTickArrayBitStartIndex + 7680) / 512
+		searchIndex := (currentTickArrayBitStartIndex + 7680) % 512
+
+		if tickArrayBitmaps[arrayIndex].Bit(int(searchIndex)) == 1 {
+			result = append(result, currentTickArrayBitStartIndex)
+		}
+
+		currentTickArrayBitStartIndex++
+		if len(result) == int(expectedCount) {
+			break
+		}
+	}
+	return scaleTickArrayOffsets(result, tickSpacing)
+}
+
+// scaleTickArrayOffsets converts bitmap offsets into tick array start indexes
+func scaleTickArrayOffsets(offsets []int64, tickSpacing int64) []int64 {
+	tickCount := getTickCount(tickSpacing)
+	finalResult := make([]int64, len(offsets))
+	for i, val := range offsets {
+		finalResult[i] = val * tickCount
+	}
+	return finalResult
+}
@@ -0,0 +1,86 @@
+package raydium
+
+import (
+	"strconv"
+
+	"github.com/solana-zh/solroute/pkg/lru"
+)
+
+const (
+	// DefaultMaxTickArrays bounds the tick arrays a CLMM pool keeps across
+	// quotes when MaxTickArrays is not set
+	DefaultMaxTickArrays = 64
+
+	// DefaultTickArrayDepth bounds the initialized tick arrays a quote loads
+	// ahead of the current tick when TickArrayDepth is not set
+	DefaultTickArrayDepth = 10
+)
+
+// tickArrayDepth is the pool's bound on the tick arrays a quote loads ahead
+func (p *CLMMPool) tickArrayDepth() int {
+	if p.TickArrayDepth <= 0 {
+		return DefaultTickArrayDepth
+	}
+	return p.TickArrayDepth
+}
+
+// tickArrayLimit is the pool's tick array bound, never below what one quote
+// or stream loads
+func (p *CLMMPool) tickArrayLimit() int {
+	limit := p.MaxTickArrays
+	if limit <= 0 {
+		limit = DefaultMaxTickArrays
+	}
+	return max(limit, 2*p.tickArrayDepth())
+}
+
+// storeTickArray caches tickArray as the most recently used one, evicting the
+// least recently used arrays beyond the pool's limit
+func (p *CLMMPool) storeTickArray(tickArray TickArray) {
+	if p.TickArrayCache == nil {
+		p.TickArrayCache = make(map[string]TickArray)
+	}
+	if p.tickArrayUse == nil || p.tickArrayUse.Capacity() != p.tickArrayLimit() {
+		p.trackTickArrays()
+	}
+	key := strconv.FormatInt(int64(tickArray.StartTickIndex), 10)
+	p.TickArrayCache[key] = tickArray
+	for _, evicted := range p.tickArrayUse.Touch(key) {
+		delete(p.TickArrayCache, evicted)
+	}
+}
+
+// cachedTickArray returns the cached tick array starting at startIndex and
+// marks it as used; ok is false when it is not loaded
+func (p *CLMMPool) cachedTickArray(startIndex int64) (TickArray, bool) {
+	key := strconv.FormatInt(startIndex, 10)
+	tickArray, ok := p.TickArrayCache[key]
+	if ok && p.tickArrayUse != nil && p.tickArrayUse.Has(key) {
+		p.tickArrayUse.Touch(key)
+	}
+	return tickArray, ok
+}
+
+// trackTickArrays starts a tracker at the pool's current limit, adopting the
+// arrays already cached
+func (p *CLMMPool) trackTickArrays() {
+	p.tickArrayUse = lru.NewTracker(p.tickArrayLimit())
+	for key := range p.TickArrayCache {
+		for _, evicted := range p.tickArrayUse.Touch(key) {
+			delete(p.TickArrayCache, evicted)
+		}
+	}
+}
+
+// Reset drops the pool's cached tick arrays and bitmaps; the next quote
+// fetches what it needs again, streamed or not
+func (p *CLMMPool) Reset() {
+	if p.stream != nil {
+		p.stream.Lock()
+		defer p.stream.Unlock()
+		p.stream.MarkStale()
+	}
+	p.TickArrayCache = nil
+	p.tickArrayUse = nil
+	p.invalidateTickArrayBitmaps()
+}
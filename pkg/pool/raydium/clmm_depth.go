@@ -0,0 +1,53 @@
+package raydium
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	cosmath "cosmossdk.io/math"
+)
+
+// errTickArrayNotLoaded is returned by a swap walk reaching a tick array
+// that is initialized but not cached
+var errTickArrayNotLoaded = errors.New("tick array not loaded")
+
+// estimateTickArrays estimates how many initialized tick arrays a swap of
+// amountIn walks, assuming the current liquidity holds all the way, with one
+// to spare. The estimate is bounded by the pool's depth, which it returns
+// when the pool state gives no estimate
+func (p *CLMMPool) estimateTickArrays(zeroForOne bool, amountIn cosmath.Int) int {
+	depth := p.tickArrayDepth()
+	liquidity, _ := new(big.Float).SetInt(p.Liquidity.Big()).Float64()
+	sqrtPrice, _ := new(big.Float).Quo(new(big.Float).SetInt(p.SqrtPriceX64.Big()), new(big.Float).SetInt(q64)).Float64()
+	amount, _ := new(big.Float).SetInt(amountIn.BigInt()).Float64()
+	if liquidity <= 0 || sqrtPrice <= 0 || amount <= 0 || p.TickSpacing == 0 {
+		return depth
+	}
+
+	// token0 in raises 1/sqrt(P) by amount/L, token1 in raises sqrt(P)
+	next := sqrtPrice + amount/liquidity
+	if zeroForOne {
+		next = 1 / (1/sqrtPrice + amount/liquidity)
+	}
+	tick := 2 * math.Log(next) / math.Log(1.0001)
+	if math.IsNaN(tick) || math.IsInf(tick, 0) {
+		return depth
+	}
+	crossed := math.Abs(tick-float64(p.TickCurrent)) / float64(getTickCount(int64(p.TickSpacing)))
+	if crossed >= float64(depth) {
+		return depth
+	}
+	return min(int(crossed)+2, depth)
+}
+
+// tickArrayStartsAhead returns the start indexes of up to count initialized
+// tick arrays from the one holding the current tick in the swap direction
+func (p *CLMMPool) tickArrayStartsAhead(zeroForOne bool, count int) []int64 {
+	tickCount := getTickCount(int64(p.TickSpacing))
+	offset := int64(math.Floor(float64(getTickArrayStartIndexByTick(int64(p.TickCurrent), int64(p.TickSpacing))) / float64(tickCount)))
+	if zeroForOne {
+		return p.searchLowBitFromStart(offset, int64(count))
+	}
+	return p.searchHighBitFromStart(offset, int64(count))
+}
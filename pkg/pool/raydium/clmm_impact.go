@@ -0,0 +1,80 @@
+package raydium
+
+import (
+	"fmt"
+	"math/big"
+
+	cosmath "cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// maxImpactSearchSteps bounds both the doubling and the bisection phase
+const maxImpactSearchSteps = 128
+
+// RawSpotPrice is the pool price cached by the last Quote
+func (p *CLMMPool) RawSpotPrice(inputMint string) (cosmath.LegacyDec, error) {
+	return pkg.PriceFromSqrtX64(p.SqrtPriceX64.Big(), inputMint == p.TokenMint0.String())
+}
+
+// MaxInputForImpact searches the cached ticks for the largest input within
+// maxImpactBps of the spot price. Inputs that run past the loaded tick arrays
+// count as exceeding the bound
+func (p *CLMMPool) MaxInputForImpact(inputMint string, maxImpactBps int) (cosmath.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return cosmath.ZeroInt(), err
+	}
+	if p.SqrtPriceX64.IsZero() {
+		return cosmath.ZeroInt(), fmt.Errorf("pool price not loaded")
+	}
+	zeroForOne := inputMint == p.TokenMint0.String()
+
+	// spot output per unit of input after fees, scaled down by the bound
+	spot := new(big.Float).Mul(new(big.Float).SetInt(p.SqrtPriceX64.Big()), new(big.Float).SetInt(p.SqrtPriceX64.Big()))
+	spot.Quo(spot, new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 128)))
+	if !zeroForOne {
+		spot.Quo(big.NewFloat(1), spot)
+	}
+	feeRate := float64(p.FeeRate) / float64(FEE_RATE_DENOMINATOR.Int64())
+	spot.Mul(spot, big.NewFloat((1-feeRate)*(1-float64(maxImpactBps)/10000)))
+
+	within := func(amount cosmath.Int) (bool, error) {
+		out, err := p.ComputeAmountOutFormat(inputMint, amount)
+		if err != nil {
+			return false, err
+		}
+		floor := new(big.Float).Mul(new(big.Float).SetInt(amount.BigInt()), spot)
+		return new(big.Float).SetInt(out.Abs().BigInt()).Cmp(floor) >= 0, nil
+	}
+
+	// small inputs lose most of their output to rounding, so double until the
+	// bound is first met and then until it is exceeded
+	lo, hi := cosmath.ZeroInt(), cosmath.Int{}
+	found := false
+	amount := cosmath.OneInt()
+	for step := 0; step < maxImpactSearchSteps && hi.IsNil(); step++ {
+		ok, err := within(amount)
+		if err != nil && !found {
+			return cosmath.ZeroInt(), fmt.Errorf("failed to quote %s: %w", amount, err)
+		}
+		switch {
+		case ok:
+			lo, found = amount, true
+		case found:
+			hi = amount
+		}
+		amount = amount.MulRaw(2)
+	}
+	if hi.IsNil() {
+		return lo, nil
+	}
+
+	for step := 0; step < maxImpactSearchSteps && hi.Sub(lo).GT(cosmath.OneInt()); step++ {
+		mid := lo.Add(hi).QuoRaw(2)
+		if ok, _ := within(mid); ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
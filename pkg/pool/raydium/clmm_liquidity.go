@@ -0,0 +1,99 @@
+package raydium
+
+import (
+	"math"
+	"math/big"
+	"sort"
+)
+
+// TickLiquidity is the liquidity active between one initialized tick and the next
+type TickLiquidity struct {
+	TickLower int32
+	TickUpper int32
+	// PriceLower and PriceUpper are token1 per token0 in raw units
+	PriceLower float64
+	PriceUpper float64
+	Liquidity  *big.Int
+	// Amount0 and Amount1 are the token amounts the range holds at the current price
+	Amount0 float64
+	Amount1 float64
+	Active  bool
+}
+
+// LiquidityDistribution returns the liquidity ranges between the initialized
+// ticks of the cached tick arrays, ordered by tick. Tick arrays are loaded by
+// Quote or FetchPoolTickArrays
+func (p *CLMMPool) LiquidityDistribution() []TickLiquidity {
+	type tickNet struct {
+		tick int32
+		net  int64
+	}
+	ticks := make([]tickNet, 0)
+	for _, tickArray := range p.TickArrayCache {
+		for _, tick := range tickArray.Ticks {
+			if tick.LiquidityGross.IsZero() {
+				continue
+			}
+			ticks = append(ticks, tickNet{tick: tick.Tick, net: tick.LiquidityNet})
+		}
+	}
+	if len(ticks) < 2 {
+		return nil
+	}
+	sort.Slice(ticks, func(i, j int) bool {
+		return ticks[i].tick < ticks[j].tick
+	})
+
+	// liquidity[i] is active in [ticks[i], ticks[i+1]); start from the range
+	// holding the current tick and cross ticks outwards in both directions
+	liquidity := make([]*big.Int, len(ticks)-1)
+	current := sort.Search(len(ticks), func(i int) bool {
+		return ticks[i].tick > p.TickCurrent
+	}) - 1
+	active := p.Liquidity.Big()
+	if current >= 0 && current < len(liquidity) {
+		liquidity[current] = active
+	}
+	level := new(big.Int).Set(active)
+	for i := current + 1; i < len(liquidity); i++ {
+		level = new(big.Int).Add(level, big.NewInt(ticks[i].net))
+		liquidity[i] = level
+	}
+	level = new(big.Int).Set(active)
+	for i := current - 1; i >= 0; i-- {
+		level = new(big.Int).Sub(level, big.NewInt(ticks[i+1].net))
+		liquidity[i] = level
+	}
+
+	sqrtCurrent := math.Pow(1.0001, float64(p.TickCurrent)/2)
+	ladder := make([]TickLiquidity, 0, len(liquidity))
+	for i, l := range liquidity {
+		if l == nil || l.Sign() <= 0 {
+			continue
+		}
+		lower, upper := ticks[i].tick, ticks[i+1].tick
+		sqrtLower := math.Pow(1.0001, float64(lower)/2)
+		sqrtUpper := math.Pow(1.0001, float64(upper)/2)
+		liquidityFloat, _ := new(big.Float).SetInt(l).Float64()
+
+		entry := TickLiquidity{
+			TickLower:  lower,
+			TickUpper:  upper,
+			PriceLower: sqrtLower * sqrtLower,
+			PriceUpper: sqrtUpper * sqrtUpper,
+			Liquidity:  l,
+			Active:     i == current,
+		}
+		switch {
+		case p.TickCurrent < lower:
+			entry.Amount0 = liquidityFloat * (1/sqrtLower - 1/sqrtUpper)
+		case p.TickCurrent >= upper:
+			entry.Amount1 = liquidityFloat * (sqrtUpper - sqrtLower)
+		default:
+			entry.Amount0 = liquidityFloat * (1/sqrtCurrent - 1/sqrtUpper)
+			entry.Amount1 = liquidityFloat * (sqrtCurrent - sqrtLower)
+		}
+		ladder = append(ladder, entry)
+	}
+	return ladder
+}
@@ -0,0 +1,103 @@
+package raydium
+
+import (
+	"context"
+	"fmt"
+
+	cosmath "cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// clmmMints is what the pool knows of its mints: the token program owning
+// each, its Token-2022 transfer fee if any, and the epoch the fees apply in
+type clmmMints struct {
+	programs [2]solana.PublicKey
+	fees     [2]*sol.TransferFeeConfig
+	epoch    uint64
+}
+
+// mintAccounts are fetched along with the pool's other state on every quote:
+// both mints and the clock, whose epoch selects the transfer fee in force
+func (p *CLMMPool) mintAccounts() []solana.PublicKey {
+	return []solana.PublicKey{p.TokenMint0, p.TokenMint1, solana.SysVarClockPubkey}
+}
+
+// loadMints reads the accounts of mintAccounts from results starting at
+// offset. Accounts missing from the response keep what was loaded before
+func (p *CLMMPool) loadMints(results *rpc.GetMultipleAccountsResult, offset int) {
+	mints := &clmmMints{}
+	if p.mints != nil {
+		*mints = *p.mints
+	}
+	for i := 0; i < 2; i++ {
+		if offset+i >= len(results.Value) || results.Value[offset+i] == nil {
+			continue
+		}
+		account := results.Value[offset+i]
+		mints.programs[i] = account.Owner
+		mints.fees[i] = nil
+		if account.Owner.Equals(TOKEN_2022_PROGRAM_ID) {
+			if config, ok := sol.ParseTransferFeeConfig(account.Data.GetBinary()); ok {
+				mints.fees[i] = config
+			}
+		}
+	}
+	if data, ok := sol.AccountData(results, offset+2); ok {
+		if clock, err := sol.ParseClock(data); err == nil {
+			mints.epoch = clock.Epoch
+		}
+	}
+	p.mints = mints
+}
+
+// ensureMints loads the mints when no quote has yet
+func (p *CLMMPool) ensureMints(ctx context.Context, solClient *sol.Client) error {
+	if p.mints != nil {
+		return nil
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, p.mintAccounts())
+	if err != nil {
+		return fmt.Errorf("failed to fetch pool mints: %w", err)
+	}
+	p.loadMints(results, 0)
+	return nil
+}
+
+// usesSwapV2 reports whether swaps go through swap_v2, which handles
+// Token-2022 mints and their transfer fees. Pools of two classic SPL Token
+// mints use the legacy swap; pools whose mints are not loaded use swap_v2,
+// which accepts both
+func (p *CLMMPool) usesSwapV2() bool {
+	if p.mints == nil {
+		return true
+	}
+	for _, program := range p.mints.programs {
+		if !program.Equals(solana.TokenProgramID) {
+			return true
+		}
+	}
+	return false
+}
+
+// swapDiscriminator is the discriminator of the swap instruction the pool builds
+func (p *CLMMPool) swapDiscriminator() []byte {
+	if p.usesSwapV2() {
+		return CLMMSwapDiscriminator
+	}
+	return CLMMLegacySwapDiscriminator
+}
+
+// transferFee returns the Token-2022 fee on a transfer of amount of the pool's
+// mint, zero for mints without one
+func (p *CLMMPool) transferFee(mint string, amount cosmath.Int) cosmath.Int {
+	if p.mints == nil {
+		return cosmath.ZeroInt()
+	}
+	i := 0
+	if mint == p.TokenMint1.String() {
+		i = 1
+	}
+	return p.mints.fees[i].Fee(p.mints.epoch, amount)
+}
@@ -0,0 +1,136 @@
+package raydium
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	// ObservationNum is the size of the CLMM observation ring buffer
+	ObservationNum = 100
+
+	observationSize        = 4 + 8 + 32 // block_timestamp, tick_cumulative, padding
+	observationsOffset     = 8 + 1 + 8 + 2 + 32
+	observationStateSize   = observationsOffset + ObservationNum*observationSize
+	observationIndexOffset = 8 + 1 + 8
+)
+
+// Observation is one oracle sample of a CLMM pool
+type Observation struct {
+	BlockTimestamp uint32
+	TickCumulative int64
+}
+
+// ObservationState is the CLMM oracle account referenced by ObservationKey
+type ObservationState struct {
+	Initialized      bool
+	RecentEpoch      uint64
+	ObservationIndex uint16
+	PoolId           solana.PublicKey
+	Observations     [ObservationNum]Observation
+}
+
+// DecodeObservationState decodes a CLMM observation account
+func DecodeObservationState(data []byte) (*ObservationState, error) {
+	if len(data) < observationStateSize {
+		return nil, fmt.Errorf("invalid observation data length: %d", len(data))
+	}
+	state := &ObservationState{
+		Initialized:      data[8] != 0,
+		RecentEpoch:      binary.LittleEndian.Uint64(data[9:17]),
+		ObservationIndex: binary.LittleEndian.Uint16(data[observationIndexOffset : observationIndexOffset+2]),
+		PoolId:           solana.PublicKeyFromBytes(data[observationIndexOffset+2 : observationsOffset]),
+	}
+	for i := range state.Observations {
+		offset := observationsOffset + i*observationSize
+		state.Observations[i] = Observation{
+			BlockTimestamp: binary.LittleEndian.Uint32(data[offset : offset+4]),
+			TickCumulative: int64(binary.LittleEndian.Uint64(data[offset+4 : offset+12])),
+		}
+	}
+	return state, nil
+}
+
+// Latest returns the most recent observation
+func (s *ObservationState) Latest() Observation {
+	return s.Observations[int(s.ObservationIndex)%ObservationNum]
+}
+
+// TWAPTick returns the time weighted average tick over the window ending at
+// the latest observation. It fails if the ring buffer does not reach back far enough
+func (s *ObservationState) TWAPTick(window time.Duration) (float64, error) {
+	if !s.Initialized {
+		return 0, fmt.Errorf("observation state not initialized")
+	}
+	seconds := uint32(window / time.Second)
+	if seconds == 0 {
+		return 0, fmt.Errorf("window must be at least one second")
+	}
+
+	latest := s.Latest()
+	if latest.BlockTimestamp < seconds {
+		return 0, fmt.Errorf("window exceeds observation history")
+	}
+	target := latest.BlockTimestamp - seconds
+
+	// walk backwards from the newest sample to the first one at or before target
+	newer := latest
+	for step := 1; step < ObservationNum; step++ {
+		index := (int(s.ObservationIndex) - step + ObservationNum) % ObservationNum
+		older := s.Observations[index]
+		if older.BlockTimestamp == 0 || older.BlockTimestamp > newer.BlockTimestamp {
+			break // unwritten slot or wrapped past the oldest sample
+		}
+		if older.BlockTimestamp <= target {
+			// interpolate the cumulative tick at target between older and newer
+			cumulative := float64(older.TickCumulative)
+			if span := newer.BlockTimestamp - older.BlockTimestamp; span > 0 {
+				rate := float64(newer.TickCumulative-older.TickCumulative) / float64(span)
+				cumulative += rate * float64(target-older.BlockTimestamp)
+			}
+			return (float64(latest.TickCumulative) - cumulative) / float64(seconds), nil
+		}
+		newer = older
+	}
+	return 0, fmt.Errorf("window exceeds observation history")
+}
+
+// FetchObservationState loads the pool's oracle account
+func (p *CLMMPool) FetchObservationState(ctx context.Context, solClient *sol.Client) (*ObservationState, error) {
+	account, err := solClient.GetAccountInfoWithOpts(ctx, p.ObservationKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch observation account: %w", err)
+	}
+	if account == nil || account.Value == nil {
+		return nil, fmt.Errorf("observation account %s not found", p.ObservationKey)
+	}
+	state, err := DecodeObservationState(account.Value.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+	if !state.PoolId.Equals(p.PoolId) {
+		return nil, fmt.Errorf("observation account belongs to pool %s", state.PoolId)
+	}
+	return state, nil
+}
+
+// TWAPPrice returns the time weighted average price of token0 in token1 over
+// window, adjusted for mint decimals
+func (p *CLMMPool) TWAPPrice(ctx context.Context, solClient *sol.Client, window time.Duration) (float64, error) {
+	state, err := p.FetchObservationState(ctx, solClient)
+	if err != nil {
+		return 0, err
+	}
+	tick, err := state.TWAPTick(window)
+	if err != nil {
+		return 0, err
+	}
+	price := math.Pow(1.0001, tick)
+	return price * math.Pow10(int(p.MintDecimals0)-int(p.MintDecimals1)), nil
+}
@@ -0,0 +1,110 @@
+package raydium
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// exBitmapSize is the size of a tick array bitmap extension account
+const exBitmapSize = 8 + 32 + 2*EXTENSION_TICKARRAY_BITMAP_SIZE*64
+
+// StreamAccounts returns the pool, its bitmap extension and the initialized
+// tick arrays a quote loads around the current tick, and follows them
+func (p *CLMMPool) StreamAccounts() []solana.PublicKey {
+	if p.stream == nil {
+		p.stream = &pkg.StreamState{}
+	}
+	p.stream.Lock()
+	defer p.stream.Unlock()
+
+	starts := p.getInitializedTickArrayInRange(int64(p.tickArrayDepth()))
+	accounts := make([]solana.PublicKey, 0, 2+len(starts))
+	accounts = append(accounts, p.PoolId, p.ExBitmapAddress)
+	p.streamStarts = make(map[solana.PublicKey]int64, len(starts))
+	for _, start := range starts {
+		address := getPdaTickArrayAddress(RAYDIUM_CLMM_PROGRAM_ID, p.PoolId, start)
+		p.streamStarts[address] = start
+		accounts = append(accounts, address)
+	}
+	p.stream.Follow(accounts)
+	return accounts
+}
+
+// ApplyAccountUpdate applies an update of a followed account. Once the
+// current tick or the bitmaps move the arrays a quote loads past the ones
+// followed, the pool stops trusting them and reports the change
+func (p *CLMMPool) ApplyAccountUpdate(account solana.PublicKey, data []byte, slot uint64) (bool, error) {
+	if p.stream == nil {
+		return false, fmt.Errorf("pool %s is not streamed", p.PoolId)
+	}
+	p.stream.Lock()
+	defer p.stream.Unlock()
+	if !p.stream.Accept(account, slot) {
+		return false, nil
+	}
+	changed, err := p.applyStreamUpdate(account, data)
+	if err != nil {
+		// quotes fetch again rather than trust a cache missing this update
+		p.stream.MarkStale()
+	}
+	return changed, err
+}
+
+// applyStreamUpdate applies the data of a followed account with the stream locked
+func (p *CLMMPool) applyStreamUpdate(account solana.PublicKey, data []byte) (bool, error) {
+	switch {
+	case account.Equals(p.PoolId):
+		if len(data) < int(p.Span()) {
+			return false, fmt.Errorf("pool account %s too short: %d bytes", account, len(data))
+		}
+		fresh := *p
+		if err := fresh.Decode(data); err != nil {
+			return false, fmt.Errorf("failed to decode pool %s: %w", account, err)
+		}
+		*p = fresh
+		return p.checkStreamCoverage(), nil
+	case account.Equals(p.ExBitmapAddress):
+		// a missing bitmap extension keeps the previously parsed bitmap
+		if data == nil {
+			return false, nil
+		}
+		if len(data) < exBitmapSize {
+			return false, fmt.Errorf("bitmap extension %s too short: %d bytes", account, len(data))
+		}
+		p.ParseExBitmapInfo(data)
+		return p.checkStreamCoverage(), nil
+	}
+
+	start := p.streamStarts[account]
+	key := strconv.FormatInt(start, 10)
+	if data == nil {
+		delete(p.TickArrayCache, key)
+		if p.tickArrayUse != nil {
+			p.tickArrayUse.Remove(key)
+		}
+		return false, nil
+	}
+	tickArray := &TickArray{}
+	if err := tickArray.Decode(data); err != nil {
+		return false, fmt.Errorf("failed to decode tick array %s: %w", account, err)
+	}
+	if !tickArray.PoolId.Equals(p.PoolId) || int64(tickArray.StartTickIndex) != start {
+		return false, fmt.Errorf("tick array %s does not start at %d of pool %s", account, start, p.PoolId)
+	}
+	p.storeTickArray(*tickArray)
+	return false, nil
+}
+
+// checkStreamCoverage marks the stream stale when the tick arrays a quote
+// loads are no longer all followed
+func (p *CLMMPool) checkStreamCoverage() bool {
+	needed, err := p.GetTickArrayAddresses()
+	if err == nil && p.stream.Covers(needed) {
+		return false
+	}
+	p.stream.MarkStale()
+	return true
+}
@@ -0,0 +1,39 @@
+package raydium
+
+import (
+	"context"
+	"testing"
+
+	cosmath "cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestCLMMDecodeMinOut(t *testing.T) {
+	user := solana.NewWallet().PublicKey()
+	minOut := cosmath.NewInt(1_234_567)
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, TOKEN_2022_PROGRAM_ID} {
+		pool := &CLMMPool{
+			PoolId:      solana.NewWallet().PublicKey(),
+			TokenMint0:  solana.NewWallet().PublicKey(),
+			TokenMint1:  solana.NewWallet().PublicKey(),
+			TickSpacing: 10,
+			mints:       &clmmMints{programs: [2]solana.PublicKey{program, program}},
+		}
+		for i := range pool.TickArrayBitmap {
+			pool.TickArrayBitmap[i] = ^uint64(0)
+		}
+		for _, inputMint := range []solana.PublicKey{pool.TokenMint0, pool.TokenMint1} {
+			instructions, err := pool.BuildSwapInstructions(context.Background(), nil, user, inputMint.String(), cosmath.NewInt(5_000_000), minOut, solana.PublicKey{}, solana.PublicKey{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := pool.DecodeMinOut(inputMint.String(), instructions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !encoded.Equal(minOut) {
+				t.Fatalf("swap v2 %v from %s encoded min out %s, want %s", pool.usesSwapV2(), inputMint, encoded, minOut)
+			}
+		}
+	}
+}
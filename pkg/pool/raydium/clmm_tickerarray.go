@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"math"
 	"math/big"
-	"strconv"
+	"math/bits"
+	"sync"
 
 	cosmath "cosmossdk.io/math"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
 	"lukechampine.com/uint128"
 )
 
@@ -108,7 +110,7 @@ func (t *TickArray) Decode(data []byte) error {
 
 // GetTickArrayAddresses returns the addresses of tick arrays
 func (p *CLMMPool) GetTickArrayAddresses() ([]solana.PublicKey, error) {
-	startIndexArray := p.getInitializedTickArrayInRange(10) // Get 10 tick arrays
+	startIndexArray := p.getInitializedTickArrayInRange(int64(p.tickArrayDepth()))
 	tickArrayAddresses := make([]solana.PublicKey, 0, len(startIndexArray))
 	for _, itemIndex := range startIndexArray {
 		tickArrayAddress := getPdaTickArrayAddress(RAYDIUM_CLMM_PROGRAM_ID, p.PoolId, itemIndex)
@@ -128,9 +130,10 @@ func (p *CLMMPool) FetchPoolTickArrays(ctx context.Context, client *rpc.Client)
 		return fmt.Errorf("get accounts error: %v", err)
 	}
 
-	p.TickArrayCache = make(map[string]TickArray)
+	p.TickArrayCache = nil
+	p.tickArrayUse = nil
 	for _, account := range accounts.Value {
-		if account == nil {
+		if account == nil || account.Data == nil {
 			continue
 		}
 		tickArray := &TickArray{}
@@ -138,7 +141,7 @@ func (p *CLMMPool) FetchPoolTickArrays(ctx context.Context, client *rpc.Client)
 		if err != nil {
 			return fmt.Errorf("failed to decode tick array: %w", err)
 		}
-		p.TickArrayCache[strconv.FormatInt(int64(tickArray.StartTickIndex), 10)] = *tickArray
+		p.storeTickArray(*tickArray)
 	}
 
 	return nil
@@ -180,40 +183,33 @@ func (p *CLMMPool) ParseExBitmapInfo(data []byte) {
 	bitmap.NegativeTickArrayBitmap = negativeBitmaps
 
 	p.exTickArrayBitmap = &bitmap
+	p.invalidateTickArrayBitmaps()
 }
 
 // getInitializedTickArrayInRange returns initialized tick arrays in range
 func (p *CLMMPool) getInitializedTickArrayInRange(count int64) []int64 {
-	tickArrayBitmap := p.TickArrayBitmap
-	exBitmapInfo := p.exTickArrayBitmap
 	tickSpacing := int64(p.TickSpacing)
 
 	tickArrayStartIndex := getTickArrayStartIndexByTick(int64(p.TickCurrent), int64(p.TickSpacing))
 	tickArrayOffset := math.Floor(float64(tickArrayStartIndex) / (float64(tickSpacing) * float64(TICK_ARRAY_SIZE)))
 
 	result := make([]int64, 0, count)
-	r := SearchLowBitFromStart(tickArrayBitmap, exBitmapInfo, int64(tickArrayOffset-1), count, tickSpacing)
+	r := p.searchLowBitFromStart(int64(tickArrayOffset-1), count)
 	result = append(result, r...)
-	r = SearchHighBitFromStart(tickArrayBitmap, exBitmapInfo, int64(tickArrayOffset-1), count, tickSpacing)
+	r = p.searchHighBitFromStart(int64(tickArrayOffset-1), count)
 	result = append(result, r...)
 
 	return result
 }
 
 // nextInitializedTickArray finds the next initialized tick array
-func nextInitializedTickArray(
-	tick int64,
-	tickSpacing int64,
-	zeroForOne bool,
-	tickArrayBitmap [16]uint64,
-	tickarrayBitmapExtension *TickArrayBitmapExtensionType,
-) (int64, bool) {
-	currentOffset := math.Floor(float64(tick) / float64(getTickCount(tickSpacing)))
+func (p *CLMMPool) nextInitializedTickArray(tick int64, zeroForOne bool) (int64, bool) {
+	currentOffset := math.Floor(float64(tick) / float64(getTickCount(int64(p.TickSpacing))))
 	var result []int64
 	if zeroForOne {
-		result = SearchLowBitFromStart(tickArrayBitmap, tickarrayBitmapExtension, int64(currentOffset-1), 1, tickSpacing)
+		result = p.searchLowBitFromStart(int64(currentOffset-1), 1)
 	} else {
-		result = SearchHighBitFromStart(tickArrayBitmap, tickarrayBitmapExtension, int64(currentOffset+1), 1, tickSpacing)
+		result = p.searchHighBitFromStart(int64(currentOffset+1), 1)
 	}
 	if len(result) > 0 {
 		return result[0], true
@@ -258,40 +254,8 @@ func SearchLowBitFromStart(
 	currentTickArrayBitStartIndex int64,
 	expectedCount int64,
 	tickSpacing int64) []int64 {
-
-	var tickArrayBitmaps []*big.Int
-
-	for i := len(exTickArrayBitmap.NegativeTickArrayBitmap) - 1; i >= 0; i-- {
-		tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(exTickArrayBitmap.NegativeTickArrayBitmap[i]))
-	}
-	tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(tickArrayBitmap[0:8]))
-	tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(tickArrayBitmap[8:16]))
-	for _, bitmap := range exTickArrayBitmap.PositiveTickArrayBitmap {
-		tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(bitmap))
-	}
-
-	result := make([]int64, 0)
-	for currentTickArrayBitStartIndex >= -7680 {
-		arrayIndex := (currentTickArrayBitStartIndex + 7680) / 512
-		searchIndex := (currentTickArrayBitStartIndex + 7680) % 512
-
-		if tickArrayBitmaps[arrayIndex].Bit(int(searchIndex)) == 1 {
-			result = append(result, (currentTickArrayBitStartIndex))
-		}
-
-		currentTickArrayBitStartIndex--
-		if len(result) == int(expectedCount) {
-			break
-		}
-	}
-
-	tickCount := getTickCount(int64(tickSpacing))
-	finalResult := make([]int64, len(result))
-	for i, val := range result {
-		finalResult[i] = int64(val) * tickCount
-	}
-
-	return finalResult
+	tickArrayBitmaps := mergeTickArrayBitmaps(tickArrayBitmap, exTickArrayBitmap)
+	return searchLowBit(tickArrayBitmaps, currentTickArrayBitStartIndex, expectedCount, tickSpacing)
 }
 
 // SearchHighBitFromStart searches for high bits from start
@@ -300,43 +264,8 @@ func SearchHighBitFromStart(tickArrayBitmap [16]uint64,
 	currentTickArrayBitStartIndex int64,
 	expectedCount int64,
 	tickSpacing int64) []int64 {
-
-	var tickArrayBitmaps []*big.Int
-
-	for i := len(exTickArrayBitmap.NegativeTickArrayBitmap) - 1; i >= 0; i-- {
-		tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(exTickArrayBitmap.NegativeTickArrayBitmap[i]))
-	}
-
-	firstPart := MergeTickArrayBitmap(tickArrayBitmap[0:8])
-	secondPart := MergeTickArrayBitmap(tickArrayBitmap[8:16])
-	tickArrayBitmaps = append(tickArrayBitmaps, firstPart, secondPart)
-
-	for _, bitmap := range exTickArrayBitmap.PositiveTickArrayBitmap {
-		tickArrayBitmaps = append(tickArrayBitmaps, MergeTickArrayBitmap(bitmap))
-	}
-
-	result := make([]int64, 0)
-	for currentTickArrayBitStartIndex < 7680 {
-		arrayIndex := (currentTickArrayBitStartIndex + 7680) / 512
-		searchIndex := (currentTickArrayBitStartIndex + 7680) % 512
-
-		if tickArrayBitmaps[arrayIndex].Bit(int(searchIndex)) == 1 {
-			result = append(result, currentTickArrayBitStartIndex)
-		}
-
-		currentTickArrayBitStartIndex++
-		if len(result) == int(expectedCount) {
-			break
-		}
-	}
-
-	tickCount := getTickCount(tickSpacing)
-	finalResult := make([]int64, len(result))
-	for i, val := range result {
-		finalResult[i] = val * tickCount
-	}
-
-	return finalResult
+	tickArrayBitmaps := mergeTickArrayBitmaps(tickArrayBitmap, exTickArrayBitmap)
+	return searchHighBit(tickArrayBitmaps, currentTickArrayBitStartIndex, expectedCount, tickSpacing)
 }
 
 // TickArrayOffsetInBitmap calculates the offset of a tick array in bitmap
@@ -446,14 +375,14 @@ func ExtensionTickBoundary(tickSpacing int64) (int64, int64, error) {
 	return positiveTickBoundary, negativeTickBoundary, nil
 }
 
-func nextInitializedTickArrayStartIndexUtils(exTickArrayBitmap *TickArrayBitmapExtensionType, tickCurrent, tickSpacing int64, tickArrayBitmap [16]uint64,
+func nextInitializedTickArrayStartIndexUtils(exTickArrayBitmap *TickArrayBitmapExtensionType, tickCurrent, tickSpacing int64, defaultBitmap *big.Int,
 	zeroForOne bool) (bool, int64, error) {
 	lastTickArrayStartIndex := GetArrayStartIndex(tickCurrent, tickSpacing)
 
 	// eslint-disable-next-line no-constant-condition
 	for {
 		startIsInit, startIndex := nextInitializedTickArrayStartIndex(
-			MergeTickArrayBitmap(tickArrayBitmap[:]),
+			defaultBitmap,
 			int64(lastTickArrayStartIndex),
 			int64(tickSpacing),
 			zeroForOne,
@@ -698,18 +627,21 @@ func GetArrayStartIndex(tickIndex int64, tickSpacing int64) int64 {
 }
 
 func MergeTickArrayBitmap(bns []uint64) *big.Int {
-	result := new(big.Int)
+	// Build the limbs directly instead of shifting and adding one word at a time
+	if bits.UintSize == 64 {
+		words := make([]big.Word, len(bns))
+		for i, bn := range bns {
+			words[i] = big.Word(bn)
+		}
+		return new(big.Int).SetBits(words)
+	}
 
-	// 遍历数组
+	words := make([]big.Word, 2*len(bns))
 	for i, bn := range bns {
-		// Convert uint64 to big.Int
-		bnBig := new(big.Int).SetUint64(bn)
-		// Create a temporary big.Int for the shift operation
-		shifted := new(big.Int).Lsh(bnBig, uint(64*i))
-		result.Add(result, shifted)
+		words[2*i] = big.Word(uint32(bn))
+		words[2*i+1] = big.Word(uint32(bn >> 32))
 	}
-
-	return result
+	return new(big.Int).SetBits(words)
 }
 
 // firstInitializedTick 查找第一个初始化的价格刻度
@@ -808,7 +740,7 @@ func (poolInfo *CLMMPool) getFirstInitializedTickArray(zeroForOne bool, exTickAr
 		exTickArrayBitmap,
 		int64(poolInfo.TickCurrent),
 		int64(poolInfo.TickSpacing),
-		poolInfo.TickArrayBitmap,
+		poolInfo.tickArrayBitmaps().defaultBitmap,
 		zeroForOne,
 	)
 	if err != nil {
@@ -831,7 +763,7 @@ func getPdaTickArrayAddress(programId solana.PublicKey, poolId solana.PublicKey,
 	seeds := [][]byte{
 		[]byte("tick_array"), poolId.Bytes(), startIndexBytes,
 	}
-	pk, _, _ := solana.FindProgramAddress(seeds, programId)
+	pk, _, _ := sol.FindProgramAddress(seeds, programId)
 	return pk
 }
 
@@ -840,7 +772,7 @@ func GetPdaExBitmapAccount(programId solana.PublicKey, id solana.PublicKey) (sol
 		[]byte("pool_tick_array_bitmap_extension"),
 		id.Bytes(),
 	}
-	return solana.FindProgramAddress(seeds, programId)
+	return sol.FindProgramAddress(seeds, programId)
 }
 
 func getTickArrayStartIndexByTick(tickIndex int64, tickSpacing int64) int64 {
@@ -1136,26 +1068,32 @@ func swapStepCompute(
 		FeeAmount:        new(big.Int),
 	}
 
-	zero := new(big.Int)
-	baseInput := amountRemaining.Cmp(zero) >= 0
+	baseInput := amountRemaining.Sign() >= 0
+
+	feeRateBig := new(big.Int).SetUint64(uint64(feeRate))
+	feeRateSubtracted := new(big.Int).Sub(feeRateDenominatorBig, feeRateBig)
+
+	// amountRemainingNeg is only used for exact-output steps
+	var amountRemainingNeg *big.Int
+	if !baseInput {
+		amountRemainingNeg = new(big.Int).Neg(amountRemaining)
+	}
 
 	if baseInput {
-		feeRateBig := cosmath.NewInt(int64(feeRate))
-		tmp := FEE_RATE_DENOMINATOR.Sub(feeRateBig)
-		amountRemainingSubtractFee := mulDivFloor(cosmath.NewIntFromBigInt(amountRemaining), tmp, FEE_RATE_DENOMINATOR)
+		amountRemainingSubtractFee := mulDivFloorBig(amountRemaining, feeRateSubtracted, feeRateDenominatorBig)
 		if zeroForOne {
 			swapStep.AmountIn = getTokenAmountAFromLiquidity(sqrtPriceX64Target, sqrtPriceX64Current, liquidity, true)
 		} else {
 			swapStep.AmountIn = getTokenAmountBFromLiquidity(sqrtPriceX64Current, sqrtPriceX64Target, liquidity, true)
 		}
 
-		if amountRemainingSubtractFee.GTE(cosmath.NewIntFromBigInt(swapStep.AmountIn)) {
+		if amountRemainingSubtractFee.Cmp(swapStep.AmountIn) >= 0 {
 			swapStep.SqrtPriceX64Next.Set(sqrtPriceX64Target)
 		} else {
 			swapStep.SqrtPriceX64Next = getNextSqrtPriceX64FromInput(
 				sqrtPriceX64Current,
 				liquidity,
-				amountRemainingSubtractFee.BigInt(),
+				amountRemainingSubtractFee,
 				zeroForOne,
 			)
 		}
@@ -1166,9 +1104,6 @@ func swapStepCompute(
 			swapStep.AmountOut = getTokenAmountAFromLiquidity(sqrtPriceX64Current, sqrtPriceX64Target, liquidity, false)
 		}
 
-		negativeOne := new(big.Int).SetInt64(-1)
-		amountRemainingNeg := new(big.Int).Mul(amountRemaining, negativeOne)
-
 		if amountRemainingNeg.Cmp(swapStep.AmountOut) >= 0 {
 			swapStep.SqrtPriceX64Next.Set(sqrtPriceX64Target)
 		} else {
@@ -1226,36 +1161,69 @@ func swapStepCompute(
 	}
 
 	if !baseInput {
-		negativeOne := new(big.Int).SetInt64(-1)
-		amountRemainingNeg := new(big.Int).Mul(amountRemaining, negativeOne)
 		if swapStep.AmountOut.Cmp(amountRemainingNeg) > 0 {
 			swapStep.AmountOut.Set(amountRemainingNeg)
 		}
 	}
 
-	if baseInput && swapStep.SqrtPriceX64Next.Cmp(sqrtPriceX64Target) != 0 {
+	if baseInput && !reachTargetPrice {
 		swapStep.FeeAmount = new(big.Int).Sub(amountRemaining, swapStep.AmountIn)
 	} else {
-		feeRateBig := cosmath.NewInt(int64(feeRate))
-		feeRateSubtracted := FEE_RATE_DENOMINATOR.Sub(feeRateBig)
-		swapStep.FeeAmount = mulDivCeil(cosmath.NewIntFromBigInt(swapStep.AmountIn), feeRateBig, feeRateSubtracted).BigInt()
+		swapStep.FeeAmount = mulDivCeilBig(swapStep.AmountIn, feeRateBig, feeRateSubtracted)
+	}
+
+	// SqrtPriceX64Next may alias the caller's price; the amounts are owned by this step
+	return cosmath.NewIntFromBigInt(swapStep.SqrtPriceX64Next), cosmath.NewIntFromBigIntMut(swapStep.AmountIn),
+		cosmath.NewIntFromBigIntMut(swapStep.AmountOut), cosmath.NewIntFromBigIntMut(swapStep.FeeAmount)
+}
+
+var (
+	// q64 is 2^64, the fixed point scale of sqrt prices
+	q64 = new(big.Int).Lsh(big.NewInt(1), U64Resolution)
+	// feeRateDenominatorBig mirrors FEE_RATE_DENOMINATOR for the big.Int math below
+	feeRateDenominatorBig = FEE_RATE_DENOMINATOR.BigInt()
+	bigOne                = big.NewInt(1)
+)
+
+// bigIntPool recycles scratch values for intermediates that never escape
+var bigIntPool = sync.Pool{
+	New: func() any { return new(big.Int) },
+}
+
+func getScratch() *big.Int {
+	return bigIntPool.Get().(*big.Int)
+}
+
+func putScratch(xs ...*big.Int) {
+	for _, x := range xs {
+		bigIntPool.Put(x)
+	}
+}
+
+// mulDivCeilBig computes ceil(a*b/denominator) without going through cosmath
+func mulDivCeilBig(a, b, denominator *big.Int) *big.Int {
+	if denominator.Sign() == 0 {
+		return new(big.Int)
 	}
 
-	return cosmath.NewIntFromBigInt(swapStep.SqrtPriceX64Next), cosmath.NewIntFromBigInt(swapStep.AmountIn),
-		cosmath.NewIntFromBigInt(swapStep.AmountOut), cosmath.NewIntFromBigInt(swapStep.FeeAmount)
+	numerator := getScratch().Mul(a, b)
+	numerator.Add(numerator, denominator)
+	numerator.Sub(numerator, bigOne)
+	result := new(big.Int).Quo(numerator, denominator)
+	putScratch(numerator)
+	return result
 }
 
-// Helper function for ceiling division
-func mulDivCeil(a, b, denominator cosmath.Int) cosmath.Int {
-	// 检查除数是否为0
-	if denominator.IsZero() {
-		return cosmath.Int{}
+// mulDivFloorBig computes floor(a*b/denominator) without going through cosmath
+func mulDivFloorBig(a, b, denominator *big.Int) *big.Int {
+	if denominator.Sign() == 0 {
+		panic("division by zero")
 	}
 
-	// 计算 a * b
-	numerator := a.Mul(b).Add(denominator.Sub(cosmath.OneInt()))
-	// 计算最终结果 numerator / denominator
-	return numerator.Quo(denominator)
+	numerator := getScratch().Mul(a, b)
+	result := new(big.Int).Quo(numerator, denominator)
+	putScratch(numerator)
+	return result
 }
 
 // getTokenAmountAFromLiquidity calculates token amount A from liquidity
@@ -1265,37 +1233,32 @@ func getTokenAmountAFromLiquidity(
 	liquidity *big.Int,
 	roundUp bool,
 ) *big.Int {
-	// Create copies to avoid modifying the original values
-	priceA := new(big.Int).Set(sqrtPriceX64A)
-	priceB := new(big.Int).Set(sqrtPriceX64B)
-
-	// Swap if priceA > priceB
+	// The inputs are only read, so order them without copying
+	priceA, priceB := sqrtPriceX64A, sqrtPriceX64B
 	if priceA.Cmp(priceB) > 0 {
 		priceA, priceB = priceB, priceA
 	}
 
 	// Check if priceA > 0
-	if priceA.Cmp(big.NewInt(0)) <= 0 {
+	if priceA.Sign() <= 0 {
 		panic("sqrtPriceX64A must be greater than 0")
 	}
 
 	// Calculate numerator1 = liquidity << U64Resolution
-	numerator1 := new(big.Int).Lsh(liquidity, U64Resolution)
+	numerator1 := getScratch().Lsh(liquidity, U64Resolution)
 
 	// Calculate numerator2 = priceB - priceA
-	numerator2 := new(big.Int).Sub(priceB, priceA)
+	numerator2 := getScratch().Sub(priceB, priceA)
+	defer putScratch(numerator1, numerator2)
 
 	if roundUp {
-		// First calculate mulDivCeil(numerator1, numerator2, priceB)
-		temp := mulDivCeil(cosmath.NewIntFromBigInt(numerator1), cosmath.NewIntFromBigInt(numerator2), cosmath.NewIntFromBigInt(priceB))
-		// Then calculate mulDivCeil(temp, 1, priceA)
-		return mulDivCeil(temp, cosmath.NewIntFromBigInt(big.NewInt(1)), cosmath.NewIntFromBigInt(priceA)).BigInt()
-	} else {
-		// Calculate mulDivFloor(numerator1, numerator2, priceB)
-		temp := mulDivFloor(cosmath.NewIntFromBigInt(numerator1), cosmath.NewIntFromBigInt(numerator2), cosmath.NewIntFromBigInt(priceB))
-		// Then divide by priceA
-		return temp.Quo(cosmath.NewIntFromBigInt(priceA)).BigInt()
+		// ceil(ceil(numerator1 * numerator2 / priceB) / priceA)
+		temp := mulDivCeilBig(numerator1, numerator2, priceB)
+		return mulDivCeilBig(temp, bigOne, priceA)
 	}
+	// floor(floor(numerator1 * numerator2 / priceB) / priceA)
+	temp := mulDivFloorBig(numerator1, numerator2, priceB)
+	return temp.Quo(temp, priceA)
 }
 
 // getTokenAmountBFromLiquidity calculates token amount B from liquidity
@@ -1305,38 +1268,25 @@ func getTokenAmountBFromLiquidity(
 	liquidity *big.Int,
 	roundUp bool,
 ) *big.Int {
-	// Create copies to avoid modifying the original values
-	priceA := new(big.Int).Set(sqrtPriceX64A)
-	priceB := new(big.Int).Set(sqrtPriceX64B)
-
-	// Swap if priceA > priceB
+	// The inputs are only read, so order them without copying
+	priceA, priceB := sqrtPriceX64A, sqrtPriceX64B
 	if priceA.Cmp(priceB) > 0 {
 		priceA, priceB = priceB, priceA
 	}
 
 	// Check if priceA > 0
-	if priceA.Cmp(big.NewInt(0)) <= 0 {
+	if priceA.Sign() <= 0 {
 		panic("sqrtPriceX64A must be greater than 0")
 	}
 
 	// Calculate price difference
-	priceDiff := new(big.Int).Sub(priceB, priceA)
+	priceDiff := getScratch().Sub(priceB, priceA)
+	defer putScratch(priceDiff)
 
 	if roundUp {
-		return mulDivCeil(cosmath.NewIntFromBigInt(liquidity), cosmath.NewIntFromBigInt(priceDiff), cosmath.NewIntFromBigInt(new(big.Int).Lsh(big.NewInt(1), U64Resolution))).BigInt()
-	} else {
-		return mulDivFloor(cosmath.NewIntFromBigInt(liquidity), cosmath.NewIntFromBigInt(priceDiff), cosmath.NewIntFromBigInt(new(big.Int).Lsh(big.NewInt(1), U64Resolution))).BigInt()
-	}
-}
-
-// mulDivFloor performs multiplication and division with floor rounding
-func mulDivFloor(a, b, denominator cosmath.Int) cosmath.Int {
-	if denominator.IsZero() {
-		panic("division by zero")
+		return mulDivCeilBig(liquidity, priceDiff, q64)
 	}
-
-	numerator := a.Mul(b)
-	return numerator.Quo(denominator)
+	return mulDivFloorBig(liquidity, priceDiff, q64)
 }
 
 func getNextSqrtPriceX64FromInput(
@@ -1402,7 +1352,7 @@ func getNextSqrtPriceFromTokenAmountARoundingUp(
 		numerator1 := liquidityLeftShift
 		denominator := new(big.Int).Add(liquidityLeftShift, new(big.Int).Mul(amount, sqrtPriceX64))
 		if denominator.Cmp(numerator1) >= 0 {
-			return mulDivCeil(cosmath.NewIntFromBigInt(numerator1), cosmath.NewIntFromBigInt(sqrtPriceX64), cosmath.NewIntFromBigInt(denominator)).BigInt()
+			return mulDivCeilBig(numerator1, sqrtPriceX64, denominator)
 		}
 
 		temp := new(big.Int).Div(numerator1, sqrtPriceX64)
@@ -1414,7 +1364,7 @@ func getNextSqrtPriceFromTokenAmountARoundingUp(
 			panic("getNextSqrtPriceFromTokenAmountARoundingUp: liquidityLeftShift must be greater than amountMulSqrtPrice")
 		}
 		denominator := new(big.Int).Sub(liquidityLeftShift, amountMulSqrtPrice)
-		return mulDivCeil(cosmath.NewIntFromBigInt(liquidityLeftShift), cosmath.NewIntFromBigInt(sqrtPriceX64), cosmath.NewIntFromBigInt(denominator)).BigInt()
+		return mulDivCeilBig(liquidityLeftShift, sqrtPriceX64, denominator)
 	}
 }
 
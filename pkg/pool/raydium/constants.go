@@ -17,8 +17,17 @@ var (
 	RAYDIUM_AMM_PROGRAM_ID  = solana.MustPublicKeyFromBase58("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8")
 	RAYDIUM_CPMM_PROGRAM_ID = solana.MustPublicKeyFromBase58("CPMMoo8L3F4NbTegBCKVNunggL7H1ZpdTHKxQB5qKP1C")
 	RAYDIUM_CLMM_PROGRAM_ID = solana.MustPublicKeyFromBase58("CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK")
+
+	// Order book program IDs that Raydium AMM v4 markets can live on
+	SERUM_PROGRAM_ID    = solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	OPENBOOK_PROGRAM_ID = solana.MustPublicKeyFromBase58("srmqPvymJeFKQ4zGQed1GFppgkRHL9kaELCbyksJtPX")
 )
 
+// IsSupportedMarketProgram reports whether an AMM market is owned by a known order book program
+func IsSupportedMarketProgram(programID solana.PublicKey) bool {
+	return programID.Equals(SERUM_PROGRAM_ID) || programID.Equals(OPENBOOK_PROGRAM_ID)
+}
+
 // Tick Array Configuration
 const (
 	TICK_ARRAY_SIZE                 = 60
@@ -45,6 +54,9 @@ var (
 
 // Seeds and Discriminators
 var (
-	AUTH_SEED                  = "vault_and_lp_mint_auth_seed"
-	SwapBaseInputDiscriminator = []byte{143, 190, 90, 218, 196, 30, 51, 222}
+	AUTH_SEED                   = "vault_and_lp_mint_auth_seed"
+	SwapBaseInputDiscriminator  = []byte{143, 190, 90, 218, 196, 30, 51, 222}
+	SwapBaseOutputDiscriminator = []byte{55, 217, 98, 86, 163, 74, 180, 173}
+	CLMMSwapDiscriminator       = []byte{43, 4, 237, 11, 26, 201, 30, 98}
+	CLMMLegacySwapDiscriminator = []byte{248, 198, 158, 145, 225, 117, 135, 200}
 )
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"log"
+	"time"
 
 	"cosmossdk.io/math"
 	cosmath "cosmossdk.io/math"
@@ -90,6 +92,81 @@ func (pool *CPMMPool) GetTokens() (string, string) {
 	return pool.Token0Mint.String(), pool.Token1Mint.String()
 }
 
+// OpensAt returns when the pool starts accepting swaps
+func (pool *CPMMPool) OpensAt() time.Time {
+	return openTime(pool.OpenTime)
+}
+
+// MintDecimals returns the decimals the pool records for mint
+func (pool *CPMMPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case pool.Token0Mint.String():
+		return pool.Mint0Decimals, true
+	case pool.Token1Mint.String():
+		return pool.Mint1Decimals, true
+	}
+	return 0, false
+}
+
+// SwapFee returns the trading fee charged on inputAmount
+func (pool *CPMMPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return inputAmount.Mul(LIQUIDITY_FEES_NUMERATOR).Quo(LIQUIDITY_FEES_DENOMINATOR)
+}
+
+// Reserves returns the token 0 and token 1 reserves cached by the last Quote
+func (pool *CPMMPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the reserve ratio cached by the last Quote
+func (pool *CPMMPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	baseMint, _ := pool.GetTokens()
+	return pkg.PriceFromReserves(pool.BaseReserve, pool.QuoteReserve, inputMint == baseMint)
+}
+
+// EstimateAccounts returns the pool's vaults, whose balances EstimatePrice reads
+func (pool *CPMMPool) EstimateAccounts() []solana.PublicKey {
+	return []solana.PublicKey{pool.Token0Vault, pool.Token1Vault}
+}
+
+// EstimatePrice is the vault ratio net of the pending PnL decoded with the pool
+func (pool *CPMMPool) EstimatePrice(inputMint string, balances []uint64) (math.LegacyDec, error) {
+	if len(balances) != 2 {
+		return math.LegacyDec{}, fmt.Errorf("expected 2 vault balances, got %d", len(balances))
+	}
+	base := math.NewIntFromUint64(balances[0]).Sub(math.NewIntFromUint64(pool.BaseNeedTakePnl))
+	quote := math.NewIntFromUint64(balances[1]).Sub(math.NewIntFromUint64(pool.QuoteNeedTakePnl))
+	if !base.IsPositive() || !quote.IsPositive() {
+		return math.LegacyDec{}, fmt.Errorf("pool %s has an empty vault", pool.PoolId)
+	}
+	return pkg.PriceFromReserves(base, quote, inputMint == pool.Token0Mint.String())
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price
+func (pool *CPMMPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return math.ZeroInt(), fmt.Errorf("pool reserves not loaded")
+	}
+	reserveIn := pool.BaseReserve
+	if inputMint == pool.Token1Mint.String() {
+		reserveIn = pool.QuoteReserve
+	}
+	return constantProductMaxInput(reserveIn, maxImpactBps), nil
+}
+
+// constantProductMaxInput inverts x * y = k: the average price of an input a
+// after fees is reserveOut / (reserveIn + a), so the impact bound b holds while
+// a <= reserveIn * b / (1 - b). The result is grossed up by the trading fee
+func constantProductMaxInput(reserveIn math.Int, maxImpactBps int) math.Int {
+	bps := math.NewInt(int64(maxImpactBps))
+	afterFee := reserveIn.Mul(bps).Quo(math.NewInt(10000).Sub(bps))
+	return afterFee.Mul(LIQUIDITY_FEES_DENOMINATOR).Quo(LIQUIDITY_FEES_DENOMINATOR.Sub(LIQUIDITY_FEES_NUMERATOR))
+}
+
 func (pool *CPMMPool) BuildSwapInstructions(
 	ctx context.Context,
 	solClient *sol.Client,
@@ -153,6 +230,17 @@ func (pool *CPMMPool) BuildSwapInstructions(
 	return instrs, nil
 }
 
+// DecodeMinOut reads minimum_amount_out back from the swap_base_input instruction
+func (pool *CPMMPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, RAYDIUM_CPMM_PROGRAM_ID, SwapBaseInputDiscriminator, 16)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in swap_base_input
+func (pool *CPMMPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: RAYDIUM_CPMM_PROGRAM_ID, Prefix: SwapBaseInputDiscriminator, Offset: 8},
+		pkg.AmountField{ProgramID: RAYDIUM_CPMM_PROGRAM_ID, Prefix: SwapBaseInputDiscriminator, Offset: 16}
+}
+
 // CPMMSwapInstruction represents the data for a CPMM swap instruction
 type CPMMSwapInstruction struct {
 	bin.BaseVariant
@@ -190,7 +278,7 @@ func getAuthorityPDA() (solana.PublicKey, uint8, error) {
 	seeds := [][]byte{
 		[]byte(AUTH_SEED),
 	}
-	authority, bump, err := solana.FindProgramAddress(seeds, RAYDIUM_CPMM_PROGRAM_ID)
+	authority, bump, err := sol.FindProgramAddress(seeds, RAYDIUM_CPMM_PROGRAM_ID)
 	if err != nil {
 		return solana.PublicKey{}, 0, fmt.Errorf("failed to find authority PDA: %v", err)
 	}
@@ -206,27 +294,29 @@ func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 	if err != nil {
 		return math.NewInt(0), fmt.Errorf("batch request failed: %v", err)
 	}
-	for i, result := range results.Value {
-		if result == nil {
-			return math.NewInt(0), fmt.Errorf("result is nil, account: %v", accounts[i].String())
-		}
-		accountKey := accounts[i].String()
-		if pool.Token0Vault.String() == accountKey {
-			amountBytes := result.Data.GetBinary()[64:72]
-			amountUint := binary.LittleEndian.Uint64(amountBytes)
-			amount := math.NewIntFromUint64(amountUint)
-			pool.BaseAmount = amount
-		} else {
-			amountBytes := result.Data.GetBinary()[64:72]
-			amountUint := binary.LittleEndian.Uint64(amountBytes)
-			amount := math.NewIntFromUint64(amountUint)
-			pool.QuoteAmount = amount
-		}
+	// a vault missing at the queried commitment keeps its last known balance
+	if amount, ok := sol.TokenAccountAmount(results, 0); ok {
+		pool.BaseAmount = math.NewIntFromUint64(amount)
+	} else if pool.BaseAmount.IsNil() {
+		return math.NewInt(0), fmt.Errorf("vault account missing: %v", pool.Token0Vault.String())
+	} else {
+		log.Printf("vault account %v missing, using cached balance", pool.Token0Vault.String())
+	}
+	if amount, ok := sol.TokenAccountAmount(results, 1); ok {
+		pool.QuoteAmount = math.NewIntFromUint64(amount)
+	} else if pool.QuoteAmount.IsNil() {
+		return math.NewInt(0), fmt.Errorf("vault account missing: %v", pool.Token1Vault.String())
+	} else {
+		log.Printf("vault account %v missing, using cached balance", pool.Token1Vault.String())
 	}
 
 	pool.BaseReserve = pool.BaseAmount.Sub(math.NewInt(int64(pool.BaseNeedTakePnl)))
 	pool.QuoteReserve = pool.QuoteAmount.Sub(math.NewInt(int64(pool.QuoteNeedTakePnl)))
+	return pool.ComputeAmountOut(inputMint, inputAmount), nil
+}
 
+// ComputeAmountOut calculates the output for inputAmount from the cached reserves
+func (pool *CPMMPool) ComputeAmountOut(inputMint string, inputAmount math.Int) math.Int {
 	// Set reserves based on direction
 	reserves := []math.Int{pool.BaseReserve, pool.QuoteReserve}
 	mintDecimals := []int{int(pool.BaseDecimal), int(pool.QuoteDecimal)}
@@ -262,5 +352,5 @@ func (pool *CPMMPool) Quote(ctx context.Context, solClient *sol.Client, inputMin
 		denominator := reserveIn.Add(amountInWithFee)
 		amountOutRaw = reserveOut.Mul(amountInWithFee).Quo(denominator)
 	}
-	return amountOutRaw, nil
+	return amountOutRaw
 }
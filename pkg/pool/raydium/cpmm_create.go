@@ -0,0 +1,189 @@
+package raydium
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+var (
+	// CPMM_CREATE_POOL_FEE_RECEIVER collects the one-off CPMM pool creation fee
+	CPMM_CREATE_POOL_FEE_RECEIVER = solana.MustPublicKeyFromBase58("DNXgeM9EiiaAbaWvwjHj9fQQLAX5ZsfHyvmYUNRAdNC8")
+	// CPMM_DEFAULT_AMM_CONFIG is the 0.25% fee tier config
+	CPMM_DEFAULT_AMM_CONFIG = solana.MustPublicKeyFromBase58("D4FPEruKEHrG5TenZ2mpDGEfu1iUvTiqBxvpU8HLBvC2")
+
+	CPMMInitializeDiscriminator = []byte{175, 175, 109, 31, 13, 152, 155, 237}
+)
+
+// CPMMPoolAddresses are the accounts a CPMM pool is created with, all derived
+// from the amm config and the sorted mint pair
+type CPMMPoolAddresses struct {
+	Pool        solana.PublicKey
+	Authority   solana.PublicKey
+	LPMint      solana.PublicKey
+	Token0Mint  solana.PublicKey
+	Token1Mint  solana.PublicKey
+	Token0Vault solana.PublicKey
+	Token1Vault solana.PublicKey
+	Observation solana.PublicKey
+}
+
+// DeriveCPMMPoolAddresses derives the addresses of the CPMM pool for a mint
+// pair under ammConfig. The mints may be given in either order
+func DeriveCPMMPoolAddresses(ammConfig, mintA, mintB solana.PublicKey) (CPMMPoolAddresses, error) {
+	mint0, mint1 := sortMints(mintA, mintB)
+	addresses := CPMMPoolAddresses{Token0Mint: mint0, Token1Mint: mint1}
+
+	authority, _, err := getAuthorityPDA()
+	if err != nil {
+		return CPMMPoolAddresses{}, err
+	}
+	addresses.Authority = authority
+
+	derive := func(seeds ...[]byte) (solana.PublicKey, error) {
+		address, _, err := sol.FindProgramAddress(seeds, RAYDIUM_CPMM_PROGRAM_ID)
+		if err != nil {
+			return solana.PublicKey{}, fmt.Errorf("failed to derive %s PDA: %w", seeds[0], err)
+		}
+		return address, nil
+	}
+	if addresses.Pool, err = derive([]byte("pool"), ammConfig[:], mint0[:], mint1[:]); err != nil {
+		return CPMMPoolAddresses{}, err
+	}
+	pool := addresses.Pool
+	if addresses.LPMint, err = derive([]byte("pool_lp_mint"), pool[:]); err != nil {
+		return CPMMPoolAddresses{}, err
+	}
+	if addresses.Token0Vault, err = derive([]byte("pool_vault"), pool[:], mint0[:]); err != nil {
+		return CPMMPoolAddresses{}, err
+	}
+	if addresses.Token1Vault, err = derive([]byte("pool_vault"), pool[:], mint1[:]); err != nil {
+		return CPMMPoolAddresses{}, err
+	}
+	if addresses.Observation, err = derive([]byte("observation"), pool[:]); err != nil {
+		return CPMMPoolAddresses{}, err
+	}
+	return addresses, nil
+}
+
+// CPMMCreateParams describes a new CPMM pool and the liquidity it is seeded with
+type CPMMCreateParams struct {
+	Creator solana.PublicKey
+	// AmmConfig selects the fee tier; zero uses CPMM_DEFAULT_AMM_CONFIG
+	AmmConfig solana.PublicKey
+
+	MintA   solana.PublicKey
+	MintB   solana.PublicKey
+	AmountA uint64
+	AmountB uint64
+	// TokenProgramA and TokenProgramB default to the SPL Token program
+	TokenProgramA solana.PublicKey
+	TokenProgramB solana.PublicKey
+	// CreatorAccountA and CreatorAccountB hold the seed liquidity and default
+	// to the creator's associated token accounts
+	CreatorAccountA solana.PublicKey
+	CreatorAccountB solana.PublicKey
+
+	// OpenTime is the unix time swaps are allowed from; zero opens immediately
+	OpenTime uint64
+}
+
+// NewCPMMInitializeInstruction builds the instruction that creates a CPMM pool
+// and deposits its initial liquidity. The creator receives the LP tokens in
+// their associated token account, which the instruction creates
+func NewCPMMInitializeInstruction(params CPMMCreateParams) (solana.Instruction, CPMMPoolAddresses, error) {
+	if params.MintA.Equals(params.MintB) {
+		return nil, CPMMPoolAddresses{}, fmt.Errorf("pool mints must differ")
+	}
+	if params.AmountA == 0 || params.AmountB == 0 {
+		return nil, CPMMPoolAddresses{}, fmt.Errorf("initial liquidity must be positive")
+	}
+	ammConfig := params.AmmConfig
+	if ammConfig.IsZero() {
+		ammConfig = CPMM_DEFAULT_AMM_CONFIG
+	}
+	addresses, err := DeriveCPMMPoolAddresses(ammConfig, params.MintA, params.MintB)
+	if err != nil {
+		return nil, CPMMPoolAddresses{}, err
+	}
+
+	programA, programB := tokenProgramOrDefault(params.TokenProgramA), tokenProgramOrDefault(params.TokenProgramB)
+	accountA, err := creatorAccountOrDefault(params.CreatorAccountA, params.Creator, params.MintA, programA)
+	if err != nil {
+		return nil, CPMMPoolAddresses{}, err
+	}
+	accountB, err := creatorAccountOrDefault(params.CreatorAccountB, params.Creator, params.MintB, programB)
+	if err != nil {
+		return nil, CPMMPoolAddresses{}, err
+	}
+	amount0, amount1 := params.AmountA, params.AmountB
+	if !addresses.Token0Mint.Equals(params.MintA) {
+		amount0, amount1 = amount1, amount0
+		accountA, accountB = accountB, accountA
+		programA, programB = programB, programA
+	}
+
+	creatorLP, _, err := sol.FindAssociatedTokenAddress(params.Creator, addresses.LPMint)
+	if err != nil {
+		return nil, CPMMPoolAddresses{}, err
+	}
+
+	data := make([]byte, 32)
+	copy(data[0:8], CPMMInitializeDiscriminator)
+	binary.LittleEndian.PutUint64(data[8:16], amount0)
+	binary.LittleEndian.PutUint64(data[16:24], amount1)
+	binary.LittleEndian.PutUint64(data[24:32], params.OpenTime)
+
+	return solana.NewInstruction(
+		RAYDIUM_CPMM_PROGRAM_ID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(params.Creator, true, true),
+			solana.NewAccountMeta(ammConfig, false, false),
+			solana.NewAccountMeta(addresses.Authority, false, false),
+			solana.NewAccountMeta(addresses.Pool, true, false),
+			solana.NewAccountMeta(addresses.Token0Mint, false, false),
+			solana.NewAccountMeta(addresses.Token1Mint, false, false),
+			solana.NewAccountMeta(addresses.LPMint, true, false),
+			solana.NewAccountMeta(accountA, true, false),
+			solana.NewAccountMeta(accountB, true, false),
+			solana.NewAccountMeta(creatorLP, true, false),
+			solana.NewAccountMeta(addresses.Token0Vault, true, false),
+			solana.NewAccountMeta(addresses.Token1Vault, true, false),
+			solana.NewAccountMeta(CPMM_CREATE_POOL_FEE_RECEIVER, true, false),
+			solana.NewAccountMeta(addresses.Observation, true, false),
+			solana.NewAccountMeta(solana.TokenProgramID, false, false),
+			solana.NewAccountMeta(programA, false, false),
+			solana.NewAccountMeta(programB, false, false),
+			solana.NewAccountMeta(solana.SPLAssociatedTokenAccountProgramID, false, false),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+			solana.NewAccountMeta(solana.SysVarRentPubkey, false, false),
+		},
+		data,
+	), addresses, nil
+}
+
+// sortMints orders a mint pair the way CPMM pools store it, by raw key bytes
+func sortMints(a, b solana.PublicKey) (solana.PublicKey, solana.PublicKey) {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		return b, a
+	}
+	return a, b
+}
+
+func tokenProgramOrDefault(program solana.PublicKey) solana.PublicKey {
+	if program.IsZero() {
+		return solana.TokenProgramID
+	}
+	return program
+}
+
+func creatorAccountOrDefault(account, creator, mint, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	if !account.IsZero() {
+		return account, nil
+	}
+	ata, _, err := sol.FindAssociatedTokenAddressWithProgram(creator, mint, tokenProgram)
+	return ata, err
+}
@@ -0,0 +1,113 @@
+package raydium
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeAMMSwap parses a Raydium AMM v4 swap_base_in or swap_base_out
+// instruction. Both the 18 account layout with the open book market and the
+// 17 account layout without target orders are accepted; the user accounts
+// are always the last three
+func DecodeAMMSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if len(data) == 0 || (data[0] != 9 && data[0] != 11) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 17 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 17); err != nil {
+		return nil, err
+	}
+
+	n := len(accounts)
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameRaydiumAmm,
+		Pool:              accounts[1].PublicKey,
+		UserInputAccount:  accounts[n-3].PublicKey,
+		UserOutputAccount: accounts[n-2].PublicKey,
+		User:              accounts[n-1].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[1:9])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[9:17])),
+		// swap_base_out takes max_amount_in and the exact amount_out
+		ExactOutput: data[0] == 11,
+	}
+	return params, nil
+}
+
+// DecodeCPMMSwap parses a Raydium CPMM swap_base_input or swap_base_output
+// instruction
+func DecodeCPMMSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	exactOutput := bytes.HasPrefix(data, SwapBaseOutputDiscriminator)
+	if !exactOutput && !bytes.HasPrefix(data, SwapBaseInputDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 24 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 13); err != nil {
+		return nil, err
+	}
+
+	first := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16]))
+	second := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24]))
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameRaydiumCpmm,
+		User:              accounts[0].PublicKey,
+		Pool:              accounts[3].PublicKey,
+		UserInputAccount:  accounts[4].PublicKey,
+		UserOutputAccount: accounts[5].PublicKey,
+		InputMint:         accounts[10].PublicKey,
+		OutputMint:        accounts[11].PublicKey,
+		AmountIn:          first,
+		MinAmountOut:      second,
+		ExactOutput:       exactOutput,
+	}
+	return params, nil
+}
+
+// DecodeCLMMSwap parses a Raydium CLMM swap_v2 or legacy swap instruction.
+// When is_base_input is false the amount is the exact output and the threshold
+// the maximum input. The legacy swap does not name the mints
+func DecodeCLMMSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	legacy := bytes.HasPrefix(data, CLMMLegacySwapDiscriminator)
+	if !legacy && !bytes.HasPrefix(data, CLMMSwapDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < 41 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	minAccounts := 13
+	if legacy {
+		minAccounts = 10
+	}
+	if err := pkg.CheckSwapAccounts(accounts, minAccounts); err != nil {
+		return nil, err
+	}
+
+	amount := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16]))
+	threshold := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[16:24]))
+	params := &pkg.SwapParams{
+		Protocol:          pkg.ProtocolNameRaydiumClmm,
+		User:              accounts[0].PublicKey,
+		Pool:              accounts[2].PublicKey,
+		UserInputAccount:  accounts[3].PublicKey,
+		UserOutputAccount: accounts[4].PublicKey,
+		AmountIn:          amount,
+		MinAmountOut:      threshold,
+	}
+	if !legacy {
+		params.InputMint = accounts[11].PublicKey
+		params.OutputMint = accounts[12].PublicKey
+	}
+	if data[40] == 0 {
+		params.AmountIn, params.MinAmountOut = threshold, amount
+		params.ExactOutput = true
+	}
+	return params, nil
+}
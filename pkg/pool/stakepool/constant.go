@@ -0,0 +1,45 @@
+// Package stakepool routes through SPL stake pools as swaps: depositing SOL
+// mints the pool's liquid staking token and withdrawing SOL redeems it, both
+// at the pool's exchange rate, so LSTs such as jitoSOL can be minted or
+// redeemed when that beats their secondary market
+package stakepool
+
+import "github.com/gagliardetto/solana-go"
+
+var (
+	// ProgramID is the SPL stake pool program, which Jito's pool also runs on
+	ProgramID = solana.MustPublicKeyFromBase58("SPoo1Ku8WFXoNDMHPsrGSTSG1Y47rzgn41SLUNakuHy")
+
+	// JitoStakePool is the stake pool behind jitoSOL
+	JitoStakePool = solana.MustPublicKeyFromBase58("Jito4APyf642JPZPx3hGc6WWJ8zPKtRbRs4P815Awbb")
+	// JitoSOLMint is the jitoSOL mint
+	JitoSOLMint = solana.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn")
+
+	// StakeProgramID is the native stake program
+	StakeProgramID = solana.MustPublicKeyFromBase58("Stake11111111111111111111111111111111111111")
+	// StakeHistorySysvar is the stake history sysvar read by withdrawals
+	StakeHistorySysvar = solana.MustPublicKeyFromBase58("SysvarStakeHistory1111111111111111111111111")
+)
+
+const (
+	// DepositSolWithSlippage and WithdrawSolWithSlippage are the instruction
+	// tags of the SOL deposit and withdrawal that enforce a minimum output
+	DepositSolWithSlippage  = 25
+	WithdrawSolWithSlippage = 26
+
+	// accountTypeStakePool is the leading byte of a stake pool account
+	accountTypeStakePool = 1
+
+	// PoolMintOffset is where the pool mint sits in a stake pool account
+	PoolMintOffset = 162
+
+	// withdrawAuthoritySeed derives the pool's withdraw authority
+	withdrawAuthoritySeed = "withdraw"
+
+	// referralFeeDenominator is the denominator of the SOL referral fee, a percentage
+	referralFeeDenominator = 100
+
+	// stakeMetaRentOffset is where the rent exempt reserve of an initialized
+	// or delegated stake account sits, after the u32 state tag
+	stakeMetaRentOffset = 4
+)
@@ -0,0 +1,77 @@
+package stakepool
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	// depositSol and withdrawSol are the SOL deposit and withdrawal without
+	// a minimum output
+	depositSol  = 14
+	withdrawSol = 16
+)
+
+// DecodeSwap parses a SOL deposit or withdrawal, with or without slippage
+// protection, as a swap between SOL and the pool token. The SOL side is the
+// user's system account, named by the WSOL mint
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if len(data) == 0 {
+		return nil, pkg.ErrNotSwap
+	}
+	var deposit, slippage bool
+	switch data[0] {
+	case depositSol:
+		deposit = true
+	case DepositSolWithSlippage:
+		deposit, slippage = true, true
+	case withdrawSol:
+	case WithdrawSolWithSlippage:
+		slippage = true
+	default:
+		return nil, pkg.ErrNotSwap
+	}
+	size := 9
+	if slippage {
+		size = 17
+	}
+	if len(data) < size {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:     pkg.ProtocolNameSPLStakePool,
+		AmountIn:     math.NewIntFromUint64(binary.LittleEndian.Uint64(data[1:9])),
+		MinAmountOut: math.ZeroInt(),
+	}
+	if slippage {
+		params.MinAmountOut = math.NewIntFromUint64(binary.LittleEndian.Uint64(data[9:17]))
+	}
+	if deposit {
+		if err := pkg.CheckSwapAccounts(accounts, 10); err != nil {
+			return nil, err
+		}
+		params.Pool = accounts[0].PublicKey
+		params.User = accounts[3].PublicKey
+		params.UserInputAccount = accounts[3].PublicKey
+		params.UserOutputAccount = accounts[4].PublicKey
+		params.InputMint = sol.WSOL
+		params.OutputMint = accounts[7].PublicKey
+		return params, nil
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 12); err != nil {
+		return nil, err
+	}
+	params.Pool = accounts[0].PublicKey
+	params.User = accounts[2].PublicKey
+	params.UserInputAccount = accounts[3].PublicKey
+	params.UserOutputAccount = accounts[5].PublicKey
+	params.InputMint = accounts[7].PublicKey
+	params.OutputMint = sol.WSOL
+	return params, nil
+}
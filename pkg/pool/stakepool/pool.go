@@ -0,0 +1,400 @@
+package stakepool
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	stdmath "math"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Fee is a stake pool fee, the fraction Numerator / Denominator
+type Fee struct {
+	Denominator uint64
+	Numerator   uint64
+}
+
+// apply returns the fee on amount rounded up, as the program charges it
+func (f Fee) apply(amount math.Int) math.Int {
+	if f.Denominator == 0 {
+		return math.ZeroInt()
+	}
+	denominator := math.NewIntFromUint64(f.Denominator)
+	return amount.Mul(math.NewIntFromUint64(f.Numerator)).Add(denominator).SubRaw(1).Quo(denominator)
+}
+
+// StakePool is an SPL stake pool traded as a pool between SOL and its pool
+// token. SOL moves as native lamports, so routes need no WSOL around it
+type StakePool struct {
+	PoolId solana.PublicKey
+	// Program is the stake pool program the pool runs on, ProgramID when
+	// zero. Forks such as Sanctum's run the same layout and instructions
+	Program               solana.PublicKey
+	Manager               solana.PublicKey
+	Staker                solana.PublicKey
+	StakeDepositAuthority solana.PublicKey
+	StakeWithdrawBumpSeed uint8
+	ValidatorList         solana.PublicKey
+	ReserveStake          solana.PublicKey
+	PoolMint              solana.PublicKey
+	ManagerFeeAccount     solana.PublicKey
+	TokenProgramID        solana.PublicKey
+	TotalLamports         uint64
+	PoolTokenSupply       uint64
+	LastUpdateEpoch       uint64
+
+	// SolDepositAuthority and SolWithdrawAuthority, when set, must co-sign
+	// SOL deposits and withdrawals, which closes them to routing
+	SolDepositAuthority  *solana.PublicKey
+	SolDepositFee        Fee
+	SolReferralFee       uint8
+	SolWithdrawAuthority *solana.PublicKey
+	SolWithdrawalFee     Fee
+	// StakeWithdrawalFee is charged on pool tokens withdrawn as stake
+	StakeWithdrawalFee Fee
+
+	// ReserveAvailable is how many lamports the reserve stake can pay out
+	// while staying rent exempt, as of the last quote
+	ReserveAvailable uint64
+	// Epoch is the cluster epoch seen by the last quote
+	Epoch uint64
+}
+
+func (pool *StakePool) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameSPLStakePool
+}
+
+func (pool *StakePool) GetProgramID() solana.PublicKey {
+	if pool.Program.IsZero() {
+		return ProgramID
+	}
+	return pool.Program
+}
+
+func (pool *StakePool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns the pool token as base and SOL, by its WSOL mint, as quote
+func (pool *StakePool) GetTokens() (string, string) {
+	return pool.PoolMint.String(), sol.WSOL.String()
+}
+
+// UsesNativeSOL reports that deposits take and withdrawals pay native lamports
+func (pool *StakePool) UsesNativeSOL(mint string) bool {
+	return mint == sol.WSOL.String()
+}
+
+// Decode parses a stake pool account. The layout has optional fields, so
+// everything after the fee schedule is read in sequence
+func (pool *StakePool) Decode(data []byte) error {
+	r := &reader{data: data}
+	if accountType := r.u8(); accountType != accountTypeStakePool {
+		return fmt.Errorf("account type %d is not a stake pool", accountType)
+	}
+	pool.Manager = r.key()
+	pool.Staker = r.key()
+	pool.StakeDepositAuthority = r.key()
+	pool.StakeWithdrawBumpSeed = r.u8()
+	pool.ValidatorList = r.key()
+	pool.ReserveStake = r.key()
+	pool.PoolMint = r.key()
+	pool.ManagerFeeAccount = r.key()
+	pool.TokenProgramID = r.key()
+	pool.TotalLamports = r.u64()
+	pool.PoolTokenSupply = r.u64()
+	pool.LastUpdateEpoch = r.u64()
+	r.skip(48) // lockup
+	r.fee()    // epoch_fee
+	r.futureFee()
+	r.optionalKey() // preferred deposit validator
+	r.optionalKey() // preferred withdraw validator
+	r.fee()         // stake_deposit_fee
+	pool.StakeWithdrawalFee = r.fee()
+	r.futureFee()
+	r.u8() // stake_referral_fee
+	pool.SolDepositAuthority = r.optionalKey()
+	pool.SolDepositFee = r.fee()
+	pool.SolReferralFee = r.u8()
+	pool.SolWithdrawAuthority = r.optionalKey()
+	pool.SolWithdrawalFee = r.fee()
+	if r.err != nil {
+		return fmt.Errorf("failed to decode stake pool: %w", r.err)
+	}
+	return nil
+}
+
+// Quote refreshes the pool, its reserve and the epoch, then prices a SOL
+// deposit or a pool token withdrawal at the pool's exchange rate
+func (pool *StakePool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.PoolId, pool.ReserveStake, solana.SysVarClockPubkey})
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	data, ok := sol.AccountData(results, 0)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("stake pool %s not found", pool.PoolId)
+	}
+	if err := pool.Decode(data); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserve, ok := sol.AccountData(results, 1)
+	if !ok || len(reserve) < stakeMetaRentOffset+8 {
+		return math.ZeroInt(), fmt.Errorf("reserve stake %s not found", pool.ReserveStake)
+	}
+	// keep a lamport above rent, as older program versions require
+	minimumReserve := binary.LittleEndian.Uint64(reserve[stakeMetaRentOffset:]) + 1
+	pool.ReserveAvailable = 0
+	if lamports := results.Value[1].Lamports; lamports > minimumReserve {
+		pool.ReserveAvailable = lamports - minimumReserve
+	}
+	clockData, ok := sol.AccountData(results, 2)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock sysvar not found")
+	}
+	clock, err := sol.ParseClock(clockData)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	pool.Epoch = clock.Epoch
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// ComputeAmountOut prices inputAmount from the cached state with the
+// program's integer math. A deposit's referral share of the fee is paid to
+// the depositor's own account on top of the returned amount
+func (pool *StakePool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if pool.LastUpdateEpoch < pool.Epoch {
+		return math.ZeroInt(), fmt.Errorf("stake pool %s is not updated for epoch %d", pool.PoolId, pool.Epoch)
+	}
+	supply := math.NewIntFromUint64(pool.PoolTokenSupply)
+	total := math.NewIntFromUint64(pool.TotalLamports)
+
+	switch inputMint {
+	case sol.WSOL.String():
+		if pool.SolDepositAuthority != nil {
+			return math.ZeroInt(), fmt.Errorf("stake pool %s restricts SOL deposits to %s", pool.PoolId, pool.SolDepositAuthority)
+		}
+		minted := inputAmount
+		if !total.IsZero() && !supply.IsZero() {
+			minted = inputAmount.Mul(supply).Quo(total)
+		}
+		userTokens := minted.Sub(pool.SolDepositFee.apply(minted))
+		if !userTokens.IsPositive() {
+			return math.ZeroInt(), fmt.Errorf("deposit of %s lamports is too small", inputAmount)
+		}
+		return userTokens, nil
+	case pool.PoolMint.String():
+		if pool.SolWithdrawAuthority != nil {
+			return math.ZeroInt(), fmt.Errorf("stake pool %s restricts SOL withdrawals to %s", pool.PoolId, pool.SolWithdrawAuthority)
+		}
+		if supply.IsZero() {
+			return math.ZeroInt(), fmt.Errorf("stake pool %s has no pool tokens", pool.PoolId)
+		}
+		burnt := inputAmount.Sub(pool.SolWithdrawalFee.apply(inputAmount))
+		lamports := burnt.Mul(total).Quo(supply)
+		if !lamports.IsPositive() {
+			return math.ZeroInt(), fmt.Errorf("withdrawal of %s pool tokens is too small", inputAmount)
+		}
+		if lamports.GT(math.NewIntFromUint64(pool.ReserveAvailable)) {
+			return math.ZeroInt(), fmt.Errorf("withdrawal of %s lamports exceeds the %d the reserve can pay", lamports, pool.ReserveAvailable)
+		}
+		return lamports, nil
+	}
+	return math.ZeroInt(), fmt.Errorf("mint %s is not traded by stake pool %s", inputMint, pool.PoolId)
+}
+
+func (pool *StakePool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amounts exceed uint64")
+	}
+	programID := pool.GetProgramID()
+	withdrawAuthority, _, err := sol.FindProgramAddress([][]byte{pool.PoolId[:], []byte(withdrawAuthoritySeed)}, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive withdraw authority: %w", err)
+	}
+	data := make([]byte, 17)
+	binary.LittleEndian.PutUint64(data[1:], inputAmount.Uint64())
+	binary.LittleEndian.PutUint64(data[9:], minOut.Uint64())
+
+	switch inputMint {
+	case sol.WSOL.String():
+		data[0] = DepositSolWithSlippage
+		accounts := solana.AccountMetaSlice{
+			solana.Meta(pool.PoolId).WRITE(),
+			solana.Meta(withdrawAuthority),
+			solana.Meta(pool.ReserveStake).WRITE(),
+			solana.Meta(user).WRITE().SIGNER(),
+			solana.Meta(userBaseAccount).WRITE(),
+			solana.Meta(pool.ManagerFeeAccount).WRITE(),
+			// the depositor refers themselves, taking the referral share of the fee
+			solana.Meta(userBaseAccount).WRITE(),
+			solana.Meta(pool.PoolMint).WRITE(),
+			solana.Meta(solana.SystemProgramID),
+			solana.Meta(pool.TokenProgramID),
+		}
+		return []solana.Instruction{solana.NewInstruction(programID, accounts, data)}, nil
+	case pool.PoolMint.String():
+		data[0] = WithdrawSolWithSlippage
+		accounts := solana.AccountMetaSlice{
+			solana.Meta(pool.PoolId).WRITE(),
+			solana.Meta(withdrawAuthority),
+			solana.Meta(user).SIGNER(),
+			solana.Meta(userBaseAccount).WRITE(),
+			solana.Meta(pool.ReserveStake).WRITE(),
+			solana.Meta(user).WRITE(),
+			solana.Meta(pool.ManagerFeeAccount).WRITE(),
+			solana.Meta(pool.PoolMint).WRITE(),
+			solana.Meta(solana.SysVarClockPubkey),
+			solana.Meta(StakeHistorySysvar),
+			solana.Meta(StakeProgramID),
+			solana.Meta(pool.TokenProgramID),
+		}
+		return []solana.Instruction{solana.NewInstruction(programID, accounts, data)}, nil
+	}
+	return nil, fmt.Errorf("mint %s is not traded by stake pool %s", inputMint, pool.PoolId)
+}
+
+// swapPrefix is the instruction tag used for inputMint
+func (pool *StakePool) swapPrefix(inputMint string) []byte {
+	if inputMint == sol.WSOL.String() {
+		return []byte{DepositSolWithSlippage}
+	}
+	return []byte{WithdrawSolWithSlippage}
+}
+
+// DecodeMinOut reads minimum_pool_tokens_out or minimum_lamports_out back
+// from the deposit or withdrawal
+func (pool *StakePool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, pool.GetProgramID(), pool.swapPrefix(inputMint), 9)
+}
+
+// SwapAmountFields locates the amount in and minimum out, which both the
+// deposit and the withdrawal carry right after their tag
+func (pool *StakePool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	prefix := pool.swapPrefix(inputMint)
+	programID := pool.GetProgramID()
+	return pkg.AmountField{ProgramID: programID, Prefix: prefix, Offset: 1},
+		pkg.AmountField{ProgramID: programID, Prefix: prefix, Offset: 9}
+}
+
+// SwapFee returns the SOL deposit or withdrawal fee, at the input's value
+func (pool *StakePool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	if inputMint == sol.WSOL.String() {
+		return pool.SolDepositFee.apply(inputAmount)
+	}
+	return pool.SolWithdrawalFee.apply(inputAmount)
+}
+
+// MaxInputForImpact returns the largest input the pool takes: the exchange
+// rate does not move with size, so only the reserve bounds withdrawals
+func (pool *StakePool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if inputMint == sol.WSOL.String() {
+		return math.NewIntFromUint64(stdmath.MaxUint64), nil
+	}
+	if pool.TotalLamports == 0 {
+		return math.ZeroInt(), fmt.Errorf("stake pool %s has no lamports", pool.PoolId)
+	}
+	return math.NewIntFromUint64(pool.ReserveAvailable).Mul(math.NewIntFromUint64(pool.PoolTokenSupply)).Quo(math.NewIntFromUint64(pool.TotalLamports)), nil
+}
+
+// SolValue is what poolTokens withdraw as stake after the stake withdrawal
+// fee, the value Sanctum's SOL value calculators give them, rounded down
+func (pool *StakePool) SolValue(poolTokens math.Int) math.Int {
+	if pool.PoolTokenSupply == 0 {
+		return math.ZeroInt()
+	}
+	burnt := poolTokens.Sub(pool.StakeWithdrawalFee.apply(poolTokens))
+	if !burnt.IsPositive() {
+		return math.ZeroInt()
+	}
+	return burnt.Mul(math.NewIntFromUint64(pool.TotalLamports)).Quo(math.NewIntFromUint64(pool.PoolTokenSupply))
+}
+
+// PoolTokensForSolValue is the most pool tokens whose SolValue does not
+// exceed lamports
+func (pool *StakePool) PoolTokensForSolValue(lamports math.Int) math.Int {
+	if pool.TotalLamports == 0 {
+		return math.ZeroInt()
+	}
+	burnt := lamports.Mul(math.NewIntFromUint64(pool.PoolTokenSupply)).Quo(math.NewIntFromUint64(pool.TotalLamports))
+	fee := pool.StakeWithdrawalFee
+	if fee.Denominator == 0 || fee.Numerator == 0 {
+		return burnt
+	}
+	if fee.Numerator >= fee.Denominator {
+		return math.ZeroInt()
+	}
+	// the fee is rounded up, so grossing up rounded down stays within lamports
+	denominator := math.NewIntFromUint64(fee.Denominator)
+	return burnt.Mul(denominator).Quo(denominator.Sub(math.NewIntFromUint64(fee.Numerator)))
+}
+
+// reader reads a stake pool account field by field, remembering the first
+// out of range read
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if r.offset+n > len(r.data) {
+		r.err = fmt.Errorf("account data too short: %d bytes", len(r.data))
+		return make([]byte, n)
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *reader) skip(n int) { r.next(n) }
+
+func (r *reader) u8() uint8 { return r.next(1)[0] }
+
+func (r *reader) u64() uint64 { return binary.LittleEndian.Uint64(r.next(8)) }
+
+func (r *reader) key() solana.PublicKey { return solana.PublicKeyFromBytes(r.next(32)) }
+
+func (r *reader) fee() Fee {
+	return Fee{Denominator: r.u64(), Numerator: r.u64()}
+}
+
+// optionalKey reads a borsh Option<Pubkey>
+func (r *reader) optionalKey() *solana.PublicKey {
+	if r.u8() == 0 {
+		return nil
+	}
+	key := r.key()
+	return &key
+}
+
+// futureFee skips a FutureEpoch<Fee>: no fee, or one taking effect in one or
+// two epochs
+func (r *reader) futureFee() {
+	if r.u8() != 0 {
+		r.fee()
+	}
+}
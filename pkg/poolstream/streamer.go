@@ -0,0 +1,273 @@
+// Package poolstream keeps streamed pools current from websocket account
+// subscriptions, so their quotes read tick and bin arrays from memory
+// instead of refetching them. A Geyser feed can drive the same pools by
+// calling their ApplyAccountUpdate directly
+package poolstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	DefaultResyncInterval = 5 * time.Minute
+	DefaultRetryDelay     = 2 * time.Second
+
+	// maxMultipleAccounts is the getMultipleAccounts batch limit
+	maxMultipleAccounts = 100
+)
+
+// errMoved ends a subscription round when a pool needs other accounts
+var errMoved = errors.New("pool moved past its followed accounts")
+
+// Streamer follows the accounts of streamed pools over websocket. Each round
+// subscribes to the accounts every pool asks for, loads them once with
+// getMultipleAccounts so quotes can trust the cache, then applies updates as
+// they arrive. A round ends after ResyncInterval, when a pool moves past its
+// accounts, or when the websocket fails, and the next one starts over
+type Streamer struct {
+	client     *sol.Client
+	wsEndpoint string
+
+	ResyncInterval time.Duration
+	RetryDelay     time.Duration
+
+	mu      sync.Mutex
+	pools   map[string]pkg.StreamedPool
+	changed chan struct{}
+}
+
+// NewStreamer creates a streamer subscribing over wsEndpoint and loading
+// accounts through solClient
+func NewStreamer(solClient *sol.Client, wsEndpoint string) *Streamer {
+	return &Streamer{
+		client:         solClient,
+		wsEndpoint:     wsEndpoint,
+		ResyncInterval: DefaultResyncInterval,
+		RetryDelay:     DefaultRetryDelay,
+		pools:          make(map[string]pkg.StreamedPool),
+		changed:        make(chan struct{}, 1),
+	}
+}
+
+// Add streams the pools that support it from the next round and returns how
+// many did. Pools are added before they are quoted concurrently
+func (s *Streamer) Add(pools ...pkg.Pool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := 0
+	for _, pool := range pools {
+		streamed, ok := pool.(pkg.StreamedPool)
+		if !ok {
+			continue
+		}
+		if _, known := s.pools[pool.GetID()]; !known {
+			streamed.StreamAccounts()
+			s.pools[pool.GetID()] = streamed
+			added++
+		}
+	}
+	if added > 0 {
+		s.notify()
+	}
+	return added
+}
+
+// Remove stops streaming the pool with id from the next round, resetting it
+// so its quotes fetch as before
+func (s *Streamer) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pool, ok := s.pools[id]
+	if !ok {
+		return
+	}
+	delete(s.pools, id)
+	if resettable, ok := pool.(pkg.ResettablePool); ok {
+		resettable.Reset()
+	}
+	s.notify()
+}
+
+// Run streams the pools until ctx is cancelled
+func (s *Streamer) Run(ctx context.Context) error {
+	for {
+		err := s.round(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil || errors.Is(err, errMoved) {
+			continue
+		}
+		log.Printf("poolstream: %v, retrying in %s", err, s.retryDelay())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.retryDelay()):
+		}
+	}
+}
+
+// round subscribes to the accounts of every pool and applies their updates
+// until the round ends
+func (s *Streamer) round(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.resyncInterval())
+	defer cancel()
+
+	followers := s.follow()
+	if len(followers) == 0 {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.changed:
+			return nil
+		}
+	}
+
+	client, err := ws.Connect(ctx, s.wsEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer client.Close()
+
+	errCh := make(chan error, 1)
+	report := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
+	// subscribe before loading so no update between the two is lost
+	for account, pools := range followers {
+		sub, err := client.AccountSubscribe(account, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", account, err)
+		}
+		go func(account solana.PublicKey, pools []pkg.StreamedPool) {
+			defer sub.Unsubscribe()
+			for {
+				result, err := sub.Recv(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						report(err)
+					}
+					return
+				}
+				var data []byte
+				// a closed account is pushed empty with no lamports
+				if result.Value.Account.Lamports > 0 {
+					data = result.Value.Account.Data.GetBinary()
+				}
+				if s.apply(account, pools, data, result.Context.Slot) {
+					report(errMoved)
+				}
+			}
+		}(account, pools)
+	}
+	if err := s.load(ctx, followers); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-s.changed:
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// follow asks every pool for the accounts it needs now and groups the pools
+// by account
+func (s *Streamer) follow() map[solana.PublicKey][]pkg.StreamedPool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// changes up to now are picked up by this round
+	select {
+	case <-s.changed:
+	default:
+	}
+	followers := make(map[solana.PublicKey][]pkg.StreamedPool)
+	for _, pool := range s.pools {
+		for _, account := range pool.StreamAccounts() {
+			followers[account] = append(followers[account], pool)
+		}
+	}
+	return followers
+}
+
+// load fetches every followed account once, in batches, and applies it
+func (s *Streamer) load(ctx context.Context, followers map[solana.PublicKey][]pkg.StreamedPool) error {
+	accounts := make([]solana.PublicKey, 0, len(followers))
+	for account := range followers {
+		accounts = append(accounts, account)
+	}
+	moved := false
+	for start := 0; start < len(accounts); start += maxMultipleAccounts {
+		batch := accounts[start:min(start+maxMultipleAccounts, len(accounts))]
+		results, err := s.client.GetMultipleAccountsWithOpts(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to load followed accounts: %w", err)
+		}
+		for i, account := range batch {
+			// a missing account is applied as nil, so quotes know it is empty
+			data, _ := sol.AccountData(results, i)
+			if s.apply(account, followers[account], data, results.Context.Slot) {
+				moved = true
+			}
+		}
+	}
+	if moved {
+		return errMoved
+	}
+	return nil
+}
+
+// apply passes an account update to the pools following it and reports
+// whether any of them moved past its accounts. Pools that reject the update
+// log it and fetch as before until the next round
+func (s *Streamer) apply(account solana.PublicKey, pools []pkg.StreamedPool, data []byte, slot uint64) bool {
+	moved := false
+	for _, pool := range pools {
+		changed, err := pool.ApplyAccountUpdate(account, data, slot)
+		if err != nil {
+			log.Printf("poolstream: failed to apply %s: %v", account, err)
+			continue
+		}
+		moved = moved || changed
+	}
+	return moved
+}
+
+// notify wakes Run to start a new round; it never blocks
+func (s *Streamer) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Streamer) resyncInterval() time.Duration {
+	if s.ResyncInterval <= 0 {
+		return DefaultResyncInterval
+	}
+	return s.ResyncInterval
+}
+
+func (s *Streamer) retryDelay() time.Duration {
+	if s.RetryDelay <= 0 {
+		return DefaultRetryDelay
+	}
+	return s.RetryDelay
+}
@@ -0,0 +1,333 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+const (
+	DefaultPollInterval   = 2 * time.Second
+	DefaultRescanInterval = 5 * time.Minute
+
+	// maxMultipleAccounts is the getMultipleAccounts batch limit
+	maxMultipleAccounts = 100
+)
+
+// token2022ProgramID owns Token-2022 accounts, scanned alongside the classic token program
+var token2022ProgramID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+
+// Balance is the balance of one account owned by a watched wallet
+type Balance struct {
+	Wallet  solana.PublicKey
+	Account solana.PublicKey
+	// Mint is zero for the wallet's native SOL balance
+	Mint   solana.PublicKey
+	Amount uint64
+	Slot   uint64
+}
+
+// Native reports whether the balance is native lamports rather than a token account
+func (b Balance) Native() bool {
+	return b.Mint.IsZero()
+}
+
+// Change is a balance update together with the amount it replaced
+type Change struct {
+	Balance
+	Previous uint64
+}
+
+// Watcher keeps the SOL and token balances of many wallets up to date. It
+// discovers token accounts with one getTokenAccountsByOwner scan per wallet
+// and RescanInterval, follows them over websocket account subscriptions, and
+// falls back to batched getMultipleAccounts polling while the websocket is down
+type Watcher struct {
+	client     *sol.Client
+	wsEndpoint string
+	wallets    []solana.PublicKey
+
+	PollInterval   time.Duration
+	RescanInterval time.Duration
+
+	mu       sync.RWMutex
+	balances map[solana.PublicKey]Balance
+
+	subsMu      sync.Mutex
+	subscribers map[int]chan Change
+	nextSub     int
+}
+
+// NewWatcher creates a watcher for wallets. An empty wsEndpoint disables
+// subscriptions and polls only
+func NewWatcher(solClient *sol.Client, wsEndpoint string, wallets ...solana.PublicKey) *Watcher {
+	return &Watcher{
+		client:         solClient,
+		wsEndpoint:     wsEndpoint,
+		wallets:        wallets,
+		PollInterval:   DefaultPollInterval,
+		RescanInterval: DefaultRescanInterval,
+		balances:       make(map[solana.PublicKey]Balance),
+		subscribers:    make(map[int]chan Change),
+	}
+}
+
+// Run keeps balances updated until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	for {
+		if err := w.Rescan(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("portfolio: rescan failed: %v", err)
+		}
+		deadline := time.Now().Add(w.rescanInterval())
+
+		if w.wsEndpoint != "" {
+			err := w.stream(ctx, deadline)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == nil {
+				continue
+			}
+			log.Printf("portfolio: websocket failed, polling until next rescan: %v", err)
+		}
+		if err := w.poll(ctx, deadline); err != nil {
+			return err
+		}
+	}
+}
+
+// Snapshot returns every known balance, ordered by wallet then account
+func (w *Watcher) Snapshot() []Balance {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	balances := make([]Balance, 0, len(w.balances))
+	for _, balance := range w.balances {
+		balances = append(balances, balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Wallet != balances[j].Wallet {
+			return balances[i].Wallet.String() < balances[j].Wallet.String()
+		}
+		return balances[i].Account.String() < balances[j].Account.String()
+	})
+	return balances
+}
+
+// Balance returns the wallet's total balance of mint; a zero mint means native SOL
+func (w *Watcher) Balance(wallet, mint solana.PublicKey) uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	total := uint64(0)
+	for _, balance := range w.balances {
+		if balance.Wallet.Equals(wallet) && balance.Mint.Equals(mint) {
+			total += balance.Amount
+		}
+	}
+	return total
+}
+
+// Subscribe streams balance changes. Changes are dropped for subscribers
+// whose buffer is full; call the returned function to unsubscribe
+func (w *Watcher) Subscribe(buffer int) (<-chan Change, func()) {
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+
+	id := w.nextSub
+	w.nextSub++
+	ch := make(chan Change, buffer)
+	w.subscribers[id] = ch
+	return ch, func() {
+		w.subsMu.Lock()
+		defer w.subsMu.Unlock()
+		if _, ok := w.subscribers[id]; ok {
+			delete(w.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// Rescan reloads every wallet's SOL balance and token accounts
+func (w *Watcher) Rescan(ctx context.Context) error {
+	for _, wallet := range w.wallets {
+		lamports, err := w.client.GetBalance(ctx, wallet, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of %s: %w", wallet, err)
+		}
+		w.update(Balance{Wallet: wallet, Account: wallet, Amount: lamports.Value, Slot: lamports.Context.Slot})
+
+		for _, programID := range []solana.PublicKey{solana.TokenProgramID, token2022ProgramID} {
+			accounts, err := w.client.GetTokenAccountsByOwner(ctx, wallet,
+				&rpc.GetTokenAccountsConfig{ProgramId: programID.ToPointer()},
+				&rpc.GetTokenAccountsOpts{Encoding: solana.EncodingBase64},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to get token accounts of %s: %w", wallet, err)
+			}
+			for _, account := range accounts.Value {
+				if account == nil {
+					continue
+				}
+				data := account.Account.Data.GetBinary()
+				if len(data) < 72 {
+					continue
+				}
+				w.update(Balance{
+					Wallet:  wallet,
+					Account: account.Pubkey,
+					Mint:    solana.PublicKeyFromBytes(data[:32]),
+					Amount:  binary.LittleEndian.Uint64(data[64:72]),
+					Slot:    accounts.Context.Slot,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// stream follows every known account over websocket until deadline
+func (w *Watcher) stream(ctx context.Context, deadline time.Time) error {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	client, err := ws.Connect(ctx, w.wsEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer client.Close()
+
+	errCh := make(chan error, 1)
+	for _, balance := range w.Snapshot() {
+		sub, err := client.AccountSubscribe(balance.Account, rpc.CommitmentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", balance.Account, err)
+		}
+		go func(tracked Balance) {
+			defer sub.Unsubscribe()
+			for {
+				result, err := sub.Recv(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						select {
+						case errCh <- err:
+						default:
+						}
+					}
+					return
+				}
+				w.apply(tracked, &result.Value.Account, result.Context.Slot)
+			}
+		}(balance)
+	}
+
+	select {
+	case <-ctx.Done():
+		if time.Now().Before(deadline) {
+			return ctx.Err()
+		}
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// poll refreshes every known account in batches until deadline
+func (w *Watcher) poll(ctx context.Context, deadline time.Time) error {
+	ticker := time.NewTicker(w.pollInterval())
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		tracked := w.Snapshot()
+		for start := 0; start < len(tracked); start += maxMultipleAccounts {
+			end := min(start+maxMultipleAccounts, len(tracked))
+			keys := make([]solana.PublicKey, 0, end-start)
+			for _, balance := range tracked[start:end] {
+				keys = append(keys, balance.Account)
+			}
+			results, err := w.client.GetMultipleAccountsWithOpts(ctx, keys)
+			if err != nil {
+				log.Printf("portfolio: poll failed: %v", err)
+				break
+			}
+			for i, balance := range tracked[start:end] {
+				if i < len(results.Value) {
+					w.apply(balance, results.Value[i], results.Context.Slot)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// apply records a fresh account state for a tracked balance; a missing
+// account is a closed token account and reads as zero
+func (w *Watcher) apply(tracked Balance, account *rpc.Account, slot uint64) {
+	tracked.Slot = slot
+	tracked.Amount = 0
+	if account != nil {
+		if tracked.Native() {
+			tracked.Amount = account.Lamports
+		} else if data := account.Data.GetBinary(); len(data) >= 72 {
+			tracked.Amount = binary.LittleEndian.Uint64(data[64:72])
+		}
+	}
+	w.update(tracked)
+}
+
+// update stores balance and notifies subscribers if the amount changed
+func (w *Watcher) update(balance Balance) {
+	w.mu.Lock()
+	previous, known := w.balances[balance.Account]
+	if known && balance.Slot < previous.Slot {
+		w.mu.Unlock()
+		return
+	}
+	w.balances[balance.Account] = balance
+	w.mu.Unlock()
+
+	if known && previous.Amount == balance.Amount {
+		return
+	}
+	change := Change{Balance: balance, Previous: previous.Amount}
+
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+	for _, ch := range w.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+func (w *Watcher) pollInterval() time.Duration {
+	if w.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return w.PollInterval
+}
+
+func (w *Watcher) rescanInterval() time.Duration {
+	if w.RescanInterval <= 0 {
+		return DefaultRescanInterval
+	}
+	return w.RescanInterval
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+)
+
+var (
+	// priceScale is one in the fixed point of a LegacyDec
+	priceScale = new(big.Int).Exp(big.NewInt(10), big.NewInt(math.LegacyPrecision), nil)
+	oneX64     = new(big.Int).Lsh(big.NewInt(1), 64)
+	oneX128    = new(big.Int).Lsh(big.NewInt(1), 128)
+)
+
+// RatioPrice returns num / den as a price, or den / num when invert is set
+func RatioPrice(num, den *big.Int, invert bool) (math.LegacyDec, error) {
+	if num.Sign() <= 0 || den.Sign() <= 0 {
+		return math.LegacyDec{}, fmt.Errorf("price is not positive")
+	}
+	if invert {
+		num, den = den, num
+	}
+	scaled := new(big.Int).Mul(num, priceScale)
+	scaled.Quo(scaled, den)
+	if scaled.BitLen() > math.MaxBitLen {
+		return math.LegacyDec{}, fmt.Errorf("price out of range")
+	}
+	return math.LegacyNewDecFromBigIntWithPrec(scaled, math.LegacyPrecision), nil
+}
+
+// PriceFromReserves is the spot price of a constant product pool, quote per
+// base when the input is the base mint
+func PriceFromReserves(base, quote math.Int, inputIsBase bool) (math.LegacyDec, error) {
+	if base.IsNil() || quote.IsNil() {
+		return math.LegacyDec{}, fmt.Errorf("pool reserves not loaded")
+	}
+	return RatioPrice(quote.BigInt(), base.BigInt(), !inputIsBase)
+}
+
+// PriceFromX64 converts a Q64.64 price of quote per base
+func PriceFromX64(priceX64 *big.Int, inputIsBase bool) (math.LegacyDec, error) {
+	return RatioPrice(priceX64, oneX64, !inputIsBase)
+}
+
+// PriceFromSqrtX64 converts the Q64.64 square root of a price of quote per
+// base, as concentrated liquidity pools store it
+func PriceFromSqrtX64(sqrtPriceX64 *big.Int, inputIsBase bool) (math.LegacyDec, error) {
+	return RatioPrice(new(big.Int).Mul(sqrtPriceX64, sqrtPriceX64), oneX128, !inputIsBase)
+}
+
+// ScalePrice converts a raw price in output base units per input base unit
+// to whole output tokens per whole input token
+func ScalePrice(raw math.LegacyDec, inputDecimals, outputDecimals uint8) math.LegacyDec {
+	shift := int64(inputDecimals) - int64(outputDecimals)
+	if shift == 0 {
+		return raw
+	}
+	exponent := shift
+	if exponent < 0 {
+		exponent = -exponent
+	}
+	factor := math.NewIntFromBigInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(exponent), nil))
+	if shift > 0 {
+		return raw.MulInt(factor)
+	}
+	return raw.QuoInt(factor)
+}
@@ -0,0 +1,62 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultCoingeckoEndpoint is the public Coingecko API
+const DefaultCoingeckoEndpoint = "https://api.coingecko.com/api/v3"
+
+// Coingecko reads prices from the Coingecko API. Mints listed in CoinIDs are
+// priced by coin, SOL as "solana" by default, and any other mint by its
+// Solana contract address. APIKey is sent as the demo API key when set
+type Coingecko struct {
+	Endpoint string
+	APIKey   string
+	CoinIDs  map[string]string
+	Client   *http.Client
+}
+
+// NewCoingecko creates a Coingecko feed on the public API
+func NewCoingecko() *Coingecko {
+	return &Coingecko{
+		Endpoint: DefaultCoingeckoEndpoint,
+		CoinIDs:  map[string]string{sol.WSOL.String(): "solana"},
+	}
+}
+
+// USDPrice returns the Coingecko USD price of mint
+func (c *Coingecko) USDPrice(ctx context.Context, mint string) (float64, error) {
+	endpoint := strings.TrimRight(c.Endpoint, "/")
+	key := mint
+	if coinID, ok := c.CoinIDs[mint]; ok {
+		key = coinID
+		endpoint += "/simple/price?" + url.Values{"ids": {coinID}, "vs_currencies": {"usd"}}.Encode()
+	} else {
+		endpoint += "/simple/token_price/solana?" + url.Values{"contract_addresses": {mint}, "vs_currencies": {"usd"}}.Encode()
+	}
+
+	var header http.Header
+	if c.APIKey != "" {
+		header = http.Header{"x-cg-demo-api-key": {c.APIKey}}
+	}
+	var resp map[string]map[string]float64
+	if err := getJSON(ctx, c.Client, endpoint, header, &resp); err != nil {
+		return 0, fmt.Errorf("coingecko: %w", err)
+	}
+	for id, prices := range resp {
+		if !strings.EqualFold(id, key) {
+			continue
+		}
+		if price, ok := prices["usd"]; ok {
+			return price, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %s on coingecko", ErrNoPrice, mint)
+}
@@ -0,0 +1,137 @@
+// Package pricefeed provides USD prices of tokens for reporting, from Pyth or
+// Coingecko or an integrator's own feed
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultHTTPTimeout = 10 * time.Second
+
+// ErrNoPrice is returned by feeds that do not price a mint
+var ErrNoPrice = errors.New("no price for mint")
+
+// Feed returns the USD price of one whole token of mint
+type Feed interface {
+	USDPrice(ctx context.Context, mint string) (float64, error)
+}
+
+// FeedFunc adapts a function to Feed, for integrators with their own prices
+type FeedFunc func(ctx context.Context, mint string) (float64, error)
+
+// USDPrice calls f
+func (f FeedFunc) USDPrice(ctx context.Context, mint string) (float64, error) {
+	return f(ctx, mint)
+}
+
+// Static is a fixed price table, for tests and offline reports
+type Static map[string]float64
+
+// USDPrice returns the fixed price of mint
+func (s Static) USDPrice(ctx context.Context, mint string) (float64, error) {
+	price, ok := s[mint]
+	if !ok {
+		return 0, fmt.Errorf("%w %s", ErrNoPrice, mint)
+	}
+	return price, nil
+}
+
+// Fallback asks each feed in turn and returns the first price found
+func Fallback(feeds ...Feed) Feed {
+	return FeedFunc(func(ctx context.Context, mint string) (float64, error) {
+		errs := make([]error, 0, len(feeds))
+		for _, feed := range feeds {
+			price, err := feed.USDPrice(ctx, mint)
+			if err == nil {
+				return price, nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return 0, fmt.Errorf("%w %s", ErrNoPrice, mint)
+		}
+		return 0, errors.Join(errs...)
+	})
+}
+
+// DefaultCacheTTL is how long Default reuses a price
+const DefaultCacheTTL = time.Minute
+
+// Default prices through Pyth with Coingecko as fallback, cached for DefaultCacheTTL
+func Default() Feed {
+	return NewCached(Fallback(NewPyth(), NewCoingecko()), DefaultCacheTTL)
+}
+
+// Cached remembers the prices of feed for ttl, so a report pricing many
+// trades asks the feed once per mint
+type Cached struct {
+	Feed Feed
+	TTL  time.Duration
+
+	mu     sync.Mutex
+	prices map[string]cachedPrice
+}
+
+type cachedPrice struct {
+	price     float64
+	fetchedAt time.Time
+}
+
+// NewCached wraps feed with a cache of the given ttl
+func NewCached(feed Feed, ttl time.Duration) *Cached {
+	return &Cached{Feed: feed, TTL: ttl, prices: make(map[string]cachedPrice)}
+}
+
+// USDPrice returns the cached price of mint, refreshing it once it is older than TTL
+func (c *Cached) USDPrice(ctx context.Context, mint string) (float64, error) {
+	c.mu.Lock()
+	cached, ok := c.prices[mint]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.TTL {
+		return cached.price, nil
+	}
+
+	price, err := c.Feed.USDPrice(ctx, mint)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.prices[mint] = cachedPrice{price: price, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return price, nil
+}
+
+// getJSON fetches url and decodes its JSON body into out
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create price request: %w", err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch price: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("price endpoint returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode price response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultPythEndpoint is the public Pyth Hermes price service
+const DefaultPythEndpoint = "https://hermes.pyth.network"
+
+// PythSOLUSDFeedID is the Pyth SOL/USD price feed
+const PythSOLUSDFeedID = "ef0d8b6fda2ceba41da15d4095d1da392a0d2f8ed0c6c7bc0f4cfac8c280b56d"
+
+// Pyth reads prices from a Pyth Hermes endpoint. FeedIDs maps mints to their
+// USD price feed; only SOL is mapped by default
+type Pyth struct {
+	Endpoint string
+	FeedIDs  map[string]string
+	Client   *http.Client
+}
+
+// NewPyth creates a Pyth feed on the public Hermes endpoint pricing SOL
+func NewPyth() *Pyth {
+	return &Pyth{
+		Endpoint: DefaultPythEndpoint,
+		FeedIDs:  map[string]string{sol.WSOL.String(): PythSOLUSDFeedID},
+	}
+}
+
+type hermesResponse struct {
+	Parsed []struct {
+		ID    string `json:"id"`
+		Price struct {
+			Price string `json:"price"`
+			Expo  int    `json:"expo"`
+		} `json:"price"`
+	} `json:"parsed"`
+}
+
+// USDPrice returns the latest Pyth price of mint
+func (p *Pyth) USDPrice(ctx context.Context, mint string) (float64, error) {
+	feedID, ok := p.FeedIDs[mint]
+	if !ok {
+		return 0, fmt.Errorf("%w %s on pyth", ErrNoPrice, mint)
+	}
+	query := url.Values{"ids[]": {feedID}, "parsed": {"true"}}
+	endpoint := strings.TrimRight(p.Endpoint, "/") + "/v2/updates/price/latest?" + query.Encode()
+
+	var resp hermesResponse
+	if err := getJSON(ctx, p.Client, endpoint, nil, &resp); err != nil {
+		return 0, fmt.Errorf("pyth: %w", err)
+	}
+	for _, parsed := range resp.Parsed {
+		if !strings.EqualFold(strings.TrimPrefix(parsed.ID, "0x"), strings.TrimPrefix(feedID, "0x")) {
+			continue
+		}
+		raw, err := strconv.ParseInt(parsed.Price.Price, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("pyth: invalid price %q: %w", parsed.Price.Price, err)
+		}
+		return float64(raw) * math.Pow10(parsed.Price.Expo), nil
+	}
+	return 0, fmt.Errorf("%w %s: feed %s missing from pyth response", ErrNoPrice, mint, feedID)
+}
@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// maxMultipleAccounts is the getMultipleAccounts key limit of Solana RPC nodes
+const maxMultipleAccounts = 100
+
+// FetchPoolAccounts loads pool accounts by ID with getMultipleAccounts and
+// returns them keyed like getProgramAccounts results. Missing accounts are skipped.
+// With SliceAt and PoolSlices it is exported for protocols defined outside
+// this package, such as the experimental venues of x/venue
+func FetchPoolAccounts(ctx context.Context, solClient *sol.Client, poolIDs []string) (rpc.GetProgramAccountsResult, error) {
+	keys := make([]solana.PublicKey, 0, len(poolIDs))
+	for _, poolID := range poolIDs {
+		key, err := solana.PublicKeyFromBase58(poolID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool ID %s: %w", poolID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	accounts := make(rpc.GetProgramAccountsResult, 0, len(keys))
+	for start := 0; start < len(keys); start += maxMultipleAccounts {
+		end := min(start+maxMultipleAccounts, len(keys))
+		result, err := solClient.GetMultipleAccountsWithOpts(ctx, keys[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pool accounts: %w", err)
+		}
+		for i, account := range result.Value {
+			if account == nil {
+				continue
+			}
+			accounts = append(accounts, &rpc.KeyedAccount{
+				Pubkey:  keys[start+i],
+				Account: account,
+			})
+		}
+	}
+	return accounts, nil
+}
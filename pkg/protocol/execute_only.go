@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// ExecuteOnlyProtocol wraps a protocol so it never scans the chain for pools.
+// Only the configured pool IDs are returned, which keeps known pools routable
+// when a venue's getProgramAccounts discovery is too expensive
+type ExecuteOnlyProtocol struct {
+	pkg.Protocol
+	PoolIDs []string
+}
+
+// NewExecuteOnly registers protocol in execute-only mode with a fixed pool list
+func NewExecuteOnly(protocol pkg.Protocol, poolIDs ...string) *ExecuteOnlyProtocol {
+	return &ExecuteOnlyProtocol{
+		Protocol: protocol,
+		PoolIDs:  poolIDs,
+	}
+}
+
+// FetchPoolsByPair returns the configured pools that trade the given pair,
+// none without an error when no configured pool does
+func (p *ExecuteOnlyProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// configured pools that failed to load or trade another pair
+func (p *ExecuteOnlyProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	configured, err := pkg.FetchPoolsByIDs(ctx, p.Protocol, p.PoolIDs)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch configured %s pools: %w", p.ProtocolName(), err)
+	}
+
+	coverage := pkg.PoolCoverage{
+		Discovered:   len(p.PoolIDs),
+		DecodeFailed: max(len(p.PoolIDs)-len(configured), 0),
+	}
+	pools := make([]pkg.Pool, 0, len(configured))
+	for _, pool := range configured {
+		if !poolTradesPair(pool, baseMint, quoteMint) {
+			coverage.Ineligible++
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	coverage.Decoded = len(pools)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs loads pools through the wrapped protocol, batched when it can
+func (p *ExecuteOnlyProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	return pkg.FetchPoolsByIDs(ctx, p.Protocol, poolIDs)
+}
+
+// poolTradesPair reports whether pool holds both mints, in either order
+func poolTradesPair(pool pkg.Pool, baseMint, quoteMint string) bool {
+	poolBase, poolQuote := pool.GetTokens()
+	return (poolBase == baseMint && poolQuote == quoteMint) ||
+		(poolBase == quoteMint && poolQuote == baseMint)
+}
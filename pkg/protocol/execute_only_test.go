@@ -0,0 +1,59 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// fixedProtocol loads its pools by ID and never scans the chain
+type fixedProtocol struct {
+	pools []pkg.Pool
+}
+
+func (p *fixedProtocol) ProtocolName() pkg.ProtocolName { return pkg.ProtocolNameRaydiumCpmm }
+
+func (p *fixedProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	return nil, errors.New("execute-only protocols must not scan")
+}
+
+func (p *fixedProtocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
+	for _, pool := range p.pools {
+		if pool.GetID() == poolID {
+			return pool, nil
+		}
+	}
+	return nil, errors.New("pool not found")
+}
+
+func TestExecuteOnlyFiltersByPair(t *testing.T) {
+	usdc := solana.MustPublicKeyFromBase58(cassetteUSDC)
+	pool := &raydium.CPMMPool{PoolId: solana.NewWallet().PublicKey(), Token0Mint: sol.WSOL, Token1Mint: usdc}
+	proto := NewExecuteOnly(&fixedProtocol{pools: []pkg.Pool{pool}}, pool.GetID(), "missing")
+
+	pools, coverage, err := proto.FetchPoolsByPairWithCoverage(context.Background(), cassetteUSDC, sol.WSOL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 1 || pools[0].GetID() != pool.GetID() {
+		t.Fatalf("returned %d pools, want %s", len(pools), pool.GetID())
+	}
+	if coverage.Discovered != 2 || coverage.DecodeFailed != 1 || coverage.Decoded != 1 {
+		t.Fatalf("coverage = %+v", coverage)
+	}
+
+	// a pair no configured pool trades is not an error, just no pools
+	other := solana.NewWallet().PublicKey().String()
+	pools, coverage, err = proto.FetchPoolsByPairWithCoverage(context.Background(), other, sol.WSOL.String())
+	if err != nil {
+		t.Fatalf("unmatched pair failed: %v", err)
+	}
+	if len(pools) != 0 || coverage.Ineligible != 1 || coverage.Decoded != 0 {
+		t.Fatalf("returned %d pools with coverage %+v", len(pools), coverage)
+	}
+}
@@ -0,0 +1,151 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/lifinity"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// LifinityProtocol discovers Lifinity v2 pools
+type LifinityProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewLifinity creates a new LifinityProtocol instance
+func NewLifinity(solClient *sol.Client) *LifinityProtocol {
+	return &LifinityProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *LifinityProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameLifinity
+}
+
+// FetchPoolsByPair retrieves the Lifinity v2 pools trading baseMint as token A
+// and quoteMint as token B
+func (p *LifinityProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the frozen pools left out
+func (p *LifinityProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getLifinityPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeLifinityPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Lifinity v2 pools with a single batched account lookup
+func (p *LifinityProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeLifinityPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Lifinity v2 pools fetching length bytes from offset of each
+func (p *LifinityProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getLifinityPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+func (p *LifinityProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(lifinity.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by lifinity v2", poolId)
+	}
+
+	pool := &lifinity.LifinityPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getLifinityPoolAccountsByTokenPair lists the Lifinity v2 pools of the pair
+func (p *LifinityProtocol) getLifinityPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, lifinity.ProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  lifinity.AmmDiscriminator,
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: lifinity.TokenAMintOffset,
+					Bytes:  baseKey.Bytes(),
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: lifinity.TokenBMintOffset,
+					Bytes:  quoteKey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodeLifinityPools decodes Lifinity v2 pool accounts, skipping ones that fail
+// to parse, belong to another program or have trading frozen
+func decodeLifinityPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(lifinity.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &lifinity.LifinityPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Frozen {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
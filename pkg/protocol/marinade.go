@@ -0,0 +1,100 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MarinadeProtocol serves Marinade's single state account as the SOL/mSOL pool
+type MarinadeProtocol struct {
+	SolClient *sol.Client
+}
+
+func NewMarinade(solClient *sol.Client) *MarinadeProtocol {
+	return &MarinadeProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *MarinadeProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameMarinade
+}
+
+// FetchPoolsByPair returns Marinade for the SOL/mSOL pair and nothing otherwise
+func (p *MarinadeProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also reporting whether
+// the state account decoded
+func (p *MarinadeProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	msol, wsol := marinade.MSOLMint.String(), sol.WSOL.String()
+	if !(baseMint == msol && quoteMint == wsol) && !(baseMint == wsol && quoteMint == msol) {
+		return nil, pkg.PoolCoverage{}, nil
+	}
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, []string{marinade.StateAddress.String()})
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, err
+	}
+	pools, coverage := decodeMarinadePools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Marinade state accounts with a single batched account lookup
+func (p *MarinadeProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeMarinadePools(accounts)
+	return pools, nil
+}
+
+func (p *MarinadeProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(marinade.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by marinade", poolId)
+	}
+
+	pool := &marinade.MarinadePool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// decodeMarinadePools decodes Marinade state accounts, skipping ones that
+// fail to parse or belong to another program
+func decodeMarinadePools(programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(programAccounts)}
+	for _, v := range programAccounts {
+		if !v.Account.Owner.Equals(marinade.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &marinade.MarinadePool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// SliceAt limits getProgramAccounts data to length bytes from offset
+func SliceAt(offset, length uint64) *rpc.DataSlice {
+	return &rpc.DataSlice{Offset: &offset, Length: &length}
+}
+
+// keyAt reads the public key at offset of a sliced account
+func keyAt(data []byte, offset uint64) (solana.PublicKey, bool) {
+	if uint64(len(data)) < offset+32 {
+		return solana.PublicKey{}, false
+	}
+	return solana.PublicKeyFromBytes(data[offset : offset+32]), true
+}
+
+// publicKeyStrings converts keys to the base58 IDs taken by FetchPoolAccounts
+func publicKeyStrings(keys []solana.PublicKey) []string {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.String())
+	}
+	return ids
+}
+
+// PoolSlices converts sliced scan results to the pool slices of ScanPoolsByPair
+func PoolSlices(accounts rpc.GetProgramAccountsResult) []pkg.PoolSlice {
+	slices := make([]pkg.PoolSlice, 0, len(accounts))
+	for _, account := range accounts {
+		slices = append(slices, pkg.PoolSlice{
+			ID:   account.Pubkey.String(),
+			Data: account.Account.Data.GetBinary(),
+		})
+	}
+	return slices
+}
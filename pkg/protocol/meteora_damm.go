@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MeteoraDammProtocol discovers Meteora Dynamic AMM pools
+type MeteoraDammProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewMeteoraDamm creates a new MeteoraDammProtocol instance
+func NewMeteoraDamm(solClient *sol.Client) *MeteoraDammProtocol {
+	return &MeteoraDammProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *MeteoraDammProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameMeteoraDamm
+}
+
+// FetchPoolsByPair retrieves the Dynamic AMM pools trading baseMint as token A
+// and quoteMint as token B. Stable curve pools are skipped, as their quotes
+// would need the stableswap math
+func (p *MeteoraDammProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the stable pools left out
+func (p *MeteoraDammProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getDammPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeDammPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Dynamic AMM pools with a single batched account lookup
+func (p *MeteoraDammProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeDammPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Dynamic AMM pools fetching length bytes from offset of each
+func (p *MeteoraDammProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getDammPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+func (p *MeteoraDammProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(meteora.DammProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by meteora dynamic amm", poolId)
+	}
+
+	pool := &meteora.MeteoraDammPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getDammPoolAccountsByTokenPair lists the Dynamic AMM pools of the pair
+func (p *MeteoraDammProtocol) getDammPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, meteora.DammProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  meteora.DammPoolDiscriminator,
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: meteora.DammTokenAMintOffset,
+					Bytes:  baseKey.Bytes(),
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: meteora.DammTokenBMintOffset,
+					Bytes:  quoteKey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodeDammPools decodes Dynamic AMM pool accounts, skipping ones that fail
+// to parse, belong to another program, are disabled or trade on the stable curve
+func decodeDammPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(meteora.DammProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &meteora.MeteoraDammPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if !pool.Enabled || !pool.Supported() {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -0,0 +1,148 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MeteoraDammV2Protocol discovers Meteora DAMM v2 pools
+type MeteoraDammV2Protocol struct {
+	SolClient *sol.Client
+}
+
+// NewMeteoraDammV2 creates a new MeteoraDammV2Protocol instance
+func NewMeteoraDammV2(solClient *sol.Client) *MeteoraDammV2Protocol {
+	return &MeteoraDammV2Protocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *MeteoraDammV2Protocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameMeteoraDammV2
+}
+
+// FetchPoolsByPair retrieves the DAMM v2 pools trading baseMint as token A
+// and quoteMint as token B
+func (p *MeteoraDammV2Protocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the disabled pools left out
+func (p *MeteoraDammV2Protocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getDammV2PoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeDammV2Pools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves DAMM v2 pools with a single batched account lookup
+func (p *MeteoraDammV2Protocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeDammV2Pools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's DAMM v2 pools fetching length bytes from offset of each
+func (p *MeteoraDammV2Protocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getDammV2PoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+func (p *MeteoraDammV2Protocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(meteora.DammV2ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by meteora damm v2", poolId)
+	}
+
+	pool := &meteora.MeteoraDammV2Pool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getDammV2PoolAccountsByTokenPair lists the DAMM v2 pools of the pair
+func (p *MeteoraDammV2Protocol) getDammV2PoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, meteora.DammV2ProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				DataSize: meteora.DammV2PoolSize,
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: meteora.DammV2TokenAMintOffset,
+					Bytes:  baseKey.Bytes(),
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: meteora.DammV2TokenBMintOffset,
+					Bytes:  quoteKey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodeDammV2Pools decodes DAMM v2 pool accounts, skipping ones that fail
+// to parse, belong to another program or are disabled
+func decodeDammV2Pools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(meteora.DammV2ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &meteora.MeteoraDammV2Pool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Disabled {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
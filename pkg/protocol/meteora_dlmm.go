@@ -3,7 +3,9 @@ package protocol
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"math"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -15,6 +17,9 @@ import (
 // MeteoraDlmmProtocol handles interactions with Meteora DLMM (Dynamic Liquidity Market Maker) pools
 type MeteoraDlmmProtocol struct {
 	SolClient *sol.Client
+	// BinArrayDepth, when set, is the BinArrayDepth of the pools found, so
+	// discovery loads that many bin arrays each way
+	BinArrayDepth int
 }
 
 // NewMeteoraDlmm creates a new MeteoraDlmmProtocol instance
@@ -30,39 +35,119 @@ func (protocol *MeteoraDlmmProtocol) ProtocolName() pkg.ProtocolName {
 
 // FetchPoolsByPair retrieves all Meteora DLMM pools for a given token pair
 func (protocol *MeteoraDlmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := protocol.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to decode or whose bin arrays failed to load
+func (protocol *MeteoraDlmmProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	programAccounts := rpc.GetProgramAccountsResult{}
 
 	// Fetch pools with baseMint as TokenX and quoteMint as TokenY
-	baseQuotePools, err := protocol.getMeteoraDlmmPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	baseQuotePools, err := protocol.getMeteoraDlmmPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with baseMint as TokenX: %w", err)
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with baseMint as TokenX: %w", err)
 	}
 	programAccounts = append(programAccounts, baseQuotePools...)
 
+	return protocol.decodeMeteoraDlmmPools(ctx, programAccounts)
+}
+
+// FetchPoolsByIDs retrieves several Meteora DLMM pools with a single batched account lookup
+func (protocol *MeteoraDlmmProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, protocol.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _, err := protocol.decodeMeteoraDlmmPools(ctx, accounts)
+	return pools, err
+}
+
+// ScanPoolsByPair scans the pair's DLMM pools fetching length bytes from offset of each
+func (protocol *MeteoraDlmmProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := protocol.getMeteoraDlmmPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+// FetchPoolMetasByPair lists DLMM pools for a pair, fetching only the static
+// parameters through the mints and skipping bin array loading
+func (protocol *MeteoraDlmmProtocol) FetchPoolMetasByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.PoolMeta, error) {
+	var layout meteora.MeteoraDlmmPool
+	const start = 8 // static parameters follow the discriminator
+	xOffset := layout.Offset("TokenXMint") - start
+	accounts, err := protocol.getMeteoraDlmmPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(start, xOffset+64))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pools with baseMint as TokenX: %w", err)
+	}
+
+	metas := make([]pkg.PoolMeta, 0, len(accounts))
+	for _, account := range accounts {
+		data := account.Account.Data.GetBinary()
+		tokenX, okX := keyAt(data, xOffset)
+		tokenY, okY := keyAt(data, xOffset+32)
+		if !okX || !okY {
+			continue
+		}
+
+		// base fee rate = base_factor * bin_step * 10 * 10^power_factor / 1e9
+		baseFactor := binary.LittleEndian.Uint16(data[0:2])
+		powerFactor := data[layout.Offset("BaseFeePowerFactor")-start]
+		binStepOffset := layout.Offset("BinStep") - start
+		binStep := binary.LittleEndian.Uint16(data[binStepOffset : binStepOffset+2])
+		feeRate := float64(baseFactor) * float64(binStep) * 10 * math.Pow10(int(powerFactor)) / meteora.FeePrecision
+
+		metas = append(metas, pkg.PoolMeta{
+			ID:        account.Pubkey.String(),
+			Protocol:  protocol.ProtocolName(),
+			BaseMint:  tokenX.String(),
+			QuoteMint: tokenY.String(),
+			FeeBps:    feeRate * 10000,
+		})
+	}
+	return metas, nil
+}
+
+// decodeMeteoraDlmmPools decodes DLMM pool accounts and loads the bin arrays needed to quote them
+func (protocol *MeteoraDlmmProtocol) decodeMeteoraDlmmPools(ctx context.Context, programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	pools := make([]pkg.Pool, 0, len(programAccounts))
+	coverage := pkg.PoolCoverage{Discovered: len(programAccounts)}
 	for _, account := range programAccounts {
+		// pools whose bin arrays fail to load are skipped, so stop before a
+		// cancelled request skips every remaining one
+		if err := ctx.Err(); err != nil {
+			return nil, coverage, err
+		}
 		poolData := &meteora.MeteoraDlmmPool{}
 		if err := poolData.Decode(account.Account.Data.GetBinary()); err != nil {
 			// Skip pools that can't be decoded
+			coverage.DecodeFailed++
 			continue
 		}
 
 		poolData.PoolId = account.Pubkey
+		poolData.BinArrayDepth = protocol.BinArrayDepth
 		if err := poolData.GetBinArrayForSwap(ctx, protocol.SolClient); err != nil {
 			// Skip pools that can't get bin array
+			coverage.DecodeFailed++
 			continue
 		}
 
 		poolData.BitmapExtensionKey, _ = meteora.DeriveBinArrayBitmapExtension(poolData.PoolId)
 		pools = append(pools, poolData)
 	}
-	return pools, nil
+	coverage.Decoded = len(pools)
+	return pools, coverage, nil
 }
 
 // getMeteoraDlmmPoolAccountsByTokenPair retrieves pool accounts for a specific token pair configuration
-func (protocol *MeteoraDlmmProtocol) getMeteoraDlmmPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+func (protocol *MeteoraDlmmProtocol) getMeteoraDlmmPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
 	var poolLayout meteora.MeteoraDlmmPool
 	result, err := protocol.SolClient.GetProgramAccountsWithOpts(ctx, meteora.MeteoraProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
 		Filters: []rpc.RPCFilter{
 			{
 				DataSize: 904, // Meteora DLMM pool account size
@@ -99,6 +184,7 @@ func (protocol *MeteoraDlmmProtocol) FetchPoolByID(ctx context.Context, poolID s
 		return nil, fmt.Errorf("failed to decode pool data: %w", err)
 	}
 
+	poolData.BinArrayDepth = protocol.BinArrayDepth
 	if err := poolData.GetBinArrayForSwap(ctx, protocol.SolClient); err != nil {
 		return nil, fmt.Errorf("failed to get bin array for swap: %w", err)
 	}
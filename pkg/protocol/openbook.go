@@ -0,0 +1,153 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/openbook"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// OpenBookProtocol discovers OpenBook v2 order book markets, routed as pools
+type OpenBookProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewOpenBook creates a new OpenBookProtocol instance
+func NewOpenBook(solClient *sol.Client) *OpenBookProtocol {
+	return &OpenBookProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *OpenBookProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameOpenBook
+}
+
+// FetchPoolsByPair retrieves the OpenBook v2 markets trading baseMint for
+// quoteMint. The book sides are loaded by Quote
+func (p *OpenBookProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the expired or permissioned markets
+// left out
+func (p *OpenBookProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getOpenBookMarketAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeOpenBookMarkets(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves OpenBook v2 markets with a single batched account lookup
+func (p *OpenBookProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeOpenBookMarkets(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's OpenBook v2 markets fetching length bytes from offset of each
+func (p *OpenBookProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getOpenBookMarketAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+func (p *OpenBookProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid market ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get market account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(openbook.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by openbook v2", poolId)
+	}
+
+	pool := &openbook.OpenBookPool{MarketId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse market data for market %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getOpenBookMarketAccountsByTokenPair lists the OpenBook v2 markets of the pair
+func (p *OpenBookProtocol) getOpenBookMarketAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, openbook.ProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  openbook.MarketDiscriminator,
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: openbook.BaseMintOffset,
+					Bytes:  baseKey.Bytes(),
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: openbook.QuoteMintOffset,
+					Bytes:  quoteKey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodeOpenBookMarkets decodes OpenBook v2 markets, skipping ones that fail to
+// parse, belong to another program, expired or need an admin to cosign orders
+func decodeOpenBookMarkets(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(openbook.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &openbook.OpenBookPool{MarketId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Market.Expired(time.Now().Unix()) || pool.Market.Permissioned() {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
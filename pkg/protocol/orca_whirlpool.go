@@ -0,0 +1,153 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/orca"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// OrcaWhirlpoolProtocol discovers Orca Whirlpools
+type OrcaWhirlpoolProtocol struct {
+	SolClient *sol.Client
+}
+
+func NewOrcaWhirlpool(solClient *sol.Client) *OrcaWhirlpoolProtocol {
+	return &OrcaWhirlpoolProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *OrcaWhirlpoolProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameOrcaWhirlpool
+}
+
+// FetchPoolsByPair returns the pair's Whirlpools of every tick spacing.
+// Adaptive fee pools are skipped, as their quotes would miss the variable fee
+func (p *OrcaWhirlpoolProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the adaptive fee pools left out
+func (p *OrcaWhirlpoolProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getWhirlpoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeWhirlpools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Whirlpools with a single batched account lookup
+func (p *OrcaWhirlpoolProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeWhirlpools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Whirlpools fetching length bytes from offset of each
+func (p *OrcaWhirlpoolProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getWhirlpoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+func (p *OrcaWhirlpoolProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(orca.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by orca whirlpool", poolId)
+	}
+
+	pool := &orca.WhirlpoolPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getWhirlpoolAccountsByTokenPair lists the pair's Whirlpools. The program
+// orders token A before token B by their bytes, so one query covers the pair
+func (p *OrcaWhirlpoolProtocol) getWhirlpoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+	mintA, mintB := baseKey, quoteKey
+	if bytes.Compare(mintA[:], mintB[:]) > 0 {
+		mintA, mintB = mintB, mintA
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, orca.ProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				DataSize: orca.WhirlpoolSize,
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: orca.TokenMintAOffset,
+					Bytes:  mintA.Bytes(),
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: orca.TokenMintBOffset,
+					Bytes:  mintB.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodeWhirlpools decodes Whirlpool accounts, skipping ones that fail to
+// parse, belong to another program or charge adaptive fees
+func decodeWhirlpools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(orca.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &orca.WhirlpoolPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.UsesAdaptiveFee() {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/phoenix"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// PhoenixProtocol discovers Phoenix order book markets, routed as pools
+type PhoenixProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewPhoenix creates a new PhoenixProtocol instance
+func NewPhoenix(solClient *sol.Client) *PhoenixProtocol {
+	return &PhoenixProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *PhoenixProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNamePhoenix
+}
+
+// FetchPoolsByPair retrieves the Phoenix markets trading baseMint for
+// quoteMint. Discovery reads the market headers only; the order book is
+// loaded by Quote
+func (p *PhoenixProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the markets closed to takers left out
+func (p *PhoenixProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getPhoenixPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(0, phoenix.MarketHeaderSize))
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodePhoenixPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Phoenix markets with a single batched account lookup
+func (p *PhoenixProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodePhoenixPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Phoenix markets fetching length bytes from offset of each
+func (p *PhoenixProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getPhoenixPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+func (p *PhoenixProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid market ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get market account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(phoenix.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by phoenix", poolId)
+	}
+
+	pool := &phoenix.PhoenixPool{MarketId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse market data for market %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getPhoenixPoolAccountsByTokenPair lists the Phoenix markets of the pair
+func (p *PhoenixProtocol) getPhoenixPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, phoenix.ProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  phoenix.MarketDiscriminator,
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: phoenix.BaseMintOffset,
+					Bytes:  baseKey.Bytes(),
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: phoenix.QuoteMintOffset,
+					Bytes:  quoteKey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodePhoenixPools decodes Phoenix market headers, skipping ones that fail
+// to parse, belong to another program or do not take orders
+func decodePhoenixPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(phoenix.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &phoenix.PhoenixPool{MarketId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Header.Status != phoenix.MarketStatusActive {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -26,26 +26,87 @@ func (p *PumpAmmProtocol) ProtocolName() pkg.ProtocolName {
 }
 
 func (p *PumpAmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse
+func (p *PumpAmmProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	programAccounts := rpc.GetProgramAccountsResult{}
-	data, err := p.getPumpAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	data, err := p.getPumpAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
 	}
 	programAccounts = append(programAccounts, data...)
 
+	pools, coverage := decodePumpAMMPools(programAccounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves several PumpSwap pools with a single batched account lookup
+func (p *PumpAmmProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodePumpAMMPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's PumpSwap pools fetching length bytes from offset of each
+func (p *PumpAmmProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getPumpAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+// FetchPoolMetasByPair lists PumpSwap pools for a pair, fetching only their mints
+func (p *PumpAmmProtocol) FetchPoolMetasByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.PoolMeta, error) {
+	accounts, err := p.getPumpAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(pump.BaseMintOffset, pump.QuoteMintOffset-pump.BaseMintOffset+32))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+
+	metas := make([]pkg.PoolMeta, 0, len(accounts))
+	for _, account := range accounts {
+		data := account.Account.Data.GetBinary()
+		base, ok0 := keyAt(data, 0)
+		quote, ok1 := keyAt(data, pump.QuoteMintOffset-pump.BaseMintOffset)
+		if !ok0 || !ok1 {
+			continue
+		}
+		metas = append(metas, pkg.PoolMeta{
+			ID:        account.Pubkey.String(),
+			Protocol:  p.ProtocolName(),
+			BaseMint:  base.String(),
+			QuoteMint: quote.String(),
+			FeeBps:    pump.TotalFeeBps,
+		})
+	}
+	return metas, nil
+}
+
+// decodePumpAMMPools decodes PumpSwap pool accounts, skipping ones that fail to parse
+func decodePumpAMMPools(programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
 	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(programAccounts)}
 	for _, v := range programAccounts {
 		layout, err := pump.ParsePoolData(v.Account.Data.GetBinary())
 		if err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		layout.PoolId = v.Pubkey
 		res = append(res, layout)
 	}
-	return res, nil
+	coverage.Decoded = len(res)
+	return res, coverage
 }
 
-func (p *PumpAmmProtocol) getPumpAMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+func (p *PumpAmmProtocol) getPumpAMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
 	var layout pump.PumpAMMPool
 	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
 	if err != nil {
@@ -57,6 +118,7 @@ func (p *PumpAmmProtocol) getPumpAMMPoolAccountsByTokenPair(ctx context.Context,
 	}
 
 	return p.SolClient.GetProgramAccountsWithOpts(ctx, pump.PumpSwapProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
 		Filters: []rpc.RPCFilter{
 			{
 				DataSize: layout.Span(),
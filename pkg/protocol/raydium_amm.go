@@ -1,11 +1,11 @@
 package protocol
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -29,29 +29,97 @@ func (p *RaydiumAMMProtocol) ProtocolName() pkg.ProtocolName {
 }
 
 func (p *RaydiumAMMProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to decode
+func (p *RaydiumAMMProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	accounts := make([]*rpc.KeyedAccount, 0)
-	programAccounts, err := p.getAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	programAccounts, err := p.getAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
 	}
 	accounts = append(accounts, programAccounts...)
 
+	return p.decodeAMMPools(ctx, accounts)
+}
+
+// FetchPoolsByIDs retrieves several AMM pools with a single batched account lookup
+func (p *RaydiumAMMProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _, err := p.decodeAMMPools(ctx, accounts)
+	return pools, err
+}
+
+// ScanPoolsByPair scans the pair's AMM pools fetching length bytes from offset of each
+func (p *RaydiumAMMProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+// FetchPoolMetasByPair lists AMM pools for a pair, fetching only the bytes
+// from the swap fee fields through the mints
+func (p *RaydiumAMMProtocol) FetchPoolMetasByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.PoolMeta, error) {
+	var layout raydium.AMMPool
+	start := layout.Offset("SwapFeeNumerator")
+	baseOffset := layout.Offset("BaseMint") - start
+	accounts, err := p.getAMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(start, baseOffset+64))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+
+	metas := make([]pkg.PoolMeta, 0, len(accounts))
+	for _, account := range accounts {
+		data := account.Account.Data.GetBinary()
+		base, ok0 := keyAt(data, baseOffset)
+		quote, ok1 := keyAt(data, baseOffset+32)
+		if !ok0 || !ok1 {
+			continue
+		}
+		meta := pkg.PoolMeta{
+			ID:        account.Pubkey.String(),
+			Protocol:  p.ProtocolName(),
+			BaseMint:  base.String(),
+			QuoteMint: quote.String(),
+		}
+		numerator := binary.LittleEndian.Uint64(data[0:8])
+		if denominator := binary.LittleEndian.Uint64(data[8:16]); denominator > 0 {
+			meta.FeeBps = float64(numerator) * 10000 / float64(denominator)
+		}
+		metas = append(metas, meta)
+	}
+	return metas, nil
+}
+
+// decodeAMMPools decodes AMM pool accounts and resolves their market authorities
+func (p *RaydiumAMMProtocol) decodeAMMPools(ctx context.Context, accounts []*rpc.KeyedAccount) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
 	for _, v := range accounts {
 		layout := &raydium.AMMPool{}
 		if err := layout.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		layout.PoolId = v.Pubkey
 		if err := p.processAMMPool(ctx, layout); err != nil {
-			return nil, fmt.Errorf("failed to process AMM pool %s: %w", v.Pubkey.String(), err)
+			return nil, coverage, fmt.Errorf("failed to process AMM pool %s: %w", v.Pubkey.String(), err)
 		}
 		res = append(res, layout)
 	}
-	return res, nil
+	coverage.Decoded = len(res)
+	return res, coverage, nil
 }
 
-func (p *RaydiumAMMProtocol) getAMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+func (p *RaydiumAMMProtocol) getAMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
 	var layout raydium.AMMPool
 	baseMintPubkey, err := solana.PublicKeyFromBase58(baseMint)
 	if err != nil {
@@ -63,6 +131,7 @@ func (p *RaydiumAMMProtocol) getAMMPoolAccountsByTokenPair(ctx context.Context,
 	}
 
 	return p.SolClient.GetProgramAccountsWithOpts(ctx, raydium.RAYDIUM_AMM_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
 		Filters: []rpc.RPCFilter{
 			{
 				DataSize: layout.Span(),
@@ -106,32 +175,62 @@ func (r *RaydiumAMMProtocol) FetchPoolByID(ctx context.Context, poolID string) (
 	return layout, nil
 }
 
-func getAssociatedAuthority(programID solana.PublicKey, marketID solana.PublicKey) (solana.PublicKey, uint8, error) {
-	seeds := [][]byte{marketID.Bytes()}
-	var nonce uint8 = 0
+// marketAuthorities caches derived market vault signers keyed by market program and market ID
+var marketAuthorities sync.Map
 
-	for nonce < 100 {
-		seedsWithNonce := append(seeds, int8ToBuf(nonce))
-		seedsWithNonce = append(seedsWithNonce, make([]byte, 7))
+type marketAuthorityKey struct {
+	programID solana.PublicKey
+	marketID  solana.PublicKey
+}
+
+// getAssociatedAuthority derives the vault signer of a Serum/OpenBook market.
+// The market's own nonce is tried first; the nonce search is only a fallback
+func getAssociatedAuthority(programID solana.PublicKey, marketID solana.PublicKey, vaultSignerNonce uint64) (solana.PublicKey, error) {
+	if !raydium.IsSupportedMarketProgram(programID) {
+		return solana.PublicKey{}, fmt.Errorf("unsupported market program %s", programID)
+	}
+
+	key := marketAuthorityKey{programID: programID, marketID: marketID}
+	if cached, ok := marketAuthorities.Load(key); ok {
+		return cached.(solana.PublicKey), nil
+	}
 
-		publicKey, err := solana.CreateProgramAddress(seedsWithNonce, programID)
+	publicKey, err := createMarketAuthority(programID, marketID, vaultSignerNonce)
+	if err != nil {
+		publicKey, err = findMarketAuthority(programID, marketID)
 		if err != nil {
-			nonce++
-			continue
+			return solana.PublicKey{}, err
 		}
-
-		return publicKey, nonce, nil
 	}
+	marketAuthorities.Store(key, publicKey)
+	return publicKey, nil
+}
 
-	return solana.PublicKey{}, 0, errors.New("unable to find a viable program address nonce")
+// createMarketAuthority derives the vault signer for a known nonce
+func createMarketAuthority(programID solana.PublicKey, marketID solana.PublicKey, nonce uint64) (solana.PublicKey, error) {
+	nonceBuf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nonceBuf, nonce)
+	return solana.CreateProgramAddress([][]byte{marketID.Bytes(), nonceBuf}, programID)
 }
 
-func int8ToBuf(value uint8) []byte {
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.LittleEndian, value)
-	return buf.Bytes()
+// findMarketAuthority searches for the first nonce that yields a valid vault signer
+func findMarketAuthority(programID solana.PublicKey, marketID solana.PublicKey) (solana.PublicKey, error) {
+	for nonce := uint64(0); nonce < 256; nonce++ {
+		publicKey, err := createMarketAuthority(programID, marketID, nonce)
+		if err != nil {
+			continue
+		}
+		return publicKey, nil
+	}
+	return solana.PublicKey{}, errors.New("unable to find a viable program address nonce")
 }
 
+// ammAuthority derives the Raydium AMM v4 authority once; it only depends on the program ID
+var ammAuthority = sync.OnceValues(func() (solana.PublicKey, error) {
+	authority, _, err := solana.FindProgramAddress([][]byte{[]byte("amm authority")}, raydium.RAYDIUM_AMM_PROGRAM_ID)
+	return authority, err
+})
+
 func (p *RaydiumAMMProtocol) processAMMPool(ctx context.Context, layout *raydium.AMMPool) error {
 	marketAccount, err := p.SolClient.GetAccountInfoWithOpts(ctx, layout.MarketId)
 	if err != nil {
@@ -143,12 +242,12 @@ func (p *RaydiumAMMProtocol) processAMMPool(ctx context.Context, layout *raydium
 		return fmt.Errorf("failed to decode market layout: %w", err)
 	}
 
-	authority, _, err := solana.FindProgramAddress([][]byte{{97, 109, 109, 32, 97, 117, 116, 104, 111, 114, 105, 116, 121}}, raydium.RAYDIUM_AMM_PROGRAM_ID)
+	authority, err := ammAuthority()
 	if err != nil {
 		return fmt.Errorf("failed to find program address: %w", err)
 	}
 
-	marketAuthority, _, err := getAssociatedAuthority(marketAccount.Value.Owner, marketLayout.OwnAddress)
+	marketAuthority, err := getAssociatedAuthority(marketAccount.Value.Owner, marketLayout.OwnAddress, marketLayout.VaultSignerNonce)
 	if err != nil {
 		return fmt.Errorf("failed to get associated authority: %w", err)
 	}
@@ -0,0 +1,112 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+)
+
+var (
+	// the Serum v3 SOL/USDC market and its vault signer on mainnet, derived
+	// with the market's vault signer nonce of 1
+	serumSOLUSDCMarket = solana.MustPublicKeyFromBase58("9wFFyRfZBsuAha4YcuxcXLKwMxJR43S7fPfQLusDBzvT")
+	serumSOLUSDCSigner = solana.MustPublicKeyFromBase58("F8Vyqk3unwxkXukZFQeYyGmFfTG3CAX4v24iyrjEYBJV")
+)
+
+func forgetMarketAuthority(programID, marketID solana.PublicKey) {
+	marketAuthorities.Delete(marketAuthorityKey{programID: programID, marketID: marketID})
+}
+
+func TestGetAssociatedAuthority(t *testing.T) {
+	tests := []struct {
+		name  string
+		nonce uint64
+	}{
+		{"market nonce", 1},
+		// nonce 0 is on the curve for this market, so the search moves on to 1
+		{"nonce search fallback", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forgetMarketAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket)
+			authority, err := getAssociatedAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket, tt.nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !authority.Equals(serumSOLUSDCSigner) {
+				t.Fatalf("authority = %s, want %s", authority, serumSOLUSDCSigner)
+			}
+		})
+	}
+}
+
+func TestGetAssociatedAuthorityCached(t *testing.T) {
+	forgetMarketAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket)
+	defer forgetMarketAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket)
+
+	if _, err := getAssociatedAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket, 1); err != nil {
+		t.Fatal(err)
+	}
+	cached, ok := marketAuthorities.Load(marketAuthorityKey{programID: raydium.SERUM_PROGRAM_ID, marketID: serumSOLUSDCMarket})
+	if !ok || !cached.(solana.PublicKey).Equals(serumSOLUSDCSigner) {
+		t.Fatalf("cached authority = %v, want %s", cached, serumSOLUSDCSigner)
+	}
+
+	// a cached authority is served without deriving it again
+	sentinel := solana.NewWallet().PublicKey()
+	marketAuthorities.Store(marketAuthorityKey{programID: raydium.SERUM_PROGRAM_ID, marketID: serumSOLUSDCMarket}, sentinel)
+	authority, err := getAssociatedAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authority.Equals(sentinel) {
+		t.Fatalf("authority = %s, want the cached %s", authority, sentinel)
+	}
+}
+
+func TestGetAssociatedAuthorityPerProgram(t *testing.T) {
+	forgetMarketAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket)
+	forgetMarketAuthority(raydium.OPENBOOK_PROGRAM_ID, serumSOLUSDCMarket)
+
+	serum, err := getAssociatedAuthority(raydium.SERUM_PROGRAM_ID, serumSOLUSDCMarket, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same market address under OpenBook has its own signer and cache entry
+	openBook, err := getAssociatedAuthority(raydium.OPENBOOK_PROGRAM_ID, serumSOLUSDCMarket, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := createMarketAuthority(raydium.OPENBOOK_PROGRAM_ID, serumSOLUSDCMarket, 1)
+	if err != nil {
+		want, err = findMarketAuthority(raydium.OPENBOOK_PROGRAM_ID, serumSOLUSDCMarket)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !openBook.Equals(want) || openBook.Equals(serum) {
+		t.Fatalf("OpenBook authority = %s, want %s distinct from Serum's %s", openBook, want, serum)
+	}
+
+	if _, err := getAssociatedAuthority(raydium.RAYDIUM_AMM_PROGRAM_ID, serumSOLUSDCMarket, 1); err == nil {
+		t.Fatal("market of an unsupported program got an authority")
+	}
+}
+
+func TestIsSupportedMarketProgram(t *testing.T) {
+	tests := []struct {
+		programID solana.PublicKey
+		want      bool
+	}{
+		{raydium.SERUM_PROGRAM_ID, true},
+		{raydium.OPENBOOK_PROGRAM_ID, true},
+		{raydium.RAYDIUM_AMM_PROGRAM_ID, false},
+		{solana.PublicKey{}, false},
+	}
+	for _, tt := range tests {
+		if got := raydium.IsSupportedMarketProgram(tt.programID); got != tt.want {
+			t.Errorf("IsSupportedMarketProgram(%s) = %v, want %v", tt.programID, got, tt.want)
+		}
+	}
+}
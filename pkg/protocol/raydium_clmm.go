@@ -27,44 +27,143 @@ func (p *RaydiumClmmProtocol) ProtocolName() pkg.ProtocolName {
 }
 
 func (p *RaydiumClmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to decode or whose amm config failed to load
+func (p *RaydiumClmmProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	accounts := make([]*rpc.KeyedAccount, 0)
-	programAccounts, err := p.getCLMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	programAccounts, err := p.getCLMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
 	}
 	accounts = append(accounts, programAccounts...)
 
+	return p.decodeCLMMPools(ctx, accounts)
+}
+
+// FetchPoolsByIDs retrieves several CLMM pools with a single batched account lookup
+func (p *RaydiumClmmProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _, err := p.decodeCLMMPools(ctx, accounts)
+	return pools, err
+}
+
+// ScanPoolsByPair scans the pair's CLMM pools fetching length bytes from offset of each
+func (p *RaydiumClmmProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getCLMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+// FetchPoolMetasByPair lists CLMM pools for a pair, fetching only their amm
+// config and mints. Fee tiers come from one batched lookup of the distinct configs
+func (p *RaydiumClmmProtocol) FetchPoolMetasByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.PoolMeta, error) {
+	// amm config, owner, token mint 0 and token mint 1 are contiguous
+	var layout raydium.CLMMPool
+	start := layout.Offset("AmmConfig")
+	token0Offset := layout.Offset("TokenMint0") - start
+	accounts, err := p.getCLMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(start, token0Offset+64))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+
+	metas := make([]pkg.PoolMeta, 0, len(accounts))
+	configs := make([]solana.PublicKey, 0)
+	poolConfigs := make([]solana.PublicKey, 0, len(accounts))
+	seen := make(map[solana.PublicKey]struct{})
+	for _, account := range accounts {
+		data := account.Account.Data.GetBinary()
+		config, ok := keyAt(data, 0)
+		token0, ok0 := keyAt(data, token0Offset)
+		token1, ok1 := keyAt(data, token0Offset+32)
+		if !ok || !ok0 || !ok1 {
+			continue
+		}
+		metas = append(metas, pkg.PoolMeta{
+			ID:        account.Pubkey.String(),
+			Protocol:  p.ProtocolName(),
+			BaseMint:  token0.String(),
+			QuoteMint: token1.String(),
+		})
+		poolConfigs = append(poolConfigs, config)
+		if _, ok := seen[config]; !ok {
+			seen[config] = struct{}{}
+			configs = append(configs, config)
+		}
+	}
+	if len(configs) == 0 {
+		return metas, nil
+	}
+
+	configAccounts, err := FetchPoolAccounts(ctx, p.SolClient, publicKeyStrings(configs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch amm configs: %w", err)
+	}
+	feeRates := make(map[solana.PublicKey]uint32, len(configAccounts))
+	for _, account := range configAccounts {
+		if feeRate, err := parseAmmConfig(account.Account.Data.GetBinary()); err == nil {
+			feeRates[account.Pubkey] = feeRate
+		}
+	}
+	for i := range metas {
+		// trade fee rates are in hundredths of a basis point
+		metas[i].FeeBps = float64(feeRates[poolConfigs[i]]) / 100
+	}
+	return metas, nil
+}
+
+// decodeCLMMPools decodes CLMM pool accounts and loads their fee rate and bitmap extension address
+func (p *RaydiumClmmProtocol) decodeCLMMPools(ctx context.Context, accounts []*rpc.KeyedAccount) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
 	for _, v := range accounts {
+		// failed lookups skip a pool, so stop before a cancelled request
+		// turns every remaining pool into one
+		if err := ctx.Err(); err != nil {
+			return nil, coverage, err
+		}
 		data := v.Account.Data.GetBinary()
 		layout := &raydium.CLMMPool{}
 		if err := layout.Decode(data); err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		layout.PoolId = v.Pubkey
 
 		ammConfigData, err := p.SolClient.GetAccountInfoWithOpts(ctx, layout.AmmConfig)
 		if err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		feeRate, err := parseAmmConfig(ammConfigData.Value.Data.GetBinary())
 		if err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		layout.FeeRate = feeRate
 
 		exBitmapAddress, _, err := raydium.GetPdaExBitmapAccount(raydium.RAYDIUM_CLMM_PROGRAM_ID, layout.PoolId)
 		if err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		layout.ExBitmapAddress = exBitmapAddress
 
 		res = append(res, layout)
 	}
-	return res, nil
+	coverage.Decoded = len(res)
+	return res, coverage, nil
 }
 
-func (p *RaydiumClmmProtocol) getCLMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+func (p *RaydiumClmmProtocol) getCLMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
 	baseKey, err := solana.PublicKeyFromBase58(baseMint)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base mint address: %w", err)
@@ -76,6 +175,7 @@ func (p *RaydiumClmmProtocol) getCLMMPoolAccountsByTokenPair(ctx context.Context
 
 	var knownPoolLayout raydium.CLMMPool
 	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, raydium.RAYDIUM_CLMM_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
 		Filters: []rpc.RPCFilter{
 			{
 				DataSize: uint64(knownPoolLayout.Span()),
@@ -29,28 +29,93 @@ func (p *RaydiumCpmmProtocol) ProtocolName() pkg.ProtocolName {
 
 // FetchPoolsByPair retrieves all pools for a given token pair
 func (p *RaydiumCpmmProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to decode
+func (p *RaydiumCpmmProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
 	// Fetch pools with baseMint as token0
-	programAccounts, err := p.getCPMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint)
+	programAccounts, err := p.getCPMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+
+	pools, coverage := p.decodeCPMMPools(programAccounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves several CPMM pools with a single batched account lookup
+func (p *RaydiumCpmmProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := p.decodeCPMMPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's CPMM pools fetching length bytes from offset of each
+func (p *RaydiumCpmmProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getCPMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return PoolSlices(accounts), nil
+}
+
+// FetchPoolMetasByPair lists CPMM pools for a pair, fetching only their mints
+func (p *RaydiumCpmmProtocol) FetchPoolMetasByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.PoolMeta, error) {
+	var layout raydium.CPMMPool
+	offset := layout.Offset("Token0Mint")
+	accounts, err := p.getCPMMPoolAccountsByTokenPair(ctx, baseMint, quoteMint, SliceAt(offset, 64))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
 	}
 
+	// CPMM quotes use the standard Raydium fee rather than the per-config rate
+	feeBps := float64(raydium.LIQUIDITY_FEES_NUMERATOR.Int64()) * 10000 / float64(raydium.LIQUIDITY_FEES_DENOMINATOR.Int64())
+	metas := make([]pkg.PoolMeta, 0, len(accounts))
+	for _, account := range accounts {
+		data := account.Account.Data.GetBinary()
+		token0, ok0 := keyAt(data, 0)
+		token1, ok1 := keyAt(data, 32)
+		if !ok0 || !ok1 {
+			continue
+		}
+		metas = append(metas, pkg.PoolMeta{
+			ID:        account.Pubkey.String(),
+			Protocol:  p.ProtocolName(),
+			BaseMint:  token0.String(),
+			QuoteMint: token1.String(),
+			FeeBps:    feeBps,
+		})
+	}
+	return metas, nil
+}
+
+// decodeCPMMPools decodes CPMM pool accounts, skipping ones that fail to decode
+func (p *RaydiumCpmmProtocol) decodeCPMMPools(programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
 	pools := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(programAccounts)}
 	for _, account := range programAccounts {
 		data := account.Account.Data.GetBinary()
 		pool := &raydium.CPMMPool{}
 		if err := pool.Decode(data); err != nil {
+			coverage.DecodeFailed++
 			continue
 		}
 		pool.PoolId = account.Pubkey
 		pools = append(pools, pool)
 	}
 
-	return pools, nil
+	coverage.Decoded = len(pools)
+	return pools, coverage
 }
 
 // getCPMMPoolAccountsByTokenPair retrieves CPMM pool accounts for a given token pair
-func (p *RaydiumCpmmProtocol) getCPMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string) (rpc.GetProgramAccountsResult, error) {
+func (p *RaydiumCpmmProtocol) getCPMMPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
 	baseKey, err := solana.PublicKeyFromBase58(baseMint)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base mint address: %w", err)
@@ -81,7 +146,8 @@ func (p *RaydiumCpmmProtocol) getCPMMPoolAccountsByTokenPair(ctx context.Context
 	}
 
 	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, raydium.RAYDIUM_CPMM_PROGRAM_ID, &rpc.GetProgramAccountsOpts{
-		Filters: filters,
+		DataSlice: dataSlice,
+		Filters:   filters,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pools: %w", err)
@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Names lists every registered protocol. These are stable: a name is only
+// dropped in a major version, after a release listing it in Deprecations.
+// Newer venues start out in x/venue
+var Names = []pkg.ProtocolName{
+	pkg.ProtocolNamePumpAmm,
+	pkg.ProtocolNameRaydiumAmm,
+	pkg.ProtocolNameRaydiumClmm,
+	pkg.ProtocolNameRaydiumCpmm,
+	pkg.ProtocolNameMeteoraDlmm,
+	pkg.ProtocolNameSPLStakePool,
+	pkg.ProtocolNameMarinade,
+	pkg.ProtocolNameOrcaWhirlpool,
+	pkg.ProtocolNameMeteoraDamm,
+	pkg.ProtocolNameMeteoraDammV2,
+	pkg.ProtocolNameLifinity,
+	pkg.ProtocolNamePhoenix,
+	pkg.ProtocolNameOpenBook,
+}
+
+// Deprecations lists the registered protocols scheduled for removal. New
+// keeps creating them until they are removed
+var Deprecations = map[pkg.ProtocolName]pkg.Deprecation{}
+
+// Stability reports the compatibility promise of the protocol name. Names
+// missing from Names carry none, as for the venues of x/venue
+func Stability(name pkg.ProtocolName) pkg.Stability {
+	if _, ok := Deprecations[name]; ok {
+		return pkg.StabilityDeprecated
+	}
+	for _, registered := range Names {
+		if registered == name {
+			return pkg.StabilityStable
+		}
+	}
+	return pkg.StabilityExperimental
+}
+
+// All creates every registered protocol, in the order of Names
+func All(solClient *sol.Client) []pkg.Protocol {
+	protocols := make([]pkg.Protocol, 0, len(Names))
+	for _, name := range Names {
+		proto, err := New(name, solClient)
+		if err != nil {
+			// every name in Names has a case in New
+			panic(err)
+		}
+		protocols = append(protocols, proto)
+	}
+	return protocols
+}
+
+// New creates the protocol registered under name
+func New(name pkg.ProtocolName, solClient *sol.Client) (pkg.Protocol, error) {
+	switch name {
+	case pkg.ProtocolNameRaydiumAmm:
+		return NewRaydiumAmm(solClient), nil
+	case pkg.ProtocolNameRaydiumClmm:
+		return NewRaydiumClmm(solClient), nil
+	case pkg.ProtocolNameRaydiumCpmm:
+		return NewRaydiumCpmm(solClient), nil
+	case pkg.ProtocolNameMeteoraDlmm:
+		return NewMeteoraDlmm(solClient), nil
+	case pkg.ProtocolNamePumpAmm:
+		return NewPumpAmm(solClient), nil
+	case pkg.ProtocolNameSPLStakePool:
+		return NewSPLStakePool(solClient), nil
+	case pkg.ProtocolNameMarinade:
+		return NewMarinade(solClient), nil
+	case pkg.ProtocolNameOrcaWhirlpool:
+		return NewOrcaWhirlpool(solClient), nil
+	case pkg.ProtocolNameMeteoraDamm:
+		return NewMeteoraDamm(solClient), nil
+	case pkg.ProtocolNameMeteoraDammV2:
+		return NewMeteoraDammV2(solClient), nil
+	case pkg.ProtocolNameLifinity:
+		return NewLifinity(solClient), nil
+	case pkg.ProtocolNamePhoenix:
+		return NewPhoenix(solClient), nil
+	case pkg.ProtocolNameOpenBook:
+		return NewOpenBook(solClient), nil
+	}
+	return nil, fmt.Errorf("unknown protocol %s", name)
+}
@@ -0,0 +1,118 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// SPLStakePoolProtocol discovers SPL stake pools, such as Jito's, as pools
+// between SOL and their liquid staking token
+type SPLStakePoolProtocol struct {
+	SolClient *sol.Client
+}
+
+func NewSPLStakePool(solClient *sol.Client) *SPLStakePoolProtocol {
+	return &SPLStakePoolProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *SPLStakePoolProtocol) ProtocolName() pkg.ProtocolName {
+	return pkg.ProtocolNameSPLStakePool
+}
+
+// FetchPoolsByPair finds the stake pools minting the non-SOL side of the
+// pair. Pairs without SOL have none
+func (p *SPLStakePoolProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse
+func (p *SPLStakePoolProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	poolMint := baseMint
+	switch sol.WSOL.String() {
+	case baseMint:
+		poolMint = quoteMint
+	case quoteMint:
+	default:
+		return nil, pkg.PoolCoverage{}, nil
+	}
+	poolMintPubkey, err := solana.PublicKeyFromBase58(poolMint)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("invalid pool mint address: %w", err)
+	}
+
+	accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, stakepool.ProgramID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  []byte{1},
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: stakepool.PoolMintOffset,
+					Bytes:  poolMintPubkey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch stake pools minting %s: %w", poolMint, err)
+	}
+	pools, coverage := decodeStakePools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves several stake pools with a single batched account lookup
+func (p *SPLStakePoolProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeStakePools(accounts)
+	return pools, nil
+}
+
+func (p *SPLStakePoolProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+
+	pool := &stakepool.StakePool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// decodeStakePools decodes stake pool accounts, skipping ones that fail to parse
+func decodeStakePools(programAccounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(programAccounts)}
+	for _, v := range programAccounts {
+		pool := &stakepool.StakePool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
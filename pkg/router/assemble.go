@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// RouteInstructions is everything an integrator needs to assemble and sign
+// a route's transaction themselves
+type RouteInstructions struct {
+	// Instructions is the full ordered list, including WSOL wrap/unwrap steps
+	Instructions []solana.Instruction
+	// Accounts is every account referenced by Instructions, deduplicated with
+	// signer/writable flags merged, program IDs included
+	Accounts solana.AccountMetaSlice
+	// AddressLookupTables are the tables published by the route's pools
+	AddressLookupTables []solana.PublicKey
+	// Signers are the accounts that must sign, user first
+	Signers []solana.PublicKey
+}
+
+// ResolveRouteInstructions builds a route's instructions for user and
+// resolves its accounts, lookup tables and required signers without
+// fetching a blockhash, signing or sending
+func ResolveRouteInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, route *Route) (*RouteInstructions, error) {
+	instructions, err := BuildRouteInstructionsWithWSOL(ctx, solClient, user, route)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &RouteInstructions{
+		Instructions: instructions,
+		Accounts:     make(solana.AccountMetaSlice, 0),
+		Signers:      []solana.PublicKey{user},
+	}
+
+	index := make(map[solana.PublicKey]int)
+	addAccount := func(meta *solana.AccountMeta) {
+		if i, ok := index[meta.PublicKey]; ok {
+			resolved.Accounts[i].IsSigner = resolved.Accounts[i].IsSigner || meta.IsSigner
+			resolved.Accounts[i].IsWritable = resolved.Accounts[i].IsWritable || meta.IsWritable
+			return
+		}
+		index[meta.PublicKey] = len(resolved.Accounts)
+		resolved.Accounts = append(resolved.Accounts, solana.NewAccountMeta(meta.PublicKey, meta.IsWritable, meta.IsSigner))
+	}
+	for _, instruction := range instructions {
+		for _, meta := range instruction.Accounts() {
+			addAccount(meta)
+		}
+		addAccount(solana.NewAccountMeta(instruction.ProgramID(), false, false))
+	}
+
+	for _, meta := range resolved.Accounts {
+		if meta.IsSigner && !meta.PublicKey.Equals(user) {
+			resolved.Signers = append(resolved.Signers, meta.PublicKey)
+		}
+	}
+
+	seenTables := make(map[solana.PublicKey]bool)
+	for _, hop := range route.Hops {
+		tablePool, ok := hop.Pool.(pkg.LookupTablePool)
+		if !ok {
+			continue
+		}
+		for _, table := range tablePool.AddressLookupTables() {
+			if !seenTables[table] {
+				seenTables[table] = true
+				resolved.AddressLookupTables = append(resolved.AddressLookupTables, table)
+			}
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,158 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// ErrBigOrder is wrapped by the *BigOrderAdvisory GetBestPool returns for
+// orders too large for the pool's liquidity
+var ErrBigOrder = errors.New("order is too large for the route's liquidity")
+
+// BigOrderAdvisory describes an order larger than SimpleRouter.BigOrderBps of
+// the best pool's input reserve, with ways to trade it at a sane price:
+// spread over SuggestedSplits pools, e.g. with OptimizeSplit, and over
+// TWAPSlices orders of SliceAmount in time
+type BigOrderAdvisory struct {
+	Pool      pkg.Pool
+	InputMint string
+	AmountIn  math.Int
+	// AmountOut is what the best pool quoted for the whole order
+	AmountOut math.Int
+	// Liquidity is the best pool's reserve of the input token
+	Liquidity math.Int
+	// FractionBps is AmountIn over Liquidity
+	FractionBps int
+	// EstimatedImpactBps is the quote's shortfall against the spot price of
+	// the reserves after fees; nil when the pool's fee or price is unknown
+	EstimatedImpactBps math.LegacyDec
+	// SuggestedSplits is how many pools, deepest first, the order needs to
+	// keep every share within BigOrderBps of its pool's reserve
+	SuggestedSplits int
+	// TWAPSlices is how many orders of SliceAmount the order should be cut
+	// into when even the split would exceed the threshold; 1 when the split
+	// suffices
+	TWAPSlices  int
+	SliceAmount math.Int
+}
+
+func (a *BigOrderAdvisory) Error() string {
+	return fmt.Sprintf("%v: %s is %d bps of pool %s's reserve, split over %d pools in %d slices of %s",
+		ErrBigOrder, a.AmountIn, a.FractionBps, a.Pool.GetID(), a.SuggestedSplits, a.TWAPSlices, a.SliceAmount)
+}
+
+func (a *BigOrderAdvisory) Unwrap() error {
+	return ErrBigOrder
+}
+
+// TWAPSlice is one order of a TWAP schedule
+type TWAPSlice struct {
+	At       time.Time
+	AmountIn math.Int
+}
+
+// Schedule spreads the order over TWAPSlices orders interval apart from
+// start, the last taking the remainder
+func (a *BigOrderAdvisory) Schedule(start time.Time, interval time.Duration) []TWAPSlice {
+	slices := make([]TWAPSlice, 0, a.TWAPSlices)
+	remaining := a.AmountIn
+	for i := 0; i < a.TWAPSlices && remaining.IsPositive(); i++ {
+		amount := a.SliceAmount
+		if i == a.TWAPSlices-1 || amount.GT(remaining) {
+			amount = remaining
+		}
+		slices = append(slices, TWAPSlice{At: start.Add(time.Duration(i) * interval), AmountIn: amount})
+		remaining = remaining.Sub(amount)
+	}
+	return slices
+}
+
+// inputReserve returns the reserve of tokenIn a pool quoted against, false
+// for pools without reserves
+func inputReserve(pool pkg.Pool, tokenIn string) (math.Int, bool) {
+	reservesPool, ok := pool.(pkg.ReservesPool)
+	if !ok {
+		return math.Int{}, false
+	}
+	base, quote := reservesPool.Reserves()
+	reserve := base
+	if baseMint, _ := pool.GetTokens(); tokenIn != baseMint {
+		reserve = quote
+	}
+	if reserve.IsNil() || !reserve.IsPositive() {
+		return math.Int{}, false
+	}
+	return reserve, true
+}
+
+// bigOrderAdvisory checks the best quote against BigOrderBps of its pool's
+// input reserve, returning nil for orders within it or pools without reserves
+func (r *SimpleRouter) bigOrderAdvisory(best PoolQuote, candidates []PoolQuote, tokenIn string, amountIn math.Int) *BigOrderAdvisory {
+	liquidity, ok := inputReserve(best.Pool, tokenIn)
+	if !ok {
+		return nil
+	}
+	threshold := liquidity.MulRaw(int64(r.BigOrderBps)).QuoRaw(10000)
+	if amountIn.LTE(threshold) {
+		return nil
+	}
+
+	advisory := &BigOrderAdvisory{
+		Pool:        best.Pool,
+		InputMint:   tokenIn,
+		AmountIn:    amountIn,
+		AmountOut:   best.AmountOut,
+		Liquidity:   liquidity,
+		FractionBps: fractionBps(amountIn, liquidity),
+		TWAPSlices:  1,
+		SliceAmount: amountIn,
+	}
+	if features := r.Features([]PoolQuote{best}, tokenIn, amountIn); len(features) == 1 && !features[0].Fee.IsNil() {
+		advisory.EstimatedImpactBps = features[0].ImpactBps
+	}
+
+	// give the deepest pools their share up to the threshold until the order fits
+	reserves := make([]math.Int, 0, len(candidates))
+	for _, candidate := range candidates {
+		if reserve, ok := inputReserve(candidate.Pool, tokenIn); ok {
+			reserves = append(reserves, reserve)
+		}
+	}
+	sort.Slice(reserves, func(i, j int) bool { return reserves[i].GT(reserves[j]) })
+	capacity := math.ZeroInt()
+	for _, reserve := range reserves {
+		capacity = capacity.Add(reserve.MulRaw(int64(r.BigOrderBps)).QuoRaw(10000))
+		advisory.SuggestedSplits++
+		if capacity.GTE(amountIn) {
+			return advisory
+		}
+	}
+	if !capacity.IsPositive() {
+		return advisory
+	}
+	slices := amountIn.Add(capacity).SubRaw(1).Quo(capacity)
+	if !slices.IsInt64() || slices.Int64() > maxTWAPSlices {
+		slices = math.NewInt(maxTWAPSlices)
+	}
+	advisory.TWAPSlices = int(slices.Int64())
+	advisory.SliceAmount = amountIn.Add(slices).SubRaw(1).Quo(slices)
+	return advisory
+}
+
+// maxTWAPSlices caps the suggested schedule for orders dwarfing the pair's
+// liquidity, which no schedule makes cheap
+const maxTWAPSlices = 1000
+
+// fractionBps is amount over total in basis points, saturating at MaxInt32
+func fractionBps(amount, total math.Int) int {
+	bps := amount.MulRaw(10000).Quo(total)
+	if !bps.IsInt64() || bps.Int64() > 1<<31-1 {
+		return 1<<31 - 1
+	}
+	return int(bps.Int64())
+}
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultBreakerThreshold   = 3
+	DefaultBreakerBaseBackoff = 5 * time.Second
+	DefaultBreakerMaxBackoff  = 5 * time.Minute
+
+	// maxBreakerDoublings caps how many times a quarantine window doubles
+	maxBreakerDoublings = 30
+)
+
+// ErrPoolQuarantined is returned for pools the circuit breaker is skipping
+var ErrPoolQuarantined = errors.New("pool is quarantined after repeated failures")
+
+// CircuitBreaker quarantines pools that keep failing to quote or execute so a
+// broken venue stops consuming RPC budget. Each trip doubles the quarantine
+// window up to MaxBackoff; a success resets the pool
+type CircuitBreaker struct {
+	Threshold   int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// OnTrip, when set, is called after a pool is quarantined
+	OnTrip func(poolID string, backoff time.Duration, err error)
+
+	mu    sync.Mutex
+	pools map[string]*breakerState
+
+	trips   atomic.Uint64
+	skipped atomic.Uint64
+}
+
+type breakerState struct {
+	failures int
+	trips    int
+	until    time.Time
+}
+
+// BreakerStats is a snapshot of circuit breaker metrics
+type BreakerStats struct {
+	Trips       uint64
+	Skipped     uint64
+	Quarantined int
+}
+
+// NewCircuitBreaker creates a breaker with the default threshold and backoff
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold:   DefaultBreakerThreshold,
+		BaseBackoff: DefaultBreakerBaseBackoff,
+		MaxBackoff:  DefaultBreakerMaxBackoff,
+		pools:       make(map[string]*breakerState),
+	}
+}
+
+// Allow reports whether the pool may be used right now
+func (b *CircuitBreaker) Allow(poolID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.pools[poolID]
+	if !ok || !time.Now().Before(state.until) {
+		return true
+	}
+	b.skipped.Add(1)
+	return false
+}
+
+// RecordSuccess clears the failure history of a pool
+func (b *CircuitBreaker) RecordSuccess(poolID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.pools, poolID)
+}
+
+// RecordFailure counts a quote or execution failure and quarantines the pool
+// once it reaches the threshold of consecutive failures
+func (b *CircuitBreaker) RecordFailure(poolID string, err error) {
+	backoff, tripped := b.recordFailure(poolID)
+	if !tripped {
+		return
+	}
+	log.Printf("quarantining pool %s for %v: %v", poolID, backoff, err)
+	if b.OnTrip != nil {
+		b.OnTrip(poolID, backoff, err)
+	}
+}
+
+// recordFailure counts the failure and returns the quarantine window if the pool tripped
+func (b *CircuitBreaker) recordFailure(poolID string) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pools == nil {
+		b.pools = make(map[string]*breakerState)
+	}
+	state, ok := b.pools[poolID]
+	if !ok {
+		state = &breakerState{}
+		b.pools[poolID] = state
+	}
+
+	state.failures++
+	if state.failures < b.threshold() {
+		return 0, false
+	}
+
+	// the shift is bounded so the doubling cannot overflow
+	backoff := b.baseBackoff() << min(state.trips, maxBreakerDoublings)
+	if backoff <= 0 || backoff > b.maxBackoff() {
+		backoff = b.maxBackoff()
+	}
+	state.failures = 0
+	state.trips++
+	state.until = time.Now().Add(backoff)
+	b.trips.Add(1)
+	return backoff, true
+}
+
+// Stats returns a snapshot of breaker metrics
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	quarantined := 0
+	now := time.Now()
+	for _, state := range b.pools {
+		if now.Before(state.until) {
+			quarantined++
+		}
+	}
+	return BreakerStats{
+		Trips:       b.trips.Load(),
+		Skipped:     b.skipped.Load(),
+		Quarantined: quarantined,
+	}
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.Threshold <= 0 {
+		return DefaultBreakerThreshold
+	}
+	return b.Threshold
+}
+
+func (b *CircuitBreaker) baseBackoff() time.Duration {
+	if b.BaseBackoff <= 0 {
+		return DefaultBreakerBaseBackoff
+	}
+	return b.BaseBackoff
+}
+
+func (b *CircuitBreaker) maxBackoff() time.Duration {
+	if b.MaxBackoff <= 0 {
+		return DefaultBreakerMaxBackoff
+	}
+	return b.MaxBackoff
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerBackoffDoesNotOverflow(t *testing.T) {
+	b := NewCircuitBreaker()
+	b.Threshold = 1
+	b.BaseBackoff = time.Nanosecond
+	b.MaxBackoff = math.MaxInt64
+
+	var backoff time.Duration
+	for i := 0; i < 100; i++ {
+		var tripped bool
+		backoff, tripped = b.recordFailure("pool")
+		if !tripped {
+			t.Fatalf("failure %d did not trip the breaker", i)
+		}
+		if backoff <= 0 {
+			t.Fatalf("trip %d backoff = %v, want positive", i, backoff)
+		}
+	}
+	if want := time.Nanosecond << maxBreakerDoublings; backoff != want {
+		t.Fatalf("backoff = %v, want it capped at %v", backoff, want)
+	}
+}
+
+func TestCircuitBreakerSuccessResets(t *testing.T) {
+	b := NewCircuitBreaker()
+	b.Threshold = 2
+	b.RecordFailure("pool", errors.New("reverted"))
+	b.RecordSuccess("pool")
+	b.RecordFailure("pool", errors.New("reverted"))
+	if !b.Allow("pool") {
+		t.Fatal("a success did not reset the consecutive failure count")
+	}
+	b.RecordFailure("pool", errors.New("reverted"))
+	if b.Allow("pool") {
+		t.Fatal("pool was not quarantined after reaching the threshold")
+	}
+}
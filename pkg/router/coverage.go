@@ -0,0 +1,88 @@
+package router
+
+import (
+	"sync"
+
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// ProtocolCoverage is the running total of one protocol's discovery and
+// quote outcomes
+type ProtocolCoverage struct {
+	pkg.PoolCoverage
+	// Quoted and QuoteFailed count quotes of the protocol's pools; quotes
+	// skipped by the circuit breaker or cut short by the caller are left out
+	Quoted      int
+	QuoteFailed int
+}
+
+// CoverageMetrics accumulates per protocol how many accounts discovery found,
+// decoded and skipped and how many quotes succeeded, so a venue whose layout
+// change breaks decoding shows up as a jump in skipped accounts
+type CoverageMetrics struct {
+	mu        sync.Mutex
+	protocols map[pkg.ProtocolName]*ProtocolCoverage
+}
+
+// NewCoverageMetrics creates empty coverage metrics
+func NewCoverageMetrics() *CoverageMetrics {
+	return &CoverageMetrics{
+		protocols: make(map[pkg.ProtocolName]*ProtocolCoverage),
+	}
+}
+
+// Stats returns a snapshot of the totals per protocol
+func (m *CoverageMetrics) Stats() map[pkg.ProtocolName]ProtocolCoverage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[pkg.ProtocolName]ProtocolCoverage, len(m.protocols))
+	for name, coverage := range m.protocols {
+		stats[name] = *coverage
+	}
+	return stats
+}
+
+// Reset clears all totals
+func (m *CoverageMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.protocols = make(map[pkg.ProtocolName]*ProtocolCoverage)
+}
+
+func (m *CoverageMetrics) recordDiscovery(name pkg.ProtocolName, coverage pkg.PoolCoverage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	total := m.protocol(name)
+	total.Discovered += coverage.Discovered
+	total.Decoded += coverage.Decoded
+	total.DecodeFailed += coverage.DecodeFailed
+	total.Ineligible += coverage.Ineligible
+}
+
+func (m *CoverageMetrics) recordQuote(name pkg.ProtocolName, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.protocol(name).QuoteFailed++
+	} else {
+		m.protocol(name).Quoted++
+	}
+}
+
+// protocol returns the totals of name, creating them on first use. The
+// caller holds mu
+func (m *CoverageMetrics) protocol(name pkg.ProtocolName) *ProtocolCoverage {
+	if m.protocols == nil {
+		m.protocols = make(map[pkg.ProtocolName]*ProtocolCoverage)
+	}
+	total, ok := m.protocols[name]
+	if !ok {
+		total = &ProtocolCoverage{}
+		m.protocols[name] = total
+	}
+	return total
+}
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultDiscoveryMaxAgeSlots is about an hour of slots; pools created after
+// an entry was stored stay unseen until it expires
+const DefaultDiscoveryMaxAgeSlots = 9_000
+
+// DiscoveryCache persists the pool IDs found by getProgramAccounts per pair
+// and protocol, stamped with the slot they were found at. Across restarts
+// QueryAllPools reloads those pools with getMultipleAccounts instead of
+// rescanning the program, and rescans only when an entry expired or one of
+// its pools no longer loads
+type DiscoveryCache struct {
+	client *sol.Client
+	path   string
+
+	// MaxAgeSlots is how long an entry is trusted
+	MaxAgeSlots uint64
+
+	mu      sync.Mutex
+	entries map[string]DiscoveryEntry
+}
+
+// DiscoveryEntry is the outcome of one pair scan on one protocol
+type DiscoveryEntry struct {
+	PoolIDs []string `json:"poolIds"`
+	Slot    uint64   `json:"slot"`
+}
+
+// NewDiscoveryCache creates a discovery cache persisted at path, loading the
+// entries of a previous run if the file exists
+func NewDiscoveryCache(solClient *sol.Client, path string) (*DiscoveryCache, error) {
+	c := &DiscoveryCache{
+		client:      solClient,
+		path:        path,
+		MaxAgeSlots: DefaultDiscoveryMaxAgeSlots,
+		entries:     make(map[string]DiscoveryEntry),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discovery cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func discoveryKey(protocol pkg.ProtocolName, baseMint, quoteMint string) string {
+	return string(protocol) + "/" + baseMint + "/" + quoteMint
+}
+
+// Lookup returns the pool IDs stored for the pair on protocol, if the entry
+// is younger than MaxAgeSlots
+func (c *DiscoveryCache) Lookup(ctx context.Context, protocol pkg.ProtocolName, baseMint, quoteMint string) ([]string, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[discoveryKey(protocol, baseMint, quoteMint)]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	slot, err := c.client.GetSlot(ctx, rpc.CommitmentConfirmed)
+	if err != nil || slot < entry.Slot || slot-entry.Slot > c.MaxAgeSlots {
+		return nil, false
+	}
+	return entry.PoolIDs, true
+}
+
+// Store records the pools found for the pair on protocol at the current slot
+// and persists the cache
+func (c *DiscoveryCache) Store(ctx context.Context, protocol pkg.ProtocolName, baseMint, quoteMint string, pools []pkg.Pool) error {
+	slot, err := c.client.GetSlot(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to get slot: %w", err)
+	}
+	poolIDs := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		poolIDs = append(poolIDs, pool.GetID())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[discoveryKey(protocol, baseMint, quoteMint)] = DiscoveryEntry{PoolIDs: poolIDs, Slot: slot}
+	return c.save()
+}
+
+// Invalidate drops the entry of the pair on protocol
+func (c *DiscoveryCache) Invalidate(protocol pkg.ProtocolName, baseMint, quoteMint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, discoveryKey(protocol, baseMint, quoteMint))
+}
+
+// save writes the entries through a temporary file so a crash never leaves a
+// truncated cache behind
+func (c *DiscoveryCache) save() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode discovery cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write discovery cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write discovery cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write discovery cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// fetchCachedPools reloads the pools of a cached scan, reporting false when
+// there is no usable entry or any of its pools failed to load
+func (c *DiscoveryCache) fetchCachedPools(ctx context.Context, proto pkg.Protocol, baseMint, quoteMint string) ([]pkg.Pool, bool) {
+	poolIDs, ok := c.Lookup(ctx, proto.ProtocolName(), baseMint, quoteMint)
+	if !ok {
+		return nil, false
+	}
+	if len(poolIDs) == 0 {
+		return []pkg.Pool{}, true
+	}
+	pools, err := pkg.FetchPoolsByIDs(ctx, proto, poolIDs)
+	if err != nil || len(pools) != len(poolIDs) {
+		return nil, false
+	}
+	for _, pool := range pools {
+		base, quote := pool.GetTokens()
+		if !(base == baseMint && quote == quoteMint) && !(base == quoteMint && quote == baseMint) {
+			return nil, false
+		}
+	}
+	return pools, true
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// fakePool quotes a fixed output and records how often it was refreshed
+type fakePool struct {
+	id        string
+	protocol  pkg.ProtocolName
+	base      string
+	quote     string
+	amountOut math.Int
+	updates   int
+}
+
+func (p *fakePool) ProtocolName() pkg.ProtocolName          { return p.protocol }
+func (p *fakePool) GetProgramID() solana.PublicKey          { return solana.PublicKey{} }
+func (p *fakePool) GetID() string                           { return p.id }
+func (p *fakePool) GetTokens() (baseMint, quoteMint string) { return p.base, p.quote }
+
+func (p *fakePool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	return p.amountOut, nil
+}
+
+func (p *fakePool) BuildSwapInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, inputAmount math.Int, minOut math.Int, userBaseAccount solana.PublicKey, userQuoteAccount solana.PublicKey) ([]solana.Instruction, error) {
+	return nil, nil
+}
+
+func (p *fakePool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*fakePool)
+	if !ok {
+		return false
+	}
+	p.amountOut = fresh.amountOut
+	p.updates++
+	return true
+}
+
+// fakeProtocol returns its pools for any pair, or fails with err
+type fakeProtocol struct {
+	name  pkg.ProtocolName
+	pools []pkg.Pool
+	err   error
+}
+
+func (p *fakeProtocol) ProtocolName() pkg.ProtocolName { return p.name }
+
+func (p *fakeProtocol) FetchPoolsByPair(ctx context.Context, baseMint, quoteMint string) ([]pkg.Pool, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.pools, nil
+}
+
+func (p *fakeProtocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
+	for _, pool := range p.pools {
+		if pool.GetID() == poolID {
+			return pool, nil
+		}
+	}
+	return nil, p.err
+}
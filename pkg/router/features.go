@@ -0,0 +1,149 @@
+package router
+
+import (
+	"context"
+	"log"
+	stdmath "math"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// QuoteFeatures is the feature vector of one successful pool quote, for
+// external models that rank pools. Features a pool cannot report are nil
+type QuoteFeatures struct {
+	PoolID       string
+	Protocol     pkg.ProtocolName
+	InputMint    string
+	OutputMint   string
+	AmountIn     math.Int
+	AmountOut    math.Int
+	NetAmountOut math.Int
+	// NormalizedOut is NetAmountOut in whole output tokens
+	NormalizedOut math.LegacyDec
+	// ReserveIn and ReserveOut are the pool's reserves of the input and
+	// output mints, for pools that trade against two reserves
+	ReserveIn  math.Int
+	ReserveOut math.Int
+	// Fee is the trading fee charged on AmountIn, in input units
+	Fee math.Int
+	// ImpactBps is how far the quote falls short of the pool's spot price
+	// after fees, from its reserves
+	ImpactBps math.LegacyDec
+	// Volume is the pool's recent volume from the router's volume index
+	Volume math.Int
+	// Staleness is the age of the quote, non-zero when it came from the
+	// quote cache
+	Staleness time.Duration
+}
+
+// VolumeIndex reports the recent traded volume of pools, in whatever window
+// and unit the index keeps. Pools it has not indexed report false
+type VolumeIndex interface {
+	RecentVolume(poolID string) (math.Int, bool)
+}
+
+// PoolScorer ranks quotes with an external model. Score returns one score per
+// feature vector, the highest winning
+type PoolScorer interface {
+	Score(ctx context.Context, features []QuoteFeatures) ([]float64, error)
+}
+
+// ScorerFunc adapts a function to PoolScorer
+type ScorerFunc func(ctx context.Context, features []QuoteFeatures) ([]float64, error)
+
+// Score calls f
+func (f ScorerFunc) Score(ctx context.Context, features []QuoteFeatures) ([]float64, error) {
+	return f(ctx, features)
+}
+
+// Features returns the feature vectors of the successful quotes, in order
+func (r *SimpleRouter) Features(quotes []PoolQuote, tokenIn string, amountIn math.Int) []QuoteFeatures {
+	now := time.Now()
+	features := make([]QuoteFeatures, 0, len(quotes))
+	for _, quote := range quotes {
+		if quote.Err != nil {
+			continue
+		}
+		f := QuoteFeatures{
+			PoolID:        quote.Pool.GetID(),
+			Protocol:      quote.Pool.ProtocolName(),
+			InputMint:     tokenIn,
+			OutputMint:    quote.OutputMint,
+			AmountIn:      amountIn,
+			AmountOut:     quote.AmountOut,
+			NetAmountOut:  quote.NetAmountOut,
+			NormalizedOut: quote.NormalizedOut,
+		}
+		if !quote.QuotedAt.IsZero() {
+			f.Staleness = now.Sub(quote.QuotedAt)
+		}
+		if feePool, ok := quote.Pool.(pkg.SwapFeePool); ok {
+			f.Fee = feePool.SwapFee(tokenIn, amountIn)
+		}
+		if reservesPool, ok := quote.Pool.(pkg.ReservesPool); ok {
+			base, quoteReserve := reservesPool.Reserves()
+			f.ReserveIn, f.ReserveOut = base, quoteReserve
+			if baseMint, _ := quote.Pool.GetTokens(); tokenIn != baseMint {
+				f.ReserveIn, f.ReserveOut = quoteReserve, base
+			}
+			f.ImpactBps = impactBps(f)
+		}
+		if r.Volume != nil {
+			if volume, ok := r.Volume.RecentVolume(f.PoolID); ok {
+				f.Volume = volume
+			}
+		}
+		features = append(features, f)
+	}
+	return features
+}
+
+// impactBps compares the quoted output with what the input after fees buys
+// at the spot price of the reserves
+func impactBps(f QuoteFeatures) math.LegacyDec {
+	if f.ReserveIn.IsNil() || f.ReserveOut.IsNil() || !f.ReserveIn.IsPositive() || f.AmountOut.IsNil() {
+		return math.LegacyDec{}
+	}
+	afterFee := f.AmountIn
+	if !f.Fee.IsNil() {
+		afterFee = afterFee.Sub(f.Fee)
+	}
+	if !afterFee.IsPositive() {
+		return math.LegacyDec{}
+	}
+	spotOut := math.LegacyNewDecFromInt(afterFee).MulInt(f.ReserveOut).QuoInt(f.ReserveIn)
+	if !spotOut.IsPositive() {
+		return math.LegacyDec{}
+	}
+	shortfall := math.LegacyOneDec().Sub(math.LegacyNewDecFromInt(f.AmountOut).Quo(spotOut))
+	return shortfall.MulInt64(10000)
+}
+
+// bestScored returns the candidate the scorer ranks highest. It reports false
+// when the scorer fails, so the caller keeps the best net output
+func (r *SimpleRouter) bestScored(ctx context.Context, candidates []PoolQuote, tokenIn string, amountIn math.Int) (*PoolQuote, bool) {
+	scores, err := r.Scorer.Score(ctx, r.Features(candidates, tokenIn, amountIn))
+	if err != nil {
+		log.Printf("pool scorer failed, ranking by net output: %v", err)
+		return nil, false
+	}
+	if len(scores) != len(candidates) {
+		log.Printf("pool scorer returned %d scores for %d quotes, ranking by net output", len(scores), len(candidates))
+		return nil, false
+	}
+	best := -1
+	for i, score := range scores {
+		if stdmath.IsNaN(score) {
+			continue
+		}
+		if best < 0 || score > scores[best] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, false
+	}
+	return &candidates[best], true
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MinOutMode controls where slippage protection is placed on multi-hop routes
+type MinOutMode int
+
+const (
+	// MinOutPerHop constrains every hop, failing as soon as any leg slips
+	MinOutPerHop MinOutMode = iota
+	// MinOutFinalOnly only constrains the final output; intermediate legs are
+	// unconstrained unless their venue needs an exact output amount
+	MinOutFinalOnly
+)
+
+// ApplyMinOut re-quotes the route hop by hop and sets each hop's input and
+// minimum output. Every hop after the first spends only the slippage-adjusted
+// output of the previous one, so legs still fill when an earlier one slips.
+// Each hop's Slot is set to the read its quote priced
+func (r *SimpleRouter) ApplyMinOut(ctx context.Context, solClient *sol.Client, route *Route, slippageBps int, mode MinOutMode) error {
+	if len(route.Hops) == 0 {
+		return fmt.Errorf("route has no hops")
+	}
+	if slippageBps < 0 || slippageBps >= 10000 {
+		return fmt.Errorf("invalid slippage %d bps", slippageBps)
+	}
+
+	amountIn := route.AmountIn
+	for i := range route.Hops {
+		hop := &route.Hops[i]
+		hop.AmountIn = amountIn
+
+		amountOut, err := r.quotePool(ctx, solClient, hop.Pool, hop.InputMint, amountIn)
+		if err != nil {
+			return fmt.Errorf("hop %d: failed to quote pool %s: %w", i, hop.Pool.GetID(), err)
+		}
+		hop.AmountOut = amountOut
+		guarded := ApplySlippage(amountOut, slippageBps)
+
+		last := i == len(route.Hops)-1
+		if last || mode == MinOutPerHop || minOutIsExact(hop.Pool, hop.InputMint) {
+			hop.MinAmountOut = guarded
+		} else {
+			hop.MinAmountOut = math.ZeroInt()
+		}
+		amountIn = guarded
+	}
+
+	route.AmountOut = route.Hops[len(route.Hops)-1].AmountOut
+	route.RecordSlots(solClient)
+	return nil
+}
+
+// ApplySlippage returns quote reduced by slippageBps basis points, rounded
+// down so the threshold never exceeds the tolerated output
+func ApplySlippage(quote math.Int, slippageBps int) math.Int {
+	return quote.Mul(math.NewInt(int64(10000 - slippageBps))).Quo(math.NewInt(10000))
+}
+
+// ErrMinOutUnverifiable is returned for pools that neither decode their swap
+// instructions' minimum output nor opt out of the check
+var ErrMinOutUnverifiable = errors.New("pool cannot verify its encoded min out")
+
+// CheckMinOut verifies that the swap instructions built for pool encode
+// exactly minOut as their threshold, or minOut rounded up to the step of
+// pools that round it. Pools without a minimum output field must opt out
+// through pkg.UnencodedMinOutPool; any other pool that cannot decode its own
+// instructions fails the check
+func CheckMinOut(pool pkg.Pool, inputMint string, instructions []solana.Instruction, minOut math.Int) error {
+	decoder, ok := pool.(pkg.MinOutDecoder)
+	if !ok {
+		if unencoded, ok := pool.(pkg.UnencodedMinOutPool); ok && unencoded.MinOutUnencoded(inputMint) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s pool %s", ErrMinOutUnverifiable, pool.ProtocolName(), pool.GetID())
+	}
+	encoded, err := decoder.DecodeMinOut(inputMint, instructions)
+	if err != nil {
+		return fmt.Errorf("failed to decode min out of pool %s: %w", pool.GetID(), err)
+	}
+	if !encoded.Equal(roundMinOut(pool, inputMint, minOut)) {
+		return fmt.Errorf("pool %s encoded min out %s, requested %s", pool.GetID(), encoded, minOut)
+	}
+	return nil
+}
+
+// roundMinOut is minOut rounded up to the step of pools that encode it in
+// whole steps
+func roundMinOut(pool pkg.Pool, inputMint string, minOut math.Int) math.Int {
+	rounded, ok := pool.(pkg.RoundedMinOutPool)
+	if !ok {
+		return minOut
+	}
+	step := rounded.MinOutStep(inputMint)
+	if step.IsNil() || !step.IsPositive() {
+		return minOut
+	}
+	return minOut.Add(step).SubRaw(1).Quo(step).Mul(step)
+}
+
+func minOutIsExact(pool pkg.Pool, inputMint string) bool {
+	exact, ok := pool.(pkg.ExactOutputPool)
+	return ok && exact.MinOutIsExact(inputMint)
+}
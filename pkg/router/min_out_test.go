@@ -0,0 +1,301 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/lifinity"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/pool/meteora"
+	"github.com/solana-zh/solroute/pkg/pool/openbook"
+	"github.com/solana-zh/solroute/pkg/pool/phoenix"
+	"github.com/solana-zh/solroute/pkg/pool/pump"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/aldrin"
+	"github.com/solana-zh/solroute/x/pool/gamma"
+	"github.com/solana-zh/solroute/x/pool/moonshot"
+	"github.com/solana-zh/solroute/x/pool/obric"
+	"github.com/solana-zh/solroute/x/pool/rfq"
+	"github.com/solana-zh/solroute/x/pool/saber"
+	"github.com/solana-zh/solroute/x/pool/sanctum"
+	"github.com/solana-zh/solroute/x/pool/stabble"
+)
+
+var (
+	minOutBase  = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	minOutQuote = solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB")
+)
+
+// minOutField is where a venue's IDL places the minimum output of a swap:
+// the u64 at offset of the instruction data starting with prefix, in units
+// of lot native tokens
+type minOutField struct {
+	prefix []byte
+	offset int
+	lot    uint64
+}
+
+// sighash is the anchor instruction discriminator of name
+func sighash(name string) []byte {
+	sum := sha256.Sum256([]byte("global:" + name))
+	return sum[:8]
+}
+
+// minOutVenue is a pool whose swap instructions build without RPC reads and
+// the IDL field its minimum output is encoded in, per input mint
+type minOutVenue struct {
+	pool  pkg.Pool
+	field func(inputMint string) minOutField
+}
+
+// fixed is a venue field that does not depend on the swap direction
+func fixed(prefix []byte, offset int) func(string) minOutField {
+	return func(string) minOutField { return minOutField{prefix: prefix, offset: offset, lot: 1} }
+}
+
+// byInput picks the field of a swap selling base or buying it
+func byInput(base solana.PublicKey, sell, buy minOutField) func(string) minOutField {
+	return func(inputMint string) minOutField {
+		if inputMint == base.String() {
+			return sell
+		}
+		return buy
+	}
+}
+
+// minOutVenues are the venues checked against their IDLs, each trading
+// minOutBase or, for SOL venues, its mint against wSOL. The prefixes and
+// offsets are written out from the programs' IDLs rather than taken from
+// the pool packages, so a builder and decoder sharing a wrong offset fail
+func minOutVenues() []minOutVenue {
+	key := func() solana.PublicKey { return solana.NewWallet().PublicKey() }
+	// a swap authority is derived from the pool address and a stored nonce
+	saberPool := key()
+	_, saberNonce, _ := solana.FindProgramAddress([][]byte{saberPool[:]}, saber.ProgramID)
+	return []minOutVenue{
+		// swap_base_in: tag 9, amount_in, minimum_amount_out
+		{&raydium.AMMPool{PoolId: key(), BaseMint: minOutBase, QuoteMint: minOutQuote}, fixed([]byte{9}, 9)},
+		// swap_base_input(amount_in, minimum_amount_out)
+		{&raydium.CPMMPool{PoolId: key(), Token0Mint: minOutBase, Token1Mint: minOutQuote}, fixed(sighash("swap_base_input"), 16)},
+		// sell(base_amount_in, min_quote_amount_out); buy(base_amount_out, max_quote_amount_in)
+		{&pump.PumpAMMPool{PoolId: key(), BaseMint: minOutBase, QuoteMint: sol.WSOL}, byInput(minOutBase,
+			minOutField{sighash("sell"), 16, 1}, minOutField{sighash("buy"), 8, 1})},
+		// WithdrawSolWithSlippage (26) and DepositSolWithSlippage (25): tag,
+		// amount in, minimum out
+		{&stakepool.StakePool{PoolId: key(), PoolMint: minOutBase, TokenProgramID: solana.TokenProgramID}, byInput(minOutBase,
+			minOutField{[]byte{26}, 9, 1}, minOutField{[]byte{25}, 9, 1})},
+		// swap(amount_in, minimum_amount_out)
+		{&lifinity.LifinityPool{PoolId: key(), TokenAMint: minOutBase, TokenBMint: minOutQuote}, fixed(sighash("swap"), 16)},
+		// swap(amount_in, minimum_amount_out)
+		{&meteora.MeteoraDammV2Pool{PoolId: key(), TokenAMint: minOutBase, TokenBMint: minOutQuote}, fixed(sighash("swap"), 16)},
+		// swap2(amount_in, min_amount_out, remaining_accounts_info)
+		{&meteora.MeteoraDlmmPool{PoolId: key(), TokenXMint: minOutBase, TokenYMint: minOutQuote}, fixed(sighash("swap2"), 16)},
+		// sell and buy(token_amount, collateral_amount, fixed_side, slippage_bps)
+		{&moonshot.MoonshotPool{PoolId: key(), Mint: minOutBase}, byInput(minOutBase,
+			minOutField{sighash("sell"), 16, 1}, minOutField{sighash("buy"), 8, 1})},
+		// swap(tokens, min_tokens, side)
+		{&aldrin.AldrinPool{PoolId: key(), BaseMint: minOutBase, QuoteMint: minOutQuote}, fixed(sighash("swap"), 16)},
+		// swap_x_to_y and swap_y_to_x(input_amt, min_output_amt)
+		{&obric.ObricPool{PoolId: key(), MintX: minOutBase, MintY: minOutQuote}, byInput(minOutBase,
+			minOutField{sighash("swap_x_to_y"), 16, 1}, minOutField{sighash("swap_y_to_x"), 16, 1})},
+		// Swap: tag 1, amount_in, minimum_amount_out
+		{&saber.SaberPool{PoolId: saberPool, Nonce: saberNonce, TokenAMint: minOutBase, TokenBMint: minOutQuote}, fixed([]byte{1}, 9)},
+		// swap_base_input(amount_in, minimum_amount_out)
+		{&gamma.GammaPool{PoolId: key(), Token0Mint: minOutBase, Token1Mint: minOutQuote}, fixed(sighash("swap_base_input"), 16)},
+		// SwapExactIn: tag 1, src and dst calc account counts (u8), src and
+		// dst lst indexes (u32), min_amount_out, amount
+		{&sanctum.InfinityPool{
+			BaseLst:  sanctum.Lst{Mint: minOutBase, TokenProgram: solana.TokenProgramID},
+			QuoteLst: sanctum.Lst{Mint: minOutQuote, TokenProgram: solana.TokenProgramID},
+		}, fixed([]byte{1}, 11)},
+		// swap(amount_in: Option<u64>, minimum_amount_out)
+		{&stabble.StabblePool{
+			PoolId:     key(),
+			Tokens:     []stabble.PoolToken{{Mint: minOutBase}, {Mint: minOutQuote}},
+			QuoteIndex: 1,
+			VaultState: &stabble.Vault{},
+		}, fixed(sighash("swap"), 17)},
+		// Swap (0) of an ImmediateOrCancel packet (2) with side (bid 0, ask 1)
+		// and no price: num_base_lots, num_quote_lots, min_base_lots_to_fill,
+		// min_quote_lots_to_fill
+		{&phoenix.PhoenixPool{MarketId: key(), Header: phoenix.MarketHeader{
+			BaseMint:     minOutBase,
+			QuoteMint:    minOutQuote,
+			BaseLotSize:  1_000,
+			QuoteLotSize: 10,
+		}}, byInput(minOutBase,
+			minOutField{[]byte{0, 2, 1, 0}, 28, 10}, minOutField{[]byte{0, 2, 0, 0}, 20, 1_000})},
+	}
+}
+
+// encodedMinOut reads field from the instruction of programID it prefixes
+func encodedMinOut(t *testing.T, instructions []solana.Instruction, programID solana.PublicKey, field minOutField) uint64 {
+	t.Helper()
+	for _, instruction := range instructions {
+		if !instruction.ProgramID().Equals(programID) {
+			continue
+		}
+		data, err := instruction.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, field.prefix) {
+			continue
+		}
+		if len(data) < field.offset+8 {
+			t.Fatalf("instruction data of %d bytes ends before offset %d", len(data), field.offset)
+		}
+		return binary.LittleEndian.Uint64(data[field.offset:]) * field.lot
+	}
+	t.Fatalf("no instruction of %s starts with %x", programID, field.prefix)
+	return 0
+}
+
+func TestCheckMinOutPerVenue(t *testing.T) {
+	user := solana.NewWallet().PublicKey()
+	baseAccount := solana.NewWallet().PublicKey()
+	quoteAccount := solana.NewWallet().PublicKey()
+	amountIn := math.NewInt(5_000_000)
+	minOut := math.NewInt(1_234_567)
+
+	for _, venue := range minOutVenues() {
+		pool := venue.pool
+		if _, ok := pool.(pkg.MinOutDecoder); !ok {
+			t.Fatalf("%s does not decode its min out", pool.ProtocolName())
+		}
+		base, quote := pool.GetTokens()
+		for _, inputMint := range []string{base, quote} {
+			t.Run(string(pool.ProtocolName())+"/"+inputMint[:4], func(t *testing.T) {
+				instructions, err := pool.BuildSwapInstructions(context.Background(), nil, user, inputMint, amountIn, minOut, baseAccount, quoteAccount)
+				if err != nil {
+					t.Fatalf("failed to build swap: %v", err)
+				}
+				field := venue.field(inputMint)
+				want := minOut.Uint64()
+				if field.lot > 1 {
+					want = (want + field.lot - 1) / field.lot * field.lot
+				}
+				if got := encodedMinOut(t, instructions, pool.GetProgramID(), field); got != want {
+					t.Fatalf("instruction encodes min out %d at offset %d, want %d", got, field.offset, want)
+				}
+				if err := CheckMinOut(pool, inputMint, instructions, minOut); err != nil {
+					t.Fatal(err)
+				}
+				// a threshold other than the requested one is caught
+				if err := CheckMinOut(pool, inputMint, instructions, minOut.AddRaw(1_000)); err == nil {
+					t.Fatal("encoded min out matched a different request")
+				}
+			})
+		}
+	}
+}
+
+func TestCheckMinOutRoundsToLots(t *testing.T) {
+	pool := &phoenix.PhoenixPool{Header: phoenix.MarketHeader{
+		BaseMint:     minOutBase,
+		QuoteMint:    minOutQuote,
+		BaseLotSize:  1_000,
+		QuoteLotSize: 10,
+	}}
+	tests := []struct {
+		name      string
+		inputMint solana.PublicKey
+		minOut    int64
+		encoded   int64
+	}{
+		{"sell rounds up to a quote lot", minOutBase, 1_234_561, 1_234_570},
+		{"sell on a lot boundary", minOutBase, 1_234_560, 1_234_560},
+		{"buy rounds up to a base lot", minOutQuote, 1_234_001, 1_235_000},
+		{"buy on a lot boundary", minOutQuote, 1_234_000, 1_234_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputMint := tt.inputMint.String()
+			minOut := math.NewInt(tt.minOut)
+			if got := roundMinOut(pool, inputMint, minOut); !got.Equal(math.NewInt(tt.encoded)) {
+				t.Fatalf("roundMinOut = %s, want %d", got, tt.encoded)
+			}
+			instructions, err := pool.BuildSwapInstructions(context.Background(), nil, solana.NewWallet().PublicKey(), inputMint, math.NewInt(10_000_000), minOut, solana.PublicKey{}, solana.PublicKey{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := pool.DecodeMinOut(inputMint, instructions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !encoded.Equal(math.NewInt(tt.encoded)) {
+				t.Fatalf("encoded min out = %s, want %d", encoded, tt.encoded)
+			}
+			if err := CheckMinOut(pool, inputMint, instructions, minOut); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCheckMinOutUndecodable(t *testing.T) {
+	pool := &phoenix.PhoenixPool{Header: phoenix.MarketHeader{BaseMint: minOutBase, QuoteMint: minOutQuote, BaseLotSize: 1, QuoteLotSize: 1}}
+	other := solana.NewInstruction(solana.NewWallet().PublicKey(), nil, []byte{1, 2, 3})
+	err := CheckMinOut(pool, minOutBase.String(), []solana.Instruction{other}, math.NewInt(1))
+	if err == nil || !strings.Contains(err.Error(), "failed to decode min out") {
+		t.Fatalf("error = %v, want a decode failure", err)
+	}
+}
+
+func TestCheckMinOutRequiresDecoderOrOptOut(t *testing.T) {
+	pool := newFakePool("fake", "fake", 1)
+	err := CheckMinOut(pool, testBase, nil, math.NewInt(1))
+	if !errors.Is(err, ErrMinOutUnverifiable) {
+		t.Fatalf("error = %v, want ErrMinOutUnverifiable", err)
+	}
+	optedOut := &unencodedFakePool{fakePool: pool}
+	if err := CheckMinOut(optedOut, testBase, nil, math.NewInt(1)); err != nil {
+		t.Fatalf("a pool opting out was checked: %v", err)
+	}
+}
+
+func TestCheckMinOutUnencodedVenues(t *testing.T) {
+	// venues whose instructions have no minimum output field must opt out
+	for _, pool := range []pkg.Pool{&marinade.MarinadePool{}, &openbook.OpenBookPool{}, &rfq.RFQPool{}} {
+		unencoded, ok := pool.(pkg.UnencodedMinOutPool)
+		if !ok || !unencoded.MinOutUnencoded(minOutBase.String()) {
+			t.Errorf("%T neither decodes its min out nor opts out", pool)
+		}
+	}
+}
+
+// unencodedFakePool is a fakePool that opts out of the min out check
+type unencodedFakePool struct {
+	*fakePool
+}
+
+func (p *unencodedFakePool) MinOutUnencoded(inputMint string) bool { return true }
+
+func TestApplySlippage(t *testing.T) {
+	tests := []struct {
+		quote int64
+		bps   int
+		want  int64
+	}{
+		{1_000_000, 50, 995_000},
+		{1_000_000, 0, 1_000_000},
+		// rounded down so the threshold never exceeds the tolerated output
+		{999, 50, 994},
+		{1, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := ApplySlippage(math.NewInt(tt.quote), tt.bps); !got.Equal(math.NewInt(tt.want)) {
+			t.Errorf("ApplySlippage(%d, %d) = %s, want %d", tt.quote, tt.bps, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"context"
+	"log"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// outputDecimals resolves the decimals of each output mint in quotes, trusting
+// the chain's mint account over what pools record and falling back to the
+// pools when the lookup fails. Mints whose decimals stay unknown, or exceed
+// what a LegacyDec can hold, are omitted
+func outputDecimals(ctx context.Context, solClient *sol.Client, quotes []PoolQuote) map[string]uint8 {
+	decimals := make(map[string]uint8)
+	reported := make(map[string]uint8)
+	for _, quote := range quotes {
+		if quote.Err != nil || quote.OutputMint == "" {
+			continue
+		}
+		if _, ok := decimals[quote.OutputMint]; ok {
+			continue
+		}
+		if pool, ok := quote.Pool.(pkg.DecimalsPool); ok {
+			if d, ok := pool.MintDecimals(quote.OutputMint); ok {
+				if previous, seen := reported[quote.OutputMint]; seen && previous != d {
+					log.Printf("pools disagree on decimals of %s: %d vs %d", quote.OutputMint, previous, d)
+				}
+				reported[quote.OutputMint] = d
+			}
+		}
+		if solClient == nil {
+			continue
+		}
+		mint, err := solana.PublicKeyFromBase58(quote.OutputMint)
+		if err != nil {
+			continue
+		}
+		d, err := solClient.GetMintDecimals(ctx, mint)
+		if err != nil {
+			log.Printf("failed to get decimals of %s: %v", quote.OutputMint, err)
+			continue
+		}
+		decimals[quote.OutputMint] = d
+	}
+	for mint, d := range reported {
+		if _, ok := decimals[mint]; !ok {
+			decimals[mint] = d
+		}
+	}
+	for mint, d := range decimals {
+		if d > math.LegacyPrecision {
+			delete(decimals, mint)
+		}
+	}
+	return decimals
+}
+
+// normalizeAmount converts a raw amount of a mint with the given decimals to
+// whole tokens
+func normalizeAmount(amount math.Int, decimals uint8) math.LegacyDec {
+	return math.LegacyNewDecFromBigIntWithPrec(amount.BigInt(), int64(decimals))
+}
+
+// betterQuote reports whether a outranks b. Normalized quotes are compared
+// in whole tokens, so pools reporting the output in different units still
+// rank apples-to-apples, and outrank every quote whose output decimals are
+// unknown; those are compared among themselves on raw net output. Keeping
+// the two groups apart makes the order transitive, as sorting requires
+func betterQuote(a, b PoolQuote) bool {
+	aNormalized, bNormalized := !a.NormalizedOut.IsNil(), !b.NormalizedOut.IsNil()
+	if aNormalized != bNormalized {
+		return aNormalized
+	}
+	if aNormalized {
+		return a.NormalizedOut.GT(b.NormalizedOut)
+	}
+	return a.NetAmountOut.GT(b.NetAmountOut)
+}
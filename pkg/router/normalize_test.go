@@ -0,0 +1,77 @@
+package router
+
+import (
+	"sort"
+	"testing"
+
+	"cosmossdk.io/math"
+)
+
+func normalizedQuote(id string, raw int64, decimals uint8) PoolQuote {
+	amount := math.NewInt(raw)
+	return PoolQuote{
+		Pool:          &fakePool{id: id},
+		NetAmountOut:  amount,
+		NormalizedOut: normalizeAmount(amount, decimals),
+	}
+}
+
+func rawQuote(id string, raw int64) PoolQuote {
+	return PoolQuote{Pool: &fakePool{id: id}, NetAmountOut: math.NewInt(raw)}
+}
+
+func TestNormalizeAmount(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		decimals uint8
+		want     string
+	}{
+		{1_500_000, 6, "1.500000000000000000"},
+		{1_500_000_000, 9, "1.500000000000000000"},
+		{42, 0, "42.000000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := normalizeAmount(math.NewInt(tt.amount), tt.decimals).String(); got != tt.want {
+			t.Errorf("normalizeAmount(%d, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestBetterQuoteComparesWholeTokens(t *testing.T) {
+	// 2 tokens of a 6 decimal mint beat 1.5 tokens of a 9 decimal mint,
+	// though the raw amounts say otherwise
+	six := normalizedQuote("six", 2_000_000, 6)
+	nine := normalizedQuote("nine", 1_500_000_000, 9)
+	if !betterQuote(six, nine) || betterQuote(nine, six) {
+		t.Fatal("quotes not ranked in whole tokens")
+	}
+}
+
+// TestBetterQuoteIsTransitive mixes normalized quotes with quotes whose
+// decimals are unknown: ranking one group on whole tokens and the other on raw
+// amounts must still give one consistent order
+func TestBetterQuoteIsTransitive(t *testing.T) {
+	quotes := []PoolQuote{
+		rawQuote("raw-large", 5_000_000_000),
+		normalizedQuote("six", 2_000_000, 6),
+		rawQuote("raw-small", 1_000_000),
+		normalizedQuote("nine", 1_500_000_000, 9),
+	}
+	for _, a := range quotes {
+		for _, b := range quotes {
+			for _, c := range quotes {
+				if betterQuote(a, b) && betterQuote(b, c) && !betterQuote(a, c) {
+					t.Fatalf("%s > %s > %s but not %s > %s", a.Pool.GetID(), b.Pool.GetID(), c.Pool.GetID(), a.Pool.GetID(), c.Pool.GetID())
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool { return betterQuote(quotes[i], quotes[j]) })
+	want := []string{"six", "nine", "raw-large", "raw-small"}
+	for i, id := range want {
+		if got := quotes[i].Pool.GetID(); got != id {
+			t.Fatalf("rank %d = %s, want %s", i, got, id)
+		}
+	}
+}
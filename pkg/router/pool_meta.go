@@ -0,0 +1,116 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// QueryPoolMetas lists the pools of a pair as metadata only, so the routing
+// graph can be built before any pool state is loaded. Protocols without
+// metadata discovery fall back to a full fetch that is reduced to metadata
+func (r *SimpleRouter) QueryPoolMetas(ctx context.Context, baseMint, quoteMint string) ([]pkg.PoolMeta, *DiscoveryReport) {
+	var metas []pkg.PoolMeta
+	report := &DiscoveryReport{
+		Protocols: make([]ProtocolReport, 0, len(r.Protocols)),
+	}
+
+	for _, proto := range r.Protocols {
+		start := time.Now()
+		found, err := fetchPoolMetas(ctx, proto, baseMint, quoteMint)
+		protocolReport := ProtocolReport{
+			Protocol:  proto.ProtocolName(),
+			PoolCount: len(found),
+			Duration:  time.Since(start),
+			Err:       err,
+		}
+		if err != nil {
+			log.Printf("error fetching pool metadata from protocol: %v", err)
+			protocolReport.PoolCount = 0
+		}
+		report.Protocols = append(report.Protocols, protocolReport)
+		metas = append(metas, found...)
+	}
+	return metas, report
+}
+
+// HydratePools loads the full state of the given pools, grouped into one
+// batched lookup per protocol, and makes them the router's pool set
+func (r *SimpleRouter) HydratePools(ctx context.Context, metas []pkg.PoolMeta) ([]pkg.Pool, error) {
+	idsByProtocol := make(map[pkg.ProtocolName][]string)
+	for _, meta := range metas {
+		idsByProtocol[meta.Protocol] = append(idsByProtocol[meta.Protocol], meta.ID)
+	}
+
+	var hydrated []pkg.Pool
+	for _, proto := range r.Protocols {
+		ids, ok := idsByProtocol[proto.ProtocolName()]
+		if !ok {
+			continue
+		}
+		pools, err := pkg.FetchPoolsByIDs(ctx, proto, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hydrate %s pools: %w", proto.ProtocolName(), err)
+		}
+		hydrated = append(hydrated, pools...)
+	}
+
+	r.Pools = r.mergePools(hydrated)
+	if r.QuoteCache != nil {
+		r.QuoteCache.Invalidate()
+	}
+	return r.Pools, nil
+}
+
+// ScanPools scans the pair's pools on protocol fetching only length bytes from
+// offset of each account, for reading a few fields of many pools
+func (r *SimpleRouter) ScanPools(ctx context.Context, protocol pkg.ProtocolName, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	for _, proto := range r.Protocols {
+		if proto.ProtocolName() != protocol {
+			continue
+		}
+		scanner, ok := proto.(pkg.PoolScanProtocol)
+		if !ok {
+			return nil, fmt.Errorf("protocol %s does not support sliced scans", protocol)
+		}
+		return scanner.ScanPoolsByPair(ctx, baseMint, quoteMint, offset, length)
+	}
+	return nil, fmt.Errorf("protocol %s is not configured", protocol)
+}
+
+// fetchPoolMetas uses the protocol's metadata discovery when available
+func fetchPoolMetas(ctx context.Context, proto pkg.Protocol, baseMint, quoteMint string) ([]pkg.PoolMeta, error) {
+	if metaProtocol, ok := proto.(pkg.PoolMetaProtocol); ok {
+		return metaProtocol.FetchPoolMetasByPair(ctx, baseMint, quoteMint)
+	}
+	pools, err := proto.FetchPoolsByPair(ctx, baseMint, quoteMint)
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]pkg.PoolMeta, 0, len(pools))
+	for _, pool := range pools {
+		metas = append(metas, poolMeta(pool))
+	}
+	return metas, nil
+}
+
+// poolMeta describes a hydrated pool, deriving the fee tier from SwapFeePool
+func poolMeta(pool pkg.Pool) pkg.PoolMeta {
+	baseMint, quoteMint := pool.GetTokens()
+	meta := pkg.PoolMeta{
+		ID:        pool.GetID(),
+		Protocol:  pool.ProtocolName(),
+		BaseMint:  baseMint,
+		QuoteMint: quoteMint,
+	}
+	if feePool, ok := pool.(pkg.SwapFeePool); ok {
+		sample := math.NewInt(100_000_000)
+		fee := feePool.SwapFee(baseMint, sample)
+		meta.FeeBps = float64(fee.Int64()) * 10000 / float64(sample.Int64())
+	}
+	return meta
+}
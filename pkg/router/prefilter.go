@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ErrUncompetitive is recorded for pools the price pre-filter dropped before
+// quoting
+var ErrUncompetitive = errors.New("pool price estimate is uncompetitive")
+
+// maxEstimateAccounts is the most accounts fetched by one getMultipleAccounts
+const maxEstimateAccounts = 100
+
+// estimate is the estimated price of one pool
+type estimate struct {
+	pool       pkg.Pool
+	outputMint string
+	price      math.LegacyDec
+}
+
+// prefilter estimates the price of every pool implementing
+// pkg.PriceEstimatePool from one batched balance lookup and returns the IDs
+// of those whose estimate trails the best estimate paying the same output
+// mint by more than slackBps. Pools that cannot be estimated are kept, as is
+// every pool when the lookup fails
+func prefilter(ctx context.Context, solClient *sol.Client, pools []pkg.Pool, tokenIn string, slackBps int) map[string]struct{} {
+	estimating := make([]pkg.PriceEstimatePool, 0)
+	owners := make([]pkg.Pool, 0)
+	accounts := make([]solana.PublicKey, 0)
+	for _, pool := range pools {
+		if estimator, ok := pool.(pkg.PriceEstimatePool); ok {
+			estimating = append(estimating, estimator)
+			owners = append(owners, pool)
+			accounts = append(accounts, estimator.EstimateAccounts()...)
+		}
+	}
+	if len(estimating) < 2 {
+		return nil
+	}
+
+	balances := make([]uint64, len(accounts))
+	found := make([]bool, len(accounts))
+	for start := 0; start < len(accounts); start += maxEstimateAccounts {
+		end := min(start+maxEstimateAccounts, len(accounts))
+		results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts[start:end])
+		if err != nil {
+			log.Printf("price pre-filter skipped: %v", err)
+			return nil
+		}
+		for i := start; i < end; i++ {
+			balances[i], found[i] = sol.TokenAccountAmount(results, i-start)
+		}
+	}
+
+	estimates := make([]estimate, 0, len(estimating))
+	best := make(map[string]math.LegacyDec)
+	next := 0
+	for i, estimator := range estimating {
+		count := len(estimator.EstimateAccounts())
+		start := next
+		next += count
+		if !allFound(found[start:next]) {
+			continue
+		}
+		outputMint, err := otherMint(owners[i], tokenIn)
+		if err != nil {
+			continue
+		}
+		price, err := estimator.EstimatePrice(tokenIn, balances[start:next])
+		if err != nil || !price.IsPositive() {
+			continue
+		}
+		estimates = append(estimates, estimate{pool: owners[i], outputMint: outputMint, price: price})
+		if top, ok := best[outputMint]; !ok || price.GT(top) {
+			best[outputMint] = price
+		}
+	}
+
+	dropped := make(map[string]struct{})
+	for _, e := range estimates {
+		floor := best[e.outputMint].MulInt64(int64(10000 - slackBps)).QuoInt64(10000)
+		if e.price.LT(floor) {
+			dropped[e.pool.GetID()] = struct{}{}
+		}
+	}
+	if len(dropped) > 0 {
+		log.Printf("price pre-filter dropped %d of %d estimated pools", len(dropped), len(estimates))
+	}
+	return dropped
+}
+
+func allFound(found []bool) bool {
+	for _, ok := range found {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
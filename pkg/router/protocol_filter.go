@@ -0,0 +1,38 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// protocolSet is the protocols one router call is limited to; nil allows all
+type protocolSet map[pkg.ProtocolName]struct{}
+
+// protocolSet checks that every name is one of the router's protocols and
+// returns them as a set, nil when names is empty
+func (r *SimpleRouter) protocolSet(names []pkg.ProtocolName) (protocolSet, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[pkg.ProtocolName]struct{}, len(r.Protocols))
+	for _, proto := range r.Protocols {
+		known[proto.ProtocolName()] = struct{}{}
+	}
+	set := make(protocolSet, len(names))
+	for _, name := range names {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("protocol %s is not configured on this router", name)
+		}
+		set[name] = struct{}{}
+	}
+	return set, nil
+}
+
+func (s protocolSet) allows(name pkg.ProtocolName) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[name]
+	return ok
+}
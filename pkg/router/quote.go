@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// MaxQuoteSlotSkew is the widest gap, in slots, between the reads of a
+// route's pools before its quote warns that they priced different states
+const MaxQuoteSlotSkew = 2
+
+// HopQuote is one hop of a RouteQuote
+type HopQuote struct {
+	Protocol     pkg.ProtocolName `json:"protocol"`
+	PoolID       string           `json:"pool_id"`
+	InputMint    string           `json:"input_mint"`
+	OutputMint   string           `json:"output_mint"`
+	AmountIn     math.Int         `json:"amount_in"`
+	AmountOut    math.Int         `json:"amount_out"`
+	MinAmountOut math.Int         `json:"min_amount_out"`
+	// Slot is the context slot the pool was read at; zero when unknown
+	Slot uint64 `json:"slot,omitempty"`
+}
+
+// RouteQuote describes a quoted route by value: what goes in, what is
+// expected out and through which pools. It holds no pool state, so it can
+// be logged, serialized and compared across quotes. Amounts are in base
+// units; a minimum is zero until slippage is applied
+type RouteQuote struct {
+	InputMint    string     `json:"input_mint"`
+	OutputMint   string     `json:"output_mint"`
+	AmountIn     math.Int   `json:"amount_in"`
+	AmountOut    math.Int   `json:"amount_out"`
+	MinAmountOut math.Int   `json:"min_amount_out"`
+	Hops         []HopQuote `json:"hops"`
+	// Recipient receives the output in place of the swapping wallet; empty
+	// when the wallet does
+	Recipient string `json:"recipient,omitempty"`
+	// MinSlot and MaxSlot bound the slots the hops were read at; zero when
+	// no hop's slot is known
+	MinSlot uint64 `json:"min_slot,omitempty"`
+	MaxSlot uint64 `json:"max_slot,omitempty"`
+	// Warnings flags consistency hazards of the quote, such as hops priced
+	// from snapshots more than MaxQuoteSlotSkew slots apart
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Quote describes the route as it stands
+func (r *Route) Quote() RouteQuote {
+	quote := RouteQuote{
+		InputMint:    r.InputMint(),
+		OutputMint:   r.OutputMint(),
+		AmountIn:     orZero(r.AmountIn),
+		AmountOut:    orZero(r.AmountOut),
+		MinAmountOut: math.ZeroInt(),
+		Hops:         make([]HopQuote, 0, len(r.Hops)),
+	}
+	for _, hop := range r.Hops {
+		quote.Hops = append(quote.Hops, HopQuote{
+			Protocol:     hop.Pool.ProtocolName(),
+			PoolID:       hop.Pool.GetID(),
+			InputMint:    hop.InputMint,
+			OutputMint:   hop.OutputMint,
+			AmountIn:     orZero(hop.AmountIn),
+			AmountOut:    orZero(hop.AmountOut),
+			MinAmountOut: orZero(hop.MinAmountOut),
+			Slot:         hop.Slot,
+		})
+	}
+	if len(quote.Hops) > 0 {
+		quote.MinAmountOut = quote.Hops[len(quote.Hops)-1].MinAmountOut
+	}
+	if !r.Recipient.IsZero() {
+		quote.Recipient = r.Recipient.String()
+	}
+	quote.checkSlots()
+	return quote
+}
+
+// checkSlots sets the slot bounds of the hops and warns when they were read
+// too far apart, or when only some of them have a known slot
+func (q *RouteQuote) checkSlots() {
+	unknown := 0
+	for _, hop := range q.Hops {
+		if hop.Slot == 0 {
+			unknown++
+			continue
+		}
+		if q.MinSlot == 0 || hop.Slot < q.MinSlot {
+			q.MinSlot = hop.Slot
+		}
+		if hop.Slot > q.MaxSlot {
+			q.MaxSlot = hop.Slot
+		}
+	}
+	if q.MaxSlot-q.MinSlot > MaxQuoteSlotSkew {
+		q.Warnings = append(q.Warnings, fmt.Sprintf("hops were read %d slots apart, between slots %d and %d", q.MaxSlot-q.MinSlot, q.MinSlot, q.MaxSlot))
+	}
+	if unknown > 0 && unknown < len(q.Hops) {
+		q.Warnings = append(q.Warnings, fmt.Sprintf("%d of %d hops have no known read slot", unknown, len(q.Hops)))
+	}
+}
+
+// orZero replaces an unset amount with zero
+func orZero(amount math.Int) math.Int {
+	if amount.IsNil() {
+		return math.ZeroInt()
+	}
+	return amount
+}
@@ -0,0 +1,167 @@
+package router
+
+import (
+	stdmath "math"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cosmossdk.io/math"
+)
+
+const (
+	// DefaultQuoteCacheSlotAge serves a memoized quote for the slot its pool
+	// state was read at and the next one
+	DefaultQuoteCacheSlotAge = 1
+	// quoteBucketsPerDoubling sets the size bucket width: amounts within
+	// about 4.4% of each other share a bucket
+	quoteBucketsPerDoubling = 16
+	// maxQuoteCacheEntries bounds the memoized quotes; the cache starts over
+	// once it is reached
+	maxQuoteCacheEntries = 4096
+)
+
+// QuoteCache memoizes pool quotes keyed by pool, input mint, size bucket and
+// the slot the pool's state was read at, so repeated quotes of about the same
+// size against unchanged state don't redo tick/bin traversal math. A bucket
+// keeps the largest amount quoted in it and serves smaller amounts pro rata:
+// average prices only get better for smaller trades on curve and CLMM pools,
+// so a scaled quote never promises more than the pool would pay. That does
+// not hold for pools filling whole lots, whose quotes GetExact and PutExact
+// memoize for the exact amount only
+type QuoteCache struct {
+	mu      sync.Mutex
+	maxAge  uint64
+	entries map[quoteKey]quoteEntry
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type quoteKey struct {
+	poolID    string
+	inputMint string
+	bucket    int
+	slot      uint64
+	// exact is the amount of a quote that is not scaled, empty for quotes
+	// served pro rata within their bucket
+	exact string
+}
+
+type quoteEntry struct {
+	amountIn  math.Int
+	amountOut math.Int
+	quotedAt  time.Time
+}
+
+// NewQuoteCache creates a quote cache serving a quote until the cluster is
+// more than maxSlotAge slots past the pool state it priced; zero uses
+// DefaultQuoteCacheSlotAge
+func NewQuoteCache(maxSlotAge uint64) *QuoteCache {
+	if maxSlotAge == 0 {
+		maxSlotAge = DefaultQuoteCacheSlotAge
+	}
+	return &QuoteCache{
+		maxAge:  maxSlotAge,
+		entries: make(map[quoteKey]quoteEntry),
+	}
+}
+
+// sizeBucket returns the logarithmic size bucket of amount
+func sizeBucket(amount math.Int) int {
+	if !amount.IsPositive() {
+		return stdmath.MinInt
+	}
+	f, _ := new(big.Float).SetInt(amount.BigInt()).Float64()
+	return int(stdmath.Floor(stdmath.Log2(f) * quoteBucketsPerDoubling))
+}
+
+// newQuoteKey returns the key of a quote of amount, bucketed unless exact
+func newQuoteKey(poolID, inputMint string, amount math.Int, slot uint64, exact bool) quoteKey {
+	key := quoteKey{poolID: poolID, inputMint: inputMint, bucket: sizeBucket(amount), slot: slot}
+	if exact {
+		key.exact = amount.String()
+	}
+	return key
+}
+
+// Get returns a memoized quote of amount against the pool state read at slot,
+// while currentSlot is at most the cache's slot age past it
+func (c *QuoteCache) Get(poolID, inputMint string, amount math.Int, slot, currentSlot uint64) (math.Int, bool) {
+	amountOut, _, ok := c.get(poolID, inputMint, amount, slot, currentSlot, false)
+	return amountOut, ok
+}
+
+// GetExact is Get for pools whose quotes cannot be scaled: only a quote of
+// exactly amount is served
+func (c *QuoteCache) GetExact(poolID, inputMint string, amount math.Int, slot, currentSlot uint64) (math.Int, bool) {
+	amountOut, _, ok := c.get(poolID, inputMint, amount, slot, currentSlot, true)
+	return amountOut, ok
+}
+
+// get returns a memoized quote and when it was quoted
+func (c *QuoteCache) get(poolID, inputMint string, amount math.Int, slot, currentSlot uint64, exact bool) (math.Int, time.Time, bool) {
+	if currentSlot > slot+c.maxAge {
+		c.misses.Add(1)
+		return math.Int{}, time.Time{}, false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[newQuoteKey(poolID, inputMint, amount, slot, exact)]
+	c.mu.Unlock()
+	if !ok || amount.GT(entry.amountIn) {
+		c.misses.Add(1)
+		return math.Int{}, time.Time{}, false
+	}
+	c.hits.Add(1)
+	if amount.Equal(entry.amountIn) {
+		return entry.amountOut, entry.quotedAt, true
+	}
+	return entry.amountOut.Mul(amount).Quo(entry.amountIn), entry.quotedAt, true
+}
+
+// Put stores the quote of amount against the pool state read at slot,
+// unless its bucket already holds a larger amount for that slot
+func (c *QuoteCache) Put(poolID, inputMint string, amount math.Int, amountOut math.Int, slot uint64) {
+	c.put(poolID, inputMint, amount, amountOut, slot, false)
+}
+
+// PutExact stores the quote of amount for GetExact
+func (c *QuoteCache) PutExact(poolID, inputMint string, amount math.Int, amountOut math.Int, slot uint64) {
+	c.put(poolID, inputMint, amount, amountOut, slot, true)
+}
+
+func (c *QuoteCache) put(poolID, inputMint string, amount math.Int, amountOut math.Int, slot uint64, exact bool) {
+	if !amount.IsPositive() {
+		return
+	}
+	key := newQuoteKey(poolID, inputMint, amount, slot, exact)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.entries[key]; ok && prev.amountIn.GT(amount) {
+		return
+	}
+	if len(c.entries) >= maxQuoteCacheEntries {
+		c.entries = make(map[quoteKey]quoteEntry)
+	}
+	c.entries[key] = quoteEntry{
+		amountIn:  amount,
+		amountOut: amountOut,
+		quotedAt:  time.Now(),
+	}
+}
+
+// Invalidate drops every memoized quote, as a pool refresh does
+func (c *QuoteCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[quoteKey]quoteEntry)
+}
+
+// Stats returns the number of cache hits and misses so far
+func (c *QuoteCache) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg/pool/openbook"
+	"github.com/solana-zh/solroute/pkg/pool/phoenix"
+	"github.com/solana-zh/solroute/pkg/pool/raydium"
+)
+
+func TestQuoteCacheBucketsBySize(t *testing.T) {
+	cache := NewQuoteCache(0)
+	cache.Put("pool", "mint", math.NewInt(1_000_000), math.NewInt(2_000_000), 100)
+
+	// the same amount is served as quoted
+	if out, ok := cache.Get("pool", "mint", math.NewInt(1_000_000), 100, 100); !ok || !out.Equal(math.NewInt(2_000_000)) {
+		t.Fatalf("exact amount: got %v, %v", out, ok)
+	}
+	// a slightly smaller amount shares the bucket and is scaled down
+	if out, ok := cache.Get("pool", "mint", math.NewInt(990_000), 100, 100); !ok || !out.Equal(math.NewInt(1_980_000)) {
+		t.Fatalf("smaller amount: got %v, %v", out, ok)
+	}
+	// a larger amount in the bucket could get a worse price than scaled
+	if _, ok := cache.Get("pool", "mint", math.NewInt(1_003_000), 100, 100); ok {
+		t.Fatal("larger amount served from a smaller quote")
+	}
+	// an amount in another bucket misses
+	if _, ok := cache.Get("pool", "mint", math.NewInt(500_000), 100, 100); ok {
+		t.Fatal("amount of another bucket served")
+	}
+
+	hits, misses := cache.Stats()
+	if hits != 2 || misses != 2 {
+		t.Fatalf("stats = %d hits, %d misses, want 2 and 2", hits, misses)
+	}
+}
+
+func TestQuoteCacheKeyedBySlot(t *testing.T) {
+	cache := NewQuoteCache(2)
+	cache.Put("pool", "mint", math.NewInt(1_000), math.NewInt(900), 100)
+
+	if _, ok := cache.Get("pool", "mint", math.NewInt(1_000), 101, 101); ok {
+		t.Fatal("quote of older pool state served")
+	}
+	if _, ok := cache.Get("pool", "mint", math.NewInt(1_000), 100, 102); !ok {
+		t.Fatal("quote within the slot age missed")
+	}
+	if _, ok := cache.Get("pool", "mint", math.NewInt(1_000), 100, 103); ok {
+		t.Fatal("quote past the slot age served")
+	}
+	if _, ok := cache.Get("other", "mint", math.NewInt(1_000), 100, 100); ok {
+		t.Fatal("quote of another pool served")
+	}
+}
+
+func TestQuoteCacheKeepsLargestAmount(t *testing.T) {
+	cache := NewQuoteCache(0)
+	cache.Put("pool", "mint", math.NewInt(1_003_000), math.NewInt(1_000_000), 100)
+	cache.Put("pool", "mint", math.NewInt(1_000_000), math.NewInt(995_500), 100)
+
+	if _, ok := cache.Get("pool", "mint", math.NewInt(1_002_000), 100, 100); !ok {
+		t.Fatal("smaller quote replaced the larger one of its bucket")
+	}
+	cache.Invalidate()
+	if _, ok := cache.Get("pool", "mint", math.NewInt(1_002_000), 100, 100); ok {
+		t.Fatal("quote served after Invalidate")
+	}
+}
+
+func TestQuoteCacheExactForLotSizedPools(t *testing.T) {
+	cache := NewQuoteCache(0)
+	// two lots of 1,000 pay 2,000; 1,990 fills only one lot and pays 1,000,
+	// far less than the 1,990 a pro rata scaling would promise
+	cache.PutExact("book", "mint", math.NewInt(2_000), math.NewInt(2_000), 100)
+
+	if _, ok := cache.GetExact("book", "mint", math.NewInt(1_990), 100, 100); ok {
+		t.Fatal("a lot-sized quote was scaled to a smaller amount")
+	}
+	if out, ok := cache.GetExact("book", "mint", math.NewInt(2_000), 100, 100); !ok || !out.Equal(math.NewInt(2_000)) {
+		t.Fatalf("exact amount: got %v, %v", out, ok)
+	}
+	// exact quotes are kept apart from bucketed ones
+	if _, ok := cache.Get("book", "mint", math.NewInt(1_990), 100, 100); ok {
+		t.Fatal("an exact quote was served pro rata")
+	}
+	if !quotesLots(&phoenix.PhoenixPool{}) || !quotesLots(&openbook.OpenBookPool{}) {
+		t.Fatal("order book pools are not cached by exact amount")
+	}
+	if quotesLots(&raydium.CPMMPool{}) {
+		t.Fatal("a constant product pool is cached by exact amount")
+	}
+}
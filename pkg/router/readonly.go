@@ -0,0 +1,74 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ReadOnlyRouter prices swaps on every stable protocol for services that
+// never trade. It takes no key and returns quotes by value, never pools, so
+// no path from it leads to building, signing or sending a transaction. Its
+// RPC client is private and has no Jito connection
+type ReadOnlyRouter struct {
+	router    *SimpleRouter
+	solClient *sol.Client
+}
+
+// NewReadOnlyRouter connects to endpoint, limited to reqLimitPerSecond
+// requests, and routes over protocol.All
+func NewReadOnlyRouter(ctx context.Context, endpoint string, reqLimitPerSecond int) (*ReadOnlyRouter, error) {
+	solClient, err := sol.NewClient(ctx, endpoint, "", reqLimitPerSecond)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return &ReadOnlyRouter{
+		router:    NewSimpleRouter(protocol.All(solClient)...),
+		solClient: solClient,
+	}, nil
+}
+
+// QueryAllPools discovers the pools of the pair, as SimpleRouter.QueryAllPools
+func (r *ReadOnlyRouter) QueryAllPools(ctx context.Context, baseMint, quoteMint string, protocols ...pkg.ProtocolName) (*DiscoveryReport, error) {
+	return r.router.QueryAllPools(ctx, baseMint, quoteMint, protocols...)
+}
+
+// QuoteAll quotes amountIn of tokenIn on every discovered pool, best first,
+// leaving out the pools that failed to quote. Each quote carries the slot
+// its pool was read at
+func (r *ReadOnlyRouter) QuoteAll(ctx context.Context, tokenIn string, amountIn math.Int, protocols ...pkg.ProtocolName) []RouteQuote {
+	quotes := make([]RouteQuote, 0)
+	for _, quote := range r.router.QuoteAll(ctx, r.solClient, tokenIn, amountIn, protocols...) {
+		if quote.Err != nil {
+			continue
+		}
+		route, err := NewSingleHopRoute(quote.Pool, tokenIn, amountIn, quote.AmountOut)
+		if err != nil {
+			continue
+		}
+		route.RecordSlots(r.solClient)
+		quotes = append(quotes, route.Quote())
+	}
+	return quotes
+}
+
+// BestQuote quotes amountIn of tokenIn on the discovered pool paying the
+// most, with MinAmountOut set slippageBps below a fresh quote
+func (r *ReadOnlyRouter) BestQuote(ctx context.Context, tokenIn string, amountIn math.Int, slippageBps int, protocols ...pkg.ProtocolName) (RouteQuote, error) {
+	pool, amountOut, err := r.router.GetBestPool(ctx, r.solClient, tokenIn, amountIn, protocols...)
+	if err != nil {
+		return RouteQuote{}, err
+	}
+	route, err := NewSingleHopRoute(pool, tokenIn, amountIn, amountOut)
+	if err != nil {
+		return RouteQuote{}, err
+	}
+	if err := r.router.ApplyMinOut(ctx, r.solClient, route, slippageBps, MinOutPerHop); err != nil {
+		return RouteQuote{}, err
+	}
+	return route.Quote(), nil
+}
@@ -0,0 +1,188 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ErrHoneypot is returned by RoundTripResult.Check for tokens that cannot be
+// sold back, or lose more than the tolerated share on a round trip
+var ErrHoneypot = errors.New("token failed the round trip check")
+
+// roundTripSellBps is the share of the quoted purchase sold back, leaving
+// room for rounding in tokens that take a small fee on transfer
+const roundTripSellBps = 9900
+
+// RoundTripResult is the outcome of a simulated buy of a token followed by
+// selling it back through the same pool
+type RoundTripResult struct {
+	Token string
+	// AmountIn was spent buying Bought of the token, of which Sold was sold
+	// back for Returned
+	AmountIn math.Int
+	Bought   math.Int
+	Sold     math.Int
+	Returned math.Int
+	// LossBps is how much less than the sold share of AmountIn came back,
+	// fees and price impact of both legs included
+	LossBps int64
+	// SellErr is set when the buy went through but the sale failed
+	SellErr error
+}
+
+// Check returns an error wrapping ErrHoneypot when the token could not be
+// sold back or the round trip lost more than maxLossBps
+func (r *RoundTripResult) Check(maxLossBps int) error {
+	if r.SellErr != nil {
+		return fmt.Errorf("%w: %s cannot be sold: %v", ErrHoneypot, r.Token, r.SellErr)
+	}
+	if r.LossBps > int64(maxLossBps) {
+		return fmt.Errorf("%w: round trip of %s loses %d bps, tolerated %d", ErrHoneypot, r.Token, r.LossBps, maxLossBps)
+	}
+	return nil
+}
+
+// SimulateRoundTrip simulates payer buying pool's other token with amountIn
+// of inputMint and selling nearly all of it straight back, in one
+// transaction, and measures what came back. A round trip that fails is
+// simulated again without the sale, so a token that cannot be sold is told
+// apart from a buy that fails by itself, which is returned as an error
+func (r *SimpleRouter) SimulateRoundTrip(ctx context.Context, solClient *sol.Client, pool pkg.Pool, payer solana.PublicKey, inputMint string, amountIn math.Int) (*RoundTripResult, error) {
+	token, err := otherMint(pool, inputMint)
+	if err != nil {
+		return nil, err
+	}
+	if usesNativeSOL(pool, inputMint) {
+		return nil, fmt.Errorf("%w: pool %s pays native SOL", ErrOutputNotMeasurable, pool.GetID())
+	}
+	quoted, err := pool.Quote(ctx, solClient, inputMint, amountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote buy: %w", err)
+	}
+	sold := quoted.MulRaw(roundTripSellBps).QuoRaw(10000)
+	if !sold.IsPositive() {
+		return nil, fmt.Errorf("buy of %s quotes too little %s to sell back", amountIn, token)
+	}
+
+	route := &Route{
+		Hops: []Hop{
+			{Pool: pool, InputMint: inputMint, OutputMint: token, AmountIn: amountIn, AmountOut: quoted, MinAmountOut: math.ZeroInt()},
+			{Pool: pool, InputMint: token, OutputMint: inputMint, AmountIn: sold, MinAmountOut: math.ZeroInt()},
+		},
+		AmountIn: amountIn,
+	}
+	segments, err := buildRouteSegments(ctx, solClient, payer, route)
+	if err != nil {
+		return nil, err
+	}
+	inputAccount, err := userTokenAccount(payer, inputMint)
+	if err != nil {
+		return nil, err
+	}
+	tokenAccount, err := userTokenAccount(payer, token)
+	if err != nil {
+		return nil, err
+	}
+	tokenKey, err := solana.PublicKeyFromBase58(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mint %s: %w", token, err)
+	}
+	create, err := sol.NewCreateATAIdempotentInstruction(payer, payer, tokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// the unwrap after the sale would close the account measured
+	buy := []solana.Instruction{create}
+	roundTrip := []solana.Instruction{create}
+	wrapped := false
+	for _, segment := range segments {
+		if segment.hop == len(route.Hops) {
+			break
+		}
+		if segment.step != nil && segment.step.Action == WSOLWrap {
+			wrapped = true
+		}
+		if segment.hop == 0 {
+			buy = append(buy, segment.instructions...)
+		}
+		roundTrip = append(roundTrip, segment.instructions...)
+	}
+
+	accounts := []solana.PublicKey{inputAccount, tokenAccount}
+	before, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token accounts: %w", err)
+	}
+	inputBefore, _ := sol.TokenAccountAmount(before, 0)
+	tokenBefore, _ := sol.TokenAccountAmount(before, 1)
+
+	result := &RoundTripResult{Token: token, AmountIn: amountIn, Sold: sold}
+	after, err := simulateAccounts(ctx, solClient, roundTrip, payer, accounts)
+	if err != nil {
+		if _, buyErr := simulateAccounts(ctx, solClient, buy, payer, accounts); buyErr != nil {
+			return nil, fmt.Errorf("buy leg failed: %w", buyErr)
+		}
+		result.SellErr = err
+		return result, nil
+	}
+
+	// a wrapped input was funded from lamports, not the measured account
+	returned := math.NewIntFromUint64(after[0]).Sub(math.NewIntFromUint64(inputBefore))
+	if !wrapped {
+		returned = returned.Add(amountIn)
+	}
+	result.Returned = returned
+	result.Bought = math.NewIntFromUint64(after[1]).Sub(math.NewIntFromUint64(tokenBefore)).Add(sold)
+	if result.Bought.IsPositive() {
+		expected := amountIn.Mul(sold).Quo(result.Bought)
+		if expected.IsPositive() {
+			result.LossBps = expected.Sub(returned).MulRaw(10000).Quo(expected).Int64()
+		}
+	}
+	return result, nil
+}
+
+// simulateAccounts simulates instructions paid by payer and returns the token
+// balances of accounts afterwards, zero for accounts that do not exist
+func simulateAccounts(ctx context.Context, solClient *sol.Client, instructions []solana.Instruction, payer solana.PublicKey, accounts []solana.PublicKey) ([]uint64, error) {
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	// Simulation skips signature checks but still expects one slot per signer
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	res, err := solClient.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentProcessed,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: accounts,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate: %w", err)
+	}
+	if res.Value.Err != nil {
+		return nil, fmt.Errorf("simulation failed: %v", res.Value.Err)
+	}
+	balances := make([]uint64, len(accounts))
+	for i := range accounts {
+		if i >= len(res.Value.Accounts) || res.Value.Accounts[i] == nil {
+			continue
+		}
+		if balances[i], err = decodeTokenAmount(res.Value.Accounts[i].Data.GetBinary()); err != nil {
+			return nil, err
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,209 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/layout"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Hop is one swap leg of a route
+type Hop struct {
+	Pool         pkg.Pool
+	InputMint    string
+	OutputMint   string
+	AmountIn     math.Int
+	AmountOut    math.Int
+	MinAmountOut math.Int
+	// Slot, when set, is the context slot the pool account was last read at
+	Slot uint64
+}
+
+// Route is an ordered list of hops where each hop consumes the previous output
+type Route struct {
+	Hops      []Hop
+	AmountIn  math.Int
+	AmountOut math.Int
+	// Delivery sets how a SOL output is handed over; nil unwraps it into the
+	// user's native balance
+	Delivery *SOLDelivery
+	// Recipient, when set, receives the output instead of the swapping
+	// wallet, which still signs and pays. Token output lands in the
+	// recipient's associated token account, created when missing, and SOL
+	// output is sent on as by SOLDelivery.Destination
+	Recipient solana.PublicKey
+	// NotBefore and NotAfter, when set, bound the cluster time the route may
+	// be sent at. The executor waits for NotBefore and for every pool of the
+	// route to open, and rejects the route once NotAfter has passed
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// NewSingleHopRoute wraps a single pool quote into a route
+func NewSingleHopRoute(pool pkg.Pool, inputMint string, amountIn, amountOut math.Int) (*Route, error) {
+	outputMint, err := otherMint(pool, inputMint)
+	if err != nil {
+		return nil, err
+	}
+	return &Route{
+		Hops: []Hop{{
+			Pool:         pool,
+			InputMint:    inputMint,
+			OutputMint:   outputMint,
+			AmountIn:     amountIn,
+			AmountOut:    amountOut,
+			MinAmountOut: math.ZeroInt(),
+		}},
+		AmountIn:  amountIn,
+		AmountOut: amountOut,
+	}, nil
+}
+
+// RecordSlots sets each hop's Slot to the slot its pool account was last
+// read at through solClient, leaving hops whose pool it never read unset
+func (r *Route) RecordSlots(solClient *sol.Client) {
+	for i := range r.Hops {
+		if slot, ok := poolReadSlot(solClient, r.Hops[i].Pool); ok {
+			r.Hops[i].Slot = slot
+		}
+	}
+}
+
+// poolReadSlot returns the slot pool's account was last read at through solClient
+func poolReadSlot(solClient *sol.Client, pool pkg.Pool) (uint64, bool) {
+	poolKey, err := solana.PublicKeyFromBase58(pool.GetID())
+	if err != nil {
+		return 0, false
+	}
+	return solClient.ReadSlot(poolKey)
+}
+
+// InputMint returns the mint the route starts from
+func (r *Route) InputMint() string {
+	if len(r.Hops) == 0 {
+		return ""
+	}
+	return r.Hops[0].InputMint
+}
+
+// OutputMint returns the mint the route ends in
+func (r *Route) OutputMint() string {
+	if len(r.Hops) == 0 {
+		return ""
+	}
+	return r.Hops[len(r.Hops)-1].OutputMint
+}
+
+// BuildRouteInstructions builds the swap instructions of every hop, using the
+// user's associated token accounts for each mint
+func BuildRouteInstructions(ctx context.Context, solClient *sol.Client, user solana.PublicKey, route *Route) ([]solana.Instruction, error) {
+	if len(route.Hops) == 0 {
+		return nil, fmt.Errorf("route has no hops")
+	}
+
+	instructions := make([]solana.Instruction, 0)
+	for i, hop := range route.Hops {
+		baseAccount, quoteAccount, err := userPoolAccounts(hop.Pool, user)
+		if err != nil {
+			return nil, fmt.Errorf("hop %d: %w", i, err)
+		}
+		if i == len(route.Hops)-1 && route.paysRecipientTokens() {
+			create, recipientAccount, err := recipientTokenAccount(user, route.Recipient, hop.OutputMint)
+			if err != nil {
+				return nil, fmt.Errorf("hop %d: %w", i, err)
+			}
+			instructions = append(instructions, create)
+			if baseMint, _ := hop.Pool.GetTokens(); hop.OutputMint == baseMint {
+				baseAccount = recipientAccount
+			} else {
+				quoteAccount = recipientAccount
+			}
+		}
+		minOut := hop.MinAmountOut
+		if minOut.IsNil() {
+			minOut = math.ZeroInt()
+		}
+		hopInstructions, err := hop.Pool.BuildSwapInstructions(ctx, solClient, user,
+			hop.InputMint, hop.AmountIn, minOut, baseAccount, quoteAccount)
+		if err != nil {
+			return nil, fmt.Errorf("hop %d: failed to build swap instructions for pool %s: %w", i, hop.Pool.GetID(), err)
+		}
+		if err := CheckMinOut(hop.Pool, hop.InputMint, hopInstructions, minOut); err != nil {
+			return nil, fmt.Errorf("hop %d: %w", i, err)
+		}
+		if err := layout.Validate(hopInstructions); err != nil {
+			return nil, fmt.Errorf("hop %d: %w", i, err)
+		}
+		instructions = append(instructions, hopInstructions...)
+	}
+	return instructions, nil
+}
+
+// paysRecipientTokens reports whether the last hop pays a token output
+// straight into the recipient's account. SOL output is delivered after the
+// unwrap instead
+func (r *Route) paysRecipientTokens() bool {
+	return !r.Recipient.IsZero() && r.OutputMint() != sol.WSOL.String()
+}
+
+// recipientTokenAccount returns the recipient's associated token account for
+// mint with an idempotent instruction creating it at user's expense
+func recipientTokenAccount(user, recipient solana.PublicKey, mint string) (solana.Instruction, solana.PublicKey, error) {
+	mintKey, err := solana.PublicKeyFromBase58(mint)
+	if err != nil {
+		return nil, solana.PublicKey{}, fmt.Errorf("invalid mint %s: %w", mint, err)
+	}
+	account, err := userTokenAccount(recipient, mint)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	create, err := sol.NewCreateATAIdempotentInstruction(user, recipient, mintKey)
+	if err != nil {
+		return nil, solana.PublicKey{}, fmt.Errorf("failed to create recipient token account: %w", err)
+	}
+	return create, account, nil
+}
+
+// userPoolAccounts derives the user's token accounts for a pool's base and quote mints
+func userPoolAccounts(pool pkg.Pool, user solana.PublicKey) (solana.PublicKey, solana.PublicKey, error) {
+	baseMint, quoteMint := pool.GetTokens()
+	baseAccount, err := userTokenAccount(user, baseMint)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, err
+	}
+	quoteAccount, err := userTokenAccount(user, quoteMint)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, err
+	}
+	return baseAccount, quoteAccount, nil
+}
+
+// userTokenAccount derives the user's associated token account for mint
+func userTokenAccount(user solana.PublicKey, mint string) (solana.PublicKey, error) {
+	mintKey, err := solana.PublicKeyFromBase58(mint)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("invalid mint %s: %w", mint, err)
+	}
+	account, _, err := sol.FindAssociatedTokenAddress(user, mintKey)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive token account for %s: %w", mint, err)
+	}
+	return account, nil
+}
+
+// otherMint returns the mint of pool that is not inputMint
+func otherMint(pool pkg.Pool, inputMint string) (string, error) {
+	baseMint, quoteMint := pool.GetTokens()
+	switch inputMint {
+	case baseMint:
+		return quoteMint, nil
+	case quoteMint:
+		return baseMint, nil
+	}
+	return "", fmt.Errorf("pool %s does not trade %s", pool.GetID(), inputMint)
+}
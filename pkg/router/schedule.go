@@ -0,0 +1,47 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// ErrRouteExpired is returned for routes whose NotAfter has passed
+var ErrRouteExpired = errors.New("route is past its execution deadline")
+
+// Earliest returns the cluster time from which the route may be sent: its
+// NotBefore or, when later, the open time of its last pool to open
+func (r *Route) Earliest() time.Time {
+	earliest := r.NotBefore
+	for _, hop := range r.Hops {
+		scheduled, ok := hop.Pool.(pkg.ScheduledPool)
+		if !ok {
+			continue
+		}
+		if opens := scheduled.OpensAt(); opens.After(earliest) {
+			earliest = opens
+		}
+	}
+	return earliest
+}
+
+// CheckWindow returns how long to wait at cluster time now before the route
+// may be sent, zero when it may go at once, or ErrRouteExpired when NotAfter
+// has passed or comes before the route can open
+func (r *Route) CheckWindow(now time.Time) (time.Duration, error) {
+	earliest := r.Earliest()
+	if !r.NotAfter.IsZero() {
+		if now.After(r.NotAfter) {
+			return 0, fmt.Errorf("%w: deadline %s, cluster time %s", ErrRouteExpired, r.NotAfter.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339))
+		}
+		if earliest.After(r.NotAfter) {
+			return 0, fmt.Errorf("%w: opens at %s, after the deadline %s", ErrRouteExpired, earliest.UTC().Format(time.RFC3339), r.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+	if earliest.After(now) {
+		return earliest.Sub(now), nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,183 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultSelfTestDeviationBps is how far a self-test's simulated output may
+// fall short of its quote
+const DefaultSelfTestDeviationBps = 100
+
+// SelfTestStage is a step of a protocol's self-test
+type SelfTestStage string
+
+const (
+	SelfTestFetch    SelfTestStage = "fetch"
+	SelfTestDecode   SelfTestStage = "decode"
+	SelfTestQuote    SelfTestStage = "quote"
+	SelfTestBuild    SelfTestStage = "build"
+	SelfTestSimulate SelfTestStage = "simulate"
+)
+
+// SelfTestCase is a known pool a protocol is checked against, with a swap
+// small enough for the payer to fund
+type SelfTestCase struct {
+	Protocol  pkg.ProtocolName `json:"protocol"`
+	PoolID    string           `json:"pool_id"`
+	InputMint string           `json:"input_mint"`
+	AmountIn  math.Int         `json:"amount_in"`
+	// MaxDeviationBps, when positive, replaces DefaultSelfTestDeviationBps
+	MaxDeviationBps int `json:"max_deviation_bps,omitempty"`
+}
+
+// SelfTestResult is the outcome of one protocol's self-test
+type SelfTestResult struct {
+	Protocol pkg.ProtocolName
+	PoolID   string
+	// Stage is the stage that failed, or the last one run
+	Stage  SelfTestStage
+	Passed bool
+	// Skipped is set for protocols without a case, which are not tested
+	Skipped bool
+	// AmountOut is the quote and SimulatedOut what the simulated swap paid,
+	// nil when the output is native SOL and cannot be measured
+	AmountOut    math.Int
+	SimulatedOut math.Int
+	// DeviationBps is how far SimulatedOut fell short of AmountOut
+	DeviationBps int64
+	Duration     time.Duration
+	Err          error
+}
+
+// SelfTestReport holds the self-test result of every enabled protocol
+type SelfTestReport struct {
+	Results []SelfTestResult
+}
+
+// Failed returns the results of protocols that were tested and failed
+func (r *SelfTestReport) Failed() []SelfTestResult {
+	var failed []SelfTestResult
+	for _, result := range r.Results {
+		if !result.Passed && !result.Skipped {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// SelfTest checks every protocol of the router against its case: it fetches
+// the known pool, decodes it through the protocol, quotes AmountIn and
+// simulates the built swap for payer, which must hold the input. It is meant
+// to run on startup, so an integration broken by a program upgrade is found
+// before it is routed through. A failing protocol does not stop the others; a
+// cancelled ctx does
+func (r *SimpleRouter) SelfTest(ctx context.Context, solClient *sol.Client, payer solana.PublicKey, cases []SelfTestCase) (*SelfTestReport, error) {
+	byProtocol := make(map[pkg.ProtocolName]SelfTestCase, len(cases))
+	for _, c := range cases {
+		byProtocol[c.Protocol] = c
+	}
+	report := &SelfTestReport{
+		Results: make([]SelfTestResult, 0, len(r.Protocols)),
+	}
+	for _, proto := range r.Protocols {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		c, ok := byProtocol[proto.ProtocolName()]
+		if !ok {
+			report.Results = append(report.Results, SelfTestResult{Protocol: proto.ProtocolName(), Skipped: true})
+			continue
+		}
+		start := time.Now()
+		result := r.selfTest(ctx, solClient, proto, payer, c)
+		result.Duration = time.Since(start)
+		if result.Passed {
+			log.Printf("self-test of %s passed on pool %s", result.Protocol, result.PoolID)
+		} else {
+			log.Printf("self-test of %s failed at %s on pool %s: %v", result.Protocol, result.Stage, result.PoolID, result.Err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, ctx.Err()
+}
+
+// selfTest runs the stages of one case, stopping at the first that fails
+func (r *SimpleRouter) selfTest(ctx context.Context, solClient *sol.Client, proto pkg.Protocol, payer solana.PublicKey, c SelfTestCase) SelfTestResult {
+	result := SelfTestResult{Protocol: proto.ProtocolName(), PoolID: c.PoolID, Stage: SelfTestFetch}
+	fail := func(err error) SelfTestResult {
+		result.Err = err
+		return result
+	}
+
+	poolKey, err := solana.PublicKeyFromBase58(c.PoolID)
+	if err != nil {
+		return fail(fmt.Errorf("invalid pool ID: %w", err))
+	}
+	if _, err := solClient.GetAccountInfoWithOpts(ctx, poolKey); err != nil {
+		return fail(fmt.Errorf("failed to get pool account: %w", err))
+	}
+
+	result.Stage = SelfTestDecode
+	pool, err := proto.FetchPoolByID(ctx, c.PoolID)
+	if err != nil {
+		return fail(err)
+	}
+
+	result.Stage = SelfTestQuote
+	if c.AmountIn.IsNil() || !c.AmountIn.IsPositive() {
+		return fail(fmt.Errorf("amount %s out of range", c.AmountIn))
+	}
+	amountOut, err := pool.Quote(ctx, solClient, c.InputMint, c.AmountIn)
+	if err != nil {
+		return fail(err)
+	}
+	if !amountOut.IsPositive() {
+		return fail(fmt.Errorf("quoted no output for %s", c.AmountIn))
+	}
+	result.AmountOut = amountOut
+
+	result.Stage = SelfTestBuild
+	route, err := NewSingleHopRoute(pool, c.InputMint, c.AmountIn, amountOut)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := BuildRouteInstructionsWithWSOL(ctx, solClient, payer, route); err != nil {
+		return fail(err)
+	}
+
+	result.Stage = SelfTestSimulate
+	simulated, err := r.SimulateRouteOutput(ctx, solClient, route, payer)
+	if errors.Is(err, ErrOutputNotMeasurable) {
+		// the swap must still go through, though what it paid is not read
+		if _, err := r.SimulateRoute(ctx, solClient, route, payer); err != nil {
+			return fail(err)
+		}
+		result.Passed = true
+		return result
+	}
+	if err != nil {
+		return fail(err)
+	}
+	result.SimulatedOut = simulated.AmountOut
+	if shortfall := amountOut.Sub(simulated.AmountOut); shortfall.IsPositive() {
+		result.DeviationBps = shortfall.MulRaw(10000).Quo(amountOut).Int64()
+	}
+	maxDeviation := int64(c.MaxDeviationBps)
+	if maxDeviation <= 0 {
+		maxDeviation = DefaultSelfTestDeviationBps
+	}
+	if result.DeviationBps > maxDeviation {
+		return fail(fmt.Errorf("simulated %s is %d bps short of the quote %s, tolerated %d", simulated.AmountOut, result.DeviationBps, amountOut, maxDeviation))
+	}
+	result.Passed = true
+	return result
+}
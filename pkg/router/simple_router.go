@@ -2,9 +2,12 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"cosmossdk.io/math"
 	"github.com/solana-zh/solroute/pkg"
@@ -14,6 +17,33 @@ import (
 type SimpleRouter struct {
 	Protocols []pkg.Protocol
 	Pools     []pkg.Pool
+
+	// QuoteCache memoizes quotes per pool/amount when set
+	QuoteCache *QuoteCache
+	// Breaker quarantines repeatedly failing pools when set
+	Breaker *CircuitBreaker
+	// DiscoveryCache reuses pool scans of earlier runs when set
+	DiscoveryCache *DiscoveryCache
+	// Warm keeps prebuilt instructions of hot pairs when set
+	Warm *WarmRoutes
+	// Volume supplies the recent volume feature of quotes when set
+	Volume VolumeIndex
+	// OnQuoteFeatures, when set, receives the feature vectors of every
+	// QuoteAll pass, for exporting them to an external model
+	OnQuoteFeatures func(features []QuoteFeatures)
+	// Scorer, when set, ranks the candidates of GetBestPool by external
+	// scores instead of net output
+	Scorer PoolScorer
+	// Coverage accumulates discovery and quote coverage per protocol when set
+	Coverage *CoverageMetrics
+	// PrefilterBps, when positive, skips quoting pools whose price estimated
+	// from vault balances trails the best estimate by more than PrefilterBps.
+	// Estimates ignore depth, so the slack should allow for the trade size
+	PrefilterBps int
+	// BigOrderBps, when positive, makes GetBestPool refuse orders above
+	// BigOrderBps of the best pool's input reserve with a *BigOrderAdvisory
+	// instead of quoting a price deep into the curve
+	BigOrderBps int
 }
 
 func NewSimpleRouter(protocols ...pkg.Protocol) *SimpleRouter {
@@ -23,72 +53,439 @@ func NewSimpleRouter(protocols ...pkg.Protocol) *SimpleRouter {
 	}
 }
 
-func (r *SimpleRouter) QueryAllPools(ctx context.Context, baseMint, quoteMint string) error {
+// ProtocolReport describes what one protocol contributed to a discovery run
+type ProtocolReport struct {
+	Protocol  pkg.ProtocolName
+	PoolCount int
+	Duration  time.Duration
+	Err       error
+	// Cached is set when the pools were reloaded from the discovery cache
+	Cached bool
+	// Coverage breaks the scanned accounts down into decoded and skipped.
+	// Protocols without coverage reporting and cached scans count every
+	// pool as both discovered and decoded
+	Coverage pkg.PoolCoverage
+}
+
+// DiscoveryReport summarizes a QueryAllPools run per protocol
+type DiscoveryReport struct {
+	Protocols []ProtocolReport
+}
+
+// Failed returns the reports of protocols whose discovery returned an error
+func (d *DiscoveryReport) Failed() []ProtocolReport {
+	failed := make([]ProtocolReport, 0)
+	for _, report := range d.Protocols {
+		if report.Err != nil {
+			failed = append(failed, report)
+		}
+	}
+	return failed
+}
+
+// TotalSkipped returns the number of accounts discovered across all protocols
+// that did not become pools
+func (d *DiscoveryReport) TotalSkipped() int {
+	total := 0
+	for _, report := range d.Protocols {
+		total += report.Coverage.Skipped()
+	}
+	return total
+}
+
+// TotalPools returns the number of pools discovered across all protocols
+func (d *DiscoveryReport) TotalPools() int {
+	total := 0
+	for _, report := range d.Protocols {
+		total += report.PoolCount
+	}
+	return total
+}
+
+// QueryAllPools discovers pools for the pair on every protocol, or only on
+// protocols when given, keeping the pools of the others. A failing protocol
+// does not abort discovery; its error is recorded in the report. A cancelled
+// ctx does, leaving the router's pools as they were
+func (r *SimpleRouter) QueryAllPools(ctx context.Context, baseMint, quoteMint string, protocols ...pkg.ProtocolName) (*DiscoveryReport, error) {
+	only, err := r.protocolSet(protocols)
+	if err != nil {
+		return nil, err
+	}
 	var allPools []pkg.Pool
+	if only != nil {
+		for _, pool := range r.Pools {
+			if !only.allows(pool.ProtocolName()) {
+				allPools = append(allPools, pool)
+			}
+		}
+	}
+	report := &DiscoveryReport{
+		Protocols: make([]ProtocolReport, 0, len(r.Protocols)),
+	}
 
 	// Loop through each protocol sequentially
 	for _, proto := range r.Protocols {
+		if !only.allows(proto.ProtocolName()) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
 		log.Printf("😈Fetching pools from protocol: %v", proto.ProtocolName())
-		pools, err := proto.FetchPoolsByPair(ctx, baseMint, quoteMint)
+		start := time.Now()
+		pools, coverage, cached, err := r.fetchPoolsByPair(ctx, proto, baseMint, quoteMint)
+		protocolReport := ProtocolReport{
+			Protocol:  proto.ProtocolName(),
+			PoolCount: len(pools),
+			Duration:  time.Since(start),
+			Err:       err,
+			Cached:    cached,
+			Coverage:  coverage,
+		}
+		if r.Coverage != nil && ctx.Err() == nil {
+			r.Coverage.recordDiscovery(proto.ProtocolName(), coverage)
+		}
+		if skipped := coverage.Skipped(); skipped > 0 {
+			log.Printf("skipped %d of %d %s accounts (%d failed to decode)", skipped, coverage.Discovered, proto.ProtocolName(), coverage.DecodeFailed)
+		}
 		if err != nil {
 			log.Printf("error fetching pools from protocol: %v", err)
+			protocolReport.PoolCount = 0
+			report.Protocols = append(report.Protocols, protocolReport)
 			continue
 		}
+		report.Protocols = append(report.Protocols, protocolReport)
 		allPools = append(allPools, pools...)
 	}
+	// a protocol cut short by cancellation reports fewer pools than exist
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
 
-	r.Pools = allPools
-	return nil
+	r.Pools = r.mergePools(allPools)
+	if r.QuoteCache != nil {
+		r.QuoteCache.Invalidate()
+	}
+	return report, nil
 }
 
-func (r *SimpleRouter) GetBestPool(ctx context.Context, solClient *sol.Client, tokenIn string, amountIn math.Int) (pkg.Pool, math.Int, error) {
-	type quoteResult struct {
-		pool      pkg.Pool
-		outAmount math.Int
-		err       error
+// fetchPoolsByPair reloads the pools of a cached scan when possible and
+// otherwise scans the protocol, storing the result in the discovery cache
+func (r *SimpleRouter) fetchPoolsByPair(ctx context.Context, proto pkg.Protocol, baseMint, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, bool, error) {
+	if r.DiscoveryCache != nil {
+		if pools, ok := r.DiscoveryCache.fetchCachedPools(ctx, proto, baseMint, quoteMint); ok {
+			return pools, fullCoverage(pools), true, nil
+		}
+	}
+	pools, coverage, err := fetchPoolsWithCoverage(ctx, proto, baseMint, quoteMint)
+	if err != nil {
+		return nil, coverage, false, err
 	}
+	if r.DiscoveryCache != nil {
+		if err := r.DiscoveryCache.Store(ctx, proto.ProtocolName(), baseMint, quoteMint, pools); err != nil {
+			log.Printf("failed to store discovered pools: %v", err)
+		}
+	}
+	return pools, coverage, false, nil
+}
+
+// fetchPoolsWithCoverage scans the protocol, taking the coverage from
+// protocols that report it
+func fetchPoolsWithCoverage(ctx context.Context, proto pkg.Protocol, baseMint, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	if reporting, ok := proto.(pkg.CoverageProtocol); ok {
+		return reporting.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	}
+	pools, err := proto.FetchPoolsByPair(ctx, baseMint, quoteMint)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, err
+	}
+	return pools, fullCoverage(pools), nil
+}
 
-	// Create a channel to collect results
-	resultChan := make(chan quoteResult, len(r.Pools))
+// fullCoverage is the coverage of a scan known only by the pools it returned
+func fullCoverage(pools []pkg.Pool) pkg.PoolCoverage {
+	return pkg.PoolCoverage{Discovered: len(pools), Decoded: len(pools)}
+}
+
+// PoolQuote is the outcome of quoting a single pool
+type PoolQuote struct {
+	Pool      pkg.Pool
+	AmountOut math.Int
+	// NetAmountOut is AmountOut plus any rebate or extra fee the venue
+	// settles outside the swap
+	NetAmountOut math.Int
+	// OutputMint is the mint AmountOut is paid in, resolved from the pool's
+	// own base/quote orientation
+	OutputMint string
+	// NormalizedOut is NetAmountOut in whole tokens of OutputMint, nil when
+	// its decimals are unknown. Quotes are ranked by it when present
+	NormalizedOut math.LegacyDec
+	// SpotPrice is the pool's marginal price after the quote's refresh, in
+	// whole OutputMint tokens per whole input token before fees; nil when
+	// the pool does not report one or decimals are unknown
+	SpotPrice math.LegacyDec
+	// QuotedAt is when AmountOut was computed, earlier than the call for
+	// quotes served from the quote cache
+	QuotedAt time.Time
+	Err      error
+}
+
+// QuoteAll quotes every pool in one concurrent pass and returns all results,
+// successful quotes first ordered by net output normalized to whole tokens.
+// Pools that do not trade tokenIn, and pools dropped by the price pre-filter,
+// are reported as errors without quoting.
+// When protocols are given only their pools are quoted
+func (r *SimpleRouter) QuoteAll(ctx context.Context, solClient *sol.Client, tokenIn string, amountIn math.Int, protocols ...pkg.ProtocolName) []PoolQuote {
+	pools := r.Pools
+	if len(protocols) > 0 {
+		only := make(protocolSet, len(protocols))
+		for _, name := range protocols {
+			only[name] = struct{}{}
+		}
+		pools = make([]pkg.Pool, 0, len(r.Pools))
+		for _, pool := range r.Pools {
+			if only.allows(pool.ProtocolName()) {
+				pools = append(pools, pool)
+			}
+		}
+	}
+	var dropped map[string]struct{}
+	if r.PrefilterBps > 0 {
+		dropped = prefilter(ctx, solClient, pools, tokenIn, r.PrefilterBps)
+	}
+	quotes := make([]PoolQuote, len(pools))
 	var wg sync.WaitGroup
 
-	// Launch goroutines for each pool
-	for _, pool := range r.Pools {
+	// Launch goroutines for each pool, each one owning its slot in quotes
+	for i, pool := range pools {
 		wg.Add(1)
-		go func(p pkg.Pool) {
+		go func(i int, p pkg.Pool) {
 			defer wg.Done()
-			outAmount, err := p.Quote(ctx, solClient, tokenIn, amountIn)
-			resultChan <- quoteResult{
-				pool:      p,
-				outAmount: outAmount,
-				err:       err,
+			outputMint, err := otherMint(p, tokenIn)
+			if err != nil {
+				quotes[i] = PoolQuote{Pool: p, Err: err}
+				return
+			}
+			if _, ok := dropped[p.GetID()]; ok {
+				quotes[i] = PoolQuote{Pool: p, OutputMint: outputMint, Err: ErrUncompetitive}
+				return
+			}
+			outAmount, quotedAt, err := r.quotePoolAt(ctx, solClient, p, tokenIn, amountIn)
+			quotes[i] = PoolQuote{
+				Pool:       p,
+				AmountOut:  outAmount,
+				OutputMint: outputMint,
+				QuotedAt:   quotedAt,
+				Err:        err,
 			}
-		}(pool)
+			if err == nil {
+				quotes[i].NetAmountOut = outAmount.Add(feeAdjustment(p, tokenIn, amountIn, outAmount))
+			}
+		}(i, pool)
 	}
+	wg.Wait()
 
-	// Close the channel when all goroutines are done
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	decimals := outputDecimals(ctx, solClient, quotes)
+	inputDecimals, inputKnown := mintDecimals(ctx, solClient, pools, tokenIn)
+	for i := range quotes {
+		if quotes[i].Err != nil {
+			continue
+		}
+		d, ok := decimals[quotes[i].OutputMint]
+		if !ok {
+			continue
+		}
+		quotes[i].NormalizedOut = normalizeAmount(quotes[i].NetAmountOut, d)
+		if inputKnown {
+			if price, err := spotPrice(quotes[i].Pool, tokenIn, inputDecimals, d); err == nil {
+				quotes[i].SpotPrice = price
+			}
+		}
+	}
 
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if (quotes[i].Err == nil) != (quotes[j].Err == nil) {
+			return quotes[i].Err == nil
+		}
+		if quotes[i].Err != nil {
+			return false
+		}
+		return betterQuote(quotes[i], quotes[j])
+	})
+	if r.OnQuoteFeatures != nil {
+		r.OnQuoteFeatures(r.Features(quotes, tokenIn, amountIn))
+	}
+	return quotes
+}
+
+// mergePools dedupes discovered pools by ID and reuses the pool objects from
+// earlier refreshes so their runtime caches survive rediscovery
+func (r *SimpleRouter) mergePools(discovered []pkg.Pool) []pkg.Pool {
+	existing := make(map[string]pkg.Pool, len(r.Pools))
+	for _, pool := range r.Pools {
+		existing[pool.GetID()] = pool
+	}
+
+	seen := make(map[string]struct{}, len(discovered))
+	merged := make([]pkg.Pool, 0, len(discovered))
+	for _, pool := range discovered {
+		id := pool.GetID()
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if previous, ok := existing[id]; ok {
+			if updatable, ok := previous.(pkg.UpdatablePool); ok && updatable.UpdateFrom(pool) {
+				pool = previous
+			}
+		}
+		merged = append(merged, pool)
+	}
+	return merged
+}
+
+// ResetPoolCaches drops the tick and bin arrays cached by the router's pools,
+// releasing their memory until the pools are quoted again
+func (r *SimpleRouter) ResetPoolCaches() {
+	for _, pool := range r.Pools {
+		if resettable, ok := pool.(pkg.ResettablePool); ok {
+			resettable.Reset()
+		}
+	}
+}
+
+// GetBestPool returns the pool with the highest net output for amountIn and
+// its quoted output, choosing among the pools of protocols when given. With
+// BigOrderBps set, an order too large for the pool fails with a
+// *BigOrderAdvisory wrapping ErrBigOrder
+func (r *SimpleRouter) GetBestPool(ctx context.Context, solClient *sol.Client, tokenIn string, amountIn math.Int, protocols ...pkg.ProtocolName) (pkg.Pool, math.Int, error) {
+	if _, err := r.protocolSet(protocols); err != nil {
+		return nil, math.ZeroInt(), err
+	}
 	// Collect results and find the best one
-	var best pkg.Pool
-	maxOut := math.NewInt(0)
+	candidates := make([]PoolQuote, 0)
+	var best *PoolQuote
 
-	for result := range resultChan {
-		if result.err != nil {
-			log.Printf("error quoting pool %s: %v", result.pool.GetID(), result.err)
+	for _, result := range r.QuoteAll(ctx, solClient, tokenIn, amountIn, protocols...) {
+		if errors.Is(result.Err, ErrPoolQuarantined) || errors.Is(result.Err, ErrUncompetitive) {
+			continue
+		}
+		if result.Err != nil {
+			log.Printf("error quoting pool %s: %v", result.Pool.GetID(), result.Err)
 			continue
 		}
-		if result.outAmount.GT(maxOut) {
-			maxOut = result.outAmount
-			best = result.pool
+		if !result.NetAmountOut.IsPositive() {
+			continue
+		}
+		candidates = append(candidates, result)
+		if best == nil || betterQuote(result, *best) {
+			best = &result
 		}
 	}
 
 	if best == nil {
 		return nil, math.ZeroInt(), fmt.Errorf("no route found")
 	}
-	return best, maxOut, nil
+	if r.Scorer != nil {
+		if scored, ok := r.bestScored(ctx, candidates, tokenIn, amountIn); ok {
+			best = scored
+		}
+	}
+	if r.BigOrderBps > 0 {
+		if advisory := r.bigOrderAdvisory(*best, candidates, tokenIn, amountIn); advisory != nil {
+			return nil, math.ZeroInt(), advisory
+		}
+	}
+	return best.Pool, best.AmountOut, nil
+}
+
+// feeAdjustment is what pool credits or charges outside the quoted output, zero
+// for pools that settle everything in the swap
+func feeAdjustment(pool pkg.Pool, tokenIn string, amountIn, amountOut math.Int) math.Int {
+	adjusted, ok := pool.(pkg.FeeAdjustedPool)
+	if !ok {
+		return math.ZeroInt()
+	}
+	adjustment := adjusted.FeeAdjustment(tokenIn, amountIn, amountOut)
+	if adjustment.IsNil() {
+		return math.ZeroInt()
+	}
+	return adjustment
+}
+
+// quotePool quotes a single pool, going through the quote cache when enabled
+func (r *SimpleRouter) quotePool(ctx context.Context, solClient *sol.Client, pool pkg.Pool, tokenIn string, amountIn math.Int) (math.Int, error) {
+	amountOut, _, err := r.quotePoolAt(ctx, solClient, pool, tokenIn, amountIn)
+	return amountOut, err
+}
+
+// quotePoolAt is quotePool also returning when the quote was computed, earlier
+// than now for quotes served from the cache
+func (r *SimpleRouter) quotePoolAt(ctx context.Context, solClient *sol.Client, pool pkg.Pool, tokenIn string, amountIn math.Int) (math.Int, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return math.Int{}, time.Time{}, err
+	}
+	if r.Breaker != nil && !r.Breaker.Allow(pool.GetID()) {
+		return math.Int{}, time.Time{}, ErrPoolQuarantined
+	}
+
+	if r.QuoteCache != nil {
+		if amountOut, quotedAt, ok := r.cachedQuote(ctx, solClient, pool, tokenIn, amountIn); ok {
+			if r.Coverage != nil {
+				r.Coverage.recordQuote(pool.ProtocolName(), nil)
+			}
+			return amountOut, quotedAt, nil
+		}
+	}
+
+	quotedAt := time.Now()
+	amountOut, err := pool.Quote(ctx, solClient, tokenIn, amountIn)
+	if err != nil {
+		// a quote cut short by the caller says nothing about the pool
+		if ctx.Err() == nil {
+			if r.Breaker != nil {
+				r.Breaker.RecordFailure(pool.GetID(), err)
+			}
+			if r.Coverage != nil {
+				r.Coverage.recordQuote(pool.ProtocolName(), err)
+			}
+		}
+		return math.Int{}, time.Time{}, err
+	}
+	if r.Breaker != nil {
+		r.Breaker.RecordSuccess(pool.GetID())
+	}
+	if r.Coverage != nil {
+		r.Coverage.recordQuote(pool.ProtocolName(), nil)
+	}
+
+	if r.QuoteCache != nil {
+		// the quote refreshed the pool; key it on the state it priced
+		if slot, ok := poolReadSlot(solClient, pool); ok {
+			r.QuoteCache.put(pool.GetID(), tokenIn, amountIn, amountOut, slot, quotesLots(pool))
+		}
+	}
+	return amountOut, quotedAt, nil
+}
+
+// cachedQuote looks up a memoized quote against the pool state last read,
+// which is only possible once the pool was read through solClient
+func (r *SimpleRouter) cachedQuote(ctx context.Context, solClient *sol.Client, pool pkg.Pool, tokenIn string, amountIn math.Int) (math.Int, time.Time, bool) {
+	slot, ok := poolReadSlot(solClient, pool)
+	if !ok {
+		return math.Int{}, time.Time{}, false
+	}
+	currentSlot, err := solClient.CurrentSlot(ctx)
+	if err != nil {
+		return math.Int{}, time.Time{}, false
+	}
+	return r.QuoteCache.get(pool.GetID(), tokenIn, amountIn, slot, currentSlot, quotesLots(pool))
+}
+
+// quotesLots reports whether pool fills whole lots, so its quotes are cached
+// for the exact amount only
+func quotesLots(pool pkg.Pool) bool {
+	_, ok := pool.(pkg.LotSizedPool)
+	return ok
 }
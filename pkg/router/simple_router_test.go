@@ -0,0 +1,120 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+const (
+	testBase  = "So11111111111111111111111111111111111111112"
+	testQuote = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+)
+
+func newFakePool(id string, protocol pkg.ProtocolName, amountOut int64) *fakePool {
+	return &fakePool{id: id, protocol: protocol, base: testBase, quote: testQuote, amountOut: math.NewInt(amountOut)}
+}
+
+func poolIDs(pools []pkg.Pool) []string {
+	ids := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		ids = append(ids, pool.GetID())
+	}
+	return ids
+}
+
+func TestMergePoolsDedupesAcrossProtocols(t *testing.T) {
+	r := NewSimpleRouter()
+	merged := r.mergePools([]pkg.Pool{
+		newFakePool("a", "first", 1),
+		newFakePool("b", "first", 1),
+		// the same account listed again by another protocol
+		newFakePool("a", "second", 2),
+	})
+	if ids := poolIDs(merged); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("merged pools = %v, want [a b]", ids)
+	}
+	if merged[0].ProtocolName() != "first" {
+		t.Fatalf("pool a taken from %s, want the first protocol listing it", merged[0].ProtocolName())
+	}
+}
+
+func TestMergePoolsKeepsPoolIdentity(t *testing.T) {
+	r := NewSimpleRouter()
+	original := newFakePool("a", "first", 1)
+	r.Pools = []pkg.Pool{original}
+
+	merged := r.mergePools([]pkg.Pool{newFakePool("a", "first", 5), newFakePool("b", "first", 1)})
+	if merged[0] != pkg.Pool(original) {
+		t.Fatal("rediscovered pool replaced the existing object")
+	}
+	if original.updates != 1 || !original.amountOut.Equal(math.NewInt(5)) {
+		t.Fatalf("existing pool not updated from the fresh one: %d updates, amount out %s", original.updates, original.amountOut)
+	}
+}
+
+func TestQueryAllPoolsReportsFailingProtocol(t *testing.T) {
+	failure := errors.New("rpc unavailable")
+	r := NewSimpleRouter(
+		&fakeProtocol{name: "first", pools: []pkg.Pool{newFakePool("a", "first", 1), newFakePool("b", "first", 1)}},
+		&fakeProtocol{name: "broken", err: failure},
+		&fakeProtocol{name: "second", pools: []pkg.Pool{newFakePool("b", "second", 1), newFakePool("c", "second", 1)}},
+	)
+
+	report, err := r.QueryAllPools(context.Background(), testBase, testQuote)
+	if err != nil {
+		t.Fatalf("a failing protocol aborted discovery: %v", err)
+	}
+	if ids := poolIDs(r.Pools); len(ids) != 3 || ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Fatalf("router pools = %v, want [a b c]", ids)
+	}
+	if len(report.Protocols) != 3 {
+		t.Fatalf("report has %d protocols, want 3", len(report.Protocols))
+	}
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].Protocol != "broken" || !errors.Is(failed[0].Err, failure) || failed[0].PoolCount != 0 {
+		t.Fatalf("failed protocols = %+v, want only broken", failed)
+	}
+	// pool counts are per protocol, before deduplication
+	if total := report.TotalPools(); total != 4 {
+		t.Fatalf("total pools = %d, want 4", total)
+	}
+}
+
+func TestQueryAllPoolsKeepsOtherProtocols(t *testing.T) {
+	first := &fakeProtocol{name: "first", pools: []pkg.Pool{newFakePool("a", "first", 1)}}
+	second := &fakeProtocol{name: "second", pools: []pkg.Pool{newFakePool("b", "second", 1)}}
+	r := NewSimpleRouter(first, second)
+	if _, err := r.QueryAllPools(context.Background(), testBase, testQuote); err != nil {
+		t.Fatal(err)
+	}
+
+	second.err = errors.New("rpc unavailable")
+	first.pools = []pkg.Pool{newFakePool("a", "first", 1), newFakePool("d", "first", 1)}
+	report, err := r.QueryAllPools(context.Background(), testBase, testQuote, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Protocols) != 1 || report.Protocols[0].Protocol != "first" {
+		t.Fatalf("report covers %+v, want only first", report.Protocols)
+	}
+	if ids := poolIDs(r.Pools); len(ids) != 3 || ids[0] != "b" {
+		t.Fatalf("router pools = %v, want b kept ahead of a and d", ids)
+	}
+}
+
+func TestQueryAllPoolsCanceled(t *testing.T) {
+	r := NewSimpleRouter(&fakeProtocol{name: "first", pools: []pkg.Pool{newFakePool("a", "first", 1)}})
+	r.Pools = []pkg.Pool{newFakePool("z", "first", 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.QueryAllPools(ctx, testBase, testQuote); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if ids := poolIDs(r.Pools); len(ids) != 1 || ids[0] != "z" {
+		t.Fatalf("router pools = %v after a canceled discovery, want them untouched", ids)
+	}
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// tokenAccountAmountOffset is the offset of the amount field in an SPL token account
+const tokenAccountAmountOffset = 64
+
+// ErrOutputNotMeasurable is returned by SimulateRouteOutput for routes whose
+// output cannot be read from a token account
+var ErrOutputNotMeasurable = errors.New("route output cannot be measured")
+
+// SimulationResult is the on-chain outcome of a simulated route
+type SimulationResult struct {
+	AmountOut     math.Int
+	UnitsConsumed uint64
+	Logs          []string
+}
+
+// SimulateRoute builds the route transaction for payer, simulates it and
+// returns the output amount observed on chain along with the compute used
+func (r *SimpleRouter) SimulateRoute(ctx context.Context, solClient *sol.Client, route *Route, payer solana.PublicKey) (*SimulationResult, error) {
+	instructions, err := BuildRouteInstructions(ctx, solClient, payer, route)
+	if err != nil {
+		return nil, err
+	}
+
+	outputAccount, err := userTokenAccount(payer, route.OutputMint())
+	if err != nil {
+		return nil, err
+	}
+	return simulateOutput(ctx, solClient, instructions, payer, outputAccount)
+}
+
+// SimulateRouteOutput simulates the route as BuildRouteInstructionsWithWSOL
+// builds it, up to and including its last hop, and returns what the last hop
+// paid into the output token account, the recipient's when it has one. Routes
+// whose last hop pays native SOL return ErrOutputNotMeasurable
+func (r *SimpleRouter) SimulateRouteOutput(ctx context.Context, solClient *sol.Client, route *Route, payer solana.PublicKey) (*SimulationResult, error) {
+	if len(route.Hops) == 0 {
+		return nil, fmt.Errorf("route has no hops")
+	}
+	last := route.Hops[len(route.Hops)-1]
+	if usesNativeSOL(last.Pool, last.OutputMint) {
+		return nil, fmt.Errorf("%w: pool %s pays native SOL", ErrOutputNotMeasurable, last.Pool.GetID())
+	}
+	segments, err := buildRouteSegments(ctx, solClient, payer, route)
+	if err != nil {
+		return nil, err
+	}
+	// the WSOL steps after the last hop would close the account measured
+	instructions := make([]solana.Instruction, 0)
+	for _, segment := range segments {
+		if segment.hop == len(route.Hops) {
+			break
+		}
+		instructions = append(instructions, segment.instructions...)
+	}
+
+	owner := payer
+	if route.paysRecipientTokens() {
+		owner = route.Recipient
+	}
+	outputAccount, err := userTokenAccount(owner, last.OutputMint)
+	if err != nil {
+		return nil, err
+	}
+	return simulateOutput(ctx, solClient, instructions, payer, outputAccount)
+}
+
+// simulateOutput simulates instructions paid by payer and returns how much
+// outputAccount gained
+func simulateOutput(ctx context.Context, solClient *sol.Client, instructions []solana.Instruction, payer, outputAccount solana.PublicKey) (*SimulationResult, error) {
+	before, err := tokenAccountAmount(ctx, solClient, outputAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	// Simulation skips signature checks but still expects one slot per signer
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	res, err := solClient.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentProcessed,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: []solana.PublicKey{outputAccount},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate route: %w", err)
+	}
+	if res.Value.Err != nil {
+		return nil, fmt.Errorf("route simulation failed: %v", res.Value.Err)
+	}
+
+	result := &SimulationResult{
+		AmountOut: math.ZeroInt(),
+		Logs:      res.Value.Logs,
+	}
+	if res.Value.UnitsConsumed != nil {
+		result.UnitsConsumed = *res.Value.UnitsConsumed
+	}
+	if len(res.Value.Accounts) == 1 && res.Value.Accounts[0] != nil {
+		after, err := decodeTokenAmount(res.Value.Accounts[0].Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		if after > before {
+			result.AmountOut = math.NewIntFromUint64(after - before)
+		}
+	}
+	return result, nil
+}
+
+// tokenAccountAmount reads a token account balance, treating a missing account as empty
+func tokenAccountAmount(ctx context.Context, solClient *sol.Client, account solana.PublicKey) (uint64, error) {
+	info, err := solClient.GetAccountInfoWithOpts(ctx, account)
+	if err != nil {
+		if err == rpc.ErrNotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get token account %s: %w", account, err)
+	}
+	return decodeTokenAmount(info.Value.Data.GetBinary())
+}
+
+func decodeTokenAmount(data []byte) (uint64, error) {
+	if len(data) < tokenAccountAmountOffset+8 {
+		return 0, fmt.Errorf("token account data too short: %d bytes", len(data))
+	}
+	return binary.LittleEndian.Uint64(data[tokenAccountAmountOffset:]), nil
+}
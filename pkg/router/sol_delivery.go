@@ -0,0 +1,182 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// SOLDelivery sets how a route ending in SOL hands its output over
+type SOLDelivery struct {
+	// KeepWSOL leaves a WSOL output in the user's WSOL account instead of
+	// closing it. Pools paying native lamports are unaffected
+	KeepWSOL bool
+	// Destination, when set, is sent the last hop's MinAmountOut as native
+	// SOL after the unwrap. Output above the minimum and any rent refund stay
+	// with the user
+	Destination solana.PublicKey
+}
+
+// transfers reports whether the delivery sends the output on
+func (d *SOLDelivery) transfers() bool {
+	return d != nil && !d.Destination.IsZero()
+}
+
+// keepsWSOL reports whether the final WSOL account stays open
+func (d *SOLDelivery) keepsWSOL() bool {
+	return d != nil && d.KeepWSOL
+}
+
+// equal reports whether two deliveries build the same instructions, apart
+// from the transferred amount
+func (d *SOLDelivery) equal(other *SOLDelivery) bool {
+	return d.keepsWSOL() == other.keepsWSOL() && d.transfers() == other.transfers() &&
+		(!d.transfers() || d.Destination.Equals(other.Destination))
+}
+
+// solDestination is the account a SOL output is sent on to: the delivery's
+// destination, else the route's recipient. Zero when the output stays with
+// the user or is not SOL
+func (r *Route) solDestination() solana.PublicKey {
+	if r.Delivery.transfers() {
+		return r.Delivery.Destination
+	}
+	if r.OutputMint() == sol.WSOL.String() {
+		return r.Recipient
+	}
+	return solana.PublicKey{}
+}
+
+// checkDelivery rejects deliveries the route cannot honor
+func checkDelivery(route *Route) error {
+	if route.Delivery.transfers() && !route.Recipient.IsZero() && !route.Recipient.Equals(route.Delivery.Destination) {
+		return fmt.Errorf("recipient %s and delivery destination %s differ", route.Recipient, route.Delivery.Destination)
+	}
+	destination := route.solDestination()
+	if destination.IsZero() {
+		if route.Delivery.transfers() {
+			return fmt.Errorf("route ends in %s, only SOL output can be transferred", route.OutputMint())
+		}
+		return nil
+	}
+	if route.Delivery.keepsWSOL() {
+		return fmt.Errorf("cannot keep WSOL and transfer native SOL to %s", destination)
+	}
+	minOut := route.Hops[len(route.Hops)-1].MinAmountOut
+	if minOut.IsNil() || !minOut.IsPositive() {
+		return fmt.Errorf("the last hop needs a MinAmountOut, which is the amount transferred")
+	}
+	return nil
+}
+
+// SOLProceeds is the lamport accounting of a route ending in SOL
+type SOLProceeds struct {
+	// AmountOut is the route's quoted output
+	AmountOut math.Int
+	// Rent is the token account rent the route returns to the user: positive
+	// when it closes a WSOL account that existed before, negative when it
+	// leaves a new one open, zero when it opens and closes its own
+	Rent math.Int
+	// Reclaimed is WSOL the user held before the route, released as native
+	// SOL when the route closes the account
+	Reclaimed math.Int
+	// Transferred is what the delivery sends to its destination
+	Transferred math.Int
+	// NetAmountOut is what the route leaves the user in SOL, native or
+	// wrapped: AmountOut plus Rent and Reclaimed, less Transferred
+	NetAmountOut math.Int
+}
+
+// SOLProceeds accounts for what route leaves user once its WSOL steps and
+// delivery run, reading the user's WSOL account as it stands before the route
+func (r *Route) SOLProceeds(ctx context.Context, solClient *sol.Client, user solana.PublicKey) (*SOLProceeds, error) {
+	if r.OutputMint() != sol.WSOL.String() {
+		return nil, fmt.Errorf("route ends in %s, not SOL", r.OutputMint())
+	}
+	if err := checkDelivery(r); err != nil {
+		return nil, err
+	}
+	wsolAccount, _, err := sol.FindAssociatedTokenAddress(user, sol.WSOL)
+	if err != nil {
+		return nil, err
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{wsolAccount})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WSOL account: %w", err)
+	}
+	rent, err := solClient.GetMinimumBalanceForRentExemption(ctx, sol.TokenAccountSize, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token account rent: %w", err)
+	}
+
+	proceeds := &SOLProceeds{
+		AmountOut:   r.AmountOut,
+		Rent:        math.ZeroInt(),
+		Reclaimed:   math.ZeroInt(),
+		Transferred: math.ZeroInt(),
+	}
+	if proceeds.AmountOut.IsNil() {
+		proceeds.AmountOut = r.Hops[len(r.Hops)-1].AmountOut
+	}
+
+	// an account held before the route returns its own rent and balance on
+	// its first close; accounts the route opens cost the current rent
+	open := false
+	var heldRent, held uint64
+	if amount, ok := sol.TokenAccountAmount(results, 0); ok {
+		open = true
+		held = amount
+		if lamports := results.Value[0].Lamports; lamports > amount {
+			heldRent = lamports - amount
+		}
+	}
+	preexisting := open
+	opened := func() {
+		if !open {
+			proceeds.Rent = proceeds.Rent.Sub(math.NewIntFromUint64(rent))
+			open = true
+		}
+	}
+	steps := PlanWSOL(r)
+	for i, hop := range r.Hops {
+		for _, step := range steps {
+			if step.BeforeHop != i {
+				continue
+			}
+			if step.Action == WSOLWrap {
+				opened()
+			} else if step.Action == WSOLUnwrap && open {
+				proceeds.Rent, proceeds.Reclaimed = closeWSOL(proceeds, preexisting, heldRent, held, rent)
+				open, preexisting = false, false
+			}
+		}
+		wsol := sol.WSOL.String()
+		if (hop.InputMint == wsol || hop.OutputMint == wsol) && !usesNativeSOL(hop.Pool, wsol) {
+			opened()
+		}
+	}
+	for _, step := range steps {
+		if step.BeforeHop == len(r.Hops) && step.Action == WSOLUnwrap && open {
+			proceeds.Rent, proceeds.Reclaimed = closeWSOL(proceeds, preexisting, heldRent, held, rent)
+		}
+		if step.Action == WSOLTransfer {
+			proceeds.Transferred = step.Amount
+		}
+	}
+	proceeds.NetAmountOut = proceeds.AmountOut.Add(proceeds.Rent).Add(proceeds.Reclaimed).Sub(proceeds.Transferred)
+	return proceeds, nil
+}
+
+// closeWSOL returns the rent and reclaimed balance after closing the WSOL
+// account: the rent it was opened with comes back, and an account held before
+// the route also returns its own rent and balance
+func closeWSOL(proceeds *SOLProceeds, preexisting bool, heldRent, held, rent uint64) (math.Int, math.Int) {
+	if preexisting {
+		return proceeds.Rent.Add(math.NewIntFromUint64(heldRent)), proceeds.Reclaimed.Add(math.NewIntFromUint64(held))
+	}
+	return proceeds.Rent.Add(math.NewIntFromUint64(rent)), proceeds.Reclaimed
+}
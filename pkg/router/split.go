@@ -0,0 +1,165 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cosmossdk.io/math"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultSplitSteps is the number of chunks an order is cut into when splitting
+const DefaultSplitSteps = 20
+
+// Allocation is the share of a split order sent through one pool
+type Allocation struct {
+	Pool      pkg.Pool
+	AmountIn  math.Int
+	AmountOut math.Int
+}
+
+// Split is an order spread across several pools for the same pair
+type Split struct {
+	InputMint   string
+	Allocations []Allocation
+	AmountIn    math.Int
+	AmountOut   math.Int
+}
+
+// Routes returns one single-hop route per allocation
+func (s *Split) Routes() ([]*Route, error) {
+	routes := make([]*Route, 0, len(s.Allocations))
+	for _, allocation := range s.Allocations {
+		route, err := NewSingleHopRoute(allocation.Pool, s.InputMint, allocation.AmountIn, allocation.AmountOut)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// splitCandidate tracks one pool while the order is being allocated
+type splitCandidate struct {
+	pool      pkg.Pool
+	amountIn  math.Int
+	amountOut math.Int
+	// nextOut is the output for amountIn plus the next chunk; nil once the
+	// pool can not take more
+	nextOut math.Int
+	// adjustment and nextAdjustment are the venue rebates or fees outside the
+	// swap for amountOut and nextOut
+	adjustment     math.Int
+	nextAdjustment math.Int
+}
+
+// gain is the extra net output of giving the pool the next chunk, i.e. its
+// marginal price over that chunk
+func (c *splitCandidate) gain() math.Int {
+	if c.nextOut.IsNil() {
+		return math.Int{}
+	}
+	return c.nextOut.Add(c.nextAdjustment).Sub(c.amountOut.Add(c.adjustment))
+}
+
+// OptimizeSplit spreads amountIn across pools to maximize the total output.
+// The order is cut into steps chunks and each chunk goes to the pool whose
+// marginal output for it is highest, which equalizes marginal prices across
+// pools as the curves are concave. Pools that fail to quote are left out.
+// A non-positive steps uses DefaultSplitSteps
+func (r *SimpleRouter) OptimizeSplit(ctx context.Context, solClient *sol.Client, pools []pkg.Pool, tokenIn string, amountIn math.Int, steps int) (*Split, error) {
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("no pools to split across")
+	}
+	if !amountIn.IsPositive() {
+		return nil, fmt.Errorf("amount in must be positive")
+	}
+	if steps <= 0 {
+		steps = DefaultSplitSteps
+	}
+	if amountIn.LT(math.NewInt(int64(steps))) {
+		steps = int(amountIn.Int64())
+	}
+	chunk := amountIn.QuoRaw(int64(steps))
+
+	// the first chunk is quoted on every pool concurrently
+	candidates := make([]*splitCandidate, len(pools))
+	var wg sync.WaitGroup
+	for i, pool := range pools {
+		wg.Add(1)
+		go func(i int, pool pkg.Pool) {
+			defer wg.Done()
+			candidate := &splitCandidate{pool: pool, amountIn: math.ZeroInt(), amountOut: math.ZeroInt(), adjustment: math.ZeroInt()}
+			if out, err := r.quotePool(ctx, solClient, pool, tokenIn, chunk); err == nil && out.IsPositive() {
+				candidate.nextOut = out
+				candidate.nextAdjustment = feeAdjustment(pool, tokenIn, chunk, out)
+			}
+			candidates[i] = candidate
+		}(i, pool)
+	}
+	wg.Wait()
+
+	allocated := math.ZeroInt()
+	for step := 0; step < steps; step++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		size := chunk
+		if step == steps-1 {
+			// the last chunk absorbs the division remainder
+			size = amountIn.Sub(allocated)
+		}
+
+		var best *splitCandidate
+		for _, candidate := range candidates {
+			gain := candidate.gain()
+			if gain.IsNil() || !gain.IsPositive() {
+				continue
+			}
+			if best == nil || gain.GT(best.gain()) {
+				best = candidate
+			}
+		}
+		if best == nil {
+			return nil, fmt.Errorf("pools can not absorb %s of %s", amountIn.Sub(allocated), amountIn)
+		}
+
+		if !size.Equal(chunk) {
+			out, err := r.quotePool(ctx, solClient, best.pool, tokenIn, best.amountIn.Add(size))
+			if err != nil {
+				return nil, fmt.Errorf("failed to quote pool %s: %w", best.pool.GetID(), err)
+			}
+			best.nextOut = out
+			best.nextAdjustment = feeAdjustment(best.pool, tokenIn, best.amountIn.Add(size), out)
+		}
+		best.amountIn = best.amountIn.Add(size)
+		best.amountOut = best.nextOut
+		best.adjustment = best.nextAdjustment
+		allocated = allocated.Add(size)
+
+		if step < steps-1 {
+			best.nextOut = math.Int{}
+			out, err := r.quotePool(ctx, solClient, best.pool, tokenIn, best.amountIn.Add(chunk))
+			if err == nil && out.GT(best.amountOut) {
+				best.nextOut = out
+				best.nextAdjustment = feeAdjustment(best.pool, tokenIn, best.amountIn.Add(chunk), out)
+			}
+		}
+	}
+
+	split := &Split{InputMint: tokenIn, AmountIn: amountIn, AmountOut: math.ZeroInt()}
+	for _, candidate := range candidates {
+		if !candidate.amountIn.IsPositive() {
+			continue
+		}
+		split.Allocations = append(split.Allocations, Allocation{
+			Pool:      candidate.pool,
+			AmountIn:  candidate.amountIn,
+			AmountOut: candidate.amountOut,
+		})
+		split.AmountOut = split.AmountOut.Add(candidate.amountOut)
+	}
+	return split, nil
+}
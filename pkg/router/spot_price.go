@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ErrNoSpotPrice is returned for pools that do not report a spot price
+var ErrNoSpotPrice = errors.New("pool does not report a spot price")
+
+// SpotPrice returns the marginal price of pool in whole output tokens per
+// whole inputMint token, before fees, for display. It reads the state cached
+// by the pool's last Quote, so quote the pool first, and resolves the mints'
+// decimals from the chain with the pool's own record as fallback
+func SpotPrice(ctx context.Context, solClient *sol.Client, pool pkg.Pool, inputMint string) (math.LegacyDec, error) {
+	outputMint, err := otherMint(pool, inputMint)
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	inputDecimals, ok := mintDecimals(ctx, solClient, []pkg.Pool{pool}, inputMint)
+	if !ok {
+		return math.LegacyDec{}, fmt.Errorf("decimals of %s are unknown", inputMint)
+	}
+	outputDecimals, ok := mintDecimals(ctx, solClient, []pkg.Pool{pool}, outputMint)
+	if !ok {
+		return math.LegacyDec{}, fmt.Errorf("decimals of %s are unknown", outputMint)
+	}
+	return spotPrice(pool, inputMint, inputDecimals, outputDecimals)
+}
+
+// spotPrice scales the raw spot price of pool by the mints' decimals
+func spotPrice(pool pkg.Pool, inputMint string, inputDecimals, outputDecimals uint8) (math.LegacyDec, error) {
+	pricePool, ok := pool.(pkg.SpotPricePool)
+	if !ok {
+		return math.LegacyDec{}, ErrNoSpotPrice
+	}
+	raw, err := pricePool.RawSpotPrice(inputMint)
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	return pkg.ScalePrice(raw, inputDecimals, outputDecimals), nil
+}
+
+// mintDecimals resolves the decimals of mint from the chain, falling back to
+// the first of pools that records them
+func mintDecimals(ctx context.Context, solClient *sol.Client, pools []pkg.Pool, mint string) (uint8, bool) {
+	if solClient != nil {
+		if key, err := solana.PublicKeyFromBase58(mint); err == nil {
+			if d, err := solClient.GetMintDecimals(ctx, key); err == nil {
+				return d, true
+			}
+		}
+	}
+	for _, pool := range pools {
+		if decimalsPool, ok := pool.(pkg.DecimalsPool); ok {
+			if d, ok := decimalsPool.MintDecimals(mint); ok {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
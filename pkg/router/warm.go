@@ -0,0 +1,365 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DefaultWarmMaxAge bounds how long prebuilt instructions are trusted. Tick
+// and bin arrays picked by CLMM and DLMM builders drift as the price moves
+const DefaultWarmMaxAge = 30 * time.Second
+
+// ErrNotWarm is returned when no usable prebuilt route matches a request;
+// callers fall back to building the route
+var ErrNotWarm = errors.New("route is not warm")
+
+// systemTransferPrefix is the instruction tag of a system transfer, followed
+// by the lamports as a u64
+var systemTransferPrefix = []byte{2, 0, 0, 0}
+
+// warmField is the position of a u64 in the prebuilt instructions
+type warmField struct {
+	instruction int
+	offset      int
+}
+
+// warmHop is where a hop's amounts sit in the prebuilt instructions
+type warmHop struct {
+	poolID      string
+	inputMint   string
+	maxAmountIn math.Int
+	amountIn    warmField
+	minOut      warmField
+	// wrap, when set, is the transfer funding the WSOL this hop spends
+	wrap *warmField
+}
+
+// WarmRoute is a route whose instructions were assembled ahead of time, so
+// executing it only rewrites the amounts
+type WarmRoute struct {
+	User       solana.PublicKey
+	InputMint  string
+	OutputMint string
+	// Tables maps the route's address lookup tables to their addresses
+	Tables  map[solana.PublicKey]solana.PublicKeySlice
+	BuiltAt time.Time
+
+	route        *Route
+	instructions []*solana.GenericInstruction
+	hops         []warmHop
+	// transfer, when set, is the delivery of the last hop's minimum output
+	transfer *warmField
+}
+
+// WarmRoutes keeps the prebuilt routes of hot pairs, one per user and pair
+type WarmRoutes struct {
+	// MaxAge is how long a prebuilt route is used before it must be refreshed
+	MaxAge time.Duration
+
+	mu     sync.RWMutex
+	routes map[string]*WarmRoute
+}
+
+// NewWarmRoutes creates an empty registry whose routes expire after maxAge
+func NewWarmRoutes(maxAge time.Duration) *WarmRoutes {
+	if maxAge <= 0 {
+		maxAge = DefaultWarmMaxAge
+	}
+	return &WarmRoutes{
+		MaxAge: maxAge,
+		routes: make(map[string]*WarmRoute),
+	}
+}
+
+func warmKey(user solana.PublicKey, inputMint, outputMint string) string {
+	return user.String() + ":" + inputMint + ":" + outputMint
+}
+
+// Register prebuilds route for user and keeps it as the hot route of its
+// pair. Each hop's AmountIn is the largest input the prebuilt instructions
+// will be reused for, so register with the largest trade size expected
+func (w *WarmRoutes) Register(ctx context.Context, solClient *sol.Client, user solana.PublicKey, route *Route) (*WarmRoute, error) {
+	warm, err := buildWarmRoute(ctx, solClient, user, route)
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.routes[warmKey(user, warm.InputMint, warm.OutputMint)] = warm
+	return warm, nil
+}
+
+// Unregister drops the hot route of a pair
+func (w *WarmRoutes) Unregister(user solana.PublicKey, inputMint, outputMint string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.routes, warmKey(user, inputMint, outputMint))
+}
+
+// Lookup returns the hot route of a pair if it is registered and fresh
+func (w *WarmRoutes) Lookup(user solana.PublicKey, inputMint, outputMint string) (*WarmRoute, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	warm, ok := w.routes[warmKey(user, inputMint, outputMint)]
+	if !ok || time.Since(warm.BuiltAt) > w.MaxAge {
+		return nil, false
+	}
+	return warm, true
+}
+
+// Instructions returns route's instructions for user from its prebuilt hot
+// route, patched with the route's amounts. It returns ErrNotWarm when the pair
+// is not warm, the route takes other pools or an amount exceeds the prebuilt one
+func (w *WarmRoutes) Instructions(user solana.PublicKey, route *Route) ([]solana.Instruction, error) {
+	warm, ok := w.Lookup(user, route.InputMint(), route.OutputMint())
+	if !ok {
+		return nil, ErrNotWarm
+	}
+	return warm.Instructions(route)
+}
+
+// Refresh rebuilds every registered route, dropping those that no longer build
+func (w *WarmRoutes) Refresh(ctx context.Context, solClient *sol.Client) error {
+	w.mu.RLock()
+	registered := make([]*WarmRoute, 0, len(w.routes))
+	for _, warm := range w.routes {
+		registered = append(registered, warm)
+	}
+	w.mu.RUnlock()
+
+	var errs []error
+	for _, old := range registered {
+		key := warmKey(old.User, old.InputMint, old.OutputMint)
+		warm, err := buildWarmRoute(ctx, solClient, old.User, old.route)
+		w.mu.Lock()
+		if err != nil {
+			log.Printf("dropping warm route %s: %v", key, err)
+			delete(w.routes, key)
+			errs = append(errs, err)
+		} else if w.routes[key] == old {
+			w.routes[key] = warm
+		}
+		w.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// RegisterHotPair quotes maxAmountIn of inputMint across the router's pools
+// and registers the best single-hop route for user in r.Warm
+func (r *SimpleRouter) RegisterHotPair(ctx context.Context, solClient *sol.Client, user solana.PublicKey, inputMint string, maxAmountIn math.Int) (*WarmRoute, error) {
+	if r.Warm == nil {
+		return nil, fmt.Errorf("warm routes are not enabled")
+	}
+	pool, amountOut, err := r.GetBestPool(ctx, solClient, inputMint, maxAmountIn)
+	if err != nil {
+		return nil, err
+	}
+	route, err := NewSingleHopRoute(pool, inputMint, maxAmountIn, amountOut)
+	if err != nil {
+		return nil, err
+	}
+	// a placeholder threshold, rewritten on every execution
+	route.Hops[0].MinAmountOut = amountOut
+	return r.Warm.Register(ctx, solClient, user, route)
+}
+
+// Instructions patches route's amounts into the prebuilt instructions. The
+// route must take the same pools in the same direction
+func (w *WarmRoute) Instructions(route *Route) ([]solana.Instruction, error) {
+	if len(route.Hops) != len(w.hops) || !route.Delivery.equal(w.route.Delivery) || !route.Recipient.Equals(w.route.Recipient) {
+		return nil, ErrNotWarm
+	}
+
+	patched := make([]*solana.GenericInstruction, len(w.instructions))
+	copy(patched, w.instructions)
+	set := func(field warmField, value math.Int) error {
+		if !value.IsUint64() {
+			return fmt.Errorf("amount %s exceeds uint64", value)
+		}
+		original := w.instructions[field.instruction]
+		if patched[field.instruction] == original {
+			data := make([]byte, len(original.DataBytes))
+			copy(data, original.DataBytes)
+			patched[field.instruction] = solana.NewInstruction(original.ProgID, original.AccountValues, data)
+		}
+		binary.LittleEndian.PutUint64(patched[field.instruction].DataBytes[field.offset:], value.Uint64())
+		return nil
+	}
+
+	for i, hop := range route.Hops {
+		warm := w.hops[i]
+		if hop.Pool.GetID() != warm.poolID || hop.InputMint != warm.inputMint {
+			return nil, ErrNotWarm
+		}
+		if hop.AmountIn.IsNil() || hop.AmountIn.GT(warm.maxAmountIn) {
+			return nil, ErrNotWarm
+		}
+		minOut := hop.MinAmountOut
+		if minOut.IsNil() {
+			minOut = math.ZeroInt()
+		}
+		if minOut.IsZero() && minOutIsExact(hop.Pool, hop.InputMint) {
+			return nil, fmt.Errorf("hop %d: pool %s needs an exact output amount", i, warm.poolID)
+		}
+		if err := set(warm.amountIn, hop.AmountIn); err != nil {
+			return nil, fmt.Errorf("hop %d: %w", i, err)
+		}
+		if err := set(warm.minOut, minOut); err != nil {
+			return nil, fmt.Errorf("hop %d: %w", i, err)
+		}
+		if warm.wrap != nil {
+			if err := set(*warm.wrap, hop.AmountIn); err != nil {
+				return nil, fmt.Errorf("hop %d: %w", i, err)
+			}
+		}
+	}
+
+	if w.transfer != nil {
+		minOut := route.Hops[len(route.Hops)-1].MinAmountOut
+		if minOut.IsNil() || !minOut.IsPositive() {
+			return nil, fmt.Errorf("the last hop needs a MinAmountOut, which is the amount transferred")
+		}
+		if err := set(*w.transfer, minOut); err != nil {
+			return nil, fmt.Errorf("delivery: %w", err)
+		}
+	}
+
+	instructions := make([]solana.Instruction, len(patched))
+	for i, instruction := range patched {
+		instructions[i] = instruction
+	}
+	return instructions, nil
+}
+
+// buildWarmRoute builds route for user and records where each hop's amounts
+// sit in the encoded instructions
+func buildWarmRoute(ctx context.Context, solClient *sol.Client, user solana.PublicKey, route *Route) (*WarmRoute, error) {
+	if len(route.Hops) == 0 {
+		return nil, fmt.Errorf("route has no hops")
+	}
+	segments, err := buildRouteSegments(ctx, solClient, user, route)
+	if err != nil {
+		return nil, err
+	}
+
+	warm := &WarmRoute{
+		User:       user,
+		InputMint:  route.InputMint(),
+		OutputMint: route.OutputMint(),
+		Tables:     make(map[solana.PublicKey]solana.PublicKeySlice),
+		route:      route,
+		hops:       make([]warmHop, len(route.Hops)),
+	}
+	var pendingWrap *warmField
+	for _, segment := range segments {
+		start := len(warm.instructions)
+		for _, instruction := range segment.instructions {
+			data, err := instruction.Data()
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode instruction: %w", err)
+			}
+			warm.instructions = append(warm.instructions,
+				solana.NewInstruction(instruction.ProgramID(), instruction.Accounts(), data))
+		}
+		encoded := warm.instructions[start:]
+
+		if segment.step != nil {
+			switch segment.step.Action {
+			case WSOLWrap:
+				field, err := findField(encoded, solana.SystemProgramID, systemTransferPrefix, 4)
+				if err != nil {
+					return nil, fmt.Errorf("wrap before hop %d: %w", segment.hop, err)
+				}
+				field.instruction += start
+				pendingWrap = &field
+			case WSOLTransfer:
+				field, err := findField(encoded, solana.SystemProgramID, systemTransferPrefix, 4)
+				if err != nil {
+					return nil, fmt.Errorf("delivery: %w", err)
+				}
+				field.instruction += start
+				warm.transfer = &field
+			}
+			continue
+		}
+
+		hop := route.Hops[segment.hop]
+		patchable, ok := hop.Pool.(pkg.PatchablePool)
+		if !ok {
+			return nil, fmt.Errorf("hop %d: pool %s does not support patching amounts", segment.hop, hop.Pool.GetID())
+		}
+		amountIn, minOut := patchable.SwapAmountFields(hop.InputMint)
+		amountInField, err := findField(encoded, amountIn.ProgramID, amountIn.Prefix, amountIn.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("hop %d: %w", segment.hop, err)
+		}
+		minOutField, err := findField(encoded, minOut.ProgramID, minOut.Prefix, minOut.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("hop %d: %w", segment.hop, err)
+		}
+		amountInField.instruction += start
+		minOutField.instruction += start
+		warm.hops[segment.hop] = warmHop{
+			poolID:      hop.Pool.GetID(),
+			inputMint:   hop.InputMint,
+			maxAmountIn: hop.AmountIn,
+			amountIn:    amountInField,
+			minOut:      minOutField,
+			wrap:        pendingWrap,
+		}
+		pendingWrap = nil
+	}
+
+	if err := warmTables(ctx, solClient, route, warm.Tables); err != nil {
+		return nil, err
+	}
+	warm.BuiltAt = time.Now()
+	return warm, nil
+}
+
+// findField locates the u64 at offset of the instruction of programID whose
+// data starts with prefix
+func findField(instructions []*solana.GenericInstruction, programID solana.PublicKey, prefix []byte, offset int) (warmField, error) {
+	for i, instruction := range instructions {
+		if !instruction.ProgID.Equals(programID) || !bytes.HasPrefix(instruction.DataBytes, prefix) {
+			continue
+		}
+		if len(instruction.DataBytes) < offset+8 {
+			return warmField{}, fmt.Errorf("instruction data too short: %d bytes", len(instruction.DataBytes))
+		}
+		return warmField{instruction: i, offset: offset}, nil
+	}
+	return warmField{}, fmt.Errorf("no instruction for program %s to patch", programID)
+}
+
+// warmTables fetches the address lookup tables the route's pools publish
+func warmTables(ctx context.Context, solClient *sol.Client, route *Route, tables map[solana.PublicKey]solana.PublicKeySlice) error {
+	for _, hop := range route.Hops {
+		tablePool, ok := hop.Pool.(pkg.LookupTablePool)
+		if !ok {
+			continue
+		}
+		for _, table := range tablePool.AddressLookupTables() {
+			if _, ok := tables[table]; ok {
+				continue
+			}
+			addresses, err := solClient.GetAddressLookupTable(ctx, table)
+			if err != nil {
+				return err
+			}
+			tables[table] = addresses
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// WSOLAction is a wrap or unwrap step inserted around a hop, or the transfer
+// delivering a SOL output
+type WSOLAction int
+
+const (
+	WSOLWrap WSOLAction = iota
+	WSOLUnwrap
+	WSOLTransfer
+)
+
+// WSOLStep runs Action before hop BeforeHop; len(route.Hops) means after the last hop
+type WSOLStep struct {
+	BeforeHop int
+	Action    WSOLAction
+	Amount    math.Int
+	// Destination receives a WSOLTransfer
+	Destination solana.PublicKey
+}
+
+// PlanWSOL works out which legs need wrapped SOL and which take native
+// lamports, returning only the wrap/unwrap steps that are actually required
+// followed by the route's delivery. SOL is identified by the WSOL mint on both sides
+func PlanWSOL(route *Route) []WSOLStep {
+	steps := make([]WSOLStep, 0)
+	wsol := sol.WSOL.String()
+
+	// holdsWSOL tracks whether the SOL balance currently sits in the WSOL
+	// account; the route starts from the user's native lamports
+	holdsWSOL := false
+	for i, hop := range route.Hops {
+		if hop.InputMint == wsol {
+			needsWSOL := !usesNativeSOL(hop.Pool, wsol)
+			if needsWSOL && !holdsWSOL {
+				steps = append(steps, WSOLStep{BeforeHop: i, Action: WSOLWrap, Amount: hop.AmountIn})
+			} else if !needsWSOL && holdsWSOL {
+				steps = append(steps, WSOLStep{BeforeHop: i, Action: WSOLUnwrap})
+			}
+		}
+		holdsWSOL = hop.OutputMint == wsol && !usesNativeSOL(hop.Pool, wsol)
+	}
+	if holdsWSOL && !route.Delivery.keepsWSOL() {
+		steps = append(steps, WSOLStep{BeforeHop: len(route.Hops), Action: WSOLUnwrap})
+	}
+	if destination := route.solDestination(); !destination.IsZero() && len(route.Hops) > 0 {
+		steps = append(steps, WSOLStep{
+			BeforeHop:   len(route.Hops),
+			Action:      WSOLTransfer,
+			Amount:      route.Hops[len(route.Hops)-1].MinAmountOut,
+			Destination: destination,
+		})
+	}
+	return steps
+}
+
+// BuildRouteInstructionsWithWSOL builds the route with only the wrap and
+// unwrap instructions its legs need. The final unwrap closes the user's WSOL
+// account unless the route's delivery keeps it, and a delivery destination is
+// sent the last hop's minimum output
+func BuildRouteInstructionsWithWSOL(ctx context.Context, solClient *sol.Client, user solana.PublicKey, route *Route) ([]solana.Instruction, error) {
+	segments, err := buildRouteSegments(ctx, solClient, user, route)
+	if err != nil {
+		return nil, err
+	}
+	instructions := make([]solana.Instruction, 0)
+	for _, segment := range segments {
+		instructions = append(instructions, segment.instructions...)
+	}
+	return instructions, nil
+}
+
+// routeSegment is the instructions of one hop, or of one WSOL step when step
+// is set
+type routeSegment struct {
+	hop          int
+	step         *WSOLStep
+	instructions []solana.Instruction
+}
+
+// buildRouteSegments builds the route hop by hop with its WSOL steps in
+// between, keeping each hop's instructions apart
+func buildRouteSegments(ctx context.Context, solClient *sol.Client, user solana.PublicKey, route *Route) ([]routeSegment, error) {
+	if err := checkDelivery(route); err != nil {
+		return nil, err
+	}
+	steps := PlanWSOL(route)
+
+	segments := make([]routeSegment, 0, len(route.Hops)+len(steps))
+	appendSteps := func(hopIndex int) error {
+		for i, step := range steps {
+			if step.BeforeHop != hopIndex {
+				continue
+			}
+			stepInstructions, err := wsolStepInstructions(user, step)
+			if err != nil {
+				return err
+			}
+			segments = append(segments, routeSegment{hop: hopIndex, step: &steps[i], instructions: stepInstructions})
+		}
+		return nil
+	}
+
+	for i := range route.Hops {
+		if err := appendSteps(i); err != nil {
+			return nil, err
+		}
+		hopRoute := &Route{Hops: route.Hops[i : i+1]}
+		if i == len(route.Hops)-1 {
+			hopRoute.Recipient = route.Recipient
+		}
+		hopInstructions, err := BuildRouteInstructions(ctx, solClient, user, hopRoute)
+		if err != nil {
+			return nil, fmt.Errorf("hop %d: %w", i, err)
+		}
+		segments = append(segments, routeSegment{hop: i, instructions: hopInstructions})
+	}
+	if err := appendSteps(len(route.Hops)); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+func wsolStepInstructions(user solana.PublicKey, step WSOLStep) ([]solana.Instruction, error) {
+	switch step.Action {
+	case WSOLWrap:
+		if !step.Amount.IsUint64() {
+			return nil, fmt.Errorf("wrap amount %s exceeds uint64", step.Amount)
+		}
+		return sol.WrapSolInstructions(user, step.Amount.Uint64())
+	case WSOLUnwrap:
+		instruction, err := sol.UnwrapSolInstruction(user)
+		if err != nil {
+			return nil, err
+		}
+		return []solana.Instruction{instruction}, nil
+	case WSOLTransfer:
+		if !step.Amount.IsUint64() {
+			return nil, fmt.Errorf("transfer amount %s exceeds uint64", step.Amount)
+		}
+		instruction, err := system.NewTransferInstruction(step.Amount.Uint64(), user, step.Destination).ValidateAndBuild()
+		if err != nil {
+			return nil, err
+		}
+		return []solana.Instruction{instruction}, nil
+	}
+	return nil, fmt.Errorf("unknown wsol action %d", step.Action)
+}
+
+func usesNativeSOL(pool pkg.Pool, mint string) bool {
+	native, ok := pool.(pkg.NativeSOLPool)
+	return ok && native.UsesNativeSOL(mint)
+}
@@ -0,0 +1,137 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// SlotDuration is the cluster's target slot time, used to extrapolate
+	// the cached slot between clock reads
+	SlotDuration = 400 * time.Millisecond
+	// ClockCacheTTL is how long a clock reading is extrapolated before the
+	// clock sysvar is read again
+	ClockCacheTTL = 10 * time.Second
+
+	// maxBlockTimeEntries bounds the remembered block times; the cache
+	// starts over once it is reached
+	maxBlockTimeEntries = 4096
+)
+
+// chainClock caches the last clock sysvar reading with the local time it was
+// taken, and the block times already fetched, which never change
+type chainClock struct {
+	mu         sync.Mutex
+	clock      *Clock
+	observed   time.Time
+	blockTimes map[uint64]time.Time
+}
+
+func (t *chainClock) get() (*Clock, time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.clock == nil || time.Since(t.observed) > ClockCacheTTL {
+		return nil, time.Time{}, false
+	}
+	return t.clock, t.observed, true
+}
+
+func (t *chainClock) put(clock *Clock, observed time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clock, t.observed = clock, observed
+}
+
+func (t *chainClock) blockTime(slot uint64) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	blockTime, ok := t.blockTimes[slot]
+	return blockTime, ok
+}
+
+func (t *chainClock) putBlockTime(slot uint64, blockTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.blockTimes == nil || len(t.blockTimes) >= maxBlockTimeEntries {
+		t.blockTimes = make(map[uint64]time.Time)
+	}
+	t.blockTimes[slot] = blockTime
+}
+
+// cachedClock returns the last clock reading and when it was taken, reading
+// the clock sysvar when none is younger than ClockCacheTTL
+func (c *Client) cachedClock(ctx context.Context) (*Clock, time.Time, error) {
+	if clock, observed, ok := c.chainClock.get(); ok {
+		return clock, observed, nil
+	}
+	observed := time.Now()
+	clock, err := c.GetClock(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	c.chainClock.put(clock, observed)
+	return clock, observed, nil
+}
+
+// CurrentSlot estimates the current slot from the cached clock reading,
+// advancing it by the slots elapsed since at SlotDuration each
+func (c *Client) CurrentSlot(ctx context.Context) (uint64, error) {
+	clock, observed, err := c.cachedClock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Slot + uint64(time.Since(observed)/SlotDuration), nil
+}
+
+// ChainTime estimates the cluster's unix time, the one programs compare
+// against such as a CLMM pool's OpenTime, from the cached clock reading
+// advanced by the local time elapsed since
+func (c *Client) ChainTime(ctx context.Context) (time.Time, error) {
+	clock, observed, err := c.cachedClock(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(clock.UnixTimestamp), 0).Add(time.Since(observed)), nil
+}
+
+// SlotAt estimates the slot in which the cluster time reaches t, the
+// current slot for times already past
+func (c *Client) SlotAt(ctx context.Context, t time.Time) (uint64, error) {
+	clock, observed, err := c.cachedClock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(observed)
+	now := time.Unix(int64(clock.UnixTimestamp), 0).Add(elapsed)
+	slot := clock.Slot + uint64(elapsed/SlotDuration)
+	if !t.After(now) {
+		return slot, nil
+	}
+	return slot + uint64(t.Sub(now)/SlotDuration), nil
+}
+
+// GetBlockTime returns the estimated production time of the block in slot,
+// fetching it only the first time it is asked for
+func (c *Client) GetBlockTime(ctx context.Context, slot uint64) (time.Time, error) {
+	if blockTime, ok := c.chainClock.blockTime(slot); ok {
+		return blockTime, nil
+	}
+	result, err := call(ctx, c, "getBlockTime", func() (int64, error) {
+		out, err := c.rpcClient.GetBlockTime(ctx, slot)
+		if err != nil || out == nil {
+			return 0, err
+		}
+		return int64(*out), nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get block time of slot %d: %w", slot, err)
+	}
+	if result == 0 {
+		return time.Time{}, fmt.Errorf("block time of slot %d is not available", slot)
+	}
+	blockTime := time.Unix(result, 0)
+	c.chainClock.putBlockTime(slot, blockTime)
+	return blockTime, nil
+}
@@ -2,8 +2,10 @@ package sol
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
 // Client represents a Solana client that handles both RPC and WebSocket connections
@@ -11,20 +13,62 @@ type Client struct {
 	rpcClient   *rpc.Client
 	jitoClient  *JitoClient
 	rateLimiter *RateLimiter
+
+	endpoint     string
+	sendEndpoint string
+	jitoEndpoint string
+	health       healthTracker
+	expiry       expiryTracker
+	mints        mintCache
+	readSlots    readSlotTracker
+	chainClock   chainClock
+
+	// sendClient, when set, submits transactions on a dedicated connection
+	sendClient *rpc.Client
+	// txSender, when set, takes over transaction submission entirely
+	txSender TxSender
 }
 
 // NewClient creates a new Solana client with custom rate limiting
 func NewClient(ctx context.Context, endpoint, jitoEndpoint string, reqLimitPerSecond int) (*Client, error) {
 	c := &Client{
-		rpcClient:   rpc.New(endpoint),
-		rateLimiter: NewRateLimiter(reqLimitPerSecond),
+		rpcClient:    rpc.New(endpoint),
+		rateLimiter:  NewRateLimiter(reqLimitPerSecond),
+		endpoint:     endpoint,
+		jitoEndpoint: jitoEndpoint,
 	}
 
 	if jitoEndpoint != "" {
 		jitoClient, err := NewJitoClient(ctx, jitoEndpoint)
+		c.health.recordJito(err)
 		if err == nil {
 			c.jitoClient = jitoClient
 		}
 	}
 	return c, nil
 }
+
+// NewClientWithHTTPClient is NewClient with RPC reads going through httpClient,
+// e.g. one backed by a vcr.Recorder to record or replay traffic
+func NewClientWithHTTPClient(ctx context.Context, endpoint, jitoEndpoint string, reqLimitPerSecond int, httpClient *http.Client) (*Client, error) {
+	c, err := NewClient(ctx, endpoint, jitoEndpoint, reqLimitPerSecond)
+	if err != nil {
+		return nil, err
+	}
+	c.rpcClient = rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+		HTTPClient: httpClient,
+	}))
+	return c, nil
+}
+
+// SetSendEndpoint routes transaction submission to a dedicated endpoint, e.g. a
+// paid or staked connection, while reads keep using the main one. Sends on
+// this lane bypass the shared rate limiter. An empty endpoint restores the default
+func (c *Client) SetSendEndpoint(endpoint string) {
+	c.sendEndpoint = endpoint
+	if endpoint == "" {
+		c.sendClient = nil
+		return
+	}
+	c.sendClient = rpc.New(endpoint)
+}
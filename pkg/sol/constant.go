@@ -6,5 +6,10 @@ var (
 	WSOL      = solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
 	NativeSOL = solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
 
+	Token2022ProgramID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+
 	TokenAccountSize = uint64(165)
 )
+
+// MaxTransactionSize is the largest serialized transaction the network accepts
+const MaxTransactionSize = 1232
@@ -0,0 +1,107 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// maxExpiryEntries is the size at which the tracker drops expired
+	// blockhashes and signatures. Entries that can still land are kept
+	maxExpiryEntries = 4096
+	// blockhashValidity is how many blocks a fetched blockhash stays valid for,
+	// so the newest last valid block height is this far ahead of the chain
+	blockhashValidity = 150
+)
+
+// ErrBlockhashExpired is returned when the chain has passed a transaction's
+// last valid block height without it landing, so it can never land anymore
+var ErrBlockhashExpired = errors.New("blockhash expired before the transaction landed")
+
+// expiryTracker remembers the last valid block height of fetched blockhashes
+// and of the transactions sent with them
+type expiryTracker struct {
+	mu          sync.Mutex
+	blockhashes map[solana.Hash]uint64
+	signatures  map[solana.Signature]uint64
+	// newest is the highest last valid block height recorded so far
+	newest uint64
+}
+
+func (t *expiryTracker) recordBlockhash(blockhash solana.Hash, lastValidBlockHeight uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.blockhashes == nil {
+		t.blockhashes = make(map[solana.Hash]uint64)
+	}
+	t.newest = max(t.newest, lastValidBlockHeight)
+	if len(t.blockhashes) >= maxExpiryEntries {
+		pruneExpired(t.blockhashes, t.expiredBelow())
+	}
+	t.blockhashes[blockhash] = lastValidBlockHeight
+}
+
+func (t *expiryTracker) recordSend(tx *solana.Transaction) {
+	if len(tx.Signatures) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	height, ok := t.blockhashes[tx.Message.RecentBlockhash]
+	if !ok {
+		return
+	}
+	if t.signatures == nil {
+		t.signatures = make(map[solana.Signature]uint64)
+	}
+	if len(t.signatures) >= maxExpiryEntries {
+		pruneExpired(t.signatures, t.expiredBelow())
+	}
+	t.signatures[tx.Signatures[0]] = height
+}
+
+// expiredBelow estimates the chain's block height from the newest blockhash;
+// entries whose last valid block height is below it can no longer land
+func (t *expiryTracker) expiredBelow() uint64 {
+	if t.newest < blockhashValidity {
+		return 0
+	}
+	return t.newest - blockhashValidity
+}
+
+// pruneExpired drops the entries whose last valid block height is below height
+func pruneExpired[K comparable](entries map[K]uint64, height uint64) {
+	for key, lastValid := range entries {
+		if lastValid < height {
+			delete(entries, key)
+		}
+	}
+}
+
+func (t *expiryTracker) signature(sig solana.Signature) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	height, ok := t.signatures[sig]
+	return height, ok
+}
+
+// LastValidBlockHeight returns the block height after which tx can no longer
+// land. It is known when the transaction's blockhash was fetched through this
+// client, as SignTransaction does
+func (c *Client) LastValidBlockHeight(tx *solana.Transaction) (uint64, bool) {
+	c.expiry.mu.Lock()
+	defer c.expiry.mu.Unlock()
+	height, ok := c.expiry.blockhashes[tx.Message.RecentBlockhash]
+	return height, ok
+}
+
+// GetBlockHeight wraps the RPC call with rate limiting
+func (c *Client) GetBlockHeight(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	return call(ctx, c, "getBlockHeight", func() (uint64, error) {
+		return c.rpcClient.GetBlockHeight(ctx, commitment)
+	})
+}
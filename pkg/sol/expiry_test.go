@@ -0,0 +1,34 @@
+package sol
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func testHash(i int) solana.Hash {
+	var hash solana.Hash
+	binary.LittleEndian.PutUint64(hash[:], uint64(i)+1)
+	return hash
+}
+
+func TestExpiryTrackerKeepsLiveEntries(t *testing.T) {
+	var tracker expiryTracker
+	// a stale blockhash that expired long ago, then a full map of live ones
+	stale := testHash(maxExpiryEntries + 1)
+	tracker.recordBlockhash(stale, 100)
+	for i := 0; i < maxExpiryEntries; i++ {
+		tracker.recordBlockhash(testHash(i), 10_000+uint64(i%blockhashValidity))
+	}
+	tracker.recordBlockhash(testHash(maxExpiryEntries), 10_100)
+
+	if _, ok := tracker.blockhashes[stale]; ok {
+		t.Fatal("an expired blockhash was kept past the size bound")
+	}
+	for i := 0; i <= maxExpiryEntries; i++ {
+		if _, ok := tracker.blockhashes[testHash(i)]; !ok {
+			t.Fatalf("live blockhash %d was dropped", i)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sol
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultBlockhashMaxAge is how long a fetched blockhash counts as fresh.
+// Blockhashes expire after about 150 slots, roughly 60 seconds
+const DefaultBlockhashMaxAge = 60 * time.Second
+
+// Health is a point-in-time snapshot of a client's state
+type Health struct {
+	Endpoint     string
+	SendEndpoint string
+
+	// LastSuccess and LastError hold the time of the latest successful and
+	// failed call per RPC method, e.g. "getSlot"
+	LastSuccess map[string]time.Time
+	LastError   map[string]time.Time
+
+	// Rate is the limiter's current requests per second, below MaxRate while
+	// the provider is throttling
+	Rate        int
+	MaxRate     int
+	Utilization float64
+	Throttled   bool
+
+	Blockhash          solana.Hash
+	BlockhashFetchedAt time.Time
+
+	JitoEndpoint    string
+	JitoConnected   bool
+	LastJitoSuccess time.Time
+	LastJitoError   time.Time
+}
+
+// BlockhashFresh reports whether the last fetched blockhash is younger than maxAge
+func (h Health) BlockhashFresh(maxAge time.Duration) bool {
+	return !h.BlockhashFetchedAt.IsZero() && time.Since(h.BlockhashFetchedAt) < maxAge
+}
+
+// Health returns a snapshot of the client's endpoints, per-method call
+// outcomes, limiter state, blockhash freshness and Jito connectivity. It
+// makes no network calls
+func (c *Client) Health() Health {
+	h := Health{
+		Endpoint:     c.endpoint,
+		SendEndpoint: c.sendEndpoint,
+		Rate:         c.rateLimiter.GetRate(),
+		MaxRate:      c.rateLimiter.MaxRate(),
+		Utilization:  c.rateLimiter.Utilization(),
+		Throttled:    c.rateLimiter.Throttled(),
+		JitoEndpoint: c.jitoEndpoint,
+		// The Jito client only exists if fetching a tip account succeeded
+		JitoConnected: c.jitoClient != nil,
+	}
+
+	t := &c.health
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h.LastSuccess = maps.Clone(t.lastSuccess)
+	h.LastError = maps.Clone(t.lastError)
+	h.Blockhash = t.blockhash
+	h.BlockhashFetchedAt = t.blockhashFetchedAt
+	h.LastJitoSuccess = t.lastJitoSuccess
+	h.LastJitoError = t.lastJitoError
+	return h
+}
+
+// healthTracker records call outcomes for Health
+type healthTracker struct {
+	mu                 sync.Mutex
+	lastSuccess        map[string]time.Time
+	lastError          map[string]time.Time
+	blockhash          solana.Hash
+	blockhashFetchedAt time.Time
+	lastJitoSuccess    time.Time
+	lastJitoError      time.Time
+}
+
+func (t *healthTracker) record(method string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lastSuccess == nil {
+		t.lastSuccess = make(map[string]time.Time)
+		t.lastError = make(map[string]time.Time)
+	}
+	if err != nil {
+		t.lastError[method] = time.Now()
+		return
+	}
+	t.lastSuccess[method] = time.Now()
+}
+
+func (t *healthTracker) recordBlockhash(blockhash solana.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.blockhash = blockhash
+	t.blockhashFetchedAt = time.Now()
+}
+
+func (t *healthTracker) recordJito(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.lastJitoError = time.Now()
+		return
+	}
+	t.lastJitoSuccess = time.Now()
+}
@@ -3,6 +3,7 @@ package sol
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -12,6 +13,12 @@ import (
 	jitorpc "github.com/jito-labs/jito-go-rpc"
 )
 
+// ErrJitoUnavailable is returned when sending a bundle without a connected Jito client
+var ErrJitoUnavailable = errors.New("jito client is not connected")
+
+// MaxBundleTransactions is the most transactions a Jito bundle holds, the tip included
+const MaxBundleTransactions = 5
+
 type JitoClient struct {
 	rpcClient  *jitorpc.JitoJsonRpcClient
 	tipAccount solana.PublicKey
@@ -31,7 +38,7 @@ func NewJitoClient(ctx context.Context, endpoint string) (*JitoClient, error) {
 	}, nil
 }
 
-func createTipTransaction(privateKey solana.PrivateKey, amount uint64, recentBlockhash solana.Hash, tipAddress string) (*solana.Transaction, error) {
+func createTipTransaction(signer Signer, amount uint64, recentBlockhash solana.Hash, tipAddress string) (*solana.Transaction, error) {
 	tipAccount, err := solana.PublicKeyFromBase58(tipAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tip account: %v", err)
@@ -41,24 +48,18 @@ func createTipTransaction(privateKey solana.PrivateKey, amount uint64, recentBlo
 		[]solana.Instruction{
 			system.NewTransferInstruction(
 				amount,
-				privateKey.PublicKey(),
+				signer.PublicKey(),
 				tipAccount,
 			).Build(),
 		},
 		recentBlockhash,
-		solana.TransactionPayer(privateKey.PublicKey()),
+		solana.TransactionPayer(signer.PublicKey()),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tip transaction: %v", err)
 	}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if privateKey.PublicKey().Equals(key) {
-			return &privateKey
-		}
-		return nil
-	})
-	if err != nil {
+	if err := signWith(tx, []Signer{signer}, false); err != nil {
 		return nil, fmt.Errorf("failed to sign tip transaction: %v", err)
 	}
 
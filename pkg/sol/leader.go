@@ -0,0 +1,123 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// slotsPerLeader is the number of consecutive slots each leader produces
+const slotsPerLeader = 4
+
+// LeaderInfo describes an upcoming slot leader and where to reach its TPU
+type LeaderInfo struct {
+	Slot     uint64
+	Identity solana.PublicKey
+	TPU      string
+	TPUQUIC  string
+}
+
+// LeaderTracker keeps the epoch leader schedule and the cluster's TPU
+// addresses so transactions can be sent toward the current leader
+type LeaderTracker struct {
+	client *Client
+
+	mu         sync.RWMutex
+	epoch      uint64
+	epochStart uint64
+	epochEnd   uint64
+	leaders    map[uint64]solana.PublicKey
+	nodes      map[solana.PublicKey]*rpc.GetClusterNodesResult
+}
+
+// NewLeaderTracker creates a tracker; call Refresh before using it
+func NewLeaderTracker(client *Client) *LeaderTracker {
+	return &LeaderTracker{
+		client:  client,
+		leaders: make(map[uint64]solana.PublicKey),
+		nodes:   make(map[solana.PublicKey]*rpc.GetClusterNodesResult),
+	}
+}
+
+// Refresh reloads the leader schedule and cluster nodes for the current epoch
+func (t *LeaderTracker) Refresh(ctx context.Context) error {
+	epochInfo, err := t.client.GetEpochInfo(ctx, rpc.CommitmentProcessed)
+	if err != nil {
+		return fmt.Errorf("failed to get epoch info: %w", err)
+	}
+	schedule, err := t.client.GetLeaderSchedule(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get leader schedule: %w", err)
+	}
+	clusterNodes, err := t.client.GetClusterNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster nodes: %w", err)
+	}
+
+	epochStart := epochInfo.AbsoluteSlot - epochInfo.SlotIndex
+	leaders := make(map[uint64]solana.PublicKey)
+	for identity, slots := range schedule {
+		for _, slotIndex := range slots {
+			leaders[epochStart+slotIndex] = identity
+		}
+	}
+	nodes := make(map[solana.PublicKey]*rpc.GetClusterNodesResult, len(clusterNodes))
+	for _, node := range clusterNodes {
+		nodes[node.Pubkey] = node
+	}
+
+	t.mu.Lock()
+	t.epoch = epochInfo.Epoch
+	t.epochStart = epochStart
+	t.epochEnd = epochStart + epochInfo.SlotsInEpoch
+	t.leaders = leaders
+	t.nodes = nodes
+	t.mu.Unlock()
+	return nil
+}
+
+// UpcomingLeaders returns the next count distinct leaders starting at the
+// current slot, refreshing the schedule when the epoch has rolled over
+func (t *LeaderTracker) UpcomingLeaders(ctx context.Context, count int) ([]LeaderInfo, error) {
+	slot, err := t.client.GetSlot(ctx, rpc.CommitmentProcessed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slot: %w", err)
+	}
+
+	t.mu.RLock()
+	stale := slot >= t.epochEnd
+	t.mu.RUnlock()
+	if stale {
+		if err := t.Refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]LeaderInfo, 0, count)
+	for s := slot; s < t.epochEnd && len(result) < count; s += slotsPerLeader {
+		identity, ok := t.leaders[s]
+		if !ok {
+			continue
+		}
+		if len(result) > 0 && result[len(result)-1].Identity.Equals(identity) {
+			continue
+		}
+		info := LeaderInfo{Slot: s, Identity: identity}
+		if node, ok := t.nodes[identity]; ok {
+			if node.TPU != nil {
+				info.TPU = *node.TPU
+			}
+			if node.TPUQUIC != nil {
+				info.TPUQUIC = *node.TPUQUIC
+			}
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
@@ -0,0 +1,35 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+// GetAddressLookupTable fetches the addresses stored in an address lookup table
+func (c *Client) GetAddressLookupTable(ctx context.Context, table solana.PublicKey) (solana.PublicKeySlice, error) {
+	state, err := c.GetAddressLookupTableState(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	return state.Addresses, nil
+}
+
+// GetAddressLookupTableState fetches an address lookup table with its
+// authority, extension and deactivation slots
+func (c *Client) GetAddressLookupTableState(ctx context.Context, table solana.PublicKey) (*addresslookuptable.AddressLookupTableState, error) {
+	account, err := c.GetAccountInfoWithOpts(ctx, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lookup table %s: %w", table, err)
+	}
+	if account == nil || account.Value == nil {
+		return nil, fmt.Errorf("lookup table %s not found", table)
+	}
+	state, err := addresslookuptable.DecodeAddressLookupTableState(account.Value.Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode lookup table %s: %w", table, err)
+	}
+	return state, nil
+}
@@ -0,0 +1,57 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// mintDecimalsOffset is where the decimals byte sits in an SPL mint account,
+// the same for Token-2022 mints
+const mintDecimalsOffset = 44
+
+// mintCache remembers mint decimals, which never change once a mint exists
+type mintCache struct {
+	mu       sync.RWMutex
+	decimals map[solana.PublicKey]uint8
+}
+
+func (m *mintCache) get(mint solana.PublicKey) (uint8, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	decimals, ok := m.decimals[mint]
+	return decimals, ok
+}
+
+func (m *mintCache) put(mint solana.PublicKey, decimals uint8) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.decimals == nil {
+		m.decimals = make(map[solana.PublicKey]uint8)
+	}
+	m.decimals[mint] = decimals
+}
+
+// GetMintDecimals returns the decimals of mint, fetching the mint account only
+// the first time it is asked for
+func (c *Client) GetMintDecimals(ctx context.Context, mint solana.PublicKey) (uint8, error) {
+	if decimals, ok := c.mints.get(mint); ok {
+		return decimals, nil
+	}
+	account, err := c.GetAccountInfoWithOpts(ctx, mint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get mint %s: %w", mint, err)
+	}
+	if account == nil || account.Value == nil {
+		return 0, fmt.Errorf("mint %s not found", mint)
+	}
+	data := account.Value.Data.GetBinary()
+	if len(data) <= mintDecimalsOffset {
+		return 0, fmt.Errorf("account %s is not a mint: %d bytes", mint, len(data))
+	}
+	decimals := data[mintDecimalsOffset]
+	c.mints.put(mint, decimals)
+	return decimals, nil
+}
@@ -0,0 +1,79 @@
+package sol
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// maxPDACacheEntries bounds the PDA cache; it is reset once full
+const maxPDACacheEntries = 100_000
+
+// pdaCache memoizes program address derivations, which are pure functions of
+// their seeds but cost up to 255 hash attempts each
+var pdaCache = struct {
+	sync.RWMutex
+	entries map[string]pdaEntry
+}{entries: make(map[string]pdaEntry)}
+
+type pdaEntry struct {
+	address solana.PublicKey
+	bump    uint8
+}
+
+// FindProgramAddress is solana.FindProgramAddress with derive-once caching
+func FindProgramAddress(seeds [][]byte, programID solana.PublicKey) (solana.PublicKey, uint8, error) {
+	key := pdaCacheKey(seeds, programID)
+
+	pdaCache.RLock()
+	entry, ok := pdaCache.entries[key]
+	pdaCache.RUnlock()
+	if ok {
+		return entry.address, entry.bump, nil
+	}
+
+	address, bump, err := solana.FindProgramAddress(seeds, programID)
+	if err != nil {
+		return solana.PublicKey{}, 0, err
+	}
+
+	pdaCache.Lock()
+	if len(pdaCache.entries) >= maxPDACacheEntries {
+		pdaCache.entries = make(map[string]pdaEntry)
+	}
+	pdaCache.entries[key] = pdaEntry{address: address, bump: bump}
+	pdaCache.Unlock()
+	return address, bump, nil
+}
+
+// FindAssociatedTokenAddress is solana.FindAssociatedTokenAddress with derive-once caching
+func FindAssociatedTokenAddress(wallet solana.PublicKey, mint solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return FindProgramAddress([][]byte{
+		wallet[:],
+		solana.TokenProgramID[:],
+		mint[:],
+	}, solana.SPLAssociatedTokenAccountProgramID)
+}
+
+// FindAssociatedTokenAddressWithProgram derives the associated token account
+// of a mint owned by tokenProgram, e.g. a Token-2022 mint
+func FindAssociatedTokenAddressWithProgram(wallet, mint, tokenProgram solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return FindProgramAddress([][]byte{
+		wallet[:],
+		tokenProgram[:],
+		mint[:],
+	}, solana.SPLAssociatedTokenAccountProgramID)
+}
+
+// pdaCacheKey joins the program ID and length-prefixed seeds into a map key
+func pdaCacheKey(seeds [][]byte, programID solana.PublicKey) string {
+	var b strings.Builder
+	b.Grow(solana.PublicKeyLength + len(seeds)*(solana.PublicKeyLength+1))
+	b.Write(programID[:])
+	for _, seed := range seeds {
+		b.WriteByte(byte(len(seed)))
+		b.Write(seed)
+	}
+	return b.String()
+}
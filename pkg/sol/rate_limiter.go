@@ -2,21 +2,79 @@ package sol
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 )
 
-// RateLimiter provides rate limiting functionality for RPC calls
+const (
+	// rampInterval is how often a throttled limiter raises its rate again
+	rampInterval = time.Second
+	// throttleCooldown is how long after lowering its rate a limiter ignores
+	// further throttling, so a burst of requests rejected together lowers it
+	// once instead of once per request
+	throttleCooldown = time.Second
+)
+
+// RateLimiter provides rate limiting functionality for RPC calls. When the
+// provider throttles, Throttle halves the rate, at most once per cooldown,
+// and successful calls ramp it back up to the configured rate
 type RateLimiter struct {
 	limiter *rate.Limiter
+
+	mu           sync.Mutex
+	maxRate      int
+	lastAdjust   time.Time
+	lastThrottle time.Time
 }
 
 // NewRateLimiter creates a new rate limiter with the specified requests per second
 func NewRateLimiter(requestsPerSecond int) *RateLimiter {
 	return &RateLimiter{
 		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), requestsPerSecond),
+		maxRate: requestsPerSecond,
+	}
+}
+
+// Throttle halves the rate, down to one request per second, after the
+// provider rejected a request for exceeding its limit. Rejections within
+// throttleCooldown of the last reduction answer requests sent at the old
+// rate and are ignored
+func (rl *RateLimiter) Throttle() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	if now.Sub(rl.lastThrottle) < throttleCooldown {
+		return
+	}
+	lowered := max(rl.GetRate()/2, 1)
+	rl.limiter.SetLimit(rate.Limit(lowered))
+	rl.limiter.SetBurst(lowered)
+	rl.lastAdjust = now
+	rl.lastThrottle = now
+}
+
+// Recover raises a throttled rate by a tenth of the configured rate, at most
+// once per rampInterval, after a successful request
+func (rl *RateLimiter) Recover() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	current := rl.GetRate()
+	if current >= rl.maxRate || time.Since(rl.lastAdjust) < rampInterval {
+		return
 	}
+	raised := min(current+max(rl.maxRate/10, 1), rl.maxRate)
+	rl.limiter.SetLimit(rate.Limit(raised))
+	rl.limiter.SetBurst(raised)
+	rl.lastAdjust = time.Now()
+}
+
+// Throttled reports whether the rate is below the configured rate
+func (rl *RateLimiter) Throttled() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.GetRate() < rl.maxRate
 }
 
 // Wait blocks until the rate limiter allows the request
@@ -34,8 +92,12 @@ func (rl *RateLimiter) Reserve() *rate.Reservation {
 	return rl.limiter.Reserve()
 }
 
-// SetRate updates the rate limiter's rate
+// SetRate updates the rate limiter's rate, which also becomes the rate a
+// throttled limiter ramps back up to
 func (rl *RateLimiter) SetRate(requestsPerSecond int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.maxRate = requestsPerSecond
 	rl.limiter.SetLimit(rate.Limit(requestsPerSecond))
 	rl.limiter.SetBurst(requestsPerSecond)
 }
@@ -45,6 +107,24 @@ func (rl *RateLimiter) GetRate() int {
 	return int(rl.limiter.Limit())
 }
 
+// MaxRate returns the configured rate a throttled limiter ramps back up to
+func (rl *RateLimiter) MaxRate() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.maxRate
+}
+
+// Utilization returns the fraction of the burst currently spent, from 0 when
+// idle to 1 when callers are waiting for tokens
+func (rl *RateLimiter) Utilization() float64 {
+	burst := rl.limiter.Burst()
+	if burst <= 0 {
+		return 1
+	}
+	used := 1 - rl.limiter.Tokens()/float64(burst)
+	return min(max(used, 0), 1)
+}
+
 // GetBurst returns the current burst size
 func (rl *RateLimiter) GetBurst() int {
 	return rl.limiter.Burst()
@@ -0,0 +1,51 @@
+package sol
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottleOncePerCooldown(t *testing.T) {
+	rl := NewRateLimiter(64)
+
+	// a burst of concurrent requests all rejected together
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rl.Throttle()
+		}()
+	}
+	wg.Wait()
+	if rate := rl.GetRate(); rate != 32 {
+		t.Fatalf("rate after a burst of rejections = %d, want 32", rate)
+	}
+
+	// a rejection after the cooldown lowers the rate again
+	rl.mu.Lock()
+	rl.lastThrottle = time.Now().Add(-throttleCooldown)
+	rl.mu.Unlock()
+	rl.Throttle()
+	if rate := rl.GetRate(); rate != 16 {
+		t.Fatalf("rate after the cooldown = %d, want 16", rate)
+	}
+}
+
+func TestRecoverRampsToMaxRate(t *testing.T) {
+	rl := NewRateLimiter(20)
+	rl.Throttle()
+	if !rl.Throttled() {
+		t.Fatal("limiter not throttled")
+	}
+	for i := 0; i < 20 && rl.Throttled(); i++ {
+		rl.mu.Lock()
+		rl.lastAdjust = time.Now().Add(-rampInterval)
+		rl.mu.Unlock()
+		rl.Recover()
+	}
+	if rate := rl.GetRate(); rate != 20 {
+		t.Fatalf("rate after recovering = %d, want 20", rate)
+	}
+}
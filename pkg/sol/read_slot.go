@@ -0,0 +1,41 @@
+package sol
+
+import (
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// readSlotTracker remembers the slot of the last response that returned
+// each account, so quotes can tell which state of the chain they priced
+type readSlotTracker struct {
+	mu    sync.RWMutex
+	slots map[solana.PublicKey]uint64
+}
+
+func (t *readSlotTracker) record(slot uint64, accounts ...solana.PublicKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.slots == nil {
+		t.slots = make(map[solana.PublicKey]uint64)
+	}
+	for _, account := range accounts {
+		// responses may land out of order; keep the newest read
+		if slot >= t.slots[account] {
+			t.slots[account] = slot
+		}
+	}
+}
+
+func (t *readSlotTracker) get(account solana.PublicKey) (uint64, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	slot, ok := t.slots[account]
+	return slot, ok
+}
+
+// ReadSlot returns the context slot of the newest getAccountInfo or
+// getMultipleAccounts response that included account
+func (c *Client) ReadSlot(account solana.PublicKey) (uint64, bool) {
+	return c.readSlots.get(account)
+}
@@ -7,82 +7,162 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-// RPC wrapper methods with rate limiting
+// RPC wrapper methods with rate limiting and backoff on provider throttling
 
-// GetAccountInfoWithOpts wraps the RPC call with rate limiting
+// GetAccountInfoWithOpts wraps the RPC call with rate limiting, recording
+// the slot the account was read at for ReadSlot
 func (c *Client) GetAccountInfoWithOpts(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
 	opts := &rpc.GetAccountInfoOpts{
 		Commitment: rpc.CommitmentProcessed,
 	}
-	return c.rpcClient.GetAccountInfoWithOpts(ctx, account, opts)
+	result, err := call(ctx, c, "getAccountInfo", func() (*rpc.GetAccountInfoResult, error) {
+		return c.rpcClient.GetAccountInfoWithOpts(ctx, account, opts)
+	})
+	if err == nil && result != nil {
+		c.readSlots.record(result.Context.Slot, account)
+	}
+	return result, err
 }
 
-// GetMultipleAccountsWithOpts wraps the RPC call with rate limiting
+// GetMultipleAccountsWithOpts wraps the RPC call with rate limiting,
+// recording the slot the accounts were read at for ReadSlot
 func (c *Client) GetMultipleAccountsWithOpts(ctx context.Context, accounts []solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
 	opts := &rpc.GetMultipleAccountsOpts{
 		Commitment: rpc.CommitmentProcessed,
 	}
-	return c.rpcClient.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+	result, err := call(ctx, c, "getMultipleAccounts", func() (*rpc.GetMultipleAccountsResult, error) {
+		return c.rpcClient.GetMultipleAccountsWithOpts(ctx, accounts, opts)
+	})
+	if err == nil && result != nil {
+		c.readSlots.record(result.Context.Slot, accounts...)
+	}
+	return result, err
 }
 
 // GetProgramAccountsWithOpts wraps the RPC call with rate limiting
 func (c *Client) GetProgramAccountsWithOpts(ctx context.Context, programID solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
-	return c.rpcClient.GetProgramAccountsWithOpts(ctx, programID, opts)
+	return call(ctx, c, "getProgramAccounts", func() (rpc.GetProgramAccountsResult, error) {
+		return c.rpcClient.GetProgramAccountsWithOpts(ctx, programID, opts)
+	})
 }
 
 // GetTokenAccountsByOwner wraps the RPC call with rate limiting
 func (c *Client) GetTokenAccountsByOwner(ctx context.Context, owner solana.PublicKey, config *rpc.GetTokenAccountsConfig, opts *rpc.GetTokenAccountsOpts) (*rpc.GetTokenAccountsResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
-	return c.rpcClient.GetTokenAccountsByOwner(ctx, owner, config, opts)
+	return call(ctx, c, "getTokenAccountsByOwner", func() (*rpc.GetTokenAccountsResult, error) {
+		return c.rpcClient.GetTokenAccountsByOwner(ctx, owner, config, opts)
+	})
 }
 
 // GetTokenAccountBalance wraps the RPC call with rate limiting
 func (c *Client) GetTokenAccountBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetTokenAccountBalanceResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
-	return c.rpcClient.GetTokenAccountBalance(ctx, account, commitment)
+	return call(ctx, c, "getTokenAccountBalance", func() (*rpc.GetTokenAccountBalanceResult, error) {
+		return c.rpcClient.GetTokenAccountBalance(ctx, account, commitment)
+	})
 }
 
 // GetBalance wraps the RPC call with rate limiting
 func (c *Client) GetBalance(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) (*rpc.GetBalanceResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
-	return c.rpcClient.GetBalance(ctx, account, commitment)
+	return call(ctx, c, "getBalance", func() (*rpc.GetBalanceResult, error) {
+		return c.rpcClient.GetBalance(ctx, account, commitment)
+	})
+}
+
+// GetMinimumBalanceForRentExemption wraps the RPC call with rate limiting
+func (c *Client) GetMinimumBalanceForRentExemption(ctx context.Context, dataSize uint64, commitment rpc.CommitmentType) (uint64, error) {
+	return call(ctx, c, "getMinimumBalanceForRentExemption", func() (uint64, error) {
+		return c.rpcClient.GetMinimumBalanceForRentExemption(ctx, dataSize, commitment)
+	})
 }
 
 // GetLatestBlockhash wraps the RPC call with rate limiting
 func (c *Client) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
+	result, err := call(ctx, c, "getLatestBlockhash", func() (*rpc.GetLatestBlockhashResult, error) {
+		return c.rpcClient.GetLatestBlockhash(ctx, commitment)
+	})
+	if err == nil && result.Value != nil {
+		c.health.recordBlockhash(result.Value.Blockhash)
+		c.expiry.recordBlockhash(result.Value.Blockhash, result.Value.LastValidBlockHeight)
 	}
-	return c.rpcClient.GetLatestBlockhash(ctx, commitment)
+	return result, err
 }
 
 // SimulateTransaction wraps the RPC call with rate limiting
 func (c *Client) SimulateTransaction(ctx context.Context, tx *solana.Transaction) (*rpc.SimulateTransactionResponse, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, err
-	}
-	return c.rpcClient.SimulateTransaction(ctx, tx)
+	return call(ctx, c, "simulateTransaction", func() (*rpc.SimulateTransactionResponse, error) {
+		return c.rpcClient.SimulateTransaction(ctx, tx)
+	})
 }
 
-// SendTransactionWithOpts wraps the RPC call with rate limiting
+// SendTransactionWithOpts wraps the RPC call with rate limiting. A configured
+// TxSender or dedicated send connection takes precedence and skips the limiter.
+// The expiry of tx's blockhash is recorded for AwaitConfirmation
 func (c *Client) SendTransactionWithOpts(ctx context.Context, tx *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error) {
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return solana.Signature{}, err
+	c.expiry.recordSend(tx)
+	if c.txSender != nil || c.sendClient != nil {
+		var sig solana.Signature
+		var err error
+		if c.txSender != nil {
+			sig, err = c.txSender.SendTransaction(ctx, tx)
+		} else {
+			sig, err = c.sendClient.SendTransactionWithOpts(ctx, tx, opts)
+		}
+		c.health.record("sendTransaction", err)
+		return sig, err
 	}
-	return c.rpcClient.SendTransactionWithOpts(ctx, tx, opts)
+	return call(ctx, c, "sendTransaction", func() (solana.Signature, error) {
+		return c.rpcClient.SendTransactionWithOpts(ctx, tx, opts)
+	})
+}
+
+// GetSignatureStatuses wraps the RPC call with rate limiting
+func (c *Client) GetSignatureStatuses(ctx context.Context, searchTransactionHistory bool, signatures ...solana.Signature) (*rpc.GetSignatureStatusesResult, error) {
+	return call(ctx, c, "getSignatureStatuses", func() (*rpc.GetSignatureStatusesResult, error) {
+		return c.rpcClient.GetSignatureStatuses(ctx, searchTransactionHistory, signatures...)
+	})
+}
+
+// GetSlot wraps the RPC call with rate limiting
+func (c *Client) GetSlot(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	return call(ctx, c, "getSlot", func() (uint64, error) {
+		return c.rpcClient.GetSlot(ctx, commitment)
+	})
+}
+
+// GetEpochInfo wraps the RPC call with rate limiting
+func (c *Client) GetEpochInfo(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetEpochInfoResult, error) {
+	return call(ctx, c, "getEpochInfo", func() (*rpc.GetEpochInfoResult, error) {
+		return c.rpcClient.GetEpochInfo(ctx, commitment)
+	})
+}
+
+// GetLeaderSchedule wraps the RPC call with rate limiting
+func (c *Client) GetLeaderSchedule(ctx context.Context) (rpc.GetLeaderScheduleResult, error) {
+	return call(ctx, c, "getLeaderSchedule", func() (rpc.GetLeaderScheduleResult, error) {
+		return c.rpcClient.GetLeaderSchedule(ctx)
+	})
+}
+
+// GetClusterNodes wraps the RPC call with rate limiting
+func (c *Client) GetClusterNodes(ctx context.Context) ([]*rpc.GetClusterNodesResult, error) {
+	return call(ctx, c, "getClusterNodes", func() ([]*rpc.GetClusterNodesResult, error) {
+		return c.rpcClient.GetClusterNodes(ctx)
+	})
+}
+
+// SimulateTransactionWithOpts wraps the RPC call with rate limiting
+func (c *Client) SimulateTransactionWithOpts(ctx context.Context, tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error) {
+	return call(ctx, c, "simulateTransaction", func() (*rpc.SimulateTransactionResponse, error) {
+		return c.rpcClient.SimulateTransactionWithOpts(ctx, tx, opts)
+	})
+}
+
+// GetTransaction wraps the RPC call with rate limiting, fetching confirmed transactions
+func (c *Client) GetTransaction(ctx context.Context, sig solana.Signature) (*rpc.GetTransactionResult, error) {
+	maxVersion := uint64(0)
+	return call(ctx, c, "getTransaction", func() (*rpc.GetTransactionResult, error) {
+		return c.rpcClient.GetTransaction(ctx, sig, &rpc.GetTransactionOpts{
+			Commitment:                     rpc.CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &maxVersion,
+		})
+	})
 }
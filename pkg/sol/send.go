@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
+// SendTx sends tx without preflight. When tx was signed with SignTransaction its
+// last valid block height is remembered, so AwaitConfirmation can tell an
+// expired transaction from a slow one
 func (c *Client) SendTx(ctx context.Context, tx *solana.Transaction) (solana.Signature, error) {
 	// Send transaction with optimized options
 	sig, err := c.SendTransactionWithOpts(
@@ -25,34 +27,69 @@ func (c *Client) SendTx(ctx context.Context, tx *solana.Transaction) (solana.Sig
 	return sig, nil
 }
 
-func (c *Client) SendTxWithJito(ctx context.Context, jitoTipAmount uint64, signers []solana.PrivateKey, mainTx *solana.Transaction) (string, error) {
+// SendTxWithJito sends mainTx as a Jito bundle and waits for the bundle to settle
+func (c *Client) SendTxWithJito(ctx context.Context, jitoTipAmount uint64, signers []Signer, mainTx *solana.Transaction) (string, error) {
+	bundleId, err := c.SendBundle(ctx, jitoTipAmount, signers, mainTx)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Bundle sent successfully. Bundle ID: %s\n", bundleId)
+	c.jitoClient.CheckBundleStatus(bundleId)
+
+	return bundleId, nil
+}
+
+// SendBundle submits mainTx together with a tip of jitoTipAmount lamports from
+// the first signer as one Jito bundle, returning the bundle ID without waiting
+func (c *Client) SendBundle(ctx context.Context, jitoTipAmount uint64, signers []Signer, mainTx *solana.Transaction) (string, error) {
+	return c.SendBundleTxs(ctx, jitoTipAmount, signers, []*solana.Transaction{mainTx})
+}
+
+// SendBundleTxs submits txs in order followed by a tip of jitoTipAmount
+// lamports from the first signer as one Jito bundle, which lands all of them
+// or none, returning the bundle ID without waiting
+func (c *Client) SendBundleTxs(ctx context.Context, jitoTipAmount uint64, signers []Signer, txs []*solana.Transaction) (string, error) {
+	if c.jitoClient == nil {
+		return "", ErrJitoUnavailable
+	}
+	if len(signers) == 0 {
+		return "", fmt.Errorf("at least one signer is required")
+	}
+	if len(txs) == 0 || len(txs) >= MaxBundleTransactions {
+		return "", fmt.Errorf("bundle takes 1 to %d transactions besides the tip, got %d", MaxBundleTransactions-1, len(txs))
+	}
 
 	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
 	if err != nil {
-		log.Fatalf("Failed to get blockhash: %v", err)
+		return "", fmt.Errorf("failed to get blockhash: %w", err)
 	}
 
 	tipTx, err := createTipTransaction(signers[0], jitoTipAmount, res.Value.Blockhash, c.jitoClient.tipAccount.String())
 	if err != nil {
-		log.Fatalf("Failed to create tip transaction: %v", err)
+		return "", err
 	}
 
-	bundleRequest := [][]string{{
-		encodeTransaction(mainTx),
-		encodeTransaction(tipTx),
-	}}
+	encoded := make([]string, 0, len(txs)+1)
+	for _, tx := range txs {
+		encoded = append(encoded, encodeTransaction(tx))
+		c.expiry.recordSend(tx)
+	}
+	bundleRequest := [][]string{append(encoded, encodeTransaction(tipTx))}
 
 	bundleIdRaw, err := c.jitoClient.rpcClient.SendBundle(bundleRequest)
+	c.health.recordJito(err)
 	if err != nil {
-		log.Fatalf("Failed to send bundle: %v", err)
+		return "", fmt.Errorf("failed to send bundle: %w", err)
 	}
 	var bundleId string
 	if err := json.Unmarshal(bundleIdRaw, &bundleId); err != nil {
-		log.Fatalf("Failed to unmarshal bundle ID: %v", err)
+		return "", fmt.Errorf("failed to unmarshal bundle ID: %w", err)
 	}
-
-	fmt.Printf("Bundle sent successfully. Bundle ID: %s\n", bundleId)
-	c.jitoClient.CheckBundleStatus(bundleId)
-
 	return bundleId, nil
 }
+
+// JitoEnabled reports whether the client can send Jito bundles
+func (c *Client) JitoEnabled() bool {
+	return c.jitoClient != nil
+}
@@ -0,0 +1,258 @@
+package sol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	DefaultMaxInflightPerWallet = 4
+	DefaultBlockhashTTL         = 10 * time.Second
+	DefaultConfirmTimeout       = 30 * time.Second
+	confirmPollInterval         = 400 * time.Millisecond
+)
+
+// ErrTransactionFailed is returned when a transaction landed but its
+// execution failed, e.g. a swap program rejected it
+var ErrTransactionFailed = errors.New("transaction failed")
+
+// SendCoordinator serializes parallel sends from the same wallets. It shares one
+// recent blockhash across sends, caps inflight transactions per fee payer and
+// holds a lock on every writable non-signer account until the transaction is
+// confirmed, so two swaps touching the same ATA never race each other
+type SendCoordinator struct {
+	client *Client
+
+	MaxInflightPerWallet int
+	BlockhashTTL         time.Duration
+	ConfirmTimeout       time.Duration
+
+	mu           sync.Mutex
+	blockhash    solana.Hash
+	blockhashAt  time.Time
+	wallets      map[solana.PublicKey]chan struct{}
+	accountLocks map[solana.PublicKey]*sync.Mutex
+}
+
+// NewSendCoordinator creates a coordinator that sends through client
+func NewSendCoordinator(client *Client, maxInflightPerWallet int) *SendCoordinator {
+	if maxInflightPerWallet <= 0 {
+		maxInflightPerWallet = DefaultMaxInflightPerWallet
+	}
+	return &SendCoordinator{
+		client:               client,
+		MaxInflightPerWallet: maxInflightPerWallet,
+		BlockhashTTL:         DefaultBlockhashTTL,
+		ConfirmTimeout:       DefaultConfirmTimeout,
+		wallets:              make(map[solana.PublicKey]chan struct{}),
+		accountLocks:         make(map[solana.PublicKey]*sync.Mutex),
+	}
+}
+
+// Blockhash returns a recent blockhash shared by every send within BlockhashTTL
+func (c *SendCoordinator) Blockhash(ctx context.Context) (solana.Hash, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.blockhash.IsZero() && time.Since(c.blockhashAt) < c.BlockhashTTL {
+		return c.blockhash, nil
+	}
+	res, err := c.client.GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return solana.Hash{}, fmt.Errorf("failed to get blockhash: %w", err)
+	}
+	c.blockhash = res.Value.Blockhash
+	c.blockhashAt = time.Now()
+	return c.blockhash, nil
+}
+
+// Send signs and submits instructions once the wallet has a free inflight slot
+// and every writable account is free, then waits for confirmation before
+// releasing them. The first signer pays the fees
+func (c *SendCoordinator) Send(ctx context.Context, signers []Signer, instrs ...solana.Instruction) (solana.Signature, error) {
+	if len(signers) == 0 {
+		return solana.Signature{}, fmt.Errorf("at least one signer is required")
+	}
+
+	release, err := c.acquireWallet(ctx, signers[0].PublicKey())
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	defer release()
+
+	unlock := c.lockAccounts(writableAccounts(signers, instrs))
+	defer unlock()
+
+	blockhash, err := c.Blockhash(ctx)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	tx, err := SignTransactionWithBlockhash(blockhash, signers, instrs...)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	sig, err := c.client.SendTx(ctx, tx)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	if err := c.awaitConfirmation(ctx, sig); err != nil {
+		return sig, err
+	}
+	return sig, nil
+}
+
+// acquireWallet takes one of the wallet's inflight slots
+func (c *SendCoordinator) acquireWallet(ctx context.Context, wallet solana.PublicKey) (func(), error) {
+	c.mu.Lock()
+	slots, ok := c.wallets[wallet]
+	if !ok {
+		slots = make(chan struct{}, c.MaxInflightPerWallet)
+		c.wallets[wallet] = slots
+	}
+	c.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lockAccounts locks accounts in a stable order so overlapping sends cannot deadlock
+func (c *SendCoordinator) lockAccounts(accounts []solana.PublicKey) func() {
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+	})
+
+	c.mu.Lock()
+	locks := make([]*sync.Mutex, 0, len(accounts))
+	for _, account := range accounts {
+		lock, ok := c.accountLocks[account]
+		if !ok {
+			lock = &sync.Mutex{}
+			c.accountLocks[account] = lock
+		}
+		locks = append(locks, lock)
+	}
+	c.mu.Unlock()
+
+	for _, lock := range locks {
+		lock.Lock()
+	}
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// awaitConfirmation polls the signature until it is confirmed, fails or times out
+func (c *SendCoordinator) awaitConfirmation(ctx context.Context, sig solana.Signature) error {
+	return c.client.AwaitConfirmation(ctx, sig, c.ConfirmTimeout)
+}
+
+// AwaitConfirmation polls sig until it is confirmed, fails or timeout elapses.
+// When sig was sent through this client with a blockhash the client fetched,
+// polling also stops with ErrBlockhashExpired once that blockhash expires, and
+// a zero timeout waits for expiry alone
+func (c *Client) AwaitConfirmation(ctx context.Context, sig solana.Signature, timeout time.Duration) error {
+	lastValidBlockHeight, known := c.expiry.signature(sig)
+	if !known && timeout <= 0 {
+		timeout = DefaultConfirmTimeout
+	}
+	return c.awaitConfirmation(ctx, sig, timeout, lastValidBlockHeight, known)
+}
+
+// AwaitConfirmationUntil polls sig until it is confirmed, fails or the chain
+// passes lastValidBlockHeight, in which case it returns ErrBlockhashExpired
+func (c *Client) AwaitConfirmationUntil(ctx context.Context, sig solana.Signature, lastValidBlockHeight uint64) error {
+	return c.awaitConfirmation(ctx, sig, 0, lastValidBlockHeight, true)
+}
+
+func (c *Client) awaitConfirmation(ctx context.Context, sig solana.Signature, timeout time.Duration, lastValidBlockHeight uint64, expires bool) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(confirmPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transaction %s not confirmed: %w", sig, ctx.Err())
+		case <-ticker.C:
+		}
+
+		confirmed, err := c.signatureConfirmed(ctx, sig)
+		if confirmed || err != nil {
+			return err
+		}
+		if !expires {
+			continue
+		}
+		height, err := c.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+		if err != nil || height <= lastValidBlockHeight {
+			continue
+		}
+		// it may have landed in the last valid block since the status check
+		if confirmed, err := c.signatureConfirmed(ctx, sig); confirmed || err != nil {
+			return err
+		}
+		return fmt.Errorf("transaction %s: %w (last valid block height %d)", sig, ErrBlockhashExpired, lastValidBlockHeight)
+	}
+}
+
+// signatureConfirmed reports whether sig reached confirmed commitment, or
+// returns an error if the transaction failed. Status lookup errors count as
+// not confirmed yet
+func (c *Client) signatureConfirmed(ctx context.Context, sig solana.Signature) (bool, error) {
+	res, err := c.GetSignatureStatuses(ctx, false, sig)
+	if err != nil || len(res.Value) == 0 || res.Value[0] == nil {
+		return false, nil
+	}
+	status := res.Value[0]
+	if status.Err != nil {
+		return false, fmt.Errorf("%w: %s: %v", ErrTransactionFailed, sig, status.Err)
+	}
+	return status.ConfirmationStatus == rpc.ConfirmationStatusConfirmed ||
+		status.ConfirmationStatus == rpc.ConfirmationStatusFinalized, nil
+}
+
+// writableAccounts lists the writable accounts that are not signers; the
+// payer is writable in every transaction and is throttled by wallet slots instead
+func writableAccounts(signers []Signer, instrs []solana.Instruction) []solana.PublicKey {
+	signerSet := make(map[solana.PublicKey]struct{}, len(signers))
+	for _, signer := range signers {
+		signerSet[signer.PublicKey()] = struct{}{}
+	}
+
+	seen := make(map[solana.PublicKey]struct{})
+	accounts := make([]solana.PublicKey, 0)
+	for _, instr := range instrs {
+		for _, account := range instr.Accounts() {
+			if !account.IsWritable {
+				continue
+			}
+			if _, ok := signerSet[account.PublicKey]; ok {
+				continue
+			}
+			if _, ok := seen[account.PublicKey]; ok {
+				continue
+			}
+			seen[account.PublicKey] = struct{}{}
+			accounts = append(accounts, account.PublicKey)
+		}
+	}
+	return accounts
+}
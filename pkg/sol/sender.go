@@ -0,0 +1,98 @@
+package sol
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// TxSender submits signed transactions. It lets callers plug in leader-aware
+// or third party submission paths without changing the rest of the client
+type TxSender interface {
+	SendTransaction(ctx context.Context, tx *solana.Transaction) (solana.Signature, error)
+}
+
+// SetTxSender routes every transaction submission through sender; nil restores the default
+func (c *Client) SetTxSender(sender TxSender) {
+	c.txSender = sender
+}
+
+// SenderEndpoint submits through a low-latency sender service such as the
+// Helius or Triton sender endpoints, which forward straight to leaders over SWQoS
+type SenderEndpoint struct {
+	rpcClient *rpc.Client
+}
+
+// NewSenderEndpoint creates a TxSender for a JSON-RPC compatible sender endpoint
+func NewSenderEndpoint(endpoint string) *SenderEndpoint {
+	return &SenderEndpoint{rpcClient: rpc.New(endpoint)}
+}
+
+// SendTransaction sends without preflight or node-side retries, as sender services expect
+func (s *SenderEndpoint) SendTransaction(ctx context.Context, tx *solana.Transaction) (solana.Signature, error) {
+	maxRetries := uint(0)
+	return s.rpcClient.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+		SkipPreflight: true,
+		MaxRetries:    &maxRetries,
+	})
+}
+
+// ForwardFunc delivers a serialized transaction to one leader's TPU, e.g. over QUIC
+type ForwardFunc func(ctx context.Context, leader LeaderInfo, wireTx []byte) error
+
+// LeaderForwarder sends each transaction to the next few leaders via Forward
+// and, when set, also through Fallback so it still lands if forwarding fails
+type LeaderForwarder struct {
+	Tracker  *LeaderTracker
+	Forward  ForwardFunc
+	Fanout   int
+	Fallback TxSender
+}
+
+// NewLeaderForwarder creates a forwarder that targets the next fanout leaders
+func NewLeaderForwarder(tracker *LeaderTracker, forward ForwardFunc, fanout int) *LeaderForwarder {
+	if fanout <= 0 {
+		fanout = 2
+	}
+	return &LeaderForwarder{
+		Tracker: tracker,
+		Forward: forward,
+		Fanout:  fanout,
+	}
+}
+
+// SendTransaction forwards the transaction to upcoming leaders
+func (f *LeaderForwarder) SendTransaction(ctx context.Context, tx *solana.Transaction) (solana.Signature, error) {
+	if len(tx.Signatures) == 0 {
+		return solana.Signature{}, fmt.Errorf("transaction is not signed")
+	}
+	wireTx, err := tx.MarshalBinary()
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+
+	forwarded := 0
+	leaders, err := f.Tracker.UpcomingLeaders(ctx, f.Fanout)
+	if err != nil {
+		log.Printf("failed to get upcoming leaders: %v", err)
+	}
+	for _, leader := range leaders {
+		if err := f.Forward(ctx, leader, wireTx); err != nil {
+			log.Printf("failed to forward to leader %s: %v", leader.Identity, err)
+			continue
+		}
+		forwarded++
+	}
+
+	if f.Fallback != nil {
+		if _, err := f.Fallback.SendTransaction(ctx, tx); err != nil && forwarded == 0 {
+			return solana.Signature{}, err
+		}
+	} else if forwarded == 0 {
+		return solana.Signature{}, fmt.Errorf("transaction was not forwarded to any leader")
+	}
+	return tx.Signatures[0], nil
+}
@@ -3,45 +3,118 @@ package sol
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-func (c *Client) SignTransaction(ctx context.Context, signers []solana.PrivateKey, instrs ...solana.Instruction) (*solana.Transaction, error) {
+// SignTransaction signs with a freshly fetched blockhash; the first signer pays
+// the fees. LastValidBlockHeight reports when the result expires
+func (c *Client) SignTransaction(ctx context.Context, signers []Signer, instrs ...solana.Instruction) (*solana.Transaction, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+
+	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockhash: %w", err)
+	}
+
+	return SignTransactionWithBlockhash(res.Value.Blockhash, signers, instrs...)
+}
+
+// SignTransactionWithBlockhash builds and signs a transaction against a known blockhash.
+// The first signer pays the fees
+func SignTransactionWithBlockhash(blockhash solana.Hash, signers []Signer, instrs ...solana.Instruction) (*solana.Transaction, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+	return buildAndSign(blockhash, signers[0].PublicKey(), signers, false, instrs...)
+}
+
+// SignTransactionWithFeePayer builds and fully signs a transaction whose fees are paid by
+// feePayer rather than the swap authority. feePayer's key must be among signers
+func (c *Client) SignTransactionWithFeePayer(ctx context.Context, feePayer solana.PublicKey, signers []Signer, instrs ...solana.Instruction) (*solana.Transaction, error) {
+	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockhash: %w", err)
+	}
+	return buildAndSign(res.Value.Blockhash, feePayer, signers, false, instrs...)
+}
 
+// SignTransactionWithTables signs a v0 transaction that loads the accounts
+// found in tables through them; the first signer pays the fees
+func (c *Client) SignTransactionWithTables(ctx context.Context, signers []Signer, tables map[solana.PublicKey]solana.PublicKeySlice, instrs ...solana.Instruction) (*solana.Transaction, error) {
 	if len(signers) == 0 {
 		return nil, fmt.Errorf("at least one signer is required")
 	}
+	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockhash: %w", err)
+	}
+	return buildAndSignWithOptions(res.Value.Blockhash, signers[0].PublicKey(), signers, false, instrs,
+		solana.TransactionAddressTables(tables))
+}
+
+// TransactionSize returns the serialized size of tx, to compare against
+// MaxTransactionSize
+func TransactionSize(tx *solana.Transaction) (int, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	return len(raw), nil
+}
 
+// PartialSignTransactionWithFeePayer builds a transaction paid by feePayer and signs it
+// only with the given signers, leaving the remaining signatures (typically the
+// relayer's) empty to be filled in later with PartialSignTransaction
+func (c *Client) PartialSignTransactionWithFeePayer(ctx context.Context, feePayer solana.PublicKey, signers []Signer, instrs ...solana.Instruction) (*solana.Transaction, error) {
 	res, err := c.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
 	if err != nil {
-		log.Fatalf("Failed to get blockhash: %v", err)
+		return nil, fmt.Errorf("failed to get blockhash: %w", err)
 	}
+	return buildAndSign(res.Value.Blockhash, feePayer, signers, true, instrs...)
+}
 
-	// Create new transaction with all instructions
+// PartialSignTransaction adds the signatures of signers to an already built
+// transaction without touching the signatures it already carries
+func PartialSignTransaction(tx *solana.Transaction, signers []Signer) error {
+	if err := signWith(tx, signers, true); err != nil {
+		return fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return nil
+}
+
+// MissingSigners returns the accounts that still have to sign tx
+func MissingSigners(tx *solana.Transaction) []solana.PublicKey {
+	missing := make([]solana.PublicKey, 0)
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	for i := 0; i < numSigners && i < len(tx.Message.AccountKeys); i++ {
+		if i >= len(tx.Signatures) || tx.Signatures[i].IsZero() {
+			missing = append(missing, tx.Message.AccountKeys[i])
+		}
+	}
+	return missing
+}
+
+func buildAndSign(blockhash solana.Hash, feePayer solana.PublicKey, signers []Signer, partial bool, instrs ...solana.Instruction) (*solana.Transaction, error) {
+	return buildAndSignWithOptions(blockhash, feePayer, signers, partial, instrs)
+}
+
+func buildAndSignWithOptions(blockhash solana.Hash, feePayer solana.PublicKey, signers []Signer, partial bool, instrs []solana.Instruction, opts ...solana.TransactionOption) (*solana.Transaction, error) {
+	// Create new transaction with all instructions; the fee payer is always
+	// placed first among the signer keys
 	tx, err := solana.NewTransaction(
 		instrs,
-		res.Value.Blockhash,
-		solana.TransactionPayer(signers[0].PublicKey()),
+		blockhash,
+		append([]solana.TransactionOption{solana.TransactionPayer(feePayer)}, opts...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	// Sign the transaction with all provided signers
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			for _, payer := range signers {
-				if payer.PublicKey().Equals(key) {
-					return &payer
-				}
-			}
-			return nil
-		},
-	)
-	if err != nil {
+	if err := signWith(tx, signers, partial); err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
 	return tx, nil
@@ -0,0 +1,110 @@
+package sol
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Signer signs serialized transaction messages without exposing its key,
+// e.g. a hardware wallet
+type Signer interface {
+	PublicKey() solana.PublicKey
+	SignMessage(message []byte) (solana.Signature, error)
+}
+
+// PrivateKeySigner adapts an in-memory private key to Signer
+type PrivateKeySigner struct {
+	Key solana.PrivateKey
+}
+
+// PublicKey returns the signer's public key
+func (s PrivateKeySigner) PublicKey() solana.PublicKey {
+	return s.Key.PublicKey()
+}
+
+// SignMessage signs message with the private key
+func (s PrivateKeySigner) SignMessage(message []byte) (solana.Signature, error) {
+	return s.Key.Sign(message)
+}
+
+// PrivateKeySigners adapts in-memory private keys to signers
+func PrivateKeySigners(keys ...solana.PrivateKey) []Signer {
+	signers := make([]Signer, 0, len(keys))
+	for _, key := range keys {
+		signers = append(signers, PrivateKeySigner{Key: key})
+	}
+	return signers
+}
+
+// SignWithSigners adds the signatures of signers to tx, leaving signatures
+// of other required signers untouched
+func SignWithSigners(tx *solana.Transaction, signers ...Signer) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode message for signing: %w", err)
+	}
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	if len(tx.Signatures) == 0 {
+		tx.Signatures = make([]solana.Signature, numSigners)
+	} else if len(tx.Signatures) != numSigners {
+		return fmt.Errorf("invalid signatures length, expected %d, actual %d", numSigners, len(tx.Signatures))
+	}
+
+	for _, signer := range signers {
+		index := -1
+		for i := 0; i < numSigners && i < len(tx.Message.AccountKeys); i++ {
+			if tx.Message.AccountKeys[i].Equals(signer.PublicKey()) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("signer %s is not a required signer of the transaction", signer.PublicKey())
+		}
+		signature, err := signer.SignMessage(message)
+		if err != nil {
+			return fmt.Errorf("signer %s failed: %w", signer.PublicKey(), err)
+		}
+		tx.Signatures[index] = signature
+	}
+	return nil
+}
+
+// signWith signs tx with the signers among its required signers, ignoring
+// any others. Unless partial, every required signer must be among them
+func signWith(tx *solana.Transaction, signers []Signer, partial bool) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode message for signing: %w", err)
+	}
+	numSigners := int(tx.Message.Header.NumRequiredSignatures)
+	if len(tx.Signatures) == 0 {
+		tx.Signatures = make([]solana.Signature, numSigners)
+	} else if len(tx.Signatures) != numSigners {
+		return fmt.Errorf("invalid signatures length, expected %d, actual %d", numSigners, len(tx.Signatures))
+	}
+
+	for i := 0; i < numSigners && i < len(tx.Message.AccountKeys); i++ {
+		key := tx.Message.AccountKeys[i]
+		var signer Signer
+		for _, candidate := range signers {
+			if candidate.PublicKey().Equals(key) {
+				signer = candidate
+				break
+			}
+		}
+		if signer == nil {
+			if partial {
+				continue
+			}
+			return fmt.Errorf("signer key %s not found", key)
+		}
+		signature, err := signer.SignMessage(message)
+		if err != nil {
+			return fmt.Errorf("signer %s failed: %w", key, err)
+		}
+		tx.Signatures[i] = signature
+	}
+	return nil
+}
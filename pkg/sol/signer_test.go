@@ -0,0 +1,50 @@
+package sol
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// deviceSigner signs like a hardware wallet, without handing out its key
+type deviceSigner struct {
+	key   solana.PrivateKey
+	signs int
+}
+
+func (s *deviceSigner) PublicKey() solana.PublicKey { return s.key.PublicKey() }
+
+func (s *deviceSigner) SignMessage(message []byte) (solana.Signature, error) {
+	s.signs++
+	return s.key.Sign(message)
+}
+
+func TestSignTransactionWithSigner(t *testing.T) {
+	device := &deviceSigner{key: solana.NewWallet().PrivateKey}
+	other := solana.NewWallet().PrivateKey
+	// the device pays; the other key co-signs as the transfer's source
+	transfer := system.NewTransferInstruction(1, other.PublicKey(), device.PublicKey()).Build()
+	blockhash := solana.Hash{1}
+
+	tx, err := SignTransactionWithBlockhash(blockhash, []Signer{device, PrivateKeySigner{Key: other}}, transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.signs != 1 {
+		t.Fatalf("device signed %d times, want 1", device.signs)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Fatalf("signatures do not verify: %v", err)
+	}
+
+	if _, err := SignTransactionWithBlockhash(blockhash, []Signer{device}, transfer); err == nil {
+		t.Fatal("a transaction missing a required signer was fully signed")
+	}
+	if tx, err = buildAndSign(blockhash, device.PublicKey(), []Signer{device}, true, transfer); err != nil {
+		t.Fatal(err)
+	}
+	if missing := MissingSigners(tx); len(missing) != 1 || !missing[0].Equals(other.PublicKey()) {
+		t.Fatalf("missing signers = %v, want only %s", missing, other.PublicKey())
+	}
+}
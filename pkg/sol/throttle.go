@@ -0,0 +1,65 @@
+package sol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const (
+	// maxThrottleRetries is how many times a throttled request is retried
+	maxThrottleRetries = 5
+	throttleBaseDelay  = 250 * time.Millisecond
+	throttleMaxDelay   = 8 * time.Second
+
+	// rpcCodeRateLimited is the JSON-RPC error code some providers use for throttling
+	rpcCodeRateLimited = -32429
+)
+
+// IsRateLimited reports whether err is a provider rejecting a request for
+// exceeding its rate limit, as an HTTP 429 or a -32429 JSON-RPC error
+func IsRateLimited(err error) bool {
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code == http.StatusTooManyRequests
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == http.StatusTooManyRequests || rpcErr.Code == rpcCodeRateLimited
+	}
+	return false
+}
+
+// call runs an RPC request through the rate limiter. Throttled requests lower
+// the limiter's rate and are retried with exponential backoff; successful
+// ones let the rate ramp back up. The outcome is recorded under method for Health
+func call[T any](ctx context.Context, c *Client, method string, request func() (T, error)) (T, error) {
+	var zero T
+	delay := throttleBaseDelay
+	for attempt := 0; ; attempt++ {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return zero, err
+		}
+		result, err := request()
+		c.health.record(method, err)
+		if !IsRateLimited(err) {
+			if err == nil {
+				c.rateLimiter.Recover()
+			}
+			return result, err
+		}
+		c.rateLimiter.Throttle()
+		if attempt >= maxThrottleRetries {
+			return result, err
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, throttleMaxDelay)
+	}
+}
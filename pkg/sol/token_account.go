@@ -2,6 +2,8 @@ package sol
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
 	"log"
 
 	"github.com/gagliardetto/solana-go"
@@ -45,7 +47,7 @@ func (t *Client) SelectOrCreateSPLTokenAccount(ctx context.Context, privateKey s
 	if len(instructions) == 0 {
 		return ataAddress, nil
 	} else {
-		signers := []solana.PrivateKey{privateKey}
+		signers := PrivateKeySigners(privateKey)
 		tx, err := t.SignTransaction(ctx, signers, instructions...)
 		if err != nil {
 			log.Printf("Failed to sign transaction: %v", err)
@@ -59,3 +61,106 @@ func (t *Client) SelectOrCreateSPLTokenAccount(ctx context.Context, privateKey s
 		return ataAddress, nil
 	}
 }
+
+// NewCreateATAIdempotentInstruction creates the owner's associated token account
+// for mint if it does not exist yet, and is a no-op otherwise
+func NewCreateATAIdempotentInstruction(payer, owner, mint solana.PublicKey) (solana.Instruction, error) {
+	return NewCreateATAIdempotentInstructionWithProgram(payer, owner, mint, solana.TokenProgramID)
+}
+
+// NewCreateATAIdempotentInstructionWithProgram is NewCreateATAIdempotentInstruction
+// for a mint owned by tokenProgram, e.g. a Token-2022 mint
+func NewCreateATAIdempotentInstructionWithProgram(payer, owner, mint, tokenProgram solana.PublicKey) (solana.Instruction, error) {
+	ata, _, err := FindAssociatedTokenAddressWithProgram(owner, mint, tokenProgram)
+	if err != nil {
+		return nil, err
+	}
+	return solana.NewInstruction(
+		solana.SPLAssociatedTokenAccountProgramID,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(payer, true, true),
+			solana.NewAccountMeta(ata, true, false),
+			solana.NewAccountMeta(owner, false, false),
+			solana.NewAccountMeta(mint, false, false),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+			solana.NewAccountMeta(tokenProgram, false, false),
+		},
+		[]byte{1}, // CreateIdempotent
+	), nil
+}
+
+// MissingTokenAccounts returns the accounts that the ATA create instructions
+// among instructions would actually create, skipping ones that already exist
+func (t *Client) MissingTokenAccounts(ctx context.Context, instructions []solana.Instruction) ([]solana.PublicKey, error) {
+	seen := make(map[solana.PublicKey]struct{})
+	accounts := make([]solana.PublicKey, 0)
+	for _, instruction := range instructions {
+		if !instruction.ProgramID().Equals(solana.SPLAssociatedTokenAccountProgramID) {
+			continue
+		}
+		metas := instruction.Accounts()
+		if len(metas) < 2 {
+			continue
+		}
+		ata := metas[1].PublicKey
+		if _, ok := seen[ata]; ok {
+			continue
+		}
+		seen[ata] = struct{}{}
+		accounts = append(accounts, ata)
+	}
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+
+	results, err := t.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token accounts: %w", err)
+	}
+	missing := make([]solana.PublicKey, 0)
+	for i, ata := range accounts {
+		if i >= len(results.Value) || results.Value[i] == nil {
+			missing = append(missing, ata)
+		}
+	}
+	return missing, nil
+}
+
+// AccountData returns the raw data of the i-th account of a batched fetch,
+// ok is false when the RPC node returned no account at that position
+func AccountData(results *rpc.GetMultipleAccountsResult, i int) ([]byte, bool) {
+	if results == nil || i < 0 || i >= len(results.Value) || results.Value[i] == nil || results.Value[i].Data == nil {
+		return nil, false
+	}
+	return results.Value[i].Data.GetBinary(), true
+}
+
+// TokenAccountAmount reads the amount of the i-th SPL token account of a batched fetch,
+// ok is false when the account is missing or too short to hold an amount
+func TokenAccountAmount(results *rpc.GetMultipleAccountsResult, i int) (uint64, bool) {
+	data, ok := AccountData(results, i)
+	if !ok || len(data) < 72 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(data[64:72]), true
+}
+
+// MintSupply reads the supply of the i-th SPL mint account of a batched fetch,
+// ok is false when the account is missing or too short to hold a supply
+func MintSupply(results *rpc.GetMultipleAccountsResult, i int) (uint64, bool) {
+	data, ok := AccountData(results, i)
+	if !ok || len(data) < 44 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(data[36:44]), true
+}
+
+// MintDecimals reads the decimals of the i-th SPL mint account of a batched fetch,
+// ok is false when the account is missing or too short to hold them
+func MintDecimals(results *rpc.GetMultipleAccountsResult, i int) (uint8, bool) {
+	data, ok := AccountData(results, i)
+	if !ok || len(data) < 45 {
+		return 0, false
+	}
+	return data[44], true
+}
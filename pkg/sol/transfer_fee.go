@@ -0,0 +1,89 @@
+package sol
+
+import (
+	"encoding/binary"
+
+	"cosmossdk.io/math"
+)
+
+const (
+	// token2022ExtensionsOffset is where the TLV extensions of a Token-2022
+	// mint start: the base mint padded to the token account size, then the
+	// one-byte account type
+	token2022ExtensionsOffset = 165 + 1
+	// transferFeeConfigExtension is the TLV type of the transfer fee extension
+	transferFeeConfigExtension = 1
+	// transferFeeConfigSize covers the two authorities, the withheld amount
+	// and the older and newer fees
+	transferFeeConfigSize = 32 + 32 + 8 + 2*transferFeeSize
+	transferFeeSize       = 8 + 8 + 2
+)
+
+// TransferFee is one transfer fee setting of a Token-2022 mint, in force from
+// Epoch
+type TransferFee struct {
+	Epoch       uint64
+	MaximumFee  uint64
+	BasisPoints uint16
+}
+
+// TransferFeeConfig is the transfer fee extension of a Token-2022 mint. The
+// newer fee replaces the older one from its epoch
+type TransferFeeConfig struct {
+	Older TransferFee
+	Newer TransferFee
+}
+
+// ParseTransferFeeConfig reads the transfer fee extension of a Token-2022 mint
+// account, reporting false for mints without one
+func ParseTransferFeeConfig(data []byte) (*TransferFeeConfig, bool) {
+	offset := token2022ExtensionsOffset
+	for offset+4 <= len(data) {
+		extensionType := binary.LittleEndian.Uint16(data[offset:])
+		length := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		offset += 4
+		if offset+length > len(data) {
+			return nil, false
+		}
+		if extensionType == transferFeeConfigExtension {
+			if length < transferFeeConfigSize {
+				return nil, false
+			}
+			fees := data[offset+32+32+8:]
+			return &TransferFeeConfig{
+				Older: parseTransferFee(fees),
+				Newer: parseTransferFee(fees[transferFeeSize:]),
+			}, true
+		}
+		offset += length
+	}
+	return nil, false
+}
+
+func parseTransferFee(data []byte) TransferFee {
+	return TransferFee{
+		Epoch:       binary.LittleEndian.Uint64(data[0:8]),
+		MaximumFee:  binary.LittleEndian.Uint64(data[8:16]),
+		BasisPoints: binary.LittleEndian.Uint16(data[16:18]),
+	}
+}
+
+// Fee returns what a transfer of amount is charged in epoch: the basis points
+// rounded up, capped at the maximum fee, as the token program computes it
+func (c *TransferFeeConfig) Fee(epoch uint64, amount math.Int) math.Int {
+	if c == nil || amount.IsNil() || !amount.IsPositive() {
+		return math.ZeroInt()
+	}
+	fee := c.Older
+	if epoch >= c.Newer.Epoch {
+		fee = c.Newer
+	}
+	if fee.BasisPoints == 0 {
+		return math.ZeroInt()
+	}
+	charged := amount.MulRaw(int64(fee.BasisPoints)).AddRaw(9999).QuoRaw(10000)
+	if maximum := math.NewIntFromUint64(fee.MaximumFee); charged.GT(maximum) {
+		return maximum
+	}
+	return charged
+}
@@ -12,8 +12,7 @@ import (
 )
 
 func (t *Client) CoverWsol(ctx context.Context, privateKey solana.PrivateKey, amount int64) error {
-	var signers []solana.PrivateKey
-	signers = append(signers, privateKey)
+	signers := PrivateKeySigners(privateKey)
 
 	allInstrs := make([]solana.Instruction, 0)
 	user := privateKey.PublicKey()
@@ -80,8 +79,7 @@ func (t *Client) CoverWsol(ctx context.Context, privateKey solana.PrivateKey, am
 }
 
 func (t *Client) CloseWsol(ctx context.Context, privateKey solana.PrivateKey) error {
-	var signers []solana.PrivateKey
-	signers = append(signers, privateKey)
+	signers := PrivateKeySigners(privateKey)
 	user := privateKey.PublicKey()
 	insts := make([]solana.Instruction, 0)
 
@@ -113,3 +111,33 @@ func (t *Client) CloseWsol(ctx context.Context, privateKey solana.PrivateKey) er
 	}
 	return nil
 }
+
+// WrapSolInstructions moves lamports into the user's WSOL account, creating it when needed
+func WrapSolInstructions(user solana.PublicKey, amount uint64) ([]solana.Instruction, error) {
+	wsolAccount, _, err := FindAssociatedTokenAddress(user, WSOL)
+	if err != nil {
+		return nil, err
+	}
+	createAtaInst, err := NewCreateATAIdempotentInstruction(user, user, WSOL)
+	if err != nil {
+		return nil, err
+	}
+	transferInst, err := system.NewTransferInstruction(amount, user, wsolAccount).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	syncNativeInst, err := token.NewSyncNativeInstruction(wsolAccount).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	return []solana.Instruction{createAtaInst, transferInst, syncNativeInst}, nil
+}
+
+// UnwrapSolInstruction closes the user's WSOL account, returning its lamports as native SOL
+func UnwrapSolInstruction(user solana.PublicKey) (solana.Instruction, error) {
+	wsolAccount, _, err := FindAssociatedTokenAddress(user, WSOL)
+	if err != nil {
+		return nil, err
+	}
+	return token.NewCloseAccountInstruction(wsolAccount, user, user, []solana.PublicKey{}).ValidateAndBuild()
+}
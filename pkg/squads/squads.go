@@ -0,0 +1,333 @@
+package squads
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/anchor"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ProgramID is the Squads v4 multisig program
+var ProgramID = solana.MustPublicKeyFromBase58("SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf")
+
+const (
+	seedPrefix      = "multisig"
+	seedVault       = "vault"
+	seedTransaction = "transaction"
+	seedProposal    = "proposal"
+
+	// transactionIndexOffset is the offset of transaction_index in the multisig
+	// account: discriminator(8) + create_key(32) + config_authority(32) + threshold(2) + time_lock(4)
+	transactionIndexOffset = 78
+)
+
+// VaultAddress derives the vault PDA that holds the multisig's funds
+func VaultAddress(multisig solana.PublicKey, vaultIndex uint8) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{
+		[]byte(seedPrefix),
+		multisig.Bytes(),
+		[]byte(seedVault),
+		{vaultIndex},
+	}, ProgramID)
+	return address, err
+}
+
+// TransactionAddress derives the vault transaction PDA for transactionIndex
+func TransactionAddress(multisig solana.PublicKey, transactionIndex uint64) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{
+		[]byte(seedPrefix),
+		multisig.Bytes(),
+		[]byte(seedTransaction),
+		uint64ToBytes(transactionIndex),
+	}, ProgramID)
+	return address, err
+}
+
+// ProposalAddress derives the proposal PDA for transactionIndex
+func ProposalAddress(multisig solana.PublicKey, transactionIndex uint64) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{
+		[]byte(seedPrefix),
+		multisig.Bytes(),
+		[]byte(seedTransaction),
+		uint64ToBytes(transactionIndex),
+		[]byte(seedProposal),
+	}, ProgramID)
+	return address, err
+}
+
+// NextTransactionIndex reads the multisig account and returns the index the
+// next vault transaction will use
+func NextTransactionIndex(ctx context.Context, solClient *sol.Client, multisig solana.PublicKey) (uint64, error) {
+	account, err := solClient.GetAccountInfoWithOpts(ctx, multisig)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch multisig account: %w", err)
+	}
+	if account == nil || account.Value == nil {
+		return 0, fmt.Errorf("multisig account %s not found", multisig)
+	}
+	data := account.Value.Data.GetBinary()
+	if len(data) < transactionIndexOffset+8 {
+		return 0, fmt.Errorf("invalid multisig account data length: %d", len(data))
+	}
+	return binary.LittleEndian.Uint64(data[transactionIndexOffset:transactionIndexOffset+8]) + 1, nil
+}
+
+// NewVaultTransactionCreateInstruction stores instructions, to be executed by the
+// vault, as a new vault transaction of the multisig
+func NewVaultTransactionCreateInstruction(
+	multisig solana.PublicKey,
+	transactionIndex uint64,
+	creator solana.PublicKey,
+	rentPayer solana.PublicKey,
+	vaultIndex uint8,
+	instructions []solana.Instruction,
+) (solana.Instruction, error) {
+	vault, err := VaultAddress(multisig, vaultIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault: %w", err)
+	}
+	message, _, err := compileVaultMessage(vault, instructions)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := TransactionAddress(multisig, transactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive transaction: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(anchor.GetDiscriminator("global", "vault_transaction_create"))
+	buf.WriteByte(vaultIndex)
+	buf.WriteByte(0) // ephemeral signers
+	binary.Write(buf, binary.LittleEndian, uint32(len(message)))
+	buf.Write(message)
+	buf.WriteByte(0) // memo: None
+
+	return solana.NewInstruction(ProgramID, solana.AccountMetaSlice{
+		solana.NewAccountMeta(multisig, true, false),
+		solana.NewAccountMeta(transaction, true, false),
+		solana.NewAccountMeta(creator, false, true),
+		solana.NewAccountMeta(rentPayer, true, true),
+		solana.NewAccountMeta(solana.SystemProgramID, false, false),
+	}, buf.Bytes()), nil
+}
+
+// NewProposalCreateInstruction opens a proposal for the vault transaction at
+// transactionIndex. Draft proposals must be activated before members can vote
+func NewProposalCreateInstruction(
+	multisig solana.PublicKey,
+	transactionIndex uint64,
+	creator solana.PublicKey,
+	rentPayer solana.PublicKey,
+	draft bool,
+) (solana.Instruction, error) {
+	proposal, err := ProposalAddress(multisig, transactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive proposal: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(anchor.GetDiscriminator("global", "proposal_create"))
+	buf.Write(uint64ToBytes(transactionIndex))
+	if draft {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+
+	return solana.NewInstruction(ProgramID, solana.AccountMetaSlice{
+		solana.NewAccountMeta(multisig, false, false),
+		solana.NewAccountMeta(proposal, true, false),
+		solana.NewAccountMeta(creator, false, true),
+		solana.NewAccountMeta(rentPayer, true, true),
+		solana.NewAccountMeta(solana.SystemProgramID, false, false),
+	}, buf.Bytes()), nil
+}
+
+// NewProposalApproveInstruction casts member's approval on the proposal at transactionIndex
+func NewProposalApproveInstruction(multisig solana.PublicKey, transactionIndex uint64, member solana.PublicKey) (solana.Instruction, error) {
+	proposal, err := ProposalAddress(multisig, transactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive proposal: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(anchor.GetDiscriminator("global", "proposal_approve"))
+	buf.WriteByte(0) // memo: None
+
+	return solana.NewInstruction(ProgramID, solana.AccountMetaSlice{
+		solana.NewAccountMeta(multisig, false, false),
+		solana.NewAccountMeta(member, false, true),
+		solana.NewAccountMeta(proposal, true, false),
+	}, buf.Bytes()), nil
+}
+
+// NewVaultTransactionExecuteInstruction executes an approved vault transaction.
+// instructions must be the same list the transaction was created with, since
+// the program expects the message accounts to be passed as remaining accounts
+func NewVaultTransactionExecuteInstruction(
+	multisig solana.PublicKey,
+	transactionIndex uint64,
+	member solana.PublicKey,
+	vaultIndex uint8,
+	instructions []solana.Instruction,
+) (solana.Instruction, error) {
+	vault, err := VaultAddress(multisig, vaultIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault: %w", err)
+	}
+	_, remainingAccounts, err := compileVaultMessage(vault, instructions)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := ProposalAddress(multisig, transactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive proposal: %w", err)
+	}
+	transaction, err := TransactionAddress(multisig, transactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive transaction: %w", err)
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.NewAccountMeta(multisig, false, false),
+		solana.NewAccountMeta(proposal, true, false),
+		solana.NewAccountMeta(transaction, false, false),
+		solana.NewAccountMeta(member, false, true),
+	}
+	accounts = append(accounts, remainingAccounts...)
+
+	return solana.NewInstruction(ProgramID, accounts,
+		anchor.GetDiscriminator("global", "vault_transaction_execute")), nil
+}
+
+// ProposeInstructions wraps instructions (e.g. a swap built with the vault as
+// user) into a new vault transaction plus an active proposal, returning the
+// instructions to send and the transaction index they were assigned
+func ProposeInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	multisig solana.PublicKey,
+	creator solana.PublicKey,
+	vaultIndex uint8,
+	instructions []solana.Instruction,
+) ([]solana.Instruction, uint64, error) {
+	transactionIndex, err := NextTransactionIndex(ctx, solClient, multisig)
+	if err != nil {
+		return nil, 0, err
+	}
+	createInst, err := NewVaultTransactionCreateInstruction(multisig, transactionIndex, creator, creator, vaultIndex, instructions)
+	if err != nil {
+		return nil, 0, err
+	}
+	proposalInst, err := NewProposalCreateInstruction(multisig, transactionIndex, creator, creator, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	return []solana.Instruction{createInst, proposalInst}, transactionIndex, nil
+}
+
+// compileVaultMessage serializes instructions in the Squads transaction
+// message format, with the vault as the only signer, and returns the accounts
+// the execute instruction has to pass along
+func compileVaultMessage(vault solana.PublicKey, instructions []solana.Instruction) ([]byte, solana.AccountMetaSlice, error) {
+	if len(instructions) == 0 {
+		return nil, nil, fmt.Errorf("no instructions to propose")
+	}
+
+	metas := solana.AccountMetaSlice{solana.NewAccountMeta(vault, true, true)}
+	index := map[solana.PublicKey]int{vault: 0}
+	addAccount := func(key solana.PublicKey, writable, signer bool) error {
+		if signer && !key.Equals(vault) {
+			return fmt.Errorf("account %s must sign, only the vault can sign inside a vault transaction", key)
+		}
+		if i, ok := index[key]; ok {
+			metas[i].IsWritable = metas[i].IsWritable || writable
+			return nil
+		}
+		index[key] = len(metas)
+		metas = append(metas, solana.NewAccountMeta(key, writable, signer))
+		return nil
+	}
+	for _, instruction := range instructions {
+		if err := addAccount(instruction.ProgramID(), false, false); err != nil {
+			return nil, nil, err
+		}
+		for _, meta := range instruction.Accounts() {
+			if err := addAccount(meta.PublicKey, meta.IsWritable, meta.IsSigner); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if len(metas) > 255 {
+		return nil, nil, fmt.Errorf("too many accounts in vault transaction: %d", len(metas))
+	}
+
+	// order keys as writable signers, readonly signers, writable non-signers, readonly non-signers
+	ordered := make(solana.AccountMetaSlice, 0, len(metas))
+	numWritableNonSigners := 0
+	for _, group := range []struct{ signer, writable bool }{{true, true}, {true, false}, {false, true}, {false, false}} {
+		for _, meta := range metas {
+			if meta.IsSigner == group.signer && meta.IsWritable == group.writable {
+				ordered = append(ordered, meta)
+				if !group.signer && group.writable {
+					numWritableNonSigners++
+				}
+			}
+		}
+	}
+	for i, meta := range ordered {
+		index[meta.PublicKey] = i
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(1) // num_signers: the vault
+	buf.WriteByte(1) // num_writable_signers
+	buf.WriteByte(uint8(numWritableNonSigners))
+	buf.WriteByte(uint8(len(ordered)))
+	for _, meta := range ordered {
+		buf.Write(meta.PublicKey.Bytes())
+	}
+	if len(instructions) > 255 {
+		return nil, nil, fmt.Errorf("too many instructions in vault transaction: %d", len(instructions))
+	}
+	buf.WriteByte(uint8(len(instructions)))
+	for _, instruction := range instructions {
+		data, err := instruction.Data()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get instruction data: %w", err)
+		}
+		if len(data) > 0xffff {
+			return nil, nil, fmt.Errorf("instruction data too large: %d bytes", len(data))
+		}
+		accounts := instruction.Accounts()
+		if len(accounts) > 255 {
+			return nil, nil, fmt.Errorf("too many accounts in instruction: %d", len(accounts))
+		}
+		buf.WriteByte(uint8(index[instruction.ProgramID()]))
+		buf.WriteByte(uint8(len(accounts)))
+		for _, meta := range accounts {
+			buf.WriteByte(uint8(index[meta.PublicKey]))
+		}
+		binary.Write(buf, binary.LittleEndian, uint16(len(data)))
+		buf.Write(data)
+	}
+	buf.WriteByte(0) // address_table_lookups
+
+	// the vault signs through the program, so no remaining account is a signer
+	remaining := make(solana.AccountMetaSlice, 0, len(ordered))
+	for _, meta := range ordered {
+		remaining = append(remaining, solana.NewAccountMeta(meta.PublicKey, meta.IsWritable, false))
+	}
+	return buf.Bytes(), remaining, nil
+}
+
+func uint64ToBytes(v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, v)
+	return buf
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import "fmt"
+
+// Stability is the compatibility promise of an API surface. The interfaces
+// of this package, pkg/protocol and pkg/router are stable: within a major
+// version they only gain methods through new optional interfaces probed with
+// a type assertion, such as ImpactBoundedPool or BatchProtocol, never on Pool
+// or Protocol themselves, so pools and protocols implemented outside the SDK
+// keep compiling. Packages under x/ are experimental and may change in any
+// release
+type Stability int
+
+const (
+	// StabilityStable surfaces keep working until the next major version
+	StabilityStable Stability = iota
+	// StabilityExperimental surfaces may change or move without notice
+	StabilityExperimental
+	// StabilityDeprecated surfaces still work but are scheduled for removal
+	StabilityDeprecated
+)
+
+func (s Stability) String() string {
+	switch s {
+	case StabilityStable:
+		return "stable"
+	case StabilityExperimental:
+		return "experimental"
+	case StabilityDeprecated:
+		return "deprecated"
+	}
+	return fmt.Sprintf("Stability(%d)", int(s))
+}
+
+// Deprecation describes a stable surface scheduled for removal. It keeps
+// working for at least one minor release after Since and is removed no
+// earlier than RemovedIn, the next major version
+type Deprecation struct {
+	// Since is the release that deprecated it
+	Since string
+	// RemovedIn is the release expected to remove it
+	RemovedIn string
+	// Replacement, when set, names what to use instead
+	Replacement string
+}
+
+func (d Deprecation) String() string {
+	msg := fmt.Sprintf("deprecated since %s, removed in %s", d.Since, d.RemovedIn)
+	if d.Replacement != "" {
+		msg += ", use " + d.Replacement
+	}
+	return msg
+}
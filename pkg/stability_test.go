@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// TestStableInterfaces pins the method sets of Pool and Protocol: adding a
+// method to either breaks every implementation outside the SDK, so new
+// capabilities belong in optional interfaces instead
+func TestStableInterfaces(t *testing.T) {
+	tests := []struct {
+		iface   reflect.Type
+		methods []string
+	}{
+		{
+			iface: reflect.TypeOf((*Pool)(nil)).Elem(),
+			methods: []string{
+				"BuildSwapInstructions",
+				"GetID",
+				"GetProgramID",
+				"GetTokens",
+				"ProtocolName",
+				"Quote",
+			},
+		},
+		{
+			iface: reflect.TypeOf((*Protocol)(nil)).Elem(),
+			methods: []string{
+				"FetchPoolByID",
+				"FetchPoolsByPair",
+				"ProtocolName",
+			},
+		},
+	}
+	for _, tt := range tests {
+		methods := make([]string, 0, tt.iface.NumMethod())
+		for i := 0; i < tt.iface.NumMethod(); i++ {
+			methods = append(methods, tt.iface.Method(i).Name)
+		}
+		if !slices.Equal(methods, tt.methods) {
+			t.Errorf("%s has methods %v, want %v", tt.iface.Name(), methods, tt.methods)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"cosmossdk.io/math"
+)
+
+// MemoryStore keeps orders in memory; useful for tests and short-lived bots
+type MemoryStore struct {
+	mu     sync.RWMutex
+	orders map[string]Order
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{orders: make(map[string]Order)}
+}
+
+// SaveOrder stores a copy of order
+func (s *MemoryStore) SaveOrder(ctx context.Context, order *Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *order
+	saved.Pools = append([]string(nil), order.Pools...)
+	if order.ProtocolFees != nil {
+		saved.ProtocolFees = make(map[string]math.Int, len(order.ProtocolFees))
+		for mint, fee := range order.ProtocolFees {
+			saved.ProtocolFees[mint] = fee
+		}
+	}
+	s.orders[order.ID] = saved
+	return nil
+}
+
+// GetOrder returns a copy of the order with id
+func (s *MemoryStore) GetOrder(ctx context.Context, id string) (*Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &order, nil
+}
+
+// ListOrders returns the matching orders, oldest first
+func (s *MemoryStore) ListOrders(ctx context.Context, filter Filter) ([]*Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]*Order, 0)
+	for _, order := range s.orders {
+		if filter.matches(&order) {
+			orders = append(orders, &order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders, nil
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS orders (
+	id                  TEXT PRIMARY KEY,
+	status              TEXT NOT NULL,
+	wallet              TEXT NOT NULL,
+	input_mint          TEXT NOT NULL,
+	output_mint         TEXT NOT NULL,
+	recipient           TEXT NOT NULL,
+	pools               TEXT NOT NULL,
+	amount_in           TEXT NOT NULL,
+	quoted_amount_out   TEXT NOT NULL,
+	min_amount_out      TEXT NOT NULL,
+	realized_amount_out TEXT NOT NULL,
+	signature           TEXT NOT NULL,
+	last_valid_height   INTEGER NOT NULL,
+	slot                INTEGER NOT NULL,
+	fee                 INTEGER NOT NULL,
+	priority_fee        INTEGER NOT NULL,
+	tip                 INTEGER NOT NULL,
+	protocol_fees       TEXT NOT NULL,
+	error               TEXT NOT NULL,
+	created_at          INTEGER NOT NULL,
+	updated_at          INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS orders_status ON orders (status);
+CREATE INDEX IF NOT EXISTS orders_wallet ON orders (wallet, created_at);
+`
+
+const orderColumns = `id, status, wallet, input_mint, output_mint, recipient, pools, amount_in,
+	quoted_amount_out, min_amount_out, realized_amount_out, signature, last_valid_height, slot, fee,
+	priority_fee, tip, protocol_fees, error, created_at, updated_at`
+
+// SQLiteStore persists orders in a SQLite database. The caller opens db with
+// the SQLite driver of their choice (e.g. modernc.org/sqlite or
+// github.com/mattn/go-sqlite3), so the SDK does not force a cgo dependency
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates the orders table in db if needed
+func NewSQLiteStore(ctx context.Context, db *sql.DB) (*SQLiteStore, error) {
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// SaveOrder inserts order or replaces the stored order with the same ID
+func (s *SQLiteStore) SaveOrder(ctx context.Context, order *Order) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO orders (`+orderColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			recipient = excluded.recipient,
+			pools = excluded.pools,
+			amount_in = excluded.amount_in,
+			quoted_amount_out = excluded.quoted_amount_out,
+			min_amount_out = excluded.min_amount_out,
+			realized_amount_out = excluded.realized_amount_out,
+			signature = excluded.signature,
+			last_valid_height = excluded.last_valid_height,
+			slot = excluded.slot,
+			fee = excluded.fee,
+			priority_fee = excluded.priority_fee,
+			tip = excluded.tip,
+			protocol_fees = excluded.protocol_fees,
+			error = excluded.error,
+			updated_at = excluded.updated_at`,
+		order.ID,
+		string(order.Status),
+		order.Wallet,
+		order.InputMint,
+		order.OutputMint,
+		order.Recipient,
+		strings.Join(order.Pools, ","),
+		intString(order.AmountIn),
+		intString(order.QuotedAmountOut),
+		intString(order.MinAmountOut),
+		intString(order.RealizedAmountOut),
+		order.Signature,
+		int64(order.LastValidBlockHeight),
+		int64(order.Slot),
+		int64(order.Fee),
+		int64(order.PriorityFee),
+		int64(order.Tip),
+		encodeFees(order.ProtocolFees),
+		order.Error,
+		order.CreatedAt.UnixNano(),
+		order.UpdatedAt.UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save order %s: %w", order.ID, err)
+	}
+	return nil
+}
+
+// GetOrder loads the order with id
+func (s *SQLiteStore) GetOrder(ctx context.Context, id string) (*Order, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+orderColumns+` FROM orders WHERE id = ?`, id)
+	order, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order %s: %w", id, err)
+	}
+	return order, nil
+}
+
+// ListOrders returns the matching orders, oldest first
+func (s *SQLiteStore) ListOrders(ctx context.Context, filter Filter) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE 1 = 1`
+	args := make([]any, 0)
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(filter.Status))
+	}
+	if filter.Wallet != "" {
+		query += ` AND wallet = ?`
+		args = append(args, filter.Wallet)
+	}
+	if !filter.Since.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, filter.Since.UnixNano())
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*Order, 0)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row rowScanner) (*Order, error) {
+	var (
+		order                              Order
+		status, pools                      string
+		amountIn, quoted, minOut, realized string
+		protocolFees                       string
+		lastValid, slot, fee, priorityFee  int64
+		tip                                int64
+		createdAt, updatedAt               int64
+	)
+	err := row.Scan(&order.ID, &status, &order.Wallet, &order.InputMint, &order.OutputMint, &order.Recipient, &pools,
+		&amountIn, &quoted, &minOut, &realized, &order.Signature, &lastValid, &slot, &fee, &priorityFee, &tip, &protocolFees, &order.Error, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = OrderStatus(status)
+	if pools != "" {
+		order.Pools = strings.Split(pools, ",")
+	}
+	for _, field := range []struct {
+		dst *math.Int
+		src string
+	}{
+		{&order.AmountIn, amountIn},
+		{&order.QuotedAmountOut, quoted},
+		{&order.MinAmountOut, minOut},
+		{&order.RealizedAmountOut, realized},
+	} {
+		if field.src == "" {
+			continue
+		}
+		value, ok := math.NewIntFromString(field.src)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", field.src)
+		}
+		*field.dst = value
+	}
+	order.LastValidBlockHeight = uint64(lastValid)
+	order.Slot = uint64(slot)
+	order.Fee = uint64(fee)
+	order.PriorityFee = uint64(priorityFee)
+	order.Tip = uint64(tip)
+	if order.ProtocolFees, err = decodeFees(protocolFees); err != nil {
+		return nil, err
+	}
+	order.CreatedAt = time.Unix(0, createdAt)
+	order.UpdatedAt = time.Unix(0, updatedAt)
+	return &order, nil
+}
+
+func intString(v math.Int) string {
+	if v.IsNil() {
+		return ""
+	}
+	return v.String()
+}
+
+// encodeFees stores per-mint fees as "mint:amount" pairs
+func encodeFees(fees map[string]math.Int) string {
+	pairs := make([]string, 0, len(fees))
+	for mint, fee := range fees {
+		pairs = append(pairs, mint+":"+intString(fee))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func decodeFees(encoded string) (map[string]math.Int, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	fees := make(map[string]math.Int)
+	for _, pair := range strings.Split(encoded, ",") {
+		mint, amount, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid fee entry %q", pair)
+		}
+		fee, ok := math.NewIntFromString(amount)
+		if !ok {
+			return nil, fmt.Errorf("invalid fee amount %q", amount)
+		}
+		fees[mint] = fee
+	}
+	return fees, nil
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cosmossdk.io/math"
+)
+
+// OrderStatus is the lifecycle stage of an order
+type OrderStatus string
+
+const (
+	StatusQuoted    OrderStatus = "quoted"
+	StatusSigned    OrderStatus = "signed"
+	StatusSent      OrderStatus = "sent"
+	StatusConfirmed OrderStatus = "confirmed"
+	StatusFailed    OrderStatus = "failed"
+	// StatusRolledBack marks a confirmed order whose transaction was on a fork
+	// the cluster dropped before finalizing it
+	StatusRolledBack OrderStatus = "rolled_back"
+)
+
+// ErrNotFound is returned when an order does not exist
+var ErrNotFound = errors.New("order not found")
+
+// Order is one route execution from quote to realized fill
+type Order struct {
+	ID         string
+	Status     OrderStatus
+	Wallet     string
+	InputMint  string
+	OutputMint string
+	// Recipient received the output in place of Wallet; empty when Wallet did
+	Recipient string
+	// Pools are the IDs of the route's pools, in hop order
+	Pools []string
+
+	AmountIn        math.Int
+	QuotedAmountOut math.Int
+	MinAmountOut    math.Int
+	// RealizedAmountOut is what the wallet actually received, set once confirmed
+	RealizedAmountOut math.Int
+
+	Signature string
+	// LastValidBlockHeight is the block height after which the signed
+	// transaction can no longer land; zero when unknown
+	LastValidBlockHeight uint64
+	Slot                 uint64
+	// Fee is the network fee in lamports, set once confirmed
+	Fee uint64
+	// PriorityFee is the part of Fee paid above the base signature fee
+	PriorityFee uint64
+	// Tip is the lamports tipped to a block engine, if any
+	Tip uint64
+	// ProtocolFees are the venue trading fees charged per mint, where the venue reports them
+	ProtocolFees map[string]math.Int
+	Error        string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Pending reports whether the order was signed but its outcome is still unknown
+func (o *Order) Pending() bool {
+	return o.Status == StatusSigned || o.Status == StatusSent
+}
+
+// Filter selects orders when listing; zero fields match everything
+type Filter struct {
+	Status OrderStatus
+	Wallet string
+	Since  time.Time
+}
+
+// Store persists orders across restarts. SaveOrder inserts or replaces the
+// order with the same ID
+type Store interface {
+	SaveOrder(ctx context.Context, order *Order) error
+	GetOrder(ctx context.Context, id string) (*Order, error)
+	ListOrders(ctx context.Context, filter Filter) ([]*Order, error)
+}
+
+func (f Filter) matches(order *Order) bool {
+	if f.Status != "" && order.Status != f.Status {
+		return false
+	}
+	if f.Wallet != "" && order.Wallet != f.Wallet {
+		return false
+	}
+	if !f.Since.IsZero() && order.CreatedAt.Before(f.Since) {
+		return false
+	}
+	return true
+}
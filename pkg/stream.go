@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// StreamState is the bookkeeping of a StreamedPool: the accounts it follows,
+// the slot each was last updated at and whether the pool's position has
+// moved past them. Its methods expect the caller to hold the lock, which also
+// keeps updates out of a quote in progress
+type StreamState struct {
+	sync.Mutex
+	slots map[solana.PublicKey]streamSlot
+	stale bool
+}
+
+type streamSlot struct {
+	slot     uint64
+	received bool
+}
+
+// Follow starts following accounts, forgetting earlier updates; nothing is
+// trusted again until every account has been updated once
+func (s *StreamState) Follow(accounts []solana.PublicKey) {
+	s.slots = make(map[solana.PublicKey]streamSlot, len(accounts))
+	for _, account := range accounts {
+		s.slots[account] = streamSlot{}
+	}
+	s.stale = false
+}
+
+// Accept records an update of account at slot, reporting false for accounts
+// not followed and updates older than the last one applied
+func (s *StreamState) Accept(account solana.PublicKey, slot uint64) bool {
+	last, ok := s.slots[account]
+	if !ok || (last.received && slot < last.slot) {
+		return false
+	}
+	s.slots[account] = streamSlot{slot: slot, received: true}
+	return true
+}
+
+// Covers reports whether every one of accounts is followed
+func (s *StreamState) Covers(accounts []solana.PublicKey) bool {
+	for _, account := range accounts {
+		if _, ok := s.slots[account]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// MarkStale stops trusting the followed accounts until Follow is called again
+func (s *StreamState) MarkStale() {
+	s.stale = true
+}
+
+// Ready reports whether every followed account has been updated and the
+// pool has not moved past them
+func (s *StreamState) Ready() bool {
+	if s.stale || len(s.slots) == 0 {
+		return false
+	}
+	for _, last := range s.slots {
+		if !last.received {
+			return false
+		}
+	}
+	return true
+}
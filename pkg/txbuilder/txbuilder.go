@@ -0,0 +1,214 @@
+// Package txbuilder assembles swap transactions from instruction groups in a
+// canonical order, deduplicating repeated setup work across hops
+package txbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Group is an instruction group; groups are emitted in declaration order
+type Group int
+
+const (
+	GroupComputeBudget Group = iota
+	GroupSetup
+	GroupSwap
+	GroupTip
+	GroupCleanup
+
+	groupCount
+)
+
+func (g Group) String() string {
+	switch g {
+	case GroupComputeBudget:
+		return "compute_budget"
+	case GroupSetup:
+		return "setup"
+	case GroupSwap:
+		return "swap"
+	case GroupTip:
+		return "tip"
+	case GroupCleanup:
+		return "cleanup"
+	}
+	return fmt.Sprintf("group(%d)", int(g))
+}
+
+// ErrNoSwap is returned when a transaction is built without any swap instruction
+var ErrNoSwap = errors.New("transaction has no swap instructions")
+
+// Builder collects instructions per group. Identical setup and cleanup
+// instructions (for example the same ATA creation for two hops) are kept once,
+// and a later compute budget instruction replaces an earlier one of the same kind
+type Builder struct {
+	groups [groupCount][]solana.Instruction
+	seen   map[string]struct{}
+	err    error
+}
+
+// New creates an empty transaction builder
+func New() *Builder {
+	return &Builder{
+		seen: make(map[string]struct{}),
+	}
+}
+
+// Add appends instructions to a group
+func (b *Builder) Add(group Group, instructions ...solana.Instruction) *Builder {
+	if group < 0 || group >= groupCount {
+		b.setErr(fmt.Errorf("unknown instruction group %d", int(group)))
+		return b
+	}
+
+	for _, instruction := range instructions {
+		if instruction == nil {
+			continue
+		}
+		switch group {
+		case GroupComputeBudget:
+			b.addComputeBudget(instruction)
+		case GroupSetup, GroupCleanup:
+			key, err := instructionKey(instruction)
+			if err != nil {
+				b.setErr(err)
+				return b
+			}
+			if _, ok := b.seen[key]; ok {
+				continue
+			}
+			b.seen[key] = struct{}{}
+			b.groups[group] = append(b.groups[group], instruction)
+		default:
+			b.groups[group] = append(b.groups[group], instruction)
+		}
+	}
+	return b
+}
+
+// addComputeBudget keeps at most one compute budget instruction of each kind
+func (b *Builder) addComputeBudget(instruction solana.Instruction) {
+	if !instruction.ProgramID().Equals(solana.ComputeBudget) {
+		b.setErr(fmt.Errorf("instruction for program %s is not a compute budget instruction", instruction.ProgramID()))
+		return
+	}
+	data, err := instruction.Data()
+	if err != nil || len(data) == 0 {
+		b.setErr(fmt.Errorf("invalid compute budget instruction: %v", err))
+		return
+	}
+
+	existing := b.groups[GroupComputeBudget]
+	for i, other := range existing {
+		otherData, err := other.Data()
+		if err == nil && len(otherData) > 0 && otherData[0] == data[0] {
+			existing[i] = instruction
+			return
+		}
+	}
+	b.groups[GroupComputeBudget] = append(existing, instruction)
+}
+
+// Instructions returns every instruction in canonical group order
+func (b *Builder) Instructions() ([]solana.Instruction, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.groups[GroupSwap]) == 0 {
+		return nil, ErrNoSwap
+	}
+
+	instructions := make([]solana.Instruction, 0)
+	for _, group := range b.groups {
+		instructions = append(instructions, group...)
+	}
+	return instructions, nil
+}
+
+// Validate checks that every required signer is available and that no
+// invoked program is also requested as a writable account
+func (b *Builder) Validate(signers []solana.PublicKey) error {
+	instructions, err := b.Instructions()
+	if err != nil {
+		return err
+	}
+
+	available := make(map[solana.PublicKey]struct{}, len(signers))
+	for _, signer := range signers {
+		available[signer] = struct{}{}
+	}
+	programs := make(map[solana.PublicKey]struct{})
+	for _, instruction := range instructions {
+		programs[instruction.ProgramID()] = struct{}{}
+	}
+
+	for i, instruction := range instructions {
+		for _, account := range instruction.Accounts() {
+			if account.IsSigner {
+				if _, ok := available[account.PublicKey]; !ok {
+					return fmt.Errorf("instruction %d (%s) requires missing signer %s", i, instruction.ProgramID(), account.PublicKey)
+				}
+			}
+			if account.IsWritable {
+				if _, ok := programs[account.PublicKey]; ok {
+					return fmt.Errorf("instruction %d (%s) marks program %s writable", i, instruction.ProgramID(), account.PublicKey)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Sign validates the instructions against the signers and signs the transaction.
+// The first signer pays the fees
+func (b *Builder) Sign(ctx context.Context, solClient *sol.Client, signers []sol.Signer) (*solana.Transaction, error) {
+	publicKeys := make([]solana.PublicKey, 0, len(signers))
+	for _, signer := range signers {
+		publicKeys = append(publicKeys, signer.PublicKey())
+	}
+	if err := b.Validate(publicKeys); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+
+	instructions, err := b.Instructions()
+	if err != nil {
+		return nil, err
+	}
+	return solClient.SignTransaction(ctx, signers, instructions...)
+}
+
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// instructionKey identifies an instruction by program, accounts and data
+func instructionKey(instruction solana.Instruction) (string, error) {
+	data, err := instruction.Data()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode instruction data: %w", err)
+	}
+
+	var key strings.Builder
+	key.Write(instruction.ProgramID().Bytes())
+	for _, account := range instruction.Accounts() {
+		key.Write(account.PublicKey.Bytes())
+		flags := byte(0)
+		if account.IsSigner {
+			flags |= 1
+		}
+		if account.IsWritable {
+			flags |= 2
+		}
+		key.WriteByte(flags)
+	}
+	key.Write(data)
+	return key.String(), nil
+}
@@ -0,0 +1,271 @@
+// Package vcr records JSON-RPC traffic to cassette files and replays it, so
+// discovery and quoting code can run deterministically against captured
+// mainnet responses without a live endpoint
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode selects whether a Recorder talks to the network
+type Mode int
+
+const (
+	// ModeReplay serves every request from the cassette and fails on a miss
+	ModeReplay Mode = iota
+	// ModeRecord forwards every request and rewrites the cassette on Save
+	ModeRecord
+	// ModeAuto replays when the cassette exists and records otherwise
+	ModeAuto
+)
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	// Request is the JSON-RPC body with ids removed
+	Request  json.RawMessage `json:"request"`
+	Status   int             `json:"status"`
+	Response string          `json:"response"`
+}
+
+// Cassette is the on-disk list of interactions, in recording order
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records to or replays from a
+// cassette. Requests are matched on their body with JSON-RPC ids stripped,
+// so the endpoint URL and any API key in it are never written to disk.
+// Identical requests replay their recorded responses in order and then keep
+// returning the last one, which suits polling loops
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	replay   map[string][]Interaction
+	served   map[string]int
+}
+
+// New opens the cassette at path. transport performs real requests while
+// recording; nil uses http.DefaultTransport
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: transport,
+		replay:    make(map[string][]Interaction),
+		served:    make(map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case r.mode == ModeRecord:
+		// recording starts from an empty cassette
+	case err == nil:
+		var cassette Cassette
+		if err := json.Unmarshal(data, &cassette); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+		}
+		if err := r.load(cassette); err != nil {
+			return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+		}
+		r.mode = ModeReplay
+	case os.IsNotExist(err) && r.mode == ModeAuto:
+		r.mode = ModeRecord
+	default:
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// NewReplay serves requests from an in-memory cassette, for cassettes stored
+// inside other documents rather than in their own file
+func NewReplay(cassette Cassette) (*Recorder, error) {
+	r := &Recorder{
+		mode:   ModeReplay,
+		replay: make(map[string][]Interaction),
+		served: make(map[string]int),
+	}
+	if err := r.load(cassette); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load indexes cassette's interactions for replay
+func (r *Recorder) load(cassette Cassette) error {
+	r.cassette = cassette
+	for _, interaction := range cassette.Interactions {
+		// cassettes are stored indented, so keys are canonicalized again
+		key, err := requestKey(interaction.Request)
+		if err != nil {
+			return fmt.Errorf("invalid request: %w", err)
+		}
+		r.replay[key] = append(r.replay[key], interaction)
+	}
+	return nil
+}
+
+// Cassette returns a copy of the interactions recorded or loaded so far
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Recording reports whether the recorder forwards requests to the network
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Client returns an http.Client that goes through the recorder
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	key, err := requestKey(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode != ModeRecord {
+		interaction, err := r.next(key)
+		if err != nil {
+			return nil, err
+		}
+		return response(req, interaction.Status, withRequestID(body, []byte(interaction.Response))), nil
+	}
+
+	forward := req.Clone(req.Context())
+	forward.Body = io.NopCloser(bytes.NewReader(body))
+	forward.ContentLength = int64(len(body))
+	resp, err := r.transport.RoundTrip(forward)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  json.RawMessage(key),
+		Status:   resp.StatusCode,
+		Response: string(respBody),
+	})
+	r.mu.Unlock()
+	return response(req, resp.StatusCode, respBody), nil
+}
+
+// Save writes the recorded interactions to the cassette; it does nothing
+// when replaying
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// next returns the recorded interaction to serve for key
+func (r *Recorder) next(key string) (Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recorded := r.replay[key]
+	if len(recorded) == 0 {
+		return Interaction{}, fmt.Errorf("no recorded interaction in %s for request %s", r.path, key)
+	}
+	i := min(r.served[key], len(recorded)-1)
+	r.served[key]++
+	return recorded[i], nil
+}
+
+// requestKey canonicalizes a JSON-RPC body (single or batch) without its ids
+func requestKey(body []byte) (string, error) {
+	var decoded any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return "", fmt.Errorf("request is not JSON-RPC: %w", err)
+	}
+	// batch ids are array positions and stay in the key so responses line up
+	if single, ok := decoded.(map[string]any); ok {
+		delete(single, "id")
+	}
+	key, err := json.Marshal(decoded)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// withRequestID copies the id of a single request into its replayed response
+func withRequestID(request, response []byte) []byte {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(request, &req); err != nil || req["id"] == nil {
+		return response
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return response
+	}
+	resp["id"] = req["id"]
+	rewritten, err := json.Marshal(resp)
+	if err != nil {
+		return response
+	}
+	return rewritten
+}
+
+func response(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
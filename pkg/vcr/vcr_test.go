@@ -0,0 +1,142 @@
+package vcr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// rpcServer answers each JSON-RPC request with the number of requests so far
+func rpcServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		resp, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": calls.Add(1)})
+		w.Write(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// call posts body through client and returns the decoded id and result
+func call(t *testing.T, client *http.Client, url, body string) (json.RawMessage, int) {
+	t.Helper()
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		ID     json.RawMessage `json:"id"`
+		Result int             `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("failed to decode response %s: %v", raw, err)
+	}
+	return decoded.ID, decoded.Result
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	server, calls := rpcServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := New(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := recorder.Client()
+	call(t, client, server.URL, `{"jsonrpc":"2.0","id":1,"method":"getSlot"}`)
+	call(t, client, server.URL, `{"jsonrpc":"2.0","id":2,"method":"getSlot"}`)
+	call(t, client, server.URL, `{"jsonrpc":"2.0","id":3,"method":"getBalance","params":["a"]}`)
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(recorder.Cassette().Interactions); got != 3 {
+		t.Fatalf("recorded %d interactions, want 3", got)
+	}
+
+	replay, err := New(path, ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Recording() {
+		t.Fatal("an existing cassette was recorded over")
+	}
+	client = replay.Client()
+	// the endpoint is never dialed, so the URL does not need to match
+	url := "http://replay.invalid"
+
+	// identical requests replay in order, then keep the last response
+	for i, want := range []int{1, 2, 2} {
+		id, result := call(t, client, url, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"getSlot"}`, 100+i))
+		if result != want {
+			t.Fatalf("getSlot %d replayed %d, want %d", i, result, want)
+		}
+		if string(id) != fmt.Sprint(100+i) {
+			t.Fatalf("replayed id %s, want the request's %d", id, 100+i)
+		}
+	}
+	// keys are matched regardless of field order
+	if _, result := call(t, client, url, `{"params":["a"],"method":"getBalance","id":9,"jsonrpc":"2.0"}`); result != 3 {
+		t.Fatalf("getBalance replayed %d, want 3", result)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("server saw %d requests, want the 3 recorded", calls.Load())
+	}
+
+	if _, err := client.Post(url, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"getBalance","params":["b"]}`)); err == nil {
+		t.Fatal("a request missing from the cassette was served")
+	}
+}
+
+func TestNewMissingCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if _, err := New(path, ModeReplay, nil); err == nil {
+		t.Fatal("replaying a missing cassette succeeded")
+	}
+	recorder, err := New(path, ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recorder.Recording() {
+		t.Fatal("a missing cassette was not recorded")
+	}
+}
+
+func TestNewReplay(t *testing.T) {
+	recorder, err := NewReplay(Cassette{Interactions: []Interaction{{
+		Request:  json.RawMessage(`{"jsonrpc": "2.0", "method": "getSlot"}`),
+		Status:   http.StatusOK,
+		Response: `{"jsonrpc":"2.0","id":0,"result":42}`,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, result := call(t, recorder.Client(), "http://replay.invalid", `{"jsonrpc":"2.0","id":7,"method":"getSlot"}`)
+	if result != 42 || string(id) != "7" {
+		t.Fatalf("replayed result %d with id %s, want 42 with id 7", result, id)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("saving a replay wrote to disk: %v", err)
+	}
+
+	if _, err := NewReplay(Cassette{Interactions: []Interaction{{Request: json.RawMessage(`not json`)}}}); err == nil {
+		t.Fatal("a cassette with an invalid request loaded")
+	}
+}
@@ -0,0 +1,65 @@
+// Package aldrin quotes and builds swaps on Aldrin AMM v2 pools, which trade
+// a base and a quote token on a constant product or a stable curve. Only
+// constant product pools are quoted
+package aldrin
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+)
+
+// Name is the protocol name of Aldrin AMM v2 pools
+const Name pkg.ProtocolName = "aldrin_v2"
+
+var (
+	// ProgramID is the Aldrin AMM v2 program
+	ProgramID = solana.MustPublicKeyFromBase58("CURVGoZn8zycx6FXwwevgBTB2gVvdbGTEpvMJDbgs2t4")
+
+	// PoolDiscriminator prefixes pool accounts
+	PoolDiscriminator = anchor.GetDiscriminator("account", "Pool")
+	// SwapDiscriminator prefixes the swap instruction
+	SwapDiscriminator = anchor.GetDiscriminator("global", "swap")
+)
+
+// Aldrin v2 pool account layout
+const (
+	PoolSize        = 474
+	BaseMintOffset  = 297
+	QuoteMintOffset = 361
+
+	poolMintOffset         = 40
+	poolSignerOffset       = 72
+	feePoolTokenOffset     = 233
+	baseVaultOffset        = 265
+	quoteVaultOffset       = 329
+	tradeFeeNumOffset      = 393
+	tradeFeeDenOffset      = 401
+	ownerTradeFeeNumOffset = 409
+	ownerTradeFeeDenOffset = 417
+	curveTypeOffset        = 441
+	curveOffset            = 442
+)
+
+// Curve types of a pool
+const (
+	CurveConstantProduct uint8 = 0
+	CurveStable          uint8 = 1
+)
+
+// Swap instruction data: discriminator, tokens, min_tokens and side
+const (
+	swapDataSize     = 25
+	swapMinOutOffset = 16
+	swapSideOffset   = 24
+)
+
+// Side is the direction of a swap, seen from the base token
+type Side uint8
+
+const (
+	// SideBid buys the base token with the quote token
+	SideBid Side = 0
+	// SideAsk sells the base token for the quote token
+	SideAsk Side = 1
+)
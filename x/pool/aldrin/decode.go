@@ -0,0 +1,43 @@
+package aldrin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses an Aldrin v2 swap. The side tells which of the user's
+// base and quote accounts pays; the mints are not named, so they are left zero
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 11); err != nil {
+		return nil, err
+	}
+	side := Side(data[swapSideOffset])
+	if side != SideBid && side != SideAsk {
+		return nil, fmt.Errorf("invalid side %d", side)
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          Name,
+		Pool:              accounts[0].PublicKey,
+		User:              accounts[6].PublicKey,
+		UserInputAccount:  accounts[8].PublicKey,
+		UserOutputAccount: accounts[7].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapMinOutOffset:])),
+	}
+	if side == SideAsk {
+		params.UserInputAccount, params.UserOutputAccount = accounts[7].PublicKey, accounts[8].PublicKey
+	}
+	return params, nil
+}
@@ -0,0 +1,316 @@
+package aldrin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// AldrinPool is an Aldrin AMM v2 pool. Swaps pay the trade fee and the
+// owner's trade fee out of the input before it meets the curve; the owner's
+// share is minted to FeePoolTokenAccount as pool tokens
+type AldrinPool struct {
+	PoolId              solana.PublicKey
+	PoolMint            solana.PublicKey
+	PoolSigner          solana.PublicKey
+	FeePoolTokenAccount solana.PublicKey
+	BaseVault           solana.PublicKey
+	BaseMint            solana.PublicKey
+	QuoteVault          solana.PublicKey
+	QuoteMint           solana.PublicKey
+
+	TradeFeeNumerator        uint64
+	TradeFeeDenominator      uint64
+	OwnerTradeFeeNumerator   uint64
+	OwnerTradeFeeDenominator uint64
+	// CurveType is CurveConstantProduct or CurveStable; Curve holds the
+	// parameters of a stable curve
+	CurveType uint8
+	Curve     solana.PublicKey
+
+	// BaseReserve and QuoteReserve are the vault balances of the last quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+	// decimals of the base and quote mints, nil until a quote loads them
+	decimals *[2]uint8
+}
+
+func (pool *AldrinPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *AldrinPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *AldrinPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+func (pool *AldrinPool) GetTokens() (string, string) {
+	return pool.BaseMint.String(), pool.QuoteMint.String()
+}
+
+// Decode parses an Aldrin v2 pool account
+func (pool *AldrinPool) Decode(data []byte) error {
+	if len(data) < PoolSize {
+		return fmt.Errorf("aldrin pool account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, PoolDiscriminator) {
+		return fmt.Errorf("not an aldrin pool account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.PoolMint = key(poolMintOffset)
+	pool.PoolSigner = key(poolSignerOffset)
+	pool.FeePoolTokenAccount = key(feePoolTokenOffset)
+	pool.BaseVault = key(baseVaultOffset)
+	pool.BaseMint = key(BaseMintOffset)
+	pool.QuoteVault = key(quoteVaultOffset)
+	pool.QuoteMint = key(QuoteMintOffset)
+	pool.TradeFeeNumerator = u64(tradeFeeNumOffset)
+	pool.TradeFeeDenominator = u64(tradeFeeDenOffset)
+	pool.OwnerTradeFeeNumerator = u64(ownerTradeFeeNumOffset)
+	pool.OwnerTradeFeeDenominator = u64(ownerTradeFeeDenOffset)
+	pool.CurveType = data[curveTypeOffset]
+	pool.Curve = key(curveOffset)
+	for _, fee := range [][2]uint64{
+		{pool.TradeFeeNumerator, pool.TradeFeeDenominator},
+		{pool.OwnerTradeFeeNumerator, pool.OwnerTradeFeeDenominator},
+	} {
+		if fee[1] != 0 && fee[0] >= fee[1] {
+			return fmt.Errorf("invalid trade fee %d/%d", fee[0], fee[1])
+		}
+	}
+	return nil
+}
+
+// Quotable returns an error for pools whose curve is not quoted
+func (pool *AldrinPool) Quotable() error {
+	if pool.CurveType != CurveConstantProduct {
+		return fmt.Errorf("aldrin pool %s has curve type %d, only constant product pools are quoted", pool.PoolId, pool.CurveType)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the reserves and decimals of the last quote
+func (pool *AldrinPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*AldrinPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	base, quote, decimals := pool.BaseReserve, pool.QuoteReserve, pool.decimals
+	*pool = *fresh
+	pool.BaseReserve, pool.QuoteReserve, pool.decimals = base, quote, decimals
+	return true
+}
+
+// MintDecimals returns the decimals of mint loaded by the last Quote
+func (pool *AldrinPool) MintDecimals(mint string) (uint8, bool) {
+	if pool.decimals == nil {
+		return 0, false
+	}
+	switch mint {
+	case pool.BaseMint.String():
+		return pool.decimals[0], true
+	case pool.QuoteMint.String():
+		return pool.decimals[1], true
+	}
+	return 0, false
+}
+
+// Quote refreshes the pool, its vault balances and the mints' decimals in
+// one batch and returns the output for inputAmount
+func (pool *AldrinPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{pool.PoolId, pool.BaseVault, pool.QuoteVault, pool.BaseMint, pool.QuoteMint}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode aldrin pool %s: %w", pool.PoolId, err)
+		}
+	}
+	if err := pool.Quotable(); err != nil {
+		return math.ZeroInt(), err
+	}
+	baseReserve, ok := sol.TokenAccountAmount(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("base vault %s not found", pool.BaseVault)
+	}
+	quoteReserve, ok := sol.TokenAccountAmount(results, 2)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("quote vault %s not found", pool.QuoteVault)
+	}
+	pool.BaseReserve = math.NewIntFromUint64(baseReserve)
+	pool.QuoteReserve = math.NewIntFromUint64(quoteReserve)
+	var decimals [2]uint8
+	for i := 0; i < 2; i++ {
+		d, ok := sol.MintDecimals(results, 3+i)
+		if !ok {
+			return math.ZeroInt(), fmt.Errorf("mint %s not found", accounts[3+i])
+		}
+		decimals[i] = d
+	}
+	pool.decimals = &decimals
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// reserves returns the input and output reserves for inputMint
+func (pool *AldrinPool) reserves(inputMint string) (math.Int, math.Int, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return math.Int{}, math.Int{}, fmt.Errorf("pool state not loaded")
+	}
+	switch inputMint {
+	case pool.BaseMint.String():
+		return pool.BaseReserve, pool.QuoteReserve, nil
+	case pool.QuoteMint.String():
+		return pool.QuoteReserve, pool.BaseReserve, nil
+	}
+	return math.Int{}, math.Int{}, fmt.Errorf("mint %s is not traded by aldrin pool %s", inputMint, pool.PoolId)
+}
+
+// ComputeAmountOut takes both trade fees off inputAmount and prices the
+// rest on the constant product of the cached reserves, rounded down
+func (pool *AldrinPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if err := pool.Quotable(); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, reserveOut, err := pool.reserves(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if !reserveIn.IsPositive() || !reserveOut.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("aldrin pool %s has an empty reserve", pool.PoolId)
+	}
+	amount := inputAmount.Sub(pool.SwapFee(inputMint, inputAmount))
+	if !amount.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("amount %s does not cover the trade fee", inputAmount)
+	}
+	amountOut := reserveOut.Mul(amount).Quo(reserveIn.Add(amount))
+	if !amountOut.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("swap of %s is too small", inputAmount)
+	}
+	return amountOut, nil
+}
+
+// SwapFee returns the trade fee and the owner's trade fee charged on inputAmount
+func (pool *AldrinPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	fee := func(numerator, denominator uint64) *big.Int {
+		if denominator == 0 {
+			return new(big.Int)
+		}
+		amount := new(big.Int).Mul(inputAmount.BigInt(), new(big.Int).SetUint64(numerator))
+		return amount.Quo(amount, new(big.Int).SetUint64(denominator))
+	}
+	total := fee(pool.TradeFeeNumerator, pool.TradeFeeDenominator)
+	total.Add(total, fee(pool.OwnerTradeFeeNumerator, pool.OwnerTradeFeeDenominator))
+	return math.NewIntFromBigInt(total)
+}
+
+// Reserves returns the base and quote vault balances cached by the last Quote
+func (pool *AldrinPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the ratio of the cached reserves
+func (pool *AldrinPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return math.LegacyDec{}, fmt.Errorf("pool state not loaded")
+	}
+	return pkg.PriceFromReserves(pool.BaseReserve, pool.QuoteReserve, inputMint == pool.BaseMint.String())
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price, grossed up by both trade fees
+func (pool *AldrinPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if err := pool.Quotable(); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, _, err := pool.reserves(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	bps := big.NewInt(int64(maxImpactBps))
+	amount := new(big.Int).Mul(reserveIn.BigInt(), bps)
+	amount.Quo(amount, new(big.Int).Sub(big.NewInt(10000), bps))
+	// the fees take a share of every input, so the gross input is the net
+	// one over the share left
+	unit := math.NewInt(1_000_000_000)
+	kept := unit.Sub(pool.SwapFee(inputMint, unit))
+	if !kept.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("aldrin pool %s keeps no input after fees", pool.PoolId)
+	}
+	amount.Mul(amount, unit.BigInt())
+	amount.Quo(amount, kept.BigInt())
+	return math.NewIntFromBigInt(amount), nil
+}
+
+// BuildSwapInstructions builds an Aldrin v2 swap: an ask when inputMint is
+// the base mint, a bid when it is the quote mint
+func (pool *AldrinPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	side := SideBid
+	if inputMint == pool.BaseMint.String() {
+		side = SideAsk
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(pool.PoolId),
+		solana.Meta(pool.PoolSigner),
+		solana.Meta(pool.PoolMint).WRITE(),
+		solana.Meta(pool.BaseVault).WRITE(),
+		solana.Meta(pool.QuoteVault).WRITE(),
+		solana.Meta(pool.FeePoolTokenAccount).WRITE(),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(userBaseAccount).WRITE(),
+		solana.Meta(userQuoteAccount).WRITE(),
+		solana.Meta(pool.Curve),
+		solana.Meta(solana.TokenProgramID),
+	}
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, SwapDiscriminator...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	data = append(data, byte(side))
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads min_tokens back from the swap instruction
+func (pool *AldrinPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, SwapDiscriminator, swapMinOutOffset)
+}
+
+// SwapAmountFields locates tokens and min_tokens in the swap instruction
+func (pool *AldrinPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SwapDiscriminator, Offset: 8},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SwapDiscriminator, Offset: swapMinOutOffset}
+}
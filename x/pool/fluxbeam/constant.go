@@ -0,0 +1,56 @@
+// Package fluxbeam quotes and builds swaps on FluxBeam, a fork of the SPL
+// token swap program that accepts Token-2022 mints. Only constant product
+// pools are quoted, net of the mints' transfer fees
+package fluxbeam
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// Name is the protocol name of FluxBeam pools
+const Name pkg.ProtocolName = "fluxbeam"
+
+var (
+	// ProgramID is the FluxBeam token swap program
+	ProgramID = solana.MustPublicKeyFromBase58("FLUXubRmkEi2q6K3Y9kBPg9248ggaZVsoSFhtJHSrm1X")
+
+	// SwapTag leads a swap instruction, followed by amount_in and
+	// minimum_amount_out
+	SwapTag = []byte{1}
+)
+
+// FluxBeam swap account layout, that of an SPL token swap v1
+const (
+	SwapSize         = 324
+	TokenAMintOffset = 131
+	TokenBMintOffset = 163
+
+	versionOffset                 = 0
+	isInitializedOffset           = 1
+	bumpSeedOffset                = 2
+	tokenProgramOffset            = 3
+	tokenAOffset                  = 35
+	tokenBOffset                  = 67
+	poolMintOffset                = 99
+	poolFeeAccountOffset          = 195
+	tradeFeeNumeratorOffset       = 227
+	tradeFeeDenomOffset           = 235
+	ownerTradeFeeNumOffset        = 243
+	ownerTradeFeeDenomOffset      = 251
+	curveTypeOffset               = 291
+	swapVersion              byte = 1
+)
+
+// Curve types of a swap
+const (
+	CurveConstantProduct uint8 = 0
+	CurveConstantPrice   uint8 = 1
+	CurveOffset          uint8 = 3
+)
+
+// Swap instruction data: the tag, amount_in and minimum_amount_out
+const (
+	swapDataSize     = 17
+	swapMinOutOffset = 9
+)
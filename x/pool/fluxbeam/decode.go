@@ -0,0 +1,38 @@
+package fluxbeam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a FluxBeam swap instruction, whose source and
+// destination mints are named among its accounts
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapTag) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 14); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          Name,
+		Pool:              accounts[0].PublicKey,
+		User:              accounts[2].PublicKey,
+		UserInputAccount:  accounts[3].PublicKey,
+		UserOutputAccount: accounts[6].PublicKey,
+		InputMint:         accounts[9].PublicKey,
+		OutputMint:        accounts[10].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[1:9])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapMinOutOffset:])),
+	}
+	return params, nil
+}
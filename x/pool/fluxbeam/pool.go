@@ -0,0 +1,441 @@
+package fluxbeam
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// FluxBeamPool is a FluxBeam token swap. Token A and token B are the base and
+// quote mints, either of which may be a Token-2022 mint with a transfer fee
+type FluxBeamPool struct {
+	PoolId     solana.PublicKey
+	TokenAMint solana.PublicKey
+	TokenBMint solana.PublicKey
+	TokenA     solana.PublicKey
+	TokenB     solana.PublicKey
+	PoolMint   solana.PublicKey
+	// PoolFeeAccount receives the owner's trade fee as pool tokens
+	PoolFeeAccount solana.PublicKey
+	// PoolTokenProgram owns the pool mint
+	PoolTokenProgram solana.PublicKey
+	BumpSeed         uint8
+
+	TradeFeeNumerator        uint64
+	TradeFeeDenominator      uint64
+	OwnerTradeFeeNumerator   uint64
+	OwnerTradeFeeDenominator uint64
+	CurveType                uint8
+
+	// BaseReserve and QuoteReserve are the token A and B balances of the last quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+	mints        *fluxBeamMints
+}
+
+// fluxBeamMints is what the pool knows of its mints from the last quote: the
+// token program owning each, their decimals, their Token-2022 transfer fee if
+// any, and the epoch the fees apply in
+type fluxBeamMints struct {
+	programs [2]solana.PublicKey
+	decimals [2]uint8
+	fees     [2]*sol.TransferFeeConfig
+	epoch    uint64
+}
+
+func (pool *FluxBeamPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *FluxBeamPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *FluxBeamPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token A as base and token B as quote
+func (pool *FluxBeamPool) GetTokens() (string, string) {
+	return pool.TokenAMint.String(), pool.TokenBMint.String()
+}
+
+// Decode parses a FluxBeam swap account
+func (pool *FluxBeamPool) Decode(data []byte) error {
+	if len(data) < SwapSize {
+		return fmt.Errorf("fluxbeam swap account too short: %d bytes", len(data))
+	}
+	if data[versionOffset] != swapVersion {
+		return fmt.Errorf("unsupported fluxbeam swap version %d", data[versionOffset])
+	}
+	if data[isInitializedOffset] == 0 {
+		return fmt.Errorf("fluxbeam swap is not initialized")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.BumpSeed = data[bumpSeedOffset]
+	pool.PoolTokenProgram = key(tokenProgramOffset)
+	pool.TokenA = key(tokenAOffset)
+	pool.TokenB = key(tokenBOffset)
+	pool.PoolMint = key(poolMintOffset)
+	pool.TokenAMint = key(TokenAMintOffset)
+	pool.TokenBMint = key(TokenBMintOffset)
+	pool.PoolFeeAccount = key(poolFeeAccountOffset)
+	pool.TradeFeeNumerator = u64(tradeFeeNumeratorOffset)
+	pool.TradeFeeDenominator = u64(tradeFeeDenomOffset)
+	pool.OwnerTradeFeeNumerator = u64(ownerTradeFeeNumOffset)
+	pool.OwnerTradeFeeDenominator = u64(ownerTradeFeeDenomOffset)
+	pool.CurveType = data[curveTypeOffset]
+	for _, fee := range [][2]uint64{
+		{pool.TradeFeeNumerator, pool.TradeFeeDenominator},
+		{pool.OwnerTradeFeeNumerator, pool.OwnerTradeFeeDenominator},
+	} {
+		if fee[0] != 0 && fee[0] >= fee[1] {
+			return fmt.Errorf("invalid trade fee %d/%d", fee[0], fee[1])
+		}
+	}
+	return nil
+}
+
+// Quotable returns an error for swaps whose curve is not quoted
+func (pool *FluxBeamPool) Quotable() error {
+	if pool.CurveType != CurveConstantProduct {
+		return fmt.Errorf("fluxbeam swap %s has curve type %d, only constant product swaps are quoted", pool.PoolId, pool.CurveType)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the reserves and mints of the last quote
+func (pool *FluxBeamPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*FluxBeamPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	base, quote, mints := pool.BaseReserve, pool.QuoteReserve, pool.mints
+	*pool = *fresh
+	pool.BaseReserve, pool.QuoteReserve, pool.mints = base, quote, mints
+	return true
+}
+
+// MintDecimals returns the decimals of mint loaded by the last Quote
+func (pool *FluxBeamPool) MintDecimals(mint string) (uint8, bool) {
+	if pool.mints == nil {
+		return 0, false
+	}
+	switch mint {
+	case pool.TokenAMint.String():
+		return pool.mints.decimals[0], true
+	case pool.TokenBMint.String():
+		return pool.mints.decimals[1], true
+	}
+	return 0, false
+}
+
+// mintAccounts are fetched along with the swap on every quote: both mints
+// and the clock, whose epoch selects the transfer fee in force
+func (pool *FluxBeamPool) mintAccounts() []solana.PublicKey {
+	return []solana.PublicKey{pool.TokenAMint, pool.TokenBMint, solana.SysVarClockPubkey}
+}
+
+// loadMints reads the accounts of mintAccounts from results starting at offset
+func (pool *FluxBeamPool) loadMints(results *rpc.GetMultipleAccountsResult, offset int) error {
+	mints := &fluxBeamMints{}
+	for i := 0; i < 2; i++ {
+		decimals, ok := sol.MintDecimals(results, offset+i)
+		if !ok {
+			return fmt.Errorf("mint %s not found", pool.mintAccounts()[i])
+		}
+		account := results.Value[offset+i]
+		mints.programs[i] = account.Owner
+		mints.decimals[i] = decimals
+		if account.Owner.Equals(solana.Token2022ProgramID) {
+			if config, ok := sol.ParseTransferFeeConfig(account.Data.GetBinary()); ok {
+				mints.fees[i] = config
+			}
+		}
+	}
+	data, ok := sol.AccountData(results, offset+2)
+	if !ok {
+		return fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return err
+	}
+	mints.epoch = clock.Epoch
+	pool.mints = mints
+	return nil
+}
+
+// Quote refreshes the swap, its reserves, both mints and the clock in one
+// batch and returns what the user receives for inputAmount, net of the
+// trade fees and of Token-2022 transfer fees on the input and the output
+func (pool *FluxBeamPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := append([]solana.PublicKey{pool.PoolId, pool.TokenA, pool.TokenB}, pool.mintAccounts()...)
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a swap missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode fluxbeam swap %s: %w", pool.PoolId, err)
+		}
+	}
+	if err := pool.Quotable(); err != nil {
+		return math.ZeroInt(), err
+	}
+	baseReserve, ok := sol.TokenAccountAmount(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("token A account %s not found", pool.TokenA)
+	}
+	quoteReserve, ok := sol.TokenAccountAmount(results, 2)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("token B account %s not found", pool.TokenB)
+	}
+	pool.BaseReserve = math.NewIntFromUint64(baseReserve)
+	pool.QuoteReserve = math.NewIntFromUint64(quoteReserve)
+	if err := pool.loadMints(results, 3); err != nil {
+		return math.ZeroInt(), err
+	}
+
+	outputMint := pool.TokenBMint.String()
+	if inputMint == pool.TokenBMint.String() {
+		outputMint = pool.TokenAMint.String()
+	}
+	curveIn := inputAmount.Sub(pool.transferFee(inputMint, inputAmount))
+	if !curveIn.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("amount %s does not cover the transfer fee", inputAmount)
+	}
+	amountOut, err := pool.ComputeAmountOut(inputMint, curveIn)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return amountOut.Sub(pool.transferFee(outputMint, amountOut)), nil
+}
+
+// transferFee returns the Token-2022 fee on a transfer of amount of mint,
+// zero for mints without one
+func (pool *FluxBeamPool) transferFee(mint string, amount math.Int) math.Int {
+	if pool.mints == nil {
+		return math.ZeroInt()
+	}
+	i := 0
+	if mint == pool.TokenBMint.String() {
+		i = 1
+	}
+	return pool.mints.fees[i].Fee(pool.mints.epoch, amount)
+}
+
+// tokenProgram returns the token program of mint i, 0 for token A, as seen
+// by the last quote
+func (pool *FluxBeamPool) tokenProgram(i int) solana.PublicKey {
+	if pool.mints == nil || pool.mints.programs[i].IsZero() {
+		return solana.TokenProgramID
+	}
+	return pool.mints.programs[i]
+}
+
+// reserves returns the input and output reserves for inputMint
+func (pool *FluxBeamPool) reserves(inputMint string) (*big.Int, *big.Int, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return nil, nil, fmt.Errorf("pool state not loaded")
+	}
+	switch inputMint {
+	case pool.TokenAMint.String():
+		return pool.BaseReserve.BigInt(), pool.QuoteReserve.BigInt(), nil
+	case pool.TokenBMint.String():
+		return pool.QuoteReserve.BigInt(), pool.BaseReserve.BigInt(), nil
+	}
+	return nil, nil, fmt.Errorf("mint %s is not traded by fluxbeam swap %s", inputMint, pool.PoolId)
+}
+
+// tradingFee is the token swap fee on amount: rounded down, but at least one
+// unit for a non-zero fee
+func tradingFee(amount *big.Int, numerator, denominator uint64) *big.Int {
+	if numerator == 0 || denominator == 0 || amount.Sign() == 0 {
+		return new(big.Int)
+	}
+	fee := new(big.Int).Mul(amount, new(big.Int).SetUint64(numerator))
+	fee.Quo(fee, new(big.Int).SetUint64(denominator))
+	if fee.Sign() == 0 {
+		fee.SetInt64(1)
+	}
+	return fee
+}
+
+// ComputeAmountOut prices inputAmount, as it reaches the swap, against the
+// cached reserves before transfer fees. Both trade fees come off the input;
+// the rest trades on the constant product, whose new output reserve is
+// rounded up as the program does
+func (pool *FluxBeamPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if err := pool.Quotable(); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, reserveOut, err := pool.reserves(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("fluxbeam swap %s has an empty reserve", pool.PoolId)
+	}
+	amount := new(big.Int).Sub(inputAmount.BigInt(), pool.SwapFee(inputMint, inputAmount).BigInt())
+	if amount.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("amount %s does not cover the trade fee", inputAmount)
+	}
+	invariant := new(big.Int).Mul(reserveIn, reserveOut)
+	newReserveIn := new(big.Int).Add(reserveIn, amount)
+	newReserveOut, remainder := new(big.Int).QuoRem(invariant, newReserveIn, new(big.Int))
+	if remainder.Sign() > 0 {
+		newReserveOut.Add(newReserveOut, big.NewInt(1))
+	}
+	amountOut := newReserveOut.Sub(reserveOut, newReserveOut)
+	if amountOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("swap of %s is too small", inputAmount)
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// SwapFee returns the trade fee and the owner's trade fee charged on inputAmount
+func (pool *FluxBeamPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	amount := inputAmount.BigInt()
+	fee := tradingFee(amount, pool.TradeFeeNumerator, pool.TradeFeeDenominator)
+	fee.Add(fee, tradingFee(amount, pool.OwnerTradeFeeNumerator, pool.OwnerTradeFeeDenominator))
+	return math.NewIntFromBigInt(fee)
+}
+
+// Reserves returns the token A and B balances cached by the last Quote
+func (pool *FluxBeamPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the ratio of the cached reserves
+func (pool *FluxBeamPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return math.LegacyDec{}, fmt.Errorf("pool state not loaded")
+	}
+	return pkg.PriceFromReserves(pool.BaseReserve, pool.QuoteReserve, inputMint == pool.TokenAMint.String())
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price, grossed up by both trade fees.
+// Transfer fees are proportional costs, not impact, and are left out
+func (pool *FluxBeamPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	if err := pool.Quotable(); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, _, err := pool.reserves(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	bps := big.NewInt(int64(maxImpactBps))
+	amount := new(big.Int).Mul(reserveIn, bps)
+	amount.Quo(amount, new(big.Int).Sub(big.NewInt(10000), bps))
+	unit := math.NewInt(1_000_000_000)
+	kept := unit.Sub(pool.SwapFee(inputMint, unit))
+	if !kept.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("fluxbeam swap %s keeps no input after fees", pool.PoolId)
+	}
+	amount.Mul(amount, unit.BigInt())
+	amount.Quo(amount, kept.BigInt())
+	return math.NewIntFromBigInt(amount), nil
+}
+
+// Authority derives the swap authority that owns the token accounts from
+// the bump seed stored in the swap
+func (pool *FluxBeamPool) Authority() (solana.PublicKey, error) {
+	authority, err := solana.CreateProgramAddress([][]byte{pool.PoolId.Bytes(), {pool.BumpSeed}}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive swap authority: %w", err)
+	}
+	return authority, nil
+}
+
+// BuildSwapInstructions builds a FluxBeam swap naming both mints and their
+// token programs, which Token-2022 transfers need. The mints' programs are
+// fetched when no quote has loaded them
+func (pool *FluxBeamPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	if pool.mints == nil {
+		results, err := solClient.GetMultipleAccountsWithOpts(ctx, pool.mintAccounts())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch swap mints: %w", err)
+		}
+		if err := pool.loadMints(results, 0); err != nil {
+			return nil, err
+		}
+	}
+	authority, err := pool.Authority()
+	if err != nil {
+		return nil, err
+	}
+	in, out := 0, 1
+	source, destination := userBaseAccount, userQuoteAccount
+	poolSource, poolDestination := pool.TokenA, pool.TokenB
+	sourceMint, destinationMint := pool.TokenAMint, pool.TokenBMint
+	if inputMint == pool.TokenBMint.String() {
+		in, out = 1, 0
+		source, destination = userQuoteAccount, userBaseAccount
+		poolSource, poolDestination = pool.TokenB, pool.TokenA
+		sourceMint, destinationMint = pool.TokenBMint, pool.TokenAMint
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(pool.PoolId),
+		solana.Meta(authority),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(poolSource).WRITE(),
+		solana.Meta(poolDestination).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(pool.PoolMint).WRITE(),
+		solana.Meta(pool.PoolFeeAccount).WRITE(),
+		solana.Meta(sourceMint),
+		solana.Meta(destinationMint),
+		solana.Meta(pool.tokenProgram(in)),
+		solana.Meta(pool.tokenProgram(out)),
+		solana.Meta(pool.PoolTokenProgram),
+	}
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, SwapTag...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_amount_out back from the swap instruction
+func (pool *FluxBeamPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, SwapTag, swapMinOutOffset)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in the swap instruction
+func (pool *FluxBeamPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SwapTag, Offset: 1},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SwapTag, Offset: swapMinOutOffset}
+}
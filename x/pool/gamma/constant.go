@@ -0,0 +1,81 @@
+// Package gamma quotes and builds swaps on GooseFX GAMMA, a constant product
+// AMM derived from the Raydium CP-swap program whose trade fee rises with the
+// pool's recent volatility. Either mint may be a Token-2022 mint
+package gamma
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+)
+
+// Name is the protocol name of GAMMA pools
+const Name pkg.ProtocolName = "goosefx_gamma"
+
+var (
+	// ProgramID is the GooseFX GAMMA program
+	ProgramID = solana.MustPublicKeyFromBase58("GAMMA7meSFWaBXF25oSUgmGRwaW6sCMFLmBNiMSdbHVT")
+
+	// PoolDiscriminator prefixes pool state accounts
+	PoolDiscriminator = anchor.GetDiscriminator("account", "PoolState")
+	// SwapBaseInputDiscriminator prefixes the exact input swap, followed by
+	// amount_in and minimum_amount_out
+	SwapBaseInputDiscriminator = anchor.GetDiscriminator("global", "swap_base_input")
+
+	// AuthSeed seeds the authority owning the vaults
+	AuthSeed = []byte("vault_and_lp_mint_auth_seed")
+)
+
+// GAMMA pool state layout, packed after the discriminator
+const (
+	Token0MintOffset = 168
+	Token1MintOffset = 200
+
+	ammConfigOffset        = 8
+	token0VaultOffset      = 72
+	token1VaultOffset      = 104
+	token0ProgramOffset    = 232
+	token1ProgramOffset    = 264
+	observationKeyOffset   = 296
+	statusOffset           = 329
+	mint0DecimalsOffset    = 331
+	mint1DecimalsOffset    = 332
+	protocolFees0Offset    = 341
+	protocolFees1Offset    = 349
+	fundFees0Offset        = 357
+	fundFees1Offset        = 365
+	openTimeOffset         = 373
+	poolStateMinSize       = 381
+	statusSwapDisabledMask = 1 << 2
+)
+
+// AMM config layout: the trade fee rate every pool of the config starts from
+const (
+	tradeFeeRateOffset = 12
+	ammConfigMinSize   = 20
+)
+
+// Observation ring layout: a u16 index of the newest entry, then entries of a
+// block timestamp and the cumulative token 0 and token 1 prices as Q32.32
+const (
+	observationIndexOffset = 9
+	observationsOffset     = 43
+	observationSize        = 8 + 16 + 16
+	observationNum         = 100
+)
+
+// Fee rates are in millionths
+const (
+	FeeRateDenominator = 1_000_000
+	// MaxFeeRate caps the dynamic fee at 10%
+	MaxFeeRate = 100_000
+	// VolatilityWindow is how far back, in seconds, observations feed the
+	// dynamic fee
+	VolatilityWindow = 3600
+)
+
+// Swap instruction data: the discriminator, amount_in and minimum_amount_out
+const (
+	swapDataSize     = 24
+	swapMinOutOffset = 16
+)
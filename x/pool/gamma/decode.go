@@ -0,0 +1,37 @@
+package gamma
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a GAMMA swap_base_input instruction
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapBaseInputDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 13); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          Name,
+		User:              accounts[0].PublicKey,
+		Pool:              accounts[3].PublicKey,
+		UserInputAccount:  accounts[4].PublicKey,
+		UserOutputAccount: accounts[5].PublicKey,
+		InputMint:         accounts[10].PublicKey,
+		OutputMint:        accounts[11].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapMinOutOffset:])),
+	}
+	return params, nil
+}
@@ -0,0 +1,88 @@
+package gamma
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// observation is one entry of a pool's observation ring
+type observation struct {
+	timestamp uint64
+	// cumulativePrice0 sums the token 0 price, Q32.32, over every second
+	cumulativePrice0 *big.Int
+}
+
+// parseObservations reads the observation ring, oldest first, leaving out
+// entries never written
+func parseObservations(data []byte) ([]observation, error) {
+	if len(data) < observationsOffset+observationNum*observationSize {
+		return nil, fmt.Errorf("observation account too short: %d bytes", len(data))
+	}
+	observations := make([]observation, 0, observationNum)
+	for i := 0; i < observationNum; i++ {
+		entry := data[observationsOffset+i*observationSize:]
+		timestamp := binary.LittleEndian.Uint64(entry)
+		if timestamp == 0 {
+			continue
+		}
+		observations = append(observations, observation{
+			timestamp:        timestamp,
+			cumulativePrice0: leUint128(entry[8:24]),
+		})
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].timestamp < observations[j].timestamp })
+	return observations, nil
+}
+
+// leUint128 reads a little endian u128
+func leUint128(data []byte) *big.Int {
+	be := make([]byte, 16)
+	for i := range be {
+		be[i] = data[15-i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// dynamicFeeRate is the fee rate a swap pays at now: base plus half the
+// range of the token 0 price over the last VolatilityWindow seconds relative
+// to its mean, capped at MaxFeeRate. The price of each interval between two
+// observations is its time-weighted average; a pool without two
+// observations in the window pays the base rate
+func dynamicFeeRate(base uint64, observations []observation, now uint64) uint64 {
+	var low, high *big.Int
+	for i := 1; i < len(observations); i++ {
+		previous, current := observations[i-1], observations[i]
+		if previous.timestamp+VolatilityWindow < now || current.timestamp <= previous.timestamp {
+			continue
+		}
+		price := new(big.Int).Sub(current.cumulativePrice0, previous.cumulativePrice0)
+		price.Quo(price, new(big.Int).SetUint64(current.timestamp-previous.timestamp))
+		if low == nil || price.Cmp(low) < 0 {
+			low = price
+		}
+		if high == nil || price.Cmp(high) > 0 {
+			high = price
+		}
+	}
+	rate := new(big.Int).SetUint64(base)
+	if low != nil {
+		if sum := new(big.Int).Add(low, high); sum.Sign() > 0 {
+			extra := new(big.Int).Sub(high, low)
+			extra.Mul(extra, big.NewInt(FeeRateDenominator))
+			rate.Add(rate, extra.Quo(extra, sum))
+		}
+	}
+	if rate.Cmp(big.NewInt(MaxFeeRate)) > 0 {
+		return MaxFeeRate
+	}
+	return rate.Uint64()
+}
+
+// tradingFee is the fee at rate on amount, rounded up as the program does
+func tradingFee(amount *big.Int, rate uint64) *big.Int {
+	fee := new(big.Int).Mul(amount, new(big.Int).SetUint64(rate))
+	fee.Add(fee, big.NewInt(FeeRateDenominator-1))
+	return fee.Quo(fee, big.NewInt(FeeRateDenominator))
+}
@@ -0,0 +1,388 @@
+package gamma
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// GammaPool is a GooseFX GAMMA pool. Token 0 and token 1 are the base and
+// quote mints; the protocol and fund fees accrued in the vaults are not part
+// of the reserves
+type GammaPool struct {
+	PoolId         solana.PublicKey
+	AmmConfig      solana.PublicKey
+	Token0Vault    solana.PublicKey
+	Token1Vault    solana.PublicKey
+	Token0Mint     solana.PublicKey
+	Token1Mint     solana.PublicKey
+	Token0Program  solana.PublicKey
+	Token1Program  solana.PublicKey
+	ObservationKey solana.PublicKey
+	Status         uint8
+	Mint0Decimals  uint8
+	Mint1Decimals  uint8
+	OpenTime       uint64
+
+	ProtocolFeesToken0 uint64
+	ProtocolFeesToken1 uint64
+	FundFeesToken0     uint64
+	FundFeesToken1     uint64
+
+	// TradeFeeRate is the base rate of the pool's config and FeeRate the
+	// dynamic rate in force at the last quote, both in millionths
+	TradeFeeRate uint64
+	FeeRate      uint64
+
+	// BaseReserve and QuoteReserve are the token 0 and 1 reserves of the last quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+	// fees are the Token-2022 transfer fees of token 0 and 1, nil for mints
+	// without one, and epoch the epoch of the last quote
+	fees  [2]*sol.TransferFeeConfig
+	epoch uint64
+}
+
+func (pool *GammaPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *GammaPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *GammaPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token 0 as base and token 1 as quote
+func (pool *GammaPool) GetTokens() (string, string) {
+	return pool.Token0Mint.String(), pool.Token1Mint.String()
+}
+
+// Decode parses a GAMMA pool state account
+func (pool *GammaPool) Decode(data []byte) error {
+	if len(data) < poolStateMinSize {
+		return fmt.Errorf("gamma pool account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, PoolDiscriminator) {
+		return fmt.Errorf("not a gamma pool account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.AmmConfig = key(ammConfigOffset)
+	pool.Token0Vault = key(token0VaultOffset)
+	pool.Token1Vault = key(token1VaultOffset)
+	pool.Token0Mint = key(Token0MintOffset)
+	pool.Token1Mint = key(Token1MintOffset)
+	pool.Token0Program = key(token0ProgramOffset)
+	pool.Token1Program = key(token1ProgramOffset)
+	pool.ObservationKey = key(observationKeyOffset)
+	pool.Status = data[statusOffset]
+	pool.Mint0Decimals = data[mint0DecimalsOffset]
+	pool.Mint1Decimals = data[mint1DecimalsOffset]
+	pool.ProtocolFeesToken0 = u64(protocolFees0Offset)
+	pool.ProtocolFeesToken1 = u64(protocolFees1Offset)
+	pool.FundFeesToken0 = u64(fundFees0Offset)
+	pool.FundFeesToken1 = u64(fundFees1Offset)
+	pool.OpenTime = u64(openTimeOffset)
+	return nil
+}
+
+// SwapDisabled reports whether the pool's status rejects swaps
+func (pool *GammaPool) SwapDisabled() bool {
+	return pool.Status&statusSwapDisabledMask != 0
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the fee rates, reserves and transfer fees of the last quote
+func (pool *GammaPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*GammaPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	previous := *pool
+	*pool = *fresh
+	pool.TradeFeeRate, pool.FeeRate = previous.TradeFeeRate, previous.FeeRate
+	pool.BaseReserve, pool.QuoteReserve = previous.BaseReserve, previous.QuoteReserve
+	pool.fees, pool.epoch = previous.fees, previous.epoch
+	return true
+}
+
+// OpensAt returns when the pool starts accepting swaps
+func (pool *GammaPool) OpensAt() time.Time {
+	if pool.OpenTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(pool.OpenTime), 0)
+}
+
+// MintDecimals returns the decimals the pool records for mint
+func (pool *GammaPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case pool.Token0Mint.String():
+		return pool.Mint0Decimals, true
+	case pool.Token1Mint.String():
+		return pool.Mint1Decimals, true
+	}
+	return 0, false
+}
+
+// Quote refreshes the pool, its config, observations, vaults, mints and the
+// clock in one batch, sets the dynamic fee rate in force and returns what
+// the user receives for inputAmount, net of Token-2022 transfer fees
+func (pool *GammaPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{
+		pool.PoolId, pool.AmmConfig, pool.ObservationKey, pool.Token0Vault, pool.Token1Vault,
+		pool.Token0Mint, pool.Token1Mint, solana.SysVarClockPubkey,
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode gamma pool %s: %w", pool.PoolId, err)
+		}
+	}
+	data, ok := sol.AccountData(results, 7)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if pool.SwapDisabled() {
+		return math.ZeroInt(), fmt.Errorf("gamma pool %s has swaps disabled", pool.PoolId)
+	}
+	if clock.UnixTimestamp < pool.OpenTime {
+		return math.ZeroInt(), fmt.Errorf("gamma pool %s opens at %d, now %d", pool.PoolId, pool.OpenTime, clock.UnixTimestamp)
+	}
+
+	data, ok = sol.AccountData(results, 1)
+	if !ok || len(data) < ammConfigMinSize {
+		return math.ZeroInt(), fmt.Errorf("amm config %s not found", pool.AmmConfig)
+	}
+	pool.TradeFeeRate = binary.LittleEndian.Uint64(data[tradeFeeRateOffset:])
+	pool.FeeRate = pool.TradeFeeRate
+	if data, ok := sol.AccountData(results, 2); ok {
+		observations, err := parseObservations(data)
+		if err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to parse observations of gamma pool %s: %w", pool.PoolId, err)
+		}
+		pool.FeeRate = dynamicFeeRate(pool.TradeFeeRate, observations, clock.UnixTimestamp)
+	}
+
+	vault0, ok := sol.TokenAccountAmount(results, 3)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("vault %s not found", pool.Token0Vault)
+	}
+	vault1, ok := sol.TokenAccountAmount(results, 4)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("vault %s not found", pool.Token1Vault)
+	}
+	pool.BaseReserve = reserve(vault0, pool.ProtocolFeesToken0, pool.FundFeesToken0)
+	pool.QuoteReserve = reserve(vault1, pool.ProtocolFeesToken1, pool.FundFeesToken1)
+
+	pool.epoch = clock.Epoch
+	for i, program := range []solana.PublicKey{pool.Token0Program, pool.Token1Program} {
+		pool.fees[i] = nil
+		if data, ok := sol.AccountData(results, 5+i); ok && program.Equals(solana.Token2022ProgramID) {
+			if config, ok := sol.ParseTransferFeeConfig(data); ok {
+				pool.fees[i] = config
+			}
+		}
+	}
+
+	outputMint := pool.Token1Mint.String()
+	if inputMint == pool.Token1Mint.String() {
+		outputMint = pool.Token0Mint.String()
+	}
+	curveIn := inputAmount.Sub(pool.transferFee(inputMint, inputAmount))
+	if !curveIn.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("amount %s does not cover the transfer fee", inputAmount)
+	}
+	amountOut, err := pool.ComputeAmountOut(inputMint, curveIn)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return amountOut.Sub(pool.transferFee(outputMint, amountOut)), nil
+}
+
+// reserve is a vault balance less the protocol and fund fees it holds
+func reserve(vault, protocolFees, fundFees uint64) math.Int {
+	amount := math.NewIntFromUint64(vault).Sub(math.NewIntFromUint64(protocolFees)).Sub(math.NewIntFromUint64(fundFees))
+	if amount.IsNegative() {
+		return math.ZeroInt()
+	}
+	return amount
+}
+
+// transferFee returns the Token-2022 fee on a transfer of amount of mint,
+// zero for mints without one
+func (pool *GammaPool) transferFee(mint string, amount math.Int) math.Int {
+	i := 0
+	if mint == pool.Token1Mint.String() {
+		i = 1
+	}
+	return pool.fees[i].Fee(pool.epoch, amount)
+}
+
+// reserves returns the input and output reserves for inputMint
+func (pool *GammaPool) reserves(inputMint string) (*big.Int, *big.Int, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return nil, nil, fmt.Errorf("pool state not loaded")
+	}
+	switch inputMint {
+	case pool.Token0Mint.String():
+		return pool.BaseReserve.BigInt(), pool.QuoteReserve.BigInt(), nil
+	case pool.Token1Mint.String():
+		return pool.QuoteReserve.BigInt(), pool.BaseReserve.BigInt(), nil
+	}
+	return nil, nil, fmt.Errorf("mint %s is not traded by gamma pool %s", inputMint, pool.PoolId)
+}
+
+// ComputeAmountOut prices inputAmount, as it reaches the vault, against the
+// cached reserves before transfer fees: the dynamic fee comes off the input
+// and the rest trades on the constant product, rounded down
+func (pool *GammaPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	reserveIn, reserveOut, err := pool.reserves(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("gamma pool %s has an empty reserve", pool.PoolId)
+	}
+	amount := new(big.Int).Sub(inputAmount.BigInt(), tradingFee(inputAmount.BigInt(), pool.FeeRate))
+	if amount.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("amount %s does not cover the trade fee", inputAmount)
+	}
+	amountOut := new(big.Int).Mul(reserveOut, amount)
+	amountOut.Quo(amountOut, new(big.Int).Add(reserveIn, amount))
+	if amountOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("swap of %s is too small", inputAmount)
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// SwapFee returns the dynamic trade fee of the last quote charged on inputAmount
+func (pool *GammaPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return math.NewIntFromBigInt(tradingFee(inputAmount.BigInt(), pool.FeeRate))
+}
+
+// Reserves returns the token 0 and 1 reserves cached by the last Quote
+func (pool *GammaPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the ratio of the cached reserves
+func (pool *GammaPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return math.LegacyDec{}, fmt.Errorf("pool state not loaded")
+	}
+	return pkg.PriceFromReserves(pool.BaseReserve, pool.QuoteReserve, inputMint == pool.Token0Mint.String())
+}
+
+// MaxInputForImpact solves the constant product curve for the largest input
+// within maxImpactBps of the spot price, grossed up by the dynamic fee
+func (pool *GammaPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, _, err := pool.reserves(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	bps := big.NewInt(int64(maxImpactBps))
+	amount := new(big.Int).Mul(reserveIn, bps)
+	amount.Quo(amount, new(big.Int).Sub(big.NewInt(10000), bps))
+	amount.Mul(amount, big.NewInt(FeeRateDenominator))
+	amount.Quo(amount, new(big.Int).SetUint64(FeeRateDenominator-pool.FeeRate))
+	return math.NewIntFromBigInt(amount), nil
+}
+
+// Authority derives the authority owning the pool's vaults
+func Authority() (solana.PublicKey, error) {
+	authority, _, err := sol.FindProgramAddress([][]byte{AuthSeed}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive gamma authority: %w", err)
+	}
+	return authority, nil
+}
+
+// BuildSwapInstructions builds a swap_base_input naming each side's mint and
+// token program, as Token-2022 transfers need
+func (pool *GammaPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	authority, err := Authority()
+	if err != nil {
+		return nil, err
+	}
+	source, destination := userBaseAccount, userQuoteAccount
+	inputVault, outputVault := pool.Token0Vault, pool.Token1Vault
+	inputProgram, outputProgram := pool.Token0Program, pool.Token1Program
+	sourceMint, destinationMint := pool.Token0Mint, pool.Token1Mint
+	if inputMint == pool.Token1Mint.String() {
+		source, destination = userQuoteAccount, userBaseAccount
+		inputVault, outputVault = pool.Token1Vault, pool.Token0Vault
+		inputProgram, outputProgram = pool.Token1Program, pool.Token0Program
+		sourceMint, destinationMint = pool.Token1Mint, pool.Token0Mint
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(user).SIGNER().WRITE(),
+		solana.Meta(authority),
+		solana.Meta(pool.AmmConfig),
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(inputVault).WRITE(),
+		solana.Meta(outputVault).WRITE(),
+		solana.Meta(inputProgram),
+		solana.Meta(outputProgram),
+		solana.Meta(sourceMint),
+		solana.Meta(destinationMint),
+		solana.Meta(pool.ObservationKey).WRITE(),
+	}
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, SwapBaseInputDiscriminator...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_amount_out back from the swap instruction
+func (pool *GammaPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, SwapBaseInputDiscriminator, swapMinOutOffset)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in the swap instruction
+func (pool *GammaPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SwapBaseInputDiscriminator, Offset: 8},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SwapBaseInputDiscriminator, Offset: swapMinOutOffset}
+}
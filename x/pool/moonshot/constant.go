@@ -0,0 +1,102 @@
+// Package moonshot quotes and builds trades against Moonshot bonding curves,
+// which sell a newly launched token for native SOL until its market cap
+// reaches the migration threshold. Every curve is a PDA of its mint, so the
+// pool is the token paired with SOL
+package moonshot
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+)
+
+// Name is the protocol name of Moonshot pools
+const Name pkg.ProtocolName = "moonshot"
+
+var (
+	// ProgramID is the Moonshot token launch program
+	ProgramID = solana.MustPublicKeyFromBase58("MoonCVVNZFSYkqNXP6bxHLPL6QQJiMagDL3qcqUQTrG")
+
+	// DefaultDexFee and DefaultHelioFee are the fee recipients trades pay,
+	// used until the config account has been read
+	DefaultDexFee   = solana.MustPublicKeyFromBase58("3udvfL24waJcLhskRAsStNMoNUvtyXdxrWQz4hgi953N")
+	DefaultHelioFee = solana.MustPublicKeyFromBase58("5K5RtTWzzLp4P8Npi84ocf7F1vBsAu29N1irG4iiUnzt")
+
+	// CurveDiscriminator prefixes curve accounts
+	CurveDiscriminator = anchor.GetDiscriminator("account", "CurveAccount")
+	// BuyDiscriminator and SellDiscriminator prefix the trade instructions
+	BuyDiscriminator  = anchor.GetDiscriminator("global", "buy")
+	SellDiscriminator = anchor.GetDiscriminator("global", "sell")
+)
+
+// Seeds of the curve and config PDAs
+const (
+	curveSeed  = "token"
+	configSeed = "config_account"
+)
+
+// Moonshot curve account layout
+const (
+	CurveSize  = 82
+	MintOffset = 24
+
+	totalSupplyOffset        = 8
+	curveAmountOffset        = 16
+	decimalsOffset           = 56
+	collateralCurrencyOffset = 57
+	curveTypeOffset          = 58
+)
+
+// Moonshot config account layout: five authorities and fee recipients, then
+// the fee rate
+const (
+	configHelioFeeOffset = 104
+	configDexFeeOffset   = 136
+	configFeeBpsOffset   = 168
+	configMinSize        = configFeeBpsOffset + 2
+)
+
+// CurveType is the pricing curve of a curve account
+type CurveType uint8
+
+const (
+	CurveLinearV1          CurveType = iota // retired, not quoted
+	CurveConstantProductV1                  // constant product on virtual reserves
+)
+
+// collateralSol is the collateral currency of curves traded for SOL
+const collateralSol = 0
+
+// Trade instruction data: the discriminator, then the token amount, the
+// collateral amount, the fixed side and the slippage tolerance in bps
+const (
+	tokenAmountOffset      = 8
+	collateralAmountOffset = 16
+	fixedSideOffset        = 24
+	slippageBpsOffset      = 25
+	tradeDataSize          = 33
+)
+
+// FixedSide is the side of a trade whose amount is exact; the other is
+// bounded by the slippage tolerance
+type FixedSide uint8
+
+const (
+	FixedSideExactIn  FixedSide = iota // exact input, minimum output
+	FixedSideExactOut                  // exact output, maximum input
+)
+
+const (
+	// initialVirtualTokenReserves and initialVirtualCollateralReserves start
+	// every constant product curve; the invariant is their product. They
+	// follow Moonshot's published SDK, not the curve account
+	initialVirtualTokenReserves      = 1_073_000_000_000_000_000
+	initialVirtualCollateralReserves = 30_000_000_000
+
+	// defaultFeeBps is the trading fee until the config account has been read
+	defaultFeeBps = 100
+	// basisPointsDenominator is the denominator of the fee rate
+	basisPointsDenominator = 10_000
+	// solDecimals are the decimals of the collateral
+	solDecimals = 9
+)
@@ -0,0 +1,59 @@
+package moonshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// DecodeSwap parses a Moonshot buy or sell. The SOL side is the user's
+// system account, named by the WSOL mint. The side that is not fixed is
+// bounded by the slippage tolerance, applied to it as the program does
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	buy := bytes.HasPrefix(data, BuyDiscriminator)
+	if !buy && !bytes.HasPrefix(data, SellDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < tradeDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 11); err != nil {
+		return nil, err
+	}
+	side := FixedSide(data[fixedSideOffset])
+	if side != FixedSideExactIn && side != FixedSideExactOut {
+		return nil, fmt.Errorf("invalid fixed side %d", side)
+	}
+	tokenAmount := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[tokenAmountOffset:]))
+	collateralAmount := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[collateralAmountOffset:]))
+	slippageBps := math.NewIntFromUint64(binary.LittleEndian.Uint64(data[slippageBpsOffset:]))
+
+	params := &pkg.SwapParams{
+		Protocol:    Name,
+		Pool:        accounts[2].PublicKey,
+		User:        accounts[0].PublicKey,
+		ExactOutput: side == FixedSideExactOut,
+	}
+	if buy {
+		params.UserInputAccount, params.UserOutputAccount = accounts[0].PublicKey, accounts[1].PublicKey
+		params.InputMint, params.OutputMint = sol.WSOL, accounts[6].PublicKey
+		params.AmountIn, params.MinAmountOut = collateralAmount, tokenAmount
+	} else {
+		params.UserInputAccount, params.UserOutputAccount = accounts[1].PublicKey, accounts[0].PublicKey
+		params.InputMint, params.OutputMint = accounts[6].PublicKey, sol.WSOL
+		params.AmountIn, params.MinAmountOut = tokenAmount, collateralAmount
+	}
+	if params.ExactOutput {
+		params.AmountIn = params.AmountIn.Mul(slippageBps.AddRaw(basisPointsDenominator)).QuoRaw(basisPointsDenominator)
+	} else if slippageBps.LT(math.NewInt(basisPointsDenominator)) {
+		params.MinAmountOut = params.MinAmountOut.Mul(math.NewInt(basisPointsDenominator).Sub(slippageBps)).QuoRaw(basisPointsDenominator)
+	} else {
+		params.MinAmountOut = math.ZeroInt()
+	}
+	return params, nil
+}
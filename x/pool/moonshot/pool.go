@@ -0,0 +1,393 @@
+package moonshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// MoonshotPool is a Moonshot bonding curve traded as a pool between its
+// token, as base, and SOL, as quote. SOL moves as native lamports, so routes
+// need no WSOL around it
+type MoonshotPool struct {
+	PoolId             solana.PublicKey
+	Mint               solana.PublicKey
+	TotalSupply        uint64
+	CurveAmount        uint64
+	Decimals           uint8
+	CollateralCurrency uint8
+	CurveType          CurveType
+
+	// FeeBps, DexFee and HelioFee are read from the config account by Quote
+	FeeBps   uint16
+	DexFee   solana.PublicKey
+	HelioFee solana.PublicKey
+	config   bool
+
+	// Fees, when set, replaces FeeBps with tiers by the SOL amount of a trade
+	// under the WSOL mint
+	Fees pkg.FeeSchedule
+}
+
+func (pool *MoonshotPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *MoonshotPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *MoonshotPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns the token as base and SOL, by its WSOL mint, as quote
+func (pool *MoonshotPool) GetTokens() (string, string) {
+	return pool.Mint.String(), sol.WSOL.String()
+}
+
+// UsesNativeSOL reports that buys take and sells pay native lamports
+func (pool *MoonshotPool) UsesNativeSOL(mint string) bool {
+	return mint == sol.WSOL.String()
+}
+
+// MintDecimals returns the decimals recorded in the curve account
+func (pool *MoonshotPool) MintDecimals(mint string) (uint8, bool) {
+	switch mint {
+	case pool.Mint.String():
+		return pool.Decimals, true
+	case sol.WSOL.String():
+		return solDecimals, true
+	}
+	return 0, false
+}
+
+// Decode parses a Moonshot curve account
+func (pool *MoonshotPool) Decode(data []byte) error {
+	if len(data) < CurveSize {
+		return fmt.Errorf("moonshot curve account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, CurveDiscriminator) {
+		return fmt.Errorf("not a moonshot curve account")
+	}
+	pool.TotalSupply = binary.LittleEndian.Uint64(data[totalSupplyOffset:])
+	pool.CurveAmount = binary.LittleEndian.Uint64(data[curveAmountOffset:])
+	pool.Mint = solana.PublicKeyFromBytes(data[MintOffset : MintOffset+32])
+	pool.Decimals = data[decimalsOffset]
+	pool.CollateralCurrency = data[collateralCurrencyOffset]
+	pool.CurveType = CurveType(data[curveTypeOffset])
+	if pool.CurveAmount > pool.TotalSupply {
+		return fmt.Errorf("curve holds %d of a %d supply", pool.CurveAmount, pool.TotalSupply)
+	}
+	return nil
+}
+
+// decodeConfig takes the fee rate and recipients from the config account
+func (pool *MoonshotPool) decodeConfig(data []byte) error {
+	if len(data) < configMinSize {
+		return fmt.Errorf("moonshot config account too short: %d bytes", len(data))
+	}
+	pool.HelioFee = solana.PublicKeyFromBytes(data[configHelioFeeOffset : configHelioFeeOffset+32])
+	pool.DexFee = solana.PublicKeyFromBytes(data[configDexFeeOffset : configDexFeeOffset+32])
+	pool.FeeBps = binary.LittleEndian.Uint16(data[configFeeBpsOffset:])
+	if pool.FeeBps >= basisPointsDenominator {
+		return fmt.Errorf("invalid fee of %d bps", pool.FeeBps)
+	}
+	pool.config = true
+	return nil
+}
+
+// Tradable reports whether the curve is still selling a token for SOL on
+// the constant product curve, the only one quoted
+func (pool *MoonshotPool) Tradable() error {
+	if pool.CollateralCurrency != collateralSol {
+		return fmt.Errorf("moonshot curve %s is not traded for SOL", pool.PoolId)
+	}
+	if pool.CurveType != CurveConstantProductV1 {
+		return fmt.Errorf("moonshot curve %s has unsupported curve type %d", pool.PoolId, pool.CurveType)
+	}
+	if pool.CurveAmount == 0 {
+		return fmt.Errorf("moonshot curve %s has migrated", pool.PoolId)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded curve of a rediscovered pool while
+// keeping the config read by the last quote
+func (pool *MoonshotPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*MoonshotPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	feeBps, dexFee, helioFee, config := pool.FeeBps, pool.DexFee, pool.HelioFee, pool.config
+	*pool = *fresh
+	if config && !fresh.config {
+		pool.FeeBps, pool.DexFee, pool.HelioFee, pool.config = feeBps, dexFee, helioFee, config
+	}
+	return true
+}
+
+// Quote refreshes the curve and the config account in one batch and prices
+// inputAmount
+func (pool *MoonshotPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	config, err := ConfigAddress()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{pool.PoolId, config})
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	data, ok := sol.AccountData(results, 0)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("moonshot curve %s not found", pool.PoolId)
+	}
+	if err := pool.Decode(data); err != nil {
+		return math.ZeroInt(), fmt.Errorf("failed to decode moonshot curve %s: %w", pool.PoolId, err)
+	}
+	data, ok = sol.AccountData(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("moonshot config %s not found", config)
+	}
+	if err := pool.decodeConfig(data); err != nil {
+		return math.ZeroInt(), err
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// virtualReserves returns the token and SOL reserves of the constant product
+// curve at the curve's position: the tokens sold come off the initial
+// virtual token reserves and the invariant sets the SOL
+func (pool *MoonshotPool) virtualReserves() (*big.Int, *big.Int, *big.Int, error) {
+	if err := pool.Tradable(); err != nil {
+		return nil, nil, nil, err
+	}
+	sold := pool.TotalSupply - pool.CurveAmount
+	if sold >= initialVirtualTokenReserves {
+		return nil, nil, nil, fmt.Errorf("moonshot curve %s sold past its virtual reserves", pool.PoolId)
+	}
+	invariant := new(big.Int).Mul(
+		new(big.Int).SetUint64(initialVirtualTokenReserves),
+		new(big.Int).SetUint64(initialVirtualCollateralReserves),
+	)
+	tokens := new(big.Int).SetUint64(initialVirtualTokenReserves - sold)
+	collateral := new(big.Int).Quo(invariant, tokens)
+	return tokens, collateral, invariant, nil
+}
+
+// feeBps is the rate charged on a trade moving solAmount lamports
+func (pool *MoonshotPool) feeBps(solAmount math.Int) int64 {
+	if bps, ok := pool.Fees.Bps(sol.WSOL.String(), solAmount); ok {
+		return bps
+	}
+	if !pool.config {
+		return defaultFeeBps
+	}
+	return int64(pool.FeeBps)
+}
+
+// fee is the fee on solAmount lamports, rounded up so quotes never overstate
+func (pool *MoonshotPool) fee(solAmount math.Int) math.Int {
+	bps := pool.feeBps(solAmount)
+	if bps <= 0 {
+		return math.ZeroInt()
+	}
+	return solAmount.MulRaw(bps).AddRaw(basisPointsDenominator - 1).QuoRaw(basisPointsDenominator)
+}
+
+// ceilQuo divides rounding up
+func ceilQuo(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// ComputeAmountOut prices inputAmount against the cached curve. The fee is
+// taken in SOL: off the input of a buy and off the proceeds of a sell. The
+// curve's side of each step is rounded in its favour. Buys cannot take more
+// than the curve holds
+func (pool *MoonshotPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	tokens, collateral, invariant, err := pool.virtualReserves()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+
+	switch inputMint {
+	case sol.WSOL.String():
+		net := inputAmount.Sub(pool.fee(inputAmount))
+		if !net.IsPositive() {
+			return math.ZeroInt(), fmt.Errorf("buy of %s lamports is too small", inputAmount)
+		}
+		remaining := ceilQuo(invariant, new(big.Int).Add(collateral, net.BigInt()))
+		out := math.NewIntFromBigInt(new(big.Int).Sub(tokens, remaining))
+		if out.GT(math.NewIntFromUint64(pool.CurveAmount)) {
+			return math.ZeroInt(), fmt.Errorf("buy of %s exceeds the %d tokens left on the curve", out, pool.CurveAmount)
+		}
+		return out, nil
+	case pool.Mint.String():
+		remaining := ceilQuo(invariant, new(big.Int).Add(tokens, inputAmount.BigInt()))
+		gross := math.NewIntFromBigInt(new(big.Int).Sub(collateral, remaining))
+		out := gross.Sub(pool.fee(gross))
+		if !out.IsPositive() {
+			return math.ZeroInt(), fmt.Errorf("sale of %s tokens is too small", inputAmount)
+		}
+		return out, nil
+	}
+	return math.ZeroInt(), fmt.Errorf("mint %s is not traded by moonshot curve %s", inputMint, pool.PoolId)
+}
+
+// SwapFee returns the fee in input units: the SOL charged on a buy, and the
+// share of the tokens sold whose proceeds the fee takes on a sell
+func (pool *MoonshotPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	if inputMint == sol.WSOL.String() {
+		return pool.fee(inputAmount)
+	}
+	return inputAmount.MulRaw(pool.feeBps(inputAmount)).QuoRaw(basisPointsDenominator)
+}
+
+// RawSpotPrice is the ratio of the curve's virtual reserves
+func (pool *MoonshotPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	tokens, collateral, _, err := pool.virtualReserves()
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	return pkg.RatioPrice(collateral, tokens, inputMint != pool.Mint.String())
+}
+
+// MaxInputForImpact inverts the constant product curve on the virtual
+// reserves like a constant product pool, grossing buys up by the fee. Buys
+// are further bounded by the SOL that takes the rest of the curve
+func (pool *MoonshotPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	tokens, collateral, invariant, err := pool.virtualReserves()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	bps := math.NewInt(int64(maxImpactBps))
+	if inputMint == pool.Mint.String() {
+		return math.NewIntFromBigInt(tokens).Mul(bps).Quo(math.NewInt(basisPointsDenominator).Sub(bps)), nil
+	}
+
+	maxIn := math.NewIntFromBigInt(collateral).Mul(bps).Quo(math.NewInt(basisPointsDenominator).Sub(bps))
+	left := new(big.Int).Sub(tokens, new(big.Int).SetUint64(pool.CurveAmount))
+	if left.Sign() > 0 {
+		rest := math.NewIntFromBigInt(new(big.Int).Sub(new(big.Int).Quo(invariant, left), collateral))
+		maxIn = math.MinInt(maxIn, rest)
+	}
+	feeBps := pool.feeBps(maxIn)
+	if feeBps <= 0 {
+		return maxIn, nil
+	}
+	return maxIn.MulRaw(basisPointsDenominator).QuoRaw(basisPointsDenominator - feeBps), nil
+}
+
+// ConfigAddress derives the program's config account
+func ConfigAddress() (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{[]byte(configSeed)}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive moonshot config PDA: %w", err)
+	}
+	return address, nil
+}
+
+// CurveAddress derives the curve account of mint
+func CurveAddress(mint solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{[]byte(curveSeed), mint[:]}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive moonshot curve PDA: %w", err)
+	}
+	return address, nil
+}
+
+// BuildSwapInstructions builds an exact input buy or sell. The trade fixes
+// the input and takes the other side as its minimum with no slippage
+// tolerance on top, so the minimum is minOut exactly. The SOL side is the
+// user's system account; userQuoteAccount is not used
+func (pool *MoonshotPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	var discriminator []byte
+	var tokenAmount, collateralAmount uint64
+	switch inputMint {
+	case sol.WSOL.String():
+		discriminator, tokenAmount, collateralAmount = BuyDiscriminator, minOut.Uint64(), inputAmount.Uint64()
+	case pool.Mint.String():
+		discriminator, tokenAmount, collateralAmount = SellDiscriminator, inputAmount.Uint64(), minOut.Uint64()
+	default:
+		return nil, fmt.Errorf("mint %s is not traded by moonshot curve %s", inputMint, pool.PoolId)
+	}
+	config, err := ConfigAddress()
+	if err != nil {
+		return nil, err
+	}
+	curveTokenAccount, _, err := sol.FindAssociatedTokenAddress(pool.PoolId, pool.Mint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive curve token account: %w", err)
+	}
+	dexFee, helioFee := pool.DexFee, pool.HelioFee
+	if !pool.config {
+		dexFee, helioFee = DefaultDexFee, DefaultHelioFee
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(user).WRITE().SIGNER(),
+		solana.Meta(userBaseAccount).WRITE(),
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(curveTokenAccount).WRITE(),
+		solana.Meta(dexFee).WRITE(),
+		solana.Meta(helioFee).WRITE(),
+		solana.Meta(pool.Mint),
+		solana.Meta(config),
+		solana.Meta(solana.TokenProgramID),
+		solana.Meta(solana.SPLAssociatedTokenAccountProgramID),
+		solana.Meta(solana.SystemProgramID),
+	}
+	data := make([]byte, 0, tradeDataSize)
+	data = append(data, discriminator...)
+	data = binary.LittleEndian.AppendUint64(data, tokenAmount)
+	data = binary.LittleEndian.AppendUint64(data, collateralAmount)
+	data = append(data, byte(FixedSideExactIn))
+	data = binary.LittleEndian.AppendUint64(data, 0)
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads the minimum back from the trade instruction: the token
+// amount of a buy, the collateral amount of a sell
+func (pool *MoonshotPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	_, minOut := pool.SwapAmountFields(inputMint)
+	return pkg.DecodeInstructionU64(instructions, ProgramID, minOut.Prefix, minOut.Offset)
+}
+
+// SwapAmountFields locates the input and the minimum in the trade instruction
+func (pool *MoonshotPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	if inputMint == sol.WSOL.String() {
+		return pkg.AmountField{ProgramID: ProgramID, Prefix: BuyDiscriminator, Offset: collateralAmountOffset},
+			pkg.AmountField{ProgramID: ProgramID, Prefix: BuyDiscriminator, Offset: tokenAmountOffset}
+	}
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SellDiscriminator, Offset: tokenAmountOffset},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SellDiscriminator, Offset: collateralAmountOffset}
+}
@@ -0,0 +1,62 @@
+// Package obric quotes and builds swaps on Obric v2, a proactive market maker
+// whose curve is re-centred on Pyth prices: liquidity is a constant product
+// of virtual reserves that sit at the oracle price while the pool holds its
+// target balance, so it trades at a tight spread around the oracle
+package obric
+
+import (
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+)
+
+// Name is the protocol name of Obric v2 pools
+const Name pkg.ProtocolName = "obric_v2"
+
+var (
+	// ProgramID is the Obric v2 program
+	ProgramID = solana.MustPublicKeyFromBase58("obriQD1zbpyLz95G5n7nJe6a4DPjpFwa5XYPoNm113y")
+
+	// TradingPairDiscriminator prefixes trading pair accounts
+	TradingPairDiscriminator = anchor.GetDiscriminator("account", "SSTradingPair")
+	// SwapXToYDiscriminator and SwapYToXDiscriminator prefix the swaps,
+	// followed by input_amt and min_output_amt
+	SwapXToYDiscriminator = anchor.GetDiscriminator("global", "swap_x_to_y")
+	SwapYToXDiscriminator = anchor.GetDiscriminator("global", "swap_y_to_x")
+)
+
+// Trading pair layout, packed after the discriminator
+const (
+	MintXOffset = 202
+	MintYOffset = 234
+
+	isInitializedOffset = 8
+	xPriceFeedOffset    = 9
+	yPriceFeedOffset    = 41
+	reserveXOffset      = 73
+	reserveYOffset      = 105
+	protocolFeeXOffset  = 137
+	protocolFeeYOffset  = 169
+	bigKOffset          = 274
+	targetXOffset       = 290
+	multXOffset         = 306
+	multYOffset         = 314
+	feeMillionthOffset  = 322
+	tradingPairMinSize  = 330
+)
+
+const (
+	// FeeDenominator is the denominator of fee_millionth
+	FeeDenominator = 1_000_000
+	// MaxOracleAge is how old a price may be, by cluster time, before the
+	// pool stops quoting, as the program rejects swaps on stale prices
+	MaxOracleAge = 30 * time.Second
+)
+
+// Swap instruction data: the discriminator, input_amt and min_output_amt
+const (
+	swapDataSize     = 24
+	swapMinOutOffset = 16
+)
@@ -0,0 +1,42 @@
+package obric
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses an Obric v2 swap_x_to_y or swap_y_to_x instruction
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	xToY := bytes.HasPrefix(data, SwapXToYDiscriminator)
+	if !xToY && !bytes.HasPrefix(data, SwapYToXDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 12); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          Name,
+		User:              accounts[10].PublicKey,
+		Pool:              accounts[0].PublicKey,
+		UserInputAccount:  accounts[5].PublicKey,
+		UserOutputAccount: accounts[6].PublicKey,
+		InputMint:         accounts[1].PublicKey,
+		OutputMint:        accounts[2].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[8:16])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapMinOutOffset:])),
+	}
+	if !xToY {
+		params.UserInputAccount, params.UserOutputAccount = params.UserOutputAccount, params.UserInputAccount
+		params.InputMint, params.OutputMint = params.OutputMint, params.InputMint
+	}
+	return params, nil
+}
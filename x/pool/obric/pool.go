@@ -0,0 +1,367 @@
+package obric
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/lifinity"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// ObricPool is an Obric v2 trading pair. Mint X and mint Y are the base and
+// quote mints, each priced in USD by its own Pyth feed
+type ObricPool struct {
+	PoolId       solana.PublicKey
+	MintX        solana.PublicKey
+	MintY        solana.PublicKey
+	ReserveX     solana.PublicKey
+	ReserveY     solana.PublicKey
+	ProtocolFeeX solana.PublicKey
+	ProtocolFeeY solana.PublicKey
+	XPriceFeed   solana.PublicKey
+	YPriceFeed   solana.PublicKey
+	Initialized  bool
+
+	// BigK is the invariant of the virtual reserves and TargetX the X
+	// balance at which they sit at the oracle price. MultX and MultY bring
+	// both mints to common decimals, and FeeMillionth is taken off the output
+	BigK         *big.Int
+	TargetX      uint64
+	MultX        uint64
+	MultY        uint64
+	FeeMillionth uint64
+
+	// BaseReserve and QuoteReserve are the X and Y balances of the last quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+	// prices are the X and Y oracle prices of the last quote
+	prices [2]*lifinity.OraclePrice
+}
+
+func (pool *ObricPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *ObricPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *ObricPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns mint X as base and mint Y as quote
+func (pool *ObricPool) GetTokens() (string, string) {
+	return pool.MintX.String(), pool.MintY.String()
+}
+
+// Decode parses a trading pair account
+func (pool *ObricPool) Decode(data []byte) error {
+	if len(data) < tradingPairMinSize {
+		return fmt.Errorf("obric trading pair account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, TradingPairDiscriminator) {
+		return fmt.Errorf("not an obric trading pair account")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.Initialized = data[isInitializedOffset] != 0
+	pool.XPriceFeed = key(xPriceFeedOffset)
+	pool.YPriceFeed = key(yPriceFeedOffset)
+	pool.ReserveX = key(reserveXOffset)
+	pool.ReserveY = key(reserveYOffset)
+	pool.ProtocolFeeX = key(protocolFeeXOffset)
+	pool.ProtocolFeeY = key(protocolFeeYOffset)
+	pool.MintX = key(MintXOffset)
+	pool.MintY = key(MintYOffset)
+	// big_k is a little endian u128
+	pool.BigK = new(big.Int).Lsh(new(big.Int).SetUint64(u64(bigKOffset+8)), 64)
+	pool.BigK.Or(pool.BigK, new(big.Int).SetUint64(u64(bigKOffset)))
+	pool.TargetX = u64(targetXOffset)
+	pool.MultX = u64(multXOffset)
+	pool.MultY = u64(multYOffset)
+	pool.FeeMillionth = u64(feeMillionthOffset)
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the reserves and prices of the last quote
+func (pool *ObricPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*ObricPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	base, quote, prices := pool.BaseReserve, pool.QuoteReserve, pool.prices
+	*pool = *fresh
+	pool.BaseReserve, pool.QuoteReserve, pool.prices = base, quote, prices
+	return true
+}
+
+// Quote refreshes the pair, its reserves, both price feeds and the clock in
+// one batch and returns the output for inputAmount. Pairs whose prices are
+// older than MaxOracleAge by cluster time do not quote
+func (pool *ObricPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{
+		pool.PoolId, pool.ReserveX, pool.ReserveY, pool.XPriceFeed, pool.YPriceFeed, solana.SysVarClockPubkey,
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode obric pool %s: %w", pool.PoolId, err)
+		}
+	}
+	reserveX, ok := sol.TokenAccountAmount(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("reserve %s not found", pool.ReserveX)
+	}
+	reserveY, ok := sol.TokenAccountAmount(results, 2)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("reserve %s not found", pool.ReserveY)
+	}
+	pool.BaseReserve = math.NewIntFromUint64(reserveX)
+	pool.QuoteReserve = math.NewIntFromUint64(reserveY)
+
+	data, ok := sol.AccountData(results, 5)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	for i, feed := range []solana.PublicKey{pool.XPriceFeed, pool.YPriceFeed} {
+		data, ok := sol.AccountData(results, 3+i)
+		if !ok {
+			return math.ZeroInt(), fmt.Errorf("price feed %s not found", feed)
+		}
+		price, err := lifinity.ParseOraclePrice(data)
+		if err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to read price feed %s: %w", feed, err)
+		}
+		if age := time.Duration(int64(clock.UnixTimestamp)-price.PublishTime) * time.Second; age > MaxOracleAge {
+			return math.ZeroInt(), fmt.Errorf("price feed %s is %s old", feed, age)
+		}
+		pool.prices[i] = price
+	}
+	if !pool.Initialized {
+		return math.ZeroInt(), fmt.Errorf("obric pool %s is not initialized", pool.PoolId)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// price returns the oracle price in Y base units per X base unit as a
+// fraction, from the two USD prices and the decimal multipliers
+func (pool *ObricPool) price() (num, den *big.Int, err error) {
+	x, y := pool.prices[0], pool.prices[1]
+	if x == nil || y == nil {
+		return nil, nil, fmt.Errorf("pool state not loaded")
+	}
+	if x.Price <= 0 || y.Price <= 0 {
+		return nil, nil, fmt.Errorf("oracle prices %d and %d are not both positive", x.Price, y.Price)
+	}
+	num = new(big.Int).Mul(big.NewInt(x.Price), new(big.Int).SetUint64(pool.MultX))
+	den = new(big.Int).Mul(big.NewInt(y.Price), new(big.Int).SetUint64(pool.MultY))
+	exponent := int64(x.Exponent) - int64(y.Exponent)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(max(exponent, -exponent)), nil)
+	if exponent >= 0 {
+		num.Mul(num, scale)
+	} else {
+		den.Mul(den, scale)
+	}
+	if num.Sign() == 0 || den.Sign() == 0 {
+		return nil, nil, fmt.Errorf("obric pool %s has no decimal multipliers", pool.PoolId)
+	}
+	return num, den, nil
+}
+
+// virtualReserves returns the X and Y reserves of the curve: at the target
+// balance they sit at the oracle price on the invariant BigK, and X moves
+// from there one for one with the actual balance
+func (pool *ObricPool) virtualReserves() (*big.Int, *big.Int, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() || pool.BigK == nil || pool.BigK.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("pool state not loaded")
+	}
+	num, den, err := pool.price()
+	if err != nil {
+		return nil, nil, err
+	}
+	// x * y = K with y / x = num / den at the target
+	virtualX := new(big.Int).Mul(pool.BigK, den)
+	virtualX.Quo(virtualX, num).Sqrt(virtualX)
+	virtualX.Add(virtualX, pool.BaseReserve.BigInt())
+	virtualX.Sub(virtualX, new(big.Int).SetUint64(pool.TargetX))
+	if virtualX.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("obric pool %s is drained of X at the oracle price", pool.PoolId)
+	}
+	virtualY := new(big.Int).Quo(pool.BigK, virtualX)
+	if virtualY.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("obric pool %s is drained of Y at the oracle price", pool.PoolId)
+	}
+	return virtualX, virtualY, nil
+}
+
+// ComputeAmountOut prices inputAmount on the virtual reserves of the cached
+// state, takes the fee off the output and caps it by the actual reserve
+func (pool *ObricPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	virtualX, virtualY, err := pool.virtualReserves()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, reserveOut, actualOut := virtualX, virtualY, pool.QuoteReserve
+	switch inputMint {
+	case pool.MintX.String():
+	case pool.MintY.String():
+		reserveIn, reserveOut, actualOut = virtualY, virtualX, pool.BaseReserve
+	default:
+		return math.ZeroInt(), fmt.Errorf("mint %s is not traded by obric pool %s", inputMint, pool.PoolId)
+	}
+
+	// the new output reserve is rounded up, so the output rounds down
+	after := new(big.Int).Add(reserveIn, inputAmount.BigInt())
+	remaining := new(big.Int).Add(pool.BigK, after)
+	remaining.Sub(remaining, big.NewInt(1)).Quo(remaining, after)
+	amountOut := new(big.Int).Sub(reserveOut, remaining)
+	if amountOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("swap of %s is too small", inputAmount)
+	}
+	amountOut.Sub(amountOut, outputFee(amountOut, pool.FeeMillionth))
+	if amountOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("swap of %s does not cover the fee", inputAmount)
+	}
+	if amountOut.Cmp(actualOut.BigInt()) > 0 {
+		return math.ZeroInt(), fmt.Errorf("output %s exceeds the pool reserve %s", amountOut, actualOut)
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// outputFee is the fee on amountOut, rounded up
+func outputFee(amountOut *big.Int, feeMillionth uint64) *big.Int {
+	fee := new(big.Int).Mul(amountOut, new(big.Int).SetUint64(feeMillionth))
+	fee.Add(fee, big.NewInt(FeeDenominator-1))
+	return fee.Quo(fee, big.NewInt(FeeDenominator))
+}
+
+// SwapFee returns the fee at its input value: the pool charges it on the
+// output, at a rate that scales with the trade
+func (pool *ObricPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	fee := new(big.Int).Mul(inputAmount.BigInt(), new(big.Int).SetUint64(pool.FeeMillionth))
+	return math.NewIntFromBigInt(fee.Quo(fee, big.NewInt(FeeDenominator)))
+}
+
+// Reserves returns the X and Y balances cached by the last Quote
+func (pool *ObricPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the marginal price of the virtual reserves, which is the
+// oracle price while the pool holds its target balance
+func (pool *ObricPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	virtualX, virtualY, err := pool.virtualReserves()
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	return pkg.PriceFromReserves(math.NewIntFromBigInt(virtualX), math.NewIntFromBigInt(virtualY), inputMint == pool.MintX.String())
+}
+
+// MaxInputForImpact solves the constant product of the virtual reserves for
+// the largest input within maxImpactBps of the spot price
+func (pool *ObricPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	virtualX, virtualY, err := pool.virtualReserves()
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn := virtualX
+	switch inputMint {
+	case pool.MintX.String():
+	case pool.MintY.String():
+		reserveIn = virtualY
+	default:
+		return math.ZeroInt(), fmt.Errorf("mint %s is not traded by obric pool %s", inputMint, pool.PoolId)
+	}
+	bps := big.NewInt(int64(maxImpactBps))
+	amount := new(big.Int).Mul(reserveIn, bps)
+	return math.NewIntFromBigInt(amount.Quo(amount, new(big.Int).Sub(big.NewInt(10000), bps))), nil
+}
+
+// swapDiscriminator is the swap for inputMint's direction
+func (pool *ObricPool) swapDiscriminator(inputMint string) []byte {
+	if inputMint == pool.MintY.String() {
+		return SwapYToXDiscriminator
+	}
+	return SwapXToYDiscriminator
+}
+
+// BuildSwapInstructions builds a swap_x_to_y or swap_y_to_x. The fee is
+// paid into the protocol fee account of the output mint
+func (pool *ObricPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	protocolFee := pool.ProtocolFeeY
+	switch inputMint {
+	case pool.MintX.String():
+	case pool.MintY.String():
+		protocolFee = pool.ProtocolFeeX
+	default:
+		return nil, fmt.Errorf("mint %s is not traded by obric pool %s", inputMint, pool.PoolId)
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(pool.MintX),
+		solana.Meta(pool.MintY),
+		solana.Meta(pool.ReserveX).WRITE(),
+		solana.Meta(pool.ReserveY).WRITE(),
+		solana.Meta(userBaseAccount).WRITE(),
+		solana.Meta(userQuoteAccount).WRITE(),
+		solana.Meta(protocolFee).WRITE(),
+		solana.Meta(pool.XPriceFeed),
+		solana.Meta(pool.YPriceFeed),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(solana.TokenProgramID),
+	}
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, pool.swapDiscriminator(inputMint)...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads min_output_amt back from the swap instruction
+func (pool *ObricPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, pool.swapDiscriminator(inputMint), swapMinOutOffset)
+}
+
+// SwapAmountFields locates input_amt and min_output_amt in the swap instruction
+func (pool *ObricPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	prefix := pool.swapDiscriminator(inputMint)
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: prefix, Offset: 8},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: prefix, Offset: swapMinOutOffset}
+}
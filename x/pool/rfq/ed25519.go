@@ -0,0 +1,37 @@
+package rfq
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Ed25519ProgramID is the native program verifying Ed25519 signatures
+var Ed25519ProgramID = solana.MustPublicKeyFromBase58("Ed25519SigVerify111111111111111111111111111")
+
+// currentInstruction marks offsets into the verify instruction's own data
+const currentInstruction = 0xFFFF
+
+// NewEd25519VerifyInstruction builds an Ed25519 program instruction checking
+// one signature of key over message, all carried in its own data: a count and
+// padding byte, the offsets, then the key, signature and message
+func NewEd25519VerifyInstruction(key solana.PublicKey, signature solana.Signature, message []byte) solana.Instruction {
+	const headerSize = 2 + 7*2
+	keyOffset := headerSize
+	signatureOffset := keyOffset + len(key)
+	messageOffset := signatureOffset + len(signature)
+
+	data := make([]byte, 0, messageOffset+len(message))
+	data = append(data, 1, 0)
+	for _, field := range []int{
+		signatureOffset, currentInstruction,
+		keyOffset, currentInstruction,
+		messageOffset, len(message), currentInstruction,
+	} {
+		data = binary.LittleEndian.AppendUint16(data, uint16(field))
+	}
+	data = append(data, key[:]...)
+	data = append(data, signature[:]...)
+	data = append(data, message...)
+	return solana.NewInstruction(Ed25519ProgramID, solana.AccountMetaSlice{}, data)
+}
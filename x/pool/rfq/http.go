@@ -0,0 +1,67 @@
+package rfq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultHTTPTimeout = 2 * time.Second
+
+// maxResponseSize bounds the body read from a maker
+const maxResponseSize = 1 << 20
+
+// HTTPQuoter requests firm quotes by posting the QuoteRequest as JSON to URL
+// and decoding the FirmQuote answered
+type HTTPQuoter struct {
+	URL string
+	// Header is added to every request, e.g. a maker's API key
+	Header http.Header
+	Client *http.Client
+}
+
+// NewHTTPQuoter creates a quoter posting to url
+func NewHTTPQuoter(url string) *HTTPQuoter {
+	return &HTTPQuoter{URL: url}
+}
+
+// RequestQuote posts request and decodes the maker's quote
+func (q *HTTPQuoter) RequestQuote(ctx context.Context, request QuoteRequest) (*FirmQuote, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode quote request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quote request: %w", err)
+	}
+	for key, values := range q.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := q.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request quote: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("maker endpoint returned %s", resp.Status)
+	}
+
+	var quote FirmQuote
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&quote); err != nil {
+		return nil, fmt.Errorf("failed to decode quote: %w", err)
+	}
+	return &quote, nil
+}
@@ -0,0 +1,230 @@
+package rfq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Maker is a market maker answering requests for quote
+type Maker struct {
+	// Name is the protocol name the maker's pools report, e.g. "zerofi"
+	Name pkg.ProtocolName
+	// Key is the public key the maker signs firm quotes with
+	Key solana.PublicKey
+	// ProgramID is the program settling the maker's quotes
+	ProgramID solana.PublicKey
+	Quoter    Quoter
+	// Pairs, when set, limits the maker to these pairs, each as a base and
+	// quote mint in either order
+	Pairs [][2]string
+	// VerifyOnChain, when set, puts an Ed25519 signature check of the quote
+	// ahead of the settlement instruction, for programs reading it from the
+	// instructions sysvar
+	VerifyOnChain bool
+}
+
+// quotes reports whether the maker quotes the pair of a and b
+func (m *Maker) quotes(a, b string) bool {
+	if len(m.Pairs) == 0 {
+		return true
+	}
+	for _, pair := range m.Pairs {
+		if (pair[0] == a && pair[1] == b) || (pair[0] == b && pair[1] == a) {
+			return true
+		}
+	}
+	return false
+}
+
+// RFQPool is one pair of a maker. Quote requests a firm quote and keeps it,
+// and BuildSwapInstructions settles that quote, so a route is built from the
+// quote it was chosen on
+type RFQPool struct {
+	Maker     *Maker
+	BaseMint  solana.PublicKey
+	QuoteMint solana.PublicKey
+	// Taker is the wallet quotes are requested for; zero asks for quotes any
+	// wallet may fill
+	Taker solana.PublicKey
+
+	mu   sync.Mutex
+	last *FirmQuote
+}
+
+// NewRFQPool creates the maker's pool for the pair
+func NewRFQPool(maker *Maker, baseMint, quoteMint, taker solana.PublicKey) *RFQPool {
+	return &RFQPool{Maker: maker, BaseMint: baseMint, QuoteMint: quoteMint, Taker: taker}
+}
+
+func (pool *RFQPool) ProtocolName() pkg.ProtocolName {
+	return pool.Maker.Name
+}
+
+func (pool *RFQPool) GetProgramID() solana.PublicKey {
+	return pool.Maker.ProgramID
+}
+
+// GetID names the pool by maker and pair, as "name:base:quote"
+func (pool *RFQPool) GetID() string {
+	return fmt.Sprintf("%s:%s:%s", pool.Maker.Name, pool.BaseMint, pool.QuoteMint)
+}
+
+func (pool *RFQPool) GetTokens() (string, string) {
+	return pool.BaseMint.String(), pool.QuoteMint.String()
+}
+
+// LastQuote returns the firm quote of the last Quote, nil before one
+func (pool *RFQPool) LastQuote() *FirmQuote {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.last
+}
+
+// Quote requests a firm quote for inputAmount, verifies it and returns the
+// amount the maker commits to pay. The maker's quote is the whole state, so
+// solClient is unused
+func (pool *RFQPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	request := QuoteRequest{AmountIn: inputAmount, Taker: pool.Taker}
+	switch inputMint {
+	case pool.BaseMint.String():
+		request.InputMint, request.OutputMint = pool.BaseMint, pool.QuoteMint
+	case pool.QuoteMint.String():
+		request.InputMint, request.OutputMint = pool.QuoteMint, pool.BaseMint
+	default:
+		return math.ZeroInt(), fmt.Errorf("mint %s is not quoted by rfq pool %s", inputMint, pool.GetID())
+	}
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+
+	quote, err := pool.Maker.Quoter.RequestQuote(ctx, request)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("maker %s failed to quote: %w", pool.Maker.Name, err)
+	}
+	if err := quote.Verify(pool.Maker, request, time.Now()); err != nil {
+		return math.ZeroInt(), fmt.Errorf("maker %s sent an invalid quote: %w", pool.Maker.Name, err)
+	}
+	pool.mu.Lock()
+	pool.last = quote
+	pool.mu.Unlock()
+	return quote.AmountOut, nil
+}
+
+// MinOutUnencoded reports that the maker's settlement fixes the output of
+// the firm quote, which the build checks against minOut
+func (pool *RFQPool) MinOutUnencoded(inputMint string) bool {
+	return true
+}
+
+// BuildSwapInstructions settles the last firm quote, which must be for
+// inputMint and inputAmount, unexpired, fillable by user and paying at least
+// minOut. The maker's settlement instruction names its own accounts, so the
+// user's token accounts are not used
+func (pool *RFQPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	quote := pool.LastQuote()
+	if quote == nil {
+		return nil, fmt.Errorf("rfq pool %s has no firm quote", pool.GetID())
+	}
+	if quote.InputMint.String() != inputMint || !quote.AmountIn.Equal(inputAmount) {
+		return nil, fmt.Errorf("firm quote is for %s of %s, swapping %s of %s", quote.AmountIn, quote.InputMint, inputAmount, inputMint)
+	}
+	if quote.Expired(time.Now()) {
+		return nil, fmt.Errorf("firm quote of rfq pool %s expired at %d", pool.GetID(), quote.ExpiresAt)
+	}
+	if !quote.Taker.IsZero() && !quote.Taker.Equals(user) {
+		return nil, fmt.Errorf("firm quote is for taker %s, not %s", quote.Taker, user)
+	}
+	if quote.AmountOut.LT(minOut) {
+		return nil, fmt.Errorf("firm quote pays %s, below the minimum %s", quote.AmountOut, minOut)
+	}
+
+	instructions := make([]solana.Instruction, 0, 2)
+	if pool.Maker.VerifyOnChain {
+		message, err := quote.Message()
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, NewEd25519VerifyInstruction(pool.Maker.Key, quote.Signature, message))
+	}
+	return append(instructions, quote.Settlement.Build()), nil
+}
+
+// Protocol lists a maker's pairs as pools. Discovery asks nothing of the
+// maker; quoting does
+type Protocol struct {
+	Maker *Maker
+	// Taker is the wallet the pools request quotes for
+	Taker solana.PublicKey
+}
+
+// NewProtocol creates the protocol of a maker quoting for taker
+func NewProtocol(maker *Maker, taker solana.PublicKey) *Protocol {
+	return &Protocol{Maker: maker, Taker: taker}
+}
+
+func (p *Protocol) ProtocolName() pkg.ProtocolName {
+	return p.Maker.Name
+}
+
+// FetchPoolsByPair returns the maker's pool for the pair, none when the
+// maker does not quote it
+func (p *Protocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	if !p.Maker.quotes(baseMint, quoteMint) {
+		return nil, nil
+	}
+	base, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint: %w", err)
+	}
+	quote, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint: %w", err)
+	}
+	return []pkg.Pool{NewRFQPool(p.Maker, base, quote, p.Taker)}, nil
+}
+
+// FetchPoolByID parses an ID from RFQPool.GetID
+func (p *Protocol) FetchPoolByID(ctx context.Context, poolID string) (pkg.Pool, error) {
+	parts := strings.Split(poolID, ":")
+	if len(parts) != 3 || parts[0] != string(p.Maker.Name) {
+		return nil, fmt.Errorf("pool %s is not a %s rfq pool", poolID, p.Maker.Name)
+	}
+	pools, err := p.FetchPoolsByPair(ctx, parts[1], parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("maker %s does not quote pool %s", p.Maker.Name, poolID)
+	}
+	return pools[0], nil
+}
+
+// FetchPoolsByIDs returns the pools of the IDs the maker quotes
+func (p *Protocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	pools := make([]pkg.Pool, 0, len(poolIDs))
+	for _, poolID := range poolIDs {
+		pool, err := p.FetchPoolByID(ctx, poolID)
+		if err != nil {
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
@@ -0,0 +1,150 @@
+// Package rfq mixes private market maker liquidity into routing. A maker
+// answers requests for quote, over HTTP, a websocket or any Quoter, with a
+// firm quote it signs and settles through its own program. Each maker is a
+// protocol whose pools are the pairs it quotes, so its quotes compete with
+// on-chain pools
+package rfq
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+)
+
+// QuoteRequest asks a maker for a firm quote of AmountIn of InputMint
+type QuoteRequest struct {
+	InputMint  solana.PublicKey `json:"input_mint"`
+	OutputMint solana.PublicKey `json:"output_mint"`
+	AmountIn   math.Int         `json:"amount_in"`
+	// Taker is the wallet that will fill the quote; zero asks for a quote
+	// any wallet may fill
+	Taker solana.PublicKey `json:"taker"`
+}
+
+// AccountMeta is an account of a settlement instruction
+type AccountMeta struct {
+	PublicKey  solana.PublicKey `json:"pubkey"`
+	IsSigner   bool             `json:"is_signer"`
+	IsWritable bool             `json:"is_writable"`
+}
+
+// Instruction is the instruction settling a firm quote on the maker's program
+type Instruction struct {
+	ProgramID solana.PublicKey `json:"program_id"`
+	Accounts  []AccountMeta    `json:"accounts"`
+	Data      []byte           `json:"data"`
+}
+
+// Build returns the instruction in solana-go form
+func (i Instruction) Build() solana.Instruction {
+	accounts := make(solana.AccountMetaSlice, 0, len(i.Accounts))
+	for _, account := range i.Accounts {
+		accounts = append(accounts, solana.NewAccountMeta(account.PublicKey, account.IsWritable, account.IsSigner))
+	}
+	return solana.NewInstruction(i.ProgramID, accounts, i.Data)
+}
+
+// FirmQuote is a maker's commitment to pay AmountOut for AmountIn until
+// ExpiresAt, signed by the maker's key over Message
+type FirmQuote struct {
+	InputMint  solana.PublicKey `json:"input_mint"`
+	OutputMint solana.PublicKey `json:"output_mint"`
+	Taker      solana.PublicKey `json:"taker"`
+	AmountIn   math.Int         `json:"amount_in"`
+	AmountOut  math.Int         `json:"amount_out"`
+	// ExpiresAt is the unix time the quote stops being honoured
+	ExpiresAt int64 `json:"expires_at"`
+	// Nonce is unique per quote of the maker, so its program can refuse a
+	// second fill
+	Nonce      uint64           `json:"nonce"`
+	Signature  solana.Signature `json:"signature"`
+	Settlement Instruction      `json:"settlement"`
+}
+
+// messageSize is the size of the signed message
+const messageSize = 3*32 + 4*8
+
+// Message is what the maker signs: the input mint, output mint and taker,
+// then amount in, amount out, expiry and nonce as little endian u64s. Its
+// fixed layout lets settlement programs parse it
+func (q *FirmQuote) Message() ([]byte, error) {
+	if !q.AmountIn.IsUint64() || !q.AmountOut.IsUint64() {
+		return nil, fmt.Errorf("quote amounts exceed uint64")
+	}
+	message := make([]byte, 0, messageSize)
+	message = append(message, q.InputMint[:]...)
+	message = append(message, q.OutputMint[:]...)
+	message = append(message, q.Taker[:]...)
+	message = binary.LittleEndian.AppendUint64(message, q.AmountIn.Uint64())
+	message = binary.LittleEndian.AppendUint64(message, q.AmountOut.Uint64())
+	message = binary.LittleEndian.AppendUint64(message, uint64(q.ExpiresAt))
+	message = binary.LittleEndian.AppendUint64(message, q.Nonce)
+	return message, nil
+}
+
+// Sign signs the quote with the maker's key, for makers built on this package
+func (q *FirmQuote) Sign(key solana.PrivateKey) error {
+	message, err := q.Message()
+	if err != nil {
+		return err
+	}
+	q.Signature, err = key.Sign(message)
+	if err != nil {
+		return fmt.Errorf("failed to sign quote: %w", err)
+	}
+	return nil
+}
+
+// Expired reports whether the quote is no longer honoured at now
+func (q *FirmQuote) Expired(now time.Time) bool {
+	return now.Unix() >= q.ExpiresAt
+}
+
+// Verify checks that the quote answers request, is signed by maker's key,
+// settles on maker's program and has not expired at now
+func (q *FirmQuote) Verify(maker *Maker, request QuoteRequest, now time.Time) error {
+	if !q.InputMint.Equals(request.InputMint) || !q.OutputMint.Equals(request.OutputMint) {
+		return fmt.Errorf("quote is for %s to %s, requested %s to %s", q.InputMint, q.OutputMint, request.InputMint, request.OutputMint)
+	}
+	if q.AmountIn.IsNil() || !q.AmountIn.Equal(request.AmountIn) {
+		return fmt.Errorf("quote is for %s in, requested %s", q.AmountIn, request.AmountIn)
+	}
+	if !request.Taker.IsZero() && !q.Taker.IsZero() && !q.Taker.Equals(request.Taker) {
+		return fmt.Errorf("quote is for taker %s, requested %s", q.Taker, request.Taker)
+	}
+	if q.AmountOut.IsNil() || !q.AmountOut.IsPositive() {
+		return fmt.Errorf("quote pays nothing")
+	}
+	if q.Expired(now) {
+		return fmt.Errorf("quote expired at %d", q.ExpiresAt)
+	}
+	if !q.Settlement.ProgramID.Equals(maker.ProgramID) {
+		return fmt.Errorf("quote settles on %s, not on maker program %s", q.Settlement.ProgramID, maker.ProgramID)
+	}
+	message, err := q.Message()
+	if err != nil {
+		return err
+	}
+	if !q.Signature.Verify(maker.Key, message) {
+		return fmt.Errorf("quote is not signed by maker key %s", maker.Key)
+	}
+	return nil
+}
+
+// Quoter requests firm quotes from one maker
+type Quoter interface {
+	RequestQuote(ctx context.Context, request QuoteRequest) (*FirmQuote, error)
+}
+
+// QuoterFunc adapts a function to Quoter, for makers reached over another
+// transport
+type QuoterFunc func(ctx context.Context, request QuoteRequest) (*FirmQuote, error)
+
+// RequestQuote calls f
+func (f QuoterFunc) RequestQuote(ctx context.Context, request QuoteRequest) (*FirmQuote, error) {
+	return f(ctx, request)
+}
@@ -0,0 +1,55 @@
+// Package saber quotes and builds swaps on Saber, a two-token stable swap
+// whose invariant blends constant sum and constant product by an
+// amplification coefficient, so pegged pairs trade near one to one until a
+// reserve runs low
+package saber
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// Name is the protocol name of Saber pools
+const Name pkg.ProtocolName = "saber"
+
+var (
+	// ProgramID is the Saber stable swap program
+	ProgramID = solana.MustPublicKeyFromBase58("SSwpkEEcbUqx4vtvYUwmM6JUhPL2A3CBCMuRkL9LdQi")
+
+	// SwapTag leads a swap instruction, followed by amount_in and
+	// minimum_amount_out
+	SwapTag = []byte{1}
+)
+
+// Saber swap info account layout
+const (
+	SwapInfoSize     = 395
+	TokenAMintOffset = 203
+	TokenBMintOffset = 235
+
+	isInitializedOffset     = 0
+	isPausedOffset          = 1
+	nonceOffset             = 2
+	initialAmpFactorOffset  = 3
+	targetAmpFactorOffset   = 11
+	startRampTsOffset       = 19
+	stopRampTsOffset        = 27
+	tokenAReserveOffset     = 107
+	tokenBReserveOffset     = 139
+	poolMintOffset          = 171
+	adminFeeAOffset         = 267
+	adminFeeBOffset         = 299
+	tradeFeeNumeratorOffset = 363
+	tradeFeeDenomOffset     = 371
+)
+
+const (
+	swapDataSize = 17
+
+	// nCoins is the number of tokens in a pool
+	nCoins = 2
+	// maxIterations bounds the Newton iterations for D and y, as the program does
+	maxIterations = 256
+	// maxImpactSearchSteps bounds both the doubling and the bisection phase
+	maxImpactSearchSteps = 128
+)
@@ -0,0 +1,36 @@
+package saber
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses a Saber swap instruction. It does not name the mints, so
+// the input and output mints are left zero
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapTag) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 9); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          Name,
+		Pool:              accounts[0].PublicKey,
+		User:              accounts[2].PublicKey,
+		UserInputAccount:  accounts[3].PublicKey,
+		UserOutputAccount: accounts[6].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[1:9])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[9:17])),
+	}
+	return params, nil
+}
@@ -0,0 +1,134 @@
+package saber
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ampFactor is the amplification coefficient at now: it moves linearly from
+// the initial to the target value while a ramp is under way
+func (pool *SaberPool) ampFactor(now int64) uint64 {
+	if now >= pool.StopRampTs || pool.StopRampTs <= pool.StartRampTs {
+		return pool.TargetAmpFactor
+	}
+	timeRange := big.NewInt(pool.StopRampTs - pool.StartRampTs)
+	timeDelta := big.NewInt(max(now-pool.StartRampTs, 0))
+	if pool.TargetAmpFactor >= pool.InitialAmpFactor {
+		delta := new(big.Int).Mul(new(big.Int).SetUint64(pool.TargetAmpFactor-pool.InitialAmpFactor), timeDelta)
+		return pool.InitialAmpFactor + delta.Quo(delta, timeRange).Uint64()
+	}
+	delta := new(big.Int).Mul(new(big.Int).SetUint64(pool.InitialAmpFactor-pool.TargetAmpFactor), timeDelta)
+	return pool.InitialAmpFactor - delta.Quo(delta, timeRange).Uint64()
+}
+
+// withinOne reports whether a and b differ by at most one, the precision the
+// program's Newton iterations stop at
+func withinOne(a, b *big.Int) bool {
+	diff := new(big.Int).Sub(a, b)
+	return diff.CmpAbs(big.NewInt(1)) <= 0
+}
+
+// computeD solves the invariant for D given the reserves, by Newton's method
+// with the program's integer steps
+func computeD(amp uint64, amountA, amountB *big.Int) (*big.Int, error) {
+	sum := new(big.Int).Add(amountA, amountB)
+	if sum.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	if amountA.Sign() == 0 || amountB.Sign() == 0 {
+		return nil, fmt.Errorf("stable swap reserve is empty")
+	}
+	coins := big.NewInt(nCoins)
+	aTimesCoins := new(big.Int).Mul(amountA, coins)
+	bTimesCoins := new(big.Int).Mul(amountB, coins)
+	ann := new(big.Int).Mul(new(big.Int).SetUint64(amp), coins)
+	leverage := new(big.Int).Mul(sum, ann)
+	annMinusOne := new(big.Int).Sub(ann, big.NewInt(1))
+
+	d := new(big.Int).Set(sum)
+	for i := 0; i < maxIterations; i++ {
+		dProd := new(big.Int).Mul(d, d)
+		dProd.Quo(dProd, aTimesCoins)
+		dProd.Mul(dProd, d).Quo(dProd, bTimesCoins)
+		prev := d
+
+		// d = (ann * sum + d_prod * n) * d / ((ann - 1) * d + (n + 1) * d_prod)
+		numerator := new(big.Int).Mul(dProd, coins)
+		numerator.Add(numerator, leverage).Mul(numerator, prev)
+		denominator := new(big.Int).Mul(prev, annMinusOne)
+		denominator.Add(denominator, new(big.Int).Mul(dProd, big.NewInt(nCoins+1)))
+		if denominator.Sign() == 0 {
+			return nil, fmt.Errorf("stable swap invariant diverged")
+		}
+		d = numerator.Quo(numerator, denominator)
+		if withinOne(d, prev) {
+			break
+		}
+	}
+	return d, nil
+}
+
+// computeY solves the invariant D for the other reserve once one reserve is x
+func computeY(amp uint64, x, d *big.Int) (*big.Int, error) {
+	if x.Sign() == 0 {
+		return nil, fmt.Errorf("stable swap reserve is empty")
+	}
+	coins := big.NewInt(nCoins)
+	ann := new(big.Int).Mul(new(big.Int).SetUint64(amp), coins)
+	// c = D^(n+1) / (n^(2n) * prod' * A), b = sum' + D / (A * n^n)
+	c := new(big.Int).Mul(d, d)
+	c.Quo(c, new(big.Int).Mul(x, coins))
+	c.Mul(c, d).Quo(c, new(big.Int).Mul(ann, coins))
+	b := new(big.Int).Quo(d, ann)
+	b.Add(b, x)
+
+	// y^2 + b*y = c, by y = (y^2 + c) / (2y + b - D)
+	y := new(big.Int).Set(d)
+	for i := 0; i < maxIterations; i++ {
+		prev := y
+		numerator := new(big.Int).Mul(prev, prev)
+		numerator.Add(numerator, c)
+		denominator := new(big.Int).Lsh(prev, 1)
+		denominator.Add(denominator, b).Sub(denominator, d)
+		if denominator.Sign() <= 0 {
+			return nil, fmt.Errorf("stable swap invariant diverged")
+		}
+		y = numerator.Quo(numerator, denominator)
+		if withinOne(y, prev) {
+			break
+		}
+	}
+	return y, nil
+}
+
+// curveOut is the output of swapping amountIn against the reserves, whose
+// invariant is d at the amplification amp, before the trade fee. It is one
+// below the exact difference, as the program rounds against the trader
+func curveOut(amp uint64, d, reserveIn, reserveOut, amountIn *big.Int) (*big.Int, error) {
+	y, err := computeY(amp, new(big.Int).Add(reserveIn, amountIn), d)
+	if err != nil {
+		return nil, err
+	}
+	dy := new(big.Int).Sub(reserveOut, y)
+	dy.Sub(dy, big.NewInt(1))
+	if dy.Sign() <= 0 {
+		return nil, fmt.Errorf("swap of %s is too small", amountIn)
+	}
+	return dy, nil
+}
+
+// spotPrice is the marginal output per unit of input at the reserves, whose
+// invariant is d, as num / den: the ratio of the invariant's partial
+// derivatives, y * (4 ann x^2 y + D^3) / (x * (4 ann x y^2 + D^3))
+func spotPrice(amp uint64, d, reserveIn, reserveOut *big.Int) (*big.Int, *big.Int) {
+	ann4 := new(big.Int).SetUint64(amp)
+	ann4.Mul(ann4, big.NewInt(nCoins*4))
+	d3 := new(big.Int).Exp(d, big.NewInt(3), nil)
+	xy := new(big.Int).Mul(reserveIn, reserveOut)
+
+	num := new(big.Int).Mul(ann4, xy)
+	num.Mul(num, reserveIn).Add(num, d3).Mul(num, reserveOut)
+	den := new(big.Int).Mul(ann4, xy)
+	den.Mul(den, reserveOut).Add(den, d3).Mul(den, reserveIn)
+	return num, den
+}
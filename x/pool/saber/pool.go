@@ -0,0 +1,374 @@
+package saber
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// SaberPool is a Saber stable swap. Token A and token B are the base and
+// quote mints; their reserves sit in token accounts owned by the swap
+// authority
+type SaberPool struct {
+	PoolId        solana.PublicKey
+	TokenAMint    solana.PublicKey
+	TokenBMint    solana.PublicKey
+	TokenAReserve solana.PublicKey
+	TokenBReserve solana.PublicKey
+	PoolMint      solana.PublicKey
+	// AdminFeeA and AdminFeeB receive the admin's share of fees taken in
+	// token A and token B
+	AdminFeeA solana.PublicKey
+	AdminFeeB solana.PublicKey
+	Nonce     uint8
+
+	// Paused swaps reject trades
+	Paused bool
+	// The amplification coefficient moves from InitialAmpFactor at
+	// StartRampTs to TargetAmpFactor at StopRampTs
+	InitialAmpFactor    uint64
+	TargetAmpFactor     uint64
+	StartRampTs         int64
+	StopRampTs          int64
+	TradeFeeNumerator   uint64
+	TradeFeeDenominator uint64
+
+	// BaseReserve and QuoteReserve are the token A and B balances of the last quote
+	BaseReserve  math.Int
+	QuoteReserve math.Int
+	// decimals of token A and B, nil until a quote loads them
+	decimals *[2]uint8
+	// now is the cluster time of the last quote, which the ramp is read at
+	now int64
+}
+
+func (pool *SaberPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *SaberPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (pool *SaberPool) GetID() string {
+	return pool.PoolId.String()
+}
+
+// GetTokens returns token A as base and token B as quote
+func (pool *SaberPool) GetTokens() (string, string) {
+	return pool.TokenAMint.String(), pool.TokenBMint.String()
+}
+
+// Decode parses a Saber swap info account
+func (pool *SaberPool) Decode(data []byte) error {
+	if len(data) < SwapInfoSize {
+		return fmt.Errorf("saber swap account too short: %d bytes", len(data))
+	}
+	if data[isInitializedOffset] == 0 {
+		return fmt.Errorf("saber swap is not initialized")
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	key := func(offset int) solana.PublicKey { return solana.PublicKeyFromBytes(data[offset : offset+32]) }
+
+	pool.Paused = data[isPausedOffset] != 0
+	pool.Nonce = data[nonceOffset]
+	pool.InitialAmpFactor = u64(initialAmpFactorOffset)
+	pool.TargetAmpFactor = u64(targetAmpFactorOffset)
+	pool.StartRampTs = int64(u64(startRampTsOffset))
+	pool.StopRampTs = int64(u64(stopRampTsOffset))
+	pool.TokenAReserve = key(tokenAReserveOffset)
+	pool.TokenBReserve = key(tokenBReserveOffset)
+	pool.PoolMint = key(poolMintOffset)
+	pool.TokenAMint = key(TokenAMintOffset)
+	pool.TokenBMint = key(TokenBMintOffset)
+	pool.AdminFeeA = key(adminFeeAOffset)
+	pool.AdminFeeB = key(adminFeeBOffset)
+	pool.TradeFeeNumerator = u64(tradeFeeNumeratorOffset)
+	pool.TradeFeeDenominator = u64(tradeFeeDenomOffset)
+	if pool.TargetAmpFactor == 0 {
+		return fmt.Errorf("invalid amplification coefficient 0")
+	}
+	if pool.TradeFeeDenominator != 0 && pool.TradeFeeNumerator >= pool.TradeFeeDenominator {
+		return fmt.Errorf("invalid trade fee %d/%d", pool.TradeFeeNumerator, pool.TradeFeeDenominator)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pool while
+// keeping the reserves, decimals and cluster time of the last quote
+func (pool *SaberPool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*SaberPool)
+	if !ok || fresh == pool || !fresh.PoolId.Equals(pool.PoolId) {
+		return false
+	}
+	base, quote, decimals, now := pool.BaseReserve, pool.QuoteReserve, pool.decimals, pool.now
+	*pool = *fresh
+	pool.BaseReserve, pool.QuoteReserve, pool.decimals, pool.now = base, quote, decimals, now
+	return true
+}
+
+// MintDecimals returns the decimals of mint loaded by the last Quote
+func (pool *SaberPool) MintDecimals(mint string) (uint8, bool) {
+	if pool.decimals == nil {
+		return 0, false
+	}
+	switch mint {
+	case pool.TokenAMint.String():
+		return pool.decimals[0], true
+	case pool.TokenBMint.String():
+		return pool.decimals[1], true
+	}
+	return 0, false
+}
+
+// Quote refreshes the swap, its reserves, the mints' decimals and the
+// cluster time in one batch and returns the output for inputAmount
+func (pool *SaberPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{
+		pool.PoolId, pool.TokenAReserve, pool.TokenBReserve, pool.TokenAMint, pool.TokenBMint,
+		solana.SysVarClockPubkey,
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a swap missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode saber swap %s: %w", pool.PoolId, err)
+		}
+	}
+	baseReserve, ok := sol.TokenAccountAmount(results, 1)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("token A reserve %s not found", pool.TokenAReserve)
+	}
+	quoteReserve, ok := sol.TokenAccountAmount(results, 2)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("token B reserve %s not found", pool.TokenBReserve)
+	}
+	pool.BaseReserve = math.NewIntFromUint64(baseReserve)
+	pool.QuoteReserve = math.NewIntFromUint64(quoteReserve)
+	var decimals [2]uint8
+	for i := 0; i < 2; i++ {
+		d, ok := sol.MintDecimals(results, 3+i)
+		if !ok {
+			return math.ZeroInt(), fmt.Errorf("mint %s not found", accounts[3+i])
+		}
+		decimals[i] = d
+	}
+	pool.decimals = &decimals
+	data, ok := sol.AccountData(results, 5)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("clock account not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	pool.now = int64(clock.UnixTimestamp)
+
+	if pool.Paused {
+		return math.ZeroInt(), fmt.Errorf("saber swap %s is paused", pool.PoolId)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// curve returns the input and output reserves for inputMint, the
+// amplification coefficient at the last quote's time and the invariant
+func (pool *SaberPool) curve(inputMint string) (*big.Int, *big.Int, uint64, *big.Int, error) {
+	if pool.BaseReserve.IsNil() || pool.QuoteReserve.IsNil() {
+		return nil, nil, 0, nil, fmt.Errorf("pool state not loaded")
+	}
+	if !pool.BaseReserve.IsPositive() || !pool.QuoteReserve.IsPositive() {
+		return nil, nil, 0, nil, fmt.Errorf("saber swap %s has an empty reserve", pool.PoolId)
+	}
+	var reserveIn, reserveOut *big.Int
+	switch inputMint {
+	case pool.TokenAMint.String():
+		reserveIn, reserveOut = pool.BaseReserve.BigInt(), pool.QuoteReserve.BigInt()
+	case pool.TokenBMint.String():
+		reserveIn, reserveOut = pool.QuoteReserve.BigInt(), pool.BaseReserve.BigInt()
+	default:
+		return nil, nil, 0, nil, fmt.Errorf("mint %s is not traded by saber swap %s", inputMint, pool.PoolId)
+	}
+	amp := pool.ampFactor(pool.now)
+	d, err := computeD(amp, reserveIn, reserveOut)
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+	return reserveIn, reserveOut, amp, d, nil
+}
+
+// tradeFee is the trade fee on an output of amount, rounded down
+func (pool *SaberPool) tradeFee(amount *big.Int) *big.Int {
+	if pool.TradeFeeDenominator == 0 {
+		return new(big.Int)
+	}
+	fee := new(big.Int).Mul(amount, new(big.Int).SetUint64(pool.TradeFeeNumerator))
+	return fee.Quo(fee, new(big.Int).SetUint64(pool.TradeFeeDenominator))
+}
+
+// ComputeAmountOut solves the stable swap invariant against the cached
+// reserves and takes the trade fee off the output, as the program does
+func (pool *SaberPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	reserveIn, reserveOut, amp, d, err := pool.curve(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	amountOut, err := curveOut(amp, d, reserveIn, reserveOut, inputAmount.BigInt())
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	amountOut.Sub(amountOut, pool.tradeFee(amountOut))
+	if amountOut.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("swap of %s is too small", inputAmount)
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// SwapFee approximates the trade fee in input units. The program charges it
+// on the output, which trades near one to one with the input on a balanced
+// swap
+func (pool *SaberPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return math.NewIntFromBigInt(pool.tradeFee(inputAmount.BigInt()))
+}
+
+// Reserves returns the token A and B amounts cached by the last Quote
+func (pool *SaberPool) Reserves() (math.Int, math.Int) {
+	return pool.BaseReserve, pool.QuoteReserve
+}
+
+// RawSpotPrice is the marginal price of the invariant at the cached reserves
+func (pool *SaberPool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	reserveIn, reserveOut, amp, d, err := pool.curve(inputMint)
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	num, den := spotPrice(amp, d, reserveIn, reserveOut)
+	return pkg.RatioPrice(num, den, false)
+}
+
+// MaxInputForImpact searches for the largest input whose average price,
+// before the trade fee, is within maxImpactBps of the marginal price. The
+// average price falls as the input grows, so the bound is found by doubling
+// and then bisection, starting from the constant product bound the
+// amplified curve always clears
+func (pool *SaberPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	reserveIn, reserveOut, amp, d, err := pool.curve(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	num, den := spotPrice(amp, d, reserveIn, reserveOut)
+	bps := big.NewInt(int64(maxImpactBps))
+	keep := new(big.Int).Sub(big.NewInt(10000), bps)
+	// within reports whether out / amount >= num / den * (1 - bps)
+	within := func(amount *big.Int) bool {
+		out, err := curveOut(amp, d, reserveIn, reserveOut, amount)
+		if err != nil {
+			return false
+		}
+		lhs := new(big.Int).Mul(out, den)
+		lhs.Mul(lhs, big.NewInt(10000))
+		rhs := new(big.Int).Mul(amount, num)
+		rhs.Mul(rhs, keep)
+		return lhs.Cmp(rhs) >= 0
+	}
+
+	hi := new(big.Int).Mul(reserveIn, bps)
+	hi.Quo(hi, keep)
+	if hi.Sign() == 0 {
+		hi.SetInt64(1)
+	}
+	lo := new(big.Int)
+	for i := 0; i < maxImpactSearchSteps && within(hi); i++ {
+		lo.Set(hi)
+		hi.Lsh(hi, 1)
+	}
+	for i := 0; i < maxImpactSearchSteps && new(big.Int).Sub(hi, lo).Cmp(big.NewInt(1)) > 0; i++ {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if within(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return math.NewIntFromBigInt(lo), nil
+}
+
+// Authority derives the swap authority that owns the reserves from the
+// nonce stored in the swap
+func (pool *SaberPool) Authority() (solana.PublicKey, error) {
+	authority, err := solana.CreateProgramAddress([][]byte{pool.PoolId.Bytes(), {pool.Nonce}}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive swap authority: %w", err)
+	}
+	return authority, nil
+}
+
+// BuildSwapInstructions builds a Saber swap, paying the admin's share of the
+// fee into the admin fee account of the output token
+func (pool *SaberPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	authority, err := pool.Authority()
+	if err != nil {
+		return nil, err
+	}
+	source, destination := userBaseAccount, userQuoteAccount
+	poolSource, poolDestination, adminFee := pool.TokenAReserve, pool.TokenBReserve, pool.AdminFeeB
+	if inputMint == pool.TokenBMint.String() {
+		source, destination = userQuoteAccount, userBaseAccount
+		poolSource, poolDestination, adminFee = pool.TokenBReserve, pool.TokenAReserve, pool.AdminFeeA
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(pool.PoolId),
+		solana.Meta(authority),
+		solana.Meta(user).SIGNER(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(poolSource).WRITE(),
+		solana.Meta(poolDestination).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(adminFee).WRITE(),
+		solana.Meta(solana.TokenProgramID),
+	}
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, SwapTag...)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_amount_out back from the swap instruction
+func (pool *SaberPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, SwapTag, 9)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in the swap instruction
+func (pool *SaberPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SwapTag, Offset: 1},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SwapTag, Offset: 9}
+}
@@ -0,0 +1,131 @@
+package sanctum
+
+import (
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// Lst is one LST of the pool and the state its SOL value calculator reads
+type Lst struct {
+	Mint         solana.PublicKey
+	TokenProgram solana.PublicKey
+	Calculator   solana.PublicKey
+	// Source is the stake pool or Marinade state the calculator prices
+	// from, zero for wSOL
+	Source        solana.PublicKey
+	SourceProgram solana.PublicKey
+
+	// Index is the LST's position in the state list, InputDisabled whether
+	// the pool refuses it as input, Reserves the pool's balance and
+	// InputFeeBps and OutputFeeBps its flat fees, all as of the last quote
+	Index         uint32
+	InputDisabled bool
+	Reserves      uint64
+	InputFeeBps   int16
+	OutputFeeBps  int16
+
+	stakePool *stakepool.StakePool
+	marinade  *marinade.MarinadePool
+}
+
+// StakePoolProgram returns the stake pool program an SPL type calculator
+// prices for, false for other calculators
+func StakePoolProgram(calculator solana.PublicKey) (solana.PublicKey, bool) {
+	switch calculator {
+	case SPLCalculatorID:
+		return stakepool.ProgramID, true
+	case SanctumSPLCalculatorID:
+		return SanctumSPLProgramID, true
+	case SanctumSPLMultiCalculatorID:
+		return SanctumSPLMultiProgramID, true
+	}
+	return solana.PublicKey{}, false
+}
+
+// Supported reports whether the LST's calculator can be priced here
+func Supported(calculator solana.PublicKey) bool {
+	if _, ok := StakePoolProgram(calculator); ok {
+		return true
+	}
+	return calculator.Equals(WSOLCalculatorID) || calculator.Equals(MarinadeCalculatorID)
+}
+
+// decodeSource parses the account the calculator prices from, checking a
+// stake pool is updated for epoch as the calculators do
+func (lst *Lst) decodeSource(data []byte, epoch uint64) error {
+	switch {
+	case lst.Calculator.Equals(WSOLCalculatorID):
+		return nil
+	case lst.Calculator.Equals(MarinadeCalculatorID):
+		state := &marinade.MarinadePool{PoolId: lst.Source}
+		if err := state.Decode(data); err != nil {
+			return err
+		}
+		lst.marinade = state
+		return nil
+	}
+	pool := &stakepool.StakePool{PoolId: lst.Source, Program: lst.SourceProgram}
+	if err := pool.Decode(data); err != nil {
+		return err
+	}
+	if pool.LastUpdateEpoch < epoch {
+		return fmt.Errorf("stake pool %s is not updated for epoch %d", lst.Source, epoch)
+	}
+	lst.stakePool = pool
+	return nil
+}
+
+// SolValue prices amount of the LST in lamports, rounded down
+func (lst *Lst) SolValue(amount math.Int) (math.Int, error) {
+	switch {
+	case lst.Calculator.Equals(WSOLCalculatorID):
+		return amount, nil
+	case lst.marinade != nil:
+		return lst.marinade.SolValue(amount), nil
+	case lst.stakePool != nil:
+		return lst.stakePool.SolValue(amount), nil
+	}
+	return math.ZeroInt(), fmt.Errorf("lst %s has no calculator state loaded", lst.Mint)
+}
+
+// LstAmount is the most of the LST worth at most lamports
+func (lst *Lst) LstAmount(lamports math.Int) (math.Int, error) {
+	switch {
+	case lst.Calculator.Equals(WSOLCalculatorID):
+		return lamports, nil
+	case lst.marinade != nil:
+		return lst.marinade.MsolForSolValue(lamports), nil
+	case lst.stakePool != nil:
+		return lst.stakePool.PoolTokensForSolValue(lamports), nil
+	}
+	return math.ZeroInt(), fmt.Errorf("lst %s has no calculator state loaded", lst.Mint)
+}
+
+// calculatorAccounts lists the calculator program and the accounts its
+// lst_to_sol and sol_to_lst read, as swaps pass them
+func (lst *Lst) calculatorAccounts() (solana.AccountMetaSlice, error) {
+	if lst.Calculator.Equals(WSOLCalculatorID) {
+		return solana.AccountMetaSlice{solana.Meta(lst.Calculator), solana.Meta(lst.Mint)}, nil
+	}
+	state, _, err := sol.FindProgramAddress([][]byte{stateSeed}, lst.Calculator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive calculator state: %w", err)
+	}
+	programData, _, err := sol.FindProgramAddress([][]byte{lst.SourceProgram[:]}, bpfLoaderUpgradeableProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive program data: %w", err)
+	}
+	return solana.AccountMetaSlice{
+		solana.Meta(lst.Calculator),
+		solana.Meta(lst.Mint),
+		solana.Meta(state),
+		solana.Meta(lst.Source),
+		solana.Meta(lst.SourceProgram),
+		solana.Meta(programData),
+	}, nil
+}
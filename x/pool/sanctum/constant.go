@@ -0,0 +1,82 @@
+// Package sanctum quotes and builds swaps on Sanctum Infinity, a multi-LST
+// pool that swaps any two of its liquid staking tokens, wSOL included, at
+// their SOL values less a flat fee. Each LST is valued by a SOL value
+// calculator program; the SPL stake pool, Sanctum stake pool, Marinade and
+// wSOL calculators are supported
+package sanctum
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// Name is the protocol name of Infinity pools
+const Name pkg.ProtocolName = "sanctum_infinity"
+
+var (
+	// ProgramID is the Infinity S controller program
+	ProgramID = solana.MustPublicKeyFromBase58("5ocnV1qiCgaQR8Jb8xWnVbApfaygJ8tNoZfgPwsgx9kx")
+	// FlatFeeProgramID is the pricing program charging each LST a fixed
+	// input and output fee
+	FlatFeeProgramID = solana.MustPublicKeyFromBase58("f1tUoNnhhHdxnJHr7GFbDNWz5p7cTbfrWLMhx1tR6EJ")
+
+	// SOL value calculator programs
+	WSOLCalculatorID              = solana.MustPublicKeyFromBase58("wsoGmxQLSvwWpuaidCApxN5kEowLe2HLQLJhCQnj4bE")
+	SPLCalculatorID               = solana.MustPublicKeyFromBase58("sp1V4h2gWorkGhVcazBc22Hfo2f5sd7jcjT4EDPrWFF")
+	SanctumSPLCalculatorID        = solana.MustPublicKeyFromBase58("sspUE1vrh7xRoXxGsg7vR1zde2WdGtJRbyK9uRumBDy")
+	SanctumSPLMultiCalculatorID   = solana.MustPublicKeyFromBase58("ssmbu3KZxgonUtjEMCKspZzxvUQCxAFnyh1rcHUeEDo")
+	MarinadeCalculatorID          = solana.MustPublicKeyFromBase58("mare3SCyfZkAndpBRBeonETmkCCB3TJTTrz8ZN2dnhP")
+	bpfLoaderUpgradeableProgramID = solana.MustPublicKeyFromBase58("BPFLoaderUpgradeab1e11111111111111111111111")
+
+	// SanctumSPLProgramID and SanctumSPLMultiProgramID are Sanctum's
+	// deployments of the SPL stake pool program, for single and multi
+	// validator pools
+	SanctumSPLProgramID      = solana.MustPublicKeyFromBase58("SP12tWFxD9oJsVWNavTTBZvMbA6gkAmxtVgxdqvyvhY")
+	SanctumSPLMultiProgramID = solana.MustPublicKeyFromBase58("SPMBzsVUuoHA4Jm6KunbsotaahvVikZs1JyTW6iJvbn")
+
+	// SwapExactInTag leads a swap_exact_in
+	SwapExactInTag = []byte{1}
+)
+
+// Seeds of the S controller and pricing program PDAs
+var (
+	stateSeed       = []byte("state")
+	lstStateSeed    = []byte("lst-state-list")
+	protocolFeeSeed = []byte("protocol-fee")
+	feeAccountSeed  = []byte("fee")
+)
+
+// Pool state layout, a packed struct without discriminator
+const (
+	isDisabledOffset     = 13
+	isRebalancingOffset  = 14
+	pricingProgramOffset = 112
+	lpTokenMintOffset    = 144
+	poolStateSize        = 176
+)
+
+// LST state list layout: a packed array of LstState entries
+const (
+	lstInputDisabledOffset = 0
+	lstSolValueOffset      = 8
+	lstMintOffset          = 16
+	lstCalculatorOffset    = 48
+	lstStateSize           = 80
+)
+
+// Flat fee account layout: the input and output fees as i16 bps
+const (
+	feeAccountSize = 4
+	bpsDenominator = 10_000
+)
+
+// swap_exact_in data: the tag, the counts of the source and destination
+// calculator accounts, the source and destination LST indices as u32s, then
+// min_amount_out and amount as u64s
+const (
+	swapMinOutOffset = 11
+	swapAmountOffset = 19
+	swapDataSize     = 27
+	// swapFixedAccounts precede the calculator and pricing accounts
+	swapFixedAccounts = 12
+)
@@ -0,0 +1,38 @@
+package sanctum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeSwap parses an Infinity swap_exact_in. Every pair shares the pool
+// state, so Pool names it rather than the pair
+func DecodeSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapExactInTag) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapDataSize {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, swapFixedAccounts); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          Name,
+		User:              accounts[0].PublicKey,
+		InputMint:         accounts[1].PublicKey,
+		OutputMint:        accounts[2].PublicKey,
+		UserInputAccount:  accounts[3].PublicKey,
+		UserOutputAccount: accounts[4].PublicKey,
+		Pool:              accounts[8].PublicKey,
+		AmountIn:          math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapAmountOffset:])),
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapMinOutOffset:])),
+	}
+	return params, nil
+}
@@ -0,0 +1,424 @@
+package sanctum
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// LstState is an entry of the pool's LST state list
+type LstState struct {
+	Index         uint32
+	Mint          solana.PublicKey
+	Calculator    solana.PublicKey
+	InputDisabled bool
+	SolValue      uint64
+}
+
+// ParseLstStates parses the LST state list account
+func ParseLstStates(data []byte) ([]LstState, error) {
+	if len(data)%lstStateSize != 0 {
+		return nil, fmt.Errorf("lst state list of %d bytes is not a whole number of entries", len(data))
+	}
+	states := make([]LstState, 0, len(data)/lstStateSize)
+	for i := 0; i < len(data); i += lstStateSize {
+		entry := data[i : i+lstStateSize]
+		states = append(states, LstState{
+			Index:         uint32(i / lstStateSize),
+			Mint:          solana.PublicKeyFromBytes(entry[lstMintOffset : lstMintOffset+32]),
+			Calculator:    solana.PublicKeyFromBytes(entry[lstCalculatorOffset : lstCalculatorOffset+32]),
+			InputDisabled: entry[lstInputDisabledOffset] != 0,
+			SolValue:      binary.LittleEndian.Uint64(entry[lstSolValueOffset:]),
+		})
+	}
+	return states, nil
+}
+
+// PoolStateAddress derives the Infinity pool state, which also owns the reserves
+func PoolStateAddress() (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{stateSeed}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive infinity pool state: %w", err)
+	}
+	return address, nil
+}
+
+// LstStateListAddress derives the Infinity LST state list
+func LstStateListAddress() (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{lstStateSeed}, ProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive infinity lst state list: %w", err)
+	}
+	return address, nil
+}
+
+// InfinityPool is one pair of LSTs of the Infinity pool. BaseLst and QuoteLst
+// trade at their SOL values, so the price does not move with size; only the
+// output's reserves bound a swap
+type InfinityPool struct {
+	PoolState    solana.PublicKey
+	LstStateList solana.PublicKey
+	BaseLst      Lst
+	QuoteLst     Lst
+
+	// PricingProgram sets the fees, Disabled and Rebalancing stop swaps, as
+	// of the last decode
+	PricingProgram solana.PublicKey
+	LpTokenMint    solana.PublicKey
+	Disabled       bool
+	Rebalancing    bool
+}
+
+// NewInfinityPool creates the pool trading base and quote
+func NewInfinityPool(base, quote Lst) (*InfinityPool, error) {
+	poolState, err := PoolStateAddress()
+	if err != nil {
+		return nil, err
+	}
+	lstStateList, err := LstStateListAddress()
+	if err != nil {
+		return nil, err
+	}
+	return &InfinityPool{PoolState: poolState, LstStateList: lstStateList, BaseLst: base, QuoteLst: quote}, nil
+}
+
+func (pool *InfinityPool) ProtocolName() pkg.ProtocolName {
+	return Name
+}
+
+func (pool *InfinityPool) GetProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+// GetID names the pool by its pair, as "sanctum_infinity:base:quote", since
+// every pair shares the one pool state
+func (pool *InfinityPool) GetID() string {
+	return fmt.Sprintf("%s:%s:%s", Name, pool.BaseLst.Mint, pool.QuoteLst.Mint)
+}
+
+func (pool *InfinityPool) GetTokens() (string, string) {
+	return pool.BaseLst.Mint.String(), pool.QuoteLst.Mint.String()
+}
+
+// DecodePoolState parses the pool state account
+func (pool *InfinityPool) DecodePoolState(data []byte) error {
+	if len(data) < poolStateSize {
+		return fmt.Errorf("infinity pool state too short: %d bytes", len(data))
+	}
+	pool.Disabled = data[isDisabledOffset] != 0
+	pool.Rebalancing = data[isRebalancingOffset] != 0
+	pool.PricingProgram = solana.PublicKeyFromBytes(data[pricingProgramOffset : pricingProgramOffset+32])
+	pool.LpTokenMint = solana.PublicKeyFromBytes(data[lpTokenMintOffset : lpTokenMintOffset+32])
+	return nil
+}
+
+// decodeLstStates finds both LSTs in the state list, whose indices shift as
+// LSTs are removed
+func (pool *InfinityPool) decodeLstStates(data []byte) error {
+	states, err := ParseLstStates(data)
+	if err != nil {
+		return err
+	}
+	for _, lst := range []*Lst{&pool.BaseLst, &pool.QuoteLst} {
+		found := false
+		for _, state := range states {
+			if state.Mint.Equals(lst.Mint) {
+				lst.Index, lst.InputDisabled, found = state.Index, state.InputDisabled, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("lst %s is no longer in the infinity pool", lst.Mint)
+		}
+	}
+	return nil
+}
+
+// FeeAccountAddress derives the flat fee account of mint
+func FeeAccountAddress(mint solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := sol.FindProgramAddress([][]byte{feeAccountSeed, mint[:]}, FlatFeeProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive fee account: %w", err)
+	}
+	return address, nil
+}
+
+// reservesAddress is the pool state's token account of the LST
+func (pool *InfinityPool) reservesAddress(lst *Lst) (solana.PublicKey, error) {
+	address, _, err := sol.FindAssociatedTokenAddressWithProgram(pool.PoolState, lst.Mint, lst.TokenProgram)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive reserves of %s: %w", lst.Mint, err)
+	}
+	return address, nil
+}
+
+// Quote refreshes the pool state, LST list, calculator sources, fee
+// accounts, reserves and the epoch in one batch and prices the swap
+func (pool *InfinityPool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{pool.PoolState, pool.LstStateList, solana.SysVarClockPubkey}
+	legs := []*Lst{&pool.BaseLst, &pool.QuoteLst}
+	// feeAt, reservesAt and sourceAt index each leg's accounts in the batch
+	var feeAt, reservesAt, sourceAt [2]int
+	for i, lst := range legs {
+		fee, err := FeeAccountAddress(lst.Mint)
+		if err != nil {
+			return math.ZeroInt(), err
+		}
+		reserves, err := pool.reservesAddress(lst)
+		if err != nil {
+			return math.ZeroInt(), err
+		}
+		feeAt[i], reservesAt[i], sourceAt[i] = len(accounts), len(accounts)+1, -1
+		accounts = append(accounts, fee, reserves)
+		if !lst.Source.IsZero() {
+			sourceAt[i] = len(accounts)
+			accounts = append(accounts, lst.Source)
+		}
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+
+	data, ok := sol.AccountData(results, 0)
+	if !ok {
+		return math.ZeroInt(), fmt.Errorf("infinity pool state %s not found", pool.PoolState)
+	}
+	if err := pool.DecodePoolState(data); err != nil {
+		return math.ZeroInt(), err
+	}
+	if data, ok = sol.AccountData(results, 1); !ok {
+		return math.ZeroInt(), fmt.Errorf("infinity lst state list %s not found", pool.LstStateList)
+	}
+	if err := pool.decodeLstStates(data); err != nil {
+		return math.ZeroInt(), err
+	}
+	if data, ok = sol.AccountData(results, 2); !ok {
+		return math.ZeroInt(), fmt.Errorf("clock sysvar not found")
+	}
+	clock, err := sol.ParseClock(data)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+
+	for i, lst := range legs {
+		data, ok := sol.AccountData(results, feeAt[i])
+		if !ok || len(data) < feeAccountSize {
+			return math.ZeroInt(), fmt.Errorf("fee account of %s not found", lst.Mint)
+		}
+		lst.InputFeeBps = int16(binary.LittleEndian.Uint16(data[0:]))
+		lst.OutputFeeBps = int16(binary.LittleEndian.Uint16(data[2:]))
+		lst.Reserves, _ = sol.TokenAccountAmount(results, reservesAt[i])
+		if sourceAt[i] < 0 {
+			continue
+		}
+		if data, ok = sol.AccountData(results, sourceAt[i]); !ok {
+			return math.ZeroInt(), fmt.Errorf("calculator source %s of %s not found", lst.Source, lst.Mint)
+		}
+		if err := lst.decodeSource(data, clock.Epoch); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode calculator source of %s: %w", lst.Mint, err)
+		}
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// legs returns the input and output LST for inputMint
+func (pool *InfinityPool) legs(inputMint string) (*Lst, *Lst, error) {
+	switch inputMint {
+	case pool.BaseLst.Mint.String():
+		return &pool.BaseLst, &pool.QuoteLst, nil
+	case pool.QuoteLst.Mint.String():
+		return &pool.QuoteLst, &pool.BaseLst, nil
+	}
+	return nil, nil, fmt.Errorf("mint %s is not traded by infinity pool %s", inputMint, pool.GetID())
+}
+
+// feeBps is the flat fee of a swap from in to out: in's input fee plus
+// out's output fee, either of which may be a negative incentive
+func feeBps(in, out *Lst) int64 {
+	return int64(in.InputFeeBps) + int64(out.OutputFeeBps)
+}
+
+// ComputeAmountOut prices inputAmount from the cached state as the program
+// does: the input's SOL value, less the flat fee, bought back in the output
+// LST, all rounded down
+func (pool *InfinityPool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	if pool.Disabled || pool.Rebalancing {
+		return math.ZeroInt(), fmt.Errorf("infinity pool is disabled or rebalancing")
+	}
+	if !pool.PricingProgram.Equals(FlatFeeProgramID) {
+		return math.ZeroInt(), fmt.Errorf("infinity pricing program %s is not supported", pool.PricingProgram)
+	}
+	in, out, err := pool.legs(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if in.InputDisabled {
+		return math.ZeroInt(), fmt.Errorf("infinity pool does not take %s as input", in.Mint)
+	}
+	fee := feeBps(in, out)
+	if fee >= bpsDenominator {
+		return math.ZeroInt(), fmt.Errorf("fee of %d bps takes the whole swap", fee)
+	}
+
+	inSol, err := in.SolValue(inputAmount)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	outSol := inSol.Mul(math.NewInt(bpsDenominator - fee)).Quo(math.NewInt(bpsDenominator))
+	amountOut, err := out.LstAmount(outSol)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	if !amountOut.IsPositive() {
+		return math.ZeroInt(), fmt.Errorf("swap of %s is too small", inputAmount)
+	}
+	if amountOut.GT(math.NewIntFromUint64(out.Reserves)) {
+		return math.ZeroInt(), fmt.Errorf("swap of %s exceeds the %d %s reserves", inputAmount, out.Reserves, out.Mint)
+	}
+	return amountOut, nil
+}
+
+// SwapFee returns the flat fee charged on inputAmount, in input units
+func (pool *InfinityPool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	in, out, err := pool.legs(inputMint)
+	if err != nil {
+		return math.ZeroInt()
+	}
+	fee := feeBps(in, out)
+	if fee <= 0 {
+		return math.ZeroInt()
+	}
+	return inputAmount.Mul(math.NewInt(fee)).Quo(math.NewInt(bpsDenominator))
+}
+
+// MaxInputForImpact returns the input that empties the output's reserves:
+// the price does not move with size, so only they bound a swap
+func (pool *InfinityPool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	in, out, err := pool.legs(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	fee := feeBps(in, out)
+	if fee >= bpsDenominator {
+		return math.ZeroInt(), fmt.Errorf("fee of %d bps takes the whole swap", fee)
+	}
+	outSol, err := out.SolValue(math.NewIntFromUint64(out.Reserves))
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	inSol := outSol.Mul(math.NewInt(bpsDenominator)).Quo(math.NewInt(bpsDenominator - fee))
+	return in.LstAmount(inSol)
+}
+
+// BuildSwapInstructions builds a swap_exact_in followed by the calculator
+// accounts of both LSTs and the flat fee pricing accounts
+func (pool *InfinityPool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	in, out, err := pool.legs(inputMint)
+	if err != nil {
+		return nil, err
+	}
+	source, destination := userBaseAccount, userQuoteAccount
+	if in == &pool.QuoteLst {
+		source, destination = userQuoteAccount, userBaseAccount
+	}
+	inReserves, err := pool.reservesAddress(in)
+	if err != nil {
+		return nil, err
+	}
+	outReserves, err := pool.reservesAddress(out)
+	if err != nil {
+		return nil, err
+	}
+	protocolFee, _, err := sol.FindProgramAddress([][]byte{protocolFeeSeed}, ProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive protocol fee authority: %w", err)
+	}
+	feeAccumulator, _, err := sol.FindAssociatedTokenAddressWithProgram(protocolFee, out.Mint, out.TokenProgram)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive protocol fee accumulator: %w", err)
+	}
+	inCalculator, err := in.calculatorAccounts()
+	if err != nil {
+		return nil, err
+	}
+	outCalculator, err := out.calculatorAccounts()
+	if err != nil {
+		return nil, err
+	}
+	inFee, err := FeeAccountAddress(in.Mint)
+	if err != nil {
+		return nil, err
+	}
+	outFee, err := FeeAccountAddress(out.Mint)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(user).SIGNER(),
+		solana.Meta(in.Mint),
+		solana.Meta(out.Mint),
+		solana.Meta(source).WRITE(),
+		solana.Meta(destination).WRITE(),
+		solana.Meta(feeAccumulator).WRITE(),
+		solana.Meta(in.TokenProgram),
+		solana.Meta(out.TokenProgram),
+		solana.Meta(pool.PoolState).WRITE(),
+		solana.Meta(pool.LstStateList).WRITE(),
+		solana.Meta(inReserves).WRITE(),
+		solana.Meta(outReserves).WRITE(),
+	}
+	accounts = append(accounts, inCalculator...)
+	accounts = append(accounts, outCalculator...)
+	accounts = append(accounts,
+		solana.Meta(FlatFeeProgramID),
+		solana.Meta(in.Mint),
+		solana.Meta(out.Mint),
+		solana.Meta(inFee),
+		solana.Meta(outFee),
+	)
+
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, SwapExactInTag...)
+	data = append(data, byte(len(inCalculator)), byte(len(outCalculator)))
+	data = binary.LittleEndian.AppendUint32(data, in.Index)
+	data = binary.LittleEndian.AppendUint32(data, out.Index)
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	return []solana.Instruction{solana.NewInstruction(ProgramID, accounts, data)}, nil
+}
+
+// DecodeMinOut reads min_amount_out back from the swap instruction
+func (pool *InfinityPool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, ProgramID, SwapExactInTag, swapMinOutOffset)
+}
+
+// SwapAmountFields locates amount and min_amount_out in the swap instruction
+func (pool *InfinityPool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: ProgramID, Prefix: SwapExactInTag, Offset: swapAmountOffset},
+		pkg.AmountField{ProgramID: ProgramID, Prefix: SwapExactInTag, Offset: swapMinOutOffset}
+}
@@ -0,0 +1,88 @@
+// Package stabble quotes and builds swaps on Stabble, whose stable swap and
+// weighted swap programs keep every pool's tokens in one shared vault. A pool
+// holds two or more tokens; each pair of them is routed as its own pool.
+// Stable pools price on an amplified invariant, as Curve does, and weighted
+// pools on a weighted product, as Balancer does
+package stabble
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/anchor"
+)
+
+const (
+	// StableName is the protocol name of Stabble stable swap pools
+	StableName pkg.ProtocolName = "stabble_stable"
+	// WeightedName is the protocol name of Stabble weighted swap pools
+	WeightedName pkg.ProtocolName = "stabble_weighted"
+)
+
+var (
+	// StableProgramID is the Stabble stable swap program
+	StableProgramID = solana.MustPublicKeyFromBase58("swapNyd8XiQwJ6ianp9snpu4brUqFxadzvHebnAXjJZ")
+	// WeightedProgramID is the Stabble weighted swap program
+	WeightedProgramID = solana.MustPublicKeyFromBase58("swapFpHZwjELNnjvThjajtiVmkz3yPQEHjLtka2fwHW")
+	// VaultProgramID is the Stabble vault program holding the pools' tokens
+	VaultProgramID = solana.MustPublicKeyFromBase58("vo1tWgqZMjG61Z2T9qUaMYKqZ75CYzMuaZ2LZP1n7HV")
+
+	// PoolDiscriminator prefixes pool accounts of both programs
+	PoolDiscriminator = anchor.GetDiscriminator("account", "Pool")
+	// VaultDiscriminator prefixes vault accounts
+	VaultDiscriminator = anchor.GetDiscriminator("account", "Vault")
+	// SwapDiscriminator prefixes the swap of both programs, followed by an
+	// optional amount_in and minimum_amount_out
+	SwapDiscriminator = anchor.GetDiscriminator("global", "swap")
+)
+
+// Pool account layout shared by both programs, after the discriminator
+const (
+	VaultOffset = 40
+
+	isActiveOffset = 105
+)
+
+// Stable pool layout
+const (
+	ampInitialFactorOffset = 106
+	ampTargetFactorOffset  = 108
+	rampStartTsOffset      = 110
+	rampStopTsOffset       = 118
+	stableSwapFeeOffset    = 126
+	stableTokensOffset     = 134
+	stableTokenSize        = 50
+)
+
+// Weighted pool layout
+const (
+	weightedSwapFeeOffset = 114
+	weightedTokensOffset  = 122
+	weightedTokenSize     = 58
+)
+
+// Vault account layout
+const (
+	vaultWithdrawAuthorityOffset = 40
+	vaultBeneficiaryOffset       = 74
+	vaultMinSize                 = 106
+)
+
+const (
+	// FeeDenominator is the denominator of swap_fee and of token weights
+	FeeDenominator = 1_000_000_000
+	// MaxTokens bounds the tokens of a pool, as the programs do
+	MaxTokens = 8
+
+	// maxIterations bounds the Newton iterations for D and y, as the program does
+	maxIterations = 256
+	// maxImpactSearchSteps bounds both the doubling and the bisection phase
+	maxImpactSearchSteps = 128
+)
+
+// Swap instruction data: the discriminator, amount_in as a present option
+// and minimum_amount_out
+const (
+	swapDataSize       = 25
+	swapAmountInOffset = 9
+	swapMinOutOffset   = 17
+)
@@ -0,0 +1,60 @@
+package stabble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+)
+
+// DecodeStableSwap parses a swap of the stable swap program. It does not name
+// the mints, so the input and output mints are left zero
+func DecodeStableSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	return decodeSwap(StableName, accounts, data)
+}
+
+// DecodeWeightedSwap parses a swap of the weighted swap program, leaving the
+// mints zero as DecodeStableSwap does
+func DecodeWeightedSwap(accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	return decodeSwap(WeightedName, accounts, data)
+}
+
+// decodeSwap parses the swap both programs share
+func decodeSwap(name pkg.ProtocolName, accounts []*solana.AccountMeta, data []byte) (*pkg.SwapParams, error) {
+	if !bytes.HasPrefix(data, SwapDiscriminator) {
+		return nil, pkg.ErrNotSwap
+	}
+	if len(data) < swapMinOutOffset {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	// amount_in is an option; a swap of the whole balance leaves it out
+	minOutOffset, amountIn := swapMinOutOffset, math.ZeroInt()
+	switch data[8] {
+	case 0:
+		minOutOffset = swapAmountInOffset
+	case 1:
+		amountIn = math.NewIntFromUint64(binary.LittleEndian.Uint64(data[swapAmountInOffset:]))
+	default:
+		return nil, fmt.Errorf("invalid amount_in option tag %d", data[8])
+	}
+	if len(data) < minOutOffset+8 {
+		return nil, fmt.Errorf("swap instruction data too short: %d bytes", len(data))
+	}
+	if err := pkg.CheckSwapAccounts(accounts, 12); err != nil {
+		return nil, err
+	}
+
+	params := &pkg.SwapParams{
+		Protocol:          name,
+		User:              accounts[0].PublicKey,
+		UserInputAccount:  accounts[1].PublicKey,
+		UserOutputAccount: accounts[2].PublicKey,
+		Pool:              accounts[6].PublicKey,
+		AmountIn:          amountIn,
+		MinAmountOut:      math.NewIntFromUint64(binary.LittleEndian.Uint64(data[minOutOffset:])),
+	}
+	return params, nil
+}
@@ -0,0 +1,226 @@
+package stabble
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// ampFactor is the amplification coefficient at now: it moves linearly from
+// the initial to the target value while a ramp is under way
+func (pool *StabblePool) ampFactor(now int64) uint64 {
+	if now >= pool.RampStopTs || pool.RampStopTs <= pool.RampStartTs {
+		return pool.AmpTargetFactor
+	}
+	timeRange := big.NewInt(pool.RampStopTs - pool.RampStartTs)
+	timeDelta := big.NewInt(max(now-pool.RampStartTs, 0))
+	if pool.AmpTargetFactor >= pool.AmpInitialFactor {
+		delta := new(big.Int).Mul(new(big.Int).SetUint64(pool.AmpTargetFactor-pool.AmpInitialFactor), timeDelta)
+		return pool.AmpInitialFactor + delta.Quo(delta, timeRange).Uint64()
+	}
+	delta := new(big.Int).Mul(new(big.Int).SetUint64(pool.AmpInitialFactor-pool.AmpTargetFactor), timeDelta)
+	return pool.AmpInitialFactor - delta.Quo(delta, timeRange).Uint64()
+}
+
+// withinOne reports whether a and b differ by at most one, the precision the
+// program's Newton iterations stop at
+func withinOne(a, b *big.Int) bool {
+	diff := new(big.Int).Sub(a, b)
+	return diff.CmpAbs(big.NewInt(1)) <= 0
+}
+
+// computeD solves the stable invariant for D given the scaled balances of
+// every token, by Newton's method with the program's integer steps
+func computeD(amp uint64, balances []*big.Int) (*big.Int, error) {
+	coins := big.NewInt(int64(len(balances)))
+	sum := new(big.Int)
+	for _, balance := range balances {
+		if balance.Sign() == 0 {
+			return nil, fmt.Errorf("stable swap balance is empty")
+		}
+		sum.Add(sum, balance)
+	}
+	ann := new(big.Int).Mul(new(big.Int).SetUint64(amp), coins)
+	leverage := new(big.Int).Mul(sum, ann)
+	annMinusOne := new(big.Int).Sub(ann, big.NewInt(1))
+	coinsPlusOne := new(big.Int).Add(coins, big.NewInt(1))
+
+	d := new(big.Int).Set(sum)
+	for i := 0; i < maxIterations; i++ {
+		// d_prod = D^(n+1) / (n^n * prod)
+		dProd := new(big.Int).Set(d)
+		for _, balance := range balances {
+			dProd.Mul(dProd, d).Quo(dProd, new(big.Int).Mul(balance, coins))
+		}
+		prev := d
+
+		// d = (ann * sum + d_prod * n) * d / ((ann - 1) * d + (n + 1) * d_prod)
+		numerator := new(big.Int).Mul(dProd, coins)
+		numerator.Add(numerator, leverage).Mul(numerator, prev)
+		denominator := new(big.Int).Mul(prev, annMinusOne)
+		denominator.Add(denominator, new(big.Int).Mul(dProd, coinsPlusOne))
+		if denominator.Sign() == 0 {
+			return nil, fmt.Errorf("stable swap invariant diverged")
+		}
+		d = numerator.Quo(numerator, denominator)
+		if withinOne(d, prev) {
+			break
+		}
+	}
+	return d, nil
+}
+
+// computeY solves the invariant D for the balance of token out once the
+// balance of token in is x and every other balance stays put
+func computeY(amp uint64, balances []*big.Int, in, out int, x, d *big.Int) (*big.Int, error) {
+	coins := big.NewInt(int64(len(balances)))
+	ann := new(big.Int).Mul(new(big.Int).SetUint64(amp), coins)
+	// c = D^(n+1) / (n^n * prod' * ann), b = sum' + D / ann
+	c := new(big.Int).Set(d)
+	sum := new(big.Int)
+	for i, balance := range balances {
+		switch i {
+		case out:
+			continue
+		case in:
+			balance = x
+		}
+		if balance.Sign() == 0 {
+			return nil, fmt.Errorf("stable swap balance is empty")
+		}
+		sum.Add(sum, balance)
+		c.Mul(c, d).Quo(c, new(big.Int).Mul(balance, coins))
+	}
+	c.Mul(c, d).Quo(c, new(big.Int).Mul(ann, coins))
+	b := new(big.Int).Quo(d, ann)
+	b.Add(b, sum)
+
+	// y^2 + b*y = c + D*y, by y = (y^2 + c) / (2y + b - D)
+	y := new(big.Int).Set(d)
+	for i := 0; i < maxIterations; i++ {
+		prev := y
+		numerator := new(big.Int).Mul(prev, prev)
+		numerator.Add(numerator, c)
+		denominator := new(big.Int).Lsh(prev, 1)
+		denominator.Add(denominator, b).Sub(denominator, d)
+		if denominator.Sign() <= 0 {
+			return nil, fmt.Errorf("stable swap invariant diverged")
+		}
+		y = numerator.Quo(numerator, denominator)
+		if withinOne(y, prev) {
+			break
+		}
+	}
+	return y, nil
+}
+
+// stableOut is the scaled output of swapping the scaled amountIn of token in
+// for token out, before the swap fee. It is one below the exact difference,
+// as the program rounds against the trader
+func stableOut(amp uint64, d *big.Int, balances []*big.Int, in, out int, amountIn *big.Int) (*big.Int, error) {
+	y, err := computeY(amp, balances, in, out, new(big.Int).Add(balances[in], amountIn), d)
+	if err != nil {
+		return nil, err
+	}
+	dy := new(big.Int).Sub(balances[out], y)
+	dy.Sub(dy, big.NewInt(1))
+	if dy.Sign() <= 0 {
+		return nil, fmt.Errorf("swap of %s is too small", amountIn)
+	}
+	return dy, nil
+}
+
+// stableSpotPrice is the marginal scaled output per scaled input as
+// num / den: the ratio of the invariant's partial derivatives,
+// y * (ann n^n P x + D^(n+1)) / (x * (ann n^n P y + D^(n+1))) with P the
+// product of the balances
+func stableSpotPrice(amp uint64, d *big.Int, balances []*big.Int, in, out int) (*big.Int, *big.Int) {
+	coins := big.NewInt(int64(len(balances)))
+	annNN := new(big.Int).Exp(coins, coins, nil)
+	annNN.Mul(annNN, coins).Mul(annNN, new(big.Int).SetUint64(amp))
+	for _, balance := range balances {
+		annNN.Mul(annNN, balance)
+	}
+	dN1 := new(big.Int).Exp(d, new(big.Int).Add(coins, big.NewInt(1)), nil)
+
+	num := new(big.Int).Mul(annNN, balances[in])
+	num.Add(num, dN1).Mul(num, balances[out])
+	den := new(big.Int).Mul(annNN, balances[out])
+	den.Add(den, dN1).Mul(den, balances[in])
+	return num, den
+}
+
+// weightedOut is the scaled output of swapping the scaled amountIn against
+// weighted balances, before the swap fee:
+// balanceOut * (1 - (balanceIn / (balanceIn + amountIn))^(weightIn / weightOut)).
+// The power is taken in floating point, through log1p and expm1 so small
+// trades keep their precision, and the output is rounded down one further
+// unit to stay below the program's fixed point result
+func weightedOut(balanceIn, balanceOut *big.Int, weightIn, weightOut uint64, amountIn *big.Int) (*big.Int, error) {
+	if balanceIn.Sign() <= 0 || balanceOut.Sign() <= 0 {
+		return nil, fmt.Errorf("weighted swap balance is empty")
+	}
+	if weightIn == 0 || weightOut == 0 {
+		return nil, fmt.Errorf("weighted swap token has no weight")
+	}
+	ratio, _ := new(big.Float).Quo(
+		new(big.Float).SetInt(amountIn),
+		new(big.Float).SetInt(new(big.Int).Add(balanceIn, amountIn)),
+	).Float64()
+	exponent := float64(weightIn) / float64(weightOut)
+	share := -math.Expm1(exponent * math.Log1p(-ratio))
+
+	out, _ := new(big.Float).SetPrec(256).Mul(
+		new(big.Float).SetPrec(256).SetInt(balanceOut),
+		new(big.Float).SetFloat64(share),
+	).Int(nil)
+	out.Sub(out, big.NewInt(1))
+	if out.Sign() <= 0 {
+		return nil, fmt.Errorf("swap of %s is too small", amountIn)
+	}
+	if out.Cmp(balanceOut) >= 0 {
+		return nil, fmt.Errorf("swap of %s drains the pool", amountIn)
+	}
+	return out, nil
+}
+
+// maxInputForImpact searches for the largest input whose average price,
+// given by curveOut, is within bps of the marginal price num / den. The
+// average price falls as the input grows, so the bound is found by doubling
+// from the constant product bound on reserveIn and then bisection
+func maxInputForImpact(reserveIn, num, den *big.Int, bps int64, curveOut func(*big.Int) (*big.Int, error)) *big.Int {
+	keep := big.NewInt(10000 - bps)
+	// within reports whether out / amount >= num / den * (1 - bps)
+	within := func(amount *big.Int) bool {
+		out, err := curveOut(amount)
+		if err != nil {
+			return false
+		}
+		lhs := new(big.Int).Mul(out, den)
+		lhs.Mul(lhs, big.NewInt(10000))
+		rhs := new(big.Int).Mul(amount, num)
+		rhs.Mul(rhs, keep)
+		return lhs.Cmp(rhs) >= 0
+	}
+
+	hi := new(big.Int).Mul(reserveIn, big.NewInt(bps))
+	hi.Quo(hi, keep)
+	if hi.Sign() == 0 {
+		hi.SetInt64(1)
+	}
+	lo := new(big.Int)
+	for i := 0; i < maxImpactSearchSteps && within(hi); i++ {
+		lo.Set(hi)
+		hi.Lsh(hi, 1)
+	}
+	for i := 0; i < maxImpactSearchSteps && new(big.Int).Sub(hi, lo).Cmp(big.NewInt(1)) > 0; i++ {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if within(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
@@ -0,0 +1,568 @@
+package stabble
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"cosmossdk.io/math"
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+)
+
+// PoolToken is a token of a pool. Balances are scaled to common decimals,
+// multiplied by ScalingFactor when ScalingUp is set and divided by it
+// otherwise, before the invariant is applied
+type PoolToken struct {
+	Mint          solana.PublicKey
+	Decimals      uint8
+	ScalingUp     bool
+	ScalingFactor uint64
+	Balance       uint64
+	// Weight is the token's share of a weighted pool, over FeeDenominator
+	Weight uint64
+}
+
+// scale converts a raw amount of the token to common decimals
+func (token PoolToken) scale(amount *big.Int) *big.Int {
+	factor := new(big.Int).SetUint64(token.ScalingFactor)
+	if token.ScalingUp {
+		return factor.Mul(amount, factor)
+	}
+	return factor.Quo(amount, factor)
+}
+
+// unscale converts a scaled amount back to the token's decimals, rounding down
+func (token PoolToken) unscale(amount *big.Int) *big.Int {
+	factor := new(big.Int).SetUint64(token.ScalingFactor)
+	if token.ScalingUp {
+		return factor.Quo(amount, factor)
+	}
+	return factor.Mul(amount, factor)
+}
+
+// Vault is the shared vault of a program's pools
+type Vault struct {
+	// WithdrawAuthority releases the pools' tokens to traders
+	WithdrawAuthority solana.PublicKey
+	// Beneficiary owns the accounts collecting the vault's share of fees
+	Beneficiary solana.PublicKey
+}
+
+// ParseVault parses a vault account
+func ParseVault(data []byte) (*Vault, error) {
+	if len(data) < vaultMinSize {
+		return nil, fmt.Errorf("stabble vault account too short: %d bytes", len(data))
+	}
+	if !bytes.HasPrefix(data, VaultDiscriminator) {
+		return nil, fmt.Errorf("not a stabble vault account")
+	}
+	return &Vault{
+		WithdrawAuthority: solana.PublicKeyFromBytes(data[vaultWithdrawAuthorityOffset : vaultWithdrawAuthorityOffset+32]),
+		Beneficiary:       solana.PublicKeyFromBytes(data[vaultBeneficiaryOffset : vaultBeneficiaryOffset+32]),
+	}, nil
+}
+
+// VaultAuthority derives the authority owning the vault's token accounts
+func VaultAuthority(vault solana.PublicKey) (solana.PublicKey, error) {
+	authority, _, err := solana.FindProgramAddress([][]byte{[]byte("vault_authority"), vault.Bytes()}, VaultProgramID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive vault authority: %w", err)
+	}
+	return authority, nil
+}
+
+// StabblePool is one pair of tokens of a Stabble stable or weighted pool:
+// the tokens at BaseIndex and QuoteIndex are its base and quote mints
+type StabblePool struct {
+	// Program is StableProgramID or WeightedProgramID
+	Program solana.PublicKey
+	PoolId  solana.PublicKey
+	Vault   solana.PublicKey
+	// Inactive pools reject swaps
+	Active bool
+	Tokens []PoolToken
+
+	BaseIndex  int
+	QuoteIndex int
+
+	// The amplification coefficient of stable pools moves from
+	// AmpInitialFactor at RampStartTs to AmpTargetFactor at RampStopTs
+	AmpInitialFactor uint64
+	AmpTargetFactor  uint64
+	RampStartTs      int64
+	RampStopTs       int64
+	// FeeRate is the swap fee taken off the input, over FeeDenominator
+	FeeRate uint64
+
+	// VaultState is the pool's vault, loaded on discovery or on the first swap
+	VaultState *Vault
+	// now is the cluster time of the last quote, which the ramp is read at
+	now int64
+}
+
+// NewPool creates the pool at poolId of program, pairing its first two tokens
+func NewPool(program, poolId solana.PublicKey) *StabblePool {
+	return &StabblePool{Program: program, PoolId: poolId, QuoteIndex: 1}
+}
+
+// Weighted reports whether the pool belongs to the weighted swap program
+func (pool *StabblePool) Weighted() bool {
+	return pool.Program.Equals(WeightedProgramID)
+}
+
+func (pool *StabblePool) ProtocolName() pkg.ProtocolName {
+	if pool.Weighted() {
+		return WeightedName
+	}
+	return StableName
+}
+
+func (pool *StabblePool) GetProgramID() solana.PublicKey {
+	return pool.Program
+}
+
+// GetID is the pool address for two token pools. The pairs of larger pools
+// append the indices of their base and quote tokens, as in ParsePoolID
+func (pool *StabblePool) GetID() string {
+	if len(pool.Tokens) == 2 && pool.BaseIndex == 0 && pool.QuoteIndex == 1 {
+		return pool.PoolId.String()
+	}
+	return fmt.Sprintf("%s:%d:%d", pool.PoolId, pool.BaseIndex, pool.QuoteIndex)
+}
+
+// ParsePoolID splits an ID from GetID into the pool address and the indices
+// of the base and quote tokens
+func ParsePoolID(poolId string) (solana.PublicKey, int, int, error) {
+	parts := strings.Split(poolId, ":")
+	address, err := solana.PublicKeyFromBase58(parts[0])
+	if err != nil {
+		return solana.PublicKey{}, 0, 0, fmt.Errorf("invalid pool ID %s: %w", poolId, err)
+	}
+	switch len(parts) {
+	case 1:
+		return address, 0, 1, nil
+	case 3:
+		base, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return solana.PublicKey{}, 0, 0, fmt.Errorf("invalid pool ID %s: %w", poolId, err)
+		}
+		quote, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return solana.PublicKey{}, 0, 0, fmt.Errorf("invalid pool ID %s: %w", poolId, err)
+		}
+		return address, base, quote, nil
+	}
+	return solana.PublicKey{}, 0, 0, fmt.Errorf("invalid pool ID %s", poolId)
+}
+
+// GetTokens returns the mints at BaseIndex and QuoteIndex
+func (pool *StabblePool) GetTokens() (string, string) {
+	if !pool.pairLoaded() {
+		return "", ""
+	}
+	return pool.Tokens[pool.BaseIndex].Mint.String(), pool.Tokens[pool.QuoteIndex].Mint.String()
+}
+
+// pairLoaded reports whether BaseIndex and QuoteIndex name two tokens of the pool
+func (pool *StabblePool) pairLoaded() bool {
+	return pool.BaseIndex != pool.QuoteIndex &&
+		pool.BaseIndex >= 0 && pool.BaseIndex < len(pool.Tokens) &&
+		pool.QuoteIndex >= 0 && pool.QuoteIndex < len(pool.Tokens)
+}
+
+// Pairs splits the pool into one pool per pair of its tokens
+func (pool *StabblePool) Pairs() []*StabblePool {
+	pairs := make([]*StabblePool, 0, len(pool.Tokens)*(len(pool.Tokens)-1)/2)
+	for i := range pool.Tokens {
+		for j := i + 1; j < len(pool.Tokens); j++ {
+			pair := *pool
+			pair.BaseIndex, pair.QuoteIndex = i, j
+			pairs = append(pairs, &pair)
+		}
+	}
+	return pairs
+}
+
+// Decode parses a pool account of the pool's program
+func (pool *StabblePool) Decode(data []byte) error {
+	if !bytes.HasPrefix(data, PoolDiscriminator) {
+		return fmt.Errorf("not a stabble pool account")
+	}
+	tokensOffset, tokenSize := stableTokensOffset, stableTokenSize
+	if pool.Weighted() {
+		tokensOffset, tokenSize = weightedTokensOffset, weightedTokenSize
+	}
+	if len(data) < tokensOffset+4 {
+		return fmt.Errorf("stabble pool account too short: %d bytes", len(data))
+	}
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+
+	count := int(binary.LittleEndian.Uint32(data[tokensOffset:]))
+	if count < 2 || count > MaxTokens {
+		return fmt.Errorf("stabble pool holds %d tokens", count)
+	}
+	if len(data) < tokensOffset+4+count*tokenSize {
+		return fmt.Errorf("stabble pool account too short for %d tokens: %d bytes", count, len(data))
+	}
+	tokens := make([]PoolToken, count)
+	for i := range tokens {
+		offset := tokensOffset + 4 + i*tokenSize
+		tokens[i] = PoolToken{
+			Mint:          solana.PublicKeyFromBytes(data[offset : offset+32]),
+			Decimals:      data[offset+32],
+			ScalingUp:     data[offset+33] != 0,
+			ScalingFactor: u64(offset + 34),
+			Balance:       u64(offset + 42),
+		}
+		if pool.Weighted() {
+			tokens[i].Weight = u64(offset + 50)
+		}
+		if tokens[i].ScalingFactor == 0 {
+			return fmt.Errorf("token %s has no scaling factor", tokens[i].Mint)
+		}
+	}
+
+	pool.Vault = solana.PublicKeyFromBytes(data[VaultOffset : VaultOffset+32])
+	pool.Active = data[isActiveOffset] != 0
+	pool.Tokens = tokens
+	if pool.Weighted() {
+		pool.FeeRate = u64(weightedSwapFeeOffset)
+	} else {
+		pool.AmpInitialFactor = uint64(binary.LittleEndian.Uint16(data[ampInitialFactorOffset:]))
+		pool.AmpTargetFactor = uint64(binary.LittleEndian.Uint16(data[ampTargetFactorOffset:]))
+		pool.RampStartTs = int64(u64(rampStartTsOffset))
+		pool.RampStopTs = int64(u64(rampStopTsOffset))
+		pool.FeeRate = u64(stableSwapFeeOffset)
+		if pool.AmpTargetFactor == 0 {
+			return fmt.Errorf("invalid amplification coefficient 0")
+		}
+	}
+	if pool.FeeRate >= FeeDenominator {
+		return fmt.Errorf("invalid swap fee %d", pool.FeeRate)
+	}
+	return nil
+}
+
+// UpdateFrom takes the freshly decoded state of a rediscovered pair while
+// keeping the cluster time of the last quote, and the vault when the fresh
+// pair has not loaded it
+func (pool *StabblePool) UpdateFrom(other pkg.Pool) bool {
+	fresh, ok := other.(*StabblePool)
+	if !ok || fresh == pool || fresh.GetID() != pool.GetID() || !fresh.Program.Equals(pool.Program) {
+		return false
+	}
+	vault, now := pool.VaultState, pool.now
+	*pool = *fresh
+	pool.now = now
+	if pool.VaultState == nil {
+		pool.VaultState = vault
+	}
+	return true
+}
+
+// MintDecimals returns the decimals the pool records for mint
+func (pool *StabblePool) MintDecimals(mint string) (uint8, bool) {
+	for _, token := range pool.Tokens {
+		if token.Mint.String() == mint {
+			return token.Decimals, true
+		}
+	}
+	return 0, false
+}
+
+// Quote refreshes the pool, and for stable pools the cluster time its ramp
+// is read at, in one batch and returns the output for inputAmount
+func (pool *StabblePool) Quote(ctx context.Context, solClient *sol.Client, inputMint string, inputAmount math.Int) (math.Int, error) {
+	accounts := []solana.PublicKey{pool.PoolId}
+	if !pool.Weighted() {
+		accounts = append(accounts, solana.SysVarClockPubkey)
+	}
+	results, err := solClient.GetMultipleAccountsWithOpts(ctx, accounts)
+	if err != nil {
+		return math.ZeroInt(), fmt.Errorf("batch request failed: %w", err)
+	}
+	// a pool missing at the queried commitment keeps its last known state
+	if data, ok := sol.AccountData(results, 0); ok {
+		if err := pool.Decode(data); err != nil {
+			return math.ZeroInt(), fmt.Errorf("failed to decode stabble pool %s: %w", pool.PoolId, err)
+		}
+	}
+	if !pool.Weighted() {
+		data, ok := sol.AccountData(results, 1)
+		if !ok {
+			return math.ZeroInt(), fmt.Errorf("clock account not found")
+		}
+		clock, err := sol.ParseClock(data)
+		if err != nil {
+			return math.ZeroInt(), err
+		}
+		pool.now = int64(clock.UnixTimestamp)
+	}
+
+	if !pool.Active {
+		return math.ZeroInt(), fmt.Errorf("stabble pool %s is not active", pool.PoolId)
+	}
+	return pool.ComputeAmountOut(inputMint, inputAmount)
+}
+
+// direction returns the indices of the input and output tokens for inputMint
+func (pool *StabblePool) direction(inputMint string) (int, int, error) {
+	if !pool.pairLoaded() {
+		return 0, 0, fmt.Errorf("pool state not loaded")
+	}
+	switch inputMint {
+	case pool.Tokens[pool.BaseIndex].Mint.String():
+		return pool.BaseIndex, pool.QuoteIndex, nil
+	case pool.Tokens[pool.QuoteIndex].Mint.String():
+		return pool.QuoteIndex, pool.BaseIndex, nil
+	}
+	return 0, 0, fmt.Errorf("mint %s is not traded by stabble pool %s", inputMint, pool.GetID())
+}
+
+// curve is the invariant of the pool's scaled balances, ready to price
+// trades from token in to token out
+type curve struct {
+	pool     *StabblePool
+	in, out  int
+	balances []*big.Int
+	amp      uint64
+	d        *big.Int
+}
+
+// curve loads the scaled balances and, for stable pools, the amplification
+// at the last quote's time and the invariant
+func (pool *StabblePool) curve(inputMint string) (*curve, error) {
+	in, out, err := pool.direction(inputMint)
+	if err != nil {
+		return nil, err
+	}
+	c := &curve{pool: pool, in: in, out: out, balances: make([]*big.Int, len(pool.Tokens))}
+	for i, token := range pool.Tokens {
+		c.balances[i] = token.scale(new(big.Int).SetUint64(token.Balance))
+	}
+	if c.balances[in].Sign() <= 0 || c.balances[out].Sign() <= 0 {
+		return nil, fmt.Errorf("stabble pool %s has an empty balance", pool.GetID())
+	}
+	if !pool.Weighted() {
+		c.amp = pool.ampFactor(pool.now)
+		if c.d, err = computeD(c.amp, c.balances); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// amountOut is the output of amountIn in token units, before the swap fee
+func (c *curve) amountOut(amountIn *big.Int) (*big.Int, error) {
+	tokenIn, tokenOut := c.pool.Tokens[c.in], c.pool.Tokens[c.out]
+	scaledIn := tokenIn.scale(amountIn)
+	var scaledOut *big.Int
+	var err error
+	if c.pool.Weighted() {
+		scaledOut, err = weightedOut(c.balances[c.in], c.balances[c.out], tokenIn.Weight, tokenOut.Weight, scaledIn)
+	} else {
+		scaledOut, err = stableOut(c.amp, c.d, c.balances, c.in, c.out, scaledIn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	amountOut := tokenOut.unscale(scaledOut)
+	if amountOut.Sign() <= 0 {
+		return nil, fmt.Errorf("swap of %s is too small", amountIn)
+	}
+	return amountOut, nil
+}
+
+// spotPrice is the marginal output per unit of input in token units, as
+// num / den
+func (c *curve) spotPrice() (*big.Int, *big.Int) {
+	tokenIn, tokenOut := c.pool.Tokens[c.in], c.pool.Tokens[c.out]
+	var num, den *big.Int
+	if c.pool.Weighted() {
+		num = new(big.Int).Mul(c.balances[c.out], new(big.Int).SetUint64(tokenIn.Weight))
+		den = new(big.Int).Mul(c.balances[c.in], new(big.Int).SetUint64(tokenOut.Weight))
+	} else {
+		num, den = stableSpotPrice(c.amp, c.d, c.balances, c.in, c.out)
+	}
+	// a scaled unit of input is worth its factor in token units when scaled
+	// up, and the inverse when scaled down; likewise for the output
+	for _, side := range []struct {
+		token PoolToken
+		input bool
+	}{{tokenIn, true}, {tokenOut, false}} {
+		factor := new(big.Int).SetUint64(side.token.ScalingFactor)
+		if side.token.ScalingUp == side.input {
+			num.Mul(num, factor)
+		} else {
+			den.Mul(den, factor)
+		}
+	}
+	return num, den
+}
+
+// swapFee is the fee on an input of amount, rounded up
+func (pool *StabblePool) swapFee(amount *big.Int) *big.Int {
+	fee := new(big.Int).Mul(amount, new(big.Int).SetUint64(pool.FeeRate))
+	fee.Add(fee, big.NewInt(FeeDenominator-1))
+	return fee.Quo(fee, big.NewInt(FeeDenominator))
+}
+
+// ComputeAmountOut takes the swap fee off the input and prices the rest on
+// the pool's invariant at the cached balances, as the programs do
+func (pool *StabblePool) ComputeAmountOut(inputMint string, inputAmount math.Int) (math.Int, error) {
+	if !inputAmount.IsPositive() || !inputAmount.IsUint64() {
+		return math.ZeroInt(), fmt.Errorf("amount %s out of range", inputAmount)
+	}
+	c, err := pool.curve(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	amountIn := new(big.Int).Sub(inputAmount.BigInt(), pool.swapFee(inputAmount.BigInt()))
+	if amountIn.Sign() <= 0 {
+		return math.ZeroInt(), fmt.Errorf("swap of %s does not cover the fee", inputAmount)
+	}
+	amountOut, err := c.amountOut(amountIn)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	return math.NewIntFromBigInt(amountOut), nil
+}
+
+// SwapFee returns the fee taken off inputAmount
+func (pool *StabblePool) SwapFee(inputMint string, inputAmount math.Int) math.Int {
+	return math.NewIntFromBigInt(pool.swapFee(inputAmount.BigInt()))
+}
+
+// Reserves returns the base and quote balances the pool records
+func (pool *StabblePool) Reserves() (math.Int, math.Int) {
+	if !pool.pairLoaded() {
+		return math.Int{}, math.Int{}
+	}
+	return math.NewIntFromUint64(pool.Tokens[pool.BaseIndex].Balance), math.NewIntFromUint64(pool.Tokens[pool.QuoteIndex].Balance)
+}
+
+// RawSpotPrice is the marginal price of the invariant at the cached balances
+func (pool *StabblePool) RawSpotPrice(inputMint string) (math.LegacyDec, error) {
+	c, err := pool.curve(inputMint)
+	if err != nil {
+		return math.LegacyDec{}, err
+	}
+	num, den := c.spotPrice()
+	return pkg.RatioPrice(num, den, false)
+}
+
+// MaxInputForImpact searches for the largest input whose average price,
+// before the swap fee, is within maxImpactBps of the marginal price
+func (pool *StabblePool) MaxInputForImpact(inputMint string, maxImpactBps int) (math.Int, error) {
+	if err := pkg.CheckImpactBps(maxImpactBps); err != nil {
+		return math.ZeroInt(), err
+	}
+	c, err := pool.curve(inputMint)
+	if err != nil {
+		return math.ZeroInt(), err
+	}
+	num, den := c.spotPrice()
+	reserveIn := new(big.Int).SetUint64(pool.Tokens[c.in].Balance)
+	return math.NewIntFromBigInt(maxInputForImpact(reserveIn, num, den, int64(maxImpactBps), c.amountOut)), nil
+}
+
+// loadVault fetches the pool's vault unless discovery loaded it
+func (pool *StabblePool) loadVault(ctx context.Context, solClient *sol.Client) (*Vault, error) {
+	if pool.VaultState != nil {
+		return pool.VaultState, nil
+	}
+	account, err := solClient.GetAccountInfoWithOpts(ctx, pool.Vault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault account %s: %w", pool.Vault, err)
+	}
+	vault, err := ParseVault(account.Value.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+	pool.VaultState = vault
+	return vault, nil
+}
+
+// BuildSwapInstructions builds a swap of the exact inputAmount, paying the
+// vault's share of the fee into the beneficiary's account of the output mint
+func (pool *StabblePool) BuildSwapInstructions(
+	ctx context.Context,
+	solClient *sol.Client,
+	user solana.PublicKey,
+	inputMint string,
+	inputAmount math.Int,
+	minOut math.Int,
+	userBaseAccount solana.PublicKey,
+	userQuoteAccount solana.PublicKey,
+) ([]solana.Instruction, error) {
+	if !inputAmount.IsUint64() || !minOut.IsUint64() {
+		return nil, fmt.Errorf("amount exceeds uint64")
+	}
+	in, out, err := pool.direction(inputMint)
+	if err != nil {
+		return nil, err
+	}
+	vault, err := pool.loadVault(ctx, solClient)
+	if err != nil {
+		return nil, err
+	}
+	vaultAuthority, err := VaultAuthority(pool.Vault)
+	if err != nil {
+		return nil, err
+	}
+	mintIn, mintOut := pool.Tokens[in].Mint, pool.Tokens[out].Mint
+	vaultIn, _, err := solana.FindAssociatedTokenAddress(vaultAuthority, mintIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault token account: %w", err)
+	}
+	vaultOut, _, err := solana.FindAssociatedTokenAddress(vaultAuthority, mintOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault token account: %w", err)
+	}
+	beneficiaryOut, _, err := solana.FindAssociatedTokenAddress(vault.Beneficiary, mintOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive beneficiary token account: %w", err)
+	}
+	userIn, userOut := userBaseAccount, userQuoteAccount
+	if in == pool.QuoteIndex {
+		userIn, userOut = userQuoteAccount, userBaseAccount
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(user).SIGNER(),
+		solana.Meta(userIn).WRITE(),
+		solana.Meta(userOut).WRITE(),
+		solana.Meta(vaultIn).WRITE(),
+		solana.Meta(vaultOut).WRITE(),
+		solana.Meta(beneficiaryOut).WRITE(),
+		solana.Meta(pool.PoolId).WRITE(),
+		solana.Meta(vault.WithdrawAuthority),
+		solana.Meta(pool.Vault),
+		solana.Meta(vaultAuthority),
+		solana.Meta(VaultProgramID),
+		solana.Meta(solana.TokenProgramID),
+	}
+	data := make([]byte, 0, swapDataSize)
+	data = append(data, SwapDiscriminator...)
+	data = append(data, 1)
+	data = binary.LittleEndian.AppendUint64(data, inputAmount.Uint64())
+	data = binary.LittleEndian.AppendUint64(data, minOut.Uint64())
+	return []solana.Instruction{solana.NewInstruction(pool.Program, accounts, data)}, nil
+}
+
+// DecodeMinOut reads minimum_amount_out back from the swap instruction
+func (pool *StabblePool) DecodeMinOut(inputMint string, instructions []solana.Instruction) (math.Int, error) {
+	return pkg.DecodeInstructionU64(instructions, pool.Program, SwapDiscriminator, swapMinOutOffset)
+}
+
+// SwapAmountFields locates amount_in and minimum_amount_out in the swap instruction
+func (pool *StabblePool) SwapAmountFields(inputMint string) (pkg.AmountField, pkg.AmountField) {
+	return pkg.AmountField{ProgramID: pool.Program, Prefix: SwapDiscriminator, Offset: swapAmountInOffset},
+		pkg.AmountField{ProgramID: pool.Program, Prefix: SwapDiscriminator, Offset: swapMinOutOffset}
+}
@@ -0,0 +1,160 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/aldrin"
+)
+
+// AldrinProtocol discovers Aldrin AMM v2 pools
+type AldrinProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewAldrin creates a new AldrinProtocol instance
+func NewAldrin(solClient *sol.Client) *AldrinProtocol {
+	return &AldrinProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *AldrinProtocol) ProtocolName() pkg.ProtocolName {
+	return aldrin.Name
+}
+
+// FetchPoolsByPair retrieves the constant product Aldrin v2 pools trading
+// baseMint and quoteMint, as base and quote in either order
+func (p *AldrinProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the stable curve pools left out
+func (p *AldrinProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getAldrinPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeAldrinPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Aldrin v2 pools with a single batched account lookup
+func (p *AldrinProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := protocol.FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeAldrinPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Aldrin v2 pools fetching length bytes from offset of each
+func (p *AldrinProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getAldrinPoolAccountsByTokenPair(ctx, baseMint, quoteMint, protocol.SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return protocol.PoolSlices(accounts), nil
+}
+
+func (p *AldrinProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(aldrin.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by aldrin", poolId)
+	}
+
+	pool := &aldrin.AldrinPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getAldrinPoolAccountsByTokenPair lists the Aldrin v2 pools of the pair in
+// both base and quote orders
+func (p *AldrinProtocol) getAldrinPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	var result rpc.GetProgramAccountsResult
+	for _, mints := range [][2]solana.PublicKey{{baseKey, quoteKey}, {quoteKey, baseKey}} {
+		accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, aldrin.ProgramID, &rpc.GetProgramAccountsOpts{
+			DataSlice: dataSlice,
+			Filters: []rpc.RPCFilter{
+				{
+					DataSize: aldrin.PoolSize,
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: 0,
+						Bytes:  aldrin.PoolDiscriminator,
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: aldrin.BaseMintOffset,
+						Bytes:  mints[0].Bytes(),
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: aldrin.QuoteMintOffset,
+						Bytes:  mints[1].Bytes(),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pools: %w", err)
+		}
+		result = append(result, accounts...)
+	}
+	return result, nil
+}
+
+// decodeAldrinPools decodes Aldrin v2 pool accounts, skipping ones that fail
+// to parse, belong to another program or trade on a curve that is not quoted
+func decodeAldrinPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(aldrin.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &aldrin.AldrinPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Quotable() != nil {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
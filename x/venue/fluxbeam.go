@@ -0,0 +1,155 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/fluxbeam"
+)
+
+// FluxBeamProtocol discovers FluxBeam token swaps
+type FluxBeamProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewFluxBeam creates a new FluxBeamProtocol instance
+func NewFluxBeam(solClient *sol.Client) *FluxBeamProtocol {
+	return &FluxBeamProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *FluxBeamProtocol) ProtocolName() pkg.ProtocolName {
+	return fluxbeam.Name
+}
+
+// FetchPoolsByPair retrieves the constant product FluxBeam swaps trading
+// baseMint and quoteMint, as token A and B in either order
+func (p *FluxBeamProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the swaps on other curves left out
+func (p *FluxBeamProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getFluxBeamSwapAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeFluxBeamPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves FluxBeam swaps with a single batched account lookup
+func (p *FluxBeamProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := protocol.FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeFluxBeamPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's FluxBeam swaps fetching length bytes from offset of each
+func (p *FluxBeamProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getFluxBeamSwapAccountsByTokenPair(ctx, baseMint, quoteMint, protocol.SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return protocol.PoolSlices(accounts), nil
+}
+
+func (p *FluxBeamProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(fluxbeam.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by fluxbeam", poolId)
+	}
+
+	pool := &fluxbeam.FluxBeamPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getFluxBeamSwapAccountsByTokenPair lists the FluxBeam swaps of the pair.
+// A swap's mints are not ordered, so both orders are listed
+func (p *FluxBeamProtocol) getFluxBeamSwapAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	var result rpc.GetProgramAccountsResult
+	for _, mints := range [][2]solana.PublicKey{{baseKey, quoteKey}, {quoteKey, baseKey}} {
+		accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, fluxbeam.ProgramID, &rpc.GetProgramAccountsOpts{
+			DataSlice: dataSlice,
+			Filters: []rpc.RPCFilter{
+				{
+					DataSize: fluxbeam.SwapSize,
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: fluxbeam.TokenAMintOffset,
+						Bytes:  mints[0].Bytes(),
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: fluxbeam.TokenBMintOffset,
+						Bytes:  mints[1].Bytes(),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pools: %w", err)
+		}
+		result = append(result, accounts...)
+	}
+	return result, nil
+}
+
+// decodeFluxBeamPools decodes FluxBeam swap accounts, skipping ones that
+// fail to parse, belong to another program or trade on a curve that is not
+// quoted
+func decodeFluxBeamPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(fluxbeam.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &fluxbeam.FluxBeamPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Quotable() != nil {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
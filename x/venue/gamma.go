@@ -0,0 +1,157 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/gamma"
+)
+
+// GammaProtocol discovers GooseFX GAMMA pools
+type GammaProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewGamma creates a new GammaProtocol instance
+func NewGamma(solClient *sol.Client) *GammaProtocol {
+	return &GammaProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *GammaProtocol) ProtocolName() pkg.ProtocolName {
+	return gamma.Name
+}
+
+// FetchPoolsByPair retrieves the GAMMA pools trading baseMint and
+// quoteMint, as token 0 and 1 in either order
+func (p *GammaProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the pools with swaps disabled left out
+func (p *GammaProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getGammaPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeGammaPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves GAMMA pools with a single batched account lookup
+func (p *GammaProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := protocol.FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeGammaPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's GAMMA pools fetching length bytes from offset of each
+func (p *GammaProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getGammaPoolAccountsByTokenPair(ctx, baseMint, quoteMint, protocol.SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return protocol.PoolSlices(accounts), nil
+}
+
+func (p *GammaProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(gamma.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by gamma", poolId)
+	}
+
+	pool := &gamma.GammaPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getGammaPoolAccountsByTokenPair lists the GAMMA pools of the pair in both
+// mint orders
+func (p *GammaProtocol) getGammaPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	var result rpc.GetProgramAccountsResult
+	for _, mints := range [][2]solana.PublicKey{{baseKey, quoteKey}, {quoteKey, baseKey}} {
+		accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, gamma.ProgramID, &rpc.GetProgramAccountsOpts{
+			DataSlice: dataSlice,
+			Filters: []rpc.RPCFilter{
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: 0,
+						Bytes:  gamma.PoolDiscriminator,
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: gamma.Token0MintOffset,
+						Bytes:  mints[0].Bytes(),
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: gamma.Token1MintOffset,
+						Bytes:  mints[1].Bytes(),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pools: %w", err)
+		}
+		result = append(result, accounts...)
+	}
+	return result, nil
+}
+
+// decodeGammaPools decodes GAMMA pool accounts, skipping ones that fail to
+// parse, belong to another program or have swaps disabled
+func decodeGammaPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(gamma.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &gamma.GammaPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.SwapDisabled() {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -0,0 +1,156 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/moonshot"
+)
+
+// MoonshotProtocol discovers Moonshot bonding curves, routed as pools between
+// a launched token and SOL
+type MoonshotProtocol struct {
+	SolClient *sol.Client
+	// Fees, when set, is given to every curve found, replacing the configured
+	// fee rate with tiers by the SOL amount of a trade
+	Fees pkg.FeeSchedule
+}
+
+// NewMoonshot creates a new MoonshotProtocol instance
+func NewMoonshot(solClient *sol.Client) *MoonshotProtocol {
+	return &MoonshotProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *MoonshotProtocol) ProtocolName() pkg.ProtocolName {
+	return moonshot.Name
+}
+
+// FetchPoolsByPair retrieves the Moonshot curve of the pair's token when the
+// other mint is SOL. The config account is loaded by Quote
+func (p *MoonshotProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the migrated or unsupported curves left
+// out
+func (p *MoonshotProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getMoonshotCurveAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := p.decodeMoonshotCurves(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Moonshot curves with a single batched account lookup
+func (p *MoonshotProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := protocol.FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := p.decodeMoonshotCurves(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Moonshot curve fetching length bytes from offset of it
+func (p *MoonshotProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getMoonshotCurveAccountsByTokenPair(ctx, baseMint, quoteMint, protocol.SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return protocol.PoolSlices(accounts), nil
+}
+
+func (p *MoonshotProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid curve ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get curve account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(moonshot.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by moonshot", poolId)
+	}
+
+	pool := &moonshot.MoonshotPool{PoolId: poolPubkey, Fees: p.Fees}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse curve data for curve %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getMoonshotCurveAccountsByTokenPair lists the Moonshot curves of the pair's
+// token, none when neither mint is SOL
+func (p *MoonshotProtocol) getMoonshotCurveAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	token := baseMint
+	switch sol.WSOL.String() {
+	case baseMint:
+		token = quoteMint
+	case quoteMint:
+	default:
+		return nil, nil
+	}
+	tokenKey, err := solana.PublicKeyFromBase58(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token mint address: %w", err)
+	}
+
+	result, err := p.SolClient.GetProgramAccountsWithOpts(ctx, moonshot.ProgramID, &rpc.GetProgramAccountsOpts{
+		DataSlice: dataSlice,
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  moonshot.CurveDiscriminator,
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: moonshot.MintOffset,
+					Bytes:  tokenKey.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pools: %w", err)
+	}
+	return result, nil
+}
+
+// decodeMoonshotCurves decodes Moonshot curves, skipping ones that fail to
+// parse, belong to another program, migrated or are not quoted
+func (p *MoonshotProtocol) decodeMoonshotCurves(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(moonshot.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &moonshot.MoonshotPool{PoolId: v.Pubkey, Fees: p.Fees}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Tradable() != nil {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
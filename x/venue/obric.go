@@ -0,0 +1,157 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/obric"
+)
+
+// ObricProtocol discovers Obric v2 trading pairs
+type ObricProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewObric creates a new ObricProtocol instance
+func NewObric(solClient *sol.Client) *ObricProtocol {
+	return &ObricProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *ObricProtocol) ProtocolName() pkg.ProtocolName {
+	return obric.Name
+}
+
+// FetchPoolsByPair retrieves the Obric v2 trading pairs of baseMint and
+// quoteMint, as mint X and Y in either order
+func (p *ObricProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the uninitialized pairs left out
+func (p *ObricProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getObricPoolAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeObricPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Obric v2 pairs with a single batched account lookup
+func (p *ObricProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := protocol.FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeObricPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Obric v2 pairs fetching length bytes from offset of each
+func (p *ObricProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getObricPoolAccountsByTokenPair(ctx, baseMint, quoteMint, protocol.SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return protocol.PoolSlices(accounts), nil
+}
+
+func (p *ObricProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(obric.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by obric", poolId)
+	}
+
+	pool := &obric.ObricPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getObricPoolAccountsByTokenPair lists the Obric v2 pairs of the pair in both
+// mint orders
+func (p *ObricProtocol) getObricPoolAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	var result rpc.GetProgramAccountsResult
+	for _, mints := range [][2]solana.PublicKey{{baseKey, quoteKey}, {quoteKey, baseKey}} {
+		accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, obric.ProgramID, &rpc.GetProgramAccountsOpts{
+			DataSlice: dataSlice,
+			Filters: []rpc.RPCFilter{
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: 0,
+						Bytes:  obric.TradingPairDiscriminator,
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: obric.MintXOffset,
+						Bytes:  mints[0].Bytes(),
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: obric.MintYOffset,
+						Bytes:  mints[1].Bytes(),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pools: %w", err)
+		}
+		result = append(result, accounts...)
+	}
+	return result, nil
+}
+
+// decodeObricPools decodes Obric v2 trading pair accounts, skipping ones that
+// fail to parse, belong to another program or are not initialized
+func decodeObricPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(obric.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &obric.ObricPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if !pool.Initialized {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -0,0 +1,249 @@
+// Package venue holds experimental venues: protocols whose layouts or
+// quoting have not settled enough for the stable registry of pkg/protocol.
+// Their names, types and behaviour may change in any release. Importing the
+// package registers their swap decoders and instruction layouts.
+//
+// A venue graduates by moving to pkg/protocol; it then stays constructible
+// here for one more minor release, listed in Deprecations
+package venue
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/decoder"
+	"github.com/solana-zh/solroute/pkg/layout"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/aldrin"
+	"github.com/solana-zh/solroute/x/pool/fluxbeam"
+	"github.com/solana-zh/solroute/x/pool/gamma"
+	"github.com/solana-zh/solroute/x/pool/moonshot"
+	"github.com/solana-zh/solroute/x/pool/obric"
+	"github.com/solana-zh/solroute/x/pool/saber"
+	"github.com/solana-zh/solroute/x/pool/sanctum"
+	"github.com/solana-zh/solroute/x/pool/stabble"
+)
+
+// Names lists every experimental venue
+var Names = []pkg.ProtocolName{
+	moonshot.Name,
+	saber.Name,
+	aldrin.Name,
+	fluxbeam.Name,
+	gamma.Name,
+	sanctum.Name,
+	obric.Name,
+	stabble.StableName,
+	stabble.WeightedName,
+}
+
+// Deprecations lists the venues that graduated to pkg/protocol, or were
+// dropped, and are still created by New
+var Deprecations = map[pkg.ProtocolName]pkg.Deprecation{}
+
+// All creates every experimental venue, in the order of Names. Routers take
+// them alongside protocol.All to opt in
+func All(solClient *sol.Client) []pkg.Protocol {
+	protocols := make([]pkg.Protocol, 0, len(Names))
+	for _, name := range Names {
+		proto, err := New(name, solClient)
+		if err != nil {
+			// every name in Names has a case in New
+			panic(err)
+		}
+		protocols = append(protocols, proto)
+	}
+	return protocols
+}
+
+// New creates the experimental venue registered under name
+func New(name pkg.ProtocolName, solClient *sol.Client) (pkg.Protocol, error) {
+	switch name {
+	case moonshot.Name:
+		return NewMoonshot(solClient), nil
+	case saber.Name:
+		return NewSaber(solClient), nil
+	case aldrin.Name:
+		return NewAldrin(solClient), nil
+	case fluxbeam.Name:
+		return NewFluxBeam(solClient), nil
+	case gamma.Name:
+		return NewGamma(solClient), nil
+	case sanctum.Name:
+		return NewSanctum(solClient), nil
+	case obric.Name:
+		return NewObric(solClient), nil
+	case stabble.StableName:
+		return NewStabbleStable(solClient), nil
+	case stabble.WeightedName:
+		return NewStabbleWeighted(solClient), nil
+	}
+	return nil, fmt.Errorf("unknown venue %s", name)
+}
+
+func init() {
+	decoder.Register(moonshot.ProgramID, moonshot.DecodeSwap)
+	decoder.Register(saber.ProgramID, saber.DecodeSwap)
+	decoder.Register(aldrin.ProgramID, aldrin.DecodeSwap)
+	decoder.Register(fluxbeam.ProgramID, fluxbeam.DecodeSwap)
+	decoder.Register(gamma.ProgramID, gamma.DecodeSwap)
+	decoder.Register(sanctum.ProgramID, sanctum.DecodeSwap)
+	for _, program := range sanctumStakePoolPrograms {
+		decoder.Register(program, stakepool.DecodeSwap)
+	}
+	decoder.Register(obric.ProgramID, obric.DecodeSwap)
+	decoder.Register(stabble.StableProgramID, stabble.DecodeStableSwap)
+	decoder.Register(stabble.WeightedProgramID, stabble.DecodeWeightedSwap)
+
+	moonshotTrade := []layout.Role{
+		{Name: "sender", Writable: true, Signer: true},
+		{Name: "sender_token_account", Writable: true},
+		{Name: "curve_account", Writable: true},
+		{Name: "curve_token_account", Writable: true},
+		{Name: "dex_fee", Writable: true},
+		{Name: "helio_fee", Writable: true},
+		{Name: "mint"},
+		{Name: "config_account"},
+		{Name: "token_program", Address: solana.TokenProgramID},
+		{Name: "associated_token_program", Address: solana.SPLAssociatedTokenAccountProgramID},
+		{Name: "system_program", Address: solana.SystemProgramID},
+	}
+	layout.Register(layout.Template{Name: "moonshot.buy", ProgramID: moonshot.ProgramID, Prefix: moonshot.BuyDiscriminator, Accounts: moonshotTrade})
+	layout.Register(layout.Template{Name: "moonshot.sell", ProgramID: moonshot.ProgramID, Prefix: moonshot.SellDiscriminator, Accounts: moonshotTrade})
+
+	layout.Register(layout.Template{
+		Name:      "saber.swap",
+		ProgramID: saber.ProgramID,
+		Prefix:    saber.SwapTag,
+		Accounts: []layout.Role{
+			{Name: "swap"},
+			{Name: "swap_authority"},
+			{Name: "user_authority", Signer: true},
+			{Name: "source", Writable: true},
+			{Name: "swap_source", Writable: true},
+			{Name: "swap_destination", Writable: true},
+			{Name: "destination", Writable: true},
+			{Name: "admin_destination", Writable: true},
+			{Name: "token_program", Address: solana.TokenProgramID},
+		},
+	})
+
+	layout.Register(layout.Template{
+		Name:      "aldrin.swap",
+		ProgramID: aldrin.ProgramID,
+		Prefix:    aldrin.SwapDiscriminator,
+		Accounts: []layout.Role{
+			{Name: "pool"},
+			{Name: "pool_signer"},
+			{Name: "pool_mint", Writable: true},
+			{Name: "base_token_vault", Writable: true},
+			{Name: "quote_token_vault", Writable: true},
+			{Name: "fee_pool_token_account", Writable: true},
+			{Name: "wallet_authority", Signer: true},
+			{Name: "user_base_token_account", Writable: true},
+			{Name: "user_quote_token_account", Writable: true},
+			{Name: "curve"},
+			{Name: "token_program", Address: solana.TokenProgramID},
+		},
+	})
+
+	layout.Register(layout.Template{
+		Name:      "fluxbeam.swap",
+		ProgramID: fluxbeam.ProgramID,
+		Prefix:    fluxbeam.SwapTag,
+		Accounts: []layout.Role{
+			{Name: "swap"},
+			{Name: "swap_authority"},
+			{Name: "user_transfer_authority", Signer: true},
+			{Name: "source", Writable: true},
+			{Name: "swap_source", Writable: true},
+			{Name: "swap_destination", Writable: true},
+			{Name: "destination", Writable: true},
+			{Name: "pool_mint", Writable: true},
+			{Name: "pool_fee_account", Writable: true},
+			{Name: "source_mint"},
+			{Name: "destination_mint"},
+			{Name: "source_token_program"},
+			{Name: "destination_token_program"},
+			{Name: "pool_token_program"},
+		},
+	})
+
+	layout.Register(layout.Template{
+		Name:      "gamma.swap_base_input",
+		ProgramID: gamma.ProgramID,
+		Prefix:    gamma.SwapBaseInputDiscriminator,
+		Accounts: []layout.Role{
+			{Name: "payer", Writable: true, Signer: true},
+			{Name: "authority"},
+			{Name: "amm_config"},
+			{Name: "pool_state", Writable: true},
+			{Name: "input_token_account", Writable: true},
+			{Name: "output_token_account", Writable: true},
+			{Name: "input_vault", Writable: true},
+			{Name: "output_vault", Writable: true},
+			{Name: "input_token_program"},
+			{Name: "output_token_program"},
+			{Name: "input_token_mint"},
+			{Name: "output_token_mint"},
+			{Name: "observation_state", Writable: true},
+		},
+	})
+
+	layout.Register(layout.Template{
+		Name:      "sanctum.swap_exact_in",
+		ProgramID: sanctum.ProgramID,
+		Prefix:    sanctum.SwapExactInTag,
+		Accounts: []layout.Role{
+			{Name: "signer", Signer: true},
+			{Name: "src_lst_mint"},
+			{Name: "dst_lst_mint"},
+			{Name: "src_lst_acc", Writable: true},
+			{Name: "dst_lst_acc", Writable: true},
+			{Name: "protocol_fee_accumulator", Writable: true},
+			{Name: "src_lst_token_program"},
+			{Name: "dst_lst_token_program"},
+			{Name: "pool_state", Writable: true},
+			{Name: "lst_state_list", Writable: true},
+			{Name: "src_pool_reserves", Writable: true},
+			{Name: "dst_pool_reserves", Writable: true},
+		},
+		Remaining: &layout.Role{Name: "calculator_or_pricing_account"},
+	})
+
+	obricSwap := []layout.Role{
+		{Name: "trading_pair", Writable: true},
+		{Name: "mint_x"},
+		{Name: "mint_y"},
+		{Name: "reserve_x", Writable: true},
+		{Name: "reserve_y", Writable: true},
+		{Name: "user_token_account_x", Writable: true},
+		{Name: "user_token_account_y", Writable: true},
+		{Name: "protocol_fee", Writable: true},
+		{Name: "x_price_feed"},
+		{Name: "y_price_feed"},
+		{Name: "user", Signer: true},
+		{Name: "token_program", Address: solana.TokenProgramID},
+	}
+	layout.Register(layout.Template{Name: "obric.swap_x_to_y", ProgramID: obric.ProgramID, Prefix: obric.SwapXToYDiscriminator, Accounts: obricSwap})
+	layout.Register(layout.Template{Name: "obric.swap_y_to_x", ProgramID: obric.ProgramID, Prefix: obric.SwapYToXDiscriminator, Accounts: obricSwap})
+
+	stabbleSwap := []layout.Role{
+		{Name: "user", Signer: true},
+		{Name: "user_token_in", Writable: true},
+		{Name: "user_token_out", Writable: true},
+		{Name: "vault_token_in", Writable: true},
+		{Name: "vault_token_out", Writable: true},
+		{Name: "beneficiary_token_out", Writable: true},
+		{Name: "pool", Writable: true},
+		{Name: "withdraw_authority"},
+		{Name: "vault"},
+		{Name: "vault_authority"},
+		{Name: "vault_program", Address: stabble.VaultProgramID},
+		{Name: "token_program", Address: solana.TokenProgramID},
+	}
+	layout.Register(layout.Template{Name: "stabble_stable.swap", ProgramID: stabble.StableProgramID, Prefix: stabble.SwapDiscriminator, Accounts: stabbleSwap})
+	layout.Register(layout.Template{Name: "stabble_weighted.swap", ProgramID: stabble.WeightedProgramID, Prefix: stabble.SwapDiscriminator, Accounts: stabbleSwap})
+}
@@ -0,0 +1,154 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/protocol"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/saber"
+)
+
+// SaberProtocol discovers Saber stable swaps
+type SaberProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewSaber creates a new SaberProtocol instance
+func NewSaber(solClient *sol.Client) *SaberProtocol {
+	return &SaberProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *SaberProtocol) ProtocolName() pkg.ProtocolName {
+	return saber.Name
+}
+
+// FetchPoolsByPair retrieves the Saber stable swaps trading baseMint and
+// quoteMint, as token A and B in either order
+func (p *SaberProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the paused swaps left out
+func (p *SaberProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	accounts, err := p.getSaberSwapAccountsByTokenPair(ctx, baseMint, quoteMint, nil)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch pools with base token %s: %w", baseMint, err)
+	}
+	pools, coverage := decodeSaberPools(accounts)
+	return pools, coverage, nil
+}
+
+// FetchPoolsByIDs retrieves Saber stable swaps with a single batched account lookup
+func (p *SaberProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	accounts, err := protocol.FetchPoolAccounts(ctx, p.SolClient, poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	pools, _ := decodeSaberPools(accounts)
+	return pools, nil
+}
+
+// ScanPoolsByPair scans the pair's Saber stable swaps fetching length bytes from offset of each
+func (p *SaberProtocol) ScanPoolsByPair(ctx context.Context, baseMint, quoteMint string, offset, length uint64) ([]pkg.PoolSlice, error) {
+	accounts, err := p.getSaberSwapAccountsByTokenPair(ctx, baseMint, quoteMint, protocol.SliceAt(offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pools with base token %s: %w", baseMint, err)
+	}
+	return protocol.PoolSlices(accounts), nil
+}
+
+func (p *SaberProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(saber.ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by saber", poolId)
+	}
+
+	pool := &saber.SaberPool{PoolId: poolPubkey}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// getSaberSwapAccountsByTokenPair lists the Saber stable swaps of the pair.
+// Saber does not order a swap's mints, so both orders are listed
+func (p *SaberProtocol) getSaberSwapAccountsByTokenPair(ctx context.Context, baseMint string, quoteMint string, dataSlice *rpc.DataSlice) (rpc.GetProgramAccountsResult, error) {
+	baseKey, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quoteKey, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	var result rpc.GetProgramAccountsResult
+	for _, mints := range [][2]solana.PublicKey{{baseKey, quoteKey}, {quoteKey, baseKey}} {
+		accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, saber.ProgramID, &rpc.GetProgramAccountsOpts{
+			DataSlice: dataSlice,
+			Filters: []rpc.RPCFilter{
+				{
+					DataSize: saber.SwapInfoSize,
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: saber.TokenAMintOffset,
+						Bytes:  mints[0].Bytes(),
+					},
+				},
+				{
+					Memcmp: &rpc.RPCFilterMemcmp{
+						Offset: saber.TokenBMintOffset,
+						Bytes:  mints[1].Bytes(),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pools: %w", err)
+		}
+		result = append(result, accounts...)
+	}
+	return result, nil
+}
+
+// decodeSaberPools decodes Saber swap accounts, skipping ones that fail to
+// parse, belong to another program or are paused
+func decodeSaberPools(accounts rpc.GetProgramAccountsResult) ([]pkg.Pool, pkg.PoolCoverage) {
+	res := make([]pkg.Pool, 0)
+	coverage := pkg.PoolCoverage{Discovered: len(accounts)}
+	for _, v := range accounts {
+		if !v.Account.Owner.Equals(saber.ProgramID) {
+			coverage.Ineligible++
+			continue
+		}
+		pool := &saber.SaberPool{PoolId: v.Pubkey}
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.DecodeFailed++
+			continue
+		}
+		if pool.Paused {
+			coverage.Ineligible++
+			continue
+		}
+		res = append(res, pool)
+	}
+	coverage.Decoded = len(res)
+	return res, coverage
+}
@@ -0,0 +1,244 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/pool/marinade"
+	"github.com/solana-zh/solroute/pkg/pool/stakepool"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/sanctum"
+)
+
+// sanctumStakePoolPrograms are the stake pool programs whose SOL deposits
+// and withdrawals the venue routes, next to the SPL program's served by
+// protocol.NewSPLStakePool
+var sanctumStakePoolPrograms = []solana.PublicKey{sanctum.SanctumSPLProgramID, sanctum.SanctumSPLMultiProgramID}
+
+// SanctumProtocol discovers Sanctum's LST liquidity: the Infinity pool,
+// between any two of its LSTs, and the stake pools on Sanctum's programs,
+// between SOL and their LST. Together with SOL pools they route trades such
+// as mSOL to SOL to USDC
+type SanctumProtocol struct {
+	SolClient *sol.Client
+}
+
+// NewSanctum creates a new SanctumProtocol instance
+func NewSanctum(solClient *sol.Client) *SanctumProtocol {
+	return &SanctumProtocol{
+		SolClient: solClient,
+	}
+}
+
+func (p *SanctumProtocol) ProtocolName() pkg.ProtocolName {
+	return sanctum.Name
+}
+
+// FetchPoolsByPair returns the Infinity pool of the pair when it holds both
+// mints, and for SOL pairs the Sanctum stake pools minting the other side
+func (p *SanctumProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the stake
+// pools that failed to parse and an Infinity pair left out because a mint's
+// calculator is not supported
+func (p *SanctumProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	base, err := solana.PublicKeyFromBase58(baseMint)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	quote, err := solana.PublicKeyFromBase58(quoteMint)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+
+	pools := make([]pkg.Pool, 0)
+	var coverage pkg.PoolCoverage
+	infinity, supported, err := p.fetchInfinityPool(ctx, base, quote)
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, err
+	}
+	if infinity != nil {
+		coverage.Discovered++
+		if supported {
+			pools = append(pools, infinity)
+		} else {
+			coverage.Ineligible++
+		}
+	}
+
+	poolMint := base
+	switch sol.WSOL {
+	case base:
+		poolMint = quote
+	case quote:
+	default:
+		coverage.Decoded = len(pools)
+		return pools, coverage, nil
+	}
+	for _, program := range sanctumStakePoolPrograms {
+		accounts, err := p.findStakePools(ctx, program, poolMint)
+		if err != nil {
+			return nil, pkg.PoolCoverage{}, err
+		}
+		coverage.Discovered += len(accounts)
+		for _, v := range accounts {
+			pool := &stakepool.StakePool{PoolId: v.Pubkey, Program: program}
+			if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+				coverage.DecodeFailed++
+				continue
+			}
+			pools = append(pools, pool)
+		}
+	}
+	coverage.Decoded = len(pools)
+	return pools, coverage, nil
+}
+
+// FetchPoolByID accepts an Infinity pair ID from InfinityPool.GetID or the
+// address of a stake pool on a Sanctum program
+func (p *SanctumProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	if parts := strings.Split(poolId, ":"); len(parts) == 3 && parts[0] == string(sanctum.Name) {
+		base, err := solana.PublicKeyFromBase58(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool ID %s: %w", poolId, err)
+		}
+		quote, err := solana.PublicKeyFromBase58(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool ID %s: %w", poolId, err)
+		}
+		pool, supported, err := p.fetchInfinityPool(ctx, base, quote)
+		if err != nil {
+			return nil, err
+		}
+		if pool == nil || !supported {
+			return nil, fmt.Errorf("infinity does not route pool %s", poolId)
+		}
+		return pool, nil
+	}
+
+	poolPubkey, err := solana.PublicKeyFromBase58(poolId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool ID: %w", err)
+	}
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, poolPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	owner := account.Value.Owner
+	if !owner.Equals(sanctum.SanctumSPLProgramID) && !owner.Equals(sanctum.SanctumSPLMultiProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by a sanctum stake pool program", poolId)
+	}
+	pool := &stakepool.StakePool{PoolId: poolPubkey, Program: owner}
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	return pool, nil
+}
+
+// FetchPoolsByIDs returns the pools of the IDs that resolve, skipping the rest
+func (p *SanctumProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	pools := make([]pkg.Pool, 0, len(poolIDs))
+	for _, poolID := range poolIDs {
+		pool, err := p.FetchPoolByID(ctx, poolID)
+		if err != nil {
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// fetchInfinityPool returns the Infinity pool of the pair, nil when it does
+// not hold both mints, and whether both their calculators can be priced
+func (p *SanctumProtocol) fetchInfinityPool(ctx context.Context, base, quote solana.PublicKey) (*sanctum.InfinityPool, bool, error) {
+	lstStateList, err := sanctum.LstStateListAddress()
+	if err != nil {
+		return nil, false, err
+	}
+	results, err := p.SolClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{lstStateList, base, quote})
+	if err != nil {
+		return nil, false, fmt.Errorf("batch request failed: %w", err)
+	}
+	data, ok := sol.AccountData(results, 0)
+	if !ok {
+		return nil, false, fmt.Errorf("infinity lst state list %s not found", lstStateList)
+	}
+	states, err := sanctum.ParseLstStates(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var lsts [2]sanctum.Lst
+	for i, mint := range []solana.PublicKey{base, quote} {
+		found := false
+		for _, state := range states {
+			if state.Mint.Equals(mint) {
+				lsts[i] = sanctum.Lst{Mint: mint, Calculator: state.Calculator, Index: state.Index}
+				found = true
+				break
+			}
+		}
+		if !found || results.Value[1+i] == nil {
+			return nil, false, nil
+		}
+		lsts[i].TokenProgram = results.Value[1+i].Owner
+	}
+
+	pool, err := sanctum.NewInfinityPool(lsts[0], lsts[1])
+	if err != nil {
+		return nil, false, err
+	}
+	for _, lst := range []*sanctum.Lst{&pool.BaseLst, &pool.QuoteLst} {
+		if !sanctum.Supported(lst.Calculator) {
+			return pool, false, nil
+		}
+		if lst.Calculator.Equals(sanctum.MarinadeCalculatorID) {
+			lst.Source, lst.SourceProgram = marinade.StateAddress, marinade.ProgramID
+			continue
+		}
+		program, ok := sanctum.StakePoolProgram(lst.Calculator)
+		if !ok {
+			continue
+		}
+		accounts, err := p.findStakePools(ctx, program, lst.Mint)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(accounts) == 0 {
+			return pool, false, nil
+		}
+		lst.Source, lst.SourceProgram = accounts[0].Pubkey, program
+	}
+	return pool, true, nil
+}
+
+// findStakePools lists the stake pools of program minting poolMint
+func (p *SanctumProtocol) findStakePools(ctx context.Context, program, poolMint solana.PublicKey) (rpc.GetProgramAccountsResult, error) {
+	accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, program, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  []byte{1},
+				},
+			},
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: stakepool.PoolMintOffset,
+					Bytes:  poolMint.Bytes(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stake pools of %s minting %s: %w", program, poolMint, err)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,189 @@
+package venue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/solana-zh/solroute/pkg"
+	"github.com/solana-zh/solroute/pkg/sol"
+	"github.com/solana-zh/solroute/x/pool/stabble"
+)
+
+// StabbleProtocol discovers the pools of one Stabble program, the stable
+// swap or the weighted swap, as one pool per pair of their tokens
+type StabbleProtocol struct {
+	SolClient *sol.Client
+	Program   solana.PublicKey
+}
+
+// NewStabbleStable creates a StabbleProtocol for stable swap pools
+func NewStabbleStable(solClient *sol.Client) *StabbleProtocol {
+	return &StabbleProtocol{
+		SolClient: solClient,
+		Program:   stabble.StableProgramID,
+	}
+}
+
+// NewStabbleWeighted creates a StabbleProtocol for weighted swap pools
+func NewStabbleWeighted(solClient *sol.Client) *StabbleProtocol {
+	return &StabbleProtocol{
+		SolClient: solClient,
+		Program:   stabble.WeightedProgramID,
+	}
+}
+
+func (p *StabbleProtocol) ProtocolName() pkg.ProtocolName {
+	if p.Program.Equals(stabble.WeightedProgramID) {
+		return stabble.WeightedName
+	}
+	return stabble.StableName
+}
+
+// FetchPoolsByPair retrieves the pairs of baseMint and quoteMint in the
+// program's pools, with the pools' vault loaded
+func (p *StabbleProtocol) FetchPoolsByPair(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, error) {
+	pools, _, err := p.FetchPoolsByPairWithCoverage(ctx, baseMint, quoteMint)
+	return pools, err
+}
+
+// FetchPoolsByPairWithCoverage is FetchPoolsByPair also counting the
+// accounts that failed to parse and the inactive pools left out. A pool's
+// tokens sit at no fixed offset, so every pool of the program is fetched
+// and only those holding both mints are counted as discovered
+func (p *StabbleProtocol) FetchPoolsByPairWithCoverage(ctx context.Context, baseMint string, quoteMint string) ([]pkg.Pool, pkg.PoolCoverage, error) {
+	if _, err := solana.PublicKeyFromBase58(baseMint); err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("invalid base mint address: %w", err)
+	}
+	if _, err := solana.PublicKeyFromBase58(quoteMint); err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("invalid quote mint address: %w", err)
+	}
+	accounts, err := p.SolClient.GetProgramAccountsWithOpts(ctx, p.Program, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{
+				Memcmp: &rpc.RPCFilterMemcmp{
+					Offset: 0,
+					Bytes:  stabble.PoolDiscriminator,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, pkg.PoolCoverage{}, fmt.Errorf("failed to fetch stabble pools: %w", err)
+	}
+
+	pairs := make([]*stabble.StabblePool, 0)
+	var coverage pkg.PoolCoverage
+	for _, v := range accounts {
+		pool := stabble.NewPool(p.Program, v.Pubkey)
+		if err := pool.Decode(v.Account.Data.GetBinary()); err != nil {
+			coverage.Discovered++
+			coverage.DecodeFailed++
+			continue
+		}
+		for _, pair := range pool.Pairs() {
+			base, quote := pair.GetTokens()
+			if (base != baseMint || quote != quoteMint) && (base != quoteMint || quote != baseMint) {
+				continue
+			}
+			coverage.Discovered++
+			if !pair.Active {
+				coverage.Ineligible++
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	if err := p.loadVaults(ctx, pairs); err != nil {
+		return nil, pkg.PoolCoverage{}, err
+	}
+
+	pools := make([]pkg.Pool, 0, len(pairs))
+	for _, pair := range pairs {
+		pools = append(pools, pair)
+	}
+	coverage.Decoded = len(pools)
+	return pools, coverage, nil
+}
+
+// FetchPoolByID accepts a pool address, for the pair of its first two
+// tokens, or a pair ID from StabblePool.GetID
+func (p *StabbleProtocol) FetchPoolByID(ctx context.Context, poolId string) (pkg.Pool, error) {
+	address, base, quote, err := stabble.ParsePoolID(poolId)
+	if err != nil {
+		return nil, err
+	}
+	account, err := p.SolClient.GetAccountInfoWithOpts(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool account %s: %w", poolId, err)
+	}
+	if !account.Value.Owner.Equals(p.Program) {
+		return nil, fmt.Errorf("account %s is not owned by %s", poolId, p.ProtocolName())
+	}
+
+	pool := stabble.NewPool(p.Program, address)
+	if err := pool.Decode(account.Value.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("failed to parse pool data for pool %s: %w", poolId, err)
+	}
+	pool.BaseIndex, pool.QuoteIndex = base, quote
+	if base, quote := pool.GetTokens(); base == "" || quote == "" {
+		return nil, fmt.Errorf("pool %s has no such pair", poolId)
+	}
+	return pool, nil
+}
+
+// FetchPoolsByIDs returns the pools of the IDs that resolve, skipping the rest
+func (p *StabbleProtocol) FetchPoolsByIDs(ctx context.Context, poolIDs []string) ([]pkg.Pool, error) {
+	pools := make([]pkg.Pool, 0, len(poolIDs))
+	for _, poolID := range poolIDs {
+		pool, err := p.FetchPoolByID(ctx, poolID)
+		if err != nil {
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// loadVaults fetches the vaults of pairs, which the program's pools
+// usually share, in one batch
+func (p *StabbleProtocol) loadVaults(ctx context.Context, pairs []*stabble.StabblePool) error {
+	keys := make([]solana.PublicKey, 0, 1)
+	for _, pair := range pairs {
+		found := false
+		for _, key := range keys {
+			if key.Equals(pair.Vault) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			keys = append(keys, pair.Vault)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	results, err := p.SolClient.GetMultipleAccountsWithOpts(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to get stabble vaults: %w", err)
+	}
+	vaults := make(map[solana.PublicKey]*stabble.Vault, len(keys))
+	for i, key := range keys {
+		data, ok := sol.AccountData(results, i)
+		if !ok {
+			continue
+		}
+		vault, err := stabble.ParseVault(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse stabble vault %s: %w", key, err)
+		}
+		vaults[key] = vault
+	}
+	// pairs whose vault is missing load it when they build a swap
+	for _, pair := range pairs {
+		pair.VaultState = vaults[pair.Vault]
+	}
+	return nil
+}